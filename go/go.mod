@@ -1,3 +1,4 @@
 module blackjack_trainer
 
-go 1.20
+// Go 1.21 for log/slog, used by the server request log and -log-level.
+go 1.21
@@ -0,0 +1,166 @@
+// Package challenge is the daily challenge: the same questions for every
+// player on a date. The questions are drawn evenly from the whole chart, as
+// for an exam, from a seed derived from the date alone, so anyone playing on
+// the same date anywhere is asked the same hands in the same order and the
+// scores can be compared. Each day's challenge counts once: the first time
+// it is played is the score that is shared and ranked.
+//
+// Challenges are recorded in the history like any session, with the date in
+// the mode name, e.g. "challenge:2026-10-16".
+package challenge
+
+import (
+	"blackjack_trainer/internal/history"
+	"blackjack_trainer/internal/trainer"
+	"blackjack_trainer/internal/ui"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Mode is the name the challenge is started by, and the prefix of the mode
+// its sessions are recorded under.
+const Mode = "challenge"
+
+// Questions is the number of questions in each day's challenge.
+const Questions = 20
+
+// DateLayout is the layout of challenge dates.
+const DateLayout = "2006-01-02"
+
+// Day returns the date of the challenge played at t, on t's calendar.
+func Day(t time.Time) string {
+	return t.Format(DateLayout)
+}
+
+// ParseDay checks a challenge date, as YYYY-MM-DD.
+func ParseDay(day string) (string, error) {
+	t, err := time.Parse(DateLayout, day)
+	if err != nil {
+		return "", fmt.Errorf("invalid date %q (use YYYY-MM-DD)", day)
+	}
+	return Day(t), nil
+}
+
+// ModeName returns the mode a day's challenge is recorded under.
+func ModeName(day string) string {
+	return Mode + ":" + day
+}
+
+// Seed returns the seed a day's questions are drawn from. It depends on
+// nothing but the date, so every player gets the same questions.
+func Seed(day string) int64 {
+	h := fnv.New64a()
+	io.WriteString(h, "blackjack_trainer daily challenge "+day)
+	return int64(h.Sum64())
+}
+
+// Scenarios returns a day's questions, in order.
+func Scenarios(day string) []trainer.Scenario {
+	draw := trainer.NewExamTrainingSession()
+	draw.Seed(Seed(day))
+	scenarios := make([]trainer.Scenario, Questions)
+	for i := range scenarios {
+		scenarios[i] = draw.GenerateScenario()
+	}
+	return scenarios
+}
+
+// Session asks a day's challenge. Like a quiz, its questions are asked in
+// order and scored like an exam, so answers can't be taken back.
+type Session struct {
+	*trainer.QuizTrainingSession
+	day string
+}
+
+// NewSession creates a session asking a day's challenge.
+func NewSession(day string) *Session {
+	return &Session{QuizTrainingSession: trainer.NewQuizTrainingSession(Mode, Scenarios(day)), day: day}
+}
+
+// Day returns the date of the challenge the session asks.
+func (s *Session) Day() string {
+	return s.day
+}
+
+// GetModeName returns the mode name, which includes the date.
+func (s *Session) GetModeName() string {
+	return ModeName(s.day)
+}
+
+// Description describes the mode for the help screen.
+func (s *Session) Description() string {
+	return fmt.Sprintf("the %d questions every player is asked on %s", Questions, s.day)
+}
+
+// SetupSession introduces the challenge.
+func (s *Session) SetupSession() bool {
+	fmt.Fprintf(ui.Output(), "Daily challenge for %s: the same %d questions for every player today.\n", s.day, Questions)
+	fmt.Fprintln(ui.Output(), "Only your first try counts, and answers can't be taken back.")
+	return true
+}
+
+// Find returns the first recorded try at a day's challenge.
+func Find(h *history.History, day string) (history.Session, bool) {
+	mode := ModeName(day)
+	for _, s := range h.Sessions {
+		if s.Mode == mode {
+			return s, true
+		}
+	}
+	return history.Session{}, false
+}
+
+// Duration returns how long a try took, to the second.
+func Duration(s history.Session) time.Duration {
+	return s.Ended.Sub(s.Started).Round(time.Second)
+}
+
+// ShareCard returns a try's score with a square per question, green for
+// right, red for wrong and white for unanswered, in rows of five, to paste
+// into a chat. It doesn't show the hands, so it gives nothing away to
+// those still to play.
+func ShareCard(day string, s history.Session) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Blackjack daily challenge %s: %d/%d in %s\n", day, s.Correct, Questions, Duration(s))
+	for i := 0; i < Questions; i++ {
+		switch {
+		case i >= len(s.Attempts):
+			b.WriteString("⬜")
+		case s.Attempts[i].Correct:
+			b.WriteString("🟩")
+		default:
+			b.WriteString("🟥")
+		}
+		if i%5 == 4 {
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+// Entry is one player's try on a leaderboard.
+type Entry struct {
+	Name     string
+	Correct  int
+	Total    int
+	Duration time.Duration
+}
+
+// Rank sorts entries from best to worst: most right, then quickest, with
+// ties in name order.
+func Rank(entries []Entry) {
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Correct != b.Correct {
+			return a.Correct > b.Correct
+		}
+		if a.Duration != b.Duration {
+			return a.Duration < b.Duration
+		}
+		return a.Name < b.Name
+	})
+}
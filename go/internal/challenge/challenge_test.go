@@ -0,0 +1,88 @@
+package challenge
+
+import (
+	"blackjack_trainer/internal/history"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test a day's questions depend on the date alone, and differ between days
+func TestScenarios(t *testing.T) {
+	day := Day(time.Date(2026, 10, 16, 23, 30, 0, 0, time.FixedZone("UTC+9", 9*60*60)))
+	if day != "2026-10-16" {
+		t.Fatalf("Day = %q, want the date on the player's calendar", day)
+	}
+	today := Scenarios(day)
+	if len(today) != Questions {
+		t.Fatalf("Got %d questions, want %d", len(today), Questions)
+	}
+	if !reflect.DeepEqual(today, Scenarios(day)) {
+		t.Error("The same day should give the same questions")
+	}
+	if reflect.DeepEqual(today, Scenarios("2026-10-17")) {
+		t.Error("Another day should give other questions")
+	}
+
+	session := NewSession(day)
+	if session.GetModeName() != "challenge:2026-10-16" || session.GetMaxQuestions() != Questions || !session.IsExam() {
+		t.Errorf("Session = %s with %d questions", session.GetModeName(), session.GetMaxQuestions())
+	}
+	for i, want := range today {
+		if got := session.GenerateScenario(); !reflect.DeepEqual(got, want) {
+			t.Fatalf("Question %d = %+v, want %+v", i+1, got, want)
+		}
+	}
+}
+
+// Test only the first try at a day's challenge is found
+func TestFind(t *testing.T) {
+	started := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	h := history.New()
+	h.Add(history.Session{Mode: ModeName("2026-10-15"), Started: started.AddDate(0, 0, -1), Correct: 20, Total: 20})
+	h.Add(history.Session{Mode: ModeName("2026-10-16"), Started: started, Correct: 17, Total: 20})
+	h.Add(history.Session{Mode: ModeName("2026-10-16"), Started: started.Add(time.Hour), Correct: 19, Total: 20})
+
+	if s, ok := Find(h, "2026-10-16"); !ok || s.Correct != 17 {
+		t.Errorf("Find = %+v, %v, want the first try", s, ok)
+	}
+	if _, ok := Find(h, "2026-10-17"); ok {
+		t.Error("No try should be found for an unplayed day")
+	}
+	if _, err := ParseDay("16/10/2026"); err == nil {
+		t.Error("ParseDay should reject other date layouts")
+	}
+}
+
+// Test the share card shows the score and a square per question
+func TestShareCard(t *testing.T) {
+	started := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	s := history.Session{Started: started, Ended: started.Add(3*time.Minute + 12*time.Second), Correct: 5}
+	for i := 0; i < 7; i++ {
+		s.Attempts = append(s.Attempts, history.Attempt{Correct: i != 2 && i != 6})
+	}
+	want := "Blackjack daily challenge 2026-10-16: 5/20 in 3m12s\n" +
+		"🟩🟩🟥🟩🟩\n🟩🟥⬜⬜⬜\n⬜⬜⬜⬜⬜\n⬜⬜⬜⬜⬜\n"
+	if got := ShareCard("2026-10-16", s); got != want {
+		t.Errorf("ShareCard =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// Test the leaderboard ranks by score, then time, then name
+func TestRank(t *testing.T) {
+	entries := []Entry{
+		{Name: "cy", Correct: 18, Duration: 3 * time.Minute},
+		{Name: "ana", Correct: 19, Duration: 5 * time.Minute},
+		{Name: "bo", Correct: 18, Duration: 2 * time.Minute},
+		{Name: "al", Correct: 18, Duration: 3 * time.Minute},
+	}
+	Rank(entries)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name)
+	}
+	if got := strings.Join(names, ","); got != "ana,bo,al,cy" {
+		t.Errorf("Ranked %s, want ana,bo,al,cy", got)
+	}
+}
@@ -0,0 +1,130 @@
+// Package cheatsheet builds a compact printable card of the chart cells a
+// player knows least, with the play and mnemonic for each.
+//
+// Cells are ranked by the mastery model in package stats, using only the
+// hands practiced under the chart's rules, so the card is regenerated from
+// the current history each time. It can be written as plain text or as
+// Markdown.
+package cheatsheet
+
+import (
+	"blackjack_trainer/internal/history"
+	"blackjack_trainer/internal/stats"
+	"blackjack_trainer/internal/strategy"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultCells is how many cells a cheat sheet lists by default.
+const DefaultCells = 20
+
+// Entry is one chart cell on the cheat sheet.
+type Entry struct {
+	Cell     stats.CellKey `json:"-"`
+	Hand     string        `json:"hand"`
+	Play     string        `json:"play"`
+	Correct  int           `json:"correct"`
+	Total    int           `json:"total"`
+	Mastery  float64       `json:"mastery"`
+	Mnemonic string        `json:"mnemonic"`
+}
+
+// Sheet is a cheat sheet of the weakest cells under one rule set.
+type Sheet struct {
+	Rules     string    `json:"rules"`
+	Generated time.Time `json:"generated"`
+	Entries   []Entry   `json:"entries"`
+}
+
+// Build ranks the cells practiced under the chart's rules by mastery as of
+// now and returns the weakest n, weakest first. Cells of equal mastery are
+// ordered by accuracy, then as in the chart.
+func Build(h *history.History, chart *strategy.StrategyChart, now time.Time, n int) Sheet {
+	rules := chart.Rules().Name
+	under := history.New()
+	for _, s := range h.Sessions {
+		attempts := make([]history.Attempt, len(s.Attempts))
+		for i, a := range s.Attempts {
+			if a.Rules == "" {
+				a.Rules = s.Rules
+			}
+			attempts[i] = a
+		}
+		s.Attempts = stats.UnderRules(attempts, rules)
+		under.Sessions = append(under.Sessions, s)
+	}
+	mastery := stats.ComputeMastery(under, now)
+	cells := stats.ByCell(under.Attempts())
+
+	var entries []Entry
+	for _, key := range stats.ChartCells() {
+		data := cells[key]
+		if data == nil {
+			continue
+		}
+		entries = append(entries, Entry{
+			Cell:     key,
+			Hand:     key.Label(),
+			Play:     strategy.ActionToString(chart.GetCorrectAction(key.HandType, key.PlayerTotal, key.DealerCard)),
+			Correct:  data.Correct,
+			Total:    data.Total,
+			Mastery:  mastery.Score(key),
+			Mnemonic: chart.GetExplanation(key.HandType, key.PlayerTotal, key.DealerCard),
+		})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Mastery != entries[j].Mastery {
+			return entries[i].Mastery < entries[j].Mastery
+		}
+		return entries[i].accuracy() < entries[j].accuracy()
+	})
+	if len(entries) > n {
+		entries = entries[:n]
+	}
+	return Sheet{Rules: rules, Generated: now, Entries: entries}
+}
+
+// accuracy returns the share of the cell's attempts answered correctly.
+func (e Entry) accuracy() float64 {
+	return float64(e.Correct) / float64(e.Total)
+}
+
+// title returns the heading of the sheet.
+func (s Sheet) title() string {
+	return fmt.Sprintf("Blackjack Cheat Sheet: your %d weakest cells (%s rules)", len(s.Entries), s.Rules)
+}
+
+// WriteText writes the sheet as plain text, sized to print on one page.
+func (s Sheet) WriteText(w io.Writer) {
+	fmt.Fprintln(w, s.title())
+	fmt.Fprintf(w, "Generated %s\n", s.Generated.Format("2006-01-02"))
+	if len(s.Entries) == 0 {
+		fmt.Fprintln(w, "\nNo hands practiced under these rules yet.")
+		return
+	}
+	fmt.Fprintf(w, "\n%-3s %-18s %-7s %-6s %s\n", "#", "Hand", "Play", "Right", "Mastery")
+	for i, e := range s.Entries {
+		fmt.Fprintf(w, "%-3d %-18s %-7s %-6s %3.0f%%\n", i+1, e.Hand, e.Play,
+			fmt.Sprintf("%d/%d", e.Correct, e.Total), e.Mastery*100)
+		fmt.Fprintf(w, "    %s\n", e.Mnemonic)
+	}
+}
+
+// WriteMarkdown writes the sheet as a Markdown table.
+func (s Sheet) WriteMarkdown(w io.Writer) {
+	fmt.Fprintf(w, "# %s\n\n", s.title())
+	fmt.Fprintf(w, "Generated %s\n\n", s.Generated.Format("2006-01-02"))
+	if len(s.Entries) == 0 {
+		fmt.Fprintln(w, "No hands practiced under these rules yet.")
+		return
+	}
+	fmt.Fprintln(w, "| # | Hand | Play | Right | Mastery | Remember |")
+	fmt.Fprintln(w, "|---|------|------|-------|---------|----------|")
+	for i, e := range s.Entries {
+		fmt.Fprintf(w, "| %d | %s | %s | %d/%d | %.0f%% | %s |\n", i+1, e.Hand, e.Play,
+			e.Correct, e.Total, e.Mastery*100, strings.ReplaceAll(e.Mnemonic, "|", `\|`))
+	}
+}
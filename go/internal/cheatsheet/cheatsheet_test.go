@@ -0,0 +1,85 @@
+package cheatsheet
+
+import (
+	"blackjack_trainer/internal/history"
+	"blackjack_trainer/internal/strategy"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// practiced returns a history with a well-known cell, a missed cell, a cell
+// missed under other rules, and a cell answered right once
+func practiced(now time.Time) *history.History {
+	h := history.New()
+	h.Add(history.Session{Mode: "random", Rules: "Standard", Ended: now, Correct: 4, Total: 6,
+		Attempts: []history.Attempt{
+			{Cards: []int{10, 2}, DealerCard: 4, HandType: "hard", Correct: true},
+			{Cards: []int{10, 2}, DealerCard: 4, HandType: "hard", Correct: true},
+			{Cards: []int{10, 2}, DealerCard: 4, HandType: "hard", Correct: true},
+			{Cards: []int{11, 7}, DealerCard: 9, HandType: "soft", Correct: false},
+			{Cards: []int{10, 6}, DealerCard: 10, HandType: "hard", Correct: true},
+			{Cards: []int{10, 5}, DealerCard: 10, HandType: "hard", Correct: false, Rules: "Vegas Strip"},
+		},
+	})
+	return h
+}
+
+// Test cells are ranked weakest first, limited to n, and taken only from the
+// chart's rules
+func TestBuild(t *testing.T) {
+	now := time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)
+	sheet := Build(practiced(now), strategy.New(), now, DefaultCells)
+
+	var hands []string
+	for _, e := range sheet.Entries {
+		hands = append(hands, e.Hand)
+	}
+	want := []string{"Soft 18 vs 9", "Hard 16 vs 10", "Hard 12 vs 4"}
+	if strings.Join(hands, ",") != strings.Join(want, ",") {
+		t.Fatalf("Entries = %v, want %v", hands, want)
+	}
+	if e := sheet.Entries[0]; e.Play != "HIT" || e.Correct != 0 || e.Total != 1 || e.Mnemonic == "" {
+		t.Errorf("Weakest entry = %+v, want HIT 0/1 with a mnemonic", e)
+	}
+	if sheet.Rules != "Standard" {
+		t.Errorf("Rules = %q, want Standard", sheet.Rules)
+	}
+
+	if got := Build(practiced(now), strategy.New(), now, 2); len(got.Entries) != 2 {
+		t.Errorf("Build with n=2 gave %d entries", len(got.Entries))
+	}
+	if got := Build(history.New(), strategy.New(), now, DefaultCells); len(got.Entries) != 0 {
+		t.Errorf("Build of an empty history gave %d entries", len(got.Entries))
+	}
+}
+
+// Test the text and Markdown sheets list each entry with its play and mnemonic
+func TestWrite(t *testing.T) {
+	now := time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)
+	sheet := Build(practiced(now), strategy.New(), now, 1)
+	mnemonic := sheet.Entries[0].Mnemonic
+
+	var text bytes.Buffer
+	sheet.WriteText(&text)
+	for _, want := range []string{"your 1 weakest cells (Standard rules)", "Generated 2024-03-06", "Soft 18 vs 9", "HIT", "0/1", mnemonic} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("Text sheet missing %q:\n%s", want, text.String())
+		}
+	}
+
+	var md bytes.Buffer
+	sheet.WriteMarkdown(&md)
+	for _, want := range []string{"# Blackjack Cheat Sheet", "| # | Hand | Play |", "| 1 | Soft 18 vs 9 | HIT | 0/1 |"} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("Markdown sheet missing %q:\n%s", want, md.String())
+		}
+	}
+
+	var empty bytes.Buffer
+	Build(history.New(), strategy.New(), now, 1).WriteText(&empty)
+	if !strings.Contains(empty.String(), "No hands practiced") {
+		t.Errorf("Empty sheet = %q", empty.String())
+	}
+}
@@ -0,0 +1,188 @@
+package classroom
+
+import (
+	"blackjack_trainer/internal/history"
+	"blackjack_trainer/internal/stats"
+	"blackjack_trainer/internal/strategy"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// WeakCellAccuracy is the class accuracy below which a cell is listed as one
+// the class misses.
+const WeakCellAccuracy = 75.0
+
+// ClassCell is the class's record on one chart cell.
+type ClassCell struct {
+	Key stats.CellKey
+	stats.CategoryData
+	// Students is the number of students asked the cell, and MissedBy the
+	// number who answered it wrong at least once.
+	Students int
+	MissedBy int
+	// CorrectAction is the chart's answer for the cell.
+	CorrectAction string
+}
+
+// ClassReport merges the results of a class.
+type ClassReport struct {
+	// Results holds each student's latest result for each quiz, ordered by
+	// quiz and then score, best first.
+	Results []Result
+	// Replaced is the number of older results dropped for a student who
+	// took the same quiz again.
+	Replaced int
+
+	ByCategory       map[string]*stats.CategoryData
+	ByDealerStrength map[string]*stats.CategoryData
+	// Cells lists every cell asked, weakest first.
+	Cells []ClassCell
+}
+
+// Aggregate merges students' results into a class report. When a student
+// has several results for the same quiz, only the latest counts.
+func Aggregate(results []Result) ClassReport {
+	var report ClassReport
+	latest := make(map[string]int)
+	for _, r := range results {
+		key := strings.ToLower(r.Quiz) + "\x00" + strings.ToLower(strings.TrimSpace(r.Student))
+		if i, exists := latest[key]; exists {
+			report.Replaced++
+			if r.Taken.After(report.Results[i].Taken) {
+				report.Results[i] = r
+			}
+			continue
+		}
+		latest[key] = len(report.Results)
+		report.Results = append(report.Results, r)
+	}
+	sort.SliceStable(report.Results, func(i, j int) bool {
+		a, b := report.Results[i], report.Results[j]
+		if a.Quiz != b.Quiz {
+			return a.Quiz < b.Quiz
+		}
+		return a.Score() > b.Score()
+	})
+
+	var attempts []history.Attempt
+	cells := make(map[stats.CellKey]*ClassCell)
+	for _, r := range report.Results {
+		attempts = append(attempts, r.Attempts...)
+		asked := make(map[stats.CellKey]bool)
+		missed := make(map[stats.CellKey]bool)
+		for _, attempt := range r.Attempts {
+			key := stats.AttemptCell(attempt)
+			cell, exists := cells[key]
+			if !exists {
+				cell = &ClassCell{Key: key, CorrectAction: attempt.CorrectAction}
+				cells[key] = cell
+			}
+			if !asked[key] {
+				asked[key] = true
+				cell.Students++
+			}
+			cell.Total++
+			if attempt.Correct {
+				cell.Correct++
+			} else if !missed[key] {
+				missed[key] = true
+				cell.MissedBy++
+			}
+		}
+	}
+	report.ByCategory, report.ByDealerStrength = stats.Tally(attempts)
+
+	for _, cell := range cells {
+		report.Cells = append(report.Cells, *cell)
+	}
+	sort.Slice(report.Cells, func(i, j int) bool {
+		a, b := report.Cells[i], report.Cells[j]
+		if a.Accuracy() != b.Accuracy() {
+			return a.Accuracy() < b.Accuracy()
+		}
+		if a.MissedBy != b.MissedBy {
+			return a.MissedBy > b.MissedBy
+		}
+		return a.Key.Label() < b.Key.Label()
+	})
+	return report
+}
+
+// Weak returns the cells with class accuracy below WeakCellAccuracy,
+// weakest first.
+func (r ClassReport) Weak() []ClassCell {
+	var weak []ClassCell
+	for _, cell := range r.Cells {
+		if cell.Accuracy() < WeakCellAccuracy {
+			weak = append(weak, cell)
+		}
+	}
+	return weak
+}
+
+// Display writes the class report to w.
+func (r ClassReport) Display(w io.Writer) {
+	fmt.Fprintln(w, strings.Repeat("=", 50))
+	fmt.Fprintln(w, "CLASS REPORT")
+	fmt.Fprintln(w, strings.Repeat("=", 50))
+	if len(r.Results) == 0 {
+		fmt.Fprintln(w, "No results.")
+		return
+	}
+
+	correct, total := 0, 0
+	quiz := ""
+	for _, result := range r.Results {
+		if result.Quiz != quiz {
+			quiz = result.Quiz
+			fmt.Fprintf(w, "\nQuiz %q:\n", quiz)
+		}
+		unfinished := ""
+		if result.Total < result.Questions {
+			unfinished = fmt.Sprintf("  (answered %d of %d)", result.Total, result.Questions)
+		}
+		fmt.Fprintf(w, "  %-24s %d/%d (%.1f%%)%s\n", result.Student, result.Correct, result.Total, result.Score(), unfinished)
+		correct += result.Correct
+		total += result.Total
+	}
+	fmt.Fprintf(w, "\nStudents' results: %d", len(r.Results))
+	if r.Replaced > 0 {
+		fmt.Fprintf(w, " (%d older retake(s) ignored)", r.Replaced)
+	}
+	fmt.Fprintf(w, "\nClass accuracy: %d/%d (%.1f%%)\n", correct, total, stats.CategoryData{Correct: correct, Total: total}.Accuracy())
+
+	displayBreakdown(w, "By Hand Type:", []string{"hard", "soft", "pair"}, r.ByCategory)
+	displayBreakdown(w, "By Dealer Strength:", []string{"weak", "medium", "strong"}, r.ByDealerStrength)
+
+	weak := r.Weak()
+	if len(weak) == 0 {
+		fmt.Fprintf(w, "\nThe class scored %.0f%% or better on every cell asked.\n", WeakCellAccuracy)
+		return
+	}
+	fmt.Fprintf(w, "\nCells the class misses (below %.0f%%):\n", WeakCellAccuracy)
+	for _, cell := range weak {
+		fmt.Fprintf(w, "  %-22s %d/%d (%.1f%%), missed by %d of %d students; correct is %s\n",
+			cell.Key.Label(), cell.Correct, cell.Total, cell.Accuracy(), cell.MissedBy, cell.Students,
+			strategy.ActionToString(firstRune(cell.CorrectAction)))
+	}
+}
+
+// displayBreakdown prints class accuracy for a set of categories.
+func displayBreakdown(w io.Writer, title string, keys []string, data map[string]*stats.CategoryData) {
+	fmt.Fprintln(w, "\n"+title)
+	for _, key := range keys {
+		if d := data[key]; d.Total > 0 {
+			fmt.Fprintf(w, "  %-18s %d/%d (%.1f%%)\n", strings.Title(key), d.Correct, d.Total, d.Accuracy())
+		}
+	}
+}
+
+// firstRune returns the first rune of s, or 0 if s is empty.
+func firstRune(s string) rune {
+	for _, r := range s {
+		return r
+	}
+	return 0
+}
@@ -0,0 +1,62 @@
+package classroom
+
+import (
+	"blackjack_trainer/internal/history"
+	"strings"
+	"testing"
+	"time"
+)
+
+// attempt builds a recorded answer to a hard hand.
+func attempt(cards []int, dealer int, correct bool) history.Attempt {
+	return history.Attempt{Cards: cards, DealerCard: dealer, HandType: "hard", Action: "S", CorrectAction: "H", Correct: correct}
+}
+
+// Test the class report merges students and finds the cells the class misses
+func TestAggregate(t *testing.T) {
+	taken := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	results := []Result{
+		{Quiz: "Week 1", Student: "Ann", Taken: taken, Correct: 1, Total: 2, Questions: 2, Attempts: []history.Attempt{
+			attempt([]int{10, 6}, 10, false), attempt([]int{10, 2}, 5, true),
+		}},
+		{Quiz: "Week 1", Student: "Bob", Taken: taken, Correct: 0, Total: 2, Questions: 2, Attempts: []history.Attempt{
+			attempt([]int{10, 6}, 10, false), attempt([]int{10, 2}, 5, false),
+		}},
+		// Bob's retake replaces his first result
+		{Quiz: "Week 1", Student: "bob ", Taken: taken.Add(time.Hour), Correct: 1, Total: 2, Questions: 2, Attempts: []history.Attempt{
+			attempt([]int{10, 6}, 10, false), attempt([]int{10, 2}, 5, true),
+		}},
+		{Quiz: "Week 1", Student: "Cy", Taken: taken, Correct: 1, Total: 1, Questions: 2, Attempts: []history.Attempt{
+			attempt([]int{9, 7}, 10, true),
+		}},
+	}
+
+	report := Aggregate(results)
+	if len(report.Results) != 3 || report.Replaced != 1 {
+		t.Fatalf("Expected 3 students with 1 retake replaced, got %d and %d", len(report.Results), report.Replaced)
+	}
+	if report.Results[0].Student != "Cy" {
+		t.Errorf("Expected the best score first, got %s", report.Results[0].Student)
+	}
+	if hard := report.ByCategory["hard"]; hard.Correct != 3 || hard.Total != 5 {
+		t.Errorf("Expected hard hands 3/5, got %d/%d", hard.Correct, hard.Total)
+	}
+
+	weak := report.Weak()
+	if len(weak) != 1 || weak[0].Key.Label() != "Hard 16 vs 10" || weak[0].MissedBy != 2 || weak[0].Students != 3 {
+		t.Fatalf("Expected only Hard 16 vs 10 missed by 2 of 3 students, got %+v", weak)
+	}
+
+	var b strings.Builder
+	report.Display(&b)
+	for _, want := range []string{
+		"Students' results: 3 (1 older retake(s) ignored)",
+		"Cy                       1/1 (100.0%)  (answered 1 of 2)",
+		"Class accuracy: 3/5 (60.0%)",
+		"Hard 16 vs 10          1/3 (33.3%), missed by 2 of 3 students; correct is HIT",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("Report missing %q:\n%s", want, b.String())
+		}
+	}
+}
@@ -0,0 +1,251 @@
+// Package classroom supports instructor-led quizzes: an instructor creates a
+// named quiz of fixed hands with a time limit and shares it as a file or a
+// code, each student takes it with "blackjack_trainer quiz", and each run
+// writes a result file for the instructor to collect.
+//
+// A quiz is stored as JSON. Its code is the same JSON, gzipped and
+// base64url-encoded behind the prefix "BJQ1.", short enough to paste into a
+// chat message or a learning management system.
+package classroom
+
+import (
+	"blackjack_trainer/internal/hand"
+	"blackjack_trainer/internal/history"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// CodePrefix starts every quiz code, identifying the format and version.
+const CodePrefix = "BJQ1."
+
+// maxCodeSize is the most a quiz code may decompress to. Codes are pasted
+// from anywhere, and a tiny one could otherwise expand to gigabytes.
+const maxCodeSize = 1 << 20
+
+// ResultSuffix ends the name of every result file.
+const ResultSuffix = ".result.json"
+
+// Question is one hand of a quiz. Cards run 2-11, with 11 for an ace.
+type Question struct {
+	Cards      []int `json:"cards"`
+	DealerCard int   `json:"dealer_card"`
+}
+
+// String formats the question, e.g. "10, 6 vs 10".
+func (q Question) String() string {
+	return fmt.Sprintf("%s vs %s", hand.New(q.Cards...), hand.CardString(q.DealerCard))
+}
+
+// ParseQuestion parses a hand and dealer card written like "10,6 vs 10" or
+// "A 7 vs 9", with the cards accepted by hand.Parse.
+func ParseQuestion(s string) (Question, error) {
+	i := strings.Index(strings.ToLower(s), "vs")
+	if i < 0 {
+		return Question{}, fmt.Errorf("question %q should be written like \"10,6 vs 10\"", strings.TrimSpace(s))
+	}
+	player, dealer := strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+len("vs"):])
+	h, err := hand.Parse(player)
+	if err != nil {
+		return Question{}, err
+	}
+	dealerCard, err := hand.ParseCard(dealer)
+	if err != nil {
+		return Question{}, err
+	}
+	return Question{Cards: h.Cards, DealerCard: dealerCard}, nil
+}
+
+// Quiz is an instructor's question set.
+type Quiz struct {
+	Name string `json:"name"`
+	// Rules is the key of the rule preset the quiz is answered under.
+	Rules string `json:"rules"`
+	// TimeLimit is the time allowed for the whole quiz; zero is untimed.
+	TimeLimit Duration   `json:"time_limit,omitempty"`
+	Questions []Question `json:"questions"`
+}
+
+// Duration is a time.Duration stored as text such as "10m", so quiz files
+// are easy to edit.
+type Duration time.Duration
+
+// MarshalText formats the duration.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// UnmarshalText parses a duration such as "10m" or "90s".
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Validate checks that the quiz has a name and only legal hands.
+func (q Quiz) Validate() error {
+	if strings.TrimSpace(q.Name) == "" {
+		return errors.New("quiz has no name")
+	}
+	if len(q.Questions) == 0 {
+		return fmt.Errorf("quiz %q has no questions", q.Name)
+	}
+	if q.TimeLimit < 0 {
+		return fmt.Errorf("quiz %q has a negative time limit", q.Name)
+	}
+	for i, question := range q.Questions {
+		if len(question.Cards) < 2 {
+			return fmt.Errorf("question %d: a hand needs at least two cards", i+1)
+		}
+		for _, card := range append([]int{question.DealerCard}, question.Cards...) {
+			if card < 2 || card > 11 {
+				return fmt.Errorf("question %d: invalid card %d (use 2-11, 11 for an ace)", i+1, card)
+			}
+		}
+		if total := hand.New(question.Cards...).Total(); total > 21 {
+			return fmt.Errorf("question %d: hand %s is bust", i+1, hand.New(question.Cards...))
+		}
+	}
+	return nil
+}
+
+// Write writes the quiz as indented JSON.
+func (q Quiz) Write(w io.Writer) error {
+	data, err := json.MarshalIndent(q, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// Code returns the quiz as a code students can paste.
+func (q Quiz) Code() (string, error) {
+	data, err := json.Marshal(q)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	zw := gzip.NewWriter(&b)
+	if _, err := zw.Write(data); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	return CodePrefix + base64.RawURLEncoding.EncodeToString(b.Bytes()), nil
+}
+
+// Parse reads a quiz from JSON and validates it.
+func Parse(data []byte) (Quiz, error) {
+	var q Quiz
+	if err := json.Unmarshal(data, &q); err != nil {
+		return Quiz{}, err
+	}
+	return q, q.Validate()
+}
+
+// Decode reads a quiz from its code.
+func Decode(code string) (Quiz, error) {
+	encoded, ok := strings.CutPrefix(strings.TrimSpace(code), CodePrefix)
+	if !ok {
+		return Quiz{}, fmt.Errorf("not a quiz code (quiz codes start with %s)", CodePrefix)
+	}
+	compressed, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Quiz{}, fmt.Errorf("damaged quiz code: %w", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return Quiz{}, fmt.Errorf("damaged quiz code: %w", err)
+	}
+	data, err := io.ReadAll(io.LimitReader(zr, maxCodeSize+1))
+	if err != nil {
+		return Quiz{}, fmt.Errorf("damaged quiz code: %w", err)
+	}
+	if len(data) > maxCodeSize {
+		return Quiz{}, errors.New("quiz code too large")
+	}
+	return Parse(data)
+}
+
+// Load reads a quiz given as a code or as the path of a quiz file.
+func Load(codeOrPath string) (Quiz, error) {
+	if strings.HasPrefix(codeOrPath, CodePrefix) {
+		return Decode(codeOrPath)
+	}
+	data, err := os.ReadFile(codeOrPath)
+	if err != nil {
+		return Quiz{}, err
+	}
+	q, err := Parse(data)
+	if err != nil {
+		return Quiz{}, fmt.Errorf("reading %s: %w", codeOrPath, err)
+	}
+	return q, nil
+}
+
+// Result is a student's answers to a quiz, written for the instructor.
+type Result struct {
+	Quiz    string    `json:"quiz"`
+	Student string    `json:"student"`
+	Rules   string    `json:"rules"`
+	Taken   time.Time `json:"taken"`
+	Correct int       `json:"correct"`
+	// Total is the number of questions answered, out of Questions in the
+	// quiz; a student who runs out of time answers fewer.
+	Total     int               `json:"total"`
+	Questions int               `json:"questions"`
+	Attempts  []history.Attempt `json:"attempts"`
+}
+
+// Score returns the percentage of answered questions that were correct.
+func (r Result) Score() float64 {
+	if r.Total == 0 {
+		return 0
+	}
+	return float64(r.Correct) / float64(r.Total) * 100
+}
+
+// unsafeChars matches runs of characters kept out of result file names.
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// FileName returns the default name of the result file, e.g.
+// "week-1-pat-dealer.result.json".
+func (r Result) FileName() string {
+	name := strings.ToLower(r.Quiz + "-" + r.Student)
+	return strings.Trim(unsafeChars.ReplaceAllString(name, "-"), "-") + ResultSuffix
+}
+
+// WriteResult writes a result file.
+func WriteResult(path string, r Result) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// ReadResult reads a result file.
+func ReadResult(path string) (Result, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Result{}, err
+	}
+	var r Result
+	if err := json.Unmarshal(data, &r); err != nil {
+		return Result{}, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return r, nil
+}
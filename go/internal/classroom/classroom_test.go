@@ -0,0 +1,151 @@
+package classroom
+
+import (
+	"blackjack_trainer/internal/history"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test questions parse from the forms an instructor would type
+func TestParseQuestion(t *testing.T) {
+	tests := []struct {
+		input  string
+		cards  []int
+		dealer int
+		ok     bool
+	}{
+		{"10,6 vs 10", []int{10, 6}, 10, true},
+		{"A 7 vs 9", []int{11, 7}, 9, true},
+		{" 8,8 VS A ", []int{8, 8}, 11, true},
+		{"10,6", nil, 0, false},
+		{"10,6 vs Z", nil, 0, false},
+	}
+	for _, tt := range tests {
+		q, err := ParseQuestion(tt.input)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseQuestion(%q) error = %v, want ok %v", tt.input, err, tt.ok)
+			continue
+		}
+		if tt.ok && (!reflect.DeepEqual(q.Cards, tt.cards) || q.DealerCard != tt.dealer) {
+			t.Errorf("ParseQuestion(%q) = %+v, want %v vs %d", tt.input, q, tt.cards, tt.dealer)
+		}
+	}
+}
+
+// Test errors quote the mistyped side without the spaces around "vs"
+func TestParseQuestionErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"x vs 10", `hand "x" needs at least two cards`},
+		{"10,6 vs 12", `invalid card "12"`},
+		{"Q,Z vs 10", `invalid card "Z"`},
+		{"  10,6  ", `question "10,6" should be written like "10,6 vs 10"`},
+	}
+	for _, tt := range tests {
+		_, err := ParseQuestion(tt.input)
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("ParseQuestion(%q) error = %v, want %s", tt.input, err, tt.want)
+		}
+	}
+}
+
+// Test a quiz survives the round trip through its code
+func TestCode(t *testing.T) {
+	quiz := Quiz{
+		Name:      "Week 1",
+		Rules:     "standard",
+		TimeLimit: Duration(10 * time.Minute),
+		Questions: []Question{{Cards: []int{10, 6}, DealerCard: 10}, {Cards: []int{11, 7}, DealerCard: 9}},
+	}
+	code, err := quiz.Code()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(code, CodePrefix) {
+		t.Errorf("Code %q should start with %s", code, CodePrefix)
+	}
+	decoded, err := Load(code)
+	if err != nil {
+		t.Fatalf("Load of code failed: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, quiz) {
+		t.Errorf("Decoded quiz = %+v, want %+v", decoded, quiz)
+	}
+
+	if _, err := Decode(CodePrefix + "not-a-quiz"); err == nil {
+		t.Error("Decode of a damaged code should fail")
+	}
+
+	// A code that expands past the limit is refused before it is read in full
+	var bomb bytes.Buffer
+	zw := gzip.NewWriter(&bomb)
+	zw.Write(bytes.Repeat([]byte(" "), 8*maxCodeSize))
+	zw.Close()
+	if _, err := Decode(CodePrefix + base64.RawURLEncoding.EncodeToString(bomb.Bytes())); err == nil || err.Error() != "quiz code too large" {
+		t.Errorf("Decode of an oversized code = %v", err)
+	}
+}
+
+// Test quizzes with missing or illegal parts are rejected
+func TestValidate(t *testing.T) {
+	good := []Question{{Cards: []int{10, 6}, DealerCard: 10}}
+	tests := []struct {
+		name string
+		quiz Quiz
+	}{
+		{"no name", Quiz{Questions: good}},
+		{"no questions", Quiz{Name: "Week 1"}},
+		{"negative time", Quiz{Name: "Week 1", TimeLimit: -1, Questions: good}},
+		{"one card", Quiz{Name: "Week 1", Questions: []Question{{Cards: []int{10}, DealerCard: 10}}}},
+		{"bad card", Quiz{Name: "Week 1", Questions: []Question{{Cards: []int{10, 1}, DealerCard: 10}}}},
+		{"bust", Quiz{Name: "Week 1", Questions: []Question{{Cards: []int{10, 6, 9}, DealerCard: 10}}}},
+	}
+	for _, tt := range tests {
+		if err := tt.quiz.Validate(); err == nil {
+			t.Errorf("%s: Validate() should fail", tt.name)
+		}
+	}
+	if _, err := Parse([]byte(`{"name": "Week 1", "rules": "standard", "time_limit": "90s", "questions": [{"cards": [10, 6], "dealer_card": 10}]}`)); err != nil {
+		t.Errorf("Parse of a valid quiz failed: %v", err)
+	}
+}
+
+// Test result files are named after the quiz and student and read back
+func TestResultFile(t *testing.T) {
+	r := Result{
+		Quiz:      "Week 1",
+		Student:   "Pat O'Dealer",
+		Rules:     "standard",
+		Taken:     time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC),
+		Correct:   1,
+		Total:     2,
+		Questions: 2,
+		Attempts:  []history.Attempt{{Cards: []int{10, 6}, DealerCard: 10, HandType: "hard", Action: "S", CorrectAction: "S", Correct: true}},
+	}
+	if got, want := r.FileName(), "week-1-pat-o-dealer"+ResultSuffix; got != want {
+		t.Errorf("FileName() = %q, want %q", got, want)
+	}
+	if got := r.Score(); got != 50 {
+		t.Errorf("Score() = %v, want 50", got)
+	}
+
+	path := filepath.Join(t.TempDir(), r.FileName())
+	if err := WriteResult(path, r); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadResult(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read, r) {
+		t.Errorf("ReadResult = %+v, want %+v", read, r)
+	}
+}
@@ -0,0 +1,216 @@
+// Package config loads user preferences for the blackjack trainer.
+//
+// Preferences are stored as JSON in the user's configuration directory
+// (for example ~/.config/blackjack_trainer/config.json on Linux). A missing
+// file is not an error; every setting has a sensible default.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// AppDirName is the directory name used under the user's config directory.
+const AppDirName = "blackjack_trainer"
+
+// FileName is the name of the configuration file.
+const FileName = "config.json"
+
+// PassphraseEnv is the environment variable that supplies the history
+// passphrase without prompting.
+const PassphraseEnv = "BLACKJACK_TRAINER_PASSPHRASE"
+
+// Config holds user preferences.
+type Config struct {
+	// KeyScheme selects a preset key layout: "letters" (default), "numbers", or "vim".
+	KeyScheme string `json:"key_scheme,omitempty"`
+	// KeyBindings maps action names (hit, stand, double, split) to keys,
+	// overriding the scheme for those actions.
+	KeyBindings map[string]string `json:"key_bindings,omitempty"`
+	// EncryptHistory asks for a passphrase at startup and encrypts the
+	// practice history with it.
+	EncryptHistory bool `json:"encrypt_history,omitempty"`
+	// LiveTablePrep shows the hand signal for the correct action after each
+	// answer, for players preparing to play at a casino table.
+	LiveTablePrep bool `json:"live_table_prep,omitempty"`
+	// LargePrint shows hands in large ASCII-art characters with
+	// high-contrast labels, for players with low vision.
+	LargePrint bool `json:"large_print,omitempty"`
+	// ConfirmAbsolutes asks "Are you sure?" once before recording an answer
+	// that breaks an always/never rule in the absolutes drill, at easy
+	// difficulty.
+	ConfirmAbsolutes bool `json:"confirm_absolutes,omitempty"`
+	// RateQuestions offers to rate each question 1-3 for how hard it felt,
+	// which the mastery model and "report -sort rating" take into account.
+	RateQuestions bool `json:"rate_questions,omitempty"`
+	// DetailedFeedback adds the odds behind the play to the feedback for
+	// every answer, such as the chance of busting if you hit.
+	DetailedFeedback bool `json:"detailed_feedback,omitempty"`
+	// Coach shows the odds before each answer in the realistic mode: the
+	// chance of busting, the dealer's outcomes and the EV of each action.
+	Coach bool `json:"coach,omitempty"`
+	// Goal ends sessions once this percent of answers are right, judged
+	// over at least GoalQuestions answers (20 if zero); zero sets no goal.
+	Goal          int `json:"goal,omitempty"`
+	GoalQuestions int `json:"goal_questions,omitempty"`
+	// Drills names constraint specs for -focus, e.g. "weak-doubles":
+	// "action=double dealer=4-6" (see trainer.ParseConstraints).
+	Drills map[string]string `json:"drills,omitempty"`
+	// Sound configures audio cues for answers.
+	Sound SoundConfig `json:"sound,omitempty"`
+	// Sync configures remote synchronization of the practice history.
+	Sync SyncConfig `json:"sync,omitempty"`
+	// Server configures the serve command.
+	Server ServerConfig `json:"server,omitempty"`
+	// Telemetry opts in to reporting anonymous error rates.
+	Telemetry TelemetryConfig `json:"telemetry,omitempty"`
+	// Summary configures where "summary -send" sends the weekly summary.
+	Summary SummaryConfig `json:"summary,omitempty"`
+}
+
+// SoundConfig holds the audio cues played for answers, for drilling without
+// watching the screen.
+type SoundConfig struct {
+	// Cues selects the backend: "bell" rings the terminal bell (once when
+	// correct, twice when wrong, three times at a streak milestone),
+	// "files" plays the sound files below, and "" or "off" plays nothing.
+	Cues string `json:"cues,omitempty"`
+	// Correct, Incorrect and Milestone are the sound files for "files";
+	// a milestone without a file plays the correct sound.
+	Correct   string `json:"correct,omitempty"`
+	Incorrect string `json:"incorrect,omitempty"`
+	Milestone string `json:"milestone,omitempty"`
+	// Player is the command that plays the files; afplay, paplay or aplay,
+	// whichever is installed, if empty.
+	Player string `json:"player,omitempty"`
+	// StreakEvery is how many correct answers in a row make a milestone;
+	// 5 if zero, and negative for no milestones.
+	StreakEvery int `json:"streak_every,omitempty"`
+}
+
+// SummaryConfig holds the destinations of the progress summary.
+type SummaryConfig struct {
+	// Name identifies the player in summaries sent to a group.
+	Name string `json:"name,omitempty"`
+	// WebhookURL receives the summary as a JSON POST.
+	WebhookURL string `json:"webhook_url,omitempty"`
+	// Email lists comma-separated addresses to mail the summary to.
+	Email string `json:"email,omitempty"`
+	// Sendmail is the sendmail-compatible command used for email;
+	// "sendmail" if empty.
+	Sendmail string `json:"sendmail,omitempty"`
+}
+
+// TelemetryConfig opts in to reporting anonymous, aggregate error rates per
+// chart cell to help tune the difficulty tiers. Nothing is sent unless
+// Enabled is set and URL names the endpoint.
+type TelemetryConfig struct {
+	Enabled bool   `json:"enabled,omitempty"`
+	URL     string `json:"url,omitempty"`
+}
+
+// SyncConfig holds the remote endpoint used by the sync command.
+type SyncConfig struct {
+	// URL is the location of the remote history document, e.g. a WebDAV file.
+	URL string `json:"url,omitempty"`
+	// Username and Password are sent with HTTP basic authentication.
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	// Token is sent as a bearer token.
+	Token string `json:"token,omitempty"`
+}
+
+// ServerConfig holds access settings for the training server.
+type ServerConfig struct {
+	// APIKeys maps API keys to the user name each key acts as, for scripts
+	// and other clients that cannot log in interactively.
+	APIKeys map[string]string `json:"api_keys,omitempty"`
+	// RateLimit is the number of requests per minute allowed from each
+	// client address. Zero means unlimited.
+	RateLimit int `json:"rate_limit,omitempty"`
+	// RateBurst is how many requests a client may make at once before being
+	// limited. Zero means the same as RateLimit.
+	RateBurst int `json:"rate_burst,omitempty"`
+	// TrustProxy identifies clients by the X-Forwarded-For header. Enable it
+	// only behind a reverse proxy that sets the header.
+	TrustProxy bool `json:"trust_proxy,omitempty"`
+	// Webhooks are notified when users finish sessions and exams.
+	Webhooks []WebhookConfig `json:"webhooks,omitempty"`
+	// OAuth lists the providers users may sign in with instead of a
+	// password.
+	OAuth []OAuthConfig `json:"oauth,omitempty"`
+	// PublicURL is the server's address as users' browsers reach it, which
+	// sign-in providers send users back to. Empty means the address each
+	// request was made to.
+	PublicURL string `json:"public_url,omitempty"`
+}
+
+// OAuthConfig is a sign-in provider: "google", "github", or any OpenID
+// Connect provider given its issuer.
+type OAuthConfig struct {
+	Name         string `json:"name"`
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	// Issuer is an OpenID Connect issuer URL, to discover the endpoints of
+	// a provider other than Google or GitHub.
+	Issuer      string   `json:"issuer,omitempty"`
+	AuthURL     string   `json:"auth_url,omitempty"`
+	TokenURL    string   `json:"token_url,omitempty"`
+	UserInfoURL string   `json:"userinfo_url,omitempty"`
+	Scopes      []string `json:"scopes,omitempty"`
+	// AllowSignup creates a profile the first time someone signs in;
+	// otherwise each identity is linked to an account with serve -link.
+	AllowSignup bool `json:"allow_signup,omitempty"`
+	// Domains limits sign-in to verified email addresses in these domains.
+	Domains []string `json:"domains,omitempty"`
+}
+
+// WebhookConfig is an address the training server posts results to.
+type WebhookConfig struct {
+	URL string `json:"url"`
+	// Events lists the events to send, "session.completed" and
+	// "exam.completed"; every event if empty.
+	Events []string `json:"events,omitempty"`
+	// Secret, if set, signs each delivery with an HMAC-SHA256 of its body.
+	Secret string `json:"secret,omitempty"`
+}
+
+// Dir returns the directory where the trainer stores its files.
+func Dir() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, AppDirName), nil
+}
+
+// DefaultPath returns the default configuration file path.
+func DefaultPath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, FileName), nil
+}
+
+// Load reads the configuration file at path.
+// A missing file returns an empty configuration.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return &cfg, nil
+}
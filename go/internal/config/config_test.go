@@ -0,0 +1,50 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Test loading a missing file returns an empty configuration
+func TestLoadMissingFile(t *testing.T) {
+	cfg, err := Load(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("Missing file should not be an error: %v", err)
+	}
+	if cfg.KeyScheme != "" || len(cfg.KeyBindings) != 0 {
+		t.Errorf("Missing file should give empty config, got %+v", cfg)
+	}
+}
+
+// Test loading key binding settings
+func TestLoadKeyBindings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	data := `{"key_scheme": "numbers", "key_bindings": {"split": "0"}}`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.KeyScheme != "numbers" {
+		t.Errorf("Expected key scheme numbers, got %q", cfg.KeyScheme)
+	}
+	if cfg.KeyBindings["split"] != "0" {
+		t.Errorf("Expected split bound to 0, got %q", cfg.KeyBindings["split"])
+	}
+}
+
+// Test malformed files are reported
+func TestLoadMalformedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(path); err == nil {
+		t.Error("Expected error for malformed config")
+	}
+}
@@ -0,0 +1,152 @@
+// Package crypt encrypts the trainer's data files with a passphrase.
+//
+// Files are sealed with AES-256-GCM using a key derived from the passphrase
+// with PBKDF2-HMAC-SHA256. The encrypted format is:
+// - the Magic header
+// - a random salt for key derivation
+// - a random nonce
+// - the ciphertext, authenticated together with the header and salt
+package crypt
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Magic identifies encrypted files.
+const Magic = "BJTCRYPT1\n"
+
+// Iterations is the PBKDF2 work factor used to derive keys.
+const Iterations = 600000
+
+const (
+	saltSize = 16
+	keySize  = 32
+)
+
+// ErrWrongPassphrase is returned when data cannot be decrypted with the
+// given passphrase, either because it is wrong or the data was modified.
+var ErrWrongPassphrase = errors.New("wrong passphrase or corrupted data")
+
+// Key is a passphrase-derived encryption key. Keys are expensive to derive,
+// so a key obtained when opening a file is reused to seal it again.
+type Key struct {
+	salt []byte
+	aead cipher.AEAD
+}
+
+// NewKey derives a key from the passphrase with a new random salt.
+func NewKey(passphrase string) (*Key, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return deriveKey(passphrase, salt)
+}
+
+// deriveKey derives the key for a passphrase and salt.
+func deriveKey(passphrase string, salt []byte) (*Key, error) {
+	if passphrase == "" {
+		return nil, errors.New("empty passphrase")
+	}
+
+	block, err := aes.NewCipher(pbkdf2([]byte(passphrase), salt, Iterations, keySize))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{salt: salt, aead: aead}, nil
+}
+
+// Seal encrypts plaintext with a fresh nonce.
+func (k *Key) Seal(plaintext []byte) ([]byte, error) {
+	header := k.header()
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	out := append(header, nonce...)
+	return k.aead.Seal(out, nonce, plaintext, header), nil
+}
+
+// header returns the magic and salt, which are authenticated with the ciphertext.
+func (k *Key) header() []byte {
+	header := make([]byte, 0, len(Magic)+len(k.salt))
+	header = append(header, Magic...)
+	return append(header, k.salt...)
+}
+
+// IsEncrypted reports whether data is in the encrypted format.
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(Magic))
+}
+
+// Open decrypts data sealed with the passphrase. It also returns the key so
+// the caller can seal updated data without deriving it again.
+func Open(data []byte, passphrase string) ([]byte, *Key, error) {
+	if !IsEncrypted(data) {
+		return nil, nil, errors.New("data is not encrypted")
+	}
+	rest := data[len(Magic):]
+	if len(rest) < saltSize {
+		return nil, nil, fmt.Errorf("encrypted data truncated")
+	}
+
+	key, err := deriveKey(passphrase, rest[:saltSize])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rest = rest[saltSize:]
+	nonceSize := key.aead.NonceSize()
+	if len(rest) < nonceSize {
+		return nil, nil, fmt.Errorf("encrypted data truncated")
+	}
+
+	plaintext, err := key.aead.Open(nil, rest[:nonceSize], rest[nonceSize:], key.header())
+	if err != nil {
+		return nil, nil, ErrWrongPassphrase
+	}
+	return plaintext, key, nil
+}
+
+// pbkdf2 derives a key with PBKDF2 (RFC 8018) using HMAC-SHA256.
+func pbkdf2(password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	hashLen := prf.Size()
+	blocks := (keyLen + hashLen - 1) / hashLen
+
+	var counter [4]byte
+	derived := make([]byte, 0, blocks*hashLen)
+	u := make([]byte, hashLen)
+	for block := 1; block <= blocks; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		binary.BigEndian.PutUint32(counter[:], uint32(block))
+		prf.Write(counter[:])
+		derived = prf.Sum(derived)
+
+		t := derived[len(derived)-hashLen:]
+		copy(u, t)
+		for i := 2; i <= iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range u {
+				t[j] ^= u[j]
+			}
+		}
+	}
+	return derived[:keyLen]
+}
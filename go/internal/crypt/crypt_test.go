@@ -0,0 +1,106 @@
+package crypt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// Test PBKDF2 against published HMAC-SHA256 test vectors
+func TestPBKDF2(t *testing.T) {
+	tests := []struct {
+		iterations int
+		want       string
+	}{
+		{1, "120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b"},
+		{2, "ae4d0c95af6b46d32d0adff928f06dd02a303f8ef3c251dfd6e2d85a95474c43"},
+		{4096, "c5e478d59288c841aa530db6845c4c8d962893a001ce4e11a4963873aa98134a"},
+	}
+
+	for _, test := range tests {
+		got := hex.EncodeToString(pbkdf2([]byte("password"), []byte("salt"), test.iterations, 32))
+		if got != test.want {
+			t.Errorf("%d iterations: expected %s, got %s", test.iterations, test.want, got)
+		}
+	}
+}
+
+// Test sealed data opens with the right passphrase only
+func TestSealOpen(t *testing.T) {
+	plaintext := []byte(`{"sessions":[]}`)
+
+	key, err := NewKey("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := key.Seal(plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !IsEncrypted(sealed) || IsEncrypted(plaintext) {
+		t.Error("IsEncrypted should detect only sealed data")
+	}
+	if bytes.Contains(sealed, plaintext) {
+		t.Error("Sealed data should not contain the plaintext")
+	}
+
+	opened, reopenedKey, err := Open(sealed, "correct horse")
+	if err != nil || !bytes.Equal(opened, plaintext) {
+		t.Fatalf("Open failed: %q, %v", opened, err)
+	}
+
+	// The returned key reseals data that opens with the same passphrase
+	resealed, err := reopenedKey.Seal([]byte("updated"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opened, _, err := Open(resealed, "correct horse"); err != nil || string(opened) != "updated" {
+		t.Errorf("Resealed data should open, got %q, %v", opened, err)
+	}
+
+	if _, _, err := Open(sealed, "wrong"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Expected ErrWrongPassphrase, got %v", err)
+	}
+
+	tampered := append([]byte{}, sealed...)
+	tampered[len(tampered)-1] ^= 1
+	if _, _, err := Open(tampered, "correct horse"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Tampered data should fail to open, got %v", err)
+	}
+
+	if _, _, err := Open([]byte(Magic+"short"), "correct horse"); err == nil {
+		t.Error("Truncated data should fail to open")
+	}
+	if _, err := NewKey(""); err == nil {
+		t.Error("Empty passphrase should be rejected")
+	}
+}
+
+// Test password hashes verify only the original password
+func TestHashPassword(t *testing.T) {
+	hash, err := HashPassword("s3cret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(hash, "s3cret") || !strings.HasPrefix(hash, "pbkdf2-sha256$") {
+		t.Errorf("Unexpected hash format: %s", hash)
+	}
+	if !CheckPassword(hash, "s3cret") {
+		t.Error("Correct password should match")
+	}
+	if CheckPassword(hash, "s3cret!") {
+		t.Error("Wrong password should not match")
+	}
+
+	for _, malformed := range []string{"", "s3cret", "pbkdf2-sha256$x$AA$AA", "md5$1$AA$AA"} {
+		if CheckPassword(malformed, "s3cret") {
+			t.Errorf("Malformed hash %q should not match", malformed)
+		}
+	}
+	if _, err := HashPassword(""); err == nil {
+		t.Error("Empty password should be rejected")
+	}
+}
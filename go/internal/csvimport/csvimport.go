@@ -0,0 +1,391 @@
+// Package csvimport reads practice history exported from other strategy
+// trainers as CSV so it can be merged into the local history.
+//
+// The input is a generic per-cell attempt format: a header row naming the
+// columns (case-insensitive, in any order) followed by one row per answered
+// question. Recognized columns are:
+// - dealer (or upcard, dealer_card): the dealer up-card, e.g. "A", "10", "K"
+// - cards (or hand, player_cards): the player's cards, e.g. "A 7" or "10-6"
+// - hand_type and total: an alternative to cards; for pairs, total is the pair card
+// - action (or answer): the action chosen, e.g. "H", "stand", "split"
+// - correct (or result): whether the answer was right (true/false, 1/0, yes/no)
+// - correct_action (or expected): the right answer; filled in from the chart if absent
+// - timestamp (or date, time): when the question was answered
+// - latency_ms: response time in milliseconds
+// - attempts: makes the row an aggregate of that many answers, with correct
+// holding the number answered correctly
+//
+// At least one of action or correct must be present. Rows are grouped into one
+// imported session per day; rows without a timestamp form a single session,
+// dated by the caller, e.g. with the file's modification time.
+package csvimport
+
+import (
+	"blackjack_trainer/internal/hand"
+	"blackjack_trainer/internal/history"
+	"blackjack_trainer/internal/strategy"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Mode is the session mode recorded for imported sessions.
+const Mode = "imported"
+
+// MaxAttemptsPerRow is the most attempts one per-cell total may stand for.
+// Each is recorded in the history, so a huge count would exhaust memory.
+const MaxAttemptsPerRow = 10000
+
+// columnAliases maps each recognized column to the header names accepted for it.
+var columnAliases = map[string][]string{
+	"dealer":         {"dealer", "dealer_card", "upcard", "up_card"},
+	"cards":          {"cards", "hand", "player_cards", "player_hand"},
+	"hand_type":      {"hand_type", "type"},
+	"total":          {"total", "player_total"},
+	"action":         {"action", "answer", "user_action"},
+	"correct":        {"correct", "result", "is_correct"},
+	"correct_action": {"correct_action", "expected", "expected_action"},
+	"timestamp":      {"timestamp", "date", "time", "datetime"},
+	"latency_ms":     {"latency_ms", "ms", "response_ms"},
+	"attempts":       {"attempts", "count"},
+}
+
+// timeLayouts are the timestamp formats accepted, tried in order.
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"01/02/2006 15:04:05",
+	"01/02/2006",
+}
+
+// row is one parsed CSV record.
+type row struct {
+	attempt   history.Attempt
+	timestamp time.Time
+	count     int // number of attempts the row stands for
+	correct   int // number of those answered correctly
+}
+
+// Read parses a CSV export and returns the imported sessions, oldest first.
+// The chart supplies correct actions missing from the input. Rows without
+// timestamps are dated undated, which should stay the same when the same
+// export is read again, such as the file's modification time, so that a
+// second import of it finds the session already present.
+func Read(r io.Reader, chart *strategy.StrategyChart, undated time.Time) ([]history.Session, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("empty file")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := mapColumns(header)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []row
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if isBlank(record) {
+			continue
+		}
+
+		line, _ := reader.FieldPos(0)
+		parsed, err := parseRow(columns, record, chart)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rows = append(rows, parsed)
+	}
+
+	return groupSessions(rows, undated), nil
+}
+
+// mapColumns returns the index of each recognized column in the header.
+func mapColumns(header []string) (map[string]int, error) {
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		for column, aliases := range columnAliases {
+			for _, alias := range aliases {
+				if name == alias {
+					columns[column] = i
+				}
+			}
+		}
+	}
+
+	if _, ok := columns["dealer"]; !ok {
+		return nil, errors.New("missing dealer column")
+	}
+	_, hasCards := columns["cards"]
+	_, hasType := columns["hand_type"]
+	_, hasTotal := columns["total"]
+	if !hasCards && !(hasType && hasTotal) {
+		return nil, errors.New("missing cards column (or hand_type and total columns)")
+	}
+	_, hasAction := columns["action"]
+	_, hasCorrect := columns["correct"]
+	if !hasAction && !hasCorrect {
+		return nil, errors.New("missing action or correct column")
+	}
+	return columns, nil
+}
+
+// parseRow converts one CSV record into an attempt.
+func parseRow(columns map[string]int, record []string, chart *strategy.StrategyChart) (row, error) {
+	field := func(column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var r row
+	var err error
+
+	if r.attempt.DealerCard, err = ParseCard(field("dealer")); err != nil {
+		return r, fmt.Errorf("dealer: %w", err)
+	}
+
+	if cards := field("cards"); cards != "" {
+		r.attempt.Cards, err = ParseCards(cards)
+	} else {
+		r.attempt.Cards, err = cellCards(field("hand_type"), field("total"))
+	}
+	if err != nil {
+		return r, err
+	}
+
+	playerHand := hand.New(r.attempt.Cards...)
+	handType, _ := strategy.Classify(playerHand)
+	r.attempt.HandType = handType.String()
+
+	if action := field("action"); action != "" {
+		userAction, err := ParseAction(action)
+		if err != nil {
+			return r, fmt.Errorf("action: %w", err)
+		}
+		r.attempt.Action = string(userAction)
+	}
+
+	correctAction := chart.GetCorrectActionForHand(playerHand, r.attempt.DealerCard)
+	if expected := field("correct_action"); expected != "" {
+		if correctAction, err = ParseAction(expected); err != nil {
+			return r, fmt.Errorf("correct_action: %w", err)
+		}
+	}
+	r.attempt.CorrectAction = string(correctAction)
+
+	if latency := field("latency_ms"); latency != "" {
+		if r.attempt.LatencyMs, err = strconv.ParseInt(latency, 10, 64); err != nil || r.attempt.LatencyMs < 0 {
+			return r, fmt.Errorf("invalid latency_ms %q", latency)
+		}
+	}
+
+	if timestamp := field("timestamp"); timestamp != "" {
+		if r.timestamp, err = parseTime(timestamp); err != nil {
+			return r, err
+		}
+	}
+
+	r.count = 1
+	attempts := field("attempts")
+	if attempts != "" {
+		if r.count, err = strconv.Atoi(attempts); err != nil || r.count < 1 {
+			return r, fmt.Errorf("invalid attempts %q", attempts)
+		}
+		if r.count > MaxAttemptsPerRow {
+			return r, fmt.Errorf("attempts %d is more than %d per row", r.count, MaxAttemptsPerRow)
+		}
+	}
+
+	correct := field("correct")
+	switch {
+	case correct == "" && r.attempt.Action == "":
+		return r, errors.New("row has neither action nor correct")
+	case correct == "":
+		if r.attempt.Action == r.attempt.CorrectAction {
+			r.correct = r.count
+		}
+	case attempts != "":
+		if r.correct, err = strconv.Atoi(correct); err != nil || r.correct < 0 || r.correct > r.count {
+			return r, fmt.Errorf("invalid correct count %q for %d attempts", correct, r.count)
+		}
+	default:
+		ok, err := parseBool(correct)
+		if err != nil {
+			return r, err
+		}
+		if ok {
+			r.correct = 1
+		}
+	}
+
+	return r, nil
+}
+
+// groupSessions groups rows into one session per calendar day, dating rows
+// without a timestamp undated.
+func groupSessions(rows []row, undated time.Time) []history.Session {
+	byDay := make(map[time.Time]*history.Session)
+	var days []time.Time
+
+	for _, r := range rows {
+		timestamp := r.timestamp
+		if timestamp.IsZero() {
+			timestamp = undated
+		}
+		day := history.StartOfDay(timestamp)
+
+		session, exists := byDay[day]
+		if !exists {
+			session = &history.Session{Mode: Mode, Started: timestamp, Ended: timestamp}
+			byDay[day] = session
+			days = append(days, day)
+		}
+		if timestamp.Before(session.Started) {
+			session.Started = timestamp
+		}
+		if timestamp.After(session.Ended) {
+			session.Ended = timestamp
+		}
+
+		for i := 0; i < r.count; i++ {
+			attempt := r.attempt
+			attempt.Correct = i < r.correct
+			session.Attempts = append(session.Attempts, attempt)
+			session.Total++
+			if attempt.Correct {
+				session.Correct++
+			}
+		}
+	}
+
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	sessions := make([]history.Session, 0, len(days))
+	for _, day := range days {
+		sessions = append(sessions, *byDay[day])
+	}
+	return sessions
+}
+
+// ParseCard parses a card name: 2-10, J, Q, K, T (ten), or A (ace).
+func ParseCard(s string) (int, error) {
+	return hand.ParseCard(s)
+}
+
+// ParseCards parses a list of cards separated by spaces, commas, dashes,
+// slashes, or plus signs (e.g. "A 7", "10-6", "8,8").
+func ParseCards(s string) ([]int, error) {
+	h, err := hand.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	return h.Cards, nil
+}
+
+// ParseAction parses an action letter or word (hit, stand, double, split).
+func ParseAction(s string) (rune, error) {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "H", "HIT":
+		return 'H', nil
+	case "S", "STAND":
+		return 'S', nil
+	case "D", "DOUBLE", "DOUBLE DOWN":
+		return 'D', nil
+	case "P", "Y", "SPLIT":
+		return 'Y', nil
+	}
+	return 0, fmt.Errorf("invalid action %q", s)
+}
+
+// cellCards returns representative cards for a chart cell given by hand type
+// and total. For pairs the total is the pair card.
+func cellCards(handType, total string) ([]int, error) {
+	var value int
+	var err error
+	if strings.EqualFold(handType, "pair") || strings.EqualFold(handType, "pairs") {
+		value, err = ParseCard(total)
+	} else {
+		value, err = strconv.Atoi(total)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid total %q", total)
+	}
+
+	switch strings.ToLower(handType) {
+	case "hard":
+		switch {
+		case value >= 5 && value <= 11:
+			return []int{2, value - 2}, nil
+		case value >= 12 && value <= 19:
+			return []int{10, value - 10}, nil
+		case value == 20 || value == 21:
+			// Two-card hard 20 is a pair and there is no two-card hard 21
+			return []int{10, 6, value - 16}, nil
+		}
+	case "soft":
+		if value >= 13 && value <= 21 {
+			return []int{hand.Ace, value - hand.Ace}, nil
+		}
+	case "pair", "pairs":
+		return []int{value, value}, nil
+	default:
+		return nil, fmt.Errorf("invalid hand_type %q", handType)
+	}
+	return nil, fmt.Errorf("invalid %s total %d", strings.ToLower(handType), value)
+}
+
+// parseBool parses the values used for the correct column.
+func parseBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "1", "true", "t", "yes", "y", "correct", "right", "pass":
+		return true, nil
+	case "0", "false", "f", "no", "n", "incorrect", "wrong", "fail":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid correct value %q", s)
+}
+
+// parseTime parses a timestamp in any of the accepted layouts. Timestamps
+// without a zone are taken as local time.
+func parseTime(s string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q", s)
+}
+
+// isBlank reports whether every field in the record is empty.
+func isBlank(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
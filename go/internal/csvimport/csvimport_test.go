@@ -0,0 +1,180 @@
+package csvimport
+
+import (
+	"blackjack_trainer/internal/hand"
+	"blackjack_trainer/internal/history"
+	"blackjack_trainer/internal/strategy"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2024, 3, 6, 18, 0, 0, 0, time.Local)
+
+// Test per-attempt rows with timestamps are grouped into daily sessions
+func TestReadAttempts(t *testing.T) {
+	input := `Timestamp,Cards,Dealer,Action,Correct,Latency_MS
+2024-03-01 10:00:00,10 6,10,S,false,1500
+2024-03-01 10:05:00,A-7,9,H,true,900
+2024-03-02 09:00:00,"8,8",A,split,yes,
+`
+	sessions, err := Read(strings.NewReader(input), strategy.New(), now)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("Expected 2 daily sessions, got %d", len(sessions))
+	}
+
+	first := sessions[0]
+	if first.Mode != Mode || first.Total != 2 || first.Correct != 1 {
+		t.Errorf("Unexpected first session: %+v", first)
+	}
+	if first.Duration() != 5*time.Minute {
+		t.Errorf("First session should span 5m, got %s", first.Duration())
+	}
+
+	attempt := first.Attempts[0]
+	if attempt.HandType != "hard" || attempt.Action != "S" || attempt.CorrectAction != "H" || attempt.LatencyMs != 1500 {
+		t.Errorf("Unexpected first attempt: %+v", attempt)
+	}
+	if soft := first.Attempts[1]; soft.HandType != "soft" || soft.Cards[0] != hand.Ace {
+		t.Errorf("A-7 should import as a soft hand, got %+v", soft)
+	}
+	if pair := sessions[1].Attempts[0]; pair.HandType != "pair" || pair.Action != "Y" || pair.DealerCard != hand.Ace {
+		t.Errorf("Unexpected pair attempt: %+v", pair)
+	}
+}
+
+// Test correctness is derived from the chart when only the action is given
+func TestReadDerivesCorrectness(t *testing.T) {
+	input := "dealer,hand,answer\n6,10 2,S\n10,10 6,S\n"
+	sessions, err := Read(strings.NewReader(input), strategy.New(), now)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(sessions) != 1 || !sessions[0].Started.Equal(now) {
+		t.Fatalf("Rows without timestamps should form one session dated as given, got %+v", sessions)
+	}
+	if s := sessions[0]; s.Total != 2 || s.Correct != 1 || !s.Attempts[0].Correct {
+		t.Errorf("Expected only 12 vs 6 standing to be correct, got %+v", s)
+	}
+}
+
+// Test importing the same file twice, with or without timestamps, adds its
+// sessions only once
+func TestReimport(t *testing.T) {
+	inputs := []string{
+		"dealer,hand,answer\n6,10 2,S\n10,10 6,S\n",
+		"date,dealer,hand,answer\n2024-03-01,6,10 2,S\n2024-03-02,10,10 6,S\n",
+	}
+	for _, input := range inputs {
+		h := history.New()
+		for i := 1; i <= 2; i++ {
+			sessions, err := Read(strings.NewReader(input), strategy.New(), now)
+			if err != nil {
+				t.Fatalf("Read failed: %v", err)
+			}
+			imported := history.New()
+			for _, s := range sessions {
+				imported.Add(s)
+			}
+			want := len(sessions)
+			if i == 2 {
+				want = 0
+			}
+			if added := h.Merge(imported); added != want {
+				t.Errorf("Import %d of %q added %d session(s), want %d", i, input, added, want)
+			}
+		}
+	}
+}
+
+// Test aggregate per-cell rows expand into individual attempts
+func TestReadAggregateCells(t *testing.T) {
+	input := `date,hand_type,total,dealer,attempts,correct
+2024-03-01,hard,16,10,4,3
+2024-03-01,soft,18,9,2,0
+2024-03-01,pair,A,6,1,1
+2024-03-01,hard,20,6,1,1
+`
+	sessions, err := Read(strings.NewReader(input), strategy.New(), now)
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if len(sessions) != 1 {
+		t.Fatalf("Expected 1 session, got %d", len(sessions))
+	}
+
+	s := sessions[0]
+	if s.Total != 8 || s.Correct != 5 {
+		t.Errorf("Expected 5/8, got %d/%d", s.Correct, s.Total)
+	}
+
+	for _, attempt := range s.Attempts {
+		handType, value := strategy.Classify(hand.New(attempt.Cards...))
+		if handType.String() != attempt.HandType {
+			t.Errorf("Cards %v classify as %s, recorded as %s", attempt.Cards, handType, attempt.HandType)
+		}
+		if attempt.HandType == "hard" && value != 16 && value != 20 {
+			t.Errorf("Unexpected hard total %d for %v", value, attempt.Cards)
+		}
+	}
+}
+
+// Test representative cards for every chart cell classify back to that cell
+func TestCellCards(t *testing.T) {
+	ranges := []struct {
+		handType  string
+		low, high int
+	}{
+		{"hard", 5, 21},
+		{"soft", 13, 21},
+		{"pair", 2, 11},
+	}
+	for _, r := range ranges {
+		for total := r.low; total <= r.high; total++ {
+			value := strconv.Itoa(total)
+			if r.handType == "pair" {
+				value = strategy.CardToString(total)
+			}
+			cards, err := cellCards(r.handType, value)
+			if err != nil {
+				t.Errorf("%s %d: %v", r.handType, total, err)
+				continue
+			}
+			handType, classified := strategy.Classify(hand.New(cards...))
+			if handType.String() != r.handType || classified != total {
+				t.Errorf("%s %d: cards %v classify as %s %d", r.handType, total, cards, handType, classified)
+			}
+		}
+	}
+}
+
+// Test malformed input is rejected with the line number
+func TestReadErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"empty", "", "empty file"},
+		{"no dealer", "cards,correct\n10 6,1\n", "missing dealer"},
+		{"no hand", "dealer,correct\n10,1\n", "missing cards"},
+		{"no answer", "dealer,cards\n10,10 6\n", "missing action or correct"},
+		{"bad card", "dealer,cards,correct\n10,10 6,1\nX,10 6,1\n", "line 3"},
+		{"bad action", "dealer,cards,action\n10,10 6,fold\n", "invalid action"},
+		{"busted", "dealer,cards,correct\n10,10 6 9,1\n", "busted"},
+		{"too many attempts", "dealer,cards,attempts,correct\n10,10 6,2,1\n10,10 6,2000000000,1\n", "line 3: attempts 2000000000 is more than 10000 per row"},
+		{"too many correct", "dealer,cards,attempts,correct\n10,10 6,2,3\n", "invalid correct count"},
+		{"bad soft total", "dealer,hand_type,total,correct\n10,soft,12,1\n", "invalid soft total"},
+	}
+
+	for _, test := range tests {
+		_, err := Read(strings.NewReader(test.input), strategy.New(), now)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: expected error containing %q, got %v", test.name, test.want, err)
+		}
+	}
+}
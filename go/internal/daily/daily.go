@@ -0,0 +1,86 @@
+// Package daily is a micro-mode that asks exactly one question, records it
+// in the practice history like any session, and reports the day streak in a
+// line short enough for a shell prompt or a tmux status bar:
+//
+//	BJ: 14-day streak
+//
+// A status bar can show Status without asking anything, and a key binding
+// or popup can run the question itself.
+package daily
+
+import (
+	"blackjack_trainer/internal/history"
+	"blackjack_trainer/internal/simulate"
+	"blackjack_trainer/internal/strategy"
+	"blackjack_trainer/internal/trainer"
+	"blackjack_trainer/internal/ui"
+	"fmt"
+	"time"
+)
+
+// ModeName is the mode daily questions are recorded under.
+const ModeName = "daily"
+
+// Status returns the one-line streak summary, noting when nothing has been
+// practiced yet today.
+func Status(h *history.History, now time.Time) string {
+	streak := h.DayStreak(now)
+	switch {
+	case streak == 0:
+		return "BJ: no streak"
+	case !PracticedToday(h, now):
+		return fmt.Sprintf("BJ: %d-day streak (due)", streak)
+	default:
+		return fmt.Sprintf("BJ: %d-day streak", streak)
+	}
+}
+
+// PracticedToday reports whether any session was started on now's day.
+func PracticedToday(h *history.History, now time.Time) bool {
+	today := history.StartOfDay(now)
+	for _, s := range h.Sessions {
+		if !s.Started.In(now.Location()).Before(today) {
+			return true
+		}
+	}
+	return false
+}
+
+// Ask asks one question from session, checked against chart, using the ui
+// streams and key bindings. It returns the session record to save, or
+// false if the player quit without answering.
+func Ask(session trainer.TrainingSession, chart *strategy.StrategyChart) (history.Session, bool) {
+	out := ui.Output()
+	scenario := session.GenerateScenario()
+	started := time.Now()
+
+	ui.DisplayHand(scenario.Hand, scenario.DealerCard)
+	action, quit := ui.GetUserAction()
+	if quit {
+		return history.Session{}, false
+	}
+	ended := time.Now()
+
+	correctAction := chart.GetCorrectActionForHand(scenario.Hand, scenario.DealerCard)
+	attempt := trainer.NewAttempt(chart, simulate.NewLossTable(chart, 0), scenario, action, correctAction, ended.Sub(started))
+	if attempt.Correct {
+		fmt.Fprintln(out, "✓ Correct!")
+	} else {
+		fmt.Fprintf(out, "❌ The play is %s: %s\n", strategy.ActionToString(correctAction),
+			chart.GetExplanationForHand(scenario.Hand, scenario.DealerCard))
+	}
+
+	record := history.Session{
+		Mode:     ModeName,
+		Rules:    chart.Rules().Name,
+		Started:  started,
+		Ended:    ended,
+		Total:    1,
+		Attempts: []history.Attempt{attempt},
+		EVLoss:   &attempt.EVLoss,
+	}
+	if attempt.Correct {
+		record.Correct = 1
+	}
+	return record, true
+}
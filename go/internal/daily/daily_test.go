@@ -0,0 +1,90 @@
+package daily
+
+import (
+	"blackjack_trainer/internal/hand"
+	"blackjack_trainer/internal/history"
+	"blackjack_trainer/internal/strategy"
+	"blackjack_trainer/internal/trainer"
+	"blackjack_trainer/internal/ui"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test the status line shows the streak and whether today's question is due
+func TestStatus(t *testing.T) {
+	now := time.Date(2026, 5, 10, 18, 0, 0, 0, time.Local)
+	h := history.New()
+	if got := Status(h, now); got != "BJ: no streak" {
+		t.Errorf("Empty history: got %q", got)
+	}
+	for days := 1; days <= 3; days++ {
+		h.Add(history.Session{Mode: ModeName, Started: now.AddDate(0, 0, -days)})
+	}
+	if got := Status(h, now); got != "BJ: 3-day streak (due)" {
+		t.Errorf("Not practiced today: got %q", got)
+	}
+	if PracticedToday(h, now) {
+		t.Error("Yesterday's session shouldn't count as today")
+	}
+	h.Add(history.Session{Mode: ModeName, Started: now.Add(-time.Hour)})
+	if got := Status(h, now); got != "BJ: 4-day streak" {
+		t.Errorf("Practiced today: got %q", got)
+	}
+}
+
+// fixedSession asks the same scenario every time.
+type fixedSession struct {
+	trainer.RandomTrainingSession
+	scenario trainer.Scenario
+}
+
+func (f *fixedSession) GenerateScenario() trainer.Scenario {
+	return f.scenario
+}
+
+// Test one question is asked and recorded with the answer and rules
+func TestAsk(t *testing.T) {
+	session := &fixedSession{scenario: trainer.Scenario{Hand: hand.New(10, 6), DealerCard: 10}}
+	for _, tt := range []struct {
+		input   string
+		correct bool
+		output  string
+	}{
+		{"h\n", true, "✓ Correct!"},
+		{"s\n", false, "The play is HIT"},
+	} {
+		var out strings.Builder
+		ui.SetIO(strings.NewReader(tt.input), &out)
+		record, ok := Ask(session, strategy.Default())
+		ui.SetIO(os.Stdin, os.Stdout)
+
+		if !ok {
+			t.Fatalf("Input %q: question not answered", tt.input)
+		}
+		if !strings.Contains(out.String(), tt.output) {
+			t.Errorf("Input %q: expected %q in output:\n%s", tt.input, tt.output, out.String())
+		}
+		if record.Mode != ModeName || record.Total != 1 || len(record.Attempts) != 1 || record.Attempts[0].Correct != tt.correct {
+			t.Errorf("Input %q: unexpected record %+v", tt.input, record)
+		}
+		if record.Rules != strategy.Default().Rules().Name || record.Attempts[0].Rules != record.Rules {
+			t.Errorf("Input %q: record should note the rules, got %+v", tt.input, record)
+		}
+		// Standing on 16 vs 10 gives up EV, so the mistake is priced and classed
+		attempt := record.Attempts[0]
+		if record.EVLoss == nil || *record.EVLoss != attempt.EVLoss || (attempt.EVLoss > 0) == tt.correct {
+			t.Errorf("Input %q: mistakes should be priced, got attempt %+v", tt.input, attempt)
+		}
+		if (attempt.Severity != "") == tt.correct {
+			t.Errorf("Input %q: got severity %q", tt.input, attempt.Severity)
+		}
+	}
+
+	ui.SetIO(strings.NewReader("q\n"), &strings.Builder{})
+	defer ui.SetIO(os.Stdin, os.Stdout)
+	if _, ok := Ask(session, strategy.Default()); ok {
+		t.Error("Quitting should record nothing")
+	}
+}
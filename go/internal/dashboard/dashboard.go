@@ -0,0 +1,462 @@
+// Package dashboard renders a full-screen terminal view of a player's
+// statistics, combining in one screen what the stats, report and cheatsheet
+// commands show separately:
+// - A heatmap of accuracy on every chart cell, under the chart's rules
+// - A sparkline of accuracy over the recent sessions
+// - Bars of accuracy by hand type, dealer strength and correct action
+// - The most recent sessions
+// - Recommendations of what to practice next
+//
+// The dashboard is built from a history, so it can be redrawn as the
+// history file changes, such as a student's while the server runs.
+package dashboard
+
+import (
+	"blackjack_trainer/internal/history"
+	"blackjack_trainer/internal/stats"
+	"blackjack_trainer/internal/strategy"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TrendSessions is the number of recent sessions the sparkline shows.
+const TrendSessions = 30
+
+// RecentSessions is the number of sessions listed.
+const RecentSessions = 5
+
+// Accuracy thresholds for the heatmap's colors: good at or above Good,
+// fair at or above Fair, and poor below.
+const (
+	Good = 90.0
+	Fair = 60.0
+)
+
+// Dashboard is the content of the dashboard at one moment.
+type Dashboard struct {
+	// Title names whose statistics are shown, e.g. a server user; empty
+	// for the local player.
+	Title     string
+	Generated time.Time
+	Rules     string
+	Sessions  int
+	Questions int
+	Accuracy  float64
+	DayStreak int
+	Mastered  int
+	Cells     int
+	Heatmap   []Section
+	// Trend holds the accuracy of the recent sessions, oldest first.
+	Trend           []float64
+	Bars            []BarGroup
+	Recent          []history.Session
+	Recommendations []string
+}
+
+// Section is one part of the chart's heatmap.
+type Section struct {
+	Title string
+	Rows  []Row
+}
+
+// Row is a chart row of the heatmap.
+type Row struct {
+	Label string
+	Cells []Cell
+}
+
+// Cell is a chart cell with the chart's play and the answers given to it.
+type Cell struct {
+	Action rune
+	stats.CategoryData
+}
+
+// BarGroup is a set of accuracy bars, e.g. by hand type.
+type BarGroup struct {
+	Title string
+	Bars  []Bar
+}
+
+// Bar is the accuracy for one category.
+type Bar struct {
+	Label string
+	stats.CategoryData
+}
+
+// Build gathers the dashboard for a history as of now.
+func Build(h *history.History, chart *strategy.StrategyChart, now time.Time) Dashboard {
+	attempts := h.Attempts()
+	accuracy, questions := h.Accuracy()
+	mastery := stats.ComputeMastery(h, now)
+	d := Dashboard{
+		Generated: now,
+		Rules:     chart.Rules().Name,
+		Sessions:  len(h.Sessions),
+		Questions: questions,
+		Accuracy:  accuracy,
+		DayStreak: h.DayStreak(now),
+		Mastered:  mastery.Mastered(),
+		Cells:     len(stats.ChartCells()),
+	}
+
+	cells := stats.ByCell(stats.UnderRules(attempts, d.Rules))
+	d.Heatmap = []Section{
+		buildSection("Hard", strategy.HandTypeHard, 5, 21, cells, chart),
+		buildSection("Soft", strategy.HandTypeSoft, 13, 21, cells, chart),
+		buildSection("Pairs", strategy.HandTypePair, 2, 11, cells, chart),
+	}
+
+	for _, s := range h.Sessions {
+		if s.Total > 0 {
+			d.Trend = append(d.Trend, float64(s.Correct)/float64(s.Total)*100)
+		}
+	}
+	if len(d.Trend) > TrendSessions {
+		d.Trend = d.Trend[len(d.Trend)-TrendSessions:]
+	}
+
+	byHandType, byDealerStrength := stats.Tally(attempts)
+	d.Bars = []BarGroup{
+		barGroup("Hand type", []string{"hard", "soft", "pair"}, byHandType),
+		barGroup("Dealer", []string{"weak", "medium", "strong"}, byDealerStrength),
+		barGroup("Play", stats.ActionKeys[:4], stats.TallyActions(attempts)),
+	}
+
+	for i := len(h.Sessions) - 1; i >= 0 && len(d.Recent) < RecentSessions; i-- {
+		d.Recent = append(d.Recent, h.Sessions[i])
+	}
+	d.Recommendations = recommend(h, d, cells, mastery, now)
+	return d
+}
+
+// buildSection builds the heatmap rows for one section of the chart.
+func buildSection(title string, handType strategy.HandType, low, high int,
+	cells map[stats.CellKey]*stats.CategoryData, chart *strategy.StrategyChart) Section {
+	section := Section{Title: title}
+	for total := low; total <= high; total++ {
+		row := Row{Label: rowLabel(handType, total)}
+		for dealer := 2; dealer <= 11; dealer++ {
+			key := stats.CellKey{HandType: handType, PlayerTotal: total, DealerCard: dealer}
+			cell := Cell{Action: chart.GetCorrectAction(handType, total, dealer)}
+			if data := cells[key]; data != nil {
+				cell.CategoryData = *data
+			}
+			row.Cells = append(row.Cells, cell)
+		}
+		section.Rows = append(section.Rows, row)
+	}
+	return section
+}
+
+// rowLabel returns the heading of a chart row.
+func rowLabel(handType strategy.HandType, total int) string {
+	switch handType {
+	case strategy.HandTypePair:
+		card := strategy.CardToString(total)
+		return card + "," + card
+	case strategy.HandTypeSoft:
+		return fmt.Sprintf("A,%d", total-11)
+	default:
+		return fmt.Sprintf("%d", total)
+	}
+}
+
+// barGroup returns the bars for keys, in order.
+func barGroup(title string, keys []string, tallies map[string]*stats.CategoryData) BarGroup {
+	group := BarGroup{Title: title}
+	for _, key := range keys {
+		group.Bars = append(group.Bars, Bar{Label: key, CategoryData: *tallies[key]})
+	}
+	return group
+}
+
+// recommend suggests what to practice next, most useful first.
+func recommend(h *history.History, d Dashboard, cells map[stats.CellKey]*stats.CategoryData,
+	mastery stats.Mastery, now time.Time) []string {
+	if d.Questions == 0 {
+		return []string{"Start practicing: blackjack_trainer"}
+	}
+	var recs []string
+
+	if due := mastery.Due(now); len(due) > 0 {
+		recs = append(recs, fmt.Sprintf("%d cell(s) due for review, such as %s: blackjack_trainer -session random -review 25",
+			len(due), due[0].Label()))
+	}
+
+	var weak []stats.CellKey
+	for key, data := range cells {
+		if data.Total >= 3 && data.Accuracy() < Fair {
+			weak = append(weak, key)
+		}
+	}
+	sort.Slice(weak, func(i, j int) bool {
+		a, b := cells[weak[i]], cells[weak[j]]
+		if a.Accuracy() != b.Accuracy() {
+			return a.Accuracy() < b.Accuracy()
+		}
+		return weak[i].Label() < weak[j].Label()
+	})
+	if len(weak) > 0 {
+		var labels []string
+		for _, key := range weak[:min(len(weak), 3)] {
+			labels = append(labels, fmt.Sprintf("%s (%.0f%%)", key.Label(), cells[key].Accuracy()))
+		}
+		recs = append(recs, "Weakest cells: "+strings.Join(labels, ", ")+"; print them: blackjack_trainer cheatsheet")
+	}
+
+	commands := map[string]string{"Hand type": "-session hand", "Dealer": "-session dealer"}
+	for _, group := range d.Bars[:2] {
+		weakest := -1
+		for i, bar := range group.Bars {
+			if bar.Total >= 10 && bar.Accuracy() < Good && (weakest < 0 || bar.Accuracy() < group.Bars[weakest].Accuracy()) {
+				weakest = i
+			}
+		}
+		if weakest >= 0 {
+			bar := group.Bars[weakest]
+			recs = append(recs, fmt.Sprintf("%s %s is your weakest (%.0f%%): blackjack_trainer %s",
+				group.Title, bar.Label, bar.Accuracy(), commands[group.Title]))
+		}
+	}
+
+	if n := len(d.Trend); n >= 10 {
+		recent, before := mean(d.Trend[n-5:]), mean(d.Trend[n-10:n-5])
+		if recent <= before-5 {
+			recs = append(recs, fmt.Sprintf("Accuracy is down %.0f points over the last 5 sessions; slow down and read the explanations",
+				before-recent))
+		}
+	}
+
+	if last := h.Sessions[len(h.Sessions)-1].Ended; d.DayStreak > 0 && !sameDay(last, now) {
+		recs = append(recs, fmt.Sprintf("Practice today to keep your %d-day streak", d.DayStreak))
+	}
+	if len(recs) == 0 {
+		recs = append(recs, "No weak spots found; try an exam: blackjack_trainer -session exam")
+	}
+	return recs
+}
+
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.Local().Format("2006-01-02") == b.Local().Format("2006-01-02")
+}
+
+// Sparkline draws values from 0 to 100 as a line of block characters, one
+// per value.
+func Sparkline(values []float64) string {
+	const blocks = "▁▂▃▄▅▆▇█"
+	levels := []rune(blocks)
+	var b strings.Builder
+	for _, v := range values {
+		i := int(v/100*float64(len(levels)-1) + 0.5)
+		i = max(0, min(i, len(levels)-1))
+		b.WriteRune(levels[i])
+	}
+	return b.String()
+}
+
+// ANSI escape sequences for styled output.
+const (
+	clearScreen = "\x1b[H\x1b[2J"
+	bold        = "\x1b[1m"
+	dim         = "\x1b[2m"
+	reset       = "\x1b[0m"
+	goodColor   = "\x1b[30;42m" // black on green
+	fairColor   = "\x1b[30;43m" // black on yellow
+	poorColor   = "\x1b[97;41m" // white on red
+)
+
+// Options controls how the dashboard is drawn.
+type Options struct {
+	// Styled draws with ANSI colors and clears the screen first, for a
+	// terminal. Otherwise marks after each play show accuracy.
+	Styled bool
+	// Footer is a line drawn at the bottom, e.g. how to quit.
+	Footer string
+}
+
+// Render draws the dashboard.
+func (d Dashboard) Render(w io.Writer, opts Options) {
+	p := &printer{w: w, styled: opts.Styled}
+	if opts.Styled {
+		fmt.Fprint(w, clearScreen)
+	}
+
+	title := "Blackjack Trainer Dashboard"
+	if d.Title != "" {
+		title += ": " + d.Title
+	}
+	p.heading(title)
+	fmt.Fprintf(w, "%d session(s), %d question(s), %.1f%% correct, %d-day streak, %d of %d cells mastered\n",
+		d.Sessions, d.Questions, d.Accuracy, d.DayStreak, d.Mastered, d.Cells)
+
+	p.heading("\nAccuracy by Cell (" + d.Rules + ")")
+	p.heatmap(d.Heatmap)
+
+	p.heading("\nTrend")
+	if len(d.Trend) == 0 {
+		fmt.Fprintln(w, "No sessions yet.")
+	} else {
+		latest := d.Trend[len(d.Trend)-1]
+		low, high := latest, latest
+		for _, v := range d.Trend {
+			low, high = min(low, v), max(high, v)
+		}
+		fmt.Fprintf(w, "%s  last %d: latest %.0f%%, best %.0f%%, worst %.0f%%\n",
+			Sparkline(d.Trend), len(d.Trend), latest, high, low)
+	}
+
+	p.heading("\nBy Category")
+	for _, group := range d.Bars {
+		for i, bar := range group.Bars {
+			label := ""
+			if i == 0 {
+				label = group.Title
+			}
+			fmt.Fprintf(w, "%-10s %-7s %s\n", label, bar.Label, p.bar(bar.CategoryData))
+		}
+	}
+
+	p.heading("\nRecent Sessions")
+	if len(d.Recent) == 0 {
+		fmt.Fprintln(w, "None yet.")
+	}
+	for _, s := range d.Recent {
+		accuracy := 0.0
+		if s.Total > 0 {
+			accuracy = float64(s.Correct) / float64(s.Total) * 100
+		}
+		fmt.Fprintf(w, "%s  %-12s %3d/%-3d %5.1f%%\n", s.Started.Local().Format("2006-01-02 15:04"), s.Mode, s.Correct, s.Total, accuracy)
+	}
+
+	p.heading("\nRecommendations")
+	for _, rec := range d.Recommendations {
+		fmt.Fprintf(w, "- %s\n", rec)
+	}
+
+	if opts.Footer != "" {
+		fmt.Fprintf(w, "\n%s\n", p.style(dim, opts.Footer))
+	}
+}
+
+// printer draws the panels of the dashboard.
+type printer struct {
+	w      io.Writer
+	styled bool
+}
+
+// style wraps text in an escape sequence on a terminal.
+func (p *printer) style(code, text string) string {
+	if !p.styled {
+		return text
+	}
+	return code + text + reset
+}
+
+func (p *printer) heading(text string) {
+	lead := strings.TrimLeft(text, "\n")
+	fmt.Fprintln(p.w, text[:len(text)-len(lead)]+p.style(bold, lead))
+}
+
+// heatmap draws the hard totals beside the soft totals and pairs.
+func (p *printer) heatmap(sections []Section) {
+	if p.styled {
+		fmt.Fprintf(p.w, "%s %.0f%%+  %s %.0f-%.0f%%  %s under %.0f%%  %s not practiced\n",
+			p.style(goodColor, "   "), Good, p.style(fairColor, "   "), Fair, Good-1,
+			p.style(poorColor, "   "), Fair, p.style(dim, " H "))
+	} else {
+		fmt.Fprintf(p.w, "+ %.0f%%+   ~ %.0f-%.0f%%   ! under %.0f%%   (no mark) not practiced\n", Good, Fair, Good-1, Fair)
+	}
+
+	left := p.sectionLines(sections[0])
+	var right []string
+	for i, section := range sections[1:] {
+		if i > 0 {
+			right = append(right, "")
+		}
+		right = append(right, p.sectionLines(section)...)
+	}
+	// Every section line is the same width on screen, whatever its styling
+	width := 5 + 3*10
+	for i := 0; i < max(len(left), len(right)); i++ {
+		line := strings.Repeat(" ", width)
+		if i < len(left) {
+			line = left[i]
+		}
+		if i < len(right) {
+			line += "   " + right[i]
+		}
+		fmt.Fprintln(p.w, strings.TrimRight(line, " "))
+	}
+}
+
+// sectionLines returns the lines of one heatmap section, each 35 columns
+// wide on screen.
+func (p *printer) sectionLines(section Section) []string {
+	lines := []string{fmt.Sprintf("%-35s", section.Title)}
+	header := fmt.Sprintf("%-5s", "")
+	for dealer := 2; dealer <= 11; dealer++ {
+		header += fmt.Sprintf("%2s ", strategy.CardToString(dealer))
+	}
+	lines = append(lines, header)
+	for _, row := range section.Rows {
+		line := fmt.Sprintf("%-5s", row.Label)
+		for _, cell := range row.Cells {
+			line += p.cell(cell)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// cell draws a heatmap cell three columns wide: the chart's play, colored
+// or marked by accuracy.
+func (p *printer) cell(c Cell) string {
+	play := string(c.Action)
+	if c.Total == 0 {
+		if p.styled {
+			return p.style(dim, " "+play+" ")
+		}
+		return " " + play + " "
+	}
+	accuracy := c.Accuracy()
+	if p.styled {
+		color := poorColor
+		switch {
+		case accuracy >= Good:
+			color = goodColor
+		case accuracy >= Fair:
+			color = fairColor
+		}
+		return p.style(color, " "+play+" ")
+	}
+	mark := "!"
+	switch {
+	case accuracy >= Good:
+		mark = "+"
+	case accuracy >= Fair:
+		mark = "~"
+	}
+	return " " + play + mark
+}
+
+// bar draws an accuracy bar 20 characters wide with the figures after it.
+func (p *printer) bar(data stats.CategoryData) string {
+	const width = 20
+	if data.Total == 0 {
+		return p.style(dim, strings.Repeat("░", width)) + "    -"
+	}
+	filled := int(data.Accuracy()/100*width + 0.5)
+	return strings.Repeat("█", filled) + p.style(dim, strings.Repeat("░", width-filled)) +
+		fmt.Sprintf(" %5.1f%% of %d", data.Accuracy(), data.Total)
+}
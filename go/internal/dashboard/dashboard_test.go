@@ -0,0 +1,117 @@
+package dashboard
+
+import (
+	"blackjack_trainer/internal/history"
+	"blackjack_trainer/internal/strategy"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// practiced returns a history of twelve sessions a day apart, ending the
+// day before now: hard 16 vs 10 always missed, hard 12 vs 4 always right,
+// and accuracy falling over the last five sessions
+func practiced(now time.Time) *history.History {
+	h := history.New()
+	for i := 0; i < 12; i++ {
+		ended := now.AddDate(0, 0, i-12)
+		attempts := []history.Attempt{
+			{Cards: []int{10, 6}, DealerCard: 10, HandType: "hard", Correct: false},
+			{Cards: []int{10, 2}, DealerCard: 4, HandType: "hard", Correct: true},
+			{Cards: []int{10, 2}, DealerCard: 4, HandType: "hard", Correct: true},
+			{Cards: []int{8, 8}, DealerCard: 6, HandType: "pair", Correct: i < 7},
+		}
+		s := history.Session{Mode: "random", Rules: "Standard", Started: ended.Add(-5 * time.Minute), Ended: ended, Attempts: attempts}
+		for _, a := range attempts {
+			s.Total++
+			if a.Correct {
+				s.Correct++
+			}
+		}
+		h.Add(s)
+	}
+	return h
+}
+
+// Test the panels are filled from the history and the recommendations
+// point at its weak spots
+func TestBuild(t *testing.T) {
+	now := time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)
+	d := Build(practiced(now), strategy.New(), now)
+
+	if d.Sessions != 12 || d.Questions != 48 || d.Rules != "Standard" {
+		t.Errorf("Summary = %d sessions, %d questions, %q rules", d.Sessions, d.Questions, d.Rules)
+	}
+	if len(d.Heatmap) != 3 || len(d.Heatmap[0].Rows) != 17 || len(d.Heatmap[1].Rows) != 9 || len(d.Heatmap[2].Rows) != 10 {
+		t.Fatalf("Heatmap sections = %+v", d.Heatmap)
+	}
+	// Hard 16 is the twelfth hard row, and dealer 10 the ninth column
+	if c := d.Heatmap[0].Rows[11].Cells[8]; c.Action != 'H' || c.Correct != 0 || c.Total != 12 {
+		t.Errorf("Hard 16 vs 10 = %+v, want H 0/12", c)
+	}
+	if c := d.Heatmap[0].Rows[0].Cells[0]; c.Total != 0 {
+		t.Errorf("Hard 5 vs 2 = %+v, want unpracticed", c)
+	}
+	if len(d.Trend) != 12 || d.Trend[0] != 75 || d.Trend[11] != 50 {
+		t.Errorf("Trend = %v", d.Trend)
+	}
+	if len(d.Recent) != RecentSessions || !d.Recent[0].Ended.Equal(now.AddDate(0, 0, -1)) {
+		t.Errorf("Recent sessions should be the last %d, newest first: %+v", RecentSessions, d.Recent)
+	}
+
+	recs := strings.Join(d.Recommendations, "\n")
+	for _, want := range []string{"Weakest cells: Hard 16 vs 10 (0%)", "Hand type pair is your weakest (58%)", "Dealer strong",
+		"Accuracy is down", "keep your 12-day streak"} {
+		if !strings.Contains(recs, want) {
+			t.Errorf("Recommendations missing %q:\n%s", want, recs)
+		}
+	}
+
+	empty := Build(history.New(), strategy.New(), now)
+	if len(empty.Trend) != 0 || len(empty.Recommendations) != 1 || !strings.HasPrefix(empty.Recommendations[0], "Start practicing") {
+		t.Errorf("Empty dashboard = %+v", empty)
+	}
+}
+
+// Test plain output marks accuracy beside each play and styled output
+// colors it instead
+func TestRender(t *testing.T) {
+	now := time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)
+	d := Build(practiced(now), strategy.New(), now)
+	d.Title = "alice"
+
+	var plain bytes.Buffer
+	d.Render(&plain, Options{Footer: "q quits"})
+	lines := strings.Split(plain.String(), "\n")
+	var hard16 string
+	for _, line := range lines {
+		if strings.HasPrefix(line, "16 ") {
+			hard16 = line
+		}
+	}
+	if !strings.Contains(hard16, " H! ") || strings.Contains(plain.String(), "\x1b[") {
+		t.Errorf("Plain hard 16 row = %q, want H! vs 10 and no escapes", hard16)
+	}
+	for _, want := range []string{"Dashboard: alice", "12 session(s), 48 question(s)", " S+ ", "8,8", " Y! ",
+		"Trend\n▆▆▆▆▆▆▆▅▅▅▅▅  last 12", "Hand type  hard", "random", "q quits"} {
+		if !strings.Contains(plain.String(), want) {
+			t.Errorf("Plain dashboard missing %q:\n%s", want, plain.String())
+		}
+	}
+
+	var styled bytes.Buffer
+	d.Render(&styled, Options{Styled: true})
+	for _, want := range []string{clearScreen, poorColor + " H " + reset, goodColor + " S " + reset} {
+		if !strings.Contains(styled.String(), want) {
+			t.Errorf("Styled dashboard missing %q", want)
+		}
+	}
+}
+
+// Test the sparkline scales 0-100 to the block heights
+func TestSparkline(t *testing.T) {
+	if got := Sparkline([]float64{0, 50, 100, 150, -10}); got != "▁▅██▁" {
+		t.Errorf("Sparkline = %q", got)
+	}
+}
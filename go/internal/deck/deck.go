@@ -0,0 +1,115 @@
+// Package deck provides a multi-deck shoe simulation for dealing cards.
+//
+// The shoe holds one or more standard 52-card decks using the card values
+// shared by the rest of the program:
+// - 2-9: Four of each per deck
+// - 10: Sixteen per deck (10, J, Q, K)
+// - 11: Four aces per deck
+//
+// Cards are dealt until the cut card is reached (controlled by penetration),
+// at which point the shoe reports that it needs a shuffle. Shuffling uses a
+// seeded random source so a shoe can be reproduced exactly from its seed.
+package deck
+
+import (
+	"math/rand"
+)
+
+// DefaultPenetration is the fraction of the shoe dealt before reshuffling.
+const DefaultPenetration = 0.75
+
+// Shoe represents a shuffled stack of one or more decks.
+type Shoe struct {
+	decks       int
+	penetration float64
+	cards       []int
+	next        int
+	rng         *rand.Rand
+}
+
+// NewShoe creates a shuffled shoe with the given number of decks.
+//
+// A deck count below 1 is treated as 1, and a penetration outside (0, 1]
+// uses DefaultPenetration. The seed determines the shuffle order.
+func NewShoe(decks int, penetration float64, seed int64) *Shoe {
+	return NewShoeFromSource(decks, penetration, rand.NewSource(seed))
+}
+
+// NewShoeFromSource creates a shuffled shoe like NewShoe, shuffling with
+// numbers from src instead of a seed.
+func NewShoeFromSource(decks int, penetration float64, src rand.Source) *Shoe {
+	if decks < 1 {
+		decks = 1
+	}
+	if penetration <= 0 || penetration > 1 {
+		penetration = DefaultPenetration
+	}
+
+	shoe := &Shoe{
+		decks:       decks,
+		penetration: penetration,
+		cards:       make([]int, 0, decks*52),
+		rng:         rand.New(src),
+	}
+
+	for d := 0; d < decks; d++ {
+		for suit := 0; suit < 4; suit++ {
+			for card := 2; card <= 9; card++ {
+				shoe.cards = append(shoe.cards, card)
+			}
+			shoe.cards = append(shoe.cards, 10, 10, 10, 10) // 10, J, Q, K
+			shoe.cards = append(shoe.cards, 11)             // Ace
+		}
+	}
+
+	shoe.Shuffle()
+	return shoe
+}
+
+// Shuffle returns all cards to the shoe and shuffles them.
+func (s *Shoe) Shuffle() {
+	s.rng.Shuffle(len(s.cards), func(i, j int) {
+		s.cards[i], s.cards[j] = s.cards[j], s.cards[i]
+	})
+	s.next = 0
+}
+
+// Deal deals the next card, shuffling first if the shoe is exhausted.
+func (s *Shoe) Deal() int {
+	if s.next >= len(s.cards) {
+		s.Shuffle()
+	}
+	card := s.cards[s.next]
+	s.next++
+	return card
+}
+
+// NeedsShuffle reports whether the cut card has been reached.
+func (s *Shoe) NeedsShuffle() bool {
+	return float64(s.next) >= float64(len(s.cards))*s.penetration
+}
+
+// Remaining returns the number of undealt cards in the shoe.
+func (s *Shoe) Remaining() int {
+	return len(s.cards) - s.next
+}
+
+// Counts returns the undealt cards by value: counts[c] is the number of
+// cards of value c left, 2-11 with 11 for aces.
+func (s *Shoe) Counts() [12]int {
+	var counts [12]int
+	for _, card := range s.cards[s.next:] {
+		counts[card]++
+	}
+	return counts
+}
+
+// Decks returns the number of decks in the shoe.
+func (s *Shoe) Decks() int {
+	return s.decks
+}
+
+// Size returns the total number of cards in the shoe.
+func (s *Shoe) Size() int {
+	return len(s.cards)
+}
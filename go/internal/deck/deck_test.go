@@ -0,0 +1,125 @@
+package deck
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Test a new shoe contains the correct composition of cards
+func TestShoeComposition(t *testing.T) {
+	for _, decks := range []int{1, 2, 6, 8} {
+		shoe := NewShoe(decks, 1.0, 1)
+
+		if shoe.Size() != decks*52 {
+			t.Errorf("%d decks: expected %d cards, got %d", decks, decks*52, shoe.Size())
+		}
+
+		counts := make(map[int]int)
+		for i := 0; i < shoe.Size(); i++ {
+			counts[shoe.Deal()]++
+		}
+
+		for card := 2; card <= 9; card++ {
+			if counts[card] != 4*decks {
+				t.Errorf("%d decks: expected %d of card %d, got %d", decks, 4*decks, card, counts[card])
+			}
+		}
+		if counts[10] != 16*decks {
+			t.Errorf("%d decks: expected %d tens, got %d", decks, 16*decks, counts[10])
+		}
+		if counts[11] != 4*decks {
+			t.Errorf("%d decks: expected %d aces, got %d", decks, 4*decks, counts[11])
+		}
+	}
+}
+
+// Test the counts of undealt cards drop as cards are dealt
+func TestShoeCounts(t *testing.T) {
+	shoe := NewShoe(1, 1.0, 1)
+	counts := shoe.Counts()
+	if counts[10] != 16 || counts[11] != 4 || counts[2] != 4 {
+		t.Fatalf("Fresh deck counts = %v", counts)
+	}
+	card := shoe.Deal()
+	after := shoe.Counts()
+	if after[card] != counts[card]-1 {
+		t.Errorf("After dealing %d, %d left, want %d", card, after[card], counts[card]-1)
+	}
+	total := 0
+	for _, n := range after {
+		total += n
+	}
+	if total != shoe.Remaining() {
+		t.Errorf("Counts add up to %d, want %d remaining", total, shoe.Remaining())
+	}
+}
+
+// Test shoes with the same seed deal identical sequences
+func TestShoeReproducible(t *testing.T) {
+	first := NewShoe(6, DefaultPenetration, 42)
+	second := NewShoe(6, DefaultPenetration, 42)
+	other := NewShoe(6, DefaultPenetration, 43)
+
+	var a, b, c []int
+	for i := 0; i < 100; i++ {
+		a = append(a, first.Deal())
+		b = append(b, second.Deal())
+		c = append(c, other.Deal())
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		t.Error("Shoes with the same seed should deal the same cards")
+	}
+	if reflect.DeepEqual(a, c) {
+		t.Error("Shoes with different seeds should deal different cards")
+	}
+}
+
+// Test penetration triggers the reshuffle indicator
+func TestShoePenetration(t *testing.T) {
+	shoe := NewShoe(1, 0.5, 1)
+
+	for i := 0; i < 25; i++ {
+		shoe.Deal()
+	}
+	if shoe.NeedsShuffle() {
+		t.Error("Shoe should not need shuffle before reaching penetration")
+	}
+
+	shoe.Deal()
+	if !shoe.NeedsShuffle() {
+		t.Error("Shoe should need shuffle after dealing half of one deck")
+	}
+	if shoe.Remaining() != 26 {
+		t.Errorf("Expected 26 remaining cards, got %d", shoe.Remaining())
+	}
+
+	shoe.Shuffle()
+	if shoe.NeedsShuffle() || shoe.Remaining() != 52 {
+		t.Errorf("Shuffle should restore the full shoe, got %d remaining", shoe.Remaining())
+	}
+}
+
+// Test dealing past the end of the shoe reshuffles automatically
+func TestShoeDealsPastEnd(t *testing.T) {
+	shoe := NewShoe(1, 1.0, 7)
+	for i := 0; i < 60; i++ {
+		if card := shoe.Deal(); card < 2 || card > 11 {
+			t.Fatalf("Invalid card dealt: %d", card)
+		}
+	}
+	if shoe.Remaining() != 44 {
+		t.Errorf("Expected 44 remaining after reshuffle, got %d", shoe.Remaining())
+	}
+}
+
+// Test invalid construction parameters fall back to defaults
+func TestShoeDefaults(t *testing.T) {
+	shoe := NewShoe(0, 2.0, 1)
+	if shoe.Decks() != 1 {
+		t.Errorf("Deck count below 1 should be treated as 1, got %d", shoe.Decks())
+	}
+	if shoe.penetration != DefaultPenetration {
+		t.Errorf("Invalid penetration should use default, got %f", shoe.penetration)
+	}
+}
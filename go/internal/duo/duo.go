@@ -0,0 +1,294 @@
+// Package duo is a cooperative mode for two players at one terminal, in the
+// way pair programmers share a keyboard. Each player answers every question
+// on their own, without seeing the other's answer. When they agree the
+// answer is revealed; when they don't, they are given prompts to talk it
+// through and must settle on one answer first. Every player's own answers
+// are scored as well as the team's, so each can see how they do alone and
+// who tends to be right when the pair disagrees.
+//
+// Results are stored as JSON in duo.json beside the practice history, apart
+// from it, since a team's answers aren't either player's own.
+package duo
+
+import (
+	"blackjack_trainer/internal/atomicfile"
+	"blackjack_trainer/internal/strategy"
+	"blackjack_trainer/internal/trainer"
+	"blackjack_trainer/internal/ui"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FileName is the name of the duo results file in the trainer's directory.
+const FileName = "duo.json"
+
+// DefaultQuestions is the length of a duo session unless set otherwise.
+const DefaultQuestions = 20
+
+// Result is the outcome of one duo session.
+type Result struct {
+	Played time.Time `json:"played"`
+	// Rules is the name of the rule set the session was played under.
+	Rules   string         `json:"rules"`
+	Players [2]PlayerScore `json:"players"`
+	// Total is the number of questions answered, TeamCorrect how many of the
+	// team's answers were right, and Disagreements how many questions the
+	// players first answered differently.
+	Total         int `json:"total"`
+	TeamCorrect   int `json:"team_correct"`
+	Disagreements int `json:"disagreements"`
+}
+
+// PlayerScore is one player's own answers in a session.
+type PlayerScore struct {
+	Name    string `json:"name"`
+	Correct int    `json:"correct"`
+	// RightInDisagreements counts the disagreements the player's own answer
+	// was right in.
+	RightInDisagreements int `json:"right_in_disagreements"`
+}
+
+// Percent returns n as a percentage of total, or 0 when total is 0.
+func Percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total) * 100
+}
+
+// Play asks a duo session of the questions the training session generates,
+// checked against chart, using the ui streams and key bindings. Players
+// quit by entering q at any answer; the questions answered until then are
+// scored.
+func Play(session trainer.TrainingSession, chart *strategy.StrategyChart, names [2]string, questions int) Result {
+	out := ui.Output()
+	result := Result{Played: time.Now(), Rules: chart.Rules().Name}
+	for i, name := range names {
+		result.Players[i].Name = name
+	}
+	fmt.Fprintf(out, "\nDuo: %s and %s, %d questions. Answer on your own; the other looks away.\n", names[0], names[1], questions)
+
+	for number := 1; number <= questions; number++ {
+		scenario := session.GenerateScenario()
+		fmt.Fprintf(out, "\nQuestion %d of %d", number, questions)
+		ui.DisplayHand(scenario.Hand, scenario.DealerCard)
+
+		var answers [2]rune
+		for i, name := range names {
+			fmt.Fprintf(out, "\n%s's turn (%s, look away).", name, names[1-i])
+			action, quit := ui.GetUserAction()
+			if quit {
+				return result
+			}
+			answers[i] = action
+			hideAnswer(out)
+			fmt.Fprintf(out, "%s has answered.\n", name)
+		}
+
+		team := answers[0]
+		agreed := answers[0] == answers[1]
+		if agreed {
+			fmt.Fprintf(out, "\nYou agree: %s.\n", strategy.ActionToString(team))
+		} else {
+			result.Disagreements++
+			fmt.Fprintf(out, "\nYou disagree: %s says %s, %s says %s.\n", names[0], strategy.ActionToString(answers[0]),
+				names[1], strategy.ActionToString(answers[1]))
+			fmt.Fprintln(out, "Talk it through before the answer is revealed:")
+			for _, prompt := range DiscussionPrompts(scenario) {
+				fmt.Fprintf(out, "  - %s\n", prompt)
+			}
+			fmt.Fprint(out, "\nNow enter the answer you agree on.")
+			action, quit := ui.GetUserAction()
+			if quit {
+				return result
+			}
+			team = action
+		}
+
+		correctAction := chart.GetCorrectActionForHand(scenario.Hand, scenario.DealerCard)
+		result.Total++
+		for i, answer := range answers {
+			if trainer.CheckAnswer(answer, correctAction) {
+				result.Players[i].Correct++
+				if !agreed {
+					result.Players[i].RightInDisagreements++
+				}
+			}
+		}
+		if trainer.CheckAnswer(team, correctAction) {
+			result.TeamCorrect++
+			fmt.Fprintln(out, "\n✓ Correct!")
+		} else {
+			fmt.Fprintf(out, "\n❌ The play is %s.\n", strategy.ActionToString(correctAction))
+		}
+		if !agreed {
+			for i, answer := range answers {
+				mark := "❌"
+				if trainer.CheckAnswer(answer, correctAction) {
+					mark = "✓"
+				}
+				fmt.Fprintf(out, "%s: %s %s\n", names[i], strategy.ActionToString(answer), mark)
+			}
+		}
+		fmt.Fprintf(out, "Pattern: %s\n", chart.GetExplanationForHand(scenario.Hand, scenario.DealerCard))
+
+		if number < questions {
+			input, err := ui.Prompt("\nPress Enter for the next question (or 'q' + Enter to quit): ")
+			if err != nil || strings.EqualFold(input, "q") {
+				return result
+			}
+		}
+	}
+	return result
+}
+
+// hideAnswer erases the line a player just typed their answer on, on a
+// terminal, so their partner doesn't see it.
+func hideAnswer(w io.Writer) {
+	if ui.Styled() {
+		fmt.Fprint(w, "\x1b[1A\x1b[2K")
+	}
+}
+
+// DiscussionPrompts returns questions for players who disagree to talk
+// through, about what decides the scenario's play, without giving it away.
+func DiscussionPrompts(scenario trainer.Scenario) []string {
+	handType, total := strategy.Classify(scenario.Hand)
+	dealer := strategy.CardToString(scenario.DealerCard)
+	prompts := []string{fmt.Sprintf("Is a dealer %s a card to fear, or one likely to bust?", dealer)}
+	switch {
+	case handType == strategy.HandTypePair:
+		card := strategy.CardToString(total)
+		prompts = append(prompts, fmt.Sprintf("Would you rather play two hands each starting with %s, or one hand as it is?", card))
+	case handType == strategy.HandTypeSoft:
+		prompts = append(prompts, "Can hitting this hand bust it? What could the next card make it?")
+		if total <= 18 {
+			prompts = append(prompts, "Is this a hand worth putting more money on against this card?")
+		}
+	case total >= 12:
+		prompts = append(prompts, fmt.Sprintf("How likely is a hit to bust %d, and how often does %d win if you stand?", total, total))
+	case total >= 9:
+		prompts = append(prompts, "How good is one more card to this total? Is it worth doubling your bet?")
+	default:
+		prompts = append(prompts, "Can any card bust this hand?")
+	}
+	return append(prompts, "Each of you explain your reasoning, then agree on one answer.")
+}
+
+// Store is the file of duo results.
+type Store struct {
+	path    string
+	Results []Result `json:"results"`
+}
+
+// Open loads the results at path. A missing file gives an empty store that
+// is created when the first result is added.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Add appends a result and saves the store.
+func (s *Store) Add(r Result) error {
+	s.Results = append(s.Results, r)
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(s.path, data, 0o600)
+}
+
+// PlayerStats is a player's lifetime duo statistics, over every partner.
+type PlayerStats struct {
+	Name     string `json:"name"`
+	Sessions int    `json:"sessions"`
+	// Questions and Correct count the player's own answers, and
+	// Disagreements and RightInDisagreements those in disagreements.
+	Questions            int `json:"questions"`
+	Correct              int `json:"correct"`
+	Disagreements        int `json:"disagreements"`
+	RightInDisagreements int `json:"right_in_disagreements"`
+	// TeamCorrect counts the right answers of the teams they played in.
+	TeamCorrect int `json:"team_correct"`
+}
+
+// Players returns each player's statistics, by name.
+func (s *Store) Players() []PlayerStats {
+	byName := make(map[string]*PlayerStats)
+	for _, r := range s.Results {
+		for _, p := range r.Players {
+			stats := byName[p.Name]
+			if stats == nil {
+				stats = &PlayerStats{Name: p.Name}
+				byName[p.Name] = stats
+			}
+			stats.Sessions++
+			stats.Questions += r.Total
+			stats.Correct += p.Correct
+			stats.Disagreements += r.Disagreements
+			stats.RightInDisagreements += p.RightInDisagreements
+			stats.TeamCorrect += r.TeamCorrect
+		}
+	}
+	players := make([]PlayerStats, 0, len(byName))
+	for _, stats := range byName {
+		players = append(players, *stats)
+	}
+	sort.Slice(players, func(i, j int) bool { return players[i].Name < players[j].Name })
+	return players
+}
+
+// WriteSummary writes a session's scores: the team's and each player's
+// alone.
+func WriteSummary(w io.Writer, r Result) {
+	fmt.Fprintf(w, "\nDuo session complete: %d question(s)\n", r.Total)
+	if r.Total == 0 {
+		return
+	}
+	fmt.Fprintf(w, "Team:  %d/%d (%.1f%%), agreed on %d of %d\n", r.TeamCorrect, r.Total, Percent(r.TeamCorrect, r.Total),
+		r.Total-r.Disagreements, r.Total)
+	for _, p := range r.Players {
+		fmt.Fprintf(w, "%-6s %d/%d (%.1f%%) alone", p.Name+":", p.Correct, r.Total, Percent(p.Correct, r.Total))
+		if r.Disagreements > 0 {
+			fmt.Fprintf(w, ", right in %d of %d disagreement(s)", p.RightInDisagreements, r.Disagreements)
+		}
+		fmt.Fprintln(w)
+	}
+	if gain := r.TeamCorrect - max(r.Players[0].Correct, r.Players[1].Correct); gain > 0 {
+		fmt.Fprintf(w, "Working together got %d more right than either of you alone.\n", gain)
+	}
+}
+
+// WriteStats writes every player's lifetime statistics as a table.
+func WriteStats(w io.Writer, players []PlayerStats) {
+	if len(players) == 0 {
+		fmt.Fprintln(w, "No duo sessions yet. Start one with: blackjack_trainer duo NAME NAME")
+		return
+	}
+	fmt.Fprintf(w, "%-16s %8s %9s %8s %15s %8s\n", "Player", "Sessions", "Questions", "Alone", "Disagreements", "Team")
+	for _, p := range players {
+		right := "-"
+		if p.Disagreements > 0 {
+			right = fmt.Sprintf("%d/%d right", p.RightInDisagreements, p.Disagreements)
+		}
+		fmt.Fprintf(w, "%-16s %8d %9d %7.1f%% %15s %7.1f%%\n", p.Name, p.Sessions, p.Questions,
+			Percent(p.Correct, p.Questions), right, Percent(p.TeamCorrect, p.Questions))
+	}
+}
@@ -0,0 +1,128 @@
+package duo
+
+import (
+	"blackjack_trainer/internal/hand"
+	"blackjack_trainer/internal/strategy"
+	"blackjack_trainer/internal/trainer"
+	"blackjack_trainer/internal/ui"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fixedSession asks the same scenario every time.
+type fixedSession struct {
+	trainer.RandomTrainingSession
+	scenario trainer.Scenario
+}
+
+func (f *fixedSession) GenerateScenario() trainer.Scenario {
+	return f.scenario
+}
+
+// Test agreeing reveals the answer at once, and disagreeing asks the pair
+// to talk it through and settle on a team answer, with both scored alone
+func TestPlay(t *testing.T) {
+	session := &fixedSession{scenario: trainer.Scenario{Hand: hand.New(10, 6), DealerCard: 10}}
+	// Question 1: both hit. Question 2: Ana hits, Ben stands, and the team stands.
+	var out strings.Builder
+	ui.SetIO(strings.NewReader("h\nh\n\nh\ns\ns\n"), &out)
+	defer ui.SetIO(os.Stdin, os.Stdout)
+
+	r := Play(session, strategy.Default(), [2]string{"Ana", "Ben"}, 2)
+	if r.Total != 2 || r.TeamCorrect != 1 || r.Disagreements != 1 {
+		t.Errorf("Result = %+v, want 1/2 for the team with 1 disagreement", r)
+	}
+	if ana := r.Players[0]; ana.Name != "Ana" || ana.Correct != 2 || ana.RightInDisagreements != 1 {
+		t.Errorf("Ana = %+v, want 2 right, 1 in disagreements", ana)
+	}
+	if ben := r.Players[1]; ben.Name != "Ben" || ben.Correct != 1 || ben.RightInDisagreements != 0 {
+		t.Errorf("Ben = %+v, want 1 right, none in disagreements", ben)
+	}
+	for _, want := range []string{"Ana's turn (Ben, look away).", "You agree: HIT.", "You disagree: Ana says HIT, Ben says STAND.",
+		"Talk it through", "Now enter the answer you agree on.", "❌ The play is HIT.", "Ana: HIT ✓", "Ben: STAND ❌"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	var summary strings.Builder
+	WriteSummary(&summary, r)
+	for _, want := range []string{"Team:  1/2 (50.0%), agreed on 1 of 2", "Ana:   2/2 (100.0%) alone, right in 1 of 1 disagreement(s)"} {
+		if !strings.Contains(summary.String(), want) {
+			t.Errorf("Summary missing %q:\n%s", want, summary.String())
+		}
+	}
+}
+
+// Test quitting mid-question scores only the questions finished
+func TestPlayQuit(t *testing.T) {
+	session := &fixedSession{scenario: trainer.Scenario{Hand: hand.New(10, 2), DealerCard: 4}}
+	var out strings.Builder
+	ui.SetIO(strings.NewReader("s\ns\n\ns\nq\n"), &out)
+	defer ui.SetIO(os.Stdin, os.Stdout)
+
+	r := Play(session, strategy.Default(), [2]string{"Ana", "Ben"}, 5)
+	if r.Total != 1 || r.TeamCorrect != 1 || r.Players[1].Correct != 1 {
+		t.Errorf("Result = %+v, want the first question only", r)
+	}
+}
+
+// Test the discussion prompts fit the hand without naming the play
+func TestDiscussionPrompts(t *testing.T) {
+	tests := []struct {
+		scenario trainer.Scenario
+		want     string
+	}{
+		{trainer.Scenario{Hand: hand.New(8, 8), DealerCard: 10}, "two hands each starting with 8"},
+		{trainer.Scenario{Hand: hand.New(11, 7), DealerCard: 9}, "Can hitting this hand bust it?"},
+		{trainer.Scenario{Hand: hand.New(10, 6), DealerCard: 11}, "How likely is a hit to bust 16"},
+		{trainer.Scenario{Hand: hand.New(6, 5), DealerCard: 6}, "Is it worth doubling your bet?"},
+	}
+	for _, test := range tests {
+		prompts := strings.Join(DiscussionPrompts(test.scenario), "\n")
+		if !strings.Contains(prompts, test.want) {
+			t.Errorf("Prompts for %v vs %d missing %q:\n%s", test.scenario.Hand.Cards, test.scenario.DealerCard, test.want, prompts)
+		}
+		for _, action := range []string{"HIT", "STAND", "DOUBLE", "SPLIT"} {
+			if strings.Contains(prompts, action) {
+				t.Errorf("Prompts for %v give away %s:\n%s", test.scenario.Hand.Cards, action, prompts)
+			}
+		}
+	}
+}
+
+// Test results are saved and each player's statistics add up over partners
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	played := time.Date(2026, 5, 10, 18, 0, 0, 0, time.UTC)
+	s.Add(Result{Played: played, Rules: "Standard", Total: 10, TeamCorrect: 9, Disagreements: 2,
+		Players: [2]PlayerScore{{"Ana", 8, 2}, {"Ben", 7, 0}}})
+	s.Add(Result{Played: played.Add(time.Hour), Rules: "Standard", Total: 10, TeamCorrect: 10, Disagreements: 1,
+		Players: [2]PlayerScore{{"Cy", 9, 0}, {"Ana", 10, 1}}})
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	players := reopened.Players()
+	if len(players) != 3 || players[0].Name != "Ana" || players[1].Name != "Ben" || players[2].Name != "Cy" {
+		t.Fatalf("Players = %+v, want Ana, Ben and Cy", players)
+	}
+	want := PlayerStats{Name: "Ana", Sessions: 2, Questions: 20, Correct: 18, Disagreements: 3, RightInDisagreements: 3, TeamCorrect: 19}
+	if players[0] != want {
+		t.Errorf("Ana = %+v, want %+v", players[0], want)
+	}
+
+	var out strings.Builder
+	WriteStats(&out, players)
+	if !strings.Contains(out.String(), "Ana                     2        20    90.0%       3/3 right    95.0%") {
+		t.Errorf("Stats:\n%s", out.String())
+	}
+}
@@ -0,0 +1,193 @@
+// Package etiquette quizzes table procedure: the hand signals, when cards
+// may be touched, and how to double, split, surrender and buy in.
+//
+// Casinos rely on hand signals so the cameras record every decision, and
+// the procedure differs between shoe games, where cards are dealt face up
+// and never touched, and hand-held pitch games (single and double deck),
+// where the player holds the cards in one hand.
+package etiquette
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// Question is a multiple-choice question about table procedure.
+type Question struct {
+	// Prompt is the question text.
+	Prompt string
+	// Choices are the possible answers.
+	Choices []string
+	// Answer is the index of the correct choice.
+	Answer int
+	// Explanation describes the correct procedure.
+	Explanation string
+}
+
+// Check reports whether input selects the correct choice. Choices may be
+// given by letter (a, b, ...) or number (1, 2, ...).
+func (q Question) Check(input string) bool {
+	choice, ok := q.parse(input)
+	return ok && choice == q.Answer
+}
+
+func (q Question) parse(input string) (int, bool) {
+	input = strings.ToLower(strings.TrimSpace(input))
+	if len(input) == 1 && input[0] >= 'a' && int(input[0]-'a') < len(q.Choices) {
+		return int(input[0] - 'a'), true
+	}
+	if n, err := strconv.Atoi(input); err == nil && n >= 1 && n <= len(q.Choices) {
+		return n - 1, true
+	}
+	return 0, false
+}
+
+// Result is the score of a completed quiz.
+type Result struct {
+	Correct int
+	Total   int
+}
+
+// questions is the question bank.
+var questions = []Question{
+	{
+		Prompt:      "In a shoe game (cards dealt face up), how do you ask for a hit?",
+		Choices:     []string{"Say \"hit\" and wait", "Tap the table or beckon with a finger", "Pick up your cards", "Wave your hand over your cards"},
+		Answer:      1,
+		Explanation: "Tap the felt behind your cards or beckon toward yourself. Saying it is fine too, but the signal is what counts for the cameras.",
+	},
+	{
+		Prompt:      "In a shoe game, how do you stand?",
+		Choices:     []string{"Wave your hand flat, palm down, over your cards", "Tap the table", "Slide your cards toward the dealer", "Push your chips forward"},
+		Answer:      0,
+		Explanation: "Wave your hand side to side, palm down, above your cards.",
+	},
+	{
+		Prompt:      "In a shoe game, may you touch your cards?",
+		Choices:     []string{"Yes, to look at them", "Only to split them", "No, cards dealt face up are never touched", "Only after the dealer checks for blackjack"},
+		Answer:      2,
+		Explanation: "Face-up cards stay on the felt. The dealer moves them for you when you split.",
+	},
+	{
+		Prompt:      "In a hand-held (pitch) game, how do you ask for a hit?",
+		Choices:     []string{"Tap the table", "Lightly scrape the edge of your cards toward you on the felt", "Hand your cards to the dealer", "Say \"card\" and wave"},
+		Answer:      1,
+		Explanation: "Scrape the cards lightly on the felt toward yourself; the dealer deals the hit face up in front of you.",
+	},
+	{
+		Prompt:      "In a hand-held (pitch) game, how do you stand?",
+		Choices:     []string{"Wave your hand over your cards", "Turn your cards face up", "Tuck your cards face down under your chips", "Hold your cards up"},
+		Answer:      2,
+		Explanation: "Slide your cards face down under your bet without moving the chips.",
+	},
+	{
+		Prompt:      "In a hand-held game, how should you hold your cards?",
+		Choices:     []string{"In one hand, above the table", "In both hands, close to your chest", "In your lap", "Face down in your pocket"},
+		Answer:      0,
+		Explanation: "Hold them in one hand and keep them over the table in view of the dealer and cameras.",
+	},
+	{
+		Prompt:      "How do you double down in a shoe game?",
+		Choices:     []string{"Put extra chips on top of your bet", "Place an equal bet beside your original bet and hold up one finger", "Say \"double\" and tap twice", "Hand chips to the dealer"},
+		Answer:      1,
+		Explanation: "Set the extra chips next to, not on top of, your original bet, and point one finger to show you want one card.",
+	},
+	{
+		Prompt:      "How do you double down in a hand-held game?",
+		Choices:     []string{"Scrape your cards twice", "Tuck your cards and add chips", "Turn your cards face up and place an equal bet beside your original bet", "Hold your cards up and say \"double\""},
+		Answer:      2,
+		Explanation: "Toss the cards face up in front of your bet, then place the double beside it.",
+	},
+	{
+		Prompt:      "How do you split a pair in a shoe game?",
+		Choices:     []string{"Separate the cards yourself", "Place an equal bet beside your original bet and make a V with two fingers", "Say \"split\" and tap", "Push your bet forward"},
+		Answer:      1,
+		Explanation: "Add an equal bet next to the original and show a V with two fingers; the dealer separates the cards.",
+	},
+	{
+		Prompt:      "How do you surrender where late surrender is offered?",
+		Choices:     []string{"Push your chips toward the dealer", "Fold your cards face down", "Say \"surrender\" clearly, drawing a line behind your bet with a finger", "Wave both hands"},
+		Answer:      2,
+		Explanation: "Surrender is announced out loud; many dealers also expect a line drawn behind the bet. Never touch your chips; the dealer takes half.",
+	},
+	{
+		Prompt:      "When may you change or touch your bet?",
+		Choices:     []string{"Any time before you act", "Only before the first card is dealt", "After you see your first card", "When the dealer shows a weak card"},
+		Answer:      1,
+		Explanation: "Once cards are in the air your bet is locked; touching it looks like past-posting.",
+	},
+	{
+		Prompt:      "How do you buy in for chips?",
+		Choices:     []string{"Hand the cash to the dealer", "Place the cash on the felt between hands", "Drop the cash in the betting circle", "Give it to the pit boss"},
+		Answer:      1,
+		Explanation: "Dealers can't take anything from your hand. Lay the cash on the table outside the betting circle, between hands.",
+	},
+	{
+		Prompt:      "How much can you bet on insurance?",
+		Choices:     []string{"Any amount", "Up to half your original bet", "Exactly your original bet", "Up to double your original bet"},
+		Answer:      1,
+		Explanation: "Insurance is up to half the original bet, placed on the insurance line. Basic strategy never takes it.",
+	},
+}
+
+// Questions returns the question bank.
+func Questions() []Question {
+	return append([]Question(nil), questions...)
+}
+
+// Run asks count questions (all of them if count is zero or larger than the
+// bank) in random order, reading answers from in and writing prompts and
+// feedback to w. Entering q stops the quiz early; the result counts the
+// questions answered.
+func Run(w io.Writer, in io.Reader, count int, rng *rand.Rand) Result {
+	asked := Questions()
+	rng.Shuffle(len(asked), func(i, j int) { asked[i], asked[j] = asked[j], asked[i] })
+	if count > 0 && count < len(asked) {
+		asked = asked[:count]
+	}
+
+	fmt.Fprintln(w, strings.Repeat("=", 40))
+	fmt.Fprintln(w, "Table Etiquette Quiz")
+	fmt.Fprintln(w, strings.Repeat("=", 40))
+	fmt.Fprintln(w, "(Press 'q' + Enter to quit at any time)")
+
+	var result Result
+	reader := bufio.NewReader(in)
+	for i, q := range asked {
+		fmt.Fprintf(w, "\nQuestion %d/%d: %s\n", i+1, len(asked), q.Prompt)
+		for j, choice := range q.Choices {
+			fmt.Fprintf(w, "  %c) %s\n", 'a'+j, choice)
+		}
+
+		var choice int
+		for {
+			fmt.Fprintf(w, "Answer (a-%c): ", 'a'+len(q.Choices)-1)
+			input, err := reader.ReadString('\n')
+			if (err != nil && input == "") || strings.EqualFold(strings.TrimSpace(input), "q") {
+				fmt.Fprintf(w, "\nEtiquette quiz score: %d/%d\n", result.Correct, result.Total)
+				return result
+			}
+			var ok bool
+			if choice, ok = q.parse(input); ok {
+				break
+			}
+			fmt.Fprintln(w, "Please choose one of the letters shown.")
+		}
+
+		result.Total++
+		if choice == q.Answer {
+			result.Correct++
+			fmt.Fprintln(w, "✓ Correct!")
+		} else {
+			fmt.Fprintf(w, "❌ Incorrect. The answer is %c) %s\n", 'a'+q.Answer, q.Choices[q.Answer])
+		}
+		fmt.Fprintf(w, "   %s\n", q.Explanation)
+	}
+
+	fmt.Fprintf(w, "\nEtiquette quiz score: %d/%d\n", result.Correct, result.Total)
+	return result
+}
@@ -0,0 +1,74 @@
+package etiquette
+
+import (
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// Test every question is well formed
+func TestQuestions(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, q := range Questions() {
+		if seen[q.Prompt] {
+			t.Errorf("Duplicate question %q", q.Prompt)
+		}
+		seen[q.Prompt] = true
+
+		if len(q.Choices) < 2 || q.Answer < 0 || q.Answer >= len(q.Choices) {
+			t.Errorf("%q: answer %d out of range for %d choices", q.Prompt, q.Answer, len(q.Choices))
+		}
+		if q.Explanation == "" {
+			t.Errorf("%q: missing explanation", q.Prompt)
+		}
+	}
+}
+
+// Test answers are accepted by letter or number
+func TestCheck(t *testing.T) {
+	q := Question{Choices: []string{"one", "two", "three"}, Answer: 1}
+
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"b", true},
+		{" B\n", true},
+		{"2", true},
+		{"a", false},
+		{"3", false},
+		{"d", false},
+		{"4", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := q.Check(tt.input); got != tt.want {
+			t.Errorf("Check(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+// Test a scripted quiz is scored, re-prompts invalid input, and stops on q
+func TestRun(t *testing.T) {
+	asked := Questions()
+	rand.New(rand.NewSource(1)).Shuffle(len(asked), func(i, j int) { asked[i], asked[j] = asked[j], asked[i] })
+
+	input := "z\n" + string(rune('a'+asked[0].Answer)) + "\n" + string(rune('a'+(asked[1].Answer+1)%len(asked[1].Choices))) + "\n"
+
+	var out bytes.Buffer
+	result := Run(&out, strings.NewReader(input), 2, rand.New(rand.NewSource(1)))
+	if result.Correct != 1 || result.Total != 2 {
+		t.Errorf("Expected 1/2, got %d/%d:\n%s", result.Correct, result.Total, out.String())
+	}
+	if !strings.Contains(out.String(), "Please choose one of the letters shown.") {
+		t.Errorf("Invalid input should be re-prompted:\n%s", out.String())
+	}
+
+	out.Reset()
+	result = Run(&out, strings.NewReader("q\n"), 0, rand.New(rand.NewSource(1)))
+	if result.Total != 0 || !strings.Contains(out.String(), "score: 0/0") {
+		t.Errorf("Quitting should end the quiz, got %d/%d:\n%s", result.Correct, result.Total, out.String())
+	}
+}
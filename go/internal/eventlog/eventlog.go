@@ -0,0 +1,90 @@
+// Package eventlog writes a detailed per-question event log for
+// learning-analytics tooling.
+//
+// The log is JSON Lines: one JSON object per answered question, appended to
+// a file. It is only written when explicitly enabled, and contains no user
+// identifiers; sessions are identified by their mode and start time.
+package eventlog
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Event records one answered question.
+type Event struct {
+	Time          time.Time `json:"time"`
+	SessionID     string    `json:"session_id"`
+	SessionMode   string    `json:"session_mode"`
+	Question      int       `json:"question"`
+	Cards         []int     `json:"cards"`
+	DealerCard    int       `json:"dealer_card"`
+	HandType      string    `json:"hand_type"`
+	PlayerValue   int       `json:"player_value"`
+	Tier          string    `json:"tier"`
+	Difficulty    string    `json:"difficulty"`
+	Action        string    `json:"action"`
+	CorrectAction string    `json:"correct_action"`
+	Correct       bool      `json:"correct"`
+	LatencyMs     int64     `json:"latency_ms"`
+	LessonViewed  bool      `json:"lesson_viewed"`
+	// Corrected marks an answer the player took back as a slip; the hand is
+	// asked again in a later event.
+	Corrected bool `json:"corrected,omitempty"`
+	// Tags are the names the player tagged the hand with.
+	Tags []string `json:"tags,omitempty"`
+	// Rating is how hard the player felt the question was, 1-3, or 0 if
+	// they didn't rate it.
+	Rating int `json:"rating,omitempty"`
+	// Hinted marks an answer given after looking at the mnemonics list.
+	Hinted bool `json:"hinted,omitempty"`
+}
+
+// SessionID returns the identifier used for events of a session.
+func SessionID(mode string, started time.Time) string {
+	return mode + "@" + started.UTC().Format(time.RFC3339Nano)
+}
+
+// Logger appends events to a writer. A nil Logger discards events.
+type Logger struct {
+	mu      sync.Mutex
+	encoder *json.Encoder
+	closer  io.Closer
+}
+
+// New creates a logger writing to w.
+func New(w io.Writer) *Logger {
+	return &Logger{encoder: json.NewEncoder(w)}
+}
+
+// Open creates a logger appending to the file at path, creating it if needed.
+func Open(path string) (*Logger, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	l := New(file)
+	l.closer = file
+	return l, nil
+}
+
+// Log writes an event.
+func (l *Logger) Log(e Event) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.encoder.Encode(e)
+}
+
+// Close closes the underlying file, if the logger opened one.
+func (l *Logger) Close() error {
+	if l == nil || l.closer == nil {
+		return nil
+	}
+	return l.closer.Close()
+}
@@ -0,0 +1,83 @@
+package eventlog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Test events are written as one JSON object per line
+func TestLog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf)
+
+	started := time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)
+	for i := 1; i <= 2; i++ {
+		err := logger.Log(Event{
+			Time:          started.Add(time.Duration(i) * time.Second),
+			SessionID:     SessionID("random", started),
+			SessionMode:   "random",
+			Question:      i,
+			Cards:         []int{10, 6},
+			DealerCard:    10,
+			HandType:      "hard",
+			PlayerValue:   16,
+			Tier:          "tricky",
+			Difficulty:    "normal",
+			Action:        "S",
+			CorrectAction: "H",
+			LatencyMs:     1200,
+		})
+		if err != nil {
+			t.Fatalf("Log failed: %v", err)
+		}
+	}
+
+	scanner := bufio.NewScanner(&buf)
+	lines := 0
+	for scanner.Scan() {
+		lines++
+		var fields map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &fields); err != nil {
+			t.Fatalf("Line %d is not JSON: %v", lines, err)
+		}
+		if fields["session_id"] != "random@2024-03-06T12:00:00Z" || fields["question"] != float64(lines) {
+			t.Errorf("Unexpected event: %v", fields)
+		}
+		if _, ok := fields["lesson_viewed"]; !ok {
+			t.Error("Events should always include lesson_viewed")
+		}
+	}
+	if lines != 2 {
+		t.Errorf("Expected 2 lines, got %d", lines)
+	}
+}
+
+// Test Open appends to an existing log and a nil logger discards events
+func TestOpenAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	for i := 0; i < 2; i++ {
+		logger, err := Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		logger.Log(Event{Question: i + 1})
+		if err := logger.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, _ := os.ReadFile(path)
+	if n := bytes.Count(data, []byte("\n")); n != 2 {
+		t.Errorf("Expected 2 appended events, got %d", n)
+	}
+
+	var logger *Logger
+	if err := logger.Log(Event{}); err != nil || logger.Close() != nil {
+		t.Error("Nil logger should discard events")
+	}
+}
@@ -0,0 +1,145 @@
+// Package exam keeps the results of strategy exams, full-chart tests taken
+// with "-session exam", apart from the practice history, and prints
+// certificates for the exams passed, as a dealer school might hand out.
+//
+// Results are stored as JSON in exams.json beside the practice history. An
+// exam is passed when every question was answered and the score reaches
+// PassMark.
+package exam
+
+import (
+	"blackjack_trainer/internal/atomicfile"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+)
+
+// FileName is the name of the exam results file in the trainer's directory.
+const FileName = "exams.json"
+
+// PassMark is the lowest percentage score that passes an exam.
+const PassMark = 90.0
+
+// Result is the outcome of one exam.
+type Result struct {
+	Taken time.Time `json:"taken"`
+	// Rules is the name of the rule set the exam was taken under.
+	Rules   string `json:"rules"`
+	Correct int    `json:"correct"`
+	// Total is the number of questions answered, out of Questions asked for.
+	Total     int `json:"total"`
+	Questions int `json:"questions"`
+}
+
+// Score returns the percentage of questions answered correctly.
+func (r Result) Score() float64 {
+	if r.Total == 0 {
+		return 0
+	}
+	return float64(r.Correct) / float64(r.Total) * 100
+}
+
+// Passed reports whether the exam was finished with a passing score.
+func (r Result) Passed() bool {
+	return r.Total > 0 && r.Total >= r.Questions && r.Score() >= PassMark
+}
+
+// String formats the result as a line for listing, e.g.
+// "2024-03-04  Standard  48/50 (96.0%)".
+func (r Result) String() string {
+	return fmt.Sprintf("%s  %-22s %d/%d (%.1f%%)", r.Taken.Format("2006-01-02"), r.Rules, r.Correct, r.Total, r.Score())
+}
+
+// Store is the file of exam results.
+type Store struct {
+	path    string
+	Results []Result `json:"results"`
+}
+
+// Open loads the results at path. A missing file gives an empty store that
+// is created when the first result is added.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Add appends a result and saves the store.
+func (s *Store) Add(r Result) error {
+	s.Results = append(s.Results, r)
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(s.path, data, 0o600)
+}
+
+// Passed returns the passed exams, oldest first.
+func (s *Store) Passed() []Result {
+	var passed []Result
+	for _, r := range s.Results {
+		if r.Passed() {
+			passed = append(passed, r)
+		}
+	}
+	return passed
+}
+
+// certificateWidth is the width of a printed certificate, in characters.
+const certificateWidth = 60
+
+// Certificate writes a printable text certificate for a passed exam. An
+// empty name leaves a line to write the name on.
+func Certificate(w io.Writer, r Result, name string) error {
+	if name == "" {
+		name = strings.Repeat("_", 30)
+	}
+	rule := strings.Repeat("=", certificateWidth)
+	lines := []string{
+		rule,
+		"",
+		center("CERTIFICATE OF ACHIEVEMENT"),
+		center("Blackjack Basic Strategy"),
+		"",
+		center("This certifies that"),
+		"",
+		center(name),
+		"",
+		center("passed the basic strategy exam"),
+		center(fmt.Sprintf("for %s rules", r.Rules)),
+		center(fmt.Sprintf("on %s", r.Taken.Format("January 2, 2006"))),
+		center(fmt.Sprintf("with a score of %d/%d (%.1f%%)", r.Correct, r.Total, r.Score())),
+		"",
+		center(fmt.Sprintf("Pass mark: %.0f%%", PassMark)),
+		"",
+		"",
+		center("Signed: ______________________________"),
+		"",
+		rule,
+	}
+	_, err := io.WriteString(w, strings.Join(lines, "\n")+"\n")
+	return err
+}
+
+// center pads text to center it on the certificate.
+func center(text string) string {
+	pad := (certificateWidth - len([]rune(text))) / 2
+	if pad < 0 {
+		pad = 0
+	}
+	return strings.Repeat(" ", pad) + text
+}
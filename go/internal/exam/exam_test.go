@@ -0,0 +1,80 @@
+package exam
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test an exam passes only when finished with the pass mark
+func TestPassed(t *testing.T) {
+	tests := []struct {
+		result Result
+		passed bool
+	}{
+		{Result{Correct: 45, Total: 50, Questions: 50}, true},
+		{Result{Correct: 44, Total: 50, Questions: 50}, false},
+		{Result{Correct: 20, Total: 20, Questions: 50}, false},
+		{Result{Questions: 50}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.result.Passed(); got != tt.passed {
+			t.Errorf("%+v: Passed() = %v, want %v", tt.result, got, tt.passed)
+		}
+	}
+}
+
+// Test results survive a save and reload, and only passes are listed
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open of missing file failed: %v", err)
+	}
+	taken := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	for _, r := range []Result{
+		{Taken: taken, Rules: "Standard", Correct: 40, Total: 50, Questions: 50},
+		{Taken: taken.Add(24 * time.Hour), Rules: "Vegas Strip", Correct: 48, Total: 50, Questions: 50},
+	} {
+		if err := s.Add(r); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+
+	reloaded, err := Open(path)
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	if len(reloaded.Results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(reloaded.Results))
+	}
+	passed := reloaded.Passed()
+	if len(passed) != 1 || passed[0].Rules != "Vegas Strip" || !passed[0].Taken.Equal(taken.Add(24*time.Hour)) {
+		t.Errorf("Expected only the Vegas Strip exam passed, got %+v", passed)
+	}
+	if got, want := passed[0].String(), "2024-03-05  Vegas Strip            48/50 (96.0%)"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+// Test the certificate names the player, rules, date and score
+func TestCertificate(t *testing.T) {
+	r := Result{Taken: time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC), Rules: "Standard", Correct: 48, Total: 50, Questions: 50}
+
+	var b strings.Builder
+	if err := Certificate(&b, r, "Pat Dealer"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"CERTIFICATE OF ACHIEVEMENT", "Pat Dealer", "for Standard rules", "on March 4, 2024", "48/50 (96.0%)"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("Certificate missing %q:\n%s", want, b.String())
+		}
+	}
+
+	b.Reset()
+	Certificate(&b, r, "")
+	if !strings.Contains(b.String(), strings.Repeat("_", 30)+"\n") {
+		t.Errorf("Certificate without a name should leave a line for one:\n%s", b.String())
+	}
+}
@@ -0,0 +1,290 @@
+// Package graphql answers GraphQL queries from resolvers written in Go, so
+// the server can offer ad-hoc queries without a third-party library.
+//
+// It implements the part of the language a read-only dashboard needs:
+// - One query operation per document, named or anonymous
+// - Fields, aliases and __typename
+// - Arguments, including lists, input objects and enum values
+// - Variables, with defaults and non-null checks
+//
+// Fragments, directives, mutations, subscriptions and introspection are not
+// supported; a server publishes its schema as a document instead.
+//
+// Values are resolved by Object implementations. A resolver returns an
+// Object for a nested object, a slice for a list, and a bool, number or
+// string for a scalar.
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Object is a GraphQL object whose fields are resolved on demand.
+type Object interface {
+	// TypeName returns the object's type name in the schema, e.g. "Stats".
+	TypeName() string
+	// Field resolves a field from its arguments. It returns an error
+	// wrapping ErrNoField for fields the type doesn't have.
+	Field(name string, args Args) (interface{}, error)
+}
+
+// ErrNoField is returned by Object.Field for an unknown field.
+var ErrNoField = errors.New("no such field")
+
+// Request is a query as clients send it over HTTP.
+type Request struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Response is the result of a query. Data is nil if the query could not be
+// run at all; otherwise fields that failed are null and described in
+// Errors.
+type Response struct {
+	Data   interface{} `json:"data,omitempty"`
+	Errors []Error     `json:"errors,omitempty"`
+}
+
+// Error describes a query that could not be run, or a field that could not
+// be resolved.
+type Error struct {
+	Message string `json:"message"`
+	// Path leads to the field that failed: response keys and list indexes.
+	Path []interface{} `json:"path,omitempty"`
+}
+
+// Execute runs a query against the root object.
+func Execute(root Object, req Request) Response {
+	op, err := parse(req.Query)
+	if err != nil {
+		return Response{Errors: []Error{{Message: err.Error()}}}
+	}
+	vars, err := op.bind(req.Variables)
+	if err != nil {
+		return Response{Errors: []Error{{Message: err.Error()}}}
+	}
+	e := &executor{vars: vars}
+	data := e.selectFields(root, op.selections, nil)
+	return Response{Data: data, Errors: e.errors}
+}
+
+// bind returns the values of the operation's variables, from those given
+// and the defaults.
+func (op *operation) bind(given map[string]interface{}) (map[string]interface{}, error) {
+	vars := make(map[string]interface{}, len(op.variables))
+	for _, def := range op.variables {
+		value, ok := given[def.name]
+		if !ok && def.hasDefault {
+			value, ok = def.defaultVal, true
+		}
+		if def.nonNull && value == nil {
+			if !ok {
+				return nil, fmt.Errorf("variable $%s of required type %s was not provided", def.name, def.typ)
+			}
+			return nil, fmt.Errorf("variable $%s of required type %s must not be null", def.name, def.typ)
+		}
+		vars[def.name] = value
+	}
+	for name := range given {
+		if _, ok := vars[name]; !ok {
+			return nil, fmt.Errorf("variable $%s is not defined by the operation", name)
+		}
+	}
+	return vars, nil
+}
+
+// executor resolves the fields of a query, collecting field errors.
+type executor struct {
+	vars   map[string]interface{}
+	errors []Error
+}
+
+func (e *executor) fail(path []interface{}, format string, args ...interface{}) {
+	e.errors = append(e.errors, Error{
+		Message: fmt.Sprintf(format, args...),
+		Path:    append([]interface{}(nil), path...),
+	})
+}
+
+// selectFields resolves the selected fields of an object.
+func (e *executor) selectFields(obj Object, selections []selection, path []interface{}) fields {
+	result := make(fields, 0, len(selections))
+	seen := make(map[string]bool, len(selections))
+	for _, sel := range selections {
+		key := sel.key()
+		fieldPath := append(path[:len(path):len(path)], key)
+		if seen[key] {
+			e.fail(fieldPath, "field %q is selected more than once; give each an alias", key)
+			continue
+		}
+		seen[key] = true
+
+		if sel.name == "__typename" {
+			result = append(result, field{key, obj.TypeName()})
+			continue
+		}
+		args, err := e.arguments(sel.args)
+		if err != nil {
+			e.fail(fieldPath, "%s", err)
+			result = append(result, field{key, nil})
+			continue
+		}
+		value, err := obj.Field(sel.name, args)
+		switch {
+		case errors.Is(err, ErrNoField):
+			e.fail(fieldPath, "cannot query field %q on type %q", sel.name, obj.TypeName())
+			value = nil
+		case err != nil:
+			e.fail(fieldPath, "%s", err)
+			value = nil
+		default:
+			value = e.complete(value, sel, fieldPath)
+		}
+		result = append(result, field{key, value})
+	}
+	return result
+}
+
+// complete turns a resolved value into its response form, resolving the
+// fields selected from objects.
+func (e *executor) complete(value interface{}, sel selection, path []interface{}) interface{} {
+	if obj, ok := value.(Object); ok {
+		if obj == nil || reflect.ValueOf(obj).Kind() == reflect.Ptr && reflect.ValueOf(obj).IsNil() {
+			return nil
+		}
+		if len(sel.selections) == 0 {
+			e.fail(path, "field %q of type %q must select subfields", sel.name, obj.TypeName())
+			return nil
+		}
+		return e.selectFields(obj, sel.selections, path)
+	}
+
+	v := reflect.ValueOf(value)
+	switch {
+	case value == nil:
+		return nil
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8:
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			list[i] = e.complete(v.Index(i).Interface(), sel, append(path[:len(path):len(path)], i))
+		}
+		return list
+	case len(sel.selections) > 0:
+		e.fail(path, "field %q is a scalar and can't select subfields", sel.name)
+		return nil
+	}
+	return value
+}
+
+// arguments evaluates a field's arguments, substituting variables.
+func (e *executor) arguments(args []argument) (Args, error) {
+	values := make(Args, len(args))
+	for _, arg := range args {
+		if _, dup := values[arg.name]; dup {
+			return nil, fmt.Errorf("argument %q is given more than once", arg.name)
+		}
+		values[arg.name] = e.resolve(arg.value)
+	}
+	return values, nil
+}
+
+// resolve substitutes variables in an argument value.
+func (e *executor) resolve(value interface{}) interface{} {
+	switch v := value.(type) {
+	case variable:
+		return e.vars[string(v)]
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = e.resolve(item)
+		}
+		return list
+	case map[string]interface{}:
+		object := make(map[string]interface{}, len(v))
+		for name, item := range v {
+			object[name] = e.resolve(item)
+		}
+		return object
+	}
+	return value
+}
+
+// field is a response key and its value.
+type field struct {
+	key   string
+	value interface{}
+}
+
+// fields is an object in a response. It marshals as a JSON object with the
+// keys in the order they were selected, as GraphQL requires.
+type fields []field
+
+func (f fields) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range f {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(field.key)
+		buf.Write(key)
+		buf.WriteByte(':')
+		value, err := json.Marshal(field.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Args holds the arguments given to a field, with variables substituted.
+type Args map[string]interface{}
+
+// Allow returns an error naming the first argument not among names, so a
+// misspelled argument isn't silently ignored.
+func (a Args) Allow(names ...string) error {
+	for name := range a {
+		found := false
+		for _, allowed := range names {
+			found = found || name == allowed
+		}
+		if !found {
+			return fmt.Errorf("unknown argument %q (valid: %s)", name, strings.Join(names, ", "))
+		}
+	}
+	return nil
+}
+
+// String returns a string argument, or "" if it is absent or null.
+func (a Args) String(name string) (string, error) {
+	switch v := a[name].(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	}
+	return "", fmt.Errorf("argument %q must be a string", name)
+}
+
+// Int returns an integer argument, or def if it is absent or null. Numbers
+// from JSON variables are accepted when they are whole.
+func (a Args) Int(name string, def int) (int, error) {
+	switch v := a[name].(type) {
+	case nil:
+		return def, nil
+	case int:
+		return v, nil
+	case float64:
+		if v == float64(int(v)) {
+			return int(v), nil
+		}
+	}
+	return 0, fmt.Errorf("argument %q must be an integer", name)
+}
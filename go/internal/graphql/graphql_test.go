@@ -0,0 +1,153 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// book is a test object with a scalar, a list and an argument.
+type book struct {
+	title   string
+	authors []author
+}
+
+func (b book) TypeName() string { return "Book" }
+
+func (b book) Field(name string, args Args) (interface{}, error) {
+	switch name {
+	case "title":
+		if err := args.Allow("upper"); err != nil {
+			return nil, err
+		}
+		if upper, _ := args["upper"].(bool); upper {
+			return strings.ToUpper(b.title), nil
+		}
+		return b.title, nil
+	case "authors":
+		limit, err := args.Int("limit", len(b.authors))
+		if err != nil {
+			return nil, err
+		}
+		return b.authors[:limit], nil
+	case "broken":
+		return nil, fmt.Errorf("out of ink")
+	}
+	return nil, ErrNoField
+}
+
+type author string
+
+func (a author) TypeName() string { return "Author" }
+
+func (a author) Field(name string, args Args) (interface{}, error) {
+	if name == "name" {
+		return string(a), nil
+	}
+	return nil, ErrNoField
+}
+
+// library is the test root.
+type library struct{}
+
+func (library) TypeName() string { return "Query" }
+
+func (library) Field(name string, args Args) (interface{}, error) {
+	if name != "book" {
+		return nil, ErrNoField
+	}
+	title, err := args.String("title")
+	if err != nil {
+		return nil, err
+	}
+	return book{title: title, authors: []author{"Thorp", "Wong"}}, nil
+}
+
+// run executes a query and returns the response as JSON.
+func run(t *testing.T, query string, vars map[string]interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(Execute(library{}, Request{Query: query, Variables: vars}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// Test fields, aliases, arguments, variables and __typename are resolved
+// in the order selected
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		query string
+		vars  map[string]interface{}
+		want  string
+	}{
+		{`{ book(title: "Beat the Dealer") { title } }`, nil,
+			`{"data":{"book":{"title":"Beat the Dealer"}}}`},
+		{`query Books($t: String!, $n: Int = 1) {
+			# a comment, and commas are ignored
+			b: book(title: $t) { __typename, loud: title(upper: true), title, authors(limit: $n) { name } }
+		}`, map[string]interface{}{"t": "Professional Blackjack"},
+			`{"data":{"b":{"__typename":"Book","loud":"PROFESSIONAL BLACKJACK","title":"Professional Blackjack","authors":[{"name":"Thorp"}]}}}`},
+		{`query ($n: Int) { book { authors(limit: $n) { name } } }`, map[string]interface{}{"n": 2.0},
+			`{"data":{"book":{"authors":[{"name":"Thorp"},{"name":"Wong"}]}}}`},
+		{`{ book(title: "\"Quoted\"\n") { title } }`, nil,
+			`{"data":{"book":{"title":"\"Quoted\"\n"}}}`},
+	}
+	for _, test := range tests {
+		if got := run(t, test.query, test.vars); got != test.want {
+			t.Errorf("%s:\n got %s\nwant %s", test.query, got, test.want)
+		}
+	}
+}
+
+// Test field errors null the field and keep the rest of the result
+func TestFieldErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`{ book { title pages } }`,
+			`{"data":{"book":{"title":"","pages":null}},"errors":[{"message":"cannot query field \"pages\" on type \"Book\"","path":["book","pages"]}]}`},
+		{`{ book { broken } }`,
+			`{"data":{"book":{"broken":null}},"errors":[{"message":"out of ink","path":["book","broken"]}]}`},
+		{`{ book { authors(limit: 1) { name age } } }`,
+			`{"data":{"book":{"authors":[{"name":"Thorp","age":null}]}},"errors":[{"message":"cannot query field \"age\" on type \"Author\"","path":["book","authors",0,"age"]}]}`},
+		{`{ book }`,
+			`{"data":{"book":null},"errors":[{"message":"field \"book\" of type \"Book\" must select subfields","path":["book"]}]}`},
+		{`{ book { title { length } } }`,
+			`{"data":{"book":{"title":null}},"errors":[{"message":"field \"title\" is a scalar and can't select subfields","path":["book","title"]}]}`},
+		{`{ book { title(loud: true) } }`,
+			`{"data":{"book":{"title":null}},"errors":[{"message":"unknown argument \"loud\" (valid: upper)","path":["book","title"]}]}`},
+		{`{ book { authors(limit: "two") { name } } }`,
+			`{"data":{"book":{"authors":null}},"errors":[{"message":"argument \"limit\" must be an integer","path":["book","authors"]}]}`},
+	}
+	for _, test := range tests {
+		if got := run(t, test.query, nil); got != test.want {
+			t.Errorf("%s:\n got %s\nwant %s", test.query, got, test.want)
+		}
+	}
+}
+
+// Test queries that can't be run return only errors
+func TestRequestErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		vars  map[string]interface{}
+		want  string
+	}{
+		{`{ book {`, nil, "syntax error at 1:9: expected a name, found end of query"},
+		{"{\n  book { ...Details } }", nil, "syntax error at 2:10: fragments are not supported"},
+		{`mutation { book }`, nil, "mutation definitions are not supported"},
+		{`{ book { title } } { book { title } }`, nil, "only one operation per document is supported"},
+		{`{ book(title: "open) { title } }`, nil, "unterminated string"},
+		{`query ($t: String!) { book(title: $t) { title } }`, nil, "variable $t of required type String! was not provided"},
+		{`{ book { title } }`, map[string]interface{}{"t": "x"}, "variable $t is not defined by the operation"},
+	}
+	for _, test := range tests {
+		resp := Execute(library{}, Request{Query: test.query, Variables: test.vars})
+		if resp.Data != nil || len(resp.Errors) != 1 || !strings.Contains(resp.Errors[0].Message, test.want) {
+			t.Errorf("%s: expected error %q, got %+v", test.query, test.want, resp)
+		}
+	}
+}
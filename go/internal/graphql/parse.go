@@ -0,0 +1,497 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// operation is a parsed query operation.
+type operation struct {
+	variables  []variableDefinition
+	selections []selection
+}
+
+// variableDefinition declares a variable of an operation, e.g.
+// "$from: String = \"2024-01-01\"".
+type variableDefinition struct {
+	name string
+	// typ is the declared type as written, e.g. "Int!" or "[String]".
+	typ        string
+	nonNull    bool
+	defaultVal interface{}
+	hasDefault bool
+}
+
+// selection is a field selected from an object, with its arguments and the
+// fields selected from its value.
+type selection struct {
+	alias, name string
+	args        []argument
+	selections  []selection
+}
+
+// key returns the name the field's value is returned under.
+func (s selection) key() string {
+	if s.alias != "" {
+		return s.alias
+	}
+	return s.name
+}
+
+// argument is a field argument as written; its value may refer to
+// variables.
+type argument struct {
+	name  string
+	value interface{}
+}
+
+// variable is a reference to a variable in an argument value.
+type variable string
+
+// token kinds.
+const (
+	tokenEOF = iota
+	tokenPunct
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind int
+	text string
+	pos  int
+}
+
+// parser reads an operation from a query document.
+type parser struct {
+	src string
+	pos int
+	tok token
+}
+
+// parse parses a document holding a single query operation.
+func parse(src string) (*operation, error) {
+	p := &parser{src: src}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	op, err := p.operation()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tokenEOF {
+		return nil, p.errorf("only one operation per document is supported")
+	}
+	return op, nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	line, col := 1, 1
+	for _, r := range p.src[:p.tok.pos] {
+		if r == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	return fmt.Errorf("syntax error at %d:%d: %s", line, col, fmt.Sprintf(format, args...))
+}
+
+// describe returns the current token as error messages show it.
+func (p *parser) describe() string {
+	if p.tok.kind == tokenEOF {
+		return "end of query"
+	}
+	return strconv.Quote(p.tok.text)
+}
+
+// is reports whether the current token is the punctuator or name.
+func (p *parser) is(kind int, text string) bool {
+	return p.tok.kind == kind && p.tok.text == text
+}
+
+// expect consumes the punctuator or fails.
+func (p *parser) expect(punct string) error {
+	if !p.is(tokenPunct, punct) {
+		return p.errorf("expected %q, found %s", punct, p.describe())
+	}
+	return p.next()
+}
+
+// name consumes a name or fails.
+func (p *parser) name() (string, error) {
+	if p.tok.kind != tokenName {
+		return "", p.errorf("expected a name, found %s", p.describe())
+	}
+	name := p.tok.text
+	return name, p.next()
+}
+
+func (p *parser) operation() (*operation, error) {
+	op := &operation{}
+	if p.tok.kind == tokenName {
+		switch p.tok.text {
+		case "query":
+		case "mutation", "subscription", "fragment":
+			return nil, p.errorf("%s definitions are not supported", p.tok.text)
+		default:
+			return nil, p.errorf("unexpected %s", p.describe())
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind == tokenName {
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+		}
+		if p.is(tokenPunct, "(") {
+			defs, err := p.variableDefinitions()
+			if err != nil {
+				return nil, err
+			}
+			op.variables = defs
+		}
+	}
+	selections, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.selections = selections
+	return op, nil
+}
+
+func (p *parser) variableDefinitions() ([]variableDefinition, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var defs []variableDefinition
+	for !p.is(tokenPunct, ")") {
+		if err := p.expect("$"); err != nil {
+			return nil, err
+		}
+		var def variableDefinition
+		var err error
+		if def.name, err = p.name(); err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		if def.typ, err = p.typeRef(); err != nil {
+			return nil, err
+		}
+		def.nonNull = strings.HasSuffix(def.typ, "!")
+		if p.is(tokenPunct, "=") {
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			if def.defaultVal, err = p.value(true); err != nil {
+				return nil, err
+			}
+			def.hasDefault = true
+		}
+		defs = append(defs, def)
+	}
+	return defs, p.next()
+}
+
+// typeRef reads a type such as "Int", "String!" or "[Int!]".
+func (p *parser) typeRef() (string, error) {
+	var typ string
+	if p.is(tokenPunct, "[") {
+		if err := p.next(); err != nil {
+			return "", err
+		}
+		inner, err := p.typeRef()
+		if err != nil {
+			return "", err
+		}
+		if err := p.expect("]"); err != nil {
+			return "", err
+		}
+		typ = "[" + inner + "]"
+	} else {
+		name, err := p.name()
+		if err != nil {
+			return "", err
+		}
+		typ = name
+	}
+	if p.is(tokenPunct, "!") {
+		typ += "!"
+		return typ, p.next()
+	}
+	return typ, nil
+}
+
+func (p *parser) selectionSet() ([]selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var selections []selection
+	for !p.is(tokenPunct, "}") {
+		switch {
+		case p.is(tokenPunct, "..."):
+			return nil, p.errorf("fragments are not supported")
+		case p.is(tokenPunct, "@"):
+			return nil, p.errorf("directives are not supported")
+		}
+		sel, err := p.field()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, sel)
+	}
+	if len(selections) == 0 {
+		return nil, p.errorf("empty selection")
+	}
+	return selections, p.next()
+}
+
+func (p *parser) field() (selection, error) {
+	var sel selection
+	name, err := p.name()
+	if err != nil {
+		return sel, err
+	}
+	sel.name = name
+	if p.is(tokenPunct, ":") {
+		if err := p.next(); err != nil {
+			return sel, err
+		}
+		sel.alias = name
+		if sel.name, err = p.name(); err != nil {
+			return sel, err
+		}
+	}
+	if p.is(tokenPunct, "(") {
+		if err := p.next(); err != nil {
+			return sel, err
+		}
+		for !p.is(tokenPunct, ")") {
+			var arg argument
+			if arg.name, err = p.name(); err != nil {
+				return sel, err
+			}
+			if err := p.expect(":"); err != nil {
+				return sel, err
+			}
+			if arg.value, err = p.value(false); err != nil {
+				return sel, err
+			}
+			sel.args = append(sel.args, arg)
+		}
+		if err := p.next(); err != nil {
+			return sel, err
+		}
+	}
+	if p.is(tokenPunct, "@") {
+		return sel, p.errorf("directives are not supported")
+	}
+	if p.is(tokenPunct, "{") {
+		if sel.selections, err = p.selectionSet(); err != nil {
+			return sel, err
+		}
+	}
+	return sel, nil
+}
+
+// value reads an argument or default value. Enum values are read as
+// strings. Constant values, such as defaults, may not refer to variables.
+func (p *parser) value(constant bool) (interface{}, error) {
+	tok := p.tok
+	switch {
+	case p.is(tokenPunct, "$"):
+		if constant {
+			return nil, p.errorf("variables are not allowed here")
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		return variable(name), err
+	case p.is(tokenPunct, "["):
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		list := []interface{}{}
+		for !p.is(tokenPunct, "]") {
+			v, err := p.value(constant)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, p.next()
+	case p.is(tokenPunct, "{"):
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		object := map[string]interface{}{}
+		for !p.is(tokenPunct, "}") {
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			if object[name], err = p.value(constant); err != nil {
+				return nil, err
+			}
+		}
+		return object, p.next()
+	case tok.kind == tokenInt:
+		n, err := strconv.Atoi(tok.text)
+		if err != nil {
+			return nil, p.errorf("integer %s out of range", tok.text)
+		}
+		return n, p.next()
+	case tok.kind == tokenFloat:
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %s", tok.text)
+		}
+		return f, p.next()
+	case tok.kind == tokenString:
+		return tok.text, p.next()
+	case tok.kind == tokenName:
+		var v interface{}
+		switch tok.text {
+		case "true":
+			v = true
+		case "false":
+			v = false
+		case "null":
+			v = nil
+		default:
+			v = tok.text
+		}
+		return v, p.next()
+	}
+	return nil, p.errorf("expected a value, found %s", p.describe())
+}
+
+// next reads the next token, skipping white space, commas and comments.
+func (p *parser) next() error {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',' {
+			p.pos++
+		} else if c == '#' {
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		} else if strings.HasPrefix(p.src[p.pos:], "\uFEFF") {
+			p.pos += len("\uFEFF")
+		} else {
+			break
+		}
+	}
+	start := p.pos
+	p.tok = token{kind: tokenEOF, pos: start}
+	if p.pos >= len(p.src) {
+		return nil
+	}
+
+	c := p.src[p.pos]
+	switch {
+	case strings.HasPrefix(p.src[p.pos:], "..."):
+		p.pos += 3
+		p.tok = token{kind: tokenPunct, text: "...", pos: start}
+	case strings.IndexByte("!$()[]{}:=@", c) >= 0:
+		p.pos++
+		p.tok = token{kind: tokenPunct, text: string(c), pos: start}
+	case c == '_' || isLetter(c):
+		for p.pos < len(p.src) && (p.src[p.pos] == '_' || isLetter(p.src[p.pos]) || isDigit(p.src[p.pos])) {
+			p.pos++
+		}
+		p.tok = token{kind: tokenName, text: p.src[start:p.pos], pos: start}
+	case c == '-' || isDigit(c):
+		return p.number()
+	case c == '"':
+		return p.string()
+	default:
+		r, _ := utf8.DecodeRuneInString(p.src[p.pos:])
+		p.tok.pos = start
+		return p.errorf("unexpected character %q", r)
+	}
+	return nil
+}
+
+func (p *parser) number() error {
+	start := p.pos
+	kind := tokenInt
+	if p.src[p.pos] == '-' {
+		p.pos++
+	}
+	digits := func() int {
+		n := 0
+		for p.pos < len(p.src) && isDigit(p.src[p.pos]) {
+			p.pos++
+			n++
+		}
+		return n
+	}
+	ok := digits() > 0
+	if ok && p.pos < len(p.src) && p.src[p.pos] == '.' {
+		p.pos++
+		kind = tokenFloat
+		ok = digits() > 0
+	}
+	if ok && p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+		p.pos++
+		kind = tokenFloat
+		if p.pos < len(p.src) && (p.src[p.pos] == '+' || p.src[p.pos] == '-') {
+			p.pos++
+		}
+		ok = digits() > 0
+	}
+	p.tok = token{kind: kind, text: p.src[start:p.pos], pos: start}
+	if !ok {
+		return p.errorf("invalid number %q", p.tok.text)
+	}
+	return nil
+}
+
+func (p *parser) string() error {
+	start := p.pos
+	p.tok = token{pos: start}
+	if strings.HasPrefix(p.src[p.pos:], `"""`) {
+		return p.errorf("block strings are not supported")
+	}
+	p.pos++
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case '\\':
+			p.pos += 2
+			continue
+		case '\n':
+			return p.errorf("unterminated string")
+		case '"':
+			p.pos++
+			text, err := strconv.Unquote(p.src[start:p.pos])
+			if err != nil {
+				return p.errorf("invalid string %s", p.src[start:p.pos])
+			}
+			p.tok = token{kind: tokenString, text: text, pos: start}
+			return nil
+		}
+		p.pos++
+	}
+	return p.errorf("unterminated string")
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
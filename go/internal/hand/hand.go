@@ -0,0 +1,145 @@
+// Package hand provides the player hand model shared across the trainer.
+//
+// A Hand is the ordered list of cards the player holds. Card values follow the
+// convention used throughout the program:
+// - 2-10: Pip value (face cards count as 10)
+// - 11: Ace
+//
+// All derived properties (total, softness, pair status, and which actions are
+// available) are computed from the cards themselves, so callers never have to
+// keep a separate hand type or total in sync with the cards being displayed.
+package hand
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Ace is the card value used to represent an ace.
+const Ace = 11
+
+// Hand represents the cards held by the player.
+type Hand struct {
+	Cards []int
+}
+
+// New creates a hand from the given card values.
+func New(cards ...int) Hand {
+	return Hand{Cards: append([]int(nil), cards...)}
+}
+
+// Total returns the best total for the hand, counting one ace as 11 when
+// doing so does not bust the hand.
+func (h Hand) Total() int {
+	total, _ := h.evaluate()
+	return total
+}
+
+// IsSoft reports whether the hand contains an ace currently counted as 11.
+func (h Hand) IsSoft() bool {
+	_, soft := h.evaluate()
+	return soft
+}
+
+// IsPair reports whether the hand is exactly two cards of the same value.
+func (h Hand) IsPair() bool {
+	return len(h.Cards) == 2 && h.Cards[0] == h.Cards[1]
+}
+
+// PairCard returns the card value of a pair, or 0 if the hand is not a pair.
+func (h Hand) PairCard() int {
+	if !h.IsPair() {
+		return 0
+	}
+	return h.Cards[0]
+}
+
+// CanDouble reports whether the hand may be doubled (first two cards only).
+func (h Hand) CanDouble() bool {
+	return len(h.Cards) == 2
+}
+
+// CanSplit reports whether the hand may be split into two hands.
+func (h Hand) CanSplit() bool {
+	return h.IsPair()
+}
+
+// String returns the cards as a comma-separated list (e.g. "A, 7").
+func (h Hand) String() string {
+	parts := make([]string, len(h.Cards))
+	for i, card := range h.Cards {
+		parts[i] = CardString(card)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ParseCard parses a card name: 2-10, J, Q, K, T (ten), or A (ace).
+func ParseCard(s string) (int, error) {
+	name := strings.ToUpper(strings.TrimSpace(s))
+	switch name {
+	case "A", "ACE", "1", "11":
+		return Ace, nil
+	case "T", "J", "Q", "K", "10":
+		return 10, nil
+	}
+	value, err := strconv.Atoi(name)
+	if err != nil || value < 2 || value > 9 {
+		return 0, fmt.Errorf("invalid card %q", strings.TrimSpace(s))
+	}
+	return value, nil
+}
+
+// Parse parses a hand of two or more cards separated by spaces, commas,
+// dashes, slashes, or plus signs (e.g. "A 7", "10-6", "8,8"). The String
+// form of a hand parses back to the same cards. Busted hands are rejected.
+func Parse(s string) (Hand, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return strings.ContainsRune(" ,-/+;", r)
+	})
+	if len(fields) < 2 {
+		return Hand{}, fmt.Errorf("hand %q needs at least two cards", strings.TrimSpace(s))
+	}
+
+	cards := make([]int, 0, len(fields))
+	for _, field := range fields {
+		card, err := ParseCard(field)
+		if err != nil {
+			return Hand{}, err
+		}
+		cards = append(cards, card)
+	}
+	h := Hand{Cards: cards}
+	if h.Total() > 21 {
+		return Hand{}, fmt.Errorf("hand %q is busted", s)
+	}
+	return h, nil
+}
+
+// evaluate computes the hand total and whether an ace is counted as 11.
+func (h Hand) evaluate() (int, bool) {
+	total := 0
+	aces := 0
+	for _, card := range h.Cards {
+		if card == Ace {
+			aces++
+			total++
+		} else {
+			total += card
+		}
+	}
+
+	// At most one ace can ever count as 11 without busting
+	if aces > 0 && total+10 <= 21 {
+		return total + 10, true
+	}
+	return total, false
+}
+
+// CardString converts a card value to its display string.
+func CardString(card int) string {
+	if card == Ace {
+		return "A"
+	}
+	return fmt.Sprintf("%d", card)
+}
@@ -0,0 +1,159 @@
+package hand
+
+import (
+	"testing"
+)
+
+// Test totals, softness, and pair detection for representative hands
+func TestHandProperties(t *testing.T) {
+	tests := []struct {
+		name  string
+		cards []int
+		total int
+		soft  bool
+		pair  bool
+	}{
+		{"hard 16", []int{10, 6}, 16, false, false},
+		{"soft 18", []int{Ace, 7}, 18, true, false},
+		{"aces", []int{Ace, Ace}, 12, true, true},
+		{"eights", []int{8, 8}, 16, false, true},
+		{"tens", []int{10, 10}, 20, false, true},
+		{"ace counted as one", []int{Ace, 7, 9}, 17, false, false},
+		{"soft multi-card", []int{Ace, 2, 4}, 17, true, false},
+		{"two aces and nine", []int{Ace, Ace, 9}, 21, true, false},
+		{"single card", []int{9}, 9, false, false},
+		{"blackjack", []int{Ace, 10}, 21, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := New(tt.cards...)
+			if total := h.Total(); total != tt.total {
+				t.Errorf("%v: expected total %d, got %d", tt.cards, tt.total, total)
+			}
+			if soft := h.IsSoft(); soft != tt.soft {
+				t.Errorf("%v: expected soft=%v, got %v", tt.cards, tt.soft, soft)
+			}
+			if pair := h.IsPair(); pair != tt.pair {
+				t.Errorf("%v: expected pair=%v, got %v", tt.cards, tt.pair, pair)
+			}
+		})
+	}
+}
+
+// Test available actions depend on the number and values of cards
+func TestAvailableActions(t *testing.T) {
+	if !New(5, 6).CanDouble() {
+		t.Error("Two-card hand should be able to double")
+	}
+	if New(2, 3, 6).CanDouble() {
+		t.Error("Three-card hand should not be able to double")
+	}
+	if !New(8, 8).CanSplit() {
+		t.Error("Pair of 8s should be able to split")
+	}
+	if New(10, 6).CanSplit() {
+		t.Error("Non-pair should not be able to split")
+	}
+}
+
+// Test pair card value and string formatting
+func TestPairCardAndString(t *testing.T) {
+	if card := New(Ace, Ace).PairCard(); card != Ace {
+		t.Errorf("A,A pair card should be %d, got %d", Ace, card)
+	}
+	if card := New(10, 6).PairCard(); card != 0 {
+		t.Errorf("Non-pair pair card should be 0, got %d", card)
+	}
+	if s := New(Ace, 7).String(); s != "A, 7" {
+		t.Errorf("Expected \"A, 7\", got %q", s)
+	}
+}
+
+// Test New copies the card slice
+func TestNewCopiesCards(t *testing.T) {
+	cards := []int{10, 6}
+	h := New(cards...)
+	cards[0] = 2
+	if h.Cards[0] != 10 {
+		t.Errorf("Hand should not share storage with caller, got %v", h.Cards)
+	}
+}
+
+// Test hands are parsed from the usual notations
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []int
+	}{
+		{"A 7", []int{Ace, 7}},
+		{"10-6", []int{10, 6}},
+		{"8,8", []int{8, 8}},
+		{"K/q", []int{10, 10}},
+		{"5+4+3", []int{5, 4, 3}},
+		{"A, 7", []int{Ace, 7}},
+	}
+	for _, tt := range tests {
+		h, err := Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.input, err)
+			continue
+		}
+		if !equalCards(h.Cards, tt.want) {
+			t.Errorf("Parse(%q) = %v, want %v", tt.input, h.Cards, tt.want)
+		}
+	}
+
+	for _, input := range []string{"", "A", "A 12", "X 5", "10 10 5"} {
+		if _, err := Parse(input); err == nil {
+			t.Errorf("Parse(%q) should fail", input)
+		}
+	}
+
+	// Errors quote the input without surrounding spaces
+	if _, err := Parse(" 9 "); err == nil || err.Error() != `hand "9" needs at least two cards` {
+		t.Errorf("Parse(\" 9 \") error = %v", err)
+	}
+	if _, err := ParseCard(" 12"); err == nil || err.Error() != `invalid card "12"` {
+		t.Errorf("ParseCard(\" 12\") error = %v", err)
+	}
+}
+
+// Fuzz hand parsing: no input panics, and anything that parses is a legal
+// unbusted hand whose String form parses back to the same cards
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{"A 7", "10-6", "8,8", "5+4+3", "K/Q", "a,2;3", " 9 - 2 "} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		h, err := Parse(input)
+		if err != nil {
+			return
+		}
+		if len(h.Cards) < 2 || h.Total() > 21 {
+			t.Fatalf("Parse(%q) = %v: not a legal hand", input, h.Cards)
+		}
+		for _, card := range h.Cards {
+			if card < 2 || card > Ace {
+				t.Fatalf("Parse(%q) = %v: invalid card %d", input, h.Cards, card)
+			}
+		}
+		again, err := Parse(h.String())
+		if err != nil || !equalCards(again.Cards, h.Cards) {
+			t.Fatalf("Parse(%q) round trip: %v, %v", h.String(), again.Cards, err)
+		}
+	})
+}
+
+func equalCards(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,432 @@
+// Package help holds the trainer's command-line help as structured data:
+// the commands, flags, session types and examples shown by -help, and
+// longer topics (rules, notation, modes, payouts, counting) shown by "help TOPIC".
+// The same data is rendered as plain text for the terminal and as a troff
+// man page, so the two never drift apart.
+package help
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Program is the name of the binary, as used in usage lines.
+const Program = "blackjack_trainer"
+
+// Summary is the one-line description of the program.
+const Summary = "memorize blackjack basic strategy through interactive practice"
+
+// Description introduces the program at the top of the help.
+const Description = `A terminal-based application to help memorize optimal blackjack strategy
+through interactive practice sessions.`
+
+// Flag is a global command-line flag.
+type Flag struct {
+	Name string
+	// Arg names the flag's value, e.g. "string"; empty for a boolean flag.
+	Arg  string
+	Text string
+}
+
+// Command is a subcommand, given after the flags.
+type Command struct {
+	Name string
+	// Usage lists the command's synopses, without the program name.
+	Usage []string
+	Text  string
+}
+
+// SessionType is a practice session chosen with -session.
+type SessionType struct {
+	Name string
+	Text string
+}
+
+// Example is an example command line and what it does.
+type Example struct {
+	Command string
+	Comment string
+}
+
+// Topic is a longer explanation shown by "help NAME".
+type Topic struct {
+	Name  string
+	Title string
+	Text  string
+}
+
+// Flags returns the global flags in the order they are listed.
+func Flags() []Flag {
+	return []Flag{
+		{"session", "string", "Session type: random, dealer, hand, absolute, realistic, composition, peek, ladder, exam"},
+		{"difficulty", "string", `Difficulty level: easy, normal, hard, adaptive (default "normal")`},
+		{"auto-difficulty", "", "Raise or ease the difficulty by your accuracy over the last 10 questions"},
+		{"confirm-absolutes", "", `Ask "Are you sure?" before recording a broken always/never rule at easy difficulty (overrides config)`},
+		{"rate", "", "Offer to rate each question 1-3 for how hard it felt, after feedback (overrides config)"},
+		{"detailed-feedback", "", "Add the odds behind each play to feedback, such as the chance of busting if you hit (overrides config)"},
+		{"coach", "", `Show the odds before each answer in the realistic mode: bust chance, dealer outcomes
+and the EV of each action; coached answers count as hinted (overrides config)`},
+		{"speak", "", "Read scenarios and results aloud (uses say or espeak)"},
+		{"sound", "string", `Audio cues for answers and streaks: bell (terminal bell), files (sound files
+from the config), off. Overrides the config's sound setting`},
+		{"large-print", "", "Show hands in large ASCII-art characters with high-contrast labels, for low vision (overrides config)"},
+		{"overlay", "dest", "Render each scenario as a PNG to a file (name.png) or serve it at host:port, for streaming"},
+		{"spectate", "addr", `Let a coach watch your sessions live at host:port (e.g. 0.0.0.0:8092) and send notes
+shown with your feedback: prints a link with a key to open in a browser or give to the watch command`},
+		{"keys", "string", "Key scheme: letters, numbers, vim (overrides config)"},
+		{"config", "string", "Path to config file (default in user config directory)"},
+		{"duration", "value", "End sessions after a time budget (e.g. 10m) instead of a question count"},
+		{"goal", "int", "End sessions once this percent of answers are right, e.g. 90 (overrides config)"},
+		{"goal-questions", "int", "Fewest answers a -goal is judged over (default 20, overrides config)"},
+		{"share", "", "Print a shareable summary card after each session"},
+		{"event-log", "file", "Append a JSON record of every question to file (off by default)"},
+		{"max-repeat", "int", "Most consecutive questions with the same correct action (default 3, 0 for no limit)"},
+		{"skips", "int", "Questions that may be skipped per session with n (default 3, 0 for none)"},
+		{"repeat-misses", "", "Ask missed hands again 3-5 questions later and near the end"},
+		{"rules", "string", `Table rules the chart is adjusted for (default "standard"):
+standard, vegas-strip, atlantic-city, european, single-deck-downtown`},
+		{"game", "string", `Blackjack variant: classic, free-bet (default "classic")`},
+		{"chart", "file", `Practice with a chart file written by "chart export" (sets its own rules)`},
+		{"live-prep", "", "Show the table hand signal for the correct action (overrides config)"},
+		{"record", "file", "Record the session's seed, rules and input to file (with -session)"},
+		{"replay", "file", "Play back a session recorded with -record, e.g. from a bug report"},
+		{"secure-rng", "", "Draw questions from a cryptographically secure generator, so they can't be predicted"},
+		{"tag", "string", "Drill the hands you tagged with this name ('t name' after an answer)"},
+		{"focus", "spec", "Drill only the cells a constraint spec allows (e.g. \"action=double dealer=2-6\"), a built-in drill (doubles, splits, ten-ace), or a drill named in the config"},
+		{"review", "int", `Percent of random-session questions that review cells you haven't
+practiced for weeks, e.g. 10 (default 0, none)`},
+		{"plan", "file", `Follow a multi-day practice plan file, or the built-in 30-day
+"bootcamp"; progress shows on the menu as "Day 3 of 30"
+(-plan off to stop following it)`},
+		{"verbose", "", "Log diagnostic details to standard error (same as -log-level debug)"},
+		{"log-level", "string", "Log level: debug, info, warn, error (default warn, info for serve)"},
+		{"json", "", `Print JSON instead of text from selftest, lookup, stats, summary,
+replay -list, chart, edge, simulate, tags, certificates, cheatsheet and aggregate`},
+		{"version", "", "Print the version, commit, build date and chart version (JSON with -json)"},
+		{"help", "", "Show this help message"},
+	}
+}
+
+// Commands returns the subcommands in the order they are listed.
+func Commands() []Command {
+	return []Command{
+		{"selftest", []string{"selftest"}, "Validate the strategy chart (coverage, legal actions, consistency)"},
+		{"lookup", []string{"lookup HAND vs DEALER"}, "Show the correct play for a hand, e.g. lookup 10,6 vs 10"},
+		{"stats", []string{"stats"}, "Show your lifetime statistics by hand type, dealer strength and rules"},
+		{"summary", []string{"summary [-days n] [-send]"}, `Summarize the last week's practice (-days n for another period)
+-send: post it to the configured webhook and email it, e.g. weekly from cron`},
+		{"report", []string{"report [-o file] [-sort rating]"}, "Write an HTML dashboard of your statistics (default blackjack_report.html); -sort rating puts the heatmap rows you rated hardest first"},
+		{"cheatsheet", []string{"cheatsheet [-n count] [-markdown] [-o file]"}, "Print a one-page card of your 20 weakest cells with their plays and mnemonics, from your current statistics"},
+		{"dashboard", []string{"dashboard [-watch interval] [-user name] [-data dir]"}, `Show all your statistics on one screen: heatmap, trend, categories, recent sessions and what to practice next
+-watch: redraw every interval, e.g. 5s (Enter redraws now, q quits); -user: a server user's statistics, from -data as serve uses it`},
+		{"watch", []string{"watch LINK"}, "Follow a session live from the link a trainer run with -spectate prints; lines you type are sent to the student as notes"},
+		{"sync", []string{"sync [-url url]"}, "Merge your history with a remote copy (WebDAV, S3, or any HTTP store)"},
+		{"telemetry", []string{"telemetry [preview|send]"}, `Show whether anonymous error-rate reporting is on (off unless enabled in the config)
+preview: print the next report as JSON; send: send it now`},
+		{"import", []string{"import [-dry-run] file.csv"}, "Merge a CSV export from another strategy trainer into your history"},
+		{"replay", []string{"replay [-list] [-all] [n]"}, "Play back a recorded session question by question (default most recent)"},
+		{"chart", []string{"chart compare [--rules a] --rules b", "chart export [-o file]"}, `compare: list the chart cells that differ between two rule sets
+export: write the chart as an editable text file (default standard output)`},
+		{"etiquette", []string{"etiquette [-n count]"}, "Quiz table procedure: hand signals, touching cards, doubling, surrender"},
+		{"edge", []string{"edge [--rules name] [-rsa]"}, `Estimate the house edge of the table rules, with what each rule adds or takes away
+-rsa: the table allows resplitting aces, which the rule presets don't record`},
+		{"simulate", []string{"simulate [-rounds n] [-workers n] [-seed n] [-all]"}, "Check every chart cell's play against the simulated EV of the alternatives"},
+		{"run-script", []string{"run-script [-update] file..."}, "Play session scripts and compare the output with golden transcripts"},
+		{"tutorial", []string{"tutorial"}, "Walk through the actions, hand notation and dealer groups (shown on first launch)"},
+		{"daily", []string{"daily [-status]"}, `Ask one question, record it, and print the streak ("BJ: 14-day streak") for a prompt or status bar
+-status: only print the streak line; exits 0 once today's practice is done`},
+		{"challenge", []string{"challenge"}, `Play today's challenge: the same 20 questions for every player, with a score card to share
+Only the first try each day counts; running it again shows that try's card`},
+		{"duo", []string{"duo [-n count] [-stats] [NAME NAME]"}, `Two players at one terminal answer each question on their own, then talk through any disagreement
+-stats: each player's accuracy alone, in disagreements and as a team`},
+		{"tags", []string{"tags"}, "List the tags you have given hands, with how many hands carry each"},
+		{"certificates", []string{"certificates [-print n] [-name name] [-o file]"}, "List the exams you have passed; -print n writes a printable certificate"},
+		{"quiz", []string{
+			"quiz create -name name [-n count | -hands list] [-time limit] [-rules rules] [-o file]",
+			"quiz code file",
+			"quiz [-name student] [-o file] CODE|file",
+		}, `create: write a quiz file of fixed hands for a class and print its code
+code: print the code for a quiz file you have edited
+CODE|file: take a quiz and write a result file for the instructor`},
+		{"aggregate", []string{"aggregate file|directory..."}, `Merge students' quiz result files (or directories of them) into a
+class report showing the cells the class misses`},
+		{"rules", []string{"rules [-reset]"}, `Answer questions about your casino's table to set your rules (asked
+on first launch too); -reset goes back to the standard rules`},
+		{"calendar", []string{"calendar [-o file] [-start date] [-at time] [-minutes n] [plan]"}, `Write an .ics calendar with a reminder for each day left of your practice plan
+(or every day of the plan named); default practice_plan.ics at 19:00`},
+		{"serve", []string{"serve [-addr host:port] [-data dir] [-open-registration] [-rate-limit n] [-add-user name] [-link name=provider:id]"}, "Run the HTTP training server for many users (-add-user creates an account, -link links a sign-in identity to one)"},
+		{"help", []string{"help [topic]"}, "Show this help, or explain a topic in depth:\n" + strings.Join(TopicNames(), ", ")},
+		{"man", []string{"man [-o file]"}, "Write this help as a man page (view it with man -l file)"},
+	}
+}
+
+// CommandNames returns the names of the subcommands.
+func CommandNames() []string {
+	var names []string
+	for _, c := range Commands() {
+		names = append(names, c.Name)
+	}
+	return names
+}
+
+// SessionTypes returns the session types in the order they are listed.
+func SessionTypes() []SessionType {
+	return []SessionType{
+		{"random", "Mixed practice with all hand types and dealer cards"},
+		{"dealer", "Practice by dealer strength groups (weak/medium/strong)"},
+		{"hand", "Focus on specific hand types (hard/soft/pairs)"},
+		{"absolute", "Practice absolute rules (always/never scenarios)"},
+		{"realistic", "Hands dealt from a six-deck shoe at real-game frequencies"},
+		{"composition", "Advanced: hands where the exact cards change the play (e.g. multi-card 16 vs 10)"},
+		{"peek", "Doubles and splits vs 10 or A that change when the dealer doesn't peek for blackjack"},
+		{"ladder", "Soft 13-18 vs 2-7 in ladder order, then shuffled, to learn where soft hands double"},
+		{"exam", "50 questions from the whole chart, no take-backs; 90% passes and earns a certificate"},
+	}
+}
+
+// Examples returns the example command lines.
+func Examples() []Example {
+	return []Example{
+		{"blackjack_trainer", "Interactive mode"},
+		{"blackjack_trainer -session random", "Quick practice"},
+		{"blackjack_trainer daily -status", "For a tmux status bar: set -g status-right '#(blackjack_trainer daily -status)'"},
+		{"blackjack_trainer challenge", "Today's 20 questions, the same for everyone; paste the card to compare"},
+		{"blackjack_trainer duo Ana Ben", "Practice as a pair and compare your answers"},
+		{"blackjack_trainer -overlay localhost:8091", "Stream the question: add http://localhost:8091/ as an OBS browser source"},
+		{"blackjack_trainer -session dealer", "Dealer groups"},
+		{"blackjack_trainer -session hand -difficulty hard", ""},
+		{"blackjack_trainer -rules european", "Practice the no-hole-card chart"},
+		{"blackjack_trainer chart compare --rules vegas --rules european", ""},
+		{"blackjack_trainer -game free-bet -session random", ""},
+		{"blackjack_trainer -rules vegas edge -rsa", "House edge of a Strip game that resplits aces"},
+		{"blackjack_trainer simulate -rounds 1000000", "Full-chart EV check on all CPUs"},
+		{"blackjack_trainer lookup A,7 vs 9", "What's the play?"},
+		{"blackjack_trainer -json stats | jq .accuracy", "Use your statistics in scripts"},
+		{"blackjack_trainer run-script internal/script/testdata/*.script", ""},
+		{"blackjack_trainer -session random -record bug.script", "Attach bug.script to a bug report"},
+		{"blackjack_trainer -replay bug.script", "Warns if recorded with another chart version"},
+		{"blackjack_trainer -tag confusing", `Drill the hands you tagged "confusing"`},
+		{"blackjack_trainer -session exam -rules european", ""},
+		{`blackjack_trainer certificates -print 1 -name "Pat Dealer" -o certificate.txt`, ""},
+		{`blackjack_trainer quiz create -name "Week 1" -n 20 -time 10m -o week1.quiz`, ""},
+		{`blackjack_trainer quiz -name "Pat Dealer" BJQ1.H4sIA...`, "Take a quiz from its code"},
+		{"blackjack_trainer aggregate results/", "Class report from collected result files"},
+		{"blackjack_trainer rules", "Describe your table instead of naming -rules"},
+		{"blackjack_trainer dashboard -watch 5s -user alice", "Follow a student live while serve runs"},
+		{"blackjack_trainer -spectate 0.0.0.0:8092", "Print a link for a coach to watch live"},
+		{"blackjack_trainer watch 'http://student:8092/?key=...'", "Watch from a second terminal"},
+		{"blackjack_trainer -session random -review 25", "A quarter of questions review old cells"},
+		{"blackjack_trainer -plan two-weeks.toml", "Follow a practice plan (P on the menu)"},
+		{"blackjack_trainer -plan bootcamp", "Follow the built-in 30-day bootcamp"},
+		{"blackjack_trainer calendar -at 07:30", "Add the plan's remaining days to your calendar"},
+		{"blackjack_trainer help notation", "How hands and dealer cards are written"},
+		{"blackjack_trainer man -o blackjack_trainer.1", "Install the man page"},
+	}
+}
+
+// Topics returns the help topics in the order they are listed.
+func Topics() []Topic {
+	return []Topic{
+		{
+			Name:  "rules",
+			Title: "Table Rules",
+			Text: `Basic strategy depends on the rules of the table. The trainer adjusts the
+chart for these rules:
+
+Decks              1 to 8. Fewer decks favor doubling slightly more often.
+Soft 17            S17: the dealer stands on soft 17. H17: the dealer hits
+                   it, which adds doubles such as 11 vs A and soft 19 vs 6.
+Double after split DAS lets you double a hand made by splitting, so more
+                   small pairs are worth splitting.
+Doubling           Any two cards, or only hard 9, 10 and 11 (European).
+Surrender          Late surrender gives up half the bet after the dealer
+                   checks for blackjack: 16 vs 9, 10 and A; 15 vs 10.
+Hole card          Without a hole card (European) the dealer checks for
+                   blackjack only after you play, so don't double or split
+                   more money against a 10 or ace.
+Blackjack pays     3:2 or 6:5. The payout doesn't change the chart, but 6:5
+                   costs you far more than any strategy mistake.
+
+Choose a preset with -rules (standard, vegas-strip, atlantic-city, european,
+single-deck-downtown), describe your casino with "blackjack_trainer rules",
+or switch from the menu with Table Rules. "chart compare" lists what changes
+between two rule sets, and the Rules Quiz on the menu checks you know them.`,
+		},
+		{
+			Name:  "notation",
+			Title: "Hand Notation",
+			Text: `Cards are written 2 through 10 and A for an ace. Jacks, queens and kings
+count 10 and are written as 10.
+
+Hard 16   No ace, or an ace that must count 1: 10,6 or 9,4,3
+Soft 18   An ace counting 11 that can drop to 1: A,7
+Pair 8    Two cards of the same rank, which you may split: 8,8
+
+A cell of the chart is a hand type and total against the dealer's up card:
+"Hard 16 vs 10", "Soft 18 vs 9", "Pair 8,8 vs A". Commands that take a hand,
+such as lookup and quiz create -hands, accept cards separated by commas or
+spaces, then "vs" and the dealer card: 10,6 vs 10 or A 7 vs 9.
+
+Actions are HIT, STAND, DOUBLE and SPLIT, answered with H, S, D and P
+(Y also splits) unless you choose another key scheme with -keys. The words
+themselves work too, as do unambiguous prefixes and aliases such as sta, dd
+and sp; a small typo like "stnad" is forgiven. After an
+answer, u takes back a mistyped answer, e simulates the outcomes, l opens
+the lesson, and t NAME tags the hand. q quits and h? shows help at any
+prompt.
+
+Dealer up cards fall into strength groups: weak (4, 5, 6), medium (2, 3,
+7, 8) and strong (9, 10, A).`,
+		},
+		{
+			Name:  "modes",
+			Title: "Practice Modes",
+			Text: `Quick Practice (-session random) mixes every hand type and dealer card;
+-review adds a share of questions for cells you haven't seen in weeks.
+
+Dealer Groups (dealer) and Hand Types (hand) drill one part of the chart
+at a time: the weak, medium or strong dealer cards, or hard totals, soft
+totals or pairs.
+
+Absolutes (absolute) covers the always-and-never plays: always split aces
+and eights, never split fives and tens, always stand on hard 17 and up and
+soft 19 and up.
+
+Realistic (realistic) deals from a six-deck shoe, so hands come up as often
+as at a real table. Composition (composition) asks the hands where the
+exact cards change the play, such as a three-card 16 vs 10.
+
+Exam (exam) asks 50 questions from the whole chart with no take-backs;
+scoring 90% earns a certificate.
+
+-difficulty easy, hard and adaptive weight questions toward trivial cells,
+tricky cells, or the cells you know least. Practice plans (-plan) string
+modes together over several days, and -tag drills the hands you tagged.`,
+		},
+		{
+			Name:  "payouts",
+			Title: "Blackjack Payouts",
+			Text: `A blackjack (an ace and a ten as the first two cards) traditionally pays
+3:2, winning $15 on a $10 bet. Many tables now pay 6:5, winning $12. The
+payout doesn't change a single play on the chart, but it changes the game
+more than any other rule.
+
+You are dealt a blackjack about once in 21 hands, and it is paid unless the
+dealer has one too. A 6:5 payout wins 0.3 of a bet less each time, so it
+adds to the house edge:
+
+1 deck     1.39% of each bet
+2 decks    1.37%
+6 decks    1.36%
+8 decks    1.36%
+
+That is about $14 more lost for every 100 hands of $10, several times what
+H17, no doubling after splits or no surrender cost together. A 6:5 single
+deck game is worse than a 3:2 eight deck shoe. The trainer warns when the
+rules pay less than 3:2, and the Rules Quiz asks the payout math.`,
+		},
+		{
+			Name:  "counting",
+			Title: "Card Counting",
+			Text: `This trainer teaches basic strategy, the best play for each hand when
+you don't know which cards are left. It doesn't teach card counting, but
+basic strategy is where counting starts: a counter plays basic strategy on
+almost every hand and only departs from it when the count says the shoe is
+rich or poor in tens and aces.
+
+The most common count, Hi-Lo, adds 1 for each 2 to 6 seen, nothing for 7
+to 9, and subtracts 1 for each 10 or ace. Dividing the running count by the
+decks left gives the true count; the higher it is, the more the remaining
+cards favor the player, who raises the bet. A handful of plays change with
+the true count, such as standing on 16 vs 10 at 0 or more and taking
+insurance at +3.
+
+Know the chart cold first: a counter who hesitates over soft 18 vs 9 gives
+back more than the count wins. The composition mode shows how the exact
+cards in a hand already shift a few close plays. Counting is legal, but
+casinos may ask counters to leave.`,
+		},
+	}
+}
+
+// TopicNames returns the names of the help topics.
+func TopicNames() []string {
+	var names []string
+	for _, t := range Topics() {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+// WriteUsage writes the command-line help to w.
+func WriteUsage(w io.Writer) {
+	fmt.Fprintf(w, "Blackjack Basic Strategy Trainer\n\n%s\n", Description)
+
+	fmt.Fprintln(w, "\nUsage:")
+	fmt.Fprintf(w, "  %s [flags]\n", Program)
+	for _, c := range Commands() {
+		for _, usage := range c.Usage {
+			fmt.Fprintf(w, "  %s %s\n", Program, usage)
+		}
+	}
+
+	fmt.Fprintln(w, "\nFlags:")
+	for _, f := range Flags() {
+		name := "-" + f.Name
+		if f.Arg != "" {
+			name += " " + f.Arg
+		}
+		writeEntry(w, name, 18, f.Text)
+	}
+
+	fmt.Fprintln(w, "\nCommands:")
+	for _, c := range Commands() {
+		writeEntry(w, c.Name, 10, c.Text)
+	}
+
+	fmt.Fprintln(w, "\nSession Types:")
+	for _, s := range SessionTypes() {
+		writeEntry(w, s.Name, 12, s.Text)
+	}
+
+	fmt.Fprintln(w, "\nExamples:")
+	for _, e := range Examples() {
+		if e.Comment == "" {
+			fmt.Fprintf(w, "  %s\n", e.Command)
+			continue
+		}
+		fmt.Fprintf(w, "  %-45s # %s\n", e.Command, e.Comment)
+	}
+
+	fmt.Fprintf(w, "\nHelp topics (%s help TOPIC): %s\n", Program, strings.Join(TopicNames(), ", "))
+	fmt.Fprintln(w, "\nIf no session type is specified, the program will start in interactive mode")
+	fmt.Fprintln(w, "with a menu to choose the practice mode.")
+}
+
+// writeEntry writes a name and its text in two columns, the name padded to
+// width. A name too long for the column goes on a line of its own.
+func writeEntry(w io.Writer, name string, width int, text string) {
+	indent := strings.Repeat(" ", width+3)
+	lines := strings.Split(text, "\n")
+	if len(name) > width {
+		fmt.Fprintf(w, "  %s\n", name)
+	} else {
+		fmt.Fprintf(w, "  %-*s %s\n", width, name, lines[0])
+		lines = lines[1:]
+	}
+	for _, line := range lines {
+		fmt.Fprintf(w, "%s%s\n", indent, line)
+	}
+}
+
+// WriteTopic writes the named topic to w.
+func WriteTopic(w io.Writer, name string) error {
+	for _, t := range Topics() {
+		if strings.EqualFold(t.Name, name) {
+			fmt.Fprintf(w, "%s\n%s\n\n%s\n", t.Title, strings.Repeat("=", len(t.Title)), t.Text)
+			return nil
+		}
+	}
+	return fmt.Errorf("no help topic %q (topics: %s)", name, strings.Join(TopicNames(), ", "))
+}
@@ -0,0 +1,96 @@
+package help
+
+import (
+	"blackjack_trainer/internal/trainer"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// Test the listed session types are the ones the trainer runs
+func TestSessionTypes(t *testing.T) {
+	var names []string
+	for _, s := range SessionTypes() {
+		names = append(names, s.Name)
+	}
+	if got, want := strings.Join(names, ","), strings.Join(trainer.SessionTypes(), ","); got != want {
+		t.Errorf("Session types %s don't match the trainer's %s", got, want)
+	}
+}
+
+// Test the usage lists every command, flag and topic
+func TestWriteUsage(t *testing.T) {
+	var buf bytes.Buffer
+	WriteUsage(&buf)
+	usage := buf.String()
+
+	for _, c := range Commands() {
+		for _, line := range c.Usage {
+			if !strings.Contains(usage, "  "+Program+" "+line+"\n") {
+				t.Errorf("Usage missing %q", line)
+			}
+		}
+		if !strings.Contains(usage, "\n  "+c.Name+" ") && !strings.Contains(usage, "\n  "+c.Name+"\n") {
+			t.Errorf("Commands missing %s", c.Name)
+		}
+	}
+	for _, f := range Flags() {
+		if !strings.Contains(usage, "\n  -"+f.Name+" ") {
+			t.Errorf("Flags missing -%s", f.Name)
+		}
+	}
+	if !strings.Contains(usage, strings.Join(TopicNames(), ", ")) {
+		t.Error("Usage should list the help topics")
+	}
+}
+
+// Test topics are found by name, in any case, and unknown topics are errors
+func TestWriteTopic(t *testing.T) {
+	for _, name := range []string{"rules", "notation", "modes", "counting"} {
+		var buf bytes.Buffer
+		if err := WriteTopic(&buf, strings.ToUpper(name)); err != nil {
+			t.Errorf("Topic %s: %v", name, err)
+		} else if buf.Len() < 200 {
+			t.Errorf("Topic %s is too short:\n%s", name, buf.String())
+		}
+	}
+	if err := WriteTopic(&bytes.Buffer{}, "poker"); err == nil || !strings.Contains(err.Error(), "notation") {
+		t.Errorf("Unknown topic should be an error listing the topics, got %v", err)
+	}
+}
+
+// Test the man page has the standard sections, escapes hyphens, and never
+// starts a text line with a character troff reads as a request
+func TestWriteMan(t *testing.T) {
+	var buf bytes.Buffer
+	WriteMan(&buf)
+	page := buf.String()
+
+	if !strings.HasPrefix(page, ".TH BLACKJACK_TRAINER 1 ") {
+		t.Errorf("Man page should start with a title line, got %q", strings.SplitN(page, "\n", 2)[0])
+	}
+	for _, section := range []string{"NAME", "SYNOPSIS", "DESCRIPTION", "OPTIONS", "COMMANDS", "EXAMPLES", "HAND NOTATION", "CARD COUNTING"} {
+		if !strings.Contains(page, "\n.SH "+section+"\n") {
+			t.Errorf("Man page missing section %s", section)
+		}
+	}
+	if !strings.Contains(page, `.BI \-max\-repeat " int"`) {
+		t.Error("Flags should be escaped option entries")
+	}
+	if !strings.Contains(page, `.B "blackjack_trainer quiz \-name ""Pat Dealer"" BJQ1.H4sIA..."`) {
+		t.Error("Examples should be quoted as single arguments")
+	}
+
+	requests := map[string]bool{".TH": true, ".SH": true, ".TP": true, ".B": true, ".BI": true, ".br": true, ".PP": true, ".nf": true, ".fi": true}
+	for _, line := range strings.Split(page, "\n") {
+		if strings.HasPrefix(line, "'") {
+			t.Errorf("Line starts with a control character: %q", line)
+		}
+		if strings.HasPrefix(line, ".") && !requests[strings.Fields(line)[0]] {
+			t.Errorf("Unexpected request: %q", line)
+		}
+		if strings.Contains(strings.ReplaceAll(line, `\-`, ""), "-") {
+			t.Errorf("Unescaped hyphen: %q", line)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package help
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteMan writes the help as a troff man page in section 1 to w.
+func WriteMan(w io.Writer) {
+	fmt.Fprintf(w, ".TH %s 1 \"\" \"%s\" \"User Commands\"\n", strings.ToUpper(Program), Program)
+
+	fmt.Fprintln(w, ".SH NAME")
+	fmt.Fprintf(w, "%s \\- %s\n", manEscape(Program), manEscape(Summary))
+
+	fmt.Fprintln(w, ".SH SYNOPSIS")
+	fmt.Fprintf(w, ".B %s\n[flags]\n", manEscape(Program))
+	for _, c := range Commands() {
+		for _, usage := range c.Usage {
+			fmt.Fprintf(w, ".br\n.B %s\n%s\n", manEscape(Program), manEscape(usage))
+		}
+	}
+
+	fmt.Fprintln(w, ".SH DESCRIPTION")
+	writeManText(w, Description)
+	fmt.Fprintln(w, ".PP")
+	writeManText(w, "If no session type or command is given, the program starts in interactive mode\nwith a menu to choose the practice mode.")
+
+	fmt.Fprintln(w, ".SH OPTIONS")
+	for _, f := range Flags() {
+		fmt.Fprintln(w, ".TP")
+		if f.Arg == "" {
+			fmt.Fprintf(w, ".B %s\n", manEscape("-"+f.Name))
+		} else {
+			fmt.Fprintf(w, ".BI %s \" %s\"\n", manEscape("-"+f.Name), manEscape(f.Arg))
+		}
+		writeManText(w, f.Text)
+	}
+
+	fmt.Fprintln(w, ".SH COMMANDS")
+	for _, c := range Commands() {
+		fmt.Fprintln(w, ".TP")
+		fmt.Fprintf(w, ".B %s\n", manEscape(c.Name))
+		writeManLines(w, c.Text)
+	}
+
+	fmt.Fprintln(w, ".SH SESSION TYPES")
+	for _, s := range SessionTypes() {
+		fmt.Fprintln(w, ".TP")
+		fmt.Fprintf(w, ".B %s\n", manEscape(s.Name))
+		writeManText(w, s.Text)
+	}
+
+	for _, t := range Topics() {
+		fmt.Fprintf(w, ".SH %s\n", manEscape(strings.ToUpper(t.Title)))
+		for i, paragraph := range strings.Split(t.Text, "\n\n") {
+			if i > 0 {
+				fmt.Fprintln(w, ".PP")
+			}
+			writeManParagraph(w, paragraph)
+		}
+	}
+
+	fmt.Fprintln(w, ".SH EXAMPLES")
+	for _, e := range Examples() {
+		fmt.Fprintln(w, ".TP")
+		fmt.Fprintf(w, ".B %s\n", manArg(e.Command))
+		if e.Comment != "" {
+			writeManText(w, e.Comment)
+		}
+	}
+
+	fmt.Fprintln(w, ".SH FILES")
+	writeManText(w, "The configuration file, practice history, exam results, saved rules, practice\nplan progress and telemetry state are kept in the blackjack_trainer directory of the user\nconfiguration directory (for example ~/.config/blackjack_trainer), unless\n-config names another configuration file.")
+}
+
+// writeManText writes filled text, joining its lines.
+func writeManText(w io.Writer, text string) {
+	for _, line := range strings.Split(text, "\n") {
+		fmt.Fprintln(w, manLine(line))
+	}
+}
+
+// writeManLines writes text keeping each line on its own output line.
+func writeManLines(w io.Writer, text string) {
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			fmt.Fprintln(w, ".br")
+		}
+		fmt.Fprintln(w, manLine(line))
+	}
+}
+
+// writeManParagraph writes a topic paragraph: filled text, unless its lines
+// are laid out in columns, which are kept as they are.
+func writeManParagraph(w io.Writer, paragraph string) {
+	if !strings.Contains(paragraph, "   ") {
+		writeManText(w, paragraph)
+		return
+	}
+	fmt.Fprintln(w, ".nf")
+	writeManText(w, paragraph)
+	fmt.Fprintln(w, ".fi")
+}
+
+// manLine escapes a line of text, protecting a leading period or quote that
+// troff would read as a request.
+func manLine(line string) string {
+	line = manEscape(line)
+	if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+		line = `\&` + line
+	}
+	return line
+}
+
+// manArg quotes s as a single macro argument.
+func manArg(s string) string {
+	return `"` + strings.ReplaceAll(manEscape(s), `"`, `""`) + `"`
+}
+
+// manEscape escapes backslashes and hyphens for troff.
+func manEscape(s string) string {
+	return strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(s)
+}
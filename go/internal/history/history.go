@@ -0,0 +1,535 @@
+// Package history provides persistent storage of completed practice sessions.
+//
+// Each completed session is appended to a JSON file in the trainer's
+// configuration directory, allowing lifetime statistics such as total
+// practice time to be reported across program runs. When a passphrase is
+// given the file is encrypted (see package crypt).
+//
+// The file is replaced atomically, so a crash while saving leaves the
+// previous history intact. A session in progress is checkpointed to a
+// separate file after each question and recovered into the history the next
+// time it is opened if the program stopped before the session was saved.
+package history
+
+import (
+	"blackjack_trainer/internal/atomicfile"
+	"blackjack_trainer/internal/crypt"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FileName is the name of the history file in the trainer's directory.
+const FileName = "history.json"
+
+// CheckpointSuffix is appended to the history file name for the checkpoint
+// of a session in progress.
+const CheckpointSuffix = ".checkpoint"
+
+// ErrEncrypted is returned when an encrypted history is opened without a passphrase.
+var ErrEncrypted = errors.New("history is encrypted; a passphrase is required")
+
+// Attempt records a single answered question.
+type Attempt struct {
+	Cards         []int  `json:"cards"`
+	DealerCard    int    `json:"dealer_card"`
+	HandType      string `json:"hand_type"`
+	Action        string `json:"action"`
+	CorrectAction string `json:"correct_action"`
+	Correct       bool   `json:"correct"`
+	LatencyMs     int64  `json:"latency_ms"`
+	// Tags are names the player gave the hand, such as "confusing", to
+	// drill the hands carrying a tag later.
+	Tags []string `json:"tags,omitempty"`
+	// Rating is how hard the player felt the question was, from 1 (easy)
+	// to 3 (hard), or 0 if they didn't rate it.
+	Rating int `json:"rating,omitempty"`
+	// Hinted is set if the player looked at the mnemonics list while the
+	// question was pending, so the answer wasn't from memory alone.
+	Hinted bool `json:"hinted,omitempty"`
+	// Notes are the notes a coach watching the session sent during the
+	// question, shown with its feedback.
+	Notes []string `json:"notes,omitempty"`
+	// Rules is the name of the rule set the hand was asked under, since the
+	// correct answer depends on it.
+	Rules string `json:"rules,omitempty"`
+	// EVLoss is the expected value, in bets, a wrong answer gave up against
+	// the chart's play, and Severity its class: harmless, minor, costly or
+	// disastrous. Both are empty for correct answers and for mistakes
+	// recorded before they were priced.
+	EVLoss   float64 `json:"ev_loss,omitempty"`
+	Severity string  `json:"severity,omitempty"`
+}
+
+// Latency returns how long the user took to answer.
+func (a Attempt) Latency() time.Duration {
+	return time.Duration(a.LatencyMs) * time.Millisecond
+}
+
+// Session records one completed practice session.
+type Session struct {
+	Mode string `json:"mode"`
+	// Rules is the name of the rule set the session was played under, e.g.
+	// "Vegas Strip". Sessions recorded before rules were noted have none.
+	Rules    string    `json:"rules,omitempty"`
+	Started  time.Time `json:"started"`
+	Ended    time.Time `json:"ended"`
+	Correct  int       `json:"correct"`
+	Total    int       `json:"total"`
+	Attempts []Attempt `json:"attempts,omitempty"`
+	// Corrected holds wrong answers the player took back as slips. They are
+	// not counted in Correct, Total or Attempts; each hand was asked again.
+	Corrected []Attempt `json:"corrected,omitempty"`
+	// Skipped holds questions the player skipped without answering. They
+	// are not counted in Correct, Total or Attempts, and have no Action.
+	Skipped []Attempt `json:"skipped,omitempty"`
+	// EVLoss is the expected value, in bets, given up by the session's
+	// wrong answers. Sessions recorded before it was tracked have none.
+	EVLoss *float64 `json:"ev_loss,omitempty"`
+}
+
+// Duration returns how long the session lasted.
+func (s Session) Duration() time.Duration {
+	return s.Ended.Sub(s.Started)
+}
+
+// EVLossRate returns the EV given up per 100 hands, or false if the
+// session's mistakes weren't priced.
+func (s Session) EVLossRate() (float64, bool) {
+	if s.EVLoss == nil || s.Total == 0 {
+		return 0, false
+	}
+	return *s.EVLoss / float64(s.Total) * 100, true
+}
+
+// BestStreak returns the longest run of consecutive correct answers in the session.
+func (s Session) BestStreak() int {
+	best, current := 0, 0
+	for _, attempt := range s.Attempts {
+		if attempt.Correct {
+			current++
+			if current > best {
+				best = current
+			}
+		} else {
+			current = 0
+		}
+	}
+	return best
+}
+
+// History holds every recorded session.
+type History struct {
+	// Version is the schema version of the stored history. Older versions
+	// are migrated when read.
+	Version  int       `json:"version"`
+	Sessions []Session `json:"sessions"`
+
+	path     string
+	key      *crypt.Key
+	backedUp bool
+}
+
+// New creates an empty in-memory history that is never written to disk.
+func New() *History {
+	return &History{}
+}
+
+// Open loads the history file at path. A missing file gives an empty
+// history that will be created on the first Save.
+func Open(path string) (*History, error) {
+	return OpenEncrypted(path, "")
+}
+
+// OpenEncrypted loads the history file at path, decrypting it with the
+// passphrase. If the passphrase is not empty the history is encrypted when
+// saved, including a file that was previously stored unencrypted.
+func OpenEncrypted(path, passphrase string) (*History, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		h := &History{path: path}
+		if err := h.SetPassphrase(passphrase); err != nil {
+			return nil, err
+		}
+		if err := h.recoverCheckpoint(passphrase); err != nil {
+			return nil, err
+		}
+		return h, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := Decode(data, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	h.path = path
+	if err := h.recoverCheckpoint(passphrase); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Parse decodes a history from its JSON representation, migrating it from
+// older schema versions. The result is in-memory until saved elsewhere.
+func Parse(data []byte) (*History, error) {
+	data, err := migrate(data)
+	if err != nil {
+		return nil, err
+	}
+	h := &History{}
+	if err := json.Unmarshal(data, h); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// Decode decodes a history that may be encrypted. Encrypted data requires
+// the passphrase; unencrypted data is accepted with or without one, and
+// with one the history is encrypted when next encoded.
+func Decode(data []byte, passphrase string) (*History, error) {
+	if !crypt.IsEncrypted(data) {
+		h, err := Parse(data)
+		if err != nil {
+			return nil, err
+		}
+		if err := h.SetPassphrase(passphrase); err != nil {
+			return nil, err
+		}
+		return h, nil
+	}
+
+	if passphrase == "" {
+		return nil, ErrEncrypted
+	}
+	plaintext, key, err := crypt.Open(data, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	h, err := Parse(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	h.key = key
+	return h, nil
+}
+
+// SetPassphrase sets the passphrase used to encrypt the history when it is
+// encoded. An empty passphrase stores the history unencrypted.
+func (h *History) SetPassphrase(passphrase string) error {
+	if passphrase == "" {
+		h.key = nil
+		return nil
+	}
+	key, err := crypt.NewKey(passphrase)
+	if err != nil {
+		return err
+	}
+	h.key = key
+	return nil
+}
+
+// Encrypted reports whether the history is encrypted when encoded.
+func (h *History) Encrypted() bool {
+	return h.key != nil
+}
+
+// Encode returns the history in its stored form: JSON, encrypted if the
+// history has a passphrase.
+func (h *History) Encode() ([]byte, error) {
+	data, err := h.Marshal()
+	if err != nil || h.key == nil {
+		return data, err
+	}
+	return h.key.Seal(data)
+}
+
+// Copy returns an in-memory copy of the history with the same sessions and
+// encryption.
+func (h *History) Copy() *History {
+	return &History{Sessions: append([]Session(nil), h.Sessions...), key: h.key}
+}
+
+// Marshal encodes the history as JSON in the current schema version.
+func (h *History) Marshal() ([]byte, error) {
+	h.Version = CurrentVersion
+	return json.MarshalIndent(h, "", "  ")
+}
+
+// Path returns the file the history is saved to, or "" for in-memory history.
+func (h *History) Path() string {
+	return h.path
+}
+
+// Add appends a session to the history.
+func (h *History) Add(s Session) {
+	h.Sessions = append(h.Sessions, s)
+}
+
+// Save atomically writes the history to its file and discards any
+// checkpoint, which the saved history supersedes. The first save after
+// opening backs up the previous file (see Backups). In-memory histories are
+// not saved.
+func (h *History) Save() error {
+	if h.path == "" {
+		return nil
+	}
+
+	data, err := h.Encode()
+	if err != nil {
+		return err
+	}
+	if !h.backedUp {
+		if err := backup(h.path, time.Now()); err != nil {
+			return fmt.Errorf("backing up %s: %w", h.path, err)
+		}
+		h.backedUp = true
+	}
+	if err := atomicfile.WriteFile(h.path, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Remove(h.path + CheckpointSuffix); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Checkpoint saves a session in progress, without adding it to the history,
+// so it can be recovered if the program stops before the session is saved.
+// Each checkpoint replaces the previous one. In-memory histories are not
+// checkpointed.
+func (h *History) Checkpoint(s Session) error {
+	if h.path == "" {
+		return nil
+	}
+	checkpoint := &History{Sessions: []Session{s}, key: h.key}
+	data, err := checkpoint.Encode()
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(h.path+CheckpointSuffix, data, 0o600)
+}
+
+// DiscardCheckpoint removes the checkpoint of a session in progress, for a
+// session that will not be saved.
+func (h *History) DiscardCheckpoint() error {
+	if h.path == "" {
+		return nil
+	}
+	if err := os.Remove(h.path + CheckpointSuffix); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// recoverCheckpoint adds the session from a leftover checkpoint file to the
+// history and saves it.
+func (h *History) recoverCheckpoint(passphrase string) error {
+	path := h.path + CheckpointSuffix
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	checkpoint, err := Decode(data, passphrase)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", path, err)
+	}
+	h.Merge(checkpoint)
+	return h.Save()
+}
+
+// Merge adds sessions from other that are not already present, keeping
+// sessions ordered by start time. Sessions are identified by mode and start
+// time. Returns the number of sessions added.
+func (h *History) Merge(other *History) int {
+	seen := make(map[string]bool)
+	for _, s := range h.Sessions {
+		seen[s.key()] = true
+	}
+
+	added := 0
+	for _, s := range other.Sessions {
+		if !seen[s.key()] {
+			seen[s.key()] = true
+			h.Sessions = append(h.Sessions, s)
+			added++
+		}
+	}
+
+	sort.SliceStable(h.Sessions, func(i, j int) bool {
+		return h.Sessions[i].Started.Before(h.Sessions[j].Started)
+	})
+	return added
+}
+
+// Contains reports whether a session with the same identity is present.
+func (h *History) Contains(s Session) bool {
+	for _, existing := range h.Sessions {
+		if existing.key() == s.key() {
+			return true
+		}
+	}
+	return false
+}
+
+// key identifies a session for merging.
+func (s Session) key() string {
+	return fmt.Sprintf("%s@%d", s.Mode, s.Started.UnixNano())
+}
+
+// TotalDuration returns the time spent across all sessions.
+func (h *History) TotalDuration() time.Duration {
+	var total time.Duration
+	for _, s := range h.Sessions {
+		total += s.Duration()
+	}
+	return total
+}
+
+// Attempts returns every recorded attempt across all sessions, oldest first.
+// An attempt that doesn't name its rules takes its session's.
+func (h *History) Attempts() []Attempt {
+	var attempts []Attempt
+	for _, s := range h.Sessions {
+		for _, a := range s.Attempts {
+			if a.Rules == "" {
+				a.Rules = s.Rules
+			}
+			attempts = append(attempts, a)
+		}
+	}
+	return attempts
+}
+
+// NormalizeTag returns a tag as it is stored: lower case, with the words of
+// a multi-word tag joined by hyphens. A blank tag gives "".
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.Join(strings.Fields(tag), "-"))
+}
+
+// HasTag reports whether the attempt carries tag.
+func (a Attempt) HasTag(tag string) bool {
+	for _, t := range a.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// handKey identifies the hand an attempt asked about.
+func (a Attempt) handKey() string {
+	return fmt.Sprint(a.Cards, a.DealerCard)
+}
+
+// Tags returns the number of distinct hands carrying each tag. Hands taken
+// back as slips count, since a slip is often what prompts a tag.
+func (h *History) Tags() map[string]int {
+	seen := make(map[string]map[string]bool)
+	for _, a := range h.taggable() {
+		for _, tag := range a.Tags {
+			if seen[tag] == nil {
+				seen[tag] = make(map[string]bool)
+			}
+			seen[tag][a.handKey()] = true
+		}
+	}
+	counts := make(map[string]int, len(seen))
+	for tag, hands := range seen {
+		counts[tag] = len(hands)
+	}
+	return counts
+}
+
+// Tagged returns one attempt for each distinct hand, its cards and the
+// dealer card, that carries tag, in the order the hands were first tagged.
+func (h *History) Tagged(tag string) []Attempt {
+	var tagged []Attempt
+	seen := make(map[string]bool)
+	for _, a := range h.taggable() {
+		if a.HasTag(tag) && !seen[a.handKey()] {
+			seen[a.handKey()] = true
+			tagged = append(tagged, a)
+		}
+	}
+	return tagged
+}
+
+// taggable returns the attempts of every session, including those taken
+// back as slips, oldest session first.
+func (h *History) taggable() []Attempt {
+	var attempts []Attempt
+	for _, s := range h.Sessions {
+		attempts = append(attempts, s.Attempts...)
+		attempts = append(attempts, s.Corrected...)
+	}
+	return attempts
+}
+
+// Accuracy returns the lifetime accuracy percentage and number of attempts.
+func (h *History) Accuracy() (float64, int) {
+	correct, total := 0, 0
+	for _, s := range h.Sessions {
+		correct += s.Correct
+		total += s.Total
+	}
+	if total == 0 {
+		return 0.0, 0
+	}
+	return (float64(correct) / float64(total)) * 100.0, total
+}
+
+// DurationSince returns the time spent in sessions started at or after t.
+func (h *History) DurationSince(t time.Time) time.Duration {
+	var total time.Duration
+	for _, s := range h.Sessions {
+		if !s.Started.Before(t) {
+			total += s.Duration()
+		}
+	}
+	return total
+}
+
+// DayStreak returns the number of consecutive days, ending today or
+// yesterday relative to now, on which at least one session was started.
+func (h *History) DayStreak(now time.Time) int {
+	practiced := make(map[time.Time]bool)
+	for _, s := range h.Sessions {
+		practiced[StartOfDay(s.Started.In(now.Location()))] = true
+	}
+
+	day := StartOfDay(now)
+	if !practiced[day] {
+		// A streak is still alive if the last practice was yesterday
+		day = day.AddDate(0, 0, -1)
+	}
+
+	streak := 0
+	for practiced[day] {
+		streak++
+		day = day.AddDate(0, 0, -1)
+	}
+	return streak
+}
+
+// StartOfDay returns midnight at the start of t's day in t's location.
+func StartOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// StartOfWeek returns midnight at the start of the Monday of t's week.
+func StartOfWeek(t time.Time) time.Time {
+	daysSinceMonday := (int(t.Weekday()) + 6) % 7
+	return StartOfDay(t).AddDate(0, 0, -daysSinceMonday)
+}
@@ -0,0 +1,416 @@
+package history
+
+import (
+	"blackjack_trainer/internal/crypt"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Test sessions survive a save and reload
+func TestSaveAndOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", FileName)
+
+	h, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open of missing file failed: %v", err)
+	}
+	if len(h.Sessions) != 0 {
+		t.Fatalf("New history should be empty, got %d sessions", len(h.Sessions))
+	}
+
+	started := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	h.Add(Session{Mode: "random", Started: started, Ended: started.Add(5 * time.Minute), Correct: 8, Total: 10})
+	if err := h.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	reloaded, err := Open(path)
+	if err != nil {
+		t.Fatalf("Reopen failed: %v", err)
+	}
+	if len(reloaded.Sessions) != 1 {
+		t.Fatalf("Expected 1 session, got %d", len(reloaded.Sessions))
+	}
+	s := reloaded.Sessions[0]
+	if s.Mode != "random" || s.Correct != 8 || s.Total != 10 || s.Duration() != 5*time.Minute {
+		t.Errorf("Reloaded session does not match: %+v", s)
+	}
+}
+
+// Test malformed history files are reported
+func TestOpenMalformed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte("not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(path); err == nil {
+		t.Error("Expected error for malformed history")
+	}
+}
+
+// Test in-memory histories are never written
+func TestInMemorySave(t *testing.T) {
+	h := New()
+	h.Add(Session{Mode: "random"})
+	if err := h.Save(); err != nil {
+		t.Errorf("In-memory save should succeed: %v", err)
+	}
+	if h.Path() != "" {
+		t.Errorf("In-memory history should have no path, got %q", h.Path())
+	}
+}
+
+// Test duration totals across sessions and time windows
+func TestDurations(t *testing.T) {
+	base := time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC) // Wednesday
+	h := New()
+	h.Add(Session{Started: base.AddDate(0, 0, -10), Ended: base.AddDate(0, 0, -10).Add(20 * time.Minute)})
+	h.Add(Session{Started: base.AddDate(0, 0, -1), Ended: base.AddDate(0, 0, -1).Add(10 * time.Minute)})
+	h.Add(Session{Started: base, Ended: base.Add(5 * time.Minute)})
+
+	if total := h.TotalDuration(); total != 35*time.Minute {
+		t.Errorf("Expected 35m total, got %v", total)
+	}
+	if today := h.DurationSince(StartOfDay(base)); today != 5*time.Minute {
+		t.Errorf("Expected 5m today, got %v", today)
+	}
+	if week := h.DurationSince(StartOfWeek(base)); week != 15*time.Minute {
+		t.Errorf("Expected 15m this week, got %v", week)
+	}
+}
+
+// Test week boundaries start on Monday
+func TestStartOfWeek(t *testing.T) {
+	monday := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	for offset := 0; offset < 7; offset++ {
+		day := monday.AddDate(0, 0, offset).Add(15 * time.Hour)
+		if start := StartOfWeek(day); !start.Equal(monday) {
+			t.Errorf("%s: expected week start %s, got %s", day.Weekday(), monday, start)
+		}
+	}
+}
+
+// Test lifetime accuracy and attempt aggregation
+func TestAccuracyAndAttempts(t *testing.T) {
+	h := New()
+	if accuracy, total := h.Accuracy(); accuracy != 0.0 || total != 0 {
+		t.Errorf("Empty history accuracy should be 0/0, got %f/%d", accuracy, total)
+	}
+
+	h.Add(Session{Correct: 3, Total: 4, Attempts: []Attempt{{DealerCard: 2}, {DealerCard: 3}}})
+	h.Add(Session{Correct: 1, Total: 4, Attempts: []Attempt{{DealerCard: 4}}})
+
+	if accuracy, total := h.Accuracy(); accuracy != 50.0 || total != 8 {
+		t.Errorf("Expected 50%% over 8 attempts, got %f over %d", accuracy, total)
+	}
+
+	attempts := h.Attempts()
+	if len(attempts) != 3 || attempts[0].DealerCard != 2 || attempts[2].DealerCard != 4 {
+		t.Errorf("Attempts should be concatenated oldest first, got %+v", attempts)
+	}
+
+	if latency := (Attempt{LatencyMs: 1500}).Latency(); latency != 1500*time.Millisecond {
+		t.Errorf("Expected 1.5s latency, got %v", latency)
+	}
+}
+
+// Test tag normalization and lookup of tagged hands
+func TestTags(t *testing.T) {
+	for input, want := range map[string]string{
+		"confusing":         "confusing",
+		" Table Saw  This ": "table-saw-this",
+		"   ":               "",
+	} {
+		if got := NormalizeTag(input); got != want {
+			t.Errorf("NormalizeTag(%q) = %q, want %q", input, got, want)
+		}
+	}
+
+	h := New()
+	h.Add(Session{
+		Attempts: []Attempt{
+			{Cards: []int{10, 6}, DealerCard: 10, Tags: []string{"confusing"}},
+			{Cards: []int{8, 8}, DealerCard: 6},
+		},
+		Corrected: []Attempt{{Cards: []int{11, 7}, DealerCard: 9, Tags: []string{"confusing", "table-saw-this"}}},
+	})
+	h.Add(Session{Attempts: []Attempt{
+		{Cards: []int{10, 6}, DealerCard: 10, Tags: []string{"confusing"}},
+		{Cards: []int{10, 6}, DealerCard: 9, Tags: []string{"confusing"}},
+	}})
+
+	tags := h.Tags()
+	if len(tags) != 2 || tags["confusing"] != 3 || tags["table-saw-this"] != 1 {
+		t.Errorf("Expected 3 confusing hands and 1 table-saw-this, got %v", tags)
+	}
+
+	tagged := h.Tagged("confusing")
+	if len(tagged) != 3 || tagged[0].DealerCard != 10 || tagged[1].DealerCard != 9 || tagged[1].Cards[0] != 11 ||
+		tagged[2].DealerCard != 9 || tagged[2].Cards[0] != 10 {
+		t.Errorf("Expected each confusing hand once, in order, got %+v", tagged)
+	}
+	if tagged := h.Tagged("missing"); len(tagged) != 0 {
+		t.Errorf("Expected no hands for an unused tag, got %+v", tagged)
+	}
+}
+
+// Test best answer streak within a session
+func TestBestStreak(t *testing.T) {
+	s := Session{Attempts: []Attempt{
+		{Correct: true}, {Correct: true}, {Correct: false},
+		{Correct: true}, {Correct: true}, {Correct: true}, {Correct: false},
+	}}
+	if streak := s.BestStreak(); streak != 3 {
+		t.Errorf("Expected best streak 3, got %d", streak)
+	}
+	if streak := (Session{}).BestStreak(); streak != 0 {
+		t.Errorf("Empty session streak should be 0, got %d", streak)
+	}
+}
+
+// Test consecutive practice day counting
+func TestDayStreak(t *testing.T) {
+	now := time.Date(2024, 3, 6, 18, 0, 0, 0, time.UTC)
+	at := func(daysAgo int) Session {
+		started := now.AddDate(0, 0, -daysAgo).Add(-time.Hour)
+		return Session{Started: started, Ended: started.Add(time.Minute)}
+	}
+
+	h := New()
+	if streak := h.DayStreak(now); streak != 0 {
+		t.Errorf("Empty history streak should be 0, got %d", streak)
+	}
+
+	h.Add(at(5))
+	h.Add(at(2))
+	h.Add(at(1))
+	if streak := h.DayStreak(now); streak != 2 {
+		t.Errorf("Streak ending yesterday should be 2, got %d", streak)
+	}
+
+	h.Add(at(0))
+	h.Add(at(0))
+	if streak := h.DayStreak(now); streak != 3 {
+		t.Errorf("Streak including today should be 3, got %d", streak)
+	}
+}
+
+// Test merging adds only unseen sessions and keeps chronological order
+func TestMerge(t *testing.T) {
+	base := time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)
+	local := New()
+	local.Add(Session{Mode: "random", Started: base})
+	local.Add(Session{Mode: "random", Started: base.Add(2 * time.Hour)})
+
+	remote := New()
+	remote.Add(Session{Mode: "random", Started: base})
+	remote.Add(Session{Mode: "absolutes", Started: base.Add(time.Hour)})
+
+	if added := local.Merge(remote); added != 1 {
+		t.Errorf("Expected 1 session added, got %d", added)
+	}
+	if len(local.Sessions) != 3 {
+		t.Fatalf("Expected 3 sessions after merge, got %d", len(local.Sessions))
+	}
+	if local.Sessions[1].Mode != "absolutes" {
+		t.Errorf("Merged sessions should be ordered by start time, got %+v", local.Sessions)
+	}
+	if added := local.Merge(remote); added != 0 {
+		t.Errorf("Merging again should add nothing, got %d", added)
+	}
+	if !local.Contains(Session{Mode: "absolutes", Started: base.Add(time.Hour)}) {
+		t.Error("Merged session should be contained")
+	}
+}
+
+// Test JSON round trip through Marshal and Parse
+func TestMarshalParse(t *testing.T) {
+	h := New()
+	h.Add(Session{Mode: "random", Correct: 1, Total: 2})
+
+	data, err := h.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	parsed, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(parsed.Sessions) != 1 || parsed.Sessions[0].Total != 2 {
+		t.Errorf("Round trip mismatch: %+v", parsed.Sessions)
+	}
+	if parsed.Path() != "" {
+		t.Error("Parsed history should be in-memory")
+	}
+}
+
+// Test encrypted histories round trip and require the passphrase
+func TestEncryptedHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+
+	// An existing unencrypted file is encrypted on the next save
+	plain, _ := Open(path)
+	plain.Add(Session{Mode: "random", Correct: 3, Total: 4})
+	if err := plain.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := OpenEncrypted(path, "secret")
+	if err != nil {
+		t.Fatalf("Opening plaintext with a passphrase failed: %v", err)
+	}
+	if !h.Encrypted() || len(h.Sessions) != 1 {
+		t.Fatalf("Expected encrypted history with 1 session, got %+v", h)
+	}
+	if err := h.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if bytes.Contains(data, []byte("random")) {
+		t.Error("Saved file should not contain plaintext")
+	}
+
+	if _, err := Open(path); !errors.Is(err, ErrEncrypted) {
+		t.Errorf("Expected ErrEncrypted without passphrase, got %v", err)
+	}
+	if _, err := OpenEncrypted(path, "wrong"); !errors.Is(err, crypt.ErrWrongPassphrase) {
+		t.Errorf("Expected ErrWrongPassphrase, got %v", err)
+	}
+
+	reopened, err := OpenEncrypted(path, "secret")
+	if err != nil || len(reopened.Sessions) != 1 || reopened.Sessions[0].Correct != 3 {
+		t.Fatalf("Reopen failed: %+v, %v", reopened, err)
+	}
+
+	// Clearing the passphrase stores the history unencrypted again
+	reopened.SetPassphrase("")
+	reopened.Save()
+	if _, err := Open(path); err != nil {
+		t.Errorf("Decrypted history should open without passphrase: %v", err)
+	}
+}
+
+// Test a checkpointed session is recovered when the history is next opened
+func TestCheckpointRecovery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	h, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)
+	h.Add(Session{Mode: "random", Started: started.Add(-time.Hour), Ended: started.Add(-50 * time.Minute), Correct: 5, Total: 5})
+	if err := h.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Checkpoints replace each other and do not change the history
+	for total := 1; total <= 3; total++ {
+		err := h.Checkpoint(Session{Mode: "absolute", Started: started, Ended: started.Add(time.Minute), Correct: total, Total: total})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(h.Sessions) != 1 {
+		t.Errorf("Checkpoint should not add to the history, got %d sessions", len(h.Sessions))
+	}
+
+	// Reopening, as after a crash, recovers the latest checkpoint
+	recovered, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recovered.Sessions) != 2 || recovered.Sessions[1].Total != 3 {
+		t.Fatalf("Expected the checkpointed session recovered, got %+v", recovered.Sessions)
+	}
+	if _, err := os.Stat(path + CheckpointSuffix); !os.IsNotExist(err) {
+		t.Error("Checkpoint should be removed once recovered")
+	}
+
+	// Saving a completed session discards its checkpoint
+	recovered.Checkpoint(Session{Mode: "random", Started: started.Add(time.Hour), Total: 1})
+	recovered.Add(Session{Mode: "random", Started: started.Add(time.Hour), Total: 2})
+	if err := recovered.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if reopened, _ := Open(path); len(reopened.Sessions) != 3 || reopened.Sessions[2].Total != 2 {
+		t.Errorf("Saved session should supersede its checkpoint, got %+v", reopened.Sessions)
+	}
+
+	// Discarding a checkpoint leaves nothing to recover
+	recovered.Checkpoint(Session{Mode: "random", Started: started.Add(2 * time.Hour), Total: 1})
+	if err := recovered.DiscardCheckpoint(); err != nil {
+		t.Fatal(err)
+	}
+	if reopened, _ := Open(path); len(reopened.Sessions) != 3 {
+		t.Errorf("Discarded checkpoint should not be recovered, got %+v", reopened.Sessions)
+	}
+
+	// In-memory histories are never checkpointed
+	if err := New().Checkpoint(Session{Mode: "random"}); err != nil {
+		t.Errorf("In-memory checkpoint should be a no-op, got %v", err)
+	}
+}
+
+// Test unversioned history is migrated and newer versions are rejected
+func TestMigrate(t *testing.T) {
+	legacy := `{"sessions": [{"mode": "random", "correct": 1, "total": 1,
+		"attempts": [{"cards": [8, 8], "dealer_card": 6, "action": "Y", "correct_action": "Y", "correct": true}]}]}`
+	h, err := Parse([]byte(legacy))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if a := h.Sessions[0].Attempts[0]; a.Action != "Y" || !a.Correct {
+		t.Errorf("Expected the attempt unchanged, got %+v", a)
+	}
+
+	data, _ := h.Marshal()
+	if !bytes.Contains(data, []byte(`"version": 2`)) {
+		t.Errorf("Marshaled history should record the current version:\n%s", data)
+	}
+
+	var newer ErrNewerVersion
+	if _, err := Parse([]byte(`{"version": 99, "sessions": []}`)); !errors.As(err, &newer) || newer.Version != 99 {
+		t.Errorf("Expected ErrNewerVersion, got %v", err)
+	}
+}
+
+// Test the first save of each run backs up the previous file, keeping the newest few
+func TestBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte(`{"sessions": []}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Backups of a run are taken once, before its first save
+	h, _ := Open(path)
+	h.Add(Session{Mode: "random", Started: time.Now()})
+	h.Save()
+	h.Save()
+	backups, _ := Backups(path)
+	if len(backups) != 1 {
+		t.Fatalf("Expected 1 backup, got %v", backups)
+	}
+	if data, _ := os.ReadFile(backups[0]); string(data) != `{"sessions": []}` {
+		t.Errorf("Backup should hold the previous file, got %s", data)
+	}
+
+	// Older backups are rotated out
+	start := time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < MaxBackups+2; i++ {
+		if err := backup(path, start.Add(time.Duration(i)*time.Second)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	backups, _ = Backups(path)
+	if len(backups) != MaxBackups {
+		t.Fatalf("Expected %d backups, got %d", MaxBackups, len(backups))
+	}
+	if want := path + ".20240306-120003.bak"; backups[0] != want {
+		t.Errorf("Expected oldest remaining backup %s, got %s", want, backups[0])
+	}
+}
@@ -0,0 +1,120 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// CurrentVersion is the schema version written by this program.
+//
+// Version history:
+//
+//	1  unversioned files written before the schema was versioned
+//	2  adds the version field
+const CurrentVersion = 2
+
+// MaxBackups is how many timestamped backups of a history file are kept.
+const MaxBackups = 5
+
+// backupSuffix ends the names of backup files, which are named
+// <file>.<timestamp>.bak beside the history file.
+const backupSuffix = ".bak"
+
+// backupTimeFormat is the timestamp in backup file names. It sorts in
+// chronological order.
+const backupTimeFormat = "20060102-150405"
+
+// migrations[v] converts a version v document to version v+1. Documents are
+// migrated as generic JSON so a migration can rename or restructure fields
+// that no longer exist in the History type.
+var migrations = map[int]func(doc map[string]interface{}) error{
+	1: addVersion,
+}
+
+// ErrNewerVersion is returned for history written by a newer version of the
+// trainer, which this version cannot read without risking data loss.
+type ErrNewerVersion struct {
+	Version int
+}
+
+func (e ErrNewerVersion) Error() string {
+	return fmt.Sprintf("history schema version %d is newer than supported version %d; upgrade the trainer",
+		e.Version, CurrentVersion)
+}
+
+// migrate upgrades a JSON history document to CurrentVersion.
+func migrate(data []byte) ([]byte, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	version := 1
+	if v, ok := doc["version"].(float64); ok {
+		version = int(v)
+	}
+	if version > CurrentVersion {
+		return nil, ErrNewerVersion{Version: version}
+	}
+	if version == CurrentVersion {
+		return data, nil
+	}
+
+	for ; version < CurrentVersion; version++ {
+		if err := migrations[version](doc); err != nil {
+			return nil, fmt.Errorf("migrating history from version %d: %w", version, err)
+		}
+	}
+	doc["version"] = CurrentVersion
+	return json.Marshal(doc)
+}
+
+// addVersion converts version 1 to 2. Only the version field is new, and
+// migrate sets that once every migration has run.
+func addVersion(doc map[string]interface{}) error {
+	return nil
+}
+
+// backup copies the file at path to a timestamped backup beside it and
+// removes all but the newest MaxBackups backups. A missing file is not
+// backed up.
+func backup(path string, now time.Time) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	name := path + "." + now.Format(backupTimeFormat) + backupSuffix
+	if err := os.WriteFile(name, data, 0o600); err != nil {
+		return err
+	}
+
+	backups, err := Backups(path)
+	if err != nil {
+		return err
+	}
+	for len(backups) > MaxBackups {
+		if err := os.Remove(backups[0]); err != nil {
+			return err
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// Backups returns the backup files of the history file at path, oldest first.
+func Backups(path string) ([]string, error) {
+	matches, err := filepath.Glob(path + ".*" + backupSuffix)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+	return matches, nil
+}
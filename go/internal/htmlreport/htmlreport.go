@@ -0,0 +1,319 @@
+// Package htmlreport renders persisted practice statistics as a standalone
+// HTML dashboard.
+//
+// The generated page needs no server or external assets: styling is inline
+// and charts are drawn with SVG. It contains:
+// - Summary figures (sessions, questions, accuracy, practice time, streak)
+// - Accuracy under each rule set practiced
+// - Accuracy heatmaps for the chart's cells, optionally hardest-rated rows first
+// - An accuracy trend line across recent sessions
+package htmlreport
+
+import (
+	"blackjack_trainer/internal/history"
+	"blackjack_trainer/internal/stats"
+	"blackjack_trainer/internal/strategy"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TrendSessions is the number of most recent sessions shown in the trend chart.
+const TrendSessions = 50
+
+// Trend chart dimensions in SVG user units.
+const (
+	trendWidth   = 600
+	trendHeight  = 200
+	trendPadding = 30
+)
+
+type cellView struct {
+	Text  string
+	Title string
+	Style template.CSS
+}
+
+type rowView struct {
+	Label  string
+	Cells  []cellView
+	rating float64 // mean difficulty rating of the row's attempts, 0 if unrated
+}
+
+type tableView struct {
+	Title string
+	Rows  []rowView
+}
+
+type pointView struct {
+	X, Y  float64
+	Title string
+}
+
+type trendView struct {
+	Width, Height int
+	Points        []pointView
+	Polyline      string
+	Gridlines     []pointView
+}
+
+type rulesView struct {
+	Rules    string
+	Score    string
+	Accuracy string
+}
+
+type pageData struct {
+	Generated    string
+	Sessions     int
+	Questions    int
+	Accuracy     string
+	PracticeTime string
+	DayStreak    int
+	ByRules      []rulesView
+	Rules        string
+	Dealers      []string
+	Tables       []tableView
+	SortByRating bool
+	Trend        trendView
+}
+
+// Options controls how the report is rendered.
+type Options struct {
+	// SortByRating orders each heatmap's rows by the difficulty the player
+	// rated their questions, hardest first, with unrated rows last in chart
+	// order.
+	SortByRating bool
+}
+
+// Render writes the HTML report for the history to w.
+func Render(w io.Writer, h *history.History, chart *strategy.StrategyChart, now time.Time, opts Options) error {
+	accuracy, questions := h.Accuracy()
+
+	data := pageData{
+		Generated:    now.Format("2006-01-02 15:04"),
+		Sessions:     len(h.Sessions),
+		Questions:    questions,
+		Accuracy:     fmt.Sprintf("%.1f%%", accuracy),
+		PracticeTime: stats.FormatDuration(h.TotalDuration()),
+		DayStreak:    h.DayStreak(now),
+		Trend:        buildTrend(h.Sessions),
+		Rules:        chart.Rules().Name,
+		SortByRating: opts.SortByRating,
+	}
+	attempts := h.Attempts()
+	for _, r := range stats.ByRules(attempts) {
+		data.ByRules = append(data.ByRules, rulesView{
+			Rules:    r.Rules,
+			Score:    fmt.Sprintf("%d/%d", r.Correct, r.Total),
+			Accuracy: fmt.Sprintf("%.1f%%", r.Accuracy()),
+		})
+	}
+	for dealer := 2; dealer <= 11; dealer++ {
+		data.Dealers = append(data.Dealers, strategy.CardToString(dealer))
+	}
+
+	cells := stats.ByCell(stats.UnderRules(attempts, data.Rules))
+	data.Tables = []tableView{
+		buildTable("Hard Totals", strategy.HandTypeHard, 5, 21, cells, chart),
+		buildTable("Soft Totals", strategy.HandTypeSoft, 13, 21, cells, chart),
+		buildTable("Pairs", strategy.HandTypePair, 2, 11, cells, chart),
+	}
+	if opts.SortByRating {
+		for _, table := range data.Tables {
+			sortByRating(table.Rows)
+		}
+	}
+
+	return pageTemplate.Execute(w, data)
+}
+
+// buildTable builds the heatmap rows for one section of the chart.
+func buildTable(title string, handType strategy.HandType, low, high int,
+	cells map[stats.CellKey]*stats.CategoryData, chart *strategy.StrategyChart) tableView {
+	table := tableView{Title: title}
+
+	for total := low; total <= high; total++ {
+		row := rowView{Label: rowLabel(handType, total)}
+		var rowData stats.CategoryData
+		for dealer := 2; dealer <= 11; dealer++ {
+			key := stats.CellKey{HandType: handType, PlayerTotal: total, DealerCard: dealer}
+			action := chart.GetCorrectAction(handType, total, dealer)
+
+			cell := cellView{
+				Text:  string(action),
+				Title: fmt.Sprintf("%s: %s (not practiced)", key.Label(), strategy.ActionToString(action)),
+				Style: "background:#eeeeee;color:#999999",
+			}
+			if data := cells[key]; data != nil && data.Total > 0 {
+				cell.Text = fmt.Sprintf("%c %.0f%%", action, data.Accuracy())
+				cell.Title = fmt.Sprintf("%s: %s, %d/%d correct", key.Label(),
+					strategy.ActionToString(action), data.Correct, data.Total)
+				cell.Style = template.CSS(fmt.Sprintf("background:%s", heatColor(data.Accuracy())))
+				if data.Rated > 0 {
+					cell.Title += fmt.Sprintf(", rated %.1f of 3", data.MeanRating())
+				}
+				rowData.Rated += data.Rated
+				rowData.RatingSum += data.RatingSum
+			}
+			row.Cells = append(row.Cells, cell)
+		}
+		row.rating = rowData.MeanRating()
+		table.Rows = append(table.Rows, row)
+	}
+	return table
+}
+
+// sortByRating orders rows by their mean difficulty rating, hardest first,
+// keeping unrated rows last in chart order.
+func sortByRating(rows []rowView) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].rating > rows[j].rating
+	})
+}
+
+// rowLabel returns the row heading for a chart row.
+func rowLabel(handType strategy.HandType, total int) string {
+	switch handType {
+	case strategy.HandTypePair:
+		card := strategy.CardToString(total)
+		return card + "," + card
+	case strategy.HandTypeSoft:
+		return fmt.Sprintf("A,%d", total-11)
+	default:
+		return fmt.Sprintf("%d", total)
+	}
+}
+
+// heatColor maps accuracy to a color from red (0%) through yellow to green (100%).
+func heatColor(accuracy float64) string {
+	hue := int(accuracy * 1.2) // 0-120 degrees
+	return fmt.Sprintf("hsl(%d,70%%,75%%)", hue)
+}
+
+// buildTrend computes the SVG trend line for the most recent sessions.
+func buildTrend(sessions []history.Session) trendView {
+	trend := trendView{Width: trendWidth, Height: trendHeight}
+
+	for _, percent := range []float64{0, 50, 100} {
+		trend.Gridlines = append(trend.Gridlines, pointView{Y: trendY(percent), Title: fmt.Sprintf("%.0f%%", percent)})
+	}
+
+	var recent []history.Session
+	for _, s := range sessions {
+		if s.Total > 0 {
+			recent = append(recent, s)
+		}
+	}
+	if len(recent) > TrendSessions {
+		recent = recent[len(recent)-TrendSessions:]
+	}
+	if len(recent) == 0 {
+		return trend
+	}
+
+	step := 0.0
+	if len(recent) > 1 {
+		step = float64(trendWidth-2*trendPadding) / float64(len(recent)-1)
+	}
+
+	var points []string
+	for i, s := range recent {
+		accuracy := float64(s.Correct) / float64(s.Total) * 100.0
+		point := pointView{
+			X: float64(trendPadding) + step*float64(i),
+			Y: trendY(accuracy),
+			Title: fmt.Sprintf("%s %s: %d/%d (%.1f%%)", s.Started.Format("2006-01-02"),
+				s.Mode, s.Correct, s.Total, accuracy),
+		}
+		trend.Points = append(trend.Points, point)
+		points = append(points, fmt.Sprintf("%.1f,%.1f", point.X, point.Y))
+	}
+	trend.Polyline = strings.Join(points, " ")
+	return trend
+}
+
+// trendY converts an accuracy percentage to a chart Y coordinate.
+func trendY(accuracy float64) float64 {
+	return float64(trendHeight-trendPadding) - accuracy/100.0*float64(trendHeight-2*trendPadding)
+}
+
+var pageTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Blackjack Strategy Trainer Report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+h1 { margin-bottom: 0.2em; }
+.generated { color: #777; margin-top: 0; }
+.summary { display: flex; flex-wrap: wrap; gap: 1em; margin: 1.5em 0; }
+.summary div { border: 1px solid #ddd; border-radius: 6px; padding: 0.8em 1.2em; min-width: 8em; }
+.summary .value { font-size: 1.6em; font-weight: bold; }
+.summary .label { color: #777; font-size: 0.9em; }
+.heatmaps { display: flex; flex-wrap: wrap; gap: 2em; }
+table { border-collapse: collapse; font-size: 0.85em; }
+th, td { border: 1px solid #fff; padding: 0.3em 0.5em; text-align: center; min-width: 3.2em; }
+th { background: #444; color: #fff; }
+svg text { font-size: 11px; fill: #777; }
+</style>
+</head>
+<body>
+<h1>Blackjack Strategy Trainer Report</h1>
+<p class="generated">Generated {{.Generated}}</p>
+
+<div class="summary">
+<div><div class="value">{{.Sessions}}</div><div class="label">Sessions</div></div>
+<div><div class="value">{{.Questions}}</div><div class="label">Questions</div></div>
+<div><div class="value">{{.Accuracy}}</div><div class="label">Accuracy</div></div>
+<div><div class="value">{{.PracticeTime}}</div><div class="label">Practice time</div></div>
+<div><div class="value">{{.DayStreak}}</div><div class="label">Day streak</div></div>
+</div>
+
+{{- if .ByRules}}
+<h2>Accuracy by Rules</h2>
+<table>
+<tr><th>Rules</th><th>Questions</th><th>Accuracy</th></tr>
+{{- range .ByRules}}
+<tr><td>{{.Rules}}</td><td>{{.Score}}</td><td>{{.Accuracy}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+
+<h2>Accuracy Heatmaps</h2>
+<p>Each cell shows the correct action under {{.Rules}} rules and your accuracy on hands practiced under them. Gray cells have not been practiced yet.{{if .SortByRating}} Rows are sorted by how hard you rated their questions, hardest first.{{end}}</p>
+<div class="heatmaps">
+{{- range .Tables}}
+<div>
+<h3>{{.Title}}</h3>
+<table>
+<tr><th></th>{{range $.Dealers}}<th>{{.}}</th>{{end}}</tr>
+{{- range .Rows}}
+<tr><th>{{.Label}}</th>{{range .Cells}}<td style="{{.Style}}" title="{{.Title}}">{{.Text}}</td>{{end}}</tr>
+{{- end}}
+</table>
+</div>
+{{- end}}
+</div>
+
+<h2>Accuracy Trend</h2>
+{{- if .Trend.Points}}
+<svg width="{{.Trend.Width}}" height="{{.Trend.Height}}" viewBox="0 0 {{.Trend.Width}} {{.Trend.Height}}">
+{{- range .Trend.Gridlines}}
+<line x1="30" x2="{{$.Trend.Width}}" y1="{{.Y}}" y2="{{.Y}}" stroke="#ddd"/>
+<text x="0" y="{{.Y}}">{{.Title}}</text>
+{{- end}}
+<polyline points="{{.Trend.Polyline}}" fill="none" stroke="#2a7ae2" stroke-width="2"/>
+{{- range .Trend.Points}}
+<circle cx="{{.X}}" cy="{{.Y}}" r="3" fill="#2a7ae2"><title>{{.Title}}</title></circle>
+{{- end}}
+</svg>
+{{- else}}
+<p>No sessions recorded yet.</p>
+{{- end}}
+</body>
+</html>
+`))
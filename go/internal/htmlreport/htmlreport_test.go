@@ -0,0 +1,115 @@
+package htmlreport
+
+import (
+	"blackjack_trainer/internal/history"
+	"blackjack_trainer/internal/strategy"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test the report renders summary figures, accuracy by rules, heatmap cells
+// from the chart's rules, and the trend chart
+func TestRender(t *testing.T) {
+	now := time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)
+	h := history.New()
+	h.Add(history.Session{
+		Mode: "random", Rules: "Standard", Started: now.Add(-time.Hour), Ended: now.Add(-50 * time.Minute),
+		Correct: 1, Total: 2,
+		Attempts: []history.Attempt{
+			{Cards: []int{10, 6}, DealerCard: 10, HandType: "hard", Correct: true},
+			{Cards: []int{11, 7}, DealerCard: 9, HandType: "soft", Correct: false},
+		},
+	})
+	h.Add(history.Session{
+		Mode: "random", Started: now.Add(-30 * time.Minute), Ended: now.Add(-20 * time.Minute),
+		Correct: 0, Total: 1,
+		Attempts: []history.Attempt{
+			{Cards: []int{10, 5}, DealerCard: 10, HandType: "hard", Correct: false, Rules: "Vegas Strip"},
+		},
+	})
+	h.Add(history.Session{Mode: "absolutes", Started: now.Add(-10 * time.Minute), Ended: now, Correct: 4, Total: 4})
+
+	var buf bytes.Buffer
+	if err := Render(&buf, h, strategy.New(), now, Options{}); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	page := buf.String()
+
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"Generated 2024-03-06 12:00",
+		`<div class="value">3</div><div class="label">Sessions</div>`,
+		`<div class="value">71.4%</div>`,
+		"<tr><td>Standard</td><td>1/2</td><td>50.0%</td></tr>",
+		"<tr><td>Vegas Strip</td><td>0/1</td><td>0.0%</td></tr>",
+		"Hard Totals", "Soft Totals", "Pairs",
+		"H 100%",
+		"Hard 16 vs 10: HIT, 1/1 correct",
+		"H 0%",
+		"<polyline",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("Report missing %q", want)
+		}
+	}
+
+	if strings.Contains(page, "Hard 15 vs 10: HIT, 0/1 correct") {
+		t.Error("Heatmaps should leave out hands practiced under other rules")
+	}
+	if strings.Contains(page, "ZgotmplZ") {
+		t.Error("Report contains values rejected by the template escaper")
+	}
+}
+
+// Test heatmap rows can be sorted by the difficulty the player rated them
+func TestRenderSortByRating(t *testing.T) {
+	now := time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)
+	h := history.New()
+	h.Add(history.Session{Mode: "random", Rules: "Standard", Ended: now, Correct: 2, Total: 2,
+		Attempts: []history.Attempt{
+			{Cards: []int{10, 2}, DealerCard: 4, HandType: "hard", Correct: true, Rating: 3},
+			{Cards: []int{10, 6}, DealerCard: 10, HandType: "hard", Correct: true, Rating: 1},
+		},
+	})
+
+	for _, sorted := range []bool{false, true} {
+		var buf bytes.Buffer
+		if err := Render(&buf, h, strategy.New(), now, Options{SortByRating: sorted}); err != nil {
+			t.Fatalf("Render failed: %v", err)
+		}
+		page := buf.String()
+		row12, row16, row5 := strings.Index(page, "<tr><th>12</th>"), strings.Index(page, "<tr><th>16</th>"), strings.Index(page, "<tr><th>5</th>")
+		if sorted && !(row12 < row16 && row16 < row5) {
+			t.Errorf("Sorted rows should put hard 12 (rated 3), then 16 (rated 1), then unrated rows")
+		}
+		if !sorted && !(row5 < row12 && row12 < row16) {
+			t.Errorf("Unsorted rows should stay in chart order")
+		}
+		if !strings.Contains(page, "Hard 12 vs 4: STAND, 1/1 correct, rated 3.0 of 3") {
+			t.Error("Cell titles should show the mean rating")
+		}
+	}
+}
+
+// Test an empty history still renders a valid page
+func TestRenderEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Render(&buf, history.New(), strategy.New(), time.Now(), Options{}); err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "No sessions recorded yet.") {
+		t.Error("Empty report should say no sessions are recorded")
+	}
+}
+
+// Test heat colors run from red to green
+func TestHeatColor(t *testing.T) {
+	if color := heatColor(0); color != "hsl(0,70%,75%)" {
+		t.Errorf("0%% should be red, got %s", color)
+	}
+	if color := heatColor(100); color != "hsl(120,70%,75%)" {
+		t.Errorf("100%% should be green, got %s", color)
+	}
+}
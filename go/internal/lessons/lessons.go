@@ -0,0 +1,271 @@
+// Package lessons provides short structured lessons for each basic strategy
+// pattern.
+//
+// Every chart cell belongs to exactly one lesson, so wrong-answer feedback can
+// link to the lesson that explains the missed decision. Each lesson has:
+// - A title and a few short paragraphs explaining the pattern and why it works
+// - Example hands with the correct play
+package lessons
+
+import (
+	"blackjack_trainer/internal/hand"
+	"blackjack_trainer/internal/strategy"
+	"fmt"
+	"strings"
+)
+
+// Example is an illustrative hand for a lesson.
+type Example struct {
+	Cards      []int
+	DealerCard int
+	Action     rune
+	Note       string
+}
+
+// Lesson explains one strategy pattern.
+type Lesson struct {
+	ID         string
+	Title      string
+	Paragraphs []string
+	Examples   []Example
+
+	// covers reports whether a chart cell belongs to this lesson.
+	covers func(handType strategy.HandType, total, dealer int) bool
+}
+
+// lineWidth is the column at which lesson paragraphs are wrapped.
+const lineWidth = 72
+
+// Format returns the lesson as text for display.
+func (l Lesson) Format() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s\n%s\n", l.Title, strings.Repeat("-", len(l.Title)))
+	for _, paragraph := range l.Paragraphs {
+		fmt.Fprintf(&b, "\n%s\n", wrap(paragraph, lineWidth))
+	}
+
+	if len(l.Examples) > 0 {
+		b.WriteString("\nExamples:\n")
+		for _, example := range l.Examples {
+			fmt.Fprintf(&b, "  %-8s vs %-2s  %-6s  %s\n", hand.New(example.Cards...),
+				strategy.CardToString(example.DealerCard), strategy.ActionToString(example.Action), example.Note)
+		}
+	}
+	return b.String()
+}
+
+// wrap breaks text into lines of at most width columns at word boundaries.
+func wrap(text string, width int) string {
+	var b strings.Builder
+	column := 0
+	for _, word := range strings.Fields(text) {
+		if column > 0 && column+1+len(word) > width {
+			b.WriteByte('\n')
+			column = 0
+		} else if column > 0 {
+			b.WriteByte(' ')
+			column++
+		}
+		b.WriteString(word)
+		column += len(word)
+	}
+	return b.String()
+}
+
+// All returns every lesson in suggested reading order.
+func All() []Lesson {
+	return all
+}
+
+// Get returns the lesson with the given ID.
+func Get(id string) (Lesson, bool) {
+	for _, lesson := range all {
+		if lesson.ID == id {
+			return lesson, true
+		}
+	}
+	return Lesson{}, false
+}
+
+// ForCell returns the lesson covering a chart cell.
+func ForCell(handType strategy.HandType, total, dealer int) (Lesson, bool) {
+	for _, lesson := range all {
+		if lesson.covers(handType, total, dealer) {
+			return lesson, true
+		}
+	}
+	return Lesson{}, false
+}
+
+// ForHand returns the lesson covering the decision for a hand.
+func ForHand(h hand.Hand, dealer int) (Lesson, bool) {
+	handType, value := strategy.Classify(h)
+	return ForCell(handType, value, dealer)
+}
+
+func pair(values ...int) func(strategy.HandType, int, int) bool {
+	return func(handType strategy.HandType, total, _ int) bool {
+		if handType != strategy.HandTypePair {
+			return false
+		}
+		for _, value := range values {
+			if total == value {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func totals(want strategy.HandType, low, high int) func(strategy.HandType, int, int) bool {
+	return func(handType strategy.HandType, total, _ int) bool {
+		return handType == want && total >= low && total <= high
+	}
+}
+
+var all = []Lesson{
+	{
+		ID:    "always-stand",
+		Title: "Hard 17+ and Soft 19+: Always Stand",
+		Paragraphs: []string{
+			"Hard 17 or more stands against every dealer card. Any card of 5 or more busts a hard 17, and a made hand beats hitting into a likely bust.",
+			"Soft 19, 20, and 21 also always stand. They already beat most dealer results, and the ace's flexibility is not worth risking a strong total.",
+		},
+		Examples: []Example{
+			{[]int{10, 7}, 11, 'S', "Even against an ace, stand"},
+			{[]int{hand.Ace, 8}, 6, 'S', "Soft 19 is too good to touch"},
+		},
+		covers: func(handType strategy.HandType, total, _ int) bool {
+			return (handType == strategy.HandTypeHard && total >= 17) ||
+				(handType == strategy.HandTypeSoft && total >= 19)
+		},
+	},
+	{
+		ID:    "aces-eights",
+		Title: "Always Split Aces and Eights",
+		Paragraphs: []string{
+			"A pair of aces is a weak 12 or soft 12, but split they become two hands that each start with an ace: every ten-value card makes 21.",
+			"A pair of eights is 16, the worst hand in blackjack. Two hands starting with 8 are far better than one 16, even against a 10 or ace.",
+			"Mnemonic: \"Aces and eights, don't hesitate.\"",
+		},
+		Examples: []Example{
+			{[]int{hand.Ace, hand.Ace}, 10, 'Y', "Two chances at 21"},
+			{[]int{8, 8}, 11, 'Y', "Escape 16 even against an ace"},
+		},
+		covers: pair(hand.Ace, 8),
+	},
+	{
+		ID:    "tens-fives",
+		Title: "Never Split Tens and Fives",
+		Paragraphs: []string{
+			"A pair of tens is 20, a winning hand. Splitting trades it for two hands that each start with 10 and will usually finish worse.",
+			"A pair of fives is hard 10, a great doubling hand. Play it as hard 10: double against 2-9, hit against 10 or ace.",
+			"Mnemonic: \"Tens and fives, keep them alive.\"",
+		},
+		Examples: []Example{
+			{[]int{10, 10}, 6, 'S', "Keep the 20"},
+			{[]int{5, 5}, 6, 'D', "Play as hard 10"},
+			{[]int{5, 5}, 10, 'H', "Hard 10 does not double vs 10"},
+		},
+		covers: pair(10, 5),
+	},
+	{
+		ID:    "other-pairs",
+		Title: "Splitting Small Pairs and Nines",
+		Paragraphs: []string{
+			"Small pairs split when the dealer is weak: 2,2, 3,3, and 7,7 split against 2-7; 6,6 against 2-6; 4,4 only against 5-6. Otherwise hit.",
+			"9,9 splits against 2-9 except 7. Against 7 your 18 already beats the dealer's likely 17; against 10 or ace, stand on 18 rather than start two hands against strength.",
+		},
+		Examples: []Example{
+			{[]int{7, 7}, 7, 'Y', "Two 7s beat one 14 vs 7"},
+			{[]int{4, 4}, 5, 'Y', "Only against 5 and 6"},
+			{[]int{9, 9}, 7, 'S', "18 beats the dealer's likely 17"},
+			{[]int{6, 6}, 7, 'H', "Do not split into strength"},
+		},
+		covers: pair(2, 3, 4, 6, 7, 9),
+	},
+	{
+		ID:    "low-hard",
+		Title: "Hard 8 and Below: Always Hit",
+		Paragraphs: []string{
+			"With hard 8 or less you cannot bust on the next card and your total is too low to win, so always hit.",
+		},
+		Examples: []Example{
+			{[]int{3, 5}, 6, 'H', "Even against a weak dealer"},
+		},
+		covers: totals(strategy.HandTypeHard, 5, 8),
+	},
+	{
+		ID:    "hard-doubling",
+		Title: "Doubling Hard 9, 10, and 11",
+		Paragraphs: []string{
+			"These totals are strong starting points because a ten-value card makes 19-21. Double when the dealer is weak enough:",
+			"Hard 11 doubles against 2-10, hard 10 against 2-9, and hard 9 only against 3-6. The stronger your total, the more dealer cards you double against.",
+			"Mnemonic: \"Double when the dealer is weak and you can improve.\"",
+		},
+		Examples: []Example{
+			{[]int{6, 5}, 10, 'D', "11 doubles vs everything but an ace"},
+			{[]int{6, 4}, 10, 'H', "10 vs 10: just hit"},
+			{[]int{5, 4}, 2, 'H', "9 doubles only vs 3-6"},
+		},
+		covers: totals(strategy.HandTypeHard, 9, 11),
+	},
+	{
+		ID:    "hard-12",
+		Title: "Hard 12: The Exception",
+		Paragraphs: []string{
+			"Hard 12 stands only against 4, 5, and 6. Against 2 and 3 the dealer busts less often and only a ten busts your 12, so hitting is better.",
+			"Mnemonic: \"12 is the exception - only stand vs 4, 5, 6.\"",
+		},
+		Examples: []Example{
+			{[]int{10, 2}, 4, 'S', "Let the dealer bust"},
+			{[]int{10, 2}, 3, 'H', "Not against 2 or 3"},
+		},
+		covers: totals(strategy.HandTypeHard, 12, 12),
+	},
+	{
+		ID:    "stiff-hands",
+		Title: "Stiff Hands: Hard 13-16",
+		Paragraphs: []string{
+			"Hard 13-16 are stiff hands: likely to bust if you hit, but unlikely to win if you stand against a strong dealer.",
+			"Against 2-6 the dealer is likely to bust, so stand and let them. Against 7-A the dealer will usually make 17 or better, so take the risk and hit.",
+			"Mnemonic: \"Teens stay vs weak, flee from strong.\"",
+		},
+		Examples: []Example{
+			{[]int{10, 6}, 6, 'S', "The dealer must hit a stiff hand too"},
+			{[]int{10, 6}, 10, 'H', "Standing on 16 vs 10 loses more"},
+			{[]int{9, 4}, 2, 'S', "13 stands even vs 2"},
+		},
+		covers: totals(strategy.HandTypeHard, 13, 16),
+	},
+	{
+		ID:    "soft-doubling",
+		Title: "Soft Doubling Ladder",
+		Paragraphs: []string{
+			"Soft hands cannot bust on one card, so they are doubled against weak dealers. The ladder climbs with your total:",
+			"A,2 and A,3 double against 5-6; A,4 and A,5 against 4-6; A,6 against 3-6. Otherwise hit - never stand on soft 17 or less.",
+		},
+		Examples: []Example{
+			{[]int{hand.Ace, 2}, 5, 'D', "Bottom rung: 5 and 6 only"},
+			{[]int{hand.Ace, 5}, 4, 'D', "A,4 and A,5 add the 4"},
+			{[]int{hand.Ace, 6}, 3, 'D', "A,6 adds the 3"},
+			{[]int{hand.Ace, 6}, 7, 'H', "Soft 17 never stands"},
+		},
+		covers: totals(strategy.HandTypeSoft, 13, 17),
+	},
+	{
+		ID:    "soft-18",
+		Title: "Soft 18 (A,7): The Tricky Hand",
+		Paragraphs: []string{
+			"A,7 has three different plays. Double against 3-6, where the dealer is weak. Stand against 2, 7, and 8, where 18 is good enough. Hit against 9, 10, and ace, where 18 is an underdog and the ace lets you draw safely.",
+			"Mnemonic: \"A,7 is the tricky soft hand.\"",
+		},
+		Examples: []Example{
+			{[]int{hand.Ace, 7}, 6, 'D', "Weak dealer: double"},
+			{[]int{hand.Ace, 7}, 8, 'S', "18 beats the dealer's likely 18 or less"},
+			{[]int{hand.Ace, 7}, 10, 'H', "18 is behind against strength"},
+		},
+		covers: totals(strategy.HandTypeSoft, 18, 18),
+	},
+}
@@ -0,0 +1,82 @@
+package lessons
+
+import (
+	"blackjack_trainer/internal/hand"
+	"blackjack_trainer/internal/strategy"
+	"strings"
+	"testing"
+)
+
+// Test every chart cell is covered by exactly one lesson
+func TestEveryCellHasOneLesson(t *testing.T) {
+	ranges := []struct {
+		handType  strategy.HandType
+		low, high int
+	}{
+		{strategy.HandTypeHard, 5, 21},
+		{strategy.HandTypeSoft, 13, 21},
+		{strategy.HandTypePair, 2, 11},
+	}
+
+	for _, r := range ranges {
+		for total := r.low; total <= r.high; total++ {
+			for dealer := 2; dealer <= 11; dealer++ {
+				count := 0
+				for _, lesson := range All() {
+					if lesson.covers(r.handType, total, dealer) {
+						count++
+					}
+				}
+				if count != 1 {
+					t.Errorf("%s is covered by %d lessons", strategy.CellLabel(r.handType, total, dealer), count)
+				}
+			}
+		}
+	}
+}
+
+// Test lesson examples agree with the strategy chart and their own lesson
+func TestExamplesMatchChart(t *testing.T) {
+	chart := strategy.New()
+	for _, lesson := range All() {
+		if len(lesson.Examples) == 0 {
+			t.Errorf("Lesson %s has no examples", lesson.ID)
+		}
+		for _, example := range lesson.Examples {
+			h := hand.New(example.Cards...)
+			if want := chart.GetCorrectActionForHand(h, example.DealerCard); example.Action != want {
+				t.Errorf("Lesson %s: %s vs %d should be %c, lesson says %c", lesson.ID, h, example.DealerCard, want, example.Action)
+			}
+			if covering, _ := ForHand(h, example.DealerCard); covering.ID != lesson.ID {
+				t.Errorf("Lesson %s example %s vs %d belongs to lesson %s", lesson.ID, h, example.DealerCard, covering.ID)
+			}
+		}
+	}
+}
+
+// Test lookup by ID and by hand
+func TestLookup(t *testing.T) {
+	if lesson, ok := ForHand(hand.New(hand.Ace, 4), 5); !ok || lesson.Title != "Soft Doubling Ladder" {
+		t.Errorf("A,4 vs 5 should link to the soft doubling ladder, got %q", lesson.Title)
+	}
+	if lesson, ok := ForHand(hand.New(8, 8), 10); !ok || lesson.ID != "aces-eights" {
+		t.Errorf("8,8 should link to aces and eights, got %q", lesson.ID)
+	}
+	if _, ok := Get("hard-12"); !ok {
+		t.Error("Get should find hard-12")
+	}
+	if _, ok := Get("missing"); ok {
+		t.Error("Get should not find an unknown lesson")
+	}
+}
+
+// Test lesson formatting includes the title, text, and examples
+func TestFormat(t *testing.T) {
+	lesson, _ := Get("stiff-hands")
+	text := lesson.Format()
+	for _, want := range []string{"Stiff Hands: Hard 13-16\n---", "Teens stay vs weak", "10, 6    vs 10  HIT"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Formatted lesson should contain %q, got:\n%s", want, text)
+		}
+	}
+}
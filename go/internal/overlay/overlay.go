@@ -0,0 +1,212 @@
+// Package overlay renders each scenario as a PNG image, the dealer's upcard
+// above the player's cards, so streamers and teachers can show the current
+// question in OBS or another broadcaster while drilling live.
+//
+// The image is published in one of two ways:
+// - File: written to a PNG file, for an OBS image source
+// - Server: served over HTTP, as /scenario.png and as a page at / that
+// reloads it, for an OBS browser source
+package overlay
+
+import (
+	"blackjack_trainer/internal/atomicfile"
+	"blackjack_trainer/internal/hand"
+	"blackjack_trainer/internal/strategy"
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Width and Height are the size of the rendered image in pixels.
+const (
+	Width  = 640
+	Height = 400
+)
+
+const (
+	cardWidth   = 90
+	cardHeight  = 126
+	cardSpacing = 100
+)
+
+var (
+	felt       = color.RGBA{0x0b, 0x5d, 0x2e, 0xff}
+	cardFace   = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	cardEdge   = color.RGBA{0x80, 0x80, 0x80, 0xff}
+	ink        = color.RGBA{0x10, 0x10, 0x10, 0xff}
+	labelColor = color.RGBA{0xf0, 0xe6, 0x8c, 0xff}
+)
+
+// Overlay publishes scenario images.
+type Overlay interface {
+	// Show publishes the image of a scenario.
+	Show(playerHand hand.Hand, dealerCard int) error
+}
+
+// Open returns an overlay for dest: a File when dest names a .png file, or
+// otherwise a Server listening on dest as a host:port address.
+func Open(dest string) (Overlay, error) {
+	if strings.HasSuffix(strings.ToLower(dest), ".png") {
+		return NewFile(dest), nil
+	}
+	return Listen(dest)
+}
+
+// Render draws the scenario: the dealer's upcard at the top, and the
+// player's cards below, labeled with the hand's type and total.
+func Render(playerHand hand.Hand, dealerCard int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, Width, Height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(felt), image.Point{}, draw.Src)
+
+	drawLabel(img, 16, "DEALER")
+	drawCard(img, (Width-cardWidth)/2, 48, dealerCard)
+
+	handType, value := strategy.Classify(playerHand)
+	total := fmt.Sprint(value)
+	if handType == strategy.HandTypePair {
+		total = strategy.CardToString(value)
+	}
+	drawLabel(img, 196, strings.ToUpper(fmt.Sprintf("You: %s %s", handType, total)))
+	cards := playerHand.Cards
+	left := (Width - (len(cards)-1)*cardSpacing - cardWidth) / 2
+	for i, card := range cards {
+		drawCard(img, left+i*cardSpacing, 228, card)
+	}
+	return img
+}
+
+// Encode renders the scenario as PNG data.
+func Encode(playerHand hand.Hand, dealerCard int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, Render(playerHand, dealerCard)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// drawLabel draws text centered across the image with its top at y.
+func drawLabel(img draw.Image, y int, text string) {
+	const scale = 3
+	drawText(img, (Width-textWidth(text, scale))/2, y, text, scale, labelColor)
+}
+
+// drawCard draws a card face with its top left corner at (x, y): the rank
+// large in the middle and small in the corners.
+func drawCard(img draw.Image, x, y, card int) {
+	draw.Draw(img, image.Rect(x, y, x+cardWidth, y+cardHeight), image.NewUniform(cardEdge), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(x+2, y+2, x+cardWidth-2, y+cardHeight-2), image.NewUniform(cardFace), image.Point{}, draw.Src)
+
+	rank := strategy.CardToString(card)
+	large := 6
+	if textWidth(rank, large) > cardWidth-16 {
+		large = 5
+	}
+	drawText(img, x+(cardWidth-textWidth(rank, large))/2, y+(cardHeight-glyphHeight*large)/2, rank, large, ink)
+	drawText(img, x+6, y+6, rank, 2, ink)
+	drawText(img, x+cardWidth-6-textWidth(rank, 2), y+cardHeight-6-glyphHeight*2, rank, 2, ink)
+}
+
+// File writes each scenario to a PNG file, replacing it atomically so a
+// program watching the file never reads a partial image.
+type File struct {
+	path string
+}
+
+// NewFile returns an overlay that writes to path.
+func NewFile(path string) *File {
+	return &File{path: path}
+}
+
+// Show writes the scenario's image to the file.
+func (f *File) Show(playerHand hand.Hand, dealerCard int) error {
+	data, err := Encode(playerHand, dealerCard)
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(f.path, data, 0o644)
+}
+
+func (f *File) String() string {
+	return f.path
+}
+
+// page shows the latest image, reloading it every second.
+const page = `<!DOCTYPE html>
+<html><head><title>Blackjack Trainer Scenario</title>
+<style>body{margin:0;background:transparent}img{display:block}</style></head>
+<body><img id="scenario" src="scenario.png" alt="">
+<script>
+setInterval(function () {
+  document.getElementById("scenario").src = "scenario.png?" + Date.now();
+}, 1000);
+</script></body></html>
+`
+
+// Server serves the latest scenario's image over HTTP.
+type Server struct {
+	addr string
+	mu   sync.Mutex
+	data []byte
+}
+
+// Listen starts serving on addr, a host:port address such as
+// "localhost:8091", and returns once the address is open. Until the first
+// scenario is shown the image is an empty table.
+func Listen(addr string) (*Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{addr: listener.Addr().String()}
+	var buf bytes.Buffer
+	empty := image.NewRGBA(image.Rect(0, 0, Width, Height))
+	draw.Draw(empty, empty.Bounds(), image.NewUniform(felt), image.Point{}, draw.Src)
+	png.Encode(&buf, empty)
+	s.data = buf.Bytes()
+
+	server := &http.Server{Handler: s, ReadHeaderTimeout: 10 * time.Second}
+	go server.Serve(listener)
+	return s, nil
+}
+
+// Show makes the scenario's image the one served.
+func (s *Server) Show(playerHand hand.Hand, dealerCard int) error {
+	data, err := Encode(playerHand, dealerCard)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.data = data
+	s.mu.Unlock()
+	return nil
+}
+
+// ServeHTTP serves the reloading page at / and the image at /scenario.png.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, page)
+	case "/scenario.png":
+		s.mu.Lock()
+		data := s.data
+		s.mu.Unlock()
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write(data)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) String() string {
+	return "http://" + s.addr + "/"
+}
@@ -0,0 +1,108 @@
+package overlay
+
+import (
+	"blackjack_trainer/internal/hand"
+	"bytes"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Test the scenario is drawn as cards on the felt, centered for any number of cards
+func TestRender(t *testing.T) {
+	for _, cards := range [][]int{{10, 6}, {2, 3, 4, 5, 2}, {11, 11}} {
+		img := Render(hand.New(cards...), 10)
+		if img.Bounds() != image.Rect(0, 0, Width, Height) {
+			t.Fatalf("Unexpected size %v", img.Bounds())
+		}
+		if img.RGBAAt(2, 2) != felt {
+			t.Errorf("%v: corner should be felt, got %v", cards, img.RGBAAt(2, 2))
+		}
+		// The dealer's card is centered at the top
+		if img.RGBAAt(Width/2-cardWidth/2+4, 52) != cardFace {
+			t.Errorf("%v: dealer card missing", cards)
+		}
+		// The player's cards are centered below, leaving the edges clear
+		left := (Width - (len(cards)-1)*cardSpacing - cardWidth) / 2
+		if img.RGBAAt(left+4, 232) != cardFace || img.RGBAAt(left-4, 232) != felt {
+			t.Errorf("%v: player cards misplaced", cards)
+		}
+	}
+}
+
+// Test every character of the labels and ranks has a glyph of the right size
+func TestGlyphs(t *testing.T) {
+	for _, text := range []string{"DEALER", "YOU: HARD SOFT PAIR", "0123456789", "A"} {
+		for _, r := range text {
+			if r == ' ' {
+				continue
+			}
+			glyph, ok := glyphs[r]
+			if !ok {
+				t.Errorf("No glyph for %q", r)
+			}
+			for _, line := range glyph {
+				if len(line) != glyphWidth {
+					t.Errorf("Glyph %q has a line %q of the wrong width", r, line)
+				}
+			}
+		}
+	}
+	if textWidth("10", 2) != 22 {
+		t.Errorf("Expected two characters at scale 2 to be 22 pixels, got %d", textWidth("10", 2))
+	}
+}
+
+// Test a file overlay writes a PNG image
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scenario.png")
+	o, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Show(hand.New(11, 7), 9); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := png.Decode(bytes.NewReader(data)); err != nil {
+		t.Errorf("File is not a PNG image: %v", err)
+	}
+}
+
+// Test a server overlay serves the latest image and a page that shows it
+func TestServer(t *testing.T) {
+	o, err := Open("localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := o.(*Server)
+	get := func(path string) []byte {
+		resp, err := http.Get(server.String() + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return data
+	}
+
+	empty := get("scenario.png")
+	if _, err := png.Decode(bytes.NewReader(empty)); err != nil {
+		t.Errorf("Served image before the first scenario is not a PNG: %v", err)
+	}
+	server.Show(hand.New(8, 8), 11)
+	if bytes.Equal(get("scenario.png"), empty) {
+		t.Error("Served image should change when a scenario is shown")
+	}
+	if page := string(get("")); !strings.Contains(page, `src="scenario.png"`) {
+		t.Errorf("Page should show the image, got %s", page)
+	}
+}
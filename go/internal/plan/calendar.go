@@ -0,0 +1,121 @@
+package plan
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Schedule places days of a plan on the calendar for a calendar export.
+type Schedule struct {
+	// First is the index of the first day to schedule, so days already
+	// completed can be left out.
+	First int
+	// Start is the date and time of the first day's session; each later
+	// day's session is at the same time on the following day.
+	Start time.Time
+	// Length is how long each session is booked for.
+	Length time.Duration
+}
+
+// WriteCalendar writes the scheduled days of the plan as an iCalendar
+// (.ics) file of daily events, each describing the day's drills and
+// reminding at its start. Times are written as local ("floating") times, so
+// the sessions stay at the same hour wherever the calendar is.
+func (p *Plan) WriteCalendar(w io.Writer, s Schedule, now time.Time) error {
+	var b strings.Builder
+	line := func(format string, args ...any) {
+		b.WriteString(foldLine(fmt.Sprintf(format, args...)))
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//blackjack_trainer//Practice Plan//EN")
+	line("CALSCALE:GREGORIAN")
+	line("X-WR-CALNAME:%s", escapeText(p.Name))
+	stamp := now.UTC().Format("20060102T150405Z")
+	for i := s.First; i < len(p.Days); i++ {
+		day := p.Days[i]
+		start := s.Start.AddDate(0, 0, i-s.First)
+		end := start.Add(s.Length)
+		line("BEGIN:VEVENT")
+		line("UID:%s-day-%d-%s@blackjack_trainer", slug(p.Name), i+1, start.Format("20060102"))
+		line("DTSTAMP:%s", stamp)
+		line("DTSTART:%s", start.Format("20060102T150405"))
+		line("DTEND:%s", end.Format("20060102T150405"))
+		line("SUMMARY:%s", escapeText(fmt.Sprintf("Blackjack: Day %d of %d - %s", i+1, len(p.Days), day.Title)))
+		line("DESCRIPTION:%s", escapeText(p.describeDay(i)))
+		line("BEGIN:VALARM")
+		line("ACTION:DISPLAY")
+		line("DESCRIPTION:%s", escapeText(day.Title))
+		line("TRIGGER:PT0M")
+		line("END:VALARM")
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// describeDay describes a day's drills for its calendar event.
+func (p *Plan) describeDay(i int) string {
+	day := p.Days[i]
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s, day %d of %d: %s\n", p.Name, i+1, len(p.Days), day.Title)
+	fmt.Fprintf(&b, "Drills: %s", strings.Join(day.Drills, ", "))
+	if day.Questions > 0 {
+		fmt.Fprintf(&b, " (%d questions each)", day.Questions)
+	}
+	if day.Pass > 0 {
+		fmt.Fprintf(&b, "\nPass each with at least %g%%.", day.Pass)
+	}
+	b.WriteString("\nRun blackjack_trainer and choose P for today's plan session.")
+	return b.String()
+}
+
+// escapeText escapes an iCalendar TEXT value.
+func escapeText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(s)
+}
+
+// foldLine ends a content line with CRLF, folding it so no line is longer
+// than 75 octets, without splitting a UTF-8 character.
+func foldLine(s string) string {
+	const limit = 75
+	var b strings.Builder
+	width := limit
+	for len(s) > width {
+		cut := width
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		// Continuation lines start with a space
+		width = limit - 1
+	}
+	b.WriteString(s)
+	b.WriteString("\r\n")
+	return b.String()
+}
+
+// slug returns the plan name in lower case with runs of other characters
+// replaced by hyphens, for event identifiers.
+func slug(name string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			hyphen = false
+		} else if !hyphen && b.Len() > 0 {
+			b.WriteByte('-')
+			hyphen = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
@@ -0,0 +1,260 @@
+// Package plan reads practice plans, multi-day programs of drills such as
+// "day 1: absolutes and hard totals, day 2: soft totals", and tracks a
+// player's progress through one.
+//
+// Plans are written in a small subset of TOML: top-level keys, then one
+// [[day]] table per day, with string, number and single-line string array
+// values and # comments:
+//
+//	name = "Two-Week Basics"
+//
+//	[[day]]
+//	title = "Absolutes and hard totals"
+//	drills = ["absolute", "hard"]
+//	questions = 20
+//	pass = 80
+//
+// A day is finished by passing each of its drills in order, scoring at
+// least pass percent (default 0) over a full session. The next day opens
+// on a later calendar day.
+//
+// Plans built into the trainer, such as the 30-day "bootcamp", are chosen by
+// name instead of a file.
+package plan
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed bootcamp.toml
+var bootcamp []byte
+
+// builtins maps the names of the built-in plans to their plan files.
+var builtins = map[string][]byte{
+	"bootcamp": bootcamp,
+}
+
+// BuiltinNames lists the built-in plans.
+func BuiltinNames() []string {
+	var names []string
+	for name := range builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsBuiltin reports whether name is a built-in plan.
+func IsBuiltin(name string) bool {
+	_, ok := builtins[name]
+	return ok
+}
+
+// Builtin returns the built-in plan with the given name.
+func Builtin(name string) (*Plan, error) {
+	data, ok := builtins[name]
+	if !ok {
+		return nil, fmt.Errorf("no built-in plan %q (built in: %s)", name, strings.Join(BuiltinNames(), ", "))
+	}
+	return Parse(data)
+}
+
+// Plan is a multi-day practice program.
+type Plan struct {
+	Name        string
+	Description string
+	Days        []Day
+}
+
+// Day is one day of a plan.
+type Day struct {
+	Title string
+	// Drills names the sessions to practice, in order: a session type such
+	// as "absolute", a hand type (hard, soft, pairs) or a dealer group
+	// (weak, medium, strong).
+	Drills []string
+	// Questions is the length of each drill; zero keeps each session's own.
+	Questions int
+	// Pass is the percentage score needed to pass each drill.
+	Pass float64
+}
+
+// Load reads and parses the plan file at path.
+func Load(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// Parse parses a plan and checks that every day has drills. Drill names are
+// not checked; that is left to the caller, which knows the sessions.
+func Parse(data []byte) (*Plan, error) {
+	p := &Plan{}
+	var day *Day
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(stripComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+		if line == "[[day]]" {
+			p.Days = append(p.Days, Day{})
+			day = &p.Days[len(p.Days)-1]
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			return nil, fmt.Errorf("line %d: unknown table %s (only [[day]] is allowed)", n, line)
+		}
+
+		key, text, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", n)
+		}
+		key, text = strings.TrimSpace(key), strings.TrimSpace(text)
+		var err error
+		if day == nil {
+			switch key {
+			case "name":
+				p.Name, err = parseString(text)
+			case "description":
+				p.Description, err = parseString(text)
+			default:
+				err = fmt.Errorf("unknown key %q", key)
+			}
+		} else {
+			switch key {
+			case "title":
+				day.Title, err = parseString(text)
+			case "drills":
+				day.Drills, err = parseStrings(text)
+			case "questions":
+				day.Questions, err = strconv.Atoi(text)
+				if err == nil && day.Questions < 0 {
+					err = errors.New("questions can't be negative")
+				}
+			case "pass":
+				day.Pass, err = strconv.ParseFloat(text, 64)
+				if err == nil && (day.Pass < 0 || day.Pass > 100) {
+					err = errors.New("pass must be a percentage from 0 to 100")
+				}
+			default:
+				err = fmt.Errorf("unknown day key %q", key)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(p.Name) == "" {
+		return nil, errors.New("plan has no name")
+	}
+	if len(p.Days) == 0 {
+		return nil, fmt.Errorf("plan %q has no [[day]] tables", p.Name)
+	}
+	for i, d := range p.Days {
+		if len(d.Drills) == 0 {
+			return nil, fmt.Errorf("day %d has no drills", i+1)
+		}
+	}
+	return p, nil
+}
+
+// Check returns an error naming the first drill not in known.
+func (p *Plan) Check(known []string) error {
+	for i, d := range p.Days {
+		for _, drill := range d.Drills {
+			if !contains(known, drill) {
+				return fmt.Errorf("day %d: unknown drill %q (use %s)", i+1, drill, strings.Join(known, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// stripComment removes a # comment that isn't inside a string.
+func stripComment(line string) string {
+	var quote rune
+	escaped := false
+	for i, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && r == '\\':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseString parses a TOML basic ("...") or literal ('...') string.
+func parseString(text string) (string, error) {
+	if len(text) >= 2 && text[0] == '\'' && text[len(text)-1] == '\'' {
+		return text[1 : len(text)-1], nil
+	}
+	if len(text) >= 2 && text[0] == '"' {
+		s, err := strconv.Unquote(text)
+		if err == nil {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("expected a quoted string, got %s", text)
+}
+
+// parseStrings parses a single-line array of strings without commas, e.g.
+// ["soft", "pairs"].
+func parseStrings(text string) ([]string, error) {
+	inner, hasOpen := strings.CutPrefix(text, "[")
+	inner, hasClose := strings.CutSuffix(inner, "]")
+	if !hasOpen || !hasClose {
+		return nil, fmt.Errorf("expected an array of strings, got %s", text)
+	}
+	var values []string
+	for _, item := range strings.Split(inner, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		s, err := parseString(item)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, s)
+	}
+	return values, nil
+}
+
+// contains reports whether list holds s.
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
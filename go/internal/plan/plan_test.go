@@ -0,0 +1,205 @@
+package plan
+
+import (
+	"blackjack_trainer/internal/trainer"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const basics = `# Two days of basics
+name = "Two-Day Basics"
+description = 'Absolutes first, # not a comment'
+
+[[day]]
+title = "Absolutes and hard totals"  # the easy ones first
+drills = ["absolute", "hard"]
+questions = 20
+pass = 80
+
+[[day]]
+title = "Soft totals"
+drills = ["soft"]
+`
+
+// Test a plan file parses into its days
+func TestParse(t *testing.T) {
+	p, err := Parse([]byte(basics))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Plan{
+		Name:        "Two-Day Basics",
+		Description: "Absolutes first, # not a comment",
+		Days: []Day{
+			{Title: "Absolutes and hard totals", Drills: []string{"absolute", "hard"}, Questions: 20, Pass: 80},
+			{Title: "Soft totals", Drills: []string{"soft"}},
+		},
+	}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("Parse() = %+v, want %+v", p, want)
+	}
+
+	if err := p.Check([]string{"absolute", "hard"}); err == nil || !strings.Contains(err.Error(), `day 2: unknown drill "soft"`) {
+		t.Errorf("Check() should reject the unknown drill, got %v", err)
+	}
+	if err := p.Check([]string{"absolute", "hard", "soft"}); err != nil {
+		t.Errorf("Check() = %v, want nil", err)
+	}
+}
+
+// Test malformed plans are rejected with the line at fault
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"[[day]]\ndrills = [\"soft\"]\n", "plan has no name"},
+		{"name = \"X\"\n", "no [[day]] tables"},
+		{"name = \"X\"\n[[day]]\ntitle = \"Empty\"\n", "day 1 has no drills"},
+		{"name = X\n", "line 1: expected a quoted string"},
+		{"name = \"X\"\n[days]\n", "line 2: unknown table"},
+		{"name = \"X\"\nlevel = 3\n", "line 2: unknown key \"level\""},
+		{"name = \"X\"\n[[day]]\ndrills = \"soft\"\n", "line 3: expected an array"},
+		{"name = \"X\"\n[[day]]\npass = 120\n", "line 3: pass must be a percentage"},
+		{"name = \"X\"\n[[day]]\nquestions = -1\n", "line 3: questions can't be negative"},
+	}
+	for _, tt := range tests {
+		_, err := Parse([]byte(tt.input))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) error = %v, want %q", tt.input, err, tt.want)
+		}
+	}
+}
+
+// Test progress moves through drills and days, one day per calendar day
+func TestProgress(t *testing.T) {
+	p, err := Parse([]byte(basics))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), ProgressFileName)
+	progress, err := OpenProgress(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	monday := time.Date(2024, 3, 4, 20, 0, 0, 0, time.UTC)
+	if err := progress.Start("/plans/basics.toml", monday); err != nil {
+		t.Fatal(err)
+	}
+	if got := progress.Status(p, monday); got != "Two-Day Basics: Day 1 of 2 - Absolutes and hard totals" {
+		t.Errorf("Status() = %q", got)
+	}
+
+	// A score below the pass mark doesn't pass the drill
+	if passed, _, _ := progress.Record(p, 15, 20, monday); passed {
+		t.Error("75% should not pass a day with an 80% pass mark")
+	}
+	if passed, dayDone, _ := progress.Record(p, 18, 20, monday); !passed || dayDone {
+		t.Errorf("Expected the first drill passed without finishing the day, got %v, %v", passed, dayDone)
+	}
+	if got := progress.Remaining(p); !reflect.DeepEqual(got, []string{"hard"}) {
+		t.Errorf("Remaining() = %v, want [hard]", got)
+	}
+	if passed, dayDone, _ := progress.Record(p, 20, 20, monday); !passed || !dayDone {
+		t.Errorf("Expected the day finished, got %v, %v", passed, dayDone)
+	}
+
+	// The next day opens at midnight
+	if progress.Open(monday.Add(3 * time.Hour)) {
+		t.Error("Day 2 should not open on the same day")
+	}
+	if got := progress.Status(p, monday); got != "Two-Day Basics: Day 2 of 2 - Soft totals (opens tomorrow)" {
+		t.Errorf("Status() = %q", got)
+	}
+	tuesday := monday.Add(4 * time.Hour)
+	if !progress.Open(tuesday) {
+		t.Error("Day 2 should open the next day")
+	}
+
+	reloaded, err := OpenProgress(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.Plan != "/plans/basics.toml" || reloaded.Day() != 2 {
+		t.Errorf("Expected day 2 of the saved plan, got %+v", reloaded)
+	}
+	reloaded.Record(p, 1, 3, tuesday)
+	if got := reloaded.Status(p, tuesday); got != "Two-Day Basics: complete" {
+		t.Errorf("Status() = %q", got)
+	}
+
+	if err := reloaded.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	if stopped, _ := OpenProgress(path); stopped.Plan != "" {
+		t.Errorf("Expected no plan after Stop, got %q", stopped.Plan)
+	}
+}
+
+// Test the built-in bootcamp is a valid month-long plan ending in an exam
+func TestBuiltin(t *testing.T) {
+	if !IsBuiltin("bootcamp") || IsBuiltin("nosuch") {
+		t.Error("Expected bootcamp, and only bootcamp, to be built in")
+	}
+	p, err := Builtin("bootcamp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.Days) != 30 {
+		t.Errorf("Expected 30 days, got %d", len(p.Days))
+	}
+	if err := p.Check(trainer.DrillNames()); err != nil {
+		t.Error(err)
+	}
+	if first, last := p.Days[0].Drills, p.Days[len(p.Days)-1].Drills; first[0] != "absolute" || last[0] != "exam" {
+		t.Errorf("Expected the bootcamp to ramp from absolutes to an exam, got %v to %v", first, last)
+	}
+	if _, err := Builtin("nosuch"); err == nil {
+		t.Error("Builtin of an unknown name should fail")
+	}
+}
+
+// Test the calendar has an event per remaining day with folded, escaped lines
+func TestWriteCalendar(t *testing.T) {
+	p, err := Builtin("bootcamp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	start := time.Date(2026, 3, 30, 19, 0, 0, 0, time.Local)
+	if err := p.WriteCalendar(&b, Schedule{First: 28, Start: start, Length: 20 * time.Minute}, start); err != nil {
+		t.Fatal(err)
+	}
+	ics := b.String()
+
+	if n := strings.Count(ics, "BEGIN:VEVENT"); n != len(p.Days)-28 {
+		t.Errorf("Expected %d events, got %d", len(p.Days)-28, n)
+	}
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"DTSTART:20260330T190000\r\n",
+		"DTEND:20260330T192000\r\n",
+		"DTSTART:20260331T190000\r\n",
+		"UID:30-day-bootcamp-day-29-20260330@blackjack_trainer\r\n",
+		"SUMMARY:Blackjack: Day 29 of 30 - ",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("Calendar missing %q", want)
+		}
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(ics, "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("Line longer than 75 octets: %q", line)
+		}
+		if strings.Contains(line, "\n") {
+			t.Errorf("Line with a bare newline: %q", line)
+		}
+	}
+	if !strings.Contains(strings.ReplaceAll(ics, "\r\n ", ""), `questions each)\nPass each`) {
+		t.Error("Descriptions should escape newlines")
+	}
+}
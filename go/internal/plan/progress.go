@@ -0,0 +1,141 @@
+package plan
+
+import (
+	"blackjack_trainer/internal/atomicfile"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// ProgressFileName is the name of the progress file in the trainer's
+// directory.
+const ProgressFileName = "plan.json"
+
+// Progress records how far the player has got through the plan they follow.
+type Progress struct {
+	path string
+	// Plan is the absolute path of the plan file, or the name of a built-in
+	// plan; empty when no plan is being followed.
+	Plan    string    `json:"plan,omitempty"`
+	Started time.Time `json:"started,omitempty"`
+	// Completed holds when each finished day was completed, in order.
+	Completed []time.Time `json:"completed,omitempty"`
+	// Drills is the number of the current day's drills passed so far.
+	Drills int `json:"drills,omitempty"`
+}
+
+// OpenProgress loads the progress file at path. A missing file gives
+// progress with no plan, saved to path when a plan is started.
+func OpenProgress(path string) (*Progress, error) {
+	p := &Progress{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return p, nil
+}
+
+// Save writes the progress file.
+func (p *Progress) Save() error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(p.path, data, 0o600)
+}
+
+// Start begins following the plan file at planPath from day 1 and saves.
+func (p *Progress) Start(planPath string, now time.Time) error {
+	*p = Progress{path: p.path, Plan: planPath, Started: now}
+	return p.Save()
+}
+
+// Stop stops following a plan, removing the progress file.
+func (p *Progress) Stop() error {
+	*p = Progress{path: p.path}
+	if err := os.Remove(p.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Day returns the current day, starting from 1.
+func (p *Progress) Day() int {
+	return len(p.Completed) + 1
+}
+
+// Finished reports whether every day of the plan is complete.
+func (p *Progress) Finished(plan *Plan) bool {
+	return len(p.Completed) >= len(plan.Days)
+}
+
+// Open reports whether the current day can be practiced as of now: the
+// first day at once, and each later day once the calendar day on which the
+// previous one was completed has passed.
+func (p *Progress) Open(now time.Time) bool {
+	if len(p.Completed) == 0 {
+		return true
+	}
+	y, m, d := p.Completed[len(p.Completed)-1].In(now.Location()).Date()
+	return !now.Before(time.Date(y, m, d+1, 0, 0, 0, 0, now.Location()))
+}
+
+// Remaining returns the current day's drills not yet passed, in order.
+func (p *Progress) Remaining(plan *Plan) []string {
+	if p.Finished(plan) {
+		return nil
+	}
+	drills := plan.Days[len(p.Completed)].Drills
+	if p.Drills >= len(drills) {
+		return nil
+	}
+	return drills[p.Drills:]
+}
+
+// Record records a finished session of the current day's next drill and
+// saves. The drill is passed with a score of at least the day's pass mark;
+// passing the day's last drill completes the day.
+func (p *Progress) Record(plan *Plan, correct, total int, now time.Time) (passed, dayDone bool, err error) {
+	if p.Finished(plan) || total == 0 {
+		return false, false, nil
+	}
+	day := plan.Days[len(p.Completed)]
+	if float64(correct)/float64(total)*100 < day.Pass {
+		return false, false, nil
+	}
+	p.Drills++
+	if p.Drills >= len(day.Drills) {
+		p.Completed = append(p.Completed, now)
+		p.Drills = 0
+		dayDone = true
+	}
+	return true, dayDone, p.Save()
+}
+
+// Status describes progress for the menu, e.g. "Two-Week Basics: Day 3 of
+// 14 - Soft totals".
+func (p *Progress) Status(plan *Plan, now time.Time) string {
+	if p.Finished(plan) {
+		return fmt.Sprintf("%s: complete", plan.Name)
+	}
+	status := fmt.Sprintf("%s: Day %d of %d", plan.Name, p.Day(), len(plan.Days))
+	if title := plan.Days[len(p.Completed)].Title; title != "" {
+		status += " - " + title
+	}
+	switch {
+	case !p.Open(now):
+		status += " (opens tomorrow)"
+	case p.Drills > 0:
+		status += fmt.Sprintf(" (%d of %d drills done)", p.Drills, len(plan.Days[len(p.Completed)].Drills))
+	}
+	return status
+}
@@ -0,0 +1,224 @@
+// Package remotesync synchronizes the practice history with a remote copy.
+//
+// The remote copy is a single JSON document addressed by URL and accessed
+// with plain HTTP GET and PUT, which works with:
+// - WebDAV servers (Nextcloud, Apache mod_dav, etc.)
+// - S3-compatible storage via pre-signed URLs or public-write buckets
+// - Any HTTP endpoint that stores and returns the uploaded document
+//
+// Synchronizing pulls the remote history, merges it with the local one
+// (sessions are never deleted), and pushes the merged result back. ETags
+// are used so that a concurrent update from another machine is merged
+// rather than overwritten. When a passphrase is set the remote copy is
+// encrypted, so the storage provider cannot read it.
+package remotesync
+
+import (
+	"blackjack_trainer/internal/history"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// maxAttempts is the number of times a sync is retried after losing a
+// race with another machine's update.
+const maxAttempts = 3
+
+// errConflict indicates the remote document changed between pull and push.
+var errConflict = errors.New("remote history changed during sync")
+
+// errNoETag is returned when changes would be pushed to a remote document
+// served without an ETag. Without one the upload can't be made conditional,
+// and it could overwrite another machine's sync.
+var errNoETag = errors.New("the sync server sends no ETag with the remote history, " +
+	"so it can't be updated without risking another machine's changes")
+
+// maxRemoteSize is the largest remote history that is downloaded.
+var maxRemoteSize int64 = 64 << 20
+
+// Client synchronizes history with a remote URL.
+type Client struct {
+	// URL is the location of the remote history document.
+	URL string
+	// Username and Password enable HTTP basic authentication when set.
+	Username string
+	Password string
+	// Token is sent as a bearer token when set.
+	Token string
+	// Passphrase encrypts the remote history when set.
+	Passphrase string
+	// HTTPClient performs requests; http.DefaultClient is used when nil.
+	HTTPClient *http.Client
+}
+
+// Result reports what a sync changed.
+type Result struct {
+	// Pulled is the number of remote sessions added to the local history.
+	Pulled int
+	// Pushed is the number of local sessions uploaded to the remote history.
+	Pushed int
+}
+
+// NewClient creates a client for the given URL with a request timeout.
+func NewClient(url string) *Client {
+	return &Client{
+		URL:        url,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Sync merges the remote history into local, uploads the merged history,
+// and saves local. The local history is only modified if the sync succeeds.
+// Cancelling ctx aborts the requests in progress.
+func (c *Client) Sync(ctx context.Context, local *history.History) (Result, error) {
+	if c.URL == "" {
+		return Result{}, errors.New("no sync URL configured")
+	}
+
+	for attempt := 1; ; attempt++ {
+		result, err := c.syncOnce(ctx, local)
+		if errors.Is(err, errConflict) && attempt < maxAttempts {
+			continue
+		}
+		return result, err
+	}
+}
+
+// syncOnce performs a single pull-merge-push cycle.
+func (c *Client) syncOnce(ctx context.Context, local *history.History) (Result, error) {
+	remote, etag, found, err := c.pull(ctx)
+	if err != nil {
+		return Result{}, err
+	}
+
+	// Merge into a copy so a failed push leaves local untouched
+	merged := remote.Copy()
+	pushed := merged.Merge(local)
+
+	pulled := 0
+	for _, s := range remote.Sessions {
+		if !local.Contains(s) {
+			pulled++
+		}
+	}
+
+	if pushed > 0 {
+		if found && etag == "" {
+			return Result{}, errNoETag
+		}
+		if err := c.push(ctx, merged, etag); err != nil {
+			return Result{}, err
+		}
+	}
+
+	if pulled > 0 {
+		local.Merge(remote)
+		if err := local.Save(); err != nil {
+			return Result{}, fmt.Errorf("saving merged history: %w", err)
+		}
+	}
+
+	return Result{Pulled: pulled, Pushed: pushed}, nil
+}
+
+// pull downloads the remote history and its ETag, and reports whether the
+// document exists. A missing document is an empty history.
+func (c *Client) pull(ctx context.Context) (*history.History, string, bool, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, nil)
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("downloading remote history: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		remote := history.New()
+		if err := remote.SetPassphrase(c.Passphrase); err != nil {
+			return nil, "", false, err
+		}
+		return remote, "", false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", false, fmt.Errorf("downloading remote history: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteSize+1))
+	if err != nil {
+		return nil, "", false, fmt.Errorf("downloading remote history: %w", err)
+	}
+	if int64(len(data)) > maxRemoteSize {
+		return nil, "", false, fmt.Errorf("remote history is larger than %d MiB", maxRemoteSize>>20)
+	}
+	remote, err := history.Decode(data, c.Passphrase)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("reading remote history: %w", err)
+	}
+	return remote, resp.Header.Get("ETag"), true, nil
+}
+
+// push uploads the history, requiring the remote to be unchanged since pull.
+func (c *Client) push(ctx context.Context, h *history.History, etag string) error {
+	data, err := h.Encode()
+	if err != nil {
+		return err
+	}
+
+	req, err := c.newRequest(ctx, http.MethodPut, data)
+	if err != nil {
+		return err
+	}
+	if h.Encrypted() {
+		req.Header.Set("Content-Type", "application/octet-stream")
+	} else {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if etag != "" {
+		req.Header.Set("If-Match", etag)
+	} else {
+		req.Header.Set("If-None-Match", "*")
+	}
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("uploading history: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusPreconditionFailed:
+		return errConflict
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return fmt.Errorf("uploading history: %s", resp.Status)
+	}
+	return nil
+}
+
+// newRequest creates an authenticated request for the remote URL.
+func (c *Client) newRequest(ctx context.Context, method string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if c.Username != "" || c.Password != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	return req, nil
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
@@ -0,0 +1,274 @@
+package remotesync
+
+import (
+	"blackjack_trainer/internal/history"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// documentServer is a minimal WebDAV-like store for a single document.
+type documentServer struct {
+	mu       sync.Mutex
+	data     []byte
+	version  int
+	username string
+	password string
+	// beforePut is called before a PUT is applied, to simulate concurrent writers.
+	beforePut func()
+	// noETag serves the document without an ETag, as some servers do.
+	noETag bool
+}
+
+func (s *documentServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.username != "" {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != s.username || pass != s.password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}
+
+	if r.Method == http.MethodPut && s.beforePut != nil {
+		hook := s.beforePut
+		s.beforePut = nil
+		hook()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	etag := fmt.Sprintf(`"v%d"`, s.version)
+
+	switch r.Method {
+	case http.MethodGet:
+		if s.data == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if !s.noETag {
+			w.Header().Set("ETag", etag)
+		}
+		w.Write(s.data)
+	case http.MethodPut:
+		if match := r.Header.Get("If-Match"); match != "" && match != etag {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		if r.Header.Get("If-None-Match") == "*" && s.data != nil {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		s.data, _ = io.ReadAll(r.Body)
+		s.version++
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *documentServer) store(h *history.History) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data, _ = h.Marshal()
+	s.version++
+}
+
+func (s *documentServer) load(t *testing.T) *history.History {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h, err := history.Parse(s.data)
+	if err != nil {
+		t.Fatalf("Server document is not valid history: %v", err)
+	}
+	return h
+}
+
+func session(mode string, minutes int) history.Session {
+	started := time.Date(2024, 3, 6, 12, minutes, 0, 0, time.UTC)
+	return history.Session{Mode: mode, Started: started, Ended: started.Add(time.Minute), Total: 1}
+}
+
+func openLocal(t *testing.T, sessions ...history.Session) *history.History {
+	local, err := history.Open(filepath.Join(t.TempDir(), history.FileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range sessions {
+		local.Add(s)
+	}
+	return local
+}
+
+// Test the first sync uploads local history to an empty remote
+func TestSyncToEmptyRemote(t *testing.T) {
+	server := &documentServer{}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	local := openLocal(t, session("random", 0), session("random", 10))
+	result, err := NewClient(ts.URL).Sync(context.Background(), local)
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if result.Pushed != 2 || result.Pulled != 0 {
+		t.Errorf("Expected 2 pushed, 0 pulled, got %+v", result)
+	}
+	if remote := server.load(t); len(remote.Sessions) != 2 {
+		t.Errorf("Remote should have 2 sessions, got %d", len(remote.Sessions))
+	}
+}
+
+// Test sessions from two machines are merged in both directions
+func TestSyncMergesBothWays(t *testing.T) {
+	server := &documentServer{username: "me", password: "secret"}
+	remote := history.New()
+	remote.Add(session("absolutes", 5))
+	server.store(remote)
+
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	local := openLocal(t, session("random", 0))
+	client := NewClient(ts.URL)
+	client.Username, client.Password = "me", "secret"
+
+	result, err := client.Sync(context.Background(), local)
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if result.Pulled != 1 || result.Pushed != 1 {
+		t.Errorf("Expected 1 pulled and 1 pushed, got %+v", result)
+	}
+	if len(local.Sessions) != 2 || local.Sessions[1].Mode != "absolutes" {
+		t.Errorf("Local should contain both sessions in order, got %+v", local.Sessions)
+	}
+	if len(server.load(t).Sessions) != 2 {
+		t.Error("Remote should contain both sessions")
+	}
+
+	reloaded, err := history.Open(local.Path())
+	if err != nil || len(reloaded.Sessions) != 2 {
+		t.Errorf("Merged local history should be saved, got %v (err %v)", reloaded, err)
+	}
+
+	// A second sync has nothing to do
+	if result, err := client.Sync(context.Background(), local); err != nil || result.Pulled != 0 || result.Pushed != 0 {
+		t.Errorf("Second sync should be a no-op, got %+v (err %v)", result, err)
+	}
+}
+
+// Test a concurrent update between pull and push is merged, not overwritten
+func TestSyncRetriesOnConflict(t *testing.T) {
+	server := &documentServer{}
+	initial := history.New()
+	initial.Add(session("random", 0))
+	server.store(initial)
+
+	server.beforePut = func() {
+		concurrent := server.load(t)
+		concurrent.Add(session("hand_types", 20))
+		server.store(concurrent)
+	}
+
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	local := openLocal(t, session("absolutes", 10))
+	if _, err := NewClient(ts.URL).Sync(context.Background(), local); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	if sessions := server.load(t).Sessions; len(sessions) != 3 {
+		t.Errorf("Remote should keep the concurrent session, got %+v", sessions)
+	}
+	if len(local.Sessions) != 3 {
+		t.Errorf("Local should receive all sessions, got %d", len(local.Sessions))
+	}
+}
+
+// Test authentication failures, missing URLs, and cancellation are reported
+func TestSyncErrors(t *testing.T) {
+	server := &documentServer{username: "me", password: "secret"}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	local := openLocal(t, session("random", 0))
+	if _, err := NewClient(ts.URL).Sync(context.Background(), local); err == nil {
+		t.Error("Expected error without credentials")
+	}
+	if _, err := NewClient("").Sync(context.Background(), local); err == nil {
+		t.Error("Expected error without URL")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewClient(ts.URL).Sync(ctx, local); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected cancellation error, got %v", err)
+	}
+	if len(local.Sessions) != 1 {
+		t.Error("Failed sync should not modify local history")
+	}
+}
+
+// Test a remote served without an ETag can be pulled from but isn't
+// overwritten, and an oversized remote is refused
+func TestSyncWithoutETag(t *testing.T) {
+	server := &documentServer{noETag: true}
+	server.store(openLocal(t, session("random", 0)))
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	local := openLocal(t)
+	if result, err := NewClient(ts.URL).Sync(context.Background(), local); err != nil || result.Pulled != 1 {
+		t.Fatalf("Pulling without an ETag = %+v, %v", result, err)
+	}
+	local.Add(session("random", 5))
+	if _, err := NewClient(ts.URL).Sync(context.Background(), local); !errors.Is(err, errNoETag) {
+		t.Errorf("Pushing without an ETag = %v, want errNoETag", err)
+	}
+	if remote := server.load(t); len(remote.Sessions) != 1 {
+		t.Errorf("Remote should be left alone, got %d sessions", len(remote.Sessions))
+	}
+
+	defer func(size int64) { maxRemoteSize = size }(maxRemoteSize)
+	maxRemoteSize = 16
+	if _, err := NewClient(ts.URL).Sync(context.Background(), local); err == nil || !strings.Contains(err.Error(), "larger than") {
+		t.Errorf("Oversized remote = %v", err)
+	}
+}
+
+// Test the remote copy is encrypted when a passphrase is set
+func TestSyncEncrypted(t *testing.T) {
+	server := &documentServer{}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	client := NewClient(ts.URL)
+	client.Passphrase = "secret"
+	if _, err := client.Sync(context.Background(), openLocal(t, session("random", 0))); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if bytes.Contains(server.data, []byte("random")) {
+		t.Error("Remote document should be encrypted")
+	}
+
+	// Another machine with the passphrase pulls the session
+	other := openLocal(t, session("absolutes", 30))
+	result, err := client.Sync(context.Background(), other)
+	if err != nil || result.Pulled != 1 || result.Pushed != 1 {
+		t.Errorf("Expected 1 pulled and 1 pushed, got %+v (err %v)", result, err)
+	}
+
+	// Without the passphrase the remote cannot be read
+	if _, err := NewClient(ts.URL).Sync(context.Background(), openLocal(t)); !errors.Is(err, history.ErrEncrypted) {
+		t.Errorf("Expected ErrEncrypted, got %v", err)
+	}
+}
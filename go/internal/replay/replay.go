@@ -0,0 +1,105 @@
+// Package replay plays back recorded practice sessions question by question.
+//
+// Each question shows the scenario as it was asked, the answer given, the
+// correct answer with its mnemonic when missed, and how long the answer took.
+package replay
+
+import (
+	"blackjack_trainer/internal/hand"
+	"blackjack_trainer/internal/history"
+	"blackjack_trainer/internal/stats"
+	"blackjack_trainer/internal/strategy"
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// List writes a numbered summary of every session, oldest first. The numbers
+// are the ones accepted by the replay command.
+func List(w io.Writer, h *history.History) {
+	if len(h.Sessions) == 0 {
+		fmt.Fprintln(w, "No sessions recorded yet.")
+		return
+	}
+
+	for i, s := range h.Sessions {
+		fmt.Fprintf(w, "%4d  %s  %-14s %s  %s\n", i+1, s.Started.Format("2006-01-02 15:04"),
+			s.Mode, score(s), stats.FormatDuration(s.Duration()))
+	}
+}
+
+// Play writes the session one question at a time. When pause is true it
+// waits for Enter between questions, read from in; entering q stops early.
+func Play(w io.Writer, in io.Reader, session history.Session, chart *strategy.StrategyChart, pause bool) {
+	fmt.Fprintln(w, strings.Repeat("=", 50))
+	fmt.Fprintf(w, "Replay: %s session, %s\n", session.Mode, session.Started.Format("Mon Jan 2 2006 15:04"))
+	fmt.Fprintf(w, "Score: %s  Time: %s\n", score(session), stats.FormatDuration(session.Duration()))
+	fmt.Fprintln(w, strings.Repeat("=", 50))
+
+	if len(session.Attempts) == 0 {
+		fmt.Fprintln(w, "\nNo questions were recorded for this session.")
+		return
+	}
+
+	reader := bufio.NewReader(in)
+	for i, attempt := range session.Attempts {
+		fmt.Fprintln(w)
+		fmt.Fprint(w, Question(i+1, len(session.Attempts), attempt, chart))
+
+		if pause && i < len(session.Attempts)-1 {
+			fmt.Fprint(w, "\nPress Enter for the next question (or 'q' + Enter to stop): ")
+			input, err := reader.ReadString('\n')
+			if err != nil || strings.EqualFold(strings.TrimSpace(input), "q") {
+				fmt.Fprintln(w)
+				return
+			}
+		}
+	}
+
+	fmt.Fprintln(w, "\nEnd of session.")
+}
+
+// Question formats one recorded question.
+func Question(number, total int, attempt history.Attempt, chart *strategy.StrategyChart) string {
+	var b strings.Builder
+	playerHand := hand.New(attempt.Cards...)
+
+	fmt.Fprintf(&b, "Question %d/%d: %s\n", number, total, stats.AttemptLabel(attempt))
+	fmt.Fprintf(&b, "  Dealer shows: %s\n", strategy.CardToString(attempt.DealerCard))
+	fmt.Fprintf(&b, "  Your hand: %s\n", playerHand)
+
+	timing := ""
+	if attempt.LatencyMs > 0 {
+		timing = fmt.Sprintf(" (%.1fs)", attempt.Latency().Seconds())
+	}
+	yourAnswer := strategy.ActionToString(firstRune(attempt.Action))
+
+	if attempt.Correct {
+		fmt.Fprintf(&b, "  You answered: %s%s  ✓\n", yourAnswer, timing)
+	} else {
+		fmt.Fprintf(&b, "  You answered: %s%s  ❌\n", yourAnswer, timing)
+		fmt.Fprintf(&b, "  Correct answer: %s\n", strategy.ActionToString(firstRune(attempt.CorrectAction)))
+		fmt.Fprintf(&b, "  Pattern: %s\n", chart.GetExplanationForHand(playerHand, attempt.DealerCard))
+	}
+	for _, note := range attempt.Notes {
+		fmt.Fprintf(&b, "  Coach: %s\n", note)
+	}
+	return b.String()
+}
+
+// score formats a session's score as "correct/total (percent)".
+func score(s history.Session) string {
+	percent := 0.0
+	if s.Total > 0 {
+		percent = float64(s.Correct) / float64(s.Total) * 100.0
+	}
+	return fmt.Sprintf("%d/%d (%.1f%%)", s.Correct, s.Total, percent)
+}
+
+func firstRune(s string) rune {
+	for _, r := range s {
+		return r
+	}
+	return 0
+}
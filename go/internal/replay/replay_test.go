@@ -0,0 +1,85 @@
+package replay
+
+import (
+	"blackjack_trainer/internal/history"
+	"blackjack_trainer/internal/strategy"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testSession() history.Session {
+	started := time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)
+	return history.Session{
+		Mode:    "random",
+		Started: started,
+		Ended:   started.Add(2 * time.Minute),
+		Correct: 1,
+		Total:   2,
+		Attempts: []history.Attempt{
+			{Cards: []int{10, 6}, DealerCard: 10, HandType: "hard", Action: "S", CorrectAction: "H", LatencyMs: 2300},
+			{Cards: []int{8, 8}, DealerCard: 11, HandType: "pair", Action: "Y", CorrectAction: "Y", Correct: true, LatencyMs: 900,
+				Notes: []string{"Always split aces"}},
+		},
+	}
+}
+
+// Test each question shows the scenario, answer, timing, correction and
+// coach's notes
+func TestQuestion(t *testing.T) {
+	session := testSession()
+	chart := strategy.New()
+
+	missed := Question(1, 2, session.Attempts[0], chart)
+	for _, want := range []string{"Question 1/2: Hard 16 vs 10", "Your hand: 10, 6", "You answered: STAND (2.3s)", "Correct answer: HIT", "Pattern:"} {
+		if !strings.Contains(missed, want) {
+			t.Errorf("Missed question should contain %q, got:\n%s", want, missed)
+		}
+	}
+
+	right := Question(2, 2, session.Attempts[1], chart)
+	if !strings.Contains(right, "You answered: SPLIT (0.9s)  ✓\n  Coach: Always split aces\n") || strings.Contains(right, "Correct answer") {
+		t.Errorf("Correct question formatted unexpectedly:\n%s", right)
+	}
+}
+
+// Test playback pauses between questions and stops on q
+func TestPlay(t *testing.T) {
+	session := testSession()
+
+	var out bytes.Buffer
+	Play(&out, strings.NewReader("\n"), session, strategy.New(), true)
+	if !strings.Contains(out.String(), "Question 2/2") || !strings.Contains(out.String(), "End of session.") {
+		t.Errorf("Full playback should show every question:\n%s", out.String())
+	}
+
+	out.Reset()
+	Play(&out, strings.NewReader("q\n"), session, strategy.New(), true)
+	if strings.Contains(out.String(), "Question 2/2") {
+		t.Errorf("Playback should stop after q:\n%s", out.String())
+	}
+
+	out.Reset()
+	Play(&out, strings.NewReader(""), session, strategy.New(), false)
+	if !strings.Contains(out.String(), "Question 2/2") {
+		t.Errorf("Playback without pausing should not need input:\n%s", out.String())
+	}
+}
+
+// Test the session list numbers sessions oldest first
+func TestList(t *testing.T) {
+	h := history.New()
+	var out bytes.Buffer
+	List(&out, h)
+	if !strings.Contains(out.String(), "No sessions") {
+		t.Errorf("Empty history should say so, got %q", out.String())
+	}
+
+	h.Add(testSession())
+	out.Reset()
+	List(&out, h)
+	if !strings.Contains(out.String(), "   1  2024-03-06 12:00  random         1/2 (50.0%)  2m 00s") {
+		t.Errorf("Unexpected list output: %q", out.String())
+	}
+}
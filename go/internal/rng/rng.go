@@ -0,0 +1,94 @@
+// Package rng provides the random number sources questions are drawn from.
+//
+// A source drives a math/rand Rand, so code that draws numbers keeps using
+// *rand.Rand whatever the source behind it:
+// - Seeded: A repeatable sequence, for replays and scripted sessions
+// - Secure: Numbers from crypto/rand, which can't be predicted by watching a session
+// - Ints: A fixed sequence of Intn results, for tests
+package rng
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Source is a source of random numbers for rand.New.
+type Source = rand.Source
+
+// Seeded returns a source that always produces the same numbers from the
+// same seed.
+func Seeded(seed int64) Source {
+	return rand.NewSource(seed)
+}
+
+// FromClock returns a source seeded from the current time.
+func FromClock() Source {
+	return Seeded(time.Now().UnixNano())
+}
+
+// secure reads numbers from the operating system's cryptographically secure
+// generator.
+type secure struct{}
+
+// Secure returns a source whose numbers come from crypto/rand, so the
+// questions of an exam or shared session can't be predicted from the ones
+// already seen. It can't be seeded, and so can't be replayed.
+func Secure() Source {
+	return secure{}
+}
+
+// Int63 returns a non-negative random 63-bit integer.
+func (secure) Int63() int64 {
+	return int64(secure{}.Uint64() &^ (1 << 63))
+}
+
+// Uint64 returns a random 64-bit integer.
+func (secure) Uint64() uint64 {
+	var b [8]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		// A source has no way to return an error, and carrying on with
+		// predictable numbers would defeat the point
+		panic(fmt.Sprintf("reading secure random numbers: %v", err))
+	}
+	return binary.LittleEndian.Uint64(b[:])
+}
+
+// Seed does nothing: secure numbers can't be reproduced.
+func (secure) Seed(int64) {}
+
+// sequence repeats a fixed list of numbers.
+type sequence struct {
+	values []int64
+	next   int
+}
+
+// Ints returns a source under which each call of Intn(n) on a rand.Rand
+// returns the next of values (modulo n), starting over after the last, so
+// a test can choose the questions it is asked. Other methods of rand.Rand
+// draw from the same list but don't return the values as given.
+func Ints(values ...int) Source {
+	s := &sequence{}
+	for _, v := range values {
+		// Intn(n) returns Int31() % n, and Int31 is the top 31 bits of Int63
+		s.values = append(s.values, int64(v)<<32)
+	}
+	return s
+}
+
+// Int63 returns the next number of the sequence.
+func (s *sequence) Int63() int64 {
+	if len(s.values) == 0 {
+		return 0
+	}
+	v := s.values[s.next]
+	s.next = (s.next + 1) % len(s.values)
+	return v
+}
+
+// Seed starts the sequence over.
+func (s *sequence) Seed(int64) {
+	s.next = 0
+}
@@ -0,0 +1,45 @@
+package rng
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// Test seeded sources repeat and fixed sequences return the chosen values
+func TestSources(t *testing.T) {
+	a, b := rand.New(Seeded(7)), rand.New(Seeded(7))
+	for i := 0; i < 10; i++ {
+		if x, y := a.Intn(100), b.Intn(100); x != y {
+			t.Fatalf("Same seed gave %d and %d", x, y)
+		}
+	}
+
+	r := rand.New(Ints(3, 0, 9))
+	for _, want := range []int{3, 0, 9, 3} {
+		if got := r.Intn(10); got != want {
+			t.Errorf("Intn(10) = %d, want %d", got, want)
+		}
+	}
+	if got := rand.New(Ints(12)).Intn(10); got != 2 {
+		t.Errorf("Values should wrap modulo n: got %d, want 2", got)
+	}
+}
+
+// Test the secure source stays in range and doesn't repeat itself
+func TestSecure(t *testing.T) {
+	r := rand.New(Secure())
+	seen := make(map[int64]bool)
+	for i := 0; i < 100; i++ {
+		v := r.Int63()
+		if v < 0 {
+			t.Fatalf("Int63 returned negative %d", v)
+		}
+		seen[v] = true
+		if n := r.Intn(10); n < 0 || n >= 10 {
+			t.Fatalf("Intn(10) returned %d", n)
+		}
+	}
+	if len(seen) < 99 {
+		t.Errorf("Expected distinct secure numbers, got %d of 100", len(seen))
+	}
+}
@@ -0,0 +1,195 @@
+// Package rulequiz quizzes the table rules of a rule set.
+//
+// Knowing the rules comes before knowing the chart: the right play for 11 vs
+// an ace depends on whether the dealer hits soft 17, and splitting 8,8 vs 10
+// depends on whether the dealer checks for blackjack. Each question is asked
+// about the selected rule set and explained after it is answered.
+package rulequiz
+
+import (
+	"blackjack_trainer/internal/strategy"
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// Question is one quiz question about a rule set.
+type Question struct {
+	// Prompt is the question text.
+	Prompt string
+	// Answer is the correct answer: "y" or "n" for yes/no questions, or a
+	// number.
+	Answer string
+	// Explanation says why the rule matters for strategy.
+	Explanation string
+}
+
+// YesNo reports whether the question is answered yes or no.
+func (q Question) YesNo() bool {
+	return q.Answer == "y" || q.Answer == "n"
+}
+
+// Check reports whether input answers the question correctly. Yes/no
+// questions accept any word starting with y or n.
+func (q Question) Check(input string) bool {
+	input = strings.ToLower(strings.TrimSpace(input))
+	if q.YesNo() {
+		return input != "" && input[:1] == q.Answer
+	}
+	n, err := strconv.Atoi(input)
+	return err == nil && strconv.Itoa(n) == q.Answer
+}
+
+// Result is the score of a completed quiz.
+type Result struct {
+	Correct int
+	Total   int
+}
+
+// Questions returns the quiz questions for a rule set, in a fixed order.
+func Questions(r strategy.RuleSet) []Question {
+	return []Question{
+		{
+			Prompt:      "Does the dealer hit soft 17?",
+			Answer:      yesNo(r.DealerHitsSoft17),
+			Explanation: "Hitting soft 17 helps the house and adds doubles: 11 vs A, A,7 vs 2 and A,8 vs 6.",
+		},
+		{
+			Prompt:      "Can you double after splitting?",
+			Answer:      yesNo(r.DoubleAfterSplit),
+			Explanation: "Doubling after a split makes splitting small pairs (2,2, 3,3, 4,4, 6,6) worth it more often.",
+		},
+		{
+			Prompt:      "Can you double on any first two cards, including soft hands?",
+			Answer:      yesNo(r.Double == strategy.DoubleAnyTwo),
+			Explanation: "When only hard 9-11 can be doubled, soft doubles become hits (or stands on soft 18 and 19).",
+		},
+		{
+			Prompt:      "Does the dealer check for blackjack before you play?",
+			Answer:      yesNo(r.HoleCard),
+			Explanation: "Without a hole card, a dealer blackjack takes doubles and splits too, so 11 vs 10 hits and 8,8 vs 10 or A hits.",
+		},
+		{
+			Prompt:      "Is late surrender allowed?",
+			Answer:      yesNo(r.LateSurrender),
+			Explanation: "Surrendering gives up half the bet; it is worth knowing for hard 15 and 16 vs strong dealer cards.",
+		},
+		{
+			Prompt:      "Does a blackjack pay 3:2?",
+			Answer:      yesNo(r.BlackjackPays == "3:2"),
+			Explanation: payoutExplanation(r),
+		},
+		payoutQuestion(r),
+		payoutCostQuestion(r),
+		{
+			Prompt:      "How many decks are in the shoe?",
+			Answer:      strconv.Itoa(r.Decks),
+			Explanation: "Fewer decks favor doubling, and single-deck charts double and split more than shoe charts.",
+		},
+	}
+}
+
+// Run asks every question for the rule set in random order, reading
+// answers from in and writing prompts and feedback to w. Entering q stops
+// the quiz early; the result counts the questions answered.
+func Run(w io.Writer, in io.Reader, r strategy.RuleSet, rng *rand.Rand) Result {
+	questions := Questions(r)
+	rng.Shuffle(len(questions), func(i, j int) { questions[i], questions[j] = questions[j], questions[i] })
+
+	fmt.Fprintln(w, "\n"+strings.Repeat("=", 40))
+	fmt.Fprintf(w, "Rules Quiz: %s\n", r.Name)
+	fmt.Fprintln(w, strings.Repeat("=", 40))
+	fmt.Fprintln(w, "(Press 'q' + Enter to quit at any time)")
+
+	var result Result
+	reader := bufio.NewReader(in)
+	for i, q := range questions {
+		hint := "number"
+		if q.YesNo() {
+			hint = "y/n"
+		}
+		fmt.Fprintf(w, "\nQuestion %d/%d: %s (%s): ", i+1, len(questions), q.Prompt, hint)
+
+		input, err := reader.ReadString('\n')
+		if err != nil && input == "" {
+			break
+		}
+		if strings.EqualFold(strings.TrimSpace(input), "q") {
+			break
+		}
+
+		result.Total++
+		if q.Check(input) {
+			result.Correct++
+			fmt.Fprintln(w, "✓ Correct!")
+		} else {
+			fmt.Fprintf(w, "❌ Incorrect. The answer is %s.\n", answerText(q))
+		}
+		fmt.Fprintf(w, "   %s\n", q.Explanation)
+	}
+
+	fmt.Fprintf(w, "\nRules quiz score: %d/%d\n", result.Correct, result.Total)
+	fmt.Fprintf(w, "%s rules: %s\n", r.Name, r.Summary())
+	return result
+}
+
+// payoutExplanation says what the table's payout is and what a short one
+// costs.
+func payoutExplanation(r strategy.RuleSet) string {
+	if cost := r.PayoutCost(); cost > 0 {
+		return fmt.Sprintf("Blackjack pays %s here, which adds %.2f%% to the house edge. Check the felt for 3:2.", r.BlackjackPays, cost)
+	}
+	return fmt.Sprintf("Blackjack pays %s here. A 6:5 payout would add over 1.3%% to the house edge, so check the felt.", r.BlackjackPays)
+}
+
+// payoutQuestion asks what a blackjack wins at the table's payout.
+func payoutQuestion(r strategy.RuleSet) Question {
+	bet := 10
+	if win := float64(bet) * r.Payout(); win != math.Trunc(win) {
+		bet = 20
+	}
+	win := int(math.Round(float64(bet) * r.Payout()))
+	return Question{
+		Prompt: fmt.Sprintf("You bet $%d and are dealt a blackjack. How many dollars do you win?", bet),
+		Answer: strconv.Itoa(win),
+		Explanation: fmt.Sprintf("At %s a $%d blackjack wins $%d; at 6:5 it would win $%d and at 3:2 $%d.",
+			r.BlackjackPays, bet, win, bet*6/5, bet*3/2),
+	}
+}
+
+// payoutCostQuestion asks what a 6:5 payout costs over a session at the
+// table's deck count.
+func payoutCostQuestion(r strategy.RuleSet) Question {
+	sixFive := r
+	sixFive.BlackjackPays = "6:5"
+	cost := sixFive.PayoutCost() / 100 * 10 * 100
+	return Question{
+		Prompt: "Over 100 hands of $10, about how many dollars more does a 6:5 payout cost than 3:2?",
+		Answer: strconv.Itoa(int(math.Round(cost))),
+		Explanation: fmt.Sprintf("A paid blackjack comes about once in %.0f hands and wins $3 less at 6:5, "+
+			"so $1,000 of bets loses about $%.2f more: %.2f%% added to the house edge.",
+			1/strategy.BlackjackChance(r.Decks), cost, sixFive.PayoutCost()),
+	}
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "y"
+	}
+	return "n"
+}
+
+func answerText(q Question) string {
+	switch q.Answer {
+	case "y":
+		return "yes"
+	case "n":
+		return "no"
+	default:
+		return q.Answer
+	}
+}
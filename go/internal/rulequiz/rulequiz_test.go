@@ -0,0 +1,106 @@
+package rulequiz
+
+import (
+	"blackjack_trainer/internal/strategy"
+	"bytes"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// Test answers follow the rule set
+func TestQuestions(t *testing.T) {
+	european, _ := strategy.LookupRules("european")
+	downtown, _ := strategy.LookupRules("downtown")
+	sixFive := strategy.Standard
+	sixFive.BlackjackPays = "6:5"
+
+	tests := []struct {
+		rules  strategy.RuleSet
+		prompt string
+		answer string
+	}{
+		{european, "Does the dealer check for blackjack before you play?", "n"},
+		{european, "Can you double on any first two cards, including soft hands?", "n"},
+		{european, "How many decks are in the shoe?", "6"},
+		{downtown, "Does the dealer hit soft 17?", "y"},
+		{downtown, "Can you double after splitting?", "n"},
+		{downtown, "How many decks are in the shoe?", "1"},
+		{strategy.Standard, "Is late surrender allowed?", "n"},
+		{strategy.Standard, "You bet $10 and are dealt a blackjack. How many dollars do you win?", "15"},
+		{sixFive, "You bet $10 and are dealt a blackjack. How many dollars do you win?", "12"},
+		{strategy.Standard, "Over 100 hands of $10, about how many dollars more does a 6:5 payout cost than 3:2?", "14"},
+		{downtown, "Over 100 hands of $10, about how many dollars more does a 6:5 payout cost than 3:2?", "14"},
+	}
+
+	for _, tt := range tests {
+		found := false
+		for _, q := range Questions(tt.rules) {
+			if q.Prompt == tt.prompt {
+				found = true
+				if q.Answer != tt.answer {
+					t.Errorf("%s: %q expected %q, got %q", tt.rules.Key, tt.prompt, tt.answer, q.Answer)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("Missing question %q", tt.prompt)
+		}
+	}
+}
+
+// Test answer checking accepts words and numbers
+func TestCheck(t *testing.T) {
+	yes := Question{Answer: "y"}
+	decks := Question{Answer: "6"}
+
+	tests := []struct {
+		q     Question
+		input string
+		want  bool
+	}{
+		{yes, "y\n", true},
+		{yes, " Yes ", true},
+		{yes, "n", false},
+		{yes, "", false},
+		{decks, "6", true},
+		{decks, "06", true},
+		{decks, "8", false},
+		{decks, "six", false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.q.Check(tt.input); got != tt.want {
+			t.Errorf("Check(%q) for answer %q = %v, want %v", tt.input, tt.q.Answer, got, tt.want)
+		}
+	}
+}
+
+// Test a scripted quiz is scored and stops on q
+func TestRun(t *testing.T) {
+	rules := strategy.Standard
+	rng := rand.New(rand.NewSource(1))
+
+	// Answer every question correctly in the order Run will ask them
+	questions := Questions(rules)
+	rand.New(rand.NewSource(1)).Shuffle(len(questions), func(i, j int) { questions[i], questions[j] = questions[j], questions[i] })
+	var input strings.Builder
+	for _, q := range questions {
+		input.WriteString(q.Answer + "\n")
+	}
+
+	var out bytes.Buffer
+	result := Run(&out, strings.NewReader(input.String()), rules, rng)
+	if result.Correct != len(questions) || result.Total != len(questions) {
+		t.Errorf("Expected a perfect score, got %d/%d:\n%s", result.Correct, result.Total, out.String())
+	}
+
+	out.Reset()
+	result = Run(&out, strings.NewReader("maybe\nq\n"), rules, rand.New(rand.NewSource(1)))
+	if result.Correct != 0 || result.Total != 1 {
+		t.Errorf("Expected 0/1 after quitting, got %d/%d", result.Correct, result.Total)
+	}
+	if !strings.Contains(out.String(), "Incorrect. The answer is") {
+		t.Errorf("Wrong answer should show the correct one:\n%s", out.String())
+	}
+}
@@ -0,0 +1,234 @@
+// Package rulewizard builds a rule set from plain-language questions about
+// the player's casino ("Does the dealer hit soft 17?", "How many decks?"),
+// for players who don't know the preset names, and saves it as their
+// default rules.
+//
+// Saved rules are stored as JSON in rules.json beside the practice history.
+package rulewizard
+
+import (
+	"blackjack_trainer/internal/atomicfile"
+	"blackjack_trainer/internal/strategy"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// FileName is the name of the saved rules file in the trainer's directory.
+const FileName = "rules.json"
+
+// CustomKey is the key of rules that match no preset.
+const CustomKey = "custom"
+
+// question is a yes/no question setting one rule. Enter takes the default,
+// the more common answer.
+type question struct {
+	prompt string
+	hint   string
+	def    bool
+	set    func(r *strategy.RuleSet, yes bool)
+}
+
+// questions are asked after the number of decks, in order.
+var questions = []question{
+	{
+		prompt: "Does the dealer hit soft 17?",
+		hint:   `Look on the felt for "Dealer hits soft 17" (H17) or "Dealer stands on all 17s" (S17).`,
+		def:    false,
+		set:    func(r *strategy.RuleSet, yes bool) { r.DealerHitsSoft17 = yes },
+	},
+	{
+		prompt: "Can you double down after splitting a pair?",
+		hint:   "Most shoe games allow it; some single-deck games don't.",
+		def:    true,
+		set:    func(r *strategy.RuleSet, yes bool) { r.DoubleAfterSplit = yes },
+	},
+	{
+		prompt: "Can you double down on any first two cards, not just 9, 10 or 11?",
+		hint:   "Some European casinos only allow doubling on hard 9, 10 and 11.",
+		def:    true,
+		set: func(r *strategy.RuleSet, yes bool) {
+			r.Double = strategy.DoubleAnyTwo
+			if !yes {
+				r.Double = strategy.DoubleNineToEleven
+			}
+		},
+	},
+	{
+		prompt: "Does the dealer check for blackjack before you play?",
+		hint:   "In most European casinos the dealer takes no hole card, so the answer is no.",
+		def:    true,
+		set:    func(r *strategy.RuleSet, yes bool) { r.HoleCard = yes },
+	},
+	{
+		prompt: "Can you surrender, giving up half your bet, after the dealer checks for blackjack?",
+		hint:   "Late surrender is offered at many Strip and Atlantic City tables.",
+		def:    false,
+		set:    func(r *strategy.RuleSet, yes bool) { r.LateSurrender = yes },
+	},
+	{
+		prompt: "Does a blackjack pay 3 to 2?",
+		hint:   "Answer no if the felt says blackjack pays 6 to 5.",
+		def:    true,
+		set: func(r *strategy.RuleSet, yes bool) {
+			r.BlackjackPays = "3:2"
+			if !yes {
+				r.BlackjackPays = "6:5"
+			}
+		},
+	},
+}
+
+// defaultDecks is the deck count assumed when the player doesn't know.
+const defaultDecks = 6
+
+// Run asks the questions, reading answers from in and writing to w, and
+// returns the rules described: a preset if the answers match one, or
+// custom rules otherwise. Entering q stops the wizard and returns false.
+func Run(w io.Writer, in io.Reader) (strategy.RuleSet, bool) {
+	fmt.Fprintln(w, "\n"+strings.Repeat("=", 40))
+	fmt.Fprintln(w, "Table Rules")
+	fmt.Fprintln(w, strings.Repeat("=", 40))
+	fmt.Fprintln(w, "Basic strategy depends on the table rules. Answer a few questions about")
+	fmt.Fprintln(w, "where you play; press Enter if you're not sure. ('q' + Enter to stop)")
+
+	reader := bufio.NewReader(in)
+	r := strategy.RuleSet{Key: CustomKey, Name: "Custom"}
+
+	for {
+		fmt.Fprintf(w, "\nHow many decks does the game use? (1-8, Enter for %d): ", defaultDecks)
+		input, ok := readAnswer(reader)
+		if !ok {
+			return strategy.RuleSet{}, false
+		}
+		if input == "" {
+			r.Decks = defaultDecks
+			break
+		}
+		if decks, err := strconv.Atoi(input); err == nil && decks >= 1 && decks <= 8 {
+			r.Decks = decks
+			break
+		}
+		fmt.Fprintln(w, "Please enter a number from 1 to 8.")
+	}
+
+	for _, q := range questions {
+		fmt.Fprintf(w, "\n%s\n  %s\n", q.prompt, q.hint)
+		for {
+			fmt.Fprintf(w, "(y/n, Enter for %s): ", yesNo(q.def))
+			input, ok := readAnswer(reader)
+			if !ok {
+				return strategy.RuleSet{}, false
+			}
+			if input == "" {
+				q.set(&r, q.def)
+				break
+			}
+			if answer := strings.ToLower(input)[:1]; answer == "y" || answer == "n" {
+				q.set(&r, answer == "y")
+				break
+			}
+		}
+	}
+
+	if preset, ok := strategy.MatchPreset(r); ok {
+		fmt.Fprintf(w, "\nThose are the %s rules: %s\n", preset.Name, preset.Summary())
+		r = preset
+	} else {
+		fmt.Fprintf(w, "\nYour rules: %s\n", r.Summary())
+	}
+	if warning := strategy.PayoutWarning(r); warning != "" {
+		fmt.Fprintln(w, warning)
+	}
+	return r, true
+}
+
+// readAnswer reads a trimmed line, returning false at the end of input or
+// when the player enters q.
+func readAnswer(reader *bufio.Reader) (string, bool) {
+	input, err := reader.ReadString('\n')
+	input = strings.TrimSpace(input)
+	if (err != nil && input == "") || strings.EqualFold(input, "q") {
+		return "", false
+	}
+	return input, true
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// saved is the JSON form of saved rules.
+type saved struct {
+	Key                string `json:"key"`
+	Name               string `json:"name"`
+	Decks              int    `json:"decks"`
+	DealerHitsSoft17   bool   `json:"dealer_hits_soft_17"`
+	DoubleAfterSplit   bool   `json:"double_after_split"`
+	DoubleNineToEleven bool   `json:"double_9_to_11_only"`
+	LateSurrender      bool   `json:"late_surrender"`
+	NoHoleCard         bool   `json:"no_hole_card"`
+	BlackjackPays      string `json:"blackjack_pays"`
+}
+
+// Save writes rules as the player's default.
+func Save(path string, r strategy.RuleSet) error {
+	data, err := json.MarshalIndent(saved{
+		Key:                r.Key,
+		Name:               r.Name,
+		Decks:              r.Decks,
+		DealerHitsSoft17:   r.DealerHitsSoft17,
+		DoubleAfterSplit:   r.DoubleAfterSplit,
+		DoubleNineToEleven: r.Double == strategy.DoubleNineToEleven,
+		LateSurrender:      r.LateSurrender,
+		NoHoleCard:         !r.HoleCard,
+		BlackjackPays:      r.BlackjackPays,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// Load reads saved rules. It returns false, with no error, when none have
+// been saved.
+func Load(path string) (strategy.RuleSet, bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return strategy.RuleSet{}, false, nil
+	}
+	if err != nil {
+		return strategy.RuleSet{}, false, err
+	}
+	var s saved
+	if err := json.Unmarshal(data, &s); err != nil {
+		return strategy.RuleSet{}, false, fmt.Errorf("reading %s: %w", path, err)
+	}
+	if s.Decks < 1 || s.Decks > 8 {
+		return strategy.RuleSet{}, false, fmt.Errorf("reading %s: invalid number of decks %d", path, s.Decks)
+	}
+	r := strategy.RuleSet{
+		Key:              s.Key,
+		Name:             s.Name,
+		Decks:            s.Decks,
+		DealerHitsSoft17: s.DealerHitsSoft17,
+		DoubleAfterSplit: s.DoubleAfterSplit,
+		Double:           strategy.DoubleAnyTwo,
+		LateSurrender:    s.LateSurrender,
+		HoleCard:         !s.NoHoleCard,
+		BlackjackPays:    s.BlackjackPays,
+	}
+	if s.DoubleNineToEleven {
+		r.Double = strategy.DoubleNineToEleven
+	}
+	return r, true, nil
+}
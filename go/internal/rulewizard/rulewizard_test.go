@@ -0,0 +1,70 @@
+package rulewizard
+
+import (
+	"blackjack_trainer/internal/strategy"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Test answers are turned into a preset when they match one, or custom rules
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		answers string
+		key     string
+		check   func(strategy.RuleSet) bool
+	}{
+		{"all defaults", "\n\n\n\n\n\n\n", "standard", nil},
+		{"european", "6\nn\ny\nn\nn\nn\ny\n", "european", nil},
+		{"retries bad answers", "9\n1\nmaybe\ny\nn\n\n\n\n\n", "single-deck-downtown", nil},
+		{"six to five", "\n\n\n\n\n\nno\n", CustomKey, func(r strategy.RuleSet) bool {
+			return r.BlackjackPays == "6:5" && r.Decks == 6 && r.HoleCard
+		}},
+	}
+	for _, tt := range tests {
+		rules, ok := Run(io.Discard, strings.NewReader(tt.answers))
+		if !ok {
+			t.Errorf("%s: wizard stopped early", tt.name)
+			continue
+		}
+		if rules.Key != tt.key {
+			t.Errorf("%s: got %s rules (%s), want %s", tt.name, rules.Key, rules.Summary(), tt.key)
+		}
+		if tt.check != nil && !tt.check(rules) {
+			t.Errorf("%s: unexpected rules %s", tt.name, rules.Summary())
+		}
+	}
+
+	var out strings.Builder
+	Run(&out, strings.NewReader("\n\n\n\n\n\nno\n"))
+	if !strings.Contains(out.String(), "Warning: blackjack pays 6:5") {
+		t.Errorf("A 6:5 table should be warned about:\n%s", out.String())
+	}
+
+	if _, ok := Run(io.Discard, strings.NewReader("6\nq\n")); ok {
+		t.Error("Entering q should stop the wizard")
+	}
+}
+
+// Test saved rules load back the same
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+	if _, ok, err := Load(path); ok || err != nil {
+		t.Errorf("Load of missing file = %v, %v; want no rules and no error", ok, err)
+	}
+
+	european, _ := strategy.LookupRules("european")
+	custom := european
+	custom.Key, custom.Name, custom.Decks, custom.LateSurrender = CustomKey, "Custom", 2, true
+	for _, rules := range []strategy.RuleSet{european, custom} {
+		if err := Save(path, rules); err != nil {
+			t.Fatal(err)
+		}
+		loaded, ok, err := Load(path)
+		if !ok || err != nil || loaded != rules {
+			t.Errorf("Load() = %+v, %v, %v; want %+v", loaded, ok, err, rules)
+		}
+	}
+}
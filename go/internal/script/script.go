@@ -0,0 +1,409 @@
+// Package script runs training sessions from scripts of recorded input and
+// compares their output with golden transcripts, for end-to-end regression
+// tests of session flows.
+//
+// A script is a text file of settings followed by the lines to type:
+//
+//	# Quick practice: answer two questions, then quit
+//	session random
+//	seed 42
+//	> h
+//	>
+//	> s
+//	> q
+//
+// Each "> " line is typed as one line of input; ">" alone presses Enter.
+// The settings are session (required: random, dealer, hand, absolute,
+// realistic or composition), seed (default 1), difficulty, auto-difficulty
+// (true or false), rules, game, max-repeat, skips (default 0),
+// repeat-misses (default false), goal, goal-questions and keys, with the same meaning as the
+// command-line flags, and "bind action key" lines that override a key
+// binding as in the config file. A recording also notes the version and
+// chart version of the build that made it. Blank lines and lines starting
+// with # are ignored.
+//
+// Sessions run against an empty history that is never saved, with a clock
+// that advances one second each time it is read, so the same script always
+// produces the same transcript. Input is echoed where it is read, as on a
+// terminal.
+//
+// A Recorder writes a script while a real session is played, so the
+// session can be reproduced later with Run.
+package script
+
+import (
+	"blackjack_trainer/internal/stats"
+	"blackjack_trainer/internal/strategy"
+	"blackjack_trainer/internal/trainer"
+	"blackjack_trainer/internal/ui"
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GoldenSuffix is appended to a script's path to name its golden transcript.
+const GoldenSuffix = ".golden"
+
+// Script is a parsed session script.
+type Script struct {
+	Session    string
+	Seed       int64
+	Difficulty trainer.Difficulty
+	// AutoDifficulty moves the difficulty with the player's accuracy.
+	AutoDifficulty bool
+	Rules          string
+	Game           string
+	MaxRepeat      int
+	Skips          int
+	RepeatMisses   bool
+	// Goal is the session's target accuracy in percent, judged over at
+	// least GoalQuestions answers; zero for none.
+	Goal          int
+	GoalQuestions int
+	// Keys is the key scheme and Bindings the per-action key overrides,
+	// which the input was typed with.
+	Keys     string
+	Bindings map[string]string
+	// Version and Chart are the build and strategy.ChartVersion a session
+	// was recorded with; empty and zero in hand-written scripts.
+	Version string
+	Chart   int
+	// Input holds the lines to type, without newlines.
+	Input []string
+}
+
+// Parse reads a script.
+func Parse(r io.Reader) (Script, error) {
+	s := Script{
+		Seed:       1,
+		Difficulty: trainer.DifficultyNormal,
+		Rules:      strategy.Standard.Key,
+		Game:       "classic",
+		MaxRepeat:  trainer.DefaultMaxRepeat,
+	}
+
+	scanner := bufio.NewScanner(r)
+	for number := 1; scanner.Scan(); number++ {
+		line := scanner.Text()
+		if strings.HasPrefix(line, ">") {
+			s.Input = append(s.Input, strings.TrimPrefix(strings.TrimPrefix(line, ">"), " "))
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if fields[0] == "bind" && len(fields) == 3 {
+			if s.Bindings == nil {
+				s.Bindings = make(map[string]string)
+			}
+			s.Bindings[fields[1]] = fields[2]
+			continue
+		}
+		if len(fields) != 2 {
+			return Script{}, fmt.Errorf("line %d: expected \"setting value\" or \"> input\"", number)
+		}
+
+		var err error
+		switch name, value := fields[0], fields[1]; name {
+		case "session":
+			s.Session = value
+		case "seed":
+			s.Seed, err = strconv.ParseInt(value, 10, 64)
+		case "difficulty":
+			s.Difficulty, err = trainer.ParseDifficulty(value)
+		case "auto-difficulty":
+			s.AutoDifficulty, err = strconv.ParseBool(value)
+		case "rules":
+			s.Rules = value
+		case "game":
+			s.Game = value
+		case "max-repeat":
+			s.MaxRepeat, err = strconv.Atoi(value)
+		case "skips":
+			s.Skips, err = strconv.Atoi(value)
+		case "repeat-misses":
+			s.RepeatMisses, err = strconv.ParseBool(value)
+		case "goal":
+			s.Goal, err = strconv.Atoi(value)
+		case "goal-questions":
+			s.GoalQuestions, err = strconv.Atoi(value)
+		case "keys":
+			s.Keys = value
+		case "version":
+			s.Version = value
+		case "chart":
+			s.Chart, err = strconv.Atoi(value)
+		default:
+			err = fmt.Errorf("unknown setting %q", name)
+		}
+		if err != nil {
+			return Script{}, fmt.Errorf("line %d: %w", number, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Script{}, err
+	}
+
+	if s.Session == "" {
+		return Script{}, errors.New("missing session setting")
+	}
+	return s, nil
+}
+
+// ParseFile reads the script at path.
+func ParseFile(path string) (Script, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return Script{}, err
+	}
+	defer file.Close()
+	return Parse(file)
+}
+
+// Run plays the script's session and writes the transcript to w. It takes
+// over the ui streams, key bindings, speech and hand signals while running.
+func Run(s Script, w io.Writer) error {
+	rules, err := strategy.LookupRules(s.Rules)
+	if err != nil {
+		return err
+	}
+	game, err := strategy.NewGame(s.Game, rules)
+	if err != nil {
+		return err
+	}
+	session := trainer.NewSession(s.Session, game)
+	if session == nil {
+		return fmt.Errorf("unknown session type %q (valid: %s)", s.Session, strings.Join(trainer.SessionTypes(), ", "))
+	}
+	keyBindings, err := ui.NewKeyBindings(s.Keys, s.Bindings)
+	if err != nil {
+		return err
+	}
+
+	ui.SetIO(&lineReader{lines: s.Input, echo: w}, w)
+	defer ui.SetIO(os.Stdin, os.Stdout)
+	ui.SetKeyBindings(keyBindings)
+	ui.SetSpeaker(nil)
+	ui.SetHandSignals(false, false)
+
+	clock := time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC)
+	trainer.RunSession(context.Background(), session, stats.New(), trainer.Options{
+		Difficulty:     s.Difficulty,
+		AutoDifficulty: s.AutoDifficulty,
+		MaxRepeat:      s.MaxRepeat,
+		Skips:          s.Skips,
+		RepeatMisses:   s.RepeatMisses,
+		Goal:           s.Goal,
+		GoalQuestions:  s.GoalQuestions,
+		Chart:          game.Chart(),
+		Seed:           s.Seed,
+		Now: func() time.Time {
+			clock = clock.Add(time.Second)
+			return clock
+		},
+	})
+	return nil
+}
+
+// Format writes a script in the format read by Parse.
+func Format(w io.Writer, s Script) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "session %s\n", s.Session)
+	fmt.Fprintf(bw, "seed %d\n", s.Seed)
+	if s.Difficulty != "" {
+		fmt.Fprintf(bw, "difficulty %s\n", s.Difficulty)
+	}
+	if s.AutoDifficulty {
+		fmt.Fprintln(bw, "auto-difficulty true")
+	}
+	if s.Rules != "" {
+		fmt.Fprintf(bw, "rules %s\n", s.Rules)
+	}
+	if s.Game != "" {
+		fmt.Fprintf(bw, "game %s\n", s.Game)
+	}
+	fmt.Fprintf(bw, "max-repeat %d\n", s.MaxRepeat)
+	if s.Skips != 0 {
+		fmt.Fprintf(bw, "skips %d\n", s.Skips)
+	}
+	if s.RepeatMisses {
+		fmt.Fprintln(bw, "repeat-misses true")
+	}
+	if s.Goal != 0 {
+		fmt.Fprintf(bw, "goal %d\n", s.Goal)
+	}
+	if s.GoalQuestions != 0 {
+		fmt.Fprintf(bw, "goal-questions %d\n", s.GoalQuestions)
+	}
+	if s.Keys != "" {
+		fmt.Fprintf(bw, "keys %s\n", s.Keys)
+	}
+	names := make([]string, 0, len(s.Bindings))
+	for name := range s.Bindings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(bw, "bind %s %s\n", name, s.Bindings[name])
+	}
+	if s.Version != "" {
+		fmt.Fprintf(bw, "version %s\n", s.Version)
+	}
+	if s.Chart != 0 {
+		fmt.Fprintf(bw, "chart %d\n", s.Chart)
+	}
+	for _, line := range s.Input {
+		fmt.Fprintln(bw, inputLine(line))
+	}
+	return bw.Flush()
+}
+
+// inputLine formats one line of input.
+func inputLine(line string) string {
+	if line == "" {
+		return ">"
+	}
+	return "> " + line
+}
+
+// Recorder passes input through from a reader while appending each line to
+// a script, writing every line as soon as it is read so that a session cut
+// short still leaves a usable recording.
+type Recorder struct {
+	r       io.Reader
+	w       io.Writer
+	partial []byte
+}
+
+// NewRecorder writes the settings of s to w, headed by comment, and returns
+// a Recorder that appends the lines read from r as input.
+func NewRecorder(r io.Reader, w io.Writer, s Script, comment string) (*Recorder, error) {
+	s.Input = nil
+	if _, err := fmt.Fprintf(w, "# %s\n", comment); err != nil {
+		return nil, err
+	}
+	if err := Format(w, s); err != nil {
+		return nil, err
+	}
+	return &Recorder{r: r, w: w}, nil
+}
+
+// Read reads from the underlying reader, recording each completed line.
+func (rec *Recorder) Read(p []byte) (int, error) {
+	n, err := rec.r.Read(p)
+	rec.partial = append(rec.partial, p[:n]...)
+	for {
+		i := bytes.IndexByte(rec.partial, '\n')
+		if i < 0 {
+			break
+		}
+		line := strings.TrimSuffix(string(rec.partial[:i]), "\r")
+		rec.partial = rec.partial[i+1:]
+		if _, werr := fmt.Fprintln(rec.w, inputLine(line)); werr != nil && err == nil {
+			err = werr
+		}
+	}
+	return n, err
+}
+
+// Close records any final line that was not ended by a newline.
+func (rec *Recorder) Close() error {
+	if len(rec.partial) == 0 {
+		return nil
+	}
+	line := string(rec.partial)
+	rec.partial = nil
+	_, err := fmt.Fprintln(rec.w, inputLine(line))
+	return err
+}
+
+// Check runs the script at path and compares its transcript with the golden
+// transcript beside it, returning an error that describes the first
+// difference.
+func Check(path string) error {
+	s, err := ParseFile(path)
+	if err != nil {
+		return err
+	}
+	want, err := os.ReadFile(path + GoldenSuffix)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no golden transcript %s (run with -update to create it)", path+GoldenSuffix)
+	}
+	if err != nil {
+		return err
+	}
+
+	var got bytes.Buffer
+	if err := Run(s, &got); err != nil {
+		return err
+	}
+	return compare(want, got.Bytes())
+}
+
+// Update runs the script at path and writes its transcript as the golden
+// transcript.
+func Update(path string) error {
+	s, err := ParseFile(path)
+	if err != nil {
+		return err
+	}
+	var got bytes.Buffer
+	if err := Run(s, &got); err != nil {
+		return err
+	}
+	return os.WriteFile(path+GoldenSuffix, got.Bytes(), 0o644)
+}
+
+// compare returns an error describing the first line where got differs
+// from want.
+func compare(want, got []byte) error {
+	if bytes.Equal(want, got) {
+		return nil
+	}
+	wantLines := strings.Split(string(want), "\n")
+	gotLines := strings.Split(string(got), "\n")
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if i >= len(wantLines) || i >= len(gotLines) || w != g {
+			return fmt.Errorf("transcript differs at line %d:\n  want: %q\n  got:  %q", i+1, w, g)
+		}
+	}
+	return errors.New("transcript differs")
+}
+
+// lineReader supplies input one line per Read, echoing each line as it is
+// read so the transcript shows the input after the prompt that asked for it.
+type lineReader struct {
+	lines   []string
+	pending []byte
+	echo    io.Writer
+}
+
+func (r *lineReader) Read(p []byte) (int, error) {
+	if len(r.pending) == 0 {
+		if len(r.lines) == 0 {
+			return 0, io.EOF
+		}
+		r.pending = []byte(r.lines[0] + "\n")
+		r.lines = r.lines[1:]
+		r.echo.Write(r.pending)
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
@@ -0,0 +1,158 @@
+package script
+
+import (
+	"blackjack_trainer/internal/trainer"
+	"bufio"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestParse tests reading settings and input lines
+func TestParse(t *testing.T) {
+	s, err := Parse(strings.NewReader("# comment\nsession dealer\nseed 9\ndifficulty easy\nrules european\n\n> 1\n>\n>  h\n"))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if s.Session != "dealer" || s.Seed != 9 || s.Difficulty != trainer.DifficultyEasy || s.Rules != "european" {
+		t.Errorf("Parse settings = %+v", s)
+	}
+	if s.MaxRepeat != trainer.DefaultMaxRepeat || s.Game != "classic" {
+		t.Errorf("Parse defaults = %+v", s)
+	}
+	want := []string{"1", "", " h"}
+	if strings.Join(s.Input, "|") != strings.Join(want, "|") {
+		t.Errorf("Parse input = %q, want %q", s.Input, want)
+	}
+}
+
+// TestParseErrors tests that bad scripts are rejected
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+	}{
+		{"missing session", "seed 1\n> q\n"},
+		{"unknown setting", "session random\ncolor red\n"},
+		{"bad seed", "session random\nseed many\n"},
+		{"bad difficulty", "session random\ndifficulty extreme\n"},
+		{"too many fields", "session random dealer\n"},
+	}
+	for _, tt := range tests {
+		if _, err := Parse(strings.NewReader(tt.script)); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+// TestRunErrors tests that scripts naming unknown sessions or rules fail
+func TestRunErrors(t *testing.T) {
+	tests := []Script{
+		{Session: "nonsense", Rules: "standard", Game: "classic"},
+		{Session: "random", Rules: "nonsense", Game: "classic"},
+		{Session: "random", Rules: "standard", Game: "nonsense"},
+	}
+	for _, s := range tests {
+		if err := Run(s, &strings.Builder{}); err == nil {
+			t.Errorf("Run(%+v): expected an error", s)
+		}
+	}
+}
+
+// TestCompare tests locating the first differing line
+func TestCompare(t *testing.T) {
+	if err := compare([]byte("a\nb\n"), []byte("a\nb\n")); err != nil {
+		t.Errorf("compare of equal transcripts: %v", err)
+	}
+	err := compare([]byte("a\nb\nc\n"), []byte("a\nx\nc\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("compare = %v, want a difference at line 2", err)
+	}
+	err = compare([]byte("a\n"), []byte("a\nb\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("compare of longer transcript = %v, want a difference at line 2", err)
+	}
+}
+
+// TestFormat tests that formatted scripts parse back unchanged
+func TestFormat(t *testing.T) {
+	want := Script{
+		Session:       "hand",
+		Seed:          1234567890123,
+		Difficulty:    trainer.DifficultyHard,
+		Rules:         "european",
+		Game:          "classic",
+		MaxRepeat:     0,
+		Goal:          90,
+		GoalQuestions: 10,
+		Keys:          "numbers",
+		Bindings:      map[string]string{"split": "0"},
+		Version:       "1.4.0+3f9c2a1b7d4e",
+		Chart:         1,
+		Input:         []string{"2", "", " h", "q"},
+	}
+	var buf strings.Builder
+	if err := Format(&buf, want); err != nil {
+		t.Fatalf("Format failed: %v", err)
+	}
+	got, err := Parse(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("Parse of formatted script failed: %v\n%s", err, buf.String())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip = %+v, want %+v", got, want)
+	}
+}
+
+// TestRecorder tests that input read through a recorder is recorded as a script
+func TestRecorder(t *testing.T) {
+	var recording strings.Builder
+	settings := Script{Session: "random", Seed: 99, Difficulty: trainer.DifficultyEasy, Rules: "standard", Game: "classic", MaxRepeat: 3}
+	rec, err := NewRecorder(strings.NewReader("h\n\r\ns\nq"), &recording, settings, "test recording")
+	if err != nil {
+		t.Fatalf("NewRecorder failed: %v", err)
+	}
+	in := bufio.NewReader(rec)
+	var typed []string
+	for {
+		line, err := in.ReadString('\n')
+		if line != "" {
+			typed = append(typed, line)
+		}
+		if err != nil {
+			break
+		}
+	}
+	if len(typed) != 4 {
+		t.Errorf("recorder passed through %q, want 4 lines", typed)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	got, err := Parse(strings.NewReader(recording.String()))
+	if err != nil {
+		t.Fatalf("Parse of recording failed: %v\n%s", err, recording.String())
+	}
+	settings.Input = []string{"h", "", "s", "q"}
+	if !reflect.DeepEqual(got, settings) {
+		t.Errorf("recording = %+v, want %+v", got, settings)
+	}
+}
+
+// TestGolden runs every script in testdata against its golden transcript
+func TestGolden(t *testing.T) {
+	paths, err := filepath.Glob("testdata/*.script")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no scripts in testdata")
+	}
+	for _, path := range paths {
+		if err := Check(path); err != nil {
+			t.Errorf("%s: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"blackjack_trainer/internal/challenge"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// leaderboard ranks the server's users on a day's challenge.
+type leaderboard struct {
+	Day       string             `json:"day"`
+	Questions int                `json:"questions"`
+	Entries   []leaderboardEntry `json:"entries"`
+}
+
+// leaderboardEntry is one user's first try at the challenge.
+type leaderboardEntry struct {
+	Rank     int    `json:"rank"`
+	Username string `json:"username"`
+	Correct  int    `json:"correct"`
+	Total    int    `json:"total"`
+	Seconds  int64  `json:"seconds"`
+	// You marks the entry of the user asking.
+	You bool `json:"you,omitempty"`
+}
+
+// handleChallenge serves /api/challenges/{day}: the leaderboard for a day's
+// challenge, where day is a date or "today".
+func (s *Server) handleChallenge(w http.ResponseWriter, r *http.Request, user string) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	day := strings.TrimPrefix(r.URL.Path, "/api/challenges/")
+	if day == "today" {
+		day = challenge.Day(s.now())
+	} else {
+		var err error
+		if day, err = challenge.ParseDay(day); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var entries []challenge.Entry
+	for _, name := range s.userNames() {
+		st, err := s.student(name)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if played, ok := challenge.Find(st.history, day); ok {
+			entries = append(entries, challenge.Entry{Name: name, Correct: played.Correct, Total: played.Total,
+				Duration: challenge.Duration(played)})
+		}
+	}
+	challenge.Rank(entries)
+
+	result := leaderboard{Day: day, Questions: challenge.Questions, Entries: []leaderboardEntry{}}
+	for i, e := range entries {
+		result.Entries = append(result.Entries, leaderboardEntry{Rank: i + 1, Username: e.Name, Correct: e.Correct,
+			Total: e.Total, Seconds: int64(e.Duration.Seconds()), You: e.Name == user})
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// userNames returns every user with an account or an API key, in sorted
+// order.
+func (s *Server) userNames() []string {
+	names := s.users.Names()
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		seen[name] = true
+	}
+	for _, name := range s.apiKeys {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
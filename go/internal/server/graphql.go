@@ -0,0 +1,404 @@
+package server
+
+import (
+	"blackjack_trainer/internal/graphql"
+	"blackjack_trainer/internal/history"
+	"blackjack_trainer/internal/stats"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GraphQLSchema describes the queries /api/graphql answers. Percentages are
+// 0-100, and dates are written YYYY-MM-DD as the player's clock recorded
+// them.
+const GraphQLSchema = `type Query {
+  # Statistics for your sessions, optionally only those started from one
+  # date to another (inclusive), under a rule set, or in a mode.
+  stats(from: String, to: String, rules: String, mode: String): Stats!
+}
+
+type Stats {
+  sessions: Int!
+  questions: Int!
+  correct: Int!
+  accuracy: Float!
+  practiceSeconds: Int!
+  byHandType: [Category!]!
+  byDealerStrength: [Category!]!
+  byAction: [Category!]!
+  byRules: [Category!]!
+  # Chart cells with at least minAttempts answers, in chart order or
+  # weakest first.
+  cells(handType: String, dealerCard: Int, minAttempts: Int = 1,
+        orderBy: CellOrder = CHART, limit: Int): [Cell!]!
+  days: [Day!]!
+}
+
+enum CellOrder { CHART ACCURACY }
+
+type Category {
+  name: String!
+  correct: Int!
+  total: Int!
+  accuracy: Float!
+}
+
+type Cell {
+  label: String!
+  handType: String!
+  playerTotal: Int!
+  dealerCard: Int!
+  correct: Int!
+  total: Int!
+  accuracy: Float!
+}
+
+type Day {
+  date: String!
+  sessions: Int!
+  correct: Int!
+  total: Int!
+  accuracy: Float!
+}
+`
+
+// dateLayout is how GraphQL arguments and results write dates.
+const dateLayout = "2006-01-02"
+
+func (s *Server) handleGraphQL(w http.ResponseWriter, r *http.Request, user string) {
+	if !allowMethods(w, r, http.MethodGet, http.MethodPost) {
+		return
+	}
+
+	var req graphql.Request
+	if r.Method == http.MethodPost {
+		if !readJSON(w, r, &req) {
+			return
+		}
+	} else {
+		req.Query = r.URL.Query().Get("query")
+		if req.Query == "" {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			fmt.Fprint(w, GraphQLSchema)
+			return
+		}
+		if vars := r.URL.Query().Get("variables"); vars != "" {
+			if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+				writeError(w, http.StatusBadRequest, "invalid variables: "+err.Error())
+				return
+			}
+		}
+	}
+
+	// Queries run on a copy of the history so a slow one doesn't hold up
+	// other users
+	s.mu.Lock()
+	st, err := s.student(user)
+	var sessions []history.Session
+	if err == nil {
+		sessions = append(sessions, st.history.Sessions...)
+	}
+	s.mu.Unlock()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	resp := graphql.Execute(queryRoot{sessions: sessions}, req)
+	status := http.StatusOK
+	if resp.Data == nil {
+		status = http.StatusBadRequest
+	}
+	writeJSON(w, status, resp)
+}
+
+// queryRoot is the GraphQL Query type.
+type queryRoot struct {
+	sessions []history.Session
+}
+
+func (q queryRoot) TypeName() string { return "Query" }
+
+func (q queryRoot) Field(name string, args graphql.Args) (interface{}, error) {
+	if name != "stats" {
+		return nil, graphql.ErrNoField
+	}
+	if err := args.Allow("from", "to", "rules", "mode"); err != nil {
+		return nil, err
+	}
+	from, err := dateArg(args, "from")
+	if err != nil {
+		return nil, err
+	}
+	to, err := dateArg(args, "to")
+	if err != nil {
+		return nil, err
+	}
+	rules, err := args.String("rules")
+	if err != nil {
+		return nil, err
+	}
+	mode, err := args.String("mode")
+	if err != nil {
+		return nil, err
+	}
+
+	var result statsObject
+	for _, session := range q.sessions {
+		date := session.Started.Format(dateLayout)
+		if from != "" && date < from || to != "" && date > to ||
+			rules != "" && session.Rules != rules || mode != "" && session.Mode != mode {
+			continue
+		}
+		result.sessions = append(result.sessions, session)
+		for _, a := range session.Attempts {
+			if a.Rules == "" {
+				a.Rules = session.Rules
+			}
+			result.attempts = append(result.attempts, a)
+		}
+	}
+	return result, nil
+}
+
+// dateArg returns a date argument, checked to be YYYY-MM-DD, or "".
+func dateArg(args graphql.Args, name string) (string, error) {
+	date, err := args.String(name)
+	if err != nil || date == "" {
+		return "", err
+	}
+	if _, err := time.Parse(dateLayout, date); err != nil {
+		return "", fmt.Errorf("argument %q must be a date like 2024-01-31", name)
+	}
+	return date, nil
+}
+
+// statsObject is the GraphQL Stats type: the sessions a stats query
+// selected.
+type statsObject struct {
+	sessions []history.Session
+	attempts []history.Attempt
+}
+
+func (o statsObject) TypeName() string { return "Stats" }
+
+func (o statsObject) Field(name string, args graphql.Args) (interface{}, error) {
+	if name != "cells" {
+		if err := args.Allow(); err != nil {
+			return nil, err
+		}
+	}
+	switch name {
+	case "sessions":
+		return len(o.sessions), nil
+	case "questions":
+		return len(o.attempts), nil
+	case "correct":
+		return o.total().Correct, nil
+	case "accuracy":
+		return o.total().Accuracy(), nil
+	case "practiceSeconds":
+		var total time.Duration
+		for _, session := range o.sessions {
+			total += session.Ended.Sub(session.Started)
+		}
+		return int64(total.Seconds()), nil
+	case "byHandType":
+		byHandType, _ := stats.Tally(o.attempts)
+		return categories([]string{"hard", "soft", "pair"}, byHandType), nil
+	case "byDealerStrength":
+		_, byDealerStrength := stats.Tally(o.attempts)
+		return categories([]string{"weak", "medium", "strong"}, byDealerStrength), nil
+	case "byAction":
+		return categories(stats.ActionKeys, stats.TallyActions(o.attempts)), nil
+	case "byRules":
+		var list []category
+		for _, r := range stats.ByRules(o.attempts) {
+			list = append(list, category{r.Rules, r.CategoryData})
+		}
+		return list, nil
+	case "cells":
+		return o.cells(args)
+	case "days":
+		return o.days(), nil
+	}
+	return nil, graphql.ErrNoField
+}
+
+// total returns the accuracy over every selected answer.
+func (o statsObject) total() stats.CategoryData {
+	var data stats.CategoryData
+	for _, a := range o.attempts {
+		data.Total++
+		if a.Correct {
+			data.Correct++
+		}
+	}
+	return data
+}
+
+// cells returns the chart cells answered, filtered and ordered by the
+// arguments.
+func (o statsObject) cells(args graphql.Args) ([]cell, error) {
+	if err := args.Allow("handType", "dealerCard", "minAttempts", "orderBy", "limit"); err != nil {
+		return nil, err
+	}
+	handType, err := args.String("handType")
+	if err != nil {
+		return nil, err
+	}
+	dealerCard, err := args.Int("dealerCard", 0)
+	if err != nil {
+		return nil, err
+	}
+	minAttempts, err := args.Int("minAttempts", 1)
+	if err != nil {
+		return nil, err
+	}
+	limit, err := args.Int("limit", 0)
+	if err != nil {
+		return nil, err
+	}
+	orderBy, err := args.String("orderBy")
+	if err != nil {
+		return nil, err
+	}
+	if orderBy != "" && orderBy != "CHART" && orderBy != "ACCURACY" {
+		return nil, fmt.Errorf("argument \"orderBy\" must be CHART or ACCURACY")
+	}
+
+	var list []cell
+	for key, data := range stats.ByCell(o.attempts) {
+		if handType != "" && !strings.EqualFold(key.HandType.String(), handType) ||
+			dealerCard != 0 && key.DealerCard != dealerCard || data.Total < minAttempts {
+			continue
+		}
+		list = append(list, cell{key, *data})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if orderBy == "ACCURACY" && a.Accuracy() != b.Accuracy() {
+			return a.Accuracy() < b.Accuracy()
+		}
+		if orderBy == "ACCURACY" && a.Total != b.Total {
+			return a.Total > b.Total
+		}
+		if a.key.HandType != b.key.HandType {
+			return a.key.HandType < b.key.HandType
+		}
+		if a.key.PlayerTotal != b.key.PlayerTotal {
+			return a.key.PlayerTotal < b.key.PlayerTotal
+		}
+		return a.key.DealerCard < b.key.DealerCard
+	})
+	if limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+// days returns the accuracy on each date practiced, oldest first.
+func (o statsObject) days() []day {
+	var list []day
+	index := make(map[string]int)
+	for _, session := range o.sessions {
+		date := session.Started.Format(dateLayout)
+		i, ok := index[date]
+		if !ok {
+			i = len(list)
+			index[date] = i
+			list = append(list, day{date: date})
+		}
+		list[i].sessions++
+		list[i].Correct += session.Correct
+		list[i].Total += session.Total
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].date < list[j].date })
+	return list
+}
+
+// categories returns the tallies for keys, in order.
+func categories(keys []string, tallies map[string]*stats.CategoryData) []category {
+	list := make([]category, 0, len(keys))
+	for _, key := range keys {
+		list = append(list, category{key, *tallies[key]})
+	}
+	return list
+}
+
+// category is the GraphQL Category type.
+type category struct {
+	name string
+	stats.CategoryData
+}
+
+func (c category) TypeName() string { return "Category" }
+
+func (c category) Field(name string, args graphql.Args) (interface{}, error) {
+	if name == "name" {
+		return c.name, args.Allow()
+	}
+	return tallyField(c.CategoryData, name, args)
+}
+
+// cell is the GraphQL Cell type.
+type cell struct {
+	key stats.CellKey
+	stats.CategoryData
+}
+
+func (c cell) TypeName() string { return "Cell" }
+
+func (c cell) Field(name string, args graphql.Args) (interface{}, error) {
+	switch name {
+	case "label":
+		return c.key.Label(), args.Allow()
+	case "handType":
+		return c.key.HandType.String(), args.Allow()
+	case "playerTotal":
+		return c.key.PlayerTotal, args.Allow()
+	case "dealerCard":
+		return c.key.DealerCard, args.Allow()
+	}
+	return tallyField(c.CategoryData, name, args)
+}
+
+// day is the GraphQL Day type.
+type day struct {
+	date     string
+	sessions int
+	stats.CategoryData
+}
+
+func (d day) TypeName() string { return "Day" }
+
+func (d day) Field(name string, args graphql.Args) (interface{}, error) {
+	switch name {
+	case "date":
+		return d.date, args.Allow()
+	case "sessions":
+		return d.sessions, args.Allow()
+	}
+	return tallyField(d.CategoryData, name, args)
+}
+
+// tallyField resolves the correct, total and accuracy fields the tallied
+// types share.
+func tallyField(data stats.CategoryData, name string, args graphql.Args) (interface{}, error) {
+	var value interface{}
+	switch name {
+	case "correct":
+		value = data.Correct
+	case "total":
+		value = data.Total
+	case "accuracy":
+		value = data.Accuracy()
+	default:
+		return nil, graphql.ErrNoField
+	}
+	return value, args.Allow()
+}
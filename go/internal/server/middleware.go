@@ -0,0 +1,182 @@
+package server
+
+import (
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit limits how often each client may call the API.
+type RateLimit struct {
+	// PerMinute is the sustained number of requests allowed per client.
+	// Zero disables rate limiting.
+	PerMinute int
+	// Burst is how many requests a client may make at once before being
+	// limited. Zero means the same as PerMinute.
+	Burst int
+}
+
+// maxIdleClients is how many client buckets are kept before full (idle)
+// buckets are discarded.
+const maxIdleClients = 10000
+
+// rateLimiter is a token bucket per client address.
+type rateLimiter struct {
+	perSecond float64
+	burst     float64
+	now       func() time.Time
+
+	mu      sync.Mutex
+	clients map[string]*bucket
+}
+
+// bucket is one client's remaining allowance.
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// newRateLimiter creates a limiter, or returns nil if the limit is disabled.
+func newRateLimiter(limit RateLimit, now func() time.Time) *rateLimiter {
+	if limit.PerMinute <= 0 {
+		return nil
+	}
+	burst := limit.Burst
+	if burst <= 0 {
+		burst = limit.PerMinute
+	}
+	return &rateLimiter{
+		perSecond: float64(limit.PerMinute) / 60,
+		burst:     float64(burst),
+		now:       now,
+		clients:   make(map[string]*bucket),
+	}
+}
+
+// allow takes a token from the client's bucket. If none is left it returns
+// false and how long until the next token.
+func (l *rateLimiter) allow(client string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b, ok := l.clients[client]
+	if !ok {
+		if len(l.clients) >= maxIdleClients {
+			l.prune(now)
+		}
+		b = &bucket{tokens: l.burst, updated: now}
+		l.clients[client] = b
+	}
+
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.updated).Seconds()*l.perSecond)
+	b.updated = now
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.perSecond * float64(time.Second))
+		return false, wait
+	}
+	b.tokens--
+	return true, 0
+}
+
+// prune discards buckets that have refilled completely, since they behave
+// the same as a new bucket. The caller must hold l.mu.
+func (l *rateLimiter) prune(now time.Time) {
+	for client, b := range l.clients {
+		if b.tokens+now.Sub(b.updated).Seconds()*l.perSecond >= l.burst {
+			delete(l.clients, client)
+		}
+	}
+}
+
+// limit wraps a handler so clients over the rate limit get 429 Too Many Requests.
+func (s *Server) limit(next http.Handler) http.Handler {
+	if s.limiter == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ok, wait := s.limiter.allow(s.clientAddr(r))
+		if !ok {
+			seconds := int(math.Ceil(wait.Seconds()))
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			writeError(w, http.StatusTooManyRequests, "rate limit exceeded; retry in "+strconv.Itoa(seconds)+"s")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientAddr returns the address requests are attributed to: the last
+// X-Forwarded-For entry when behind a trusted proxy, otherwise the remote IP.
+// The proxy appends the address it saw to whatever the client sent, so only
+// the last entry can be trusted; earlier ones are the client's to forge.
+func (s *Server) clientAddr(r *http.Request) string {
+	if s.trustProxy {
+		if values := r.Header.Values("X-Forwarded-For"); len(values) > 0 {
+			forwarded := values[len(values)-1]
+			if i := strings.LastIndex(forwarded, ","); i >= 0 {
+				forwarded = forwarded[i+1:]
+			}
+			if last := strings.TrimSpace(forwarded); last != "" {
+				return last
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// statusRecorder captures the status and size of a response for logging.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	n, err := r.ResponseWriter.Write(b)
+	r.bytes += n
+	return n, err
+}
+
+// logRequests wraps a handler to log every request once it completes.
+func (s *Server) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started := s.now()
+		recorder := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+
+		status := recorder.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		}
+		s.logger.LogAttrs(r.Context(), level, "request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", status),
+			slog.Int("bytes", recorder.bytes),
+			slog.Duration("duration", s.now().Sub(started)),
+			slog.String("client", s.clientAddr(r)),
+		)
+	})
+}
@@ -0,0 +1,520 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// oauthStateTTL is how long a sign-in may take between leaving for the
+// provider and coming back.
+const oauthStateTTL = 10 * time.Minute
+
+// maxOAuthStates is the most sign-ins that may be pending at once. Starting
+// one needs no login, so without a limit anyone could fill the server's
+// memory with them.
+const maxOAuthStates = 1000
+
+// oauthTimeout bounds each request to a provider.
+const oauthTimeout = 10 * time.Second
+
+// OAuthProvider lets users sign in with an account elsewhere, through OAuth
+// 2.0 or OpenID Connect, instead of a password kept by the server.
+//
+// "google" and "github" need only the client ID and secret; their endpoints
+// are known. Any other OpenID Connect provider needs its Issuer, from which
+// the endpoints are discovered, or the endpoints themselves.
+type OAuthProvider struct {
+	// Name identifies the provider in URLs and in linked identities.
+	Name         string
+	ClientID     string
+	ClientSecret string
+	// Issuer is an OpenID Connect issuer URL whose discovery document gives
+	// the endpoints not set below.
+	Issuer      string
+	AuthURL     string
+	TokenURL    string
+	UserInfoURL string
+	// Scopes are requested at sign-in; the provider's defaults when empty.
+	Scopes []string
+	// AllowSignup creates a profile the first time an identity signs in.
+	// Otherwise (unless registration is open) the administrator links each
+	// identity to an account first.
+	AllowSignup bool
+	// Domains, if set, only admits identities with a verified email address
+	// in one of these domains, such as a school's.
+	Domains []string
+
+	// emailsURL lists a GitHub user's email addresses, which its user
+	// endpoint doesn't say are verified.
+	emailsURL string
+}
+
+// knownProviders holds the endpoints of the providers configured by name
+// alone.
+var knownProviders = map[string]OAuthProvider{
+	"google": {
+		AuthURL:     "https://accounts.google.com/o/oauth2/v2/auth",
+		TokenURL:    "https://oauth2.googleapis.com/token",
+		UserInfoURL: "https://openidconnect.googleapis.com/v1/userinfo",
+		Scopes:      []string{"openid", "email", "profile"},
+	},
+	"github": {
+		AuthURL:     "https://github.com/login/oauth/authorize",
+		TokenURL:    "https://github.com/login/oauth/access_token",
+		UserInfoURL: "https://api.github.com/user",
+		Scopes:      []string{"read:user", "user:email"},
+		emailsURL:   "https://api.github.com/user/emails",
+	},
+}
+
+// withDefaults fills in the endpoints of a known provider and checks the
+// configuration.
+func (p OAuthProvider) withDefaults() (OAuthProvider, error) {
+	if !validName.MatchString(p.Name) || strings.Contains(p.Name, ":") {
+		return p, fmt.Errorf("invalid sign-in provider name %q", p.Name)
+	}
+	if p.ClientID == "" {
+		return p, fmt.Errorf("sign-in provider %s: no client ID", p.Name)
+	}
+	if known, ok := knownProviders[p.Name]; ok {
+		if p.AuthURL == "" {
+			p.AuthURL = known.AuthURL
+		}
+		if p.TokenURL == "" {
+			p.TokenURL = known.TokenURL
+		}
+		if p.UserInfoURL == "" {
+			p.UserInfoURL = known.UserInfoURL
+			p.emailsURL = known.emailsURL
+		}
+		if len(p.Scopes) == 0 {
+			p.Scopes = known.Scopes
+		}
+	}
+	if p.Issuer == "" && (p.AuthURL == "" || p.TokenURL == "" || p.UserInfoURL == "") {
+		return p, fmt.Errorf("sign-in provider %s: set an issuer or the auth, token and user info URLs", p.Name)
+	}
+	if len(p.Scopes) == 0 {
+		p.Scopes = []string{"openid", "email", "profile"}
+	}
+	return p, nil
+}
+
+// discover fills in the endpoints not configured from the issuer's OpenID
+// Connect discovery document.
+func (p *OAuthProvider) discover() error {
+	if p.AuthURL != "" && p.TokenURL != "" && p.UserInfoURL != "" {
+		return nil
+	}
+	var doc struct {
+		AuthURL     string `json:"authorization_endpoint"`
+		TokenURL    string `json:"token_endpoint"`
+		UserInfoURL string `json:"userinfo_endpoint"`
+	}
+	wellKnown := strings.TrimSuffix(p.Issuer, "/") + "/.well-known/openid-configuration"
+	if err := getJSON(wellKnown, "", &doc); err != nil {
+		return fmt.Errorf("discovering %s endpoints: %w", p.Name, err)
+	}
+	if p.AuthURL == "" {
+		p.AuthURL = doc.AuthURL
+	}
+	if p.TokenURL == "" {
+		p.TokenURL = doc.TokenURL
+	}
+	if p.UserInfoURL == "" {
+		p.UserInfoURL = doc.UserInfoURL
+	}
+	if p.AuthURL == "" || p.TokenURL == "" || p.UserInfoURL == "" {
+		return fmt.Errorf("discovering %s endpoints: incomplete discovery document", p.Name)
+	}
+	return nil
+}
+
+// oauthState is a sign-in in progress, kept until the provider sends the
+// user back.
+type oauthState struct {
+	provider    string
+	verifier    string
+	redirectURI string
+	expires     time.Time
+}
+
+// oauthProviderList is the response listing the sign-in providers.
+type oauthProviderList struct {
+	Providers []oauthProviderLink `json:"providers"`
+}
+
+// oauthProviderLink is a sign-in provider and where to start signing in.
+type oauthProviderLink struct {
+	Name     string `json:"name"`
+	LoginURL string `json:"login_url"`
+}
+
+// handleOAuth serves /api/oauth, the list of providers, and
+// /api/oauth/{provider}/login and /api/oauth/{provider}/callback.
+func (s *Server) handleOAuth(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	rest := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/api/oauth"), "/")
+	if rest == "" {
+		list := oauthProviderList{Providers: []oauthProviderLink{}}
+		for _, p := range s.oauth {
+			list.Providers = append(list.Providers, oauthProviderLink{p.Name, "/api/oauth/" + p.Name + "/login"})
+		}
+		writeJSON(w, http.StatusOK, list)
+		return
+	}
+
+	name, step, _ := strings.Cut(rest, "/")
+	if step != "login" && step != "callback" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	provider, ok, err := s.oauthProvider(name)
+	switch {
+	case !ok:
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown sign-in provider %q", name))
+	case err != nil:
+		writeError(w, http.StatusBadGateway, err.Error())
+	case step == "login":
+		s.oauthLogin(w, r, &provider)
+	default:
+		s.oauthCallback(w, r, &provider)
+	}
+}
+
+// oauthProvider returns the named provider, discovering its endpoints on
+// first use.
+func (s *Server) oauthProvider(name string) (OAuthProvider, bool, error) {
+	s.oauthMu.Lock()
+	defer s.oauthMu.Unlock()
+	for i := range s.oauth {
+		if s.oauth[i].Name == name {
+			err := s.oauth[i].discover()
+			return s.oauth[i], true, err
+		}
+	}
+	return OAuthProvider{}, false, nil
+}
+
+// oauthLogin sends the user to the provider to sign in.
+func (s *Server) oauthLogin(w http.ResponseWriter, r *http.Request, p *OAuthProvider) {
+	state, err := newToken()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	// The verifier proves the code is redeemed by whoever asked for it
+	// (PKCE), in case the callback is intercepted
+	verifier, err := newToken()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+	redirectURI := s.publicURL(r) + "/api/oauth/" + p.Name + "/callback"
+
+	s.mu.Lock()
+	now := s.now()
+	for key, pending := range s.oauthStates {
+		if !now.Before(pending.expires) {
+			delete(s.oauthStates, key)
+		}
+	}
+	if len(s.oauthStates) >= maxOAuthStates {
+		s.mu.Unlock()
+		w.Header().Set("Retry-After", strconv.Itoa(int(oauthStateTTL.Seconds())))
+		writeError(w, http.StatusServiceUnavailable, "too many sign-ins in progress; try again later")
+		return
+	}
+	s.oauthStates[state] = oauthState{provider: p.Name, verifier: verifier, redirectURI: redirectURI, expires: now.Add(oauthStateTTL)}
+	s.mu.Unlock()
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.ClientID},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {strings.Join(p.Scopes, " ")},
+		"state":                 {state},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(p.AuthURL, "?") {
+		separator = "&"
+	}
+	http.Redirect(w, r, p.AuthURL+separator+query.Encode(), http.StatusFound)
+}
+
+// oauthCallback finishes signing in when the provider sends the user back:
+// it redeems the code, looks up the identity's profile, creating it if
+// allowed, and issues a login token.
+func (s *Server) oauthCallback(w http.ResponseWriter, r *http.Request, p *OAuthProvider) {
+	query := r.URL.Query()
+	s.mu.Lock()
+	state, ok := s.oauthStates[query.Get("state")]
+	delete(s.oauthStates, query.Get("state"))
+	now := s.now()
+	s.mu.Unlock()
+	if !ok || state.provider != p.Name || !now.Before(state.expires) {
+		writeError(w, http.StatusBadRequest, "unknown or expired sign-in; start again")
+		return
+	}
+	if e := query.Get("error"); e != "" {
+		writeError(w, http.StatusUnauthorized, "sign-in refused: "+strings.TrimSpace(e+" "+query.Get("error_description")))
+		return
+	}
+
+	accessToken, err := p.exchange(query.Get("code"), state)
+	if err != nil {
+		s.logger.Warn("sign-in failed", slog.String("provider", p.Name), slog.Any("error", err))
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	id, err := p.identify(accessToken)
+	if err != nil {
+		s.logger.Warn("sign-in failed", slog.String("provider", p.Name), slog.Any("error", err))
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	if !p.admits(id) {
+		writeError(w, http.StatusForbidden, "sign-in is limited to verified addresses at "+strings.Join(p.Domains, ", "))
+		return
+	}
+
+	identity := p.Name + ":" + id.subject
+	user, linked := s.users.Identity(identity)
+	if !linked {
+		if !p.AllowSignup && !s.openRegistration {
+			writeError(w, http.StatusForbidden, fmt.Sprintf("no account is linked to %s; ask the administrator to link it", identity))
+			return
+		}
+		if user, err = s.users.AddLinked(id.userName(p.Name), identity); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		s.logger.Info("signed up", slog.String("user", user), slog.String("identity", identity))
+	}
+
+	value, err := newToken()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	expires := s.now().Add(s.tokenTTL)
+	s.mu.Lock()
+	s.tokens[value] = token{user: user, expires: expires}
+	s.mu.Unlock()
+	s.logger.Info("login", slog.String("user", user), slog.String("provider", p.Name))
+
+	writeJSON(w, http.StatusOK, oauthLoginResult{Username: user, loginResult: loginResult{Token: value, Expires: expires}})
+}
+
+// oauthLoginResult is the response to signing in through a provider.
+type oauthLoginResult struct {
+	Username string `json:"username"`
+	loginResult
+}
+
+// exchange redeems an authorization code for an access token.
+func (p *OAuthProvider) exchange(code string, state oauthState) (string, error) {
+	if code == "" {
+		return "", errors.New("sign-in failed: no authorization code")
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {state.redirectURI},
+		"client_id":     {p.ClientID},
+		"client_secret": {p.ClientSecret},
+		"code_verifier": {state.verifier},
+	}
+	req, err := http.NewRequest(http.MethodPost, p.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		Error       string `json:"error"`
+		Description string `json:"error_description"`
+	}
+	if err := doJSON(req, &result); err != nil {
+		return "", fmt.Errorf("redeeming %s sign-in: %w", p.Name, err)
+	}
+	if result.AccessToken == "" {
+		return "", fmt.Errorf("redeeming %s sign-in: %s", p.Name, strings.TrimSpace(result.Error+" "+result.Description))
+	}
+	return result.AccessToken, nil
+}
+
+// externalIdentity is who the provider says signed in.
+type externalIdentity struct {
+	subject       string
+	name          string
+	email         string
+	emailVerified bool
+}
+
+// identify fetches the signed-in user's identity. OpenID Connect providers
+// give "sub"; GitHub gives a numeric "id" and "login".
+func (p *OAuthProvider) identify(accessToken string) (externalIdentity, error) {
+	var info struct {
+		Subject           string      `json:"sub"`
+		ID                interface{} `json:"id"`
+		PreferredUsername string      `json:"preferred_username"`
+		Login             string      `json:"login"`
+		Email             string      `json:"email"`
+		EmailVerified     interface{} `json:"email_verified"`
+	}
+	if err := getJSON(p.UserInfoURL, accessToken, &info); err != nil {
+		return externalIdentity{}, fmt.Errorf("reading %s identity: %w", p.Name, err)
+	}
+
+	id := externalIdentity{subject: info.Subject, name: info.PreferredUsername, email: info.Email}
+	switch v := info.ID.(type) {
+	case float64:
+		if id.subject == "" {
+			id.subject = strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	case string:
+		if id.subject == "" {
+			id.subject = v
+		}
+	}
+	if id.name == "" {
+		id.name = info.Login
+	}
+	// Some providers send email_verified as a string
+	switch v := info.EmailVerified.(type) {
+	case bool:
+		id.emailVerified = v
+	case string:
+		id.emailVerified, _ = strconv.ParseBool(v)
+	}
+	if id.subject == "" {
+		return id, fmt.Errorf("reading %s identity: no subject", p.Name)
+	}
+
+	if p.emailsURL != "" {
+		var emails []struct {
+			Email    string `json:"email"`
+			Primary  bool   `json:"primary"`
+			Verified bool   `json:"verified"`
+		}
+		if err := getJSON(p.emailsURL, accessToken, &emails); err != nil {
+			return id, fmt.Errorf("reading %s email addresses: %w", p.Name, err)
+		}
+		for _, e := range emails {
+			if e.Primary {
+				id.email, id.emailVerified = e.Email, e.Verified
+			}
+		}
+	}
+	return id, nil
+}
+
+// admits reports whether an identity may sign in under the provider's
+// domain restriction.
+func (p *OAuthProvider) admits(id externalIdentity) bool {
+	if len(p.Domains) == 0 {
+		return true
+	}
+	_, domain, ok := strings.Cut(id.email, "@")
+	if !ok || !id.emailVerified {
+		return false
+	}
+	for _, d := range p.Domains {
+		if strings.EqualFold(domain, d) {
+			return true
+		}
+	}
+	return false
+}
+
+// userName suggests a user name for a new profile: the identity's user
+// name, or the start of its email address, reduced to the characters user
+// names allow.
+func (id externalIdentity) userName(provider string) string {
+	name := id.name
+	if name == "" {
+		name, _, _ = strings.Cut(id.email, "@")
+	}
+	clean := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_', r == '-', r == '.':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		}
+		return '-'
+	}, name)
+	clean = strings.TrimLeft(clean, ".")
+	if len(clean) > 28 {
+		clean = clean[:28]
+	}
+	if clean == "" {
+		clean = provider + "-user"
+	}
+	return clean
+}
+
+// publicURL returns the server's address as users' browsers reach it, for
+// the provider to send them back to.
+func (s *Server) publicURL(r *http.Request) string {
+	if s.externalURL != "" {
+		return s.externalURL
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); s.trustProxy && proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host
+}
+
+// getJSON fetches a JSON document, with a bearer token if given.
+func getJSON(address, bearer string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, address, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	return doJSON(req, v)
+}
+
+// doJSON sends a request to a provider and decodes its JSON response.
+func doJSON(req *http.Request, v interface{}) error {
+	client := &http.Client{Timeout: oauthTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,230 @@
+package server
+
+import (
+	"blackjack_trainer/internal/graphql"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenAPIVersion is the version of the OpenAPI specification the API
+// document follows.
+const OpenAPIVersion = "3.0.3"
+
+// param is a query or path parameter of an endpoint.
+type param struct {
+	name, in, description string
+}
+
+// endpoint describes one API operation for the generated document. Request
+// and response bodies are given as example values whose types are described
+// by reflection, so the document stays in step with the handlers.
+type endpoint struct {
+	method, path, summary string
+	public                bool
+	params                []param
+	request               interface{}
+	status                int
+	response              interface{}
+}
+
+// endpoints lists every operation served by Handler.
+var endpoints = []endpoint{
+	{method: http.MethodPost, path: "/api/register", summary: "Create an account (when registration is open)",
+		public: true, request: credentials{}, status: http.StatusCreated, response: account{}},
+	{method: http.MethodPost, path: "/api/login", summary: "Exchange a username and password for a session token",
+		public: true, request: credentials{}, status: http.StatusOK, response: loginResult{}},
+	{method: http.MethodGet, path: "/api/oauth", summary: "List the providers users can sign in with",
+		public: true, status: http.StatusOK, response: oauthProviderList{}},
+	{method: http.MethodGet, path: "/api/oauth/{provider}/login", summary: "Start signing in with a provider (redirects to it)",
+		public: true, params: []param{{"provider", "path", "Provider name, e.g. google or github"}}, status: http.StatusFound},
+	{method: http.MethodGet, path: "/api/oauth/{provider}/callback", summary: "Finish signing in when the provider sends the user back",
+		public: true, params: []param{
+			{"provider", "path", "Provider name"},
+			{"code", "query", "Authorization code from the provider"},
+			{"state", "query", "State from the login redirect"},
+		},
+		status: http.StatusOK, response: oauthLoginResult{}},
+	{method: http.MethodPost, path: "/api/logout", summary: "Revoke the current session token",
+		status: http.StatusNoContent},
+	{method: http.MethodGet, path: "/api/lookup", summary: "Look up the correct play for a hand",
+		params: []param{
+			{"cards", "query", "Player cards, e.g. A,7 or 10,6"},
+			{"dealer", "query", "Dealer up-card: 2-10 or A"},
+		},
+		status: http.StatusOK, response: lookupResult{}},
+	{method: http.MethodGet, path: "/api/sessions", summary: "List your sessions in progress",
+		status: http.StatusOK, response: sessionList{}},
+	{method: http.MethodPost, path: "/api/sessions", summary: "Start a training session",
+		request: newSessionRequest{}, status: http.StatusCreated, response: sessionState{}},
+	{method: http.MethodGet, path: "/api/sessions/{id}", summary: "Get session progress and the current question",
+		params: []param{{"id", "path", "Session ID"}}, status: http.StatusOK, response: sessionState{}},
+	{method: http.MethodDelete, path: "/api/sessions/{id}", summary: "End a session, saving the answered questions",
+		params: []param{{"id", "path", "Session ID"}}, status: http.StatusOK, response: sessionState{}},
+	{method: http.MethodPost, path: "/api/sessions/{id}/answer", summary: "Answer the current question",
+		params:  []param{{"id", "path", "Session ID"}},
+		request: answerRequest{}, status: http.StatusOK, response: answerResult{}},
+	{method: http.MethodGet, path: "/api/stats", summary: "Get your lifetime statistics",
+		status: http.StatusOK, response: userStats{}},
+	{method: http.MethodGet, path: "/api/challenges/{day}", summary: "Rank every user's first try at a day's challenge",
+		params: []param{{"day", "path", "Date as YYYY-MM-DD, or today"}}, status: http.StatusOK, response: leaderboard{}},
+	{method: http.MethodPost, path: "/api/graphql", summary: "Query your statistics with GraphQL (GET without a query returns the schema)",
+		request: graphql.Request{}, status: http.StatusOK, response: graphql.Response{}},
+}
+
+// OpenAPI returns the OpenAPI document describing the server's API.
+func OpenAPI() map[string]interface{} {
+	schemas := make(map[string]interface{})
+	errorSchema := schemaFor(reflect.TypeOf(errorResponse{}), schemas)
+
+	paths := make(map[string]map[string]interface{})
+	for _, e := range endpoints {
+		responses := map[string]interface{}{
+			"default": jsonContent("Error", errorSchema),
+		}
+		if e.response == nil {
+			responses[strconv.Itoa(e.status)] = map[string]interface{}{"description": http.StatusText(e.status)}
+		} else {
+			responses[strconv.Itoa(e.status)] = jsonContent(http.StatusText(e.status),
+				schemaFor(reflect.TypeOf(e.response), schemas))
+		}
+
+		op := map[string]interface{}{
+			"summary":     e.summary,
+			"operationId": operationID(e),
+			"responses":   responses,
+		}
+		if e.public {
+			op["security"] = []interface{}{}
+		}
+		if len(e.params) > 0 {
+			var params []interface{}
+			for _, p := range e.params {
+				params = append(params, map[string]interface{}{
+					"name":        p.name,
+					"in":          p.in,
+					"description": p.description,
+					"required":    true,
+					"schema":      map[string]interface{}{"type": "string"},
+				})
+			}
+			op["parameters"] = params
+		}
+		if e.request != nil {
+			body := jsonContent("", schemaFor(reflect.TypeOf(e.request), schemas))
+			delete(body, "description")
+			body["required"] = true
+			op["requestBody"] = body
+		}
+
+		if paths[e.path] == nil {
+			paths[e.path] = make(map[string]interface{})
+		}
+		paths[e.path][strings.ToLower(e.method)] = op
+	}
+
+	return map[string]interface{}{
+		"openapi": OpenAPIVersion,
+		"info": map[string]interface{}{
+			"title":       "Blackjack Strategy Trainer API",
+			"description": "Practice blackjack basic strategy. Every user has their own sessions and statistics.",
+			"version":     "1.0",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer",
+					"description": "Session token from /api/login, or an API key"},
+				"basicAuth": map[string]interface{}{"type": "http", "scheme": "basic"},
+				"apiKey":    map[string]interface{}{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+			},
+		},
+		"security": []interface{}{
+			map[string]interface{}{"bearerAuth": []string{}},
+			map[string]interface{}{"basicAuth": []string{}},
+			map[string]interface{}{"apiKey": []string{}},
+		},
+	}
+}
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+	writeJSON(w, http.StatusOK, OpenAPI())
+}
+
+// schemaFor returns the JSON schema for a Go type. Struct types are added
+// to schemas under a name derived from the type and referenced by $ref.
+func schemaFor(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	if t.Kind() == reflect.Pointer {
+		return schemaFor(t.Elem(), schemas)
+	}
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": schemaFor(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaFor(t.Elem(), schemas)}
+	case reflect.Struct:
+		name := schemaName(t)
+		if _, done := schemas[name]; !done {
+			schemas[name] = nil // placeholder in case the type refers to itself
+			properties := make(map[string]interface{})
+			for i := 0; i < t.NumField(); i++ {
+				field := t.Field(i)
+				tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+				if tag == "" || tag == "-" {
+					continue
+				}
+				properties[tag] = schemaFor(field.Type, schemas)
+			}
+			schemas[name] = map[string]interface{}{"type": "object", "properties": properties}
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// schemaName capitalizes a type name for use as a schema name.
+func schemaName(t reflect.Type) string {
+	name := t.Name()
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// jsonContent returns a response or request body with a JSON schema.
+func jsonContent(description string, schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": schema},
+		},
+	}
+}
+
+// operationID derives an operation ID such as "postSessionsByIdAnswer".
+func operationID(e endpoint) string {
+	id := strings.ToLower(e.method)
+	for _, part := range strings.Split(strings.TrimPrefix(e.path, "/api/"), "/") {
+		if name, ok := strings.CutPrefix(part, "{"); ok {
+			part = "by" + strings.TrimSuffix(name, "}")
+		}
+		id += strings.ToUpper(part[:1]) + part[1:]
+	}
+	return id
+}
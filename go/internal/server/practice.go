@@ -0,0 +1,132 @@
+package server
+
+import (
+	"blackjack_trainer/internal/history"
+	"blackjack_trainer/internal/simulate"
+	"blackjack_trainer/internal/strategy"
+	"blackjack_trainer/internal/trainer"
+	"time"
+)
+
+// sessionModes lists the training modes available over the API. Modes that
+// ask the user to choose a focus during setup are only offered interactively.
+var sessionModes = map[string]func() trainer.TrainingSession{
+	"random":    func() trainer.TrainingSession { return trainer.NewRandomTrainingSession() },
+	"absolute":  func() trainer.TrainingSession { return trainer.NewAbsoluteTrainingSession() },
+	"realistic": func() trainer.TrainingSession { return trainer.NewRealisticTrainingSession() },
+	"exam":      func() trainer.TrainingSession { return trainer.NewExamTrainingSession() },
+}
+
+// practice is one user's training session in progress.
+type practice struct {
+	id       string
+	session  trainer.TrainingSession
+	started  time.Time
+	current  trainer.Scenario
+	asked    time.Time
+	correct  int
+	attempts []history.Attempt
+	// evLoss is the EV given up by the wrong answers so far.
+	evLoss float64
+}
+
+// question is a scenario as sent to clients.
+type question struct {
+	Number     int    `json:"number"`
+	Cards      []int  `json:"cards"`
+	DealerCard int    `json:"dealer_card"`
+	HandType   string `json:"hand_type"`
+	Total      int    `json:"total"`
+}
+
+// sessionState describes a session in progress.
+type sessionState struct {
+	ID           string    `json:"id"`
+	Mode         string    `json:"mode"`
+	Started      time.Time `json:"started"`
+	Correct      int       `json:"correct"`
+	Total        int       `json:"total"`
+	MaxQuestions int       `json:"max_questions"`
+	Question     *question `json:"question,omitempty"`
+}
+
+// newPractice starts a session and deals its first question.
+func newPractice(id string, session trainer.TrainingSession, now time.Time) *practice {
+	p := &practice{id: id, session: session, started: now}
+	p.deal(now)
+	return p
+}
+
+// deal generates the next question.
+func (p *practice) deal(now time.Time) {
+	p.current = p.session.GenerateScenario()
+	p.asked = now
+}
+
+// done reports whether every question of the session has been answered.
+func (p *practice) done() bool {
+	return len(p.attempts) >= p.session.GetMaxQuestions()
+}
+
+// question returns the current question, or nil once the session is done.
+func (p *practice) question() *question {
+	if p.done() {
+		return nil
+	}
+	handType, total := strategy.Classify(p.current.Hand)
+	return &question{
+		Number:     len(p.attempts) + 1,
+		Cards:      p.current.Hand.Cards,
+		DealerCard: p.current.DealerCard,
+		HandType:   handType.String(),
+		Total:      total,
+	}
+}
+
+// state returns the session's progress and current question.
+func (p *practice) state() sessionState {
+	return sessionState{
+		ID:           p.id,
+		Mode:         p.session.GetModeName(),
+		Started:      p.started,
+		Correct:      p.correct,
+		Total:        len(p.attempts),
+		MaxQuestions: p.session.GetMaxQuestions(),
+		Question:     p.question(),
+	}
+}
+
+// answer records the user's action for the current question, deals the
+// next one unless the session is done, and returns the recorded attempt.
+// Wrong answers are priced with the loss table.
+func (p *practice) answer(action rune, chart *strategy.StrategyChart, losses *simulate.LossTable, now time.Time) history.Attempt {
+	scenario := p.current
+	correctAction := chart.GetCorrectActionForHand(scenario.Hand, scenario.DealerCard)
+	attempt := trainer.NewAttempt(chart, losses, scenario, action, correctAction, now.Sub(p.asked))
+	p.attempts = append(p.attempts, attempt)
+	if attempt.Correct {
+		p.correct++
+	}
+	p.evLoss += attempt.EVLoss
+
+	if !p.done() {
+		p.deal(now)
+	}
+	return attempt
+}
+
+// record returns the session as saved to the user's history, played under
+// the named rules.
+func (p *practice) record(rules string, now time.Time) history.Session {
+	evLoss := p.evLoss
+	return history.Session{
+		Mode:     p.session.GetModeName(),
+		Rules:    rules,
+		Started:  p.started,
+		Ended:    now,
+		Correct:  p.correct,
+		Total:    len(p.attempts),
+		Attempts: p.attempts,
+		EVLoss:   &evLoss,
+	}
+}
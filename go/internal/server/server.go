@@ -0,0 +1,874 @@
+// Package server serves the trainer over HTTP so one deployment can serve a
+// whole class.
+//
+// Each user has an account in a simple user store, logs in to obtain a
+// session token, and then practices with their own training sessions and
+// statistics, isolated from every other user. Finished sessions are saved to
+// a per-user history file in the server's data directory:
+//
+//	users.json             user accounts with hashed passwords
+//	history/<name>.json    each user's practice history
+//
+// Requests and responses are JSON. Authenticated endpoints accept any of:
+//   - a login token, as "Authorization: Bearer <token>"
+//   - an API key from the config, as a bearer token or "X-API-Key: <key>"
+//   - HTTP basic authentication with a user's name and password
+package server
+
+import (
+	"blackjack_trainer/internal/challenge"
+	"blackjack_trainer/internal/csvimport"
+	"blackjack_trainer/internal/hand"
+	"blackjack_trainer/internal/history"
+	"blackjack_trainer/internal/rng"
+	"blackjack_trainer/internal/simulate"
+	"blackjack_trainer/internal/strategy"
+	"blackjack_trainer/internal/trainer"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTokenTTL is how long a login token stays valid by default.
+const DefaultTokenTTL = 12 * time.Hour
+
+// DefaultSessionTimeout is how long a training session may go unanswered
+// before it is ended by default.
+const DefaultSessionTimeout = 30 * time.Minute
+
+// shutdownTimeout bounds how long Serve waits for requests in progress
+// when its context is cancelled.
+const shutdownTimeout = 10 * time.Second
+
+// maxBodySize limits the size of request bodies.
+const maxBodySize = 1 << 20
+
+// Options configures a server.
+type Options struct {
+	// DataDir holds the user store and per-user histories.
+	DataDir string
+	// OpenRegistration lets anyone create an account through the API.
+	// Otherwise accounts are created by the administrator.
+	OpenRegistration bool
+	// TokenTTL is how long a login token stays valid. Zero means DefaultTokenTTL.
+	TokenTTL time.Duration
+	// SessionTimeout ends training sessions left unanswered this long,
+	// saving the questions answered so far. Zero means DefaultSessionTimeout.
+	SessionTimeout time.Duration
+	// APIKeys maps API keys to the user name each key acts as.
+	APIKeys map[string]string
+	// RateLimit limits requests per client address.
+	RateLimit RateLimit
+	// TrustProxy attributes requests to the client the reverse proxy
+	// appended to the X-Forwarded-For header, for servers behind one.
+	TrustProxy bool
+	// Logger receives a record of every request. Nil discards them.
+	Logger *slog.Logger
+	// Chart is the strategy chart answers are checked against. Nil means
+	// the shared chart for the standard rules, strategy.Default().
+	Chart *strategy.StrategyChart
+	// Webhooks are notified when users finish sessions and exams.
+	Webhooks []Webhook
+	// OAuth lists the providers users may sign in with instead of a
+	// password.
+	OAuth []OAuthProvider
+	// PublicURL is the server's address as users' browsers reach it, e.g.
+	// "https://blackjack.example.edu", for providers to send users back to
+	// after signing in. Empty means the address each request was made to.
+	PublicURL string
+}
+
+// Server is the HTTP training server.
+type Server struct {
+	users            *UserStore
+	dataDir          string
+	openRegistration bool
+	tokenTTL         time.Duration
+	sessionTimeout   time.Duration
+	apiKeys          map[string]string
+	limiter          *rateLimiter
+	trustProxy       bool
+	logger           *slog.Logger
+	chart            *strategy.StrategyChart
+	losses           *simulate.LossTable
+	webhooks         []Webhook
+	externalURL      string
+	now              func() time.Time
+
+	// oauth holds the sign-in providers; oauthMu guards discovering their
+	// endpoints.
+	oauth   []OAuthProvider
+	oauthMu sync.Mutex
+
+	// deliveries counts the webhook deliveries in progress.
+	deliveries sync.WaitGroup
+
+	mu          sync.Mutex
+	tokens      map[string]token
+	verified    map[[sha256.Size]byte]time.Time
+	students    map[string]*student
+	oauthStates map[string]oauthState
+}
+
+// token is an issued login token.
+type token struct {
+	user    string
+	expires time.Time
+}
+
+// student is the state kept for one logged-in user.
+type student struct {
+	user     string
+	history  *history.History
+	sessions map[string]*practice
+	nextID   int
+}
+
+// New creates a server using the data directory in opts.
+func New(opts Options) (*Server, error) {
+	if opts.DataDir == "" {
+		return nil, errors.New("no data directory")
+	}
+	users, err := OpenUsers(filepath.Join(opts.DataDir, UsersFileName))
+	if err != nil {
+		return nil, err
+	}
+	ttl := opts.TokenTTL
+	if ttl == 0 {
+		ttl = DefaultTokenTTL
+	}
+	sessionTimeout := opts.SessionTimeout
+	if sessionTimeout == 0 {
+		sessionTimeout = DefaultSessionTimeout
+	}
+	for key, user := range opts.APIKeys {
+		if key == "" {
+			return nil, fmt.Errorf("empty API key for user %q", user)
+		}
+		if !validName.MatchString(user) {
+			return nil, fmt.Errorf("invalid user name %q for API key", user)
+		}
+	}
+	for _, hook := range opts.Webhooks {
+		if err := hook.check(); err != nil {
+			return nil, err
+		}
+	}
+	var providers []OAuthProvider
+	for _, p := range opts.OAuth {
+		p, err := p.withDefaults()
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, p)
+	}
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+
+	chart := opts.Chart
+	if chart == nil {
+		chart = strategy.Default()
+	}
+
+	s := &Server{
+		users:            users,
+		dataDir:          opts.DataDir,
+		openRegistration: opts.OpenRegistration,
+		tokenTTL:         ttl,
+		sessionTimeout:   sessionTimeout,
+		apiKeys:          opts.APIKeys,
+		trustProxy:       opts.TrustProxy,
+		logger:           logger,
+		chart:            chart,
+		losses:           simulate.NewLossTable(chart, 0),
+		webhooks:         opts.Webhooks,
+		externalURL:      strings.TrimSuffix(opts.PublicURL, "/"),
+		oauth:            providers,
+		now:              time.Now,
+		tokens:           make(map[string]token),
+		verified:         make(map[[sha256.Size]byte]time.Time),
+		students:         make(map[string]*student),
+		oauthStates:      make(map[string]oauthState),
+	}
+	s.limiter = newRateLimiter(opts.RateLimit, func() time.Time { return s.now() })
+	return s, nil
+}
+
+// Serve accepts connections on the listener until ctx is cancelled, then
+// stops accepting requests, waits briefly for those in progress, and ends
+// every training session, saving the questions answered so far, and waits
+// for the webhooks to be notified. Request contexts derive from ctx. Idle
+// sessions are ended while serving.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	srv := &http.Server{
+		Handler:     s.Handler(),
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+
+	stopped := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(s.sessionTimeout / 4)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.expireSessions(s.now())
+			case <-stopped:
+				return
+			case <-ctx.Done():
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+				defer cancel()
+				if err := srv.Shutdown(shutdownCtx); err != nil {
+					s.logger.Warn("shutdown", slog.Any("error", err))
+				}
+				return
+			}
+		}
+	}()
+
+	err := srv.Serve(listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		err = nil
+	} else {
+		close(stopped)
+	}
+	<-done
+	s.endSessions(func(*practice) bool { return true })
+	s.deliveries.Wait()
+	return err
+}
+
+// expireSessions ends sessions that have been idle longer than the session
+// timeout as of now.
+func (s *Server) expireSessions(now time.Time) {
+	cutoff := now.Add(-s.sessionTimeout)
+	s.endSessions(func(p *practice) bool { return p.asked.Before(cutoff) })
+}
+
+// endSessions ends every session for which the predicate is true, saving
+// the questions answered so far.
+func (s *Server) endSessions(match func(*practice) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for user, st := range s.students {
+		for _, p := range st.sessions {
+			if !match(p) {
+				continue
+			}
+			if err := s.finish(st, p); err != nil {
+				s.logger.Error("saving session", slog.String("user", user), slog.Any("error", err))
+			}
+		}
+	}
+}
+
+// Users returns the server's user store.
+func (s *Server) Users() *UserStore {
+	return s.users
+}
+
+// Handler returns the HTTP handler for the API, with request logging and
+// rate limiting applied.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/openapi.json", s.handleOpenAPI)
+	mux.HandleFunc("/api/register", s.handleRegister)
+	mux.HandleFunc("/api/login", s.handleLogin)
+	mux.HandleFunc("/api/oauth", s.handleOAuth)
+	mux.HandleFunc("/api/oauth/", s.handleOAuth)
+	mux.HandleFunc("/api/logout", s.authenticated(s.handleLogout))
+	mux.HandleFunc("/api/lookup", s.authenticated(s.handleLookup))
+	mux.HandleFunc("/api/sessions", s.authenticated(s.handleSessions))
+	mux.HandleFunc("/api/sessions/", s.authenticated(s.handleSession))
+	mux.HandleFunc("/api/stats", s.authenticated(s.handleStats))
+	mux.HandleFunc("/api/challenges/", s.authenticated(s.handleChallenge))
+	mux.HandleFunc("/api/graphql", s.authenticated(s.handleGraphQL))
+	return s.logRequests(s.limit(mux))
+}
+
+// userHandler handles a request from an authenticated user.
+type userHandler func(w http.ResponseWriter, r *http.Request, user string)
+
+// authenticated wraps a handler so it only runs for a known user.
+func (s *Server) authenticated(next userHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := s.user(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Basic realm="blackjack_trainer"`)
+			writeError(w, http.StatusUnauthorized, "missing or invalid credentials")
+			return
+		}
+		next(w, r, user)
+	}
+}
+
+// user returns the user a request is authenticated as, from a login token,
+// an API key, or basic authentication.
+func (s *Server) user(r *http.Request) (string, bool) {
+	if name, password, ok := r.BasicAuth(); ok {
+		return name, s.basicAuth(name, password)
+	}
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return s.apiKeyUser(key)
+	}
+
+	value, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return "", false
+	}
+	if user, ok := s.apiKeyUser(value); ok {
+		return user, true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, found := s.tokens[value]
+	if found && !s.now().Before(t.expires) {
+		delete(s.tokens, value)
+		found = false
+	}
+	return t.user, found
+}
+
+// basicAuthTTL is how long a name and password that passed basic
+// authentication are remembered. Checking a password is deliberately slow,
+// and basic authentication sends it with every request.
+const basicAuthTTL = time.Minute
+
+// basicAuth reports whether the name and password match a user. A match is
+// remembered for basicAuthTTL, keyed by a hash of both, so a client's later
+// requests skip the slow password check. Failures are never remembered.
+func (s *Server) basicAuth(name, password string) bool {
+	key := sha256.Sum256([]byte(name + "\x00" + password))
+	s.mu.Lock()
+	expires, found := s.verified[key]
+	s.mu.Unlock()
+	if found && s.now().Before(expires) {
+		return true
+	}
+	if !s.users.Authenticate(name, password) {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	for k, expires := range s.verified {
+		if !now.Before(expires) {
+			delete(s.verified, k)
+		}
+	}
+	s.verified[key] = now.Add(basicAuthTTL)
+	return true
+}
+
+// apiKeyUser returns the user an API key acts as. Every key is compared in
+// constant time so response timing does not reveal partial matches.
+func (s *Server) apiKeyUser(key string) (string, bool) {
+	var user string
+	found := false
+	for k, u := range s.apiKeys {
+		if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+			user, found = u, true
+		}
+	}
+	return user, found
+}
+
+// credentials is the body of register and login requests.
+type credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// account is the response to a successful registration.
+type account struct {
+	Username string `json:"username"`
+}
+
+// loginResult is the response to a successful login.
+type loginResult struct {
+	Token   string    `json:"token"`
+	Expires time.Time `json:"expires"`
+}
+
+func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+	if !s.openRegistration {
+		writeError(w, http.StatusForbidden, "registration is closed; ask the administrator for an account")
+		return
+	}
+
+	var c credentials
+	if !readJSON(w, r, &c) {
+		return
+	}
+	err := s.users.Add(c.Username, c.Password)
+	if errors.Is(err, ErrUserExists) {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, account{Username: c.Username})
+}
+
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+
+	var c credentials
+	if !readJSON(w, r, &c) {
+		return
+	}
+	if !s.users.Authenticate(c.Username, c.Password) {
+		s.logger.Warn("login failed", slog.String("user", c.Username), slog.String("client", s.clientAddr(r)))
+		writeError(w, http.StatusUnauthorized, "invalid username or password")
+		return
+	}
+
+	value, err := newToken()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	expires := s.now().Add(s.tokenTTL)
+
+	s.mu.Lock()
+	s.tokens[value] = token{user: c.Username, expires: expires}
+	s.mu.Unlock()
+	s.logger.Info("login", slog.String("user", c.Username))
+
+	writeJSON(w, http.StatusOK, loginResult{Token: value, Expires: expires})
+}
+
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request, user string) {
+	if !allowMethods(w, r, http.MethodPost) {
+		return
+	}
+	value := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	s.mu.Lock()
+	delete(s.tokens, value)
+	s.mu.Unlock()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// lookupResult is the correct play for a hand.
+type lookupResult struct {
+	Cards       []int  `json:"cards"`
+	DealerCard  int    `json:"dealer_card"`
+	HandType    string `json:"hand_type"`
+	Total       int    `json:"total"`
+	Action      string `json:"action"`
+	ActionName  string `json:"action_name"`
+	Explanation string `json:"explanation"`
+}
+
+func (s *Server) handleLookup(w http.ResponseWriter, r *http.Request, user string) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+
+	h, err := hand.Parse(r.URL.Query().Get("cards"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	dealerCard, err := hand.ParseCard(r.URL.Query().Get("dealer"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "dealer: "+err.Error())
+		return
+	}
+
+	handType, total := strategy.Classify(h)
+	action := s.chart.GetCorrectActionForHand(h, dealerCard)
+	writeJSON(w, http.StatusOK, lookupResult{
+		Cards:       h.Cards,
+		DealerCard:  dealerCard,
+		HandType:    handType.String(),
+		Total:       total,
+		Action:      string(action),
+		ActionName:  strategy.ActionToString(action),
+		Explanation: s.chart.GetExplanationForHand(h, dealerCard),
+	})
+}
+
+// sessionList is the user's sessions in progress.
+type sessionList struct {
+	Sessions []sessionState `json:"sessions"`
+}
+
+// newSessionRequest is the body of a request to start a session.
+type newSessionRequest struct {
+	// Mode is a training mode, or "challenge" for today's daily challenge,
+	// which each user can play once.
+	Mode string `json:"mode"`
+	// Constraints is a spec for a focused drill, e.g. "action=double
+	// dealer=2-6" (see trainer.ParseConstraints). It implies the
+	// constrained mode.
+	Constraints string `json:"constraints,omitempty"`
+}
+
+// handleSessions lists the user's sessions in progress or starts a new one.
+func (s *Server) handleSessions(w http.ResponseWriter, r *http.Request, user string) {
+	if !allowMethods(w, r, http.MethodGet, http.MethodPost) {
+		return
+	}
+
+	if r.Method == http.MethodGet {
+		s.mu.Lock()
+		st, err := s.student(user)
+		var states []sessionState
+		if err == nil {
+			for _, p := range st.sessions {
+				states = append(states, p.state())
+			}
+		}
+		s.mu.Unlock()
+
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		sort.Slice(states, func(i, j int) bool { return states[i].Started.Before(states[j].Started) })
+		writeJSON(w, http.StatusOK, sessionList{Sessions: states})
+		return
+	}
+
+	var req newSessionRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	var session trainer.TrainingSession
+	if req.Constraints != "" {
+		if req.Mode != "" && req.Mode != "constrained" {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("constraints can't be combined with mode %q", req.Mode))
+			return
+		}
+		c, err := trainer.ParseConstraints(req.Constraints)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		c.Chart = s.chart
+		constrained, err := trainer.NewConstrainedSession(c)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		session = constrained
+	} else if req.Mode == challenge.Mode {
+		// Everyone is asked the same questions, so they come from the
+		// day's seed rather than a secure source
+		session = challenge.NewSession(challenge.Day(s.now()))
+	} else {
+		if req.Mode == "" {
+			req.Mode = "random"
+		}
+		newSession, ok := sessionModes[req.Mode]
+		if !ok {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown mode %q (valid: %s)", req.Mode, modeNames()))
+			return
+		}
+		session = newSession()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, err := s.student(user)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if c, ok := session.(*challenge.Session); ok {
+		if _, played := challenge.Find(st.history, c.Day()); played {
+			writeError(w, http.StatusConflict, "today's challenge has been played; a new one starts tomorrow")
+			return
+		}
+		// A second try open at once could be played after the first showed
+		// the answers, so only one try may be in progress
+		for _, other := range st.sessions {
+			if o, ok := other.session.(*challenge.Session); ok && o.Day() == c.Day() {
+				writeError(w, http.StatusConflict, "today's challenge is already in progress in session "+other.id)
+				return
+			}
+		}
+	}
+	st.nextID++
+	// Many players share a server, so questions come from a secure source
+	// that can't be predicted from the ones other sessions were asked
+	trainer.SetSource(session, rng.Secure())
+	p := newPractice(strconv.Itoa(st.nextID), session, s.now())
+	st.sessions[p.id] = p
+	s.logger.Debug("session started", slog.String("user", user), slog.String("session", p.id), slog.String("mode", req.Mode))
+	writeJSON(w, http.StatusCreated, p.state())
+}
+
+// handleSession serves /api/sessions/{id} and /api/sessions/{id}/answer.
+func (s *Server) handleSession(w http.ResponseWriter, r *http.Request, user string) {
+	id, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/sessions/"), "/")
+	switch action {
+	case "":
+		if !allowMethods(w, r, http.MethodGet, http.MethodDelete) {
+			return
+		}
+	case "answer":
+		if !allowMethods(w, r, http.MethodPost) {
+			return
+		}
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	var answer answerRequest
+	var userAction rune
+	if action == "answer" {
+		if !readJSON(w, r, &answer) {
+			return
+		}
+		var err error
+		if userAction, err = csvimport.ParseAction(answer.Action); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.Context().Err() != nil {
+		// The client went away while waiting; leave the session unchanged
+		// so a retried answer is not counted twice.
+		return
+	}
+	st, err := s.student(user)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	p, ok := st.sessions[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "no session "+id)
+		return
+	}
+
+	switch {
+	case action == "answer":
+		s.answer(w, st, p, userAction)
+	case r.Method == http.MethodDelete:
+		if err := s.finish(st, p); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, p.state())
+	default:
+		writeJSON(w, http.StatusOK, p.state())
+	}
+}
+
+// answerRequest is the body of an answer.
+type answerRequest struct {
+	Action string `json:"action"`
+}
+
+// answerResult is the feedback for an answered question.
+type answerResult struct {
+	Correct       bool          `json:"correct"`
+	Action        string        `json:"action"`
+	CorrectAction string        `json:"correct_action"`
+	Explanation   string        `json:"explanation"`
+	Session       *sessionState `json:"session"`
+}
+
+// answer scores an answer and finishes the session after its last question.
+// The caller must hold s.mu.
+func (s *Server) answer(w http.ResponseWriter, st *student, p *practice, action rune) {
+	scenario := p.current
+	attempt := p.answer(action, s.chart, s.losses, s.now())
+	if p.done() {
+		if err := s.finish(st, p); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	state := p.state()
+	writeJSON(w, http.StatusOK, answerResult{
+		Correct:       attempt.Correct,
+		Action:        attempt.Action,
+		CorrectAction: attempt.CorrectAction,
+		Explanation:   s.chart.GetExplanationForHand(scenario.Hand, scenario.DealerCard),
+		Session:       &state,
+	})
+}
+
+// finish ends a session and, if any question was answered, saves it to the
+// user's history and notifies the webhooks. The caller must hold s.mu.
+func (s *Server) finish(st *student, p *practice) error {
+	delete(st.sessions, p.id)
+	s.logger.Debug("session ended", slog.String("path", st.history.Path()), slog.String("session", p.id),
+		slog.String("mode", p.session.GetModeName()), slog.Int("correct", p.correct), slog.Int("total", len(p.attempts)))
+	if len(p.attempts) == 0 {
+		return nil
+	}
+	record := p.record(s.chart.Rules().Name, s.now())
+	st.history.Add(record)
+	s.notify(st.user, p, record)
+	return st.history.Save()
+}
+
+// categoryStats is the accuracy for one hand type.
+type categoryStats struct {
+	Correct  int     `json:"correct"`
+	Total    int     `json:"total"`
+	Accuracy float64 `json:"accuracy"`
+}
+
+// userStats summarizes a user's practice history.
+type userStats struct {
+	Username        string                   `json:"username"`
+	Sessions        int                      `json:"sessions"`
+	Questions       int                      `json:"questions"`
+	Accuracy        float64                  `json:"accuracy"`
+	PracticeSeconds int64                    `json:"practice_seconds"`
+	DayStreak       int                      `json:"day_streak"`
+	ByHandType      map[string]categoryStats `json:"by_hand_type"`
+}
+
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request, user string) {
+	if !allowMethods(w, r, http.MethodGet) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, err := s.student(user)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h := st.history
+	accuracy, questions := h.Accuracy()
+	result := userStats{
+		Username:        user,
+		Sessions:        len(h.Sessions),
+		Questions:       questions,
+		Accuracy:        accuracy,
+		PracticeSeconds: int64(h.TotalDuration().Seconds()),
+		DayStreak:       h.DayStreak(s.now()),
+		ByHandType:      make(map[string]categoryStats),
+	}
+	for _, attempt := range h.Attempts() {
+		c := result.ByHandType[attempt.HandType]
+		c.Total++
+		if attempt.Correct {
+			c.Correct++
+		}
+		c.Accuracy = float64(c.Correct) / float64(c.Total) * 100.0
+		result.ByHandType[attempt.HandType] = c
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// student returns the state for a user, loading their history on first use.
+// The caller must hold s.mu.
+func (s *Server) student(user string) (*student, error) {
+	if st, ok := s.students[user]; ok {
+		return st, nil
+	}
+	h, err := history.Open(filepath.Join(s.dataDir, "history", user+".json"))
+	if err != nil {
+		return nil, err
+	}
+	st := &student{user: user, history: h, sessions: make(map[string]*practice)}
+	s.students[user] = st
+	return st, nil
+}
+
+// newToken returns a random login token.
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// modeNames lists the available session modes.
+func modeNames() string {
+	names := make([]string, 0, len(sessionModes))
+	for name := range sessionModes {
+		names = append(names, name)
+	}
+	names = append(names, challenge.Mode)
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// allowMethods reports whether the request uses one of the methods, and
+// otherwise responds with 405 Method Not Allowed.
+func allowMethods(w http.ResponseWriter, r *http.Request, methods ...string) bool {
+	for _, method := range methods {
+		if r.Method == method {
+			return true
+		}
+	}
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	return false
+}
+
+// readJSON decodes the request body into v, responding with 400 Bad Request
+// if it is not valid JSON.
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err := decoder.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// writeJSON writes v as the JSON response body.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// errorResponse is the body of every error response.
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// writeError writes an error response of the form {"error": "..."}.
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, errorResponse{Error: message})
+}
@@ -0,0 +1,949 @@
+package server
+
+import (
+	"blackjack_trainer/internal/challenge"
+	"blackjack_trainer/internal/hand"
+	"blackjack_trainer/internal/history"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestServer starts a server with open registration in a temporary directory.
+func newTestServer(t *testing.T) (*Server, *httptest.Server) {
+	t.Helper()
+	s, err := New(Options{DataDir: t.TempDir(), OpenRegistration: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(ts.Close)
+	return s, ts
+}
+
+// call sends a JSON request and decodes the JSON response into out.
+func call(t *testing.T, ts *httptest.Server, method, path, token string, body, out interface{}) int {
+	t.Helper()
+	var data []byte
+	if body != nil {
+		data, _ = json.Marshal(body)
+	}
+	req, _ := http.NewRequest(method, ts.URL+path, bytes.NewReader(data))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		json.NewDecoder(resp.Body).Decode(out)
+	}
+	return resp.StatusCode
+}
+
+// login registers a user and returns a login token.
+func login(t *testing.T, ts *httptest.Server, name string) string {
+	t.Helper()
+	creds := credentials{Username: name, Password: name + "-password"}
+	if status := call(t, ts, "POST", "/api/register", "", creds, nil); status != http.StatusCreated {
+		t.Fatalf("Register %s: status %d", name, status)
+	}
+	var result struct{ Token string }
+	if status := call(t, ts, "POST", "/api/login", "", creds, &result); status != http.StatusOK || result.Token == "" {
+		t.Fatalf("Login %s: status %d", name, status)
+	}
+	return result.Token
+}
+
+// Test the user store hashes passwords and persists accounts
+func TestUserStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), UsersFileName)
+	users, err := OpenUsers(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := users.Add("alice", "wonderland"); err != nil {
+		t.Fatal(err)
+	}
+	if err := users.Add("alice", "again"); err != ErrUserExists {
+		t.Errorf("Expected ErrUserExists, got %v", err)
+	}
+	for _, name := range []string{"", "../evil", ".hidden", "a b"} {
+		if err := users.Add(name, "password"); err == nil {
+			t.Errorf("Name %q should be rejected", name)
+		}
+	}
+
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "wonderland") {
+		t.Error("User store should not contain plaintext passwords")
+	}
+
+	reopened, err := OpenUsers(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reopened.Authenticate("alice", "wonderland") || reopened.Authenticate("alice", "wrong") {
+		t.Error("Reopened store should authenticate only the right password")
+	}
+	if reopened.Authenticate("bob", "wonderland") {
+		t.Error("Unknown users should not authenticate")
+	}
+}
+
+// Test endpoints require a valid token and login rejects bad passwords
+func TestAuthentication(t *testing.T) {
+	s, ts := newTestServer(t)
+	token := login(t, ts, "alice")
+
+	if status := call(t, ts, "GET", "/api/stats", "", nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("Missing token: expected 401, got %d", status)
+	}
+	if status := call(t, ts, "GET", "/api/stats", "bogus", nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("Unknown token: expected 401, got %d", status)
+	}
+	bad := credentials{Username: "alice", Password: "wrong"}
+	if status := call(t, ts, "POST", "/api/login", "", bad, nil); status != http.StatusUnauthorized {
+		t.Errorf("Wrong password: expected 401, got %d", status)
+	}
+
+	if status := call(t, ts, "POST", "/api/logout", token, nil, nil); status != http.StatusNoContent {
+		t.Errorf("Logout: expected 204, got %d", status)
+	}
+	if status := call(t, ts, "GET", "/api/stats", token, nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("Token should be invalid after logout, got %d", status)
+	}
+
+	// Tokens expire
+	token = login(t, ts, "bob")
+	s.now = func() time.Time { return time.Now().Add(DefaultTokenTTL) }
+	if status := call(t, ts, "GET", "/api/stats", token, nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("Expired token: expected 401, got %d", status)
+	}
+}
+
+// Test registration can be closed so only the administrator adds users
+func TestClosedRegistration(t *testing.T) {
+	s, err := New(Options{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	creds := credentials{Username: "alice", Password: "wonderland"}
+	if status := call(t, ts, "POST", "/api/register", "", creds, nil); status != http.StatusForbidden {
+		t.Errorf("Expected 403, got %d", status)
+	}
+	if err := s.Users().Add("alice", "wonderland"); err != nil {
+		t.Fatal(err)
+	}
+	if status := call(t, ts, "POST", "/api/login", "", creds, nil); status != http.StatusOK {
+		t.Errorf("Administrator-created user should log in, got %d", status)
+	}
+}
+
+// Test lookup returns the chart's play for a hand
+func TestLookup(t *testing.T) {
+	_, ts := newTestServer(t)
+	token := login(t, ts, "alice")
+
+	var result lookupResult
+	if status := call(t, ts, "GET", "/api/lookup?cards=10,6&dealer=10", token, nil, &result); status != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", status)
+	}
+	if result.Action != "H" || result.HandType != "hard" || result.Total != 16 {
+		t.Errorf("Unexpected lookup result: %+v", result)
+	}
+	if status := call(t, ts, "GET", "/api/lookup?cards=10&dealer=10", token, nil, nil); status != http.StatusBadRequest {
+		t.Errorf("Invalid hand: expected 400, got %d", status)
+	}
+}
+
+// Test each user's sessions and statistics are isolated from other users
+func TestIsolatedSessions(t *testing.T) {
+	s, ts := newTestServer(t)
+	alice := login(t, ts, "alice")
+	bob := login(t, ts, "bob")
+
+	var state sessionState
+	if status := call(t, ts, "POST", "/api/sessions", alice, map[string]string{"mode": "absolute"}, &state); status != http.StatusCreated {
+		t.Fatalf("Start session: status %d", status)
+	}
+	if state.Question == nil || state.MaxQuestions != 20 {
+		t.Fatalf("Unexpected new session: %+v", state)
+	}
+
+	// Bob cannot see or answer Alice's session
+	if status := call(t, ts, "GET", "/api/sessions/"+state.ID, bob, nil, nil); status != http.StatusNotFound {
+		t.Errorf("Other user's session: expected 404, got %d", status)
+	}
+
+	// Answer every question correctly, looking up the right play
+	for state.Question != nil {
+		q := state.Question
+		action := string(s.chart.GetCorrectActionForHand(hand.New(q.Cards...), q.DealerCard))
+		var result answerResult
+		path := "/api/sessions/" + state.ID + "/answer"
+		if status := call(t, ts, "POST", path, alice, map[string]string{"action": action}, &result); status != http.StatusOK {
+			t.Fatalf("Answer: status %d", status)
+		}
+		if !result.Correct {
+			t.Fatalf("Answer %s for %+v should be correct", action, q)
+		}
+		state = *result.Session
+	}
+	if state.Total != 20 || state.Correct != 20 {
+		t.Errorf("Expected 20/20, got %d/%d", state.Correct, state.Total)
+	}
+
+	// The finished session is saved to Alice's history only
+	var stats userStats
+	call(t, ts, "GET", "/api/stats", alice, nil, &stats)
+	if stats.Sessions != 1 || stats.Questions != 20 || stats.Accuracy != 100 {
+		t.Errorf("Unexpected stats for alice: %+v", stats)
+	}
+	call(t, ts, "GET", "/api/stats", bob, nil, &stats)
+	if stats.Sessions != 0 || stats.Questions != 0 {
+		t.Errorf("Bob should have no history: %+v", stats)
+	}
+	if _, err := os.Stat(filepath.Join(s.dataDir, "history", "alice.json")); err != nil {
+		t.Errorf("Alice's history should be saved: %v", err)
+	}
+}
+
+// Test ending a session early records only the answered questions
+func TestEndSession(t *testing.T) {
+	s, ts := newTestServer(t)
+	token := login(t, ts, "alice")
+
+	var state sessionState
+	call(t, ts, "POST", "/api/sessions", token, map[string]string{}, &state)
+	if state.Mode != "random" {
+		t.Errorf("Default mode should be random, got %q", state.Mode)
+	}
+	// Answer wrongly, so the mistake is priced
+	q := state.Question
+	miss := "H"
+	if s.chart.GetCorrectActionForHand(hand.New(q.Cards...), q.DealerCard) == 'H' {
+		miss = "S"
+	}
+	call(t, ts, "POST", "/api/sessions/"+state.ID+"/answer", token, map[string]string{"action": miss}, nil)
+
+	var list struct{ Sessions []sessionState }
+	call(t, ts, "GET", "/api/sessions", token, nil, &list)
+	if len(list.Sessions) != 1 || list.Sessions[0].Total != 1 {
+		t.Errorf("Expected one session with one answer, got %+v", list.Sessions)
+	}
+
+	if status := call(t, ts, "DELETE", "/api/sessions/"+state.ID, token, nil, nil); status != http.StatusOK {
+		t.Errorf("End session: status %d", status)
+	}
+	if status := call(t, ts, "GET", "/api/sessions/"+state.ID, token, nil, nil); status != http.StatusNotFound {
+		t.Errorf("Ended session should be gone, got %d", status)
+	}
+
+	var stats userStats
+	call(t, ts, "GET", "/api/stats", token, nil, &stats)
+	if stats.Sessions != 1 || stats.Questions != 1 {
+		t.Errorf("Expected one recorded question, got %+v", stats)
+	}
+	s.mu.Lock()
+	record := s.students["alice"].history.Sessions[0]
+	s.mu.Unlock()
+	if a := record.Attempts[0]; a.Correct || a.Severity == "" || record.EVLoss == nil || *record.EVLoss != a.EVLoss {
+		t.Errorf("The mistake should be priced and classed like a normal session's: %+v, EV loss %v", a, record.EVLoss)
+	}
+
+	if status := call(t, ts, "POST", "/api/sessions", token, map[string]string{"mode": "bogus"}, nil); status != http.StatusBadRequest {
+		t.Errorf("Unknown mode: expected 400, got %d", status)
+	}
+
+	call(t, ts, "POST", "/api/sessions", token, map[string]string{"constraints": "action=split dealer=2-6"}, &state)
+	if state.Mode != "constrained" || state.Question == nil || state.Question.HandType != "pair" {
+		t.Errorf("Constraints should start a constrained drill of pairs, got %+v", state)
+	}
+	if status := call(t, ts, "POST", "/api/sessions", token, map[string]string{"constraints": "tier=hard"}, nil); status != http.StatusBadRequest {
+		t.Errorf("Invalid constraints: expected 400, got %d", status)
+	}
+}
+
+// Test API keys from the config and basic authentication identify users
+func TestAPIKeysAndBasicAuth(t *testing.T) {
+	s, err := New(Options{DataDir: t.TempDir(), APIKeys: map[string]string{"class-key": "teacher"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Users().Add("alice", "wonderland"); err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	get := func(setAuth func(*http.Request)) (int, userStats) {
+		req, _ := http.NewRequest("GET", ts.URL+"/api/stats", nil)
+		setAuth(req)
+		resp, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var stats userStats
+		json.NewDecoder(resp.Body).Decode(&stats)
+		return resp.StatusCode, stats
+	}
+
+	tests := []struct {
+		name    string
+		setAuth func(*http.Request)
+		want    string // "" for unauthorized
+	}{
+		{"api key header", func(r *http.Request) { r.Header.Set("X-API-Key", "class-key") }, "teacher"},
+		{"api key bearer", func(r *http.Request) { r.Header.Set("Authorization", "Bearer class-key") }, "teacher"},
+		{"wrong api key", func(r *http.Request) { r.Header.Set("X-API-Key", "class-kez") }, ""},
+		{"basic auth", func(r *http.Request) { r.SetBasicAuth("alice", "wonderland") }, "alice"},
+		{"wrong password", func(r *http.Request) { r.SetBasicAuth("alice", "wrong") }, ""},
+		{"no credentials", func(r *http.Request) {}, ""},
+	}
+
+	for _, test := range tests {
+		status, stats := get(test.setAuth)
+		if test.want == "" {
+			if status != http.StatusUnauthorized {
+				t.Errorf("%s: expected 401, got %d", test.name, status)
+			}
+			continue
+		}
+		if status != http.StatusOK || stats.Username != test.want {
+			t.Errorf("%s: expected %s, got %d %q", test.name, test.want, status, stats.Username)
+		}
+	}
+
+	if _, err := New(Options{DataDir: t.TempDir(), APIKeys: map[string]string{"key": "../evil"}}); err == nil {
+		t.Error("API keys for invalid user names should be rejected")
+	}
+}
+
+// Test a verified basic-auth password is remembered briefly, so later
+// requests skip the slow password check, and a wrong one never is
+func TestBasicAuthCache(t *testing.T) {
+	s, err := New(Options{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Users().Add("alice", "wonderland"); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	if !s.basicAuth("alice", "wonderland") || s.basicAuth("alice", "wrong") {
+		t.Fatal("Only the right password should authenticate")
+	}
+	if len(s.verified) != 1 {
+		t.Errorf("Expected 1 remembered password, got %d", len(s.verified))
+	}
+
+	// With the account gone from the store, only the remembered password passes
+	if s.users, err = OpenUsers(filepath.Join(t.TempDir(), UsersFileName)); err != nil {
+		t.Fatal(err)
+	}
+	if !s.basicAuth("alice", "wonderland") {
+		t.Error("A verified password should be remembered")
+	}
+	if s.basicAuth("alice", "wrong") {
+		t.Error("A wrong password should never pass")
+	}
+	now = now.Add(basicAuthTTL)
+	if s.basicAuth("alice", "wonderland") {
+		t.Error("A remembered password should be checked again once it expires")
+	}
+}
+
+// Test the OpenAPI document describes every endpoint and its schemas
+func TestOpenAPI(t *testing.T) {
+	_, ts := newTestServer(t)
+
+	var doc struct {
+		OpenAPI    string
+		Paths      map[string]map[string]map[string]interface{}
+		Components struct{ Schemas map[string]interface{} }
+	}
+	if status := call(t, ts, "GET", "/api/openapi.json", "", nil, &doc); status != http.StatusOK {
+		t.Fatalf("Expected 200 without credentials, got %d", status)
+	}
+	if doc.OpenAPI != OpenAPIVersion {
+		t.Errorf("Expected OpenAPI %s, got %q", OpenAPIVersion, doc.OpenAPI)
+	}
+
+	ids := make(map[string]bool)
+	for _, e := range endpoints {
+		op, ok := doc.Paths[e.path][strings.ToLower(e.method)]
+		if !ok {
+			t.Errorf("Missing %s %s", e.method, e.path)
+			continue
+		}
+		id := op["operationId"].(string)
+		if ids[id] {
+			t.Errorf("Duplicate operation ID %s", id)
+		}
+		ids[id] = true
+
+		// Every documented operation is served (not 404 or 405)
+		path := strings.ReplaceAll(e.path, "{id}", "1")
+		status := call(t, ts, e.method, path, "", nil, nil)
+		if status == http.StatusNotFound || status == http.StatusMethodNotAllowed {
+			t.Errorf("%s %s: documented but not served (%d)", e.method, e.path, status)
+		}
+	}
+
+	for _, name := range []string{"SessionState", "Question", "AnswerResult", "UserStats", "CategoryStats", "ErrorResponse"} {
+		if doc.Components.Schemas[name] == nil {
+			t.Errorf("Missing schema %s", name)
+		}
+	}
+	data, _ := json.Marshal(doc.Components.Schemas["Question"])
+	if !strings.Contains(string(data), `"dealer_card":{"type":"integer"}`) {
+		t.Errorf("Unexpected Question schema: %s", data)
+	}
+}
+
+// Test the rate limiter allows a burst, then refills at the sustained rate
+func TestRateLimiter(t *testing.T) {
+	now := time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)
+	limiter := newRateLimiter(RateLimit{PerMinute: 60, Burst: 3}, func() time.Time { return now })
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := limiter.allow("a"); !ok {
+			t.Fatalf("Request %d of the burst should be allowed", i+1)
+		}
+	}
+	ok, wait := limiter.allow("a")
+	if ok || wait != time.Second {
+		t.Errorf("Expected limit with 1s wait, got %v %v", ok, wait)
+	}
+	if ok, _ := limiter.allow("b"); !ok {
+		t.Error("Other clients should have their own allowance")
+	}
+
+	now = now.Add(time.Second)
+	if ok, _ := limiter.allow("a"); !ok {
+		t.Error("A token should be refilled after a second")
+	}
+
+	if newRateLimiter(RateLimit{}, time.Now) != nil {
+		t.Error("A zero limit should disable rate limiting")
+	}
+}
+
+// Test limited clients get 429 and every request is logged
+func TestRateLimitAndLogging(t *testing.T) {
+	var logs bytes.Buffer
+	s, err := New(Options{
+		DataDir:    t.TempDir(),
+		RateLimit:  RateLimit{PerMinute: 1, Burst: 2},
+		TrustProxy: true,
+		Logger:     slog.New(slog.NewJSONHandler(&logs, nil)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	get := func(client string) *http.Response {
+		req, _ := http.NewRequest("GET", ts.URL+"/api/openapi.json", nil)
+		req.Header.Set("X-Forwarded-For", client)
+		resp, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	for i := 0; i < 2; i++ {
+		if resp := get("203.0.113.1"); resp.StatusCode != http.StatusOK {
+			t.Fatalf("Request %d: expected 200, got %d", i+1, resp.StatusCode)
+		}
+	}
+	// The proxy appends the address it saw, so one the client made up first
+	// doesn't get around the limit
+	resp := get("198.51.100.7, 203.0.113.1")
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") == "" {
+		t.Errorf("Expected 429 with Retry-After, got %d", resp.StatusCode)
+	}
+	if resp := get("203.0.113.2"); resp.StatusCode != http.StatusOK {
+		t.Errorf("Another client should not be limited, got %d", resp.StatusCode)
+	}
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected 4 log records, got %d:\n%s", len(lines), logs.String())
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[2]), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record["msg"] != "request" || record["status"] != float64(429) ||
+		record["path"] != "/api/openapi.json" || record["client"] != "203.0.113.1" {
+		t.Errorf("Unexpected log record: %v", record)
+	}
+}
+
+// Test idle sessions expire and stopping the server saves sessions in progress
+func TestSessionsEndWithServer(t *testing.T) {
+	s, err := New(Options{DataDir: t.TempDir(), OpenRegistration: true, SessionTimeout: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error)
+	go func() { served <- s.Serve(ctx, listener) }()
+
+	ts := &httptest.Server{URL: "http://" + listener.Addr().String(), Listener: listener}
+	token := login(t, ts, "alice")
+	start := func() string {
+		var state sessionState
+		call(t, ts, "POST", "/api/sessions", token, map[string]string{}, &state)
+		call(t, ts, "POST", "/api/sessions/"+state.ID+"/answer", token, map[string]string{"action": "H"}, nil)
+		return state.ID
+	}
+
+	idle := start()
+	s.expireSessions(time.Now().Add(2 * time.Minute))
+	if status := call(t, ts, "GET", "/api/sessions/"+idle, token, nil, nil); status != http.StatusNotFound {
+		t.Errorf("Idle session should expire, got %d", status)
+	}
+
+	start()
+	cancel()
+	if err := <-served; err != nil {
+		t.Fatalf("Serve returned %v", err)
+	}
+
+	h, err := history.Open(filepath.Join(s.dataDir, "history", "alice.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.Sessions) != 2 {
+		t.Errorf("Expected expired and interrupted sessions saved, got %d", len(h.Sessions))
+	}
+}
+
+// Test GraphQL queries filter and break down the history
+func TestGraphQL(t *testing.T) {
+	s, ts := newTestServer(t)
+	token := login(t, ts, "alice")
+
+	day := func(date string) time.Time {
+		d, _ := time.Parse("2006-01-02 15:04", date)
+		return d
+	}
+	s.mu.Lock()
+	st, err := s.student("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.history.Add(history.Session{Mode: "random", Rules: "Vegas Strip", Started: day("2024-03-01 10:00"), Ended: day("2024-03-01 10:05"),
+		Correct: 1, Total: 2, Attempts: []history.Attempt{
+			{Cards: []int{10, 6}, DealerCard: 10, HandType: "hard", Action: "S", CorrectAction: "H"},
+			{Cards: []int{11, 7}, DealerCard: 9, HandType: "soft", Action: "H", CorrectAction: "H", Correct: true},
+		}})
+	st.history.Add(history.Session{Mode: "absolute", Rules: "Vegas Strip", Started: day("2024-03-02 09:00"), Ended: day("2024-03-02 09:01"),
+		Correct: 2, Total: 2, Attempts: []history.Attempt{
+			{Cards: []int{8, 8}, DealerCard: 10, HandType: "pair", Action: "Y", CorrectAction: "Y", Correct: true},
+			{Cards: []int{10, 6}, DealerCard: 10, HandType: "hard", Action: "H", CorrectAction: "H", Correct: true},
+		}})
+	s.mu.Unlock()
+
+	query := `query Weak($from: String = "2024-03-01", $limit: Int) {
+		stats(from: $from) {
+			sessions questions accuracy practiceSeconds
+			byHandType { name correct total }
+			weakest: cells(orderBy: ACCURACY, limit: $limit) { label correct total }
+			days { date accuracy }
+		}
+		second: stats(from: "2024-03-02", mode: "absolute") { sessions }
+	}`
+	var resp struct {
+		Data   json.RawMessage
+		Errors []struct{ Message string }
+	}
+	body := map[string]interface{}{"query": query, "variables": map[string]interface{}{"limit": 1}}
+	if status := call(t, ts, "POST", "/api/graphql", token, body, &resp); status != http.StatusOK || len(resp.Errors) > 0 {
+		t.Fatalf("Query: status %d, errors %+v", status, resp.Errors)
+	}
+	want := `{"stats":{"sessions":2,"questions":4,"accuracy":75,"practiceSeconds":360,` +
+		`"byHandType":[{"name":"hard","correct":1,"total":2},{"name":"soft","correct":1,"total":1},{"name":"pair","correct":1,"total":1}],` +
+		`"weakest":[{"label":"Hard 16 vs 10","correct":1,"total":2}],` +
+		`"days":[{"date":"2024-03-01","accuracy":50},{"date":"2024-03-02","accuracy":100}]},` +
+		`"second":{"sessions":1}}`
+	if string(resp.Data) != want {
+		t.Errorf("Unexpected data:\n got %s\nwant %s", resp.Data, want)
+	}
+
+	// Field errors leave the rest of the result; syntax errors are a bad request
+	call(t, ts, "POST", "/api/graphql", token, map[string]string{"query": `{ stats(from: "March") { sessions } }`}, &resp)
+	if string(resp.Data) != `{"stats":null}` || len(resp.Errors) != 1 {
+		t.Errorf("Bad date: data %s, errors %+v", resp.Data, resp.Errors)
+	}
+	if status := call(t, ts, "POST", "/api/graphql", token, map[string]string{"query": "{ stats {"}, nil); status != http.StatusBadRequest {
+		t.Errorf("Syntax error: expected 400, got %d", status)
+	}
+
+	// GET runs a query given as a parameter, or returns the schema
+	resp.Data = nil
+	if status := call(t, ts, "GET", "/api/graphql?query={stats{questions}}", token, nil, &resp); status != http.StatusOK || string(resp.Data) != `{"stats":{"questions":4}}` {
+		t.Errorf("GET query: status %d, data %s", status, resp.Data)
+	}
+	req, _ := http.NewRequest("GET", ts.URL+"/api/graphql", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	r, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, _ := io.ReadAll(r.Body)
+	r.Body.Close()
+	if !strings.Contains(string(schema), "type Stats {") {
+		t.Errorf("Expected the schema, got %q", schema)
+	}
+}
+
+// Test every user is asked the same challenge, can play it once a day, and
+// is ranked on the day's leaderboard by their first try
+func TestChallenge(t *testing.T) {
+	s, ts := newTestServer(t)
+	alice := login(t, ts, "alice")
+	bob := login(t, ts, "bob")
+
+	var first, second sessionState
+	call(t, ts, "POST", "/api/sessions", alice, map[string]string{"mode": "challenge"}, &first)
+	call(t, ts, "POST", "/api/sessions", bob, map[string]string{"mode": "challenge"}, &second)
+	// A second try can't be started while the first is open to show the answers
+	if status := call(t, ts, "POST", "/api/sessions", alice, map[string]string{"mode": "challenge"}, nil); status != http.StatusConflict {
+		t.Errorf("Two tries at once: expected 409, got %d", status)
+	}
+	today := challenge.Day(time.Now())
+	if first.Mode != "challenge:"+today || first.MaxQuestions != challenge.Questions || first.Question == nil {
+		t.Fatalf("Unexpected challenge: %+v", first)
+	}
+	if !reflect.DeepEqual(first.Question, second.Question) {
+		t.Errorf("Users should get the same first question: %+v and %+v", first.Question, second.Question)
+	}
+
+	// Alice answers every question correctly; Bob misses one and stops
+	for first.Question != nil {
+		q := first.Question
+		action := string(s.chart.GetCorrectActionForHand(hand.New(q.Cards...), q.DealerCard))
+		var result answerResult
+		call(t, ts, "POST", "/api/sessions/"+first.ID+"/answer", alice, map[string]string{"action": action}, &result)
+		first = *result.Session
+	}
+	q := second.Question
+	miss := "H"
+	if s.chart.GetCorrectActionForHand(hand.New(q.Cards...), q.DealerCard) == 'H' {
+		miss = "S"
+	}
+	call(t, ts, "POST", "/api/sessions/"+second.ID+"/answer", bob, map[string]string{"action": miss}, nil)
+	call(t, ts, "DELETE", "/api/sessions/"+second.ID, bob, nil, nil)
+
+	if status := call(t, ts, "POST", "/api/sessions", alice, map[string]string{"mode": "challenge"}, nil); status != http.StatusConflict {
+		t.Errorf("Second try: expected 409, got %d", status)
+	}
+
+	var board leaderboard
+	if status := call(t, ts, "GET", "/api/challenges/today", bob, nil, &board); status != http.StatusOK {
+		t.Fatalf("Leaderboard: status %d", status)
+	}
+	if board.Day != today || len(board.Entries) != 2 {
+		t.Fatalf("Unexpected leaderboard: %+v", board)
+	}
+	if e := board.Entries[0]; e.Rank != 1 || e.Username != "alice" || e.Correct != 20 || e.Total != 20 || e.You {
+		t.Errorf("First place = %+v, want alice with 20/20", e)
+	}
+	if e := board.Entries[1]; e.Rank != 2 || e.Username != "bob" || e.Correct != 0 || e.Total != 1 || !e.You {
+		t.Errorf("Second place = %+v, want bob (you) with one answer", e)
+	}
+
+	call(t, ts, "GET", "/api/challenges/2000-01-01", bob, nil, &board)
+	if len(board.Entries) != 0 {
+		t.Errorf("Nobody played on 2000-01-01: %+v", board)
+	}
+	if status := call(t, ts, "GET", "/api/challenges/yesterday", bob, nil, nil); status != http.StatusBadRequest {
+		t.Errorf("Invalid day: expected 400, got %d", status)
+	}
+}
+
+// Test finished sessions and exams are posted to the webhooks that want
+// them, signed and retried
+func TestWebhooks(t *testing.T) {
+	webhookRetryDelay = time.Millisecond
+	defer func() { webhookRetryDelay = 2 * time.Second }()
+
+	type delivery struct {
+		event, signature string
+		payload          webhookPayload
+	}
+	var mu sync.Mutex
+	var received []delivery
+	failures := 1
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if failures > 0 {
+			failures--
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		d := delivery{event: r.Header.Get("X-Trainer-Event"), signature: r.Header.Get("X-Trainer-Signature")}
+		if err := json.Unmarshal(body, &d.payload); err != nil {
+			t.Errorf("Invalid payload %s: %v", body, err)
+		}
+		mac := hmac.New(sha256.New, []byte("shh"))
+		mac.Write(body)
+		if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); d.signature != want {
+			t.Errorf("Signature %q, want %q", d.signature, want)
+		}
+		received = append(received, d)
+	}))
+	defer hook.Close()
+
+	if _, err := New(Options{DataDir: t.TempDir(), Webhooks: []Webhook{{URL: "ftp://example.com"}}}); err == nil {
+		t.Error("Expected an error for a webhook that isn't http")
+	}
+	if _, err := New(Options{DataDir: t.TempDir(), Webhooks: []Webhook{{URL: hook.URL, Events: []string{"started"}}}}); err == nil {
+		t.Error("Expected an error for an unknown event")
+	}
+	s, err := New(Options{DataDir: t.TempDir(), OpenRegistration: true, Webhooks: []Webhook{
+		{URL: hook.URL, Secret: "shh"},
+		{URL: hook.URL + "/exams", Events: []string{EventExam}, Secret: "shh"},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+	token := login(t, ts, "alice")
+
+	// A session ended early goes to the first webhook only, after a retry
+	var state sessionState
+	call(t, ts, "POST", "/api/sessions", token, map[string]string{"mode": "absolute"}, &state)
+	call(t, ts, "POST", "/api/sessions/"+state.ID+"/answer", token, map[string]string{"action": "P"}, nil)
+	call(t, ts, "DELETE", "/api/sessions/"+state.ID, token, nil, nil)
+	s.deliveries.Wait()
+	if len(received) != 1 || received[0].event != EventSession {
+		t.Fatalf("Expected one session event, got %+v", received)
+	}
+	p := received[0].payload
+	if p.User != "alice" || p.Session.Mode != "absolutes" || p.Session.Total != 1 || p.Session.Complete || p.Exam != nil ||
+		p.Text == "" || p.Content != p.Text {
+		t.Errorf("Unexpected session payload: %+v", p)
+	}
+
+	// A finished exam goes to both
+	call(t, ts, "POST", "/api/sessions", token, map[string]string{"mode": "exam"}, &state)
+	for state.Question != nil {
+		q := state.Question
+		action := string(s.chart.GetCorrectActionForHand(hand.New(q.Cards...), q.DealerCard))
+		var result answerResult
+		call(t, ts, "POST", "/api/sessions/"+state.ID+"/answer", token, map[string]string{"action": action}, &result)
+		state = *result.Session
+	}
+	s.deliveries.Wait()
+	if len(received) != 3 || received[1].event != EventExam || received[2].event != EventExam {
+		t.Fatalf("Expected two exam events, got %+v", received[1:])
+	}
+	p = received[1].payload
+	if p.Exam == nil || !p.Exam.Passed || p.Exam.Score != 100 || !p.Session.Complete || len(p.Session.Missed) != 0 ||
+		!strings.Contains(p.Text, "alice passed the exam") {
+		t.Errorf("Unexpected exam payload: %+v", p)
+	}
+}
+
+// Test signing in through an OpenID Connect provider links identities to
+// profiles, creating them when allowed
+func TestOAuth(t *testing.T) {
+	type account struct {
+		sub, login, email string
+	}
+	accounts := map[string]account{
+		"code-ann": {"1001", "Ann.Smith", "ann@school.edu"},
+		"code-bob": {"1002", "bob", "bob@elsewhere.com"},
+	}
+	var challenges = make(map[string]string)
+	var mu sync.Mutex
+	var provider *httptest.Server
+	provider = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(map[string]string{
+				"authorization_endpoint": provider.URL + "/authorize",
+				"token_endpoint":         provider.URL + "/token",
+				"userinfo_endpoint":      provider.URL + "/userinfo",
+			})
+		case "/token":
+			r.ParseForm()
+			sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+			mu.Lock()
+			challenge := challenges[r.Form.Get("redirect_uri")]
+			mu.Unlock()
+			if r.Form.Get("client_secret") != "s3cret" || challenge != base64.RawURLEncoding.EncodeToString(sum[:]) {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{"access_token": "token-" + r.Form.Get("code"), "token_type": "Bearer"})
+		case "/userinfo":
+			a, ok := accounts[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer token-")]
+			if !ok {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"sub": a.sub, "preferred_username": a.login, "email": a.email, "email_verified": true,
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer provider.Close()
+
+	s, err := New(Options{DataDir: t.TempDir(), OAuth: []OAuthProvider{
+		{Name: "school", ClientID: "trainer", ClientSecret: "s3cret", Issuer: provider.URL, AllowSignup: true, Domains: []string{"school.edu"}},
+		{Name: "oidc", ClientID: "trainer", ClientSecret: "s3cret", Issuer: provider.URL},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+	if _, err := New(Options{DataDir: t.TempDir(), OAuth: []OAuthProvider{{Name: "custom", ClientID: "x"}}}); err == nil {
+		t.Error("Expected an error for a provider without endpoints")
+	}
+
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	// signIn goes through the provider's sign-in with an authorization code
+	// and returns the callback's status and result
+	signIn := func(name, code string) (int, oauthLoginResult) {
+		t.Helper()
+		resp, err := noRedirect.Get(ts.URL + "/api/oauth/" + name + "/login")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		location, err := url.Parse(resp.Header.Get("Location"))
+		if resp.StatusCode != http.StatusFound || err != nil || !strings.HasPrefix(location.String(), provider.URL+"/authorize?") {
+			t.Fatalf("Login: status %d, location %q", resp.StatusCode, location)
+		}
+		query := location.Query()
+		mu.Lock()
+		challenges[query.Get("redirect_uri")] = query.Get("code_challenge")
+		mu.Unlock()
+		if query.Get("redirect_uri") != ts.URL+"/api/oauth/"+name+"/callback" || query.Get("client_id") != "trainer" {
+			t.Errorf("Unexpected authorization request %s", location)
+		}
+
+		var result oauthLoginResult
+		callback := "/api/oauth/" + name + "/callback?" + url.Values{"code": {code}, "state": {query.Get("state")}}.Encode()
+		status := call(t, ts, "GET", callback, "", nil, &result)
+		return status, result
+	}
+
+	var providers oauthProviderList
+	call(t, ts, "GET", "/api/oauth", "", nil, &providers)
+	if len(providers.Providers) != 2 || providers.Providers[0].LoginURL != "/api/oauth/school/login" {
+		t.Errorf("Unexpected providers %+v", providers)
+	}
+
+	// A first sign-in creates a profile, and later ones log in to it
+	status, result := signIn("school", "code-ann")
+	if status != http.StatusOK || result.Username != "ann.smith" || result.Token == "" {
+		t.Fatalf("Sign-up: status %d, %+v", status, result)
+	}
+	if status := call(t, ts, "GET", "/api/stats", result.Token, nil, nil); status != http.StatusOK {
+		t.Errorf("Stats with the sign-in token: status %d", status)
+	}
+	if status, again := signIn("school", "code-ann"); status != http.StatusOK || again.Username != "ann.smith" {
+		t.Errorf("Second sign-in: status %d, %+v", status, again)
+	}
+	if s.users.Authenticate("ann.smith", "") {
+		t.Error("A profile created by sign-in should have no password")
+	}
+
+	// Outside the domain, and without sign-ups, identities are refused
+	if status, _ := signIn("school", "code-bob"); status != http.StatusForbidden {
+		t.Errorf("Other domain: expected 403, got %d", status)
+	}
+	if status, _ := signIn("oidc", "code-bob"); status != http.StatusForbidden {
+		t.Errorf("Unlinked identity: expected 403, got %d", status)
+	}
+	if err := s.users.Add("robert", "robert-password"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.users.Link("robert", "oidc:1002"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.users.Link("ann.smith", "oidc:1002"); !errors.Is(err, ErrIdentityLinked) {
+		t.Errorf("Linking an identity twice: %v", err)
+	}
+	if status, linked := signIn("oidc", "code-bob"); status != http.StatusOK || linked.Username != "robert" {
+		t.Errorf("Linked identity: status %d, %+v", status, linked)
+	}
+
+	// Codes are checked by the provider, and states are used once
+	if status, _ := signIn("oidc", "code-nobody"); status != http.StatusBadGateway {
+		t.Errorf("Bad code: expected 502, got %d", status)
+	}
+	if status := call(t, ts, "GET", "/api/oauth/oidc/callback?code=code-bob&state=forged", "", nil, nil); status != http.StatusBadRequest {
+		t.Errorf("Forged state: expected 400, got %d", status)
+	}
+
+	// Only so many sign-ins may be pending, until they expire
+	s.mu.Lock()
+	for i := 0; i < maxOAuthStates; i++ {
+		s.oauthStates[strconv.Itoa(i)] = oauthState{provider: "oidc", expires: time.Now().Add(oauthStateTTL)}
+	}
+	s.mu.Unlock()
+	login := func() int {
+		resp, err := noRedirect.Get(ts.URL + "/api/oauth/oidc/login")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if status := login(); status != http.StatusServiceUnavailable {
+		t.Errorf("Too many pending sign-ins: expected 503, got %d", status)
+	}
+	s.now = func() time.Time { return time.Now().Add(oauthStateTTL) }
+	if status := login(); status != http.StatusFound {
+		t.Errorf("After the pending sign-ins expire: expected 302, got %d", status)
+	}
+}
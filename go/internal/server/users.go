@@ -0,0 +1,207 @@
+package server
+
+import (
+	"blackjack_trainer/internal/atomicfile"
+	"blackjack_trainer/internal/crypt"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// UsersFileName is the name of the user store inside the server's data directory.
+const UsersFileName = "users.json"
+
+// ErrUserExists is returned when adding a user whose name is taken.
+var ErrUserExists = errors.New("user already exists")
+
+// validName restricts user names to characters that are safe in file names,
+// since each user's history is stored in a file named after them.
+var validName = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9_.-]{0,31}$`)
+
+// ErrIdentityLinked is returned when linking an external identity that is
+// already linked to another user.
+var ErrIdentityLinked = errors.New("identity is linked to another user")
+
+// User is an account in the user store. Only a hash of the password is kept.
+// Users created by single sign-on have no password.
+type User struct {
+	Name         string    `json:"name"`
+	PasswordHash string    `json:"password_hash,omitempty"`
+	Created      time.Time `json:"created"`
+	// Identities are the external accounts that sign in as the user, as
+	// "<provider>:<subject>", e.g. "github:12345".
+	Identities []string `json:"identities,omitempty"`
+}
+
+// UserStore is a simple JSON file of user accounts.
+type UserStore struct {
+	mu    sync.Mutex
+	path  string
+	users map[string]User
+}
+
+// OpenUsers loads the user store at path. A missing file gives an empty
+// store that will be created when the first user is added.
+func OpenUsers(path string) (*UserStore, error) {
+	s := &UserStore{path: path, users: make(map[string]User)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Users []User `json:"users"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	for _, u := range file.Users {
+		s.users[u.Name] = u
+	}
+	return s, nil
+}
+
+// Add creates a user with the given password and saves the store.
+func (s *UserStore) Add(name, password string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid user name %q (use up to 32 letters, digits, '.', '_' or '-')", name)
+	}
+	hash, err := crypt.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.users[name]; exists {
+		return ErrUserExists
+	}
+	s.users[name] = User{Name: name, PasswordHash: hash, Created: time.Now()}
+	if err := s.save(); err != nil {
+		delete(s.users, name)
+		return err
+	}
+	return nil
+}
+
+// AddLinked creates a user without a password who signs in through an
+// external identity, and saves the store. The name is suggested: if it is
+// taken, a number is appended. The name given to the user is returned.
+func (s *UserStore) AddLinked(name, identity string) (string, error) {
+	if !validName.MatchString(name) {
+		return "", fmt.Errorf("invalid user name %q", name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, linked := s.identity(identity); linked {
+		return "", ErrIdentityLinked
+	}
+	base := name
+	for n := 2; ; n++ {
+		if _, exists := s.users[name]; !exists {
+			break
+		}
+		suffix := fmt.Sprintf("-%d", n)
+		name = base[:min(len(base), 32-len(suffix))] + suffix
+	}
+	s.users[name] = User{Name: name, Created: time.Now(), Identities: []string{identity}}
+	if err := s.save(); err != nil {
+		delete(s.users, name)
+		return "", err
+	}
+	return name, nil
+}
+
+// Link links an external identity, e.g. "github:12345", to a user, so
+// signing in with it logs in as them, and saves the store.
+func (s *UserStore) Link(name, identity string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[name]
+	if !ok {
+		return fmt.Errorf("no user %q", name)
+	}
+	if owner, linked := s.identity(identity); linked {
+		if owner == name {
+			return nil
+		}
+		return fmt.Errorf("%s: %w %q", identity, ErrIdentityLinked, owner)
+	}
+	previous := u.Identities
+	u.Identities = append(append([]string(nil), previous...), identity)
+	s.users[name] = u
+	if err := s.save(); err != nil {
+		u.Identities = previous
+		s.users[name] = u
+		return err
+	}
+	return nil
+}
+
+// Identity returns the user an external identity is linked to.
+func (s *UserStore) Identity(identity string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.identity(identity)
+}
+
+// identity returns the user an identity is linked to. The caller must hold
+// s.mu.
+func (s *UserStore) identity(identity string) (string, bool) {
+	for name, u := range s.users {
+		for _, id := range u.Identities {
+			if id == identity {
+				return name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Authenticate reports whether the name and password match a user.
+func (s *UserStore) Authenticate(name, password string) bool {
+	s.mu.Lock()
+	u, ok := s.users[name]
+	s.mu.Unlock()
+	return ok && crypt.CheckPassword(u.PasswordHash, password)
+}
+
+// Names returns the names of all users in sorted order.
+func (s *UserStore) Names() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, 0, len(s.users))
+	for name := range s.users {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// save writes the store to its file. The caller must hold s.mu.
+func (s *UserStore) save() error {
+	var file struct {
+		Users []User `json:"users"`
+	}
+	for _, u := range s.users {
+		file.Users = append(file.Users, u)
+	}
+	sort.Slice(file.Users, func(i, j int) bool { return file.Users[i].Name < file.Users[j].Name })
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(s.path, data, 0o600)
+}
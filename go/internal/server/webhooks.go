@@ -0,0 +1,236 @@
+package server
+
+import (
+	"blackjack_trainer/internal/exam"
+	"blackjack_trainer/internal/history"
+	"blackjack_trainer/internal/stats"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Webhook events.
+const (
+	// EventSession is sent when a training session ends with questions
+	// answered.
+	EventSession = "session.completed"
+	// EventExam is sent instead of EventSession when the session was an
+	// exam.
+	EventExam = "exam.completed"
+)
+
+// webhookAttempts is how many times a delivery is tried before giving up,
+// and webhookTimeout bounds each try.
+const (
+	webhookAttempts = 3
+	webhookTimeout  = 10 * time.Second
+)
+
+// webhookRetryDelay is the wait before the first retry; it doubles for each
+// one after. Tests shorten it.
+var webhookRetryDelay = 2 * time.Second
+
+// Webhook is an address notified when users finish sessions, so other
+// systems, such as a learning management system or a chat channel, can
+// react to training results.
+type Webhook struct {
+	// URL receives each event as a JSON POST.
+	URL string
+	// Events lists the events sent, EventSession and EventExam; every event
+	// when empty.
+	Events []string
+	// Secret, if set, signs each delivery: the X-Trainer-Signature header
+	// holds "sha256=" and the hex HMAC-SHA256 of the body keyed by it.
+	Secret string
+}
+
+// check returns an error if the webhook can't be used.
+func (h Webhook) check() error {
+	u, err := url.Parse(h.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("webhook %q: not an http or https URL", h.URL)
+	}
+	for _, event := range h.Events {
+		if event != EventSession && event != EventExam {
+			return fmt.Errorf("webhook %q: unknown event %q (valid: %s, %s)", h.URL, event, EventSession, EventExam)
+		}
+	}
+	return nil
+}
+
+// wants reports whether the webhook is sent the event.
+func (h Webhook) wants(event string) bool {
+	if len(h.Events) == 0 {
+		return true
+	}
+	for _, e := range h.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// webhookPayload is the body of a webhook delivery. Text and Content hold
+// the same message, as Slack and Mattermost, and Discord, post it.
+type webhookPayload struct {
+	Event   string         `json:"event"`
+	User    string         `json:"user"`
+	Text    string         `json:"text"`
+	Content string         `json:"content"`
+	Session sessionSummary `json:"session"`
+	// Exam is set for exams.
+	Exam *examSummary `json:"exam,omitempty"`
+}
+
+// sessionSummary is a finished session as webhooks receive it.
+type sessionSummary struct {
+	ID      string    `json:"id"`
+	Mode    string    `json:"mode"`
+	Rules   string    `json:"rules"`
+	Started time.Time `json:"started"`
+	Ended   time.Time `json:"ended"`
+	Correct int       `json:"correct"`
+	Total   int       `json:"total"`
+	// Questions is the number the session asks; Complete is set if all of
+	// them were answered rather than the session ending early.
+	Questions  int                      `json:"questions"`
+	Complete   bool                     `json:"complete"`
+	Accuracy   float64                  `json:"accuracy"`
+	ByHandType map[string]categoryStats `json:"by_hand_type"`
+	// Missed lists the chart cells answered wrongly, e.g. "Hard 16 vs 10".
+	Missed []string `json:"missed"`
+}
+
+// examSummary is the outcome of an exam.
+type examSummary struct {
+	Passed   bool    `json:"passed"`
+	Score    float64 `json:"score"`
+	PassMark float64 `json:"pass_mark"`
+}
+
+// newWebhookPayload describes a finished session for webhooks.
+func newWebhookPayload(user string, p *practice, record history.Session) webhookPayload {
+	summary := sessionSummary{
+		ID:         p.id,
+		Mode:       record.Mode,
+		Rules:      record.Rules,
+		Started:    record.Started,
+		Ended:      record.Ended,
+		Correct:    record.Correct,
+		Total:      record.Total,
+		Questions:  p.session.GetMaxQuestions(),
+		Complete:   p.done(),
+		Accuracy:   float64(record.Correct) / float64(record.Total) * 100,
+		ByHandType: make(map[string]categoryStats),
+		Missed:     []string{},
+	}
+	for _, attempt := range record.Attempts {
+		c := summary.ByHandType[attempt.HandType]
+		c.Total++
+		if attempt.Correct {
+			c.Correct++
+		} else {
+			summary.Missed = append(summary.Missed, stats.AttemptLabel(attempt))
+		}
+		c.Accuracy = float64(c.Correct) / float64(c.Total) * 100
+		summary.ByHandType[attempt.HandType] = c
+	}
+
+	payload := webhookPayload{Event: EventSession, User: user, Session: summary}
+	payload.Text = fmt.Sprintf("%s finished a session (%s): %d/%d (%.1f%%)",
+		user, record.Mode, record.Correct, record.Total, summary.Accuracy)
+	if record.Mode == "exam" {
+		result := exam.Result{Taken: record.Ended, Rules: record.Rules, Correct: record.Correct,
+			Total: record.Total, Questions: summary.Questions}
+		payload.Event = EventExam
+		payload.Exam = &examSummary{Passed: result.Passed(), Score: result.Score(), PassMark: exam.PassMark}
+		if result.Passed() {
+			payload.Text = fmt.Sprintf("%s passed the exam with %.1f%% (%d/%d)", user, result.Score(), record.Correct, record.Total)
+		} else {
+			payload.Text = fmt.Sprintf("%s did not pass the exam: %d/%d (%.1f%%)", user, record.Correct, record.Total, result.Score())
+		}
+	}
+	payload.Content = payload.Text
+	return payload
+}
+
+// notify sends a finished session to the webhooks that want it. Deliveries
+// run in the background; Serve waits for them before returning.
+func (s *Server) notify(user string, p *practice, record history.Session) {
+	if len(s.webhooks) == 0 {
+		return
+	}
+	payload := newWebhookPayload(user, p, record)
+	body, err := json.Marshal(payload)
+	if err != nil {
+		s.logger.Error("webhook payload", slog.Any("error", err))
+		return
+	}
+	for _, hook := range s.webhooks {
+		if !hook.wants(payload.Event) {
+			continue
+		}
+		s.deliveries.Add(1)
+		go func(hook Webhook) {
+			defer s.deliveries.Done()
+			s.deliver(hook, payload.Event, body)
+		}(hook)
+	}
+}
+
+// deliver posts an event to a webhook, retrying failures that may be
+// temporary: network errors, 429 and 5xx responses.
+func (s *Server) deliver(hook Webhook, event string, body []byte) {
+	delay := webhookRetryDelay
+	for attempt := 1; ; attempt++ {
+		retry, err := s.post(hook, event, body)
+		if err == nil {
+			s.logger.Debug("webhook delivered", slog.String("url", hook.URL), slog.String("event", event))
+			return
+		}
+		if !retry || attempt == webhookAttempts {
+			s.logger.Warn("webhook failed", slog.String("url", hook.URL), slog.String("event", event),
+				slog.Int("attempts", attempt), slog.Any("error", err))
+			return
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// post makes one delivery, reporting whether a failure is worth retrying.
+func (s *Server) post(hook Webhook, event string, body []byte) (retry bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Trainer-Event", event)
+	if hook.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(hook.Secret))
+		mac.Write(body)
+		req.Header.Set("X-Trainer-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return retry, fmt.Errorf("%s", resp.Status)
+	}
+	return false, nil
+}
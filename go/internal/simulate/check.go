@@ -0,0 +1,127 @@
+package simulate
+
+import (
+	"blackjack_trainer/internal/hand"
+	"blackjack_trainer/internal/strategy"
+	"context"
+	"math"
+)
+
+// Cell is the simulated EV of every legal action on one chart cell.
+type Cell struct {
+	HandType    strategy.HandType
+	PlayerTotal int
+	DealerCard  int
+	// Hand is the representative hand simulated for the cell.
+	Hand hand.Hand
+	// Chart is the chart's action for the cell.
+	Chart rune
+	// Results holds one result per legal action, in H, S, D, Y order.
+	Results []Result
+}
+
+// Result returns the result for an action.
+func (c Cell) Result(action rune) (Result, bool) {
+	if action == 'P' {
+		action = 'Y'
+	}
+	for _, r := range c.Results {
+		if r.Action == action {
+			return r, true
+		}
+	}
+	return Result{}, false
+}
+
+// Best returns the result with the highest EV.
+func (c Cell) Best() Result {
+	var best Result
+	for i, r := range c.Results {
+		if i == 0 || r.EV() > best.EV() {
+			best = r
+		}
+	}
+	return best
+}
+
+// Loss returns the EV the chart's action gives up against the best action.
+func (c Cell) Loss() float64 {
+	chart, ok := c.Result(c.Chart)
+	if !ok {
+		return 0
+	}
+	return c.Best().EV() - chart.EV()
+}
+
+// Disagrees reports whether the best action beats the chart's action by more
+// than three standard errors of the difference, i.e. by more than noise.
+func (c Cell) Disagrees() bool {
+	chart, ok := c.Result(c.Chart)
+	if !ok {
+		return false
+	}
+	best := c.Best()
+	noise := 3 * math.Sqrt(chart.StdErr()*chart.StdErr()+best.StdErr()*best.StdErr())
+	return best.EV()-chart.EV() > noise
+}
+
+// representativeHand returns a two-card hand for a chart cell. Hard 20 can
+// only be made from two cards as a pair, so it is dealt as 10-6-4.
+func representativeHand(handType strategy.HandType, total int) hand.Hand {
+	switch {
+	case handType == strategy.HandTypePair:
+		return hand.New(total, total)
+	case handType == strategy.HandTypeSoft:
+		return hand.New(hand.Ace, total-11)
+	case total == 20:
+		return hand.New(10, 6, 4)
+	case total >= 12:
+		return hand.New(10, total-10)
+	default:
+		return hand.New(2, total-2)
+	}
+}
+
+// CheckChart simulates every legal action on every chart cell: hard 5-20,
+// soft 13-20 and pairs, against each dealer card. Every cell and action is
+// simulated with the same seeds, so differences between actions reflect the
+// plays rather than the luck of the draw.
+func CheckChart(ctx context.Context, chart *strategy.StrategyChart, opts Options) ([]Cell, error) {
+	sections := []struct {
+		handType   strategy.HandType
+		first, end int
+	}{
+		{strategy.HandTypeHard, 5, 20},
+		{strategy.HandTypeSoft, 13, 20},
+		{strategy.HandTypePair, 2, 11},
+	}
+
+	var cells []Cell
+	for _, section := range sections {
+		for total := section.first; total <= section.end; total++ {
+			h := representativeHand(section.handType, total)
+			var actions []rune
+			for _, action := range []rune{'H', 'S', 'D', 'Y'} {
+				if legal(chart, h, action) == nil {
+					actions = append(actions, action)
+				}
+			}
+
+			for dealer := 2; dealer <= 11; dealer++ {
+				results, err := CompareParallel(ctx, chart, h, dealer, actions, opts)
+				if err != nil {
+					return nil, err
+				}
+				cells = append(cells, Cell{
+					HandType:    section.handType,
+					PlayerTotal: total,
+					DealerCard:  dealer,
+					Hand:        h,
+					Chart:       chart.GetCorrectAction(section.handType, total, dealer),
+					Results:     results,
+				})
+			}
+		}
+	}
+	return cells, nil
+}
@@ -0,0 +1,97 @@
+package simulate
+
+import (
+	"blackjack_trainer/internal/hand"
+	"blackjack_trainer/internal/strategy"
+	"math/rand"
+	"sync"
+)
+
+// LossRounds is how many rounds a LossTable simulates for each action. It is
+// far fewer than DefaultRounds so a mistake is priced without a pause, at the
+// cost of a few hundredths of a bet of noise on close plays.
+const LossRounds = 20000
+
+// lossSeed seeds every simulation of a LossTable, so the same mistake always
+// costs the same and both actions on a cell see the same cards.
+const lossSeed = 1
+
+// LossTable prices mistakes: the EV, in bets, that playing a cell one way
+// gives up against the chart's play. Each cell's EVs are simulated on its
+// representative hand the first time they are needed and remembered, so a
+// table builds up only the part of the chart a player gets wrong. It is safe
+// for concurrent use.
+type LossTable struct {
+	chart  *strategy.StrategyChart
+	rounds int
+
+	mu sync.Mutex
+	ev map[lossKey]float64
+}
+
+type lossKey struct {
+	handType    strategy.HandType
+	playerTotal int
+	dealerCard  int
+	action      rune
+}
+
+// NewLossTable returns an empty table for the chart, simulating rounds per
+// action (LossRounds if zero).
+func NewLossTable(chart *strategy.StrategyChart, rounds int) *LossTable {
+	if rounds <= 0 {
+		rounds = LossRounds
+	}
+	return &LossTable{chart: chart, rounds: rounds, ev: make(map[lossKey]float64)}
+}
+
+// Loss returns the EV given up by taking action instead of the chart's play
+// on the hand's cell. It is zero for the chart's play, for an action that
+// can't be taken on the cell, and when simulation noise makes the action
+// look no worse.
+func (t *LossTable) Loss(h hand.Hand, dealerCard int, action rune) float64 {
+	handType, total := strategy.Classify(h)
+	correct := t.chart.GetCorrectAction(handType, total, dealerCard)
+	if action == 'P' {
+		action = 'Y'
+	}
+	if action == correct {
+		return 0
+	}
+	chosen, ok := t.cellEV(handType, total, dealerCard, action)
+	if !ok {
+		return 0
+	}
+	best, ok := t.cellEV(handType, total, dealerCard, correct)
+	if !ok || best <= chosen {
+		return 0
+	}
+	return best - chosen
+}
+
+// EV returns the simulated EV, in bets, of taking action on the hand's
+// cell, or false if the action can't be taken there.
+func (t *LossTable) EV(h hand.Hand, dealerCard int, action rune) (float64, bool) {
+	handType, total := strategy.Classify(h)
+	if action == 'P' {
+		action = 'Y'
+	}
+	return t.cellEV(handType, total, dealerCard, action)
+}
+
+// cellEV returns the simulated EV of an action on a cell, or false if the
+// action can't be taken there.
+func (t *LossTable) cellEV(handType strategy.HandType, total, dealerCard int, action rune) (float64, bool) {
+	key := lossKey{handType, total, dealerCard, action}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if ev, ok := t.ev[key]; ok {
+		return ev, true
+	}
+	result, err := Run(t.chart, representativeHand(handType, total), dealerCard, action, t.rounds, rand.New(rand.NewSource(lossSeed)))
+	if err != nil {
+		return 0, false
+	}
+	t.ev[key] = result.EV()
+	return result.EV(), true
+}
@@ -0,0 +1,119 @@
+package simulate
+
+import (
+	"blackjack_trainer/internal/hand"
+	"blackjack_trainer/internal/strategy"
+	"context"
+	"fmt"
+	"math/rand"
+	"runtime"
+	"sync"
+)
+
+// ChunkRounds is how many rounds each RNG stream simulates. Rounds are split
+// into chunks with their own seeded stream, so a parallel run gives the same
+// result however many workers share the chunks.
+const ChunkRounds = 10000
+
+// Options control a parallel simulation.
+type Options struct {
+	// Rounds is how many rounds to simulate for each action; zero means
+	// DefaultRounds.
+	Rounds int
+	// Workers is how many goroutines share the chunks; zero means
+	// runtime.GOMAXPROCS(0).
+	Workers int
+	// Seed seeds the first chunk's stream; chunk i uses Seed+i.
+	Seed int64
+}
+
+func (o Options) rounds() int {
+	if o.Rounds > 0 {
+		return o.Rounds
+	}
+	return DefaultRounds
+}
+
+func (o Options) workers() int {
+	if o.Workers > 0 {
+		return o.Workers
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// RunParallel is Run split across a pool of workers. Each chunk of
+// ChunkRounds rounds draws from its own stream, and chunk results are merged
+// in order, so the result depends only on the seed and round count.
+func RunParallel(ctx context.Context, chart *strategy.StrategyChart, h hand.Hand, dealerCard int, action rune, opts Options) (Result, error) {
+	if err := legal(chart, h, action); err != nil {
+		return Result{}, err
+	}
+	if action == 'P' {
+		action = 'Y'
+	}
+
+	rounds := opts.rounds()
+	chunks := (rounds + ChunkRounds - 1) / ChunkRounds
+	results := make([]Result, chunks)
+
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < opts.workers() && w < chunks; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				n := ChunkRounds
+				if i == chunks-1 {
+					n = rounds - i*ChunkRounds
+				}
+				rng := rand.New(rand.NewSource(opts.Seed + int64(i)))
+				results[i], _ = Run(chart, h, dealerCard, action, n, rng)
+			}
+		}()
+	}
+
+	var err error
+feed:
+	for i := 0; i < chunks; i++ {
+		select {
+		case next <- i:
+		case <-ctx.Done():
+			err = ctx.Err()
+			break feed
+		}
+	}
+	close(next)
+	wg.Wait()
+	if err != nil {
+		return Result{}, err
+	}
+
+	total := Result{Action: action}
+	for _, r := range results {
+		total.add(r)
+	}
+	return total, nil
+}
+
+// CompareParallel is Compare using RunParallel for each action.
+func CompareParallel(ctx context.Context, chart *strategy.StrategyChart, h hand.Hand, dealerCard int, actions []rune, opts Options) ([]Result, error) {
+	var results []Result
+	seen := make(map[rune]bool)
+	for _, action := range actions {
+		if action == 'P' {
+			action = 'Y'
+		}
+		if seen[action] {
+			continue
+		}
+		seen[action] = true
+
+		result, err := RunParallel(ctx, chart, h, dealerCard, action, opts)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", strategy.ActionToString(action), err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
@@ -0,0 +1,313 @@
+// Package simulate estimates how a decision plays out by Monte Carlo
+// simulation.
+//
+// Each round resolves one scenario (a player hand against a dealer up-card)
+// after a chosen first action. Later decisions in the round follow the
+// strategy chart, and the dealer plays by the chart's rules. Cards are drawn
+// from an infinite deck: every rank is equally likely on every draw, with
+// tens four times as likely as the other ranks.
+//
+// Under rules with a hole card the dealer has already checked for
+// blackjack, so rounds where the dealer would have one are not dealt.
+// Without a hole card a dealer blackjack takes every bet, including doubles
+// and splits.
+package simulate
+
+import (
+	"blackjack_trainer/internal/hand"
+	"blackjack_trainer/internal/strategy"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// DefaultRounds is how many rounds are simulated for each action.
+const DefaultRounds = 100000
+
+// Result summarizes the rounds simulated for one action. A round counts as a
+// win, push or loss by its net result, so a split that wins one hand and
+// loses the other is a push.
+type Result struct {
+	Action rune
+	Rounds int
+	Wins   int
+	Pushes int
+	Losses int
+	// Net is the total won or lost, in units of the original bet.
+	Net float64
+
+	sumSquares float64 // of each round's net, for the standard error
+}
+
+// WinRate returns the fraction of rounds won.
+func (r Result) WinRate() float64 { return r.rate(r.Wins) }
+
+// PushRate returns the fraction of rounds pushed.
+func (r Result) PushRate() float64 { return r.rate(r.Pushes) }
+
+// LossRate returns the fraction of rounds lost.
+func (r Result) LossRate() float64 { return r.rate(r.Losses) }
+
+// EV returns the average result per round in units of the original bet.
+func (r Result) EV() float64 {
+	if r.Rounds == 0 {
+		return 0
+	}
+	return r.Net / float64(r.Rounds)
+}
+
+// StdErr returns the standard error of EV.
+func (r Result) StdErr() float64 {
+	if r.Rounds < 2 {
+		return 0
+	}
+	n := float64(r.Rounds)
+	mean := r.Net / n
+	variance := (r.sumSquares/n - mean*mean) * n / (n - 1)
+	return math.Sqrt(variance / n)
+}
+
+// add merges the rounds of another result for the same action.
+func (r *Result) add(other Result) {
+	r.Rounds += other.Rounds
+	r.Wins += other.Wins
+	r.Pushes += other.Pushes
+	r.Losses += other.Losses
+	r.Net += other.Net
+	r.sumSquares += other.sumSquares
+}
+
+func (r Result) rate(n int) float64 {
+	if r.Rounds == 0 {
+		return 0
+	}
+	return float64(n) / float64(r.Rounds)
+}
+
+// String formats the result, e.g. "HIT: win 42.1%, push 8.0%, lose 49.9%, EV -0.078".
+func (r Result) String() string {
+	return fmt.Sprintf("%s: win %.1f%%, push %.1f%%, lose %.1f%%, EV %+.3f",
+		strategy.ActionToString(r.Action), r.WinRate()*100, r.PushRate()*100, r.LossRate()*100, r.EV())
+}
+
+// Legal reports whether an action may be taken as the first action on a hand.
+func Legal(h hand.Hand, action rune) error {
+	switch action {
+	case 'H', 'S':
+		return nil
+	case 'D':
+		if !h.CanDouble() {
+			return fmt.Errorf("only the first two cards can be doubled")
+		}
+		return nil
+	case 'Y', 'P':
+		if !h.CanSplit() {
+			return fmt.Errorf("only pairs can be split")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown action %q", action)
+	}
+}
+
+// legal is Legal under the chart's rules, which may restrict doubling.
+func legal(chart *strategy.StrategyChart, h hand.Hand, action rune) error {
+	if err := Legal(h, action); err != nil {
+		return err
+	}
+	if action == 'D' && chart.Rules().Double == strategy.DoubleNineToEleven {
+		if total := h.Total(); h.IsSoft() || total < 9 || total > 11 {
+			return fmt.Errorf("%s rules only allow doubling on hard 9, 10 and 11", chart.Rules().Name)
+		}
+	}
+	return nil
+}
+
+// Run simulates rounds of a scenario after the first action, using the
+// chart for later decisions and its rules for the dealer.
+func Run(chart *strategy.StrategyChart, h hand.Hand, dealerCard int, action rune, rounds int, rng *rand.Rand) (Result, error) {
+	if err := legal(chart, h, action); err != nil {
+		return Result{}, err
+	}
+	if action == 'P' {
+		action = 'Y'
+	}
+
+	s := round{chart: chart, rules: chart.Rules(), rng: rng}
+	result := Result{Action: action, Rounds: rounds}
+	for i := 0; i < rounds; i++ {
+		net := s.play(h.Cards, dealerCard, action)
+		result.Net += net
+		result.sumSquares += net * net
+		switch {
+		case net > 0:
+			result.Wins++
+		case net < 0:
+			result.Losses++
+		default:
+			result.Pushes++
+		}
+	}
+	return result, nil
+}
+
+// Compare simulates each distinct action in turn, skipping repeats.
+func Compare(chart *strategy.StrategyChart, h hand.Hand, dealerCard int, actions []rune, rounds int, rng *rand.Rand) ([]Result, error) {
+	var results []Result
+	seen := make(map[rune]bool)
+	for _, action := range actions {
+		if action == 'P' {
+			action = 'Y'
+		}
+		if seen[action] {
+			continue
+		}
+		seen[action] = true
+
+		result, err := Run(chart, h, dealerCard, action, rounds, rng)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", strategy.ActionToString(action), err)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// round plays out simulated rounds.
+type round struct {
+	chart *strategy.StrategyChart
+	rules strategy.RuleSet
+	rng   *rand.Rand
+}
+
+// draw returns a card from an infinite deck.
+func (s *round) draw() int {
+	switch r := s.rng.Intn(13); {
+	case r < 8:
+		return r + 2 // 2-9
+	case r < 12:
+		return 10 // 10, J, Q, K
+	default:
+		return hand.Ace
+	}
+}
+
+// play resolves one round and returns the player's net result.
+func (s *round) play(cards []int, dealerCard int, action rune) float64 {
+	hole := s.draw()
+	dealerBlackjack := isBlackjack([]int{dealerCard, hole})
+	for s.rules.HoleCard && dealerBlackjack {
+		hole = s.draw()
+		dealerBlackjack = isBlackjack([]int{dealerCard, hole})
+	}
+
+	// Each finished player hand: its cards and the bet riding on it
+	type finished struct {
+		cards []int
+		bet   float64
+	}
+	var hands []finished
+	split := false
+
+	switch action {
+	case 'Y':
+		split = true
+		for i := 0; i < 2; i++ {
+			splitHand := []int{cards[0], s.draw()}
+			if cards[0] == hand.Ace {
+				hands = append(hands, finished{splitHand, 1}) // split aces get one card
+				continue
+			}
+			played, bet := s.playOut(splitHand, dealerCard, s.rules.DoubleAfterSplit)
+			hands = append(hands, finished{played, bet})
+		}
+	case 'D':
+		hands = append(hands, finished{append(append([]int(nil), cards...), s.draw()), 2})
+	case 'S':
+		hands = append(hands, finished{cards, 1})
+	default: // 'H'
+		played, bet := s.playOut(append(append([]int(nil), cards...), s.draw()), dealerCard, false)
+		hands = append(hands, finished{played, bet})
+	}
+
+	if dealerBlackjack {
+		net := 0.0
+		for _, h := range hands {
+			net -= h.bet
+		}
+		if !split && isBlackjack(cards) {
+			return 0
+		}
+		return net
+	}
+	if !split && len(hands[0].cards) == 2 && isBlackjack(hands[0].cards) {
+		return 1.5
+	}
+
+	dealerTotal := s.dealerTotal(dealerCard, hole)
+	net := 0.0
+	for _, h := range hands {
+		total := hand.Hand{Cards: h.cards}.Total()
+		switch {
+		case total > 21:
+			net -= h.bet
+		case dealerTotal > 21 || total > dealerTotal:
+			net += h.bet
+		case total < dealerTotal:
+			net -= h.bet
+		}
+	}
+	return net
+}
+
+// playOut continues a hand by the chart until it stands, doubles or busts,
+// returning the final cards and the bet on them.
+func (s *round) playOut(cards []int, dealerCard int, canDouble bool) ([]int, float64) {
+	for {
+		h := hand.Hand{Cards: cards}
+		total := h.Total()
+		if total >= 21 {
+			return cards, 1
+		}
+
+		var action rune
+		if h.IsSoft() {
+			action = s.chart.GetCorrectAction(strategy.HandTypeSoft, total, dealerCard)
+		} else {
+			// Pairs after a split are not resplit; play them as totals
+			action = s.chart.GetCorrectAction(strategy.HandTypeHard, total, dealerCard)
+		}
+
+		switch action {
+		case 'S':
+			return cards, 1
+		case 'D':
+			if canDouble && len(cards) == 2 {
+				return append(cards, s.draw()), 2
+			}
+			// Without a double, soft 18 and up stand and everything else hits
+			if h.IsSoft() && total >= 18 {
+				return cards, 1
+			}
+		}
+		cards = append(cards, s.draw())
+		canDouble = false
+	}
+}
+
+// dealerTotal plays the dealer's hand and returns its final total.
+func (s *round) dealerTotal(upCard, hole int) int {
+	cards := []int{upCard, hole}
+	for {
+		h := hand.Hand{Cards: cards}
+		total := h.Total()
+		if total > 17 || total == 17 && !(h.IsSoft() && s.rules.DealerHitsSoft17) {
+			return total
+		}
+		cards = append(cards, s.draw())
+	}
+}
+
+func isBlackjack(cards []int) bool {
+	return len(cards) == 2 && hand.Hand{Cards: cards}.Total() == 21
+}
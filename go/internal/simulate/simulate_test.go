@@ -0,0 +1,181 @@
+package simulate
+
+import (
+	"blackjack_trainer/internal/hand"
+	"blackjack_trainer/internal/strategy"
+	"context"
+	"math/rand"
+	"testing"
+)
+
+const testRounds = 50000
+
+// Test the chart's play beats the alternative in clear-cut scenarios
+func TestRunFavorsCorrectPlay(t *testing.T) {
+	chart := strategy.New()
+	rng := rand.New(rand.NewSource(1))
+
+	tests := []struct {
+		cards  []int
+		dealer int
+		better rune
+		worse  rune
+	}{
+		{[]int{10, 10}, 6, 'S', 'H'},
+		{[]int{6, 5}, 6, 'D', 'H'},
+		{[]int{8, 8}, 10, 'Y', 'S'},
+		{[]int{10, 2}, 10, 'H', 'S'},
+		{[]int{10, 6}, 6, 'S', 'H'},
+	}
+
+	for _, tt := range tests {
+		h := hand.New(tt.cards...)
+		better, err := Run(chart, h, tt.dealer, tt.better, testRounds, rng)
+		if err != nil {
+			t.Fatal(err)
+		}
+		worse, err := Run(chart, h, tt.dealer, tt.worse, testRounds, rng)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if better.EV() <= worse.EV() {
+			t.Errorf("%v vs %d: expected %s to beat %s", tt.cards, tt.dealer, better, worse)
+		}
+	}
+}
+
+// Test results account for every round and known expectations
+func TestResultRates(t *testing.T) {
+	chart := strategy.New()
+	rng := rand.New(rand.NewSource(2))
+
+	result, err := Run(chart, hand.New(10, 10), 6, 'S', testRounds, rng)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Wins+result.Pushes+result.Losses != testRounds {
+		t.Errorf("Outcomes should cover every round: %+v", result)
+	}
+	if result.WinRate() < 0.6 || result.LossRate() > 0.3 {
+		t.Errorf("Standing on 20 vs 6 should win most rounds: %s", result)
+	}
+
+	// Doubling always busts or stands; a stand on 21 never loses with a hole card
+	doubled, _ := Run(chart, hand.New(10, 10), 6, 'D', 1000, rng)
+	if doubled.Net == 0 || doubled.EV() < -2 || doubled.EV() > 2 {
+		t.Errorf("Doubled bets should win or lose two units: %s", doubled)
+	}
+}
+
+// Test illegal first actions are rejected
+func TestLegal(t *testing.T) {
+	chart := strategy.New()
+	rng := rand.New(rand.NewSource(3))
+
+	if _, err := Run(chart, hand.New(10, 6), 10, 'Y', 10, rng); err == nil {
+		t.Error("Splitting a non-pair should be rejected")
+	}
+	if _, err := Run(chart, hand.New(5, 4, 3), 10, 'D', 10, rng); err == nil {
+		t.Error("Doubling three cards should be rejected")
+	}
+
+	rules, err := strategy.LookupRules("european")
+	if err != nil {
+		t.Fatal(err)
+	}
+	european := strategy.NewForRules(rules)
+	if _, err := Run(european, hand.New(hand.Ace, 7), 6, 'D', 10, rng); err == nil {
+		t.Error("Doubling soft 18 should be rejected where only 9-11 may be doubled")
+	}
+
+	results, err := Compare(chart, hand.New(8, 8), 10, []rune{'P', 'Y', 'S'}, 100, rng)
+	if err != nil || len(results) != 2 || results[0].Action != 'Y' {
+		t.Errorf("Compare should merge P and Y: %v, %v", results, err)
+	}
+}
+
+// Test parallel runs depend only on the seed, not the number of workers
+func TestRunParallel(t *testing.T) {
+	chart := strategy.New()
+	h := hand.New(10, 2)
+	opts := Options{Rounds: 3*ChunkRounds + 123, Seed: 7}
+
+	var results []Result
+	for _, workers := range []int{1, 4} {
+		opts.Workers = workers
+		result, err := RunParallel(context.Background(), chart, h, 4, 'H', opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Rounds != opts.Rounds || result.Wins+result.Pushes+result.Losses != opts.Rounds {
+			t.Errorf("%d workers: outcomes should cover every round: %+v", workers, result)
+		}
+		results = append(results, result)
+	}
+	if results[0] != results[1] {
+		t.Errorf("Results should not depend on workers: %+v vs %+v", results[0], results[1])
+	}
+	if results[0].StdErr() <= 0 || results[0].StdErr() > 0.02 {
+		t.Errorf("Unexpected standard error %f", results[0].StdErr())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := RunParallel(ctx, chart, h, 4, 'H', Options{Rounds: 10 * ChunkRounds, Workers: 1}); err == nil {
+		t.Error("A cancelled run should return an error")
+	}
+}
+
+// Test the full-chart check covers every cell and agrees on clear-cut plays
+func TestCheckChart(t *testing.T) {
+	chart := strategy.New()
+	cells, err := CheckChart(context.Background(), chart, Options{Rounds: 2000, Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (16 + 8 + 10) * 10; len(cells) != want {
+		t.Fatalf("Expected %d cells, got %d", want, len(cells))
+	}
+
+	for _, cell := range cells {
+		if _, ok := cell.Result(cell.Chart); !ok {
+			t.Errorf("%s: chart action %c was not simulated", strategy.CellLabel(cell.HandType, cell.PlayerTotal, cell.DealerCard), cell.Chart)
+		}
+		if cell.HandType == strategy.HandTypeHard && cell.PlayerTotal == 20 && cell.Best().Action != 'S' {
+			t.Errorf("Hard 20 vs %d: expected stand to be best, got %s", cell.DealerCard, cell.Best())
+		}
+	}
+}
+
+// Test the loss table prices big mistakes above small ones and charges nothing for the chart's play
+func TestLossTable(t *testing.T) {
+	table := NewLossTable(strategy.New(), 0)
+	standOn11 := table.Loss(hand.New(6, 5), 6, 'S')
+	hitHard20 := table.Loss(hand.New(10, 10), 6, 'H')
+	closeCall := table.Loss(hand.New(10, 6), 10, 'S')
+
+	if table.Loss(hand.New(6, 5), 6, 'D') != 0 {
+		t.Error("The chart's play should cost nothing")
+	}
+	if table.Loss(hand.New(10, 6), 10, 'Y') != 0 {
+		t.Error("A split that can't be made should cost nothing")
+	}
+	if standOn11 < 0.5 || hitHard20 < 0.5 {
+		t.Errorf("Standing on 11 vs 6 (%.3f) and hitting 20 (%.3f) should cost over half a bet", standOn11, hitHard20)
+	}
+	if closeCall > 0.1 {
+		t.Errorf("Standing on 16 vs 10 should be a close call, cost %.3f", closeCall)
+	}
+	if again := table.Loss(hand.New(6, 5), 6, 'S'); again != standOn11 {
+		t.Errorf("The same mistake should cost the same, got %.3f and %.3f", standOn11, again)
+	}
+
+	double, _ := table.EV(hand.New(6, 5), 6, 'D')
+	stand, _ := table.EV(hand.New(6, 5), 6, 'S')
+	if double-stand != standOn11 {
+		t.Errorf("EVs %.3f and %.3f should differ by the loss %.3f", double, stand, standOn11)
+	}
+	if _, ok := table.EV(hand.New(10, 6), 10, 'Y'); ok {
+		t.Error("A split that can't be made should have no EV")
+	}
+}
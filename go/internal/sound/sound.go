@@ -0,0 +1,152 @@
+// Package sound provides optional audio cues for answers, so a player can
+// drill without watching the screen.
+//
+// Cues are played through the Beeper interface so the trainer does not
+// depend on any particular backend. Two backends are provided:
+// - Bell: Rings the terminal bell, more times for bigger news
+// - CommandBeeper: Plays a sound file per cue (afplay, paplay or aplay)
+//
+// Cues are played asynchronously so the prompt is never blocked.
+package sound
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// Cue is an event that can be heard.
+type Cue int
+
+const (
+	// Correct is played for a correct answer.
+	Correct Cue = iota
+	// Incorrect is played for a wrong answer.
+	Incorrect
+	// Milestone is played instead of Correct when a correct answer reaches
+	// a streak milestone.
+	Milestone
+)
+
+// String returns the cue's name, as used in the configuration.
+func (c Cue) String() string {
+	switch c {
+	case Correct:
+		return "correct"
+	case Incorrect:
+		return "incorrect"
+	case Milestone:
+		return "milestone"
+	default:
+		return "unknown"
+	}
+}
+
+// DefaultStreakEvery is how many correct answers in a row make a streak
+// milestone unless configured otherwise.
+const DefaultStreakEvery = 5
+
+// AnswerCue returns the cue for an answer: Milestone for a correct answer
+// that brings the streak to a multiple of every, otherwise Correct or
+// Incorrect. A zero or negative every means no milestones.
+func AnswerCue(correct bool, streak, every int) Cue {
+	switch {
+	case !correct:
+		return Incorrect
+	case every > 0 && streak > 0 && streak%every == 0:
+		return Milestone
+	default:
+		return Correct
+	}
+}
+
+// Beeper plays audio cues.
+type Beeper interface {
+	// Beep starts playing the cue and returns without waiting for it to finish.
+	Beep(cue Cue) error
+}
+
+// bellGap is the pause between rings of a cue, so they are heard apart.
+const bellGap = 150 * time.Millisecond
+
+// Bell rings the terminal bell by writing BEL characters: once for Correct,
+// twice for Incorrect and three times for Milestone.
+type Bell struct {
+	mu  sync.Mutex
+	w   io.Writer
+	gap time.Duration
+}
+
+// NewBell creates a bell that rings on the terminal written to by w.
+func NewBell(w io.Writer) *Bell {
+	return &Bell{w: w, gap: bellGap}
+}
+
+// Beep rings the bell for the cue.
+func (b *Bell) Beep(cue Cue) error {
+	rings := map[Cue]int{Correct: 1, Incorrect: 2, Milestone: 3}[cue]
+	if rings == 0 {
+		return fmt.Errorf("unknown cue %d", cue)
+	}
+	go func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i := 0; i < rings; i++ {
+			if i > 0 {
+				time.Sleep(b.gap)
+			}
+			io.WriteString(b.w, "\a")
+		}
+	}()
+	return nil
+}
+
+// ErrNoPlayer is returned when no supported sound player is installed.
+var ErrNoPlayer = errors.New("no sound player found (tried afplay, paplay, aplay)")
+
+// systemPlayers lists the supported sound players in order of preference.
+var systemPlayers = []string{"afplay", "paplay", "aplay"}
+
+// CommandBeeper plays a sound file for each cue by running a player command
+// with the file as its final argument. Cues without a file are silent.
+type CommandBeeper struct {
+	path  string
+	files map[Cue]string
+}
+
+// NewCommandBeeper creates a beeper that plays files with the given command.
+func NewCommandBeeper(path string, files map[Cue]string) *CommandBeeper {
+	return &CommandBeeper{path: path, files: files}
+}
+
+// NewSystemBeeper creates a beeper that plays files with the first
+// available system player.
+func NewSystemBeeper(files map[Cue]string) (*CommandBeeper, error) {
+	for _, name := range systemPlayers {
+		if path, err := exec.LookPath(name); err == nil {
+			return NewCommandBeeper(path, files), nil
+		}
+	}
+	return nil, ErrNoPlayer
+}
+
+// Beep starts playing the cue's sound file, if it has one.
+func (b *CommandBeeper) Beep(cue Cue) error {
+	file := b.files[cue]
+	if cue == Milestone && file == "" {
+		file = b.files[Correct]
+	}
+	if file == "" {
+		return nil
+	}
+	cmd := exec.Command(b.path, file)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("starting %s: %w", b.path, err)
+	}
+	// Reap the process when it finishes so it does not linger
+	go cmd.Wait()
+	return nil
+}
@@ -0,0 +1,106 @@
+package sound
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer collects writes from the bell's goroutine.
+type syncBuffer struct {
+	mu sync.Mutex
+	b  strings.Builder
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.Write(p)
+}
+
+func (s *syncBuffer) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.b.String()
+}
+
+// Test milestones replace the correct cue at multiples of the streak interval
+func TestAnswerCue(t *testing.T) {
+	tests := []struct {
+		correct       bool
+		streak, every int
+		want          Cue
+	}{
+		{true, 1, 5, Correct},
+		{true, 5, 5, Milestone},
+		{true, 10, 5, Milestone},
+		{false, 0, 5, Incorrect},
+		{true, 5, 0, Correct},
+		{true, 5, -1, Correct},
+	}
+	for _, tt := range tests {
+		if got := AnswerCue(tt.correct, tt.streak, tt.every); got != tt.want {
+			t.Errorf("AnswerCue(%v, %d, %d) = %s, want %s", tt.correct, tt.streak, tt.every, got, tt.want)
+		}
+	}
+}
+
+// Test the bell rings once, twice or three times by cue
+func TestBell(t *testing.T) {
+	for cue, rings := range map[Cue]int{Correct: 1, Incorrect: 2, Milestone: 3} {
+		var out syncBuffer
+		bell := NewBell(&out)
+		bell.gap = time.Millisecond
+		if err := bell.Beep(cue); err != nil {
+			t.Fatal(err)
+		}
+		deadline := time.Now().Add(time.Second)
+		for out.String() != strings.Repeat("\a", rings) && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+		if got := out.String(); got != strings.Repeat("\a", rings) {
+			t.Errorf("%s cue rang %q, want %d bell(s)", cue, got, rings)
+		}
+	}
+	if err := NewBell(&syncBuffer{}).Beep(Cue(9)); err == nil {
+		t.Error("An unknown cue should be an error")
+	}
+}
+
+// Test the command beeper plays each cue's file, falling back to the correct sound for milestones
+func TestCommandBeeper(t *testing.T) {
+	dir := t.TempDir()
+	played := filepath.Join(dir, "played")
+	player := filepath.Join(dir, "player")
+	script := "#!/bin/sh\necho \"$1\" >> " + played + "\n"
+	if err := os.WriteFile(player, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	beeper := NewCommandBeeper(player, map[Cue]string{Correct: "ding.wav", Incorrect: "buzz.wav"})
+	for _, cue := range []Cue{Correct, Incorrect, Milestone} {
+		if err := beeper.Beep(cue); err != nil {
+			t.Fatal(err)
+		}
+		// Wait for each file so the order is kept
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			data, _ := os.ReadFile(played)
+			if strings.Count(string(data), "\n") > int(cue) {
+				break
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	data, _ := os.ReadFile(played)
+	if got := string(data); got != "ding.wav\nbuzz.wav\nding.wav\n" {
+		t.Errorf("Played %q", got)
+	}
+
+	if err := NewCommandBeeper(player, nil).Beep(Correct); err != nil {
+		t.Errorf("A cue without a file should be silent, got %v", err)
+	}
+}
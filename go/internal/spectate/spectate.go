@@ -0,0 +1,507 @@
+// Package spectate lets others watch a training session live over the
+// network, read-only, such as a coach observing a student remotely. The
+// student's trainer serves each question and answer as it happens; a
+// spectator follows along in a browser, or in a second terminal with the
+// watch command.
+//
+// The server publishes over HTTP:
+// - /events: the session's events as a stream of server-sent events
+// - /: a page that shows the stream in a browser
+// - /notes: a POST of a short note for the student, such as a coach's
+// advice, shown with the current question's feedback
+//
+// All need the key in the spectator link, so only those given the link
+// can watch. Spectators can't answer or change anything in the session;
+// notes are all they can send.
+package spectate
+
+import (
+	"blackjack_trainer/internal/strategy"
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Event types.
+const (
+	// Started is sent when a session begins.
+	Started = "started"
+	// Question is sent when a question is dealt, before it's answered.
+	Question = "question"
+	// Answer is sent when a question is answered.
+	Answer = "answer"
+	// Skipped is sent when a question is skipped.
+	Skipped = "skipped"
+	// Corrected is sent when an answer is taken back as a slip.
+	Corrected = "corrected"
+	// Ended is sent when a session ends.
+	Ended = "ended"
+	// Note is sent when a spectator sends the student a note.
+	Note = "note"
+)
+
+// MaxNote is the longest note a spectator can send, in characters.
+const MaxNote = 280
+
+// noteQueue is how many notes can wait for the student to see them.
+const noteQueue = 16
+
+// Event is something that happened in the watched session.
+type Event struct {
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	// Mode and Rules describe the session, for Started.
+	Mode  string `json:"mode,omitempty"`
+	Rules string `json:"rules,omitempty"`
+	// Number is the question's number in the session, and Questions the
+	// session's length, zero if it runs until a time limit or goal.
+	Number    int `json:"number,omitempty"`
+	Questions int `json:"questions,omitempty"`
+	// Cards and DealerCard are the question asked, and Label its chart
+	// cell, e.g. "Hard 16 vs 10".
+	Cards      []int  `json:"cards,omitempty"`
+	DealerCard int    `json:"dealer_card,omitempty"`
+	Label      string `json:"label,omitempty"`
+	// Action is the player's answer and CorrectAction the chart's, as
+	// letters: H, S, D or Y.
+	Action        string `json:"action,omitempty"`
+	CorrectAction string `json:"correct_action,omitempty"`
+	Correct       bool   `json:"correct"`
+	Explanation   string `json:"explanation,omitempty"`
+	// Note is the text of a Note, which belongs to question Number.
+	Note string `json:"note,omitempty"`
+	// Score and Answered are the session's correct and scored answers so
+	// far.
+	Score    int `json:"score"`
+	Answered int `json:"answered"`
+	// Text is the event as spectators read it, from String.
+	Text string `json:"text"`
+}
+
+// String describes the event as spectators read it.
+func (e Event) String() string {
+	switch e.Type {
+	case Started:
+		length := "open-ended"
+		if e.Questions > 0 {
+			length = fmt.Sprintf("%d questions", e.Questions)
+		}
+		return fmt.Sprintf("== %s (%s, %s) ==", e.Mode, e.Rules, length)
+	case Question:
+		cards := make([]string, len(e.Cards))
+		for i, card := range e.Cards {
+			cards[i] = strategy.CardToString(card)
+		}
+		return fmt.Sprintf("Q%d: %s vs %s (%s)", e.Number, strings.Join(cards, ","), strategy.CardToString(e.DealerCard), e.Label)
+	case Answer:
+		result := "correct"
+		if !e.Correct {
+			result = "wrong, the play is " + strategy.ActionToString(rune(e.CorrectAction[0]))
+		}
+		text := fmt.Sprintf("    %s: %s (%d/%d)", strategy.ActionToString(rune(e.Action[0])), result, e.Score, e.Answered)
+		if !e.Correct && e.Explanation != "" {
+			text += "\n    " + strings.ReplaceAll(e.Explanation, "\n", "\n    ")
+		}
+		return text
+	case Skipped:
+		return "    Skipped"
+	case Corrected:
+		return "    Taken back as a slip; it will be asked again"
+	case Note:
+		return "    Coach: " + e.Note
+	case Ended:
+		if e.Answered == 0 {
+			return "== Session ended with no answers =="
+		}
+		return fmt.Sprintf("== Session ended: %d/%d (%.1f%%) ==", e.Score, e.Answered, float64(e.Score)/float64(e.Answered)*100)
+	}
+	return e.Type
+}
+
+// maxBacklog bounds the events kept for spectators who join mid-session.
+const maxBacklog = 500
+
+// keepAlive is how often an idle stream is sent a comment, so proxies
+// don't close it.
+const keepAlive = 15 * time.Second
+
+// Server publishes a session's events to spectators.
+type Server struct {
+	addr string
+	key  string
+
+	mu sync.Mutex
+	// backlog holds the events since the current session started, sent to
+	// each spectator when they join
+	backlog  []Event
+	watchers map[chan Event]bool
+	// running is set between a session's Started and Ended events, and
+	// question is the number of its current question
+	running  bool
+	question int
+	notes    chan string
+}
+
+// Listen starts serving on addr, a host:port address such as
+// "0.0.0.0:8092", and returns once the address is open.
+func Listen(addr string) (*Server, error) {
+	key := make([]byte, 8)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{addr: listener.Addr().String(), key: hex.EncodeToString(key),
+		watchers: make(map[chan Event]bool), notes: make(chan string, noteQueue)}
+	server := &http.Server{Handler: s, ReadHeaderTimeout: 10 * time.Second}
+	go server.Serve(listener)
+	return s, nil
+}
+
+// Publish sends an event to every spectator. A Started event begins a new
+// backlog. Publishing to a nil Server does nothing, so sessions without
+// spectators needn't check.
+func (s *Server) Publish(e Event) {
+	if s == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	e.Text = e.String()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch e.Type {
+	case Started:
+		s.backlog, s.running, s.question = nil, true, 0
+	case Question:
+		s.question = e.Number
+	case Ended:
+		s.running = false
+	}
+	if len(s.backlog) == maxBacklog {
+		s.backlog = s.backlog[1:]
+	}
+	s.backlog = append(s.backlog, e)
+	for events := range s.watchers {
+		select {
+		case events <- e:
+		default:
+			// A spectator too slow to keep up is dropped rather than
+			// holding up the session; they can reconnect
+			delete(s.watchers, events)
+			close(events)
+		}
+	}
+}
+
+// Notes returns the notes spectators send, for showing to the student.
+func (s *Server) Notes() <-chan string {
+	return s.notes
+}
+
+// Subscribe returns the current session's events so far and a channel of
+// those that follow, until stop is called or the spectator falls behind
+// and the channel is closed.
+func (s *Server) Subscribe() (backlog []Event, events <-chan Event, stop func()) {
+	ch := make(chan Event, 64)
+	s.mu.Lock()
+	backlog = append(backlog, s.backlog...)
+	s.watchers[ch] = true
+	s.mu.Unlock()
+	stop = func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.watchers[ch] {
+			delete(s.watchers, ch)
+			close(ch)
+		}
+	}
+	return backlog, ch, stop
+}
+
+// ServeHTTP serves the page at / and the event stream at /events, and
+// takes notes at /notes, for requests with the key.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	method := http.MethodGet
+	switch r.URL.Path {
+	case "/", "/events":
+	case "/notes":
+		method = http.MethodPost
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		http.Error(w, "method not allowed; spectators can only watch and send notes", http.StatusMethodNotAllowed)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("key")), []byte(s.key)) != 1 {
+		http.Error(w, "missing or wrong spectator key", http.StatusForbidden)
+		return
+	}
+	switch r.URL.Path {
+	case "/":
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, page, html.EscapeString(s.key), html.EscapeString(s.key))
+	case "/events":
+		s.stream(w, r)
+	case "/notes":
+		s.takeNote(w, r)
+	}
+}
+
+// takeNote queues a note, sent as the request's plain text body, for the
+// student. Runs of white space become single spaces, and notes with other
+// control characters are refused, since the note is printed on the
+// student's terminal and those could drive it.
+func (s *Server) takeNote(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 4*MaxNote+1))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	note := strings.Join(strings.Fields(string(body)), " ")
+	if note == "" || utf8.RuneCountInString(note) > MaxNote {
+		http.Error(w, fmt.Sprintf("a note must be 1-%d characters", MaxNote), http.StatusBadRequest)
+		return
+	}
+	if strings.IndexFunc(note, unicode.IsControl) >= 0 {
+		http.Error(w, "a note can't contain control characters", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	running, question := s.running, s.question
+	s.mu.Unlock()
+	if !running {
+		http.Error(w, "no session is running", http.StatusConflict)
+		return
+	}
+	select {
+	case s.notes <- note:
+	default:
+		http.Error(w, "too many notes waiting; try again after the student's next answer", http.StatusServiceUnavailable)
+		return
+	}
+	s.Publish(Event{Type: Note, Number: question, Note: note})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// stream sends the events as server-sent events until the spectator goes
+// away.
+func (s *Server) stream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+
+	backlog, events, stop := s.Subscribe()
+	defer stop()
+	send := func(e Event) error {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+		return err
+	}
+	for _, e := range backlog {
+		if send(e) != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-events:
+			if !ok || send(e) != nil {
+				return
+			}
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+// URL returns the spectator link: the page, with the key.
+func (s *Server) URL() string {
+	return "http://" + s.addr + "/?key=" + s.key
+}
+
+func (s *Server) String() string {
+	return s.URL()
+}
+
+// page shows the event stream in a browser, newest last.
+const page = `<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Blackjack Trainer Spectator</title>
+<style>body{font-family:monospace;background:#0b5d2e;color:#f0e68c;margin:1em}
+pre{white-space:pre-wrap;margin:0}.wrong{color:#ffb0a0}.status{color:#ccc}</style></head>
+<body><div id="log"></div><p class="status" id="status">Connecting...</p>
+<form id="note"><input id="text" maxlength="280" size="60" placeholder="Note for the student">
+<button>Send</button></form>
+<script>
+var log = document.getElementById("log"), status = document.getElementById("status");
+var key = "%s", text = document.getElementById("text");
+document.getElementById("note").onsubmit = function (event) {
+  event.preventDefault();
+  fetch("notes?key=" + key, {method: "POST", body: text.value}).then(function (response) {
+    if (response.ok) { text.value = ""; return; }
+    return response.text().then(function (message) { status.textContent = "Note not sent: " + message; });
+  });
+};
+var source = new EventSource("events?key=%s");
+source.onopen = function () { status.textContent = "Watching live (read-only)"; };
+source.onerror = function () { status.textContent = "Disconnected; retrying..."; };
+source.onmessage = function (message) {
+  var e = JSON.parse(message.data);
+  if (e.type === "started") { log.innerHTML = ""; }
+  var line = document.createElement("pre");
+  line.textContent = e.text;
+  if (e.type === "answer" && !e.correct) { line.className = "wrong"; }
+  log.appendChild(line);
+  window.scrollTo(0, document.body.scrollHeight);
+};
+</script></body></html>
+`
+
+// SendNote sends a note to the student of the session at a spectator link.
+func SendNote(ctx context.Context, link, note string) error {
+	u, err := parseLink(link)
+	if err != nil {
+		return err
+	}
+	u.Path = "/notes"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), strings.NewReader(note))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return errors.New(strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// parseLink checks a spectator link.
+func parseLink(link string) (*url.URL, error) {
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Query().Get("key") == "" {
+		return nil, fmt.Errorf("%q is not a spectator link like http://host:port/?key=...", link)
+	}
+	return u, nil
+}
+
+// retryDelay is how long Watch waits before reconnecting after losing
+// the session.
+var retryDelay = 2 * time.Second
+
+// Watch follows the session at a spectator link, writing each event to w
+// as it happens, until ctx is done. If the connection drops, such as when
+// the student restarts the trainer, it reconnects; it returns an error if
+// the first connection fails.
+func Watch(ctx context.Context, link string, w io.Writer) error {
+	u, err := parseLink(link)
+	if err != nil {
+		return err
+	}
+	u.Path = "/events"
+
+	connected := false
+	for {
+		err := follow(ctx, u.String(), w, func() { connected = true })
+		if ctx.Err() != nil {
+			return nil
+		}
+		if !connected {
+			return err
+		}
+		fmt.Fprintf(w, "Connection lost (%v); reconnecting...\n", err)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(retryDelay):
+		}
+	}
+}
+
+// errClosed reports the trainer closing the stream.
+var errClosed = errors.New("stream closed")
+
+// follow reads the event stream once, calling opened when it connects.
+// Events from before the connection are replayed, so after a reconnect
+// the session is shown again from its start.
+func follow(ctx context.Context, eventsURL string, w io.Writer, opened func()) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, eventsURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	opened()
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var e Event
+		if err := json.Unmarshal([]byte(data), &e); err != nil {
+			return fmt.Errorf("reading event: %w", err)
+		}
+		if e.Type == Started {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintln(w, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return errClosed
+}
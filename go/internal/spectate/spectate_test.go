@@ -0,0 +1,182 @@
+package spectate
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lockedBuffer is a buffer Watch can write while the test reads it.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// waitFor waits for the buffer to contain want.
+func waitFor(t *testing.T, b *lockedBuffer, want string) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if strings.Contains(b.String(), want) {
+			return
+		}
+	}
+	t.Fatalf("Timed out waiting for %q in:\n%s", want, b.String())
+}
+
+// Test each event reads as a spectator sees it
+func TestEventString(t *testing.T) {
+	tests := []struct {
+		event Event
+		want  string
+	}{
+		{Event{Type: Started, Mode: "Random Practice", Rules: "Standard", Questions: 20}, "== Random Practice (Standard, 20 questions) =="},
+		{Event{Type: Started, Mode: "Random Practice", Rules: "Standard"}, "== Random Practice (Standard, open-ended) =="},
+		{Event{Type: Question, Number: 3, Cards: []int{10, 6}, DealerCard: 11, Label: "Hard 16 vs A"}, "Q3: 10,6 vs A (Hard 16 vs A)"},
+		{Event{Type: Answer, Action: "S", CorrectAction: "S", Correct: true, Score: 3, Answered: 3}, "    STAND: correct (3/3)"},
+		{Event{Type: Answer, Action: "S", CorrectAction: "H", Explanation: "Hit 16 against a 10.\nSurrender if allowed.", Score: 2, Answered: 4},
+			"    STAND: wrong, the play is HIT (2/4)\n    Hit 16 against a 10.\n    Surrender if allowed."},
+		{Event{Type: Note, Number: 4, Note: "Think about the dealer's bust chance"}, "    Coach: Think about the dealer's bust chance"},
+		{Event{Type: Ended, Score: 9, Answered: 10}, "== Session ended: 9/10 (90.0%) =="},
+	}
+	for _, test := range tests {
+		if got := test.event.String(); got != test.want {
+			t.Errorf("%s event = %q, want %q", test.event.Type, got, test.want)
+		}
+	}
+}
+
+// Test a spectator joining mid-session is shown the session so far, then
+// follows it live, and a new session starts a new backlog
+func TestWatch(t *testing.T) {
+	s, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Publish(Event{Type: Started, Mode: "Old Session", Rules: "Standard"})
+	s.Publish(Event{Type: Started, Mode: "Random Practice", Rules: "Standard", Questions: 2})
+	s.Publish(Event{Type: Question, Number: 1, Cards: []int{10, 6}, DealerCard: 10, Label: "Hard 16 vs 10"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var out lockedBuffer
+	done := make(chan error)
+	go func() { done <- Watch(ctx, s.URL(), &out) }()
+	waitFor(t, &out, "Q1: 10,6 vs 10")
+	if strings.Contains(out.String(), "Old Session") {
+		t.Errorf("A new session should start a new backlog:\n%s", out.String())
+	}
+
+	s.Publish(Event{Type: Answer, Number: 1, Action: "H", CorrectAction: "H", Correct: true, Score: 1, Answered: 1})
+	s.Publish(Event{Type: Ended, Score: 1, Answered: 1})
+	waitFor(t, &out, "== Session ended: 1/1 (100.0%) ==")
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Watch returned %v after cancelling", err)
+	}
+	if !strings.Contains(out.String(), "    HIT: correct (1/1)") {
+		t.Errorf("Missing the answer:\n%s", out.String())
+	}
+}
+
+// Test notes reach the student while a session runs, tidied and tied to
+// the current question, and spectators see them too
+func TestNotes(t *testing.T) {
+	s, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := SendNote(ctx, s.URL(), "Too early"); err == nil || !strings.Contains(err.Error(), "no session is running") {
+		t.Errorf("Note before the session = %v", err)
+	}
+
+	s.Publish(Event{Type: Started, Mode: "Random Practice", Rules: "Standard", Questions: 20})
+	s.Publish(Event{Type: Question, Number: 2, Cards: []int{10, 6}, DealerCard: 10})
+	if err := SendNote(ctx, s.URL(), "  Surrender\nif you can  "); err != nil {
+		t.Fatal(err)
+	}
+	if note := <-s.Notes(); note != "Surrender if you can" {
+		t.Errorf("Note = %q", note)
+	}
+	backlog, _, stop := s.Subscribe()
+	stop()
+	if last := backlog[len(backlog)-1]; last.Type != Note || last.Number != 2 || last.Note != "Surrender if you can" {
+		t.Errorf("Spectators should see the note for question 2, got %+v", last)
+	}
+
+	for _, bad := range []string{" ", strings.Repeat("x", MaxNote+1)} {
+		if err := SendNote(ctx, s.URL(), bad); err == nil || !strings.Contains(err.Error(), "1-280 characters") {
+			t.Errorf("Note of %d characters = %v", len(bad), err)
+		}
+	}
+	for _, bad := range []string{"Stand\x1b[2J", "Hit\x07", "Split\u009b31m"} {
+		if err := SendNote(ctx, s.URL(), bad); err == nil || !strings.Contains(err.Error(), "control characters") {
+			t.Errorf("Note %q = %v", bad, err)
+		}
+	}
+	for i := 0; i < noteQueue; i++ {
+		SendNote(ctx, s.URL(), "Again")
+	}
+	if err := SendNote(ctx, s.URL(), "One too many"); err == nil || !strings.Contains(err.Error(), "too many notes") {
+		t.Errorf("Note past the queue = %v", err)
+	}
+}
+
+// Test watching needs the key and notes are all spectators can send
+func TestAccess(t *testing.T) {
+	s, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, _ := url.Parse(s.URL())
+	key := page.Query().Get("key")
+	base := "http://" + page.Host
+
+	tests := []struct {
+		method, path string
+		want         int
+	}{
+		{http.MethodGet, "/?key=" + key, http.StatusOK},
+		{http.MethodGet, "/", http.StatusForbidden},
+		{http.MethodGet, "/events?key=wrong", http.StatusForbidden},
+		{http.MethodPost, "/events?key=" + key, http.StatusMethodNotAllowed},
+		{http.MethodGet, "/notes?key=" + key, http.StatusMethodNotAllowed},
+		{http.MethodPost, "/notes?key=wrong", http.StatusForbidden},
+		{http.MethodGet, "/answer?key=" + key, http.StatusNotFound},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest(test.method, base+test.path, nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != test.want {
+			t.Errorf("%s %s = %d, want %d", test.method, test.path, resp.StatusCode, test.want)
+		}
+	}
+
+	wrong := base + "/?key=wrong"
+	if err := Watch(context.Background(), wrong, &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Watch with the wrong key = %v, want 403", err)
+	}
+	if err := Watch(context.Background(), base+"/", &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "not a spectator link") {
+		t.Errorf("Watch without a key = %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+// Package speech provides optional text-to-speech announcements.
+//
+// Speech is delivered through the Speaker interface so the trainer does not
+// depend on any particular backend. The default backend shells out to a
+// system text-to-speech command:
+// - say: Built into macOS
+// - espeak-ng / espeak: Commonly available on Linux
+//
+// Announcements are spoken asynchronously so the prompt is never blocked; a
+// new announcement interrupts one that is still playing.
+package speech
+
+import (
+	"blackjack_trainer/internal/hand"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// Speaker reads text aloud.
+type Speaker interface {
+	// Speak starts reading the text aloud and returns without waiting for it to finish.
+	Speak(text string) error
+}
+
+// ErrNoBackend is returned when no supported text-to-speech command is installed.
+var ErrNoBackend = errors.New("no text-to-speech command found (tried say, espeak-ng, espeak)")
+
+// systemCommands lists the supported text-to-speech commands in order of preference.
+var systemCommands = []string{"say", "espeak-ng", "espeak"}
+
+// CommandSpeaker speaks by running an external command with the text as its final argument.
+type CommandSpeaker struct {
+	path    string
+	args    []string
+	mu      sync.Mutex
+	current *exec.Cmd
+}
+
+// NewCommandSpeaker creates a speaker that runs the given command.
+func NewCommandSpeaker(path string, args ...string) *CommandSpeaker {
+	return &CommandSpeaker{path: path, args: args}
+}
+
+// NewSystemSpeaker creates a speaker using the first available system command.
+func NewSystemSpeaker() (*CommandSpeaker, error) {
+	for _, name := range systemCommands {
+		if path, err := exec.LookPath(name); err == nil {
+			return NewCommandSpeaker(path), nil
+		}
+	}
+	return nil, ErrNoBackend
+}
+
+// Speak starts reading the text aloud, interrupting any announcement in progress.
+func (s *CommandSpeaker) Speak(text string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.current != nil && s.current.Process != nil {
+		s.current.Process.Kill()
+	}
+
+	args := append(append([]string(nil), s.args...), text)
+	cmd := exec.Command(s.path, args...)
+	if err := cmd.Start(); err != nil {
+		s.current = nil
+		return fmt.Errorf("starting %s: %w", s.path, err)
+	}
+	s.current = cmd
+
+	// Reap the process when it finishes so it does not linger
+	go cmd.Wait()
+	return nil
+}
+
+// DescribeScenario returns the spoken description of a scenario.
+func DescribeScenario(playerHand hand.Hand, dealerCard int) string {
+	cards := make([]string, len(playerHand.Cards))
+	for i, card := range playerHand.Cards {
+		cards[i] = CardWord(card)
+	}
+
+	var handDesc string
+	switch {
+	case playerHand.IsPair():
+		handDesc = fmt.Sprintf("pair of %ss", CardWord(playerHand.PairCard()))
+	case playerHand.IsSoft():
+		handDesc = fmt.Sprintf("soft %d", playerHand.Total())
+	default:
+		handDesc = fmt.Sprintf("hard %d", playerHand.Total())
+	}
+
+	return fmt.Sprintf("Dealer shows %s. You have %s. %s.",
+		CardWord(dealerCard), strings.Join(cards, ", "), handDesc)
+}
+
+// DescribeResult returns the spoken feedback for an answer.
+func DescribeResult(correct bool, correctAction string) string {
+	if correct {
+		return "Correct."
+	}
+	return fmt.Sprintf("Incorrect. The answer is %s.", strings.ToLower(correctAction))
+}
+
+// CardWord returns the spoken name of a card value.
+func CardWord(card int) string {
+	if card == hand.Ace {
+		return "ace"
+	}
+	return fmt.Sprintf("%d", card)
+}
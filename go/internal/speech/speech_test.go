@@ -0,0 +1,57 @@
+package speech
+
+import (
+	"blackjack_trainer/internal/hand"
+	"os/exec"
+	"testing"
+)
+
+// Test scenario descriptions name the dealer card, cards, and hand type
+func TestDescribeScenario(t *testing.T) {
+	tests := []struct {
+		cards  []int
+		dealer int
+		want   string
+	}{
+		{[]int{11, 7}, 6, "Dealer shows 6. You have ace, 7. soft 18."},
+		{[]int{8, 8}, 11, "Dealer shows ace. You have 8, 8. pair of 8s."},
+		{[]int{10, 6}, 10, "Dealer shows 10. You have 10, 6. hard 16."},
+	}
+
+	for _, tt := range tests {
+		if got := DescribeScenario(hand.New(tt.cards...), tt.dealer); got != tt.want {
+			t.Errorf("DescribeScenario(%v, %d) = %q, want %q", tt.cards, tt.dealer, got, tt.want)
+		}
+	}
+}
+
+// Test result descriptions
+func TestDescribeResult(t *testing.T) {
+	if got := DescribeResult(true, "STAND"); got != "Correct." {
+		t.Errorf("Unexpected correct description: %q", got)
+	}
+	if got := DescribeResult(false, "STAND"); got != "Incorrect. The answer is stand." {
+		t.Errorf("Unexpected incorrect description: %q", got)
+	}
+}
+
+// Test a command speaker runs its command with the text argument
+func TestCommandSpeaker(t *testing.T) {
+	path, err := exec.LookPath("true")
+	if err != nil {
+		t.Skip("true command not available")
+	}
+
+	speaker := NewCommandSpeaker(path)
+	if err := speaker.Speak("hello"); err != nil {
+		t.Errorf("Speak failed: %v", err)
+	}
+	if err := speaker.Speak("again"); err != nil {
+		t.Errorf("Second Speak failed: %v", err)
+	}
+
+	missing := NewCommandSpeaker("/nonexistent/speech-command")
+	if err := missing.Speak("hello"); err == nil {
+		t.Error("Expected error for missing command")
+	}
+}
@@ -0,0 +1,110 @@
+package stats
+
+import (
+	"blackjack_trainer/internal/hand"
+	"blackjack_trainer/internal/history"
+	"blackjack_trainer/internal/strategy"
+	"sort"
+)
+
+// CellKey identifies a single strategy chart cell.
+type CellKey struct {
+	HandType    strategy.HandType
+	PlayerTotal int
+	DealerCard  int
+}
+
+// Label returns the cell's display label (e.g. "Hard 16 vs 10").
+func (k CellKey) Label() string {
+	return strategy.CellLabel(k.HandType, k.PlayerTotal, k.DealerCard)
+}
+
+// AttemptCell returns the chart cell a recorded attempt was asked about.
+func AttemptCell(attempt history.Attempt) CellKey {
+	handType, value := strategy.Classify(hand.New(attempt.Cards...))
+	return CellKey{HandType: handType, PlayerTotal: value, DealerCard: attempt.DealerCard}
+}
+
+// ByCell aggregates attempts by chart cell.
+func ByCell(attempts []history.Attempt) map[CellKey]*CategoryData {
+	cells := make(map[CellKey]*CategoryData)
+	for _, attempt := range attempts {
+		key := AttemptCell(attempt)
+		data, exists := cells[key]
+		if !exists {
+			data = &CategoryData{}
+			cells[key] = data
+		}
+		data.Total++
+		if attempt.Correct {
+			data.Correct++
+		}
+		if attempt.Rating > 0 {
+			data.Rated++
+			data.RatingSum += attempt.Rating
+		}
+	}
+	return cells
+}
+
+// UnrecordedRules labels attempts recorded before the rules were noted.
+const UnrecordedRules = "Not recorded"
+
+// RulesData is accuracy on the hands asked under one rule set.
+type RulesData struct {
+	Rules string
+	CategoryData
+}
+
+// ByRules aggregates attempts by the rule set they were asked under, most
+// practiced first. Hard and soft 17 answers differ between rule sets, so
+// accuracy is only comparable within one.
+func ByRules(attempts []history.Attempt) []RulesData {
+	var byRules []RulesData
+	index := make(map[string]int)
+	for _, attempt := range attempts {
+		rules := attempt.Rules
+		if rules == "" {
+			rules = UnrecordedRules
+		}
+		i, exists := index[rules]
+		if !exists {
+			i = len(byRules)
+			index[rules] = i
+			byRules = append(byRules, RulesData{Rules: rules})
+		}
+		byRules[i].Total++
+		if attempt.Correct {
+			byRules[i].Correct++
+		}
+	}
+	sort.SliceStable(byRules, func(i, j int) bool {
+		return byRules[i].Total > byRules[j].Total
+	})
+	return byRules
+}
+
+// UnderRules returns the attempts asked under the named rules.
+func UnderRules(attempts []history.Attempt, rules string) []history.Attempt {
+	var under []history.Attempt
+	for _, attempt := range attempts {
+		if attempt.Rules == rules {
+			under = append(under, attempt)
+		}
+	}
+	return under
+}
+
+// Accuracy returns the percentage of correct attempts, or 0 if there are none.
+func (d CategoryData) Accuracy() float64 {
+	return percentage(d.Correct, d.Total)
+}
+
+// MeanRating returns the average difficulty the player rated the attempts,
+// from 1 (easy) to 3 (hard), or 0 if none were rated.
+func (d CategoryData) MeanRating() float64 {
+	if d.Rated == 0 {
+		return 0
+	}
+	return float64(d.RatingSum) / float64(d.Rated)
+}
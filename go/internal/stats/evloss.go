@@ -0,0 +1,68 @@
+package stats
+
+import (
+	"blackjack_trainer/internal/history"
+	"fmt"
+)
+
+// RecentSessions is how many of the latest sessions make up the recent EV
+// loss that is compared with the sessions before.
+const RecentSessions = 5
+
+// EVLoss is the expected value given up by wrong answers over a run of
+// sessions whose mistakes were priced.
+type EVLoss struct {
+	// Bets is the total EV given up, in bets.
+	Bets     float64
+	Hands    int
+	Sessions int
+}
+
+// Rate returns the EV given up per 100 hands.
+func (e EVLoss) Rate() float64 {
+	if e.Hands == 0 {
+		return 0
+	}
+	return e.Bets / float64(e.Hands) * 100
+}
+
+// String formats the rate, e.g. "0.42 bets per 100 hands".
+func (e EVLoss) String() string {
+	return fmt.Sprintf("%.2f bets per 100 hands", e.Rate())
+}
+
+// TallyEVLoss totals the EV loss of the sessions whose mistakes were priced,
+// skipping those recorded before EV loss was tracked.
+func TallyEVLoss(sessions []history.Session) EVLoss {
+	var total EVLoss
+	for _, session := range sessions {
+		if session.EVLoss == nil {
+			continue
+		}
+		total.Bets += *session.EVLoss
+		total.Hands += session.Total
+		total.Sessions++
+	}
+	return total
+}
+
+// EVLossTrend returns the EV loss of the latest RecentSessions priced
+// sessions and of the RecentSessions before them, to show whether mistakes
+// are getting cheaper.
+func EVLossTrend(h *history.History) (recent, before EVLoss) {
+	var priced []history.Session
+	for _, session := range h.Sessions {
+		if session.EVLoss != nil {
+			priced = append(priced, session)
+		}
+	}
+	split := len(priced) - RecentSessions
+	if split < 0 {
+		split = 0
+	}
+	start := split - RecentSessions
+	if start < 0 {
+		start = 0
+	}
+	return TallyEVLoss(priced[split:]), TallyEVLoss(priced[start:split])
+}
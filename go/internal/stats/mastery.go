@@ -0,0 +1,161 @@
+package stats
+
+import (
+	"blackjack_trainer/internal/history"
+	"blackjack_trainer/internal/strategy"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// MasteryHalfLife is how long it takes a cell's mastery to halve when the
+// cell isn't practiced.
+const MasteryHalfLife = 14 * 24 * time.Hour
+
+// MasteredScore is the mastery score at which a cell counts as mastered.
+const MasteredScore = 0.8
+
+// ReviewAfter is how long a cell can go unpracticed before it is due for
+// review.
+const ReviewAfter = 14 * 24 * time.Hour
+
+// masteryGain is the share of the gap to full mastery that a correct answer
+// closes, so four correct answers in a row master a new cell.
+const masteryGain = 0.4
+
+// ratingGain scales masteryGain by how hard the player rated a correct
+// answer: one that felt easy counts for more, and one that felt hard, right
+// but maybe by luck, for half. Unrated answers count as medium.
+var ratingGain = map[int]float64{1: 1.25, 2: 1, 3: 0.5}
+
+// masteryLoss is the share of its mastery a cell loses to a wrong answer
+// that wasn't priced, or was costly.
+const masteryLoss = 0.5
+
+// CellMastery is how well a chart cell is known.
+type CellMastery struct {
+	// Score runs from 0, never answered correctly, to 1, mastered and
+	// recently practiced.
+	Score    float64
+	LastSeen time.Time
+	Attempts int
+}
+
+// Mastery holds the mastery of each practiced chart cell.
+type Mastery map[CellKey]CellMastery
+
+// ComputeMastery replays the attempts in the history in order to score each
+// cell as of now. A correct answer raises the score, a wrong one cuts it,
+// and it decays between practices with a half-life of MasteryHalfLife.
+// The cut is deeper the more a mistake gave up (see Severity), and the rise
+// smaller the harder the player rated the question. Attempts are dated by
+// the end of their session.
+func ComputeMastery(h *history.History, now time.Time) Mastery {
+	sessions := make([]history.Session, len(h.Sessions))
+	copy(sessions, h.Sessions)
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].Ended.Before(sessions[j].Ended)
+	})
+
+	mastery := make(Mastery)
+	for _, s := range sessions {
+		for _, attempt := range s.Attempts {
+			key := AttemptCell(attempt)
+			cell := mastery[key]
+			cell.Score = cell.decayed(s.Ended)
+			if attempt.Correct {
+				gain, rated := ratingGain[attempt.Rating]
+				if !rated {
+					gain = 1
+				}
+				cell.Score += (1 - cell.Score) * masteryGain * gain
+			} else {
+				cell.Score *= 1 - AttemptSeverity(attempt).masteryLoss()
+			}
+			cell.LastSeen = s.Ended
+			cell.Attempts++
+			mastery[key] = cell
+		}
+	}
+
+	for key, cell := range mastery {
+		cell.Score = cell.decayed(now)
+		mastery[key] = cell
+	}
+	return mastery
+}
+
+// decayed returns the cell's score decayed from when it was last seen to t.
+func (c CellMastery) decayed(t time.Time) float64 {
+	if c.Attempts == 0 || !t.After(c.LastSeen) {
+		return c.Score
+	}
+	return c.Score * math.Pow(0.5, float64(t.Sub(c.LastSeen))/float64(MasteryHalfLife))
+}
+
+// Score returns a cell's mastery score, or 0 if it hasn't been practiced.
+func (m Mastery) Score(key CellKey) float64 {
+	return m[key].Score
+}
+
+// Mastered returns the number of cells with a score of at least
+// MasteredScore.
+func (m Mastery) Mastered() int {
+	mastered := 0
+	for _, cell := range m {
+		if cell.Score >= MasteredScore {
+			mastered++
+		}
+	}
+	return mastered
+}
+
+// Due returns the cells due for review as of now: practiced before, but not
+// for at least ReviewAfter, and decayed below MasteredScore. The longest
+// unpracticed come first.
+func (m Mastery) Due(now time.Time) []CellKey {
+	var due []CellKey
+	for key, cell := range m {
+		if now.Sub(cell.LastSeen) >= ReviewAfter && cell.Score < MasteredScore {
+			due = append(due, key)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		a, b := m[due[i]].LastSeen, m[due[j]].LastSeen
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return due[i].Label() < due[j].Label()
+	})
+	return due
+}
+
+// Summary describes mastery of the whole chart, e.g. "You have mastered
+// 83% of 360 cells".
+func (m Mastery) Summary() string {
+	total := len(ChartCells())
+	return fmt.Sprintf("You have mastered %.0f%% of %d cells", percentage(m.Mastered(), total), total)
+}
+
+// ChartCells returns every cell of the strategy chart: hard totals 5-21,
+// soft totals 13-21 and pairs, each against dealer cards 2 through ace.
+func ChartCells() []CellKey {
+	sections := []struct {
+		handType  strategy.HandType
+		low, high int
+	}{
+		{strategy.HandTypeHard, 5, 21},
+		{strategy.HandTypeSoft, 13, 21},
+		{strategy.HandTypePair, 2, 11},
+	}
+	var cells []CellKey
+	for _, section := range sections {
+		for total := section.low; total <= section.high; total++ {
+			for dealer := 2; dealer <= 11; dealer++ {
+				cells = append(cells, CellKey{HandType: section.handType, PlayerTotal: total, DealerCard: dealer})
+			}
+		}
+	}
+	return cells
+}
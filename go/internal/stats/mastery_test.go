@@ -0,0 +1,129 @@
+package stats
+
+import (
+	"blackjack_trainer/internal/history"
+	"blackjack_trainer/internal/strategy"
+	"math"
+	"testing"
+	"time"
+)
+
+// Test mastery rises with correct answers, falls with wrong ones and decays
+func TestComputeMastery(t *testing.T) {
+	day := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	hard16 := history.Attempt{Cards: []int{10, 6}, DealerCard: 10, Correct: true}
+	soft18 := history.Attempt{Cards: []int{11, 7}, DealerCard: 9, Correct: true}
+	wrong := soft18
+	wrong.Correct = false
+
+	h := history.New()
+	// Added out of order: mastery replays sessions by when they ended
+	h.Add(history.Session{Ended: day.Add(time.Hour), Attempts: []history.Attempt{hard16, hard16, soft18, wrong}})
+	h.Add(history.Session{Ended: day, Attempts: []history.Attempt{hard16, hard16}})
+
+	mastery := ComputeMastery(h, day.Add(time.Hour))
+	hard16Key := CellKey{HandType: strategy.HandTypeHard, PlayerTotal: 16, DealerCard: 10}
+	soft18Key := CellKey{HandType: strategy.HandTypeSoft, PlayerTotal: 18, DealerCard: 9}
+
+	// Four correct answers: 1 - 0.6^4, slightly decayed between sessions
+	if score := mastery.Score(hard16Key); score < MasteredScore || score > 1-math.Pow(0.6, 4) {
+		t.Errorf("Expected hard 16 vs 10 mastered after four correct answers, got %f", score)
+	}
+	if score := mastery.Score(soft18Key); math.Abs(score-0.2) > 1e-9 {
+		t.Errorf("Expected soft 18 vs 9 at 0.4 halved by a wrong answer, got %f", score)
+	}
+	if cell := mastery[hard16Key]; cell.Attempts != 4 || !cell.LastSeen.Equal(day.Add(time.Hour)) {
+		t.Errorf("Expected 4 attempts last seen at the later session, got %+v", cell)
+	}
+	if score := mastery.Score(CellKey{HandType: strategy.HandTypePair, PlayerTotal: 8, DealerCard: 6}); score != 0 {
+		t.Errorf("Unpracticed cell should score 0, got %f", score)
+	}
+	if mastered := mastery.Mastered(); mastered != 1 {
+		t.Errorf("Expected 1 mastered cell, got %d", mastered)
+	}
+
+	later := ComputeMastery(h, day.Add(time.Hour+MasteryHalfLife))
+	if got, want := later.Score(hard16Key), mastery.Score(hard16Key)/2; math.Abs(got-want) > 1e-9 {
+		t.Errorf("Expected mastery halved after one half-life, got %f want %f", got, want)
+	}
+	if mastered := later.Mastered(); mastered != 0 {
+		t.Errorf("Expected no mastered cells after a half-life, got %d", mastered)
+	}
+}
+
+// Test correct answers rated hard raise mastery less than ones rated easy
+func TestMasteryRating(t *testing.T) {
+	day := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	key := CellKey{HandType: strategy.HandTypeHard, PlayerTotal: 16, DealerCard: 10}
+	scores := make(map[int]float64)
+	for _, rating := range []int{0, 1, 2, 3} {
+		h := history.New()
+		h.Add(history.Session{Ended: day, Attempts: []history.Attempt{{Cards: []int{10, 6}, DealerCard: 10, Correct: true, Rating: rating}}})
+		scores[rating] = ComputeMastery(h, day).Score(key)
+	}
+	if scores[0] != scores[2] || math.Abs(scores[2]-masteryGain) > 1e-9 {
+		t.Errorf("Unrated and medium answers should gain %f, got %f and %f", masteryGain, scores[0], scores[2])
+	}
+	if !(scores[1] > scores[2] && scores[2] > scores[3]) {
+		t.Errorf("Easy should gain most and hard least, got %v", scores)
+	}
+}
+
+// Test the chart mastery summary counts every chart cell
+func TestMasterySummary(t *testing.T) {
+	if cells := len(ChartCells()); cells != 360 {
+		t.Fatalf("Expected 360 chart cells, got %d", cells)
+	}
+
+	mastery := Mastery{}
+	for i, key := range ChartCells() {
+		if i%4 != 0 {
+			mastery[key] = CellMastery{Score: 0.9, Attempts: 5}
+		}
+	}
+	if got, want := mastery.Summary(), "You have mastered 75% of 360 cells"; got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+}
+
+// Test cells unpracticed for weeks come due for review, oldest first
+func TestMasteryDue(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	old := CellKey{HandType: strategy.HandTypeHard, PlayerTotal: 12, DealerCard: 3}
+	older := CellKey{HandType: strategy.HandTypeSoft, PlayerTotal: 18, DealerCard: 9}
+	recent := CellKey{HandType: strategy.HandTypePair, PlayerTotal: 9, DealerCard: 7}
+	kept := CellKey{HandType: strategy.HandTypeHard, PlayerTotal: 16, DealerCard: 10}
+
+	mastery := Mastery{
+		old:    {Score: 0.5, LastSeen: now.Add(-ReviewAfter), Attempts: 3},
+		older:  {Score: 0.1, LastSeen: now.Add(-3 * ReviewAfter), Attempts: 1},
+		recent: {Score: 0.2, LastSeen: now.Add(-time.Hour), Attempts: 1},
+		kept:   {Score: 0.9, LastSeen: now.Add(-2 * ReviewAfter), Attempts: 30},
+	}
+	due := mastery.Due(now)
+	if len(due) != 2 || due[0] != older || due[1] != old {
+		t.Errorf("Expected %v then %v due, got %v", older, old, due)
+	}
+}
+
+// Test an expensive mistake cuts mastery deeper than a harmless one
+func TestMasterySeverity(t *testing.T) {
+	day := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	correct := history.Attempt{Cards: []int{10, 6}, DealerCard: 10, Correct: true}
+	harmless, disastrous := correct, correct
+	harmless.Correct, harmless.Severity = false, "harmless"
+	disastrous.Correct, disastrous.Severity = false, "disastrous"
+	key := CellKey{HandType: strategy.HandTypeHard, PlayerTotal: 16, DealerCard: 10}
+
+	score := func(attempts ...history.Attempt) float64 {
+		h := history.New()
+		h.Add(history.Session{Ended: day, Attempts: attempts})
+		return ComputeMastery(h, day).Score(key)
+	}
+	if got := score(correct, harmless); math.Abs(got-0.32) > 1e-9 {
+		t.Errorf("A harmless mistake should keep 80%% of 0.4, got %f", got)
+	}
+	if got := score(correct, disastrous); math.Abs(got-0.12) > 1e-9 {
+		t.Errorf("A disastrous mistake should keep 30%% of 0.4, got %f", got)
+	}
+}
@@ -0,0 +1,87 @@
+package stats
+
+import (
+	"blackjack_trainer/internal/history"
+	"blackjack_trainer/internal/strategy"
+	"fmt"
+)
+
+// ChartCell is one cell of a session chart: the chart's play and how the
+// session's answers to it went. Cells the session didn't ask have no Total.
+type ChartCell struct {
+	Action rune
+	CategoryData
+}
+
+// ChartRow is one row of a session chart, with a cell for each dealer card
+// from 2 to A.
+type ChartRow struct {
+	Label string
+	Cells [10]ChartCell
+}
+
+// ChartSection is the hard, soft or pairs section of a session chart.
+type ChartSection struct {
+	Title string
+	Rows  []ChartRow
+}
+
+// SessionChart builds a mini chart of the rows a session asked about. Every
+// dealer column is kept so each hand is seen in its place on the full
+// chart; cells not asked only show the chart's play. Sections with no
+// questions are left out.
+func SessionChart(attempts []history.Attempt, chart *strategy.StrategyChart) []ChartSection {
+	cells := ByCell(attempts)
+	asked := make(map[strategy.HandType]map[int]bool)
+	for key := range cells {
+		if asked[key.HandType] == nil {
+			asked[key.HandType] = make(map[int]bool)
+		}
+		asked[key.HandType][key.PlayerTotal] = true
+	}
+
+	var sections []ChartSection
+	for _, s := range []struct {
+		title     string
+		handType  strategy.HandType
+		low, high int
+	}{
+		{"Hard Totals", strategy.HandTypeHard, 5, 21},
+		{"Soft Totals", strategy.HandTypeSoft, 13, 21},
+		{"Pairs", strategy.HandTypePair, 2, 11},
+	} {
+		section := ChartSection{Title: s.title}
+		for total := s.low; total <= s.high; total++ {
+			if !asked[s.handType][total] {
+				continue
+			}
+			row := ChartRow{Label: chartRowLabel(s.handType, total)}
+			for dealer := 2; dealer <= 11; dealer++ {
+				cell := ChartCell{Action: chart.GetCorrectAction(s.handType, total, dealer)}
+				if data := cells[CellKey{HandType: s.handType, PlayerTotal: total, DealerCard: dealer}]; data != nil {
+					cell.CategoryData = *data
+				}
+				row.Cells[dealer-2] = cell
+			}
+			section.Rows = append(section.Rows, row)
+		}
+		if len(section.Rows) > 0 {
+			sections = append(sections, section)
+		}
+	}
+	return sections
+}
+
+// chartRowLabel returns the row heading for a chart row, e.g. "16", "A,7"
+// or "8,8".
+func chartRowLabel(handType strategy.HandType, total int) string {
+	switch handType {
+	case strategy.HandTypePair:
+		card := strategy.CardToString(total)
+		return card + "," + card
+	case strategy.HandTypeSoft:
+		return fmt.Sprintf("A,%d", total-11)
+	default:
+		return fmt.Sprintf("%d", total)
+	}
+}
@@ -0,0 +1,329 @@
+package stats
+
+import (
+	"blackjack_trainer/internal/hand"
+	"blackjack_trainer/internal/history"
+	"blackjack_trainer/internal/strategy"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// MissedCell describes a chart cell answered incorrectly during a session.
+type MissedCell struct {
+	Label         string
+	UserAction    rune
+	CorrectAction rune
+	Mnemonic      string
+	Count         int
+	// Severity is the worst severity of the cell's mistakes, if priced.
+	Severity Severity
+}
+
+// ReportCard summarizes a completed session and compares it to lifetime performance.
+type ReportCard struct {
+	Session history.Session
+
+	// Session breakdowns
+	ByCategory       map[string]*CategoryData
+	ByDealerStrength map[string]*CategoryData
+	ByAction         map[string]*CategoryData
+	ByHandSize       map[string]*CategoryData
+
+	// Lifetime performance before this session, under the session's rules
+	// if it names them
+	LifetimeAccuracy         float64
+	LifetimeAttempts         int
+	LifetimeByCategory       map[string]*CategoryData
+	LifetimeByDealerStrength map[string]*CategoryData
+	LifetimeByAction         map[string]*CategoryData
+	LifetimeByHandSize       map[string]*CategoryData
+	// LifetimeEVLoss is the EV given up in past sessions whose mistakes
+	// were priced.
+	LifetimeEVLoss EVLoss
+
+	// Slowest is the question that took longest to answer, if any were timed.
+	Slowest *history.Attempt
+	// Missed lists each distinct cell answered incorrectly, the most
+	// expensive mistakes first, otherwise in the order first missed.
+	Missed []MissedCell
+}
+
+// NewReportCard builds a report card for a session. The past history should
+// not yet include the session so that lifetime figures are a fair baseline.
+func NewReportCard(session history.Session, past *history.History, chart *strategy.StrategyChart) ReportCard {
+	report := ReportCard{Session: session}
+	report.ByCategory, report.ByDealerStrength = Tally(session.Attempts)
+	report.ByAction = TallyActions(session.Attempts)
+	report.ByHandSize = TallyHandSizes(session.Attempts)
+	lifetime := past.Attempts()
+	if session.Rules == "" {
+		report.LifetimeAccuracy, report.LifetimeAttempts = past.Accuracy()
+	} else {
+		lifetime = UnderRules(lifetime, session.Rules)
+		correct := 0
+		for _, attempt := range lifetime {
+			if attempt.Correct {
+				correct++
+			}
+		}
+		report.LifetimeAccuracy, report.LifetimeAttempts = percentage(correct, len(lifetime)), len(lifetime)
+	}
+	report.LifetimeByCategory, report.LifetimeByDealerStrength = Tally(lifetime)
+	report.LifetimeByAction = TallyActions(lifetime)
+	report.LifetimeByHandSize = TallyHandSizes(lifetime)
+	report.LifetimeEVLoss = TallyEVLoss(past.Sessions)
+
+	missedIndex := make(map[string]int)
+	for i := range session.Attempts {
+		attempt := &session.Attempts[i]
+		if attempt.LatencyMs > 0 && (report.Slowest == nil || attempt.LatencyMs > report.Slowest.LatencyMs) {
+			report.Slowest = attempt
+		}
+
+		if attempt.Correct {
+			continue
+		}
+
+		playerHand := hand.New(attempt.Cards...)
+		label := AttemptLabel(*attempt)
+		severity := AttemptSeverity(*attempt)
+		if index, exists := missedIndex[label]; exists {
+			report.Missed[index].Count++
+			if severity > report.Missed[index].Severity {
+				report.Missed[index].Severity = severity
+			}
+			continue
+		}
+		missedIndex[label] = len(report.Missed)
+		report.Missed = append(report.Missed, MissedCell{
+			Label:         label,
+			UserAction:    firstRune(attempt.Action),
+			CorrectAction: firstRune(attempt.CorrectAction),
+			Mnemonic:      chart.GetExplanationForHand(playerHand, attempt.DealerCard),
+			Count:         1,
+			Severity:      severity,
+		})
+	}
+	sort.SliceStable(report.Missed, func(i, j int) bool {
+		return report.Missed[i].Severity > report.Missed[j].Severity
+	})
+
+	return report
+}
+
+// Display writes the report card to w.
+func (r ReportCard) Display(w io.Writer) {
+	session := r.Session
+
+	fmt.Fprintln(w, "\n"+strings.Repeat("=", 50))
+	fmt.Fprintln(w, "SESSION REPORT CARD")
+	fmt.Fprintln(w, strings.Repeat("=", 50))
+
+	accuracy := percentage(session.Correct, session.Total)
+	fmt.Fprintf(w, "Mode: %s\n", session.Mode)
+	if session.Rules != "" {
+		fmt.Fprintf(w, "Rules: %s\n", session.Rules)
+	}
+	fmt.Fprintf(w, "Score: %d/%d (%.1f%%)\n", session.Correct, session.Total, accuracy)
+	if session.EVLoss != nil && session.Correct < session.Total {
+		fmt.Fprintf(w, "Weighted by mistake cost: %.1f%%\n", WeightedScore(session.Attempts))
+	}
+	fmt.Fprintf(w, "Time: %s\n", FormatDuration(session.Duration()))
+	if rate, ok := session.EVLossRate(); ok && session.Correct < session.Total {
+		fmt.Fprintf(w, "Your mistakes cost ~%.2f bets per 100 hands", rate)
+		if r.LifetimeEVLoss.Hands > 0 {
+			fmt.Fprintf(w, " (lifetime %.2f)", r.LifetimeEVLoss.Rate())
+		}
+		fmt.Fprintln(w)
+	}
+	if n := len(session.Corrected); n > 0 {
+		fmt.Fprintf(w, "Corrected: %d slip(s) taken back and asked again (not scored)\n", n)
+	}
+	if n := len(session.Skipped); n > 0 {
+		fmt.Fprintf(w, "Skipped: %d question(s) (not scored)\n", n)
+	}
+	if n := hinted(session.Attempts); n > 0 {
+		fmt.Fprintf(w, "Hints: mnemonics looked up for %d question(s)\n", n)
+	}
+
+	lifetime := "Lifetime"
+	if session.Rules != "" {
+		lifetime = fmt.Sprintf("Lifetime (%s rules)", session.Rules)
+	}
+	if r.LifetimeAttempts > 0 {
+		fmt.Fprintf(w, "%s: %.1f%% over %d questions (this session %+.1f points)\n",
+			lifetime, r.LifetimeAccuracy, r.LifetimeAttempts, accuracy-r.LifetimeAccuracy)
+	} else {
+		fmt.Fprintf(w, "%s: first recorded session\n", lifetime)
+	}
+
+	fmt.Fprintf(w, "\n%-20s %-16s %s\n", "", "Session", "Lifetime")
+	r.displayBreakdown(w, "By Hand Type:", []string{"hard", "soft", "pair"}, r.ByCategory, r.LifetimeByCategory)
+	r.displayBreakdown(w, "By Dealer Strength:", []string{"weak", "medium", "strong"}, r.ByDealerStrength, r.LifetimeByDealerStrength)
+	r.displayBreakdown(w, "By Correct Action:", ActionKeys, r.ByAction, r.LifetimeByAction)
+	r.displayBreakdown(w, "By Hand Size:", HandSizeKeys, r.ByHandSize, r.LifetimeByHandSize)
+
+	if r.Slowest != nil {
+		fmt.Fprintf(w, "\nSlowest question: %s (%s) - %.1fs\n",
+			AttemptLabel(*r.Slowest), hand.New(r.Slowest.Cards...), r.Slowest.Latency().Seconds())
+	}
+
+	if len(r.Missed) == 0 {
+		fmt.Fprintln(w, "\nNo cells missed. Perfect session!")
+		return
+	}
+
+	fmt.Fprintln(w, "\nCells missed:")
+	for _, missed := range r.Missed {
+		times := ""
+		if missed.Count > 1 {
+			times = fmt.Sprintf(" (x%d)", missed.Count)
+		}
+		severity := ""
+		if missed.Severity != SeverityUnknown {
+			severity = " [" + missed.Severity.String() + "]"
+		}
+		fmt.Fprintf(w, "  %s%s%s: you chose %s, correct is %s\n", missed.Label, times, severity,
+			strategy.ActionToString(missed.UserAction), strategy.ActionToString(missed.CorrectAction))
+		fmt.Fprintf(w, "      %s\n", missed.Mnemonic)
+	}
+}
+
+// hinted counts the attempts answered after looking up the mnemonics.
+func hinted(attempts []history.Attempt) int {
+	n := 0
+	for _, attempt := range attempts {
+		if attempt.Hinted {
+			n++
+		}
+	}
+	return n
+}
+
+// displayBreakdown prints session and lifetime accuracy for a set of categories.
+func (r ReportCard) displayBreakdown(w io.Writer, title string, keys []string, session, lifetime map[string]*CategoryData) {
+	fmt.Fprintln(w, title)
+	for _, key := range keys {
+		data := session[key]
+		if data.Total == 0 {
+			continue
+		}
+
+		sessionText := fmt.Sprintf("%d/%d (%.1f%%)", data.Correct, data.Total, percentage(data.Correct, data.Total))
+		lifetimeText := "-"
+		if past := lifetime[key]; past.Total > 0 {
+			lifetimeText = fmt.Sprintf("%.1f%%", percentage(past.Correct, past.Total))
+		}
+		fmt.Fprintf(w, "  %-18s %-16s %s\n", strings.Title(key), sessionText, lifetimeText)
+	}
+}
+
+// AttemptLabel returns the chart cell label for a recorded attempt.
+func AttemptLabel(attempt history.Attempt) string {
+	return AttemptCell(attempt).Label()
+}
+
+// Tally aggregates attempts by hand type and dealer strength.
+func Tally(attempts []history.Attempt) (map[string]*CategoryData, map[string]*CategoryData) {
+	byCategory := map[string]*CategoryData{"hard": {}, "soft": {}, "pair": {}}
+	byDealerStrength := map[string]*CategoryData{"weak": {}, "medium": {}, "strong": {}}
+
+	for _, attempt := range attempts {
+		for _, data := range []*CategoryData{byCategory[attempt.HandType], byDealerStrength[dealerStrength(attempt.DealerCard)]} {
+			if data == nil {
+				continue
+			}
+			data.Total++
+			if attempt.Correct {
+				data.Correct++
+			}
+		}
+	}
+	return byCategory, byDealerStrength
+}
+
+// ActionKeys lists the correct-action classes tallied by TallyActions, in
+// display order. No chart calls for surrender yet, so it is only shown once
+// an attempt has it as the answer.
+var ActionKeys = []string{"hit", "stand", "double", "split", "surrender"}
+
+// actionKey returns the correct-action class of an action code, or "" if
+// unknown.
+func actionKey(action string) string {
+	switch action {
+	case "H":
+		return "hit"
+	case "S":
+		return "stand"
+	case "D":
+		return "double"
+	case "Y", "P":
+		return "split"
+	case "R":
+		return "surrender"
+	}
+	return ""
+}
+
+// TallyActions aggregates attempts by the correct action, so accuracy on
+// the hands that should be doubled can be told from the ones that should
+// be stood on.
+func TallyActions(attempts []history.Attempt) map[string]*CategoryData {
+	byAction := make(map[string]*CategoryData, len(ActionKeys))
+	for _, key := range ActionKeys {
+		byAction[key] = &CategoryData{}
+	}
+	for _, attempt := range attempts {
+		data := byAction[actionKey(attempt.CorrectAction)]
+		if data == nil {
+			continue
+		}
+		data.Total++
+		if attempt.Correct {
+			data.Correct++
+		}
+	}
+	return byAction
+}
+
+// HandSizeKeys lists the hand sizes tallied by TallyHandSizes, in display
+// order.
+var HandSizeKeys = []string{"two-card", "multi-card"}
+
+// TallyHandSizes aggregates attempts by whether the hand had two cards or
+// more. Doubling and splitting are only offered on the first two cards, so
+// their mistakes only show up on two-card hands.
+func TallyHandSizes(attempts []history.Attempt) map[string]*CategoryData {
+	bySize := map[string]*CategoryData{"two-card": {}, "multi-card": {}}
+	for _, attempt := range attempts {
+		if len(attempt.Cards) < 2 {
+			continue
+		}
+		data := bySize["two-card"]
+		if len(attempt.Cards) > 2 {
+			data = bySize["multi-card"]
+		}
+		data.Total++
+		if attempt.Correct {
+			data.Correct++
+		}
+	}
+	return bySize
+}
+
+// percentage returns correct as a percentage of total, or 0 when total is 0.
+func percentage(correct, total int) float64 {
+	if total == 0 {
+		return 0.0
+	}
+	return (float64(correct) / float64(total)) * 100.0
+}
+
+func firstRune(s string) rune {
+	for _, r := range s {
+		return r
+	}
+	return 0
+}
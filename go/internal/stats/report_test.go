@@ -0,0 +1,281 @@
+package stats
+
+import (
+	"blackjack_trainer/internal/history"
+	"blackjack_trainer/internal/strategy"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test report cards break down the session and compare to lifetime history
+func TestNewReportCard(t *testing.T) {
+	past := history.New()
+	past.Add(history.Session{Correct: 1, Total: 2, Attempts: []history.Attempt{
+		{Cards: []int{10, 6}, DealerCard: 10, HandType: "hard", Correct: true},
+		{Cards: []int{11, 7}, DealerCard: 9, HandType: "soft", Correct: false},
+	}})
+
+	started := time.Now()
+	session := history.Session{
+		Mode:    "random",
+		Started: started,
+		Ended:   started.Add(time.Minute),
+		Correct: 2,
+		Total:   5,
+		Attempts: []history.Attempt{
+			{Cards: []int{10, 6}, DealerCard: 10, HandType: "hard", Action: "S", CorrectAction: "H", LatencyMs: 900},
+			{Cards: []int{8, 8}, DealerCard: 11, HandType: "pair", Action: "Y", CorrectAction: "Y", Correct: true, LatencyMs: 4000},
+			{Cards: []int{11, 7}, DealerCard: 9, HandType: "soft", Action: "S", CorrectAction: "H", LatencyMs: 1200},
+			{Cards: []int{10, 6}, DealerCard: 10, HandType: "hard", Action: "S", CorrectAction: "H", LatencyMs: 800},
+			{Cards: []int{4, 5}, DealerCard: 5, HandType: "hard", Action: "D", CorrectAction: "D", Correct: true, LatencyMs: 700},
+		},
+	}
+
+	report := NewReportCard(session, past, strategy.New())
+
+	if data := report.ByCategory["hard"]; data.Correct != 1 || data.Total != 3 {
+		t.Errorf("Hard session breakdown should be 1/3, got %d/%d", data.Correct, data.Total)
+	}
+	if data := report.ByDealerStrength["strong"]; data.Correct != 1 || data.Total != 4 {
+		t.Errorf("Strong session breakdown should be 1/4, got %d/%d", data.Correct, data.Total)
+	}
+	if data := report.ByAction["hit"]; data.Correct != 0 || data.Total != 3 {
+		t.Errorf("Hit session breakdown should be 0/3, got %d/%d", data.Correct, data.Total)
+	}
+	if data := report.ByHandSize["two-card"]; data.Correct != 2 || data.Total != 5 {
+		t.Errorf("Two-card session breakdown should be 2/5, got %d/%d", data.Correct, data.Total)
+	}
+	if report.LifetimeAccuracy != 50.0 || report.LifetimeAttempts != 2 {
+		t.Errorf("Lifetime should be 50%% over 2, got %f over %d", report.LifetimeAccuracy, report.LifetimeAttempts)
+	}
+	if data := report.LifetimeByCategory["soft"]; data.Correct != 0 || data.Total != 1 {
+		t.Errorf("Lifetime soft should be 0/1, got %d/%d", data.Correct, data.Total)
+	}
+
+	if report.Slowest == nil || report.Slowest.LatencyMs != 4000 {
+		t.Errorf("Slowest question should be the 4s pair, got %+v", report.Slowest)
+	}
+
+	if len(report.Missed) != 2 {
+		t.Fatalf("Expected 2 distinct missed cells, got %d", len(report.Missed))
+	}
+	first := report.Missed[0]
+	if first.Label != "Hard 16 vs 10" || first.Count != 2 || first.UserAction != 'S' || first.CorrectAction != 'H' {
+		t.Errorf("Unexpected first missed cell: %+v", first)
+	}
+	if first.Mnemonic == "" {
+		t.Error("Missed cell should include a mnemonic")
+	}
+	if report.Missed[1].Label != "Soft 18 vs 9" {
+		t.Errorf("Unexpected second missed cell: %+v", report.Missed[1])
+	}
+}
+
+// Test per-cell aggregation
+func TestByCell(t *testing.T) {
+	cells := ByCell([]history.Attempt{
+		{Cards: []int{10, 6}, DealerCard: 10, Correct: true},
+		{Cards: []int{9, 7}, DealerCard: 10, Correct: false},
+		{Cards: []int{8, 8}, DealerCard: 10, Correct: true},
+	})
+
+	hard16 := cells[CellKey{strategy.HandTypeHard, 16, 10}]
+	if hard16 == nil || hard16.Correct != 1 || hard16.Total != 2 {
+		t.Errorf("Hard 16 vs 10 should be 1/2, got %+v", hard16)
+	}
+	if hard16.Accuracy() != 50.0 {
+		t.Errorf("Hard 16 vs 10 accuracy should be 50, got %f", hard16.Accuracy())
+	}
+
+	pair8 := cells[CellKey{strategy.HandTypePair, 8, 10}]
+	if pair8 == nil || pair8.Total != 1 {
+		t.Errorf("Pair 8,8 vs 10 should be tracked separately from hard 16, got %+v", pair8)
+	}
+}
+
+// Test accuracy is partitioned by rule set, and report cards compare a
+// session only to past hands under the same rules
+func TestByRules(t *testing.T) {
+	past := history.New()
+	past.Add(history.Session{Rules: "Vegas Strip", Correct: 2, Total: 2, Attempts: []history.Attempt{
+		{Cards: []int{11, 6}, DealerCard: 2, HandType: "soft", Correct: true},
+		{Cards: []int{11, 7}, DealerCard: 2, HandType: "soft", Correct: true},
+	}})
+	past.Add(history.Session{Rules: "Standard", Correct: 0, Total: 1, Attempts: []history.Attempt{
+		{Cards: []int{11, 7}, DealerCard: 2, HandType: "soft", Correct: false, Rules: "Standard"},
+	}})
+	past.Add(history.Session{Correct: 1, Total: 1, Attempts: []history.Attempt{
+		{Cards: []int{10, 6}, DealerCard: 10, HandType: "hard", Correct: true},
+	}})
+
+	byRules := ByRules(past.Attempts())
+	want := []RulesData{
+		{"Vegas Strip", CategoryData{Correct: 2, Total: 2}},
+		{"Standard", CategoryData{Correct: 0, Total: 1}},
+		{UnrecordedRules, CategoryData{Correct: 1, Total: 1}},
+	}
+	if len(byRules) != len(want) {
+		t.Fatalf("Expected %d rule sets, got %+v", len(want), byRules)
+	}
+	for i := range want {
+		if byRules[i] != want[i] {
+			t.Errorf("Rule set %d: expected %+v, got %+v", i, want[i], byRules[i])
+		}
+	}
+
+	session := history.Session{Mode: "soft", Rules: "Standard", Correct: 1, Total: 1, Attempts: []history.Attempt{
+		{Cards: []int{11, 7}, DealerCard: 2, HandType: "soft", Correct: true, Rules: "Standard"},
+	}}
+	report := NewReportCard(session, past, strategy.New())
+	if report.LifetimeAttempts != 1 || report.LifetimeAccuracy != 0 {
+		t.Errorf("Lifetime should cover only the Standard hand, got %f over %d", report.LifetimeAccuracy, report.LifetimeAttempts)
+	}
+	if data := report.LifetimeByCategory["soft"]; data.Total != 1 {
+		t.Errorf("Lifetime soft should be 0/1 under Standard rules, got %d/%d", data.Correct, data.Total)
+	}
+}
+
+// Test EV loss is totaled over priced sessions and split into recent and earlier sessions
+func TestEVLoss(t *testing.T) {
+	h := history.New()
+	h.Add(history.Session{Total: 50}) // recorded before EV loss was tracked
+	for i := 0; i < 7; i++ {
+		loss := 2.0
+		if i >= 2 {
+			loss = 0.5
+		}
+		h.Add(history.Session{Total: 100, Correct: 90, EVLoss: &loss})
+	}
+
+	lifetime := TallyEVLoss(h.Sessions)
+	if lifetime.Sessions != 7 || lifetime.Hands != 700 || lifetime.Bets != 6.5 {
+		t.Errorf("Unexpected lifetime EV loss %+v", lifetime)
+	}
+	recent, before := EVLossTrend(h)
+	if recent.Sessions != RecentSessions || recent.Rate() != 0.5 {
+		t.Errorf("Recent EV loss should be 0.5 over %d sessions, got %+v", RecentSessions, recent)
+	}
+	if before.Sessions != 2 || before.Rate() != 2 || before.String() != "2.00 bets per 100 hands" {
+		t.Errorf("Earlier EV loss should be 2 over 2 sessions, got %+v", before)
+	}
+
+	var out strings.Builder
+	session := history.Session{Mode: "random", Total: 100, Correct: 90, EVLoss: &lifetime.Bets}
+	NewReportCard(session, h, strategy.New()).Display(&out)
+	if !strings.Contains(out.String(), "Your mistakes cost ~6.50 bets per 100 hands (lifetime 0.93)") {
+		t.Errorf("Report card should price the mistakes:\n%s", out.String())
+	}
+}
+
+// Test mistakes are classified by cost and weighted in the score
+func TestSeverity(t *testing.T) {
+	for _, tt := range []struct {
+		loss float64
+		want Severity
+	}{
+		{0, Harmless}, {0.019, Harmless}, {0.05, Minor}, {0.2, Costly}, {0.4, Disastrous}, {1.6, Disastrous},
+	} {
+		if got := ClassifyLoss(tt.loss); got != tt.want {
+			t.Errorf("ClassifyLoss(%v) = %s, want %s", tt.loss, got, tt.want)
+		}
+		if got := ParseSeverity(tt.want.String()); got != tt.want {
+			t.Errorf("ParseSeverity(%q) = %v", tt.want, got)
+		}
+	}
+
+	attempts := []history.Attempt{
+		{Cards: []int{10, 6}, DealerCard: 10, Correct: true},
+		{Cards: []int{10, 6}, DealerCard: 10, Severity: "harmless"},
+		{Cards: []int{6, 5}, DealerCard: 6, Severity: "disastrous"},
+		{Cards: []int{11, 7}, DealerCard: 9, Severity: "minor"},
+		{Cards: []int{11, 7}, DealerCard: 9},
+	}
+	if got := WeightedScore(attempts); got != 45 {
+		t.Errorf("WeightedScore = %v, want 45 (2.25 of 5)", got)
+	}
+	if got := FormatSeverities(CountSeverities(attempts)); got != "1 disastrous, 1 minor, 1 harmless" {
+		t.Errorf("FormatSeverities = %q", got)
+	}
+
+	session := history.Session{Total: 5, Correct: 1, Attempts: attempts}
+	report := NewReportCard(session, history.New(), strategy.New())
+	if len(report.Missed) != 3 || report.Missed[0].Label != "Hard 11 vs 6" || report.Missed[1].Severity != Minor {
+		t.Errorf("Missed cells should be listed worst first: %+v", report.Missed)
+	}
+}
+
+// Test attempts are tallied by the correct action, whatever was answered
+func TestTallyActions(t *testing.T) {
+	attempts := []history.Attempt{
+		{Action: "S", CorrectAction: "S", Correct: true},
+		{Action: "S", CorrectAction: "S", Correct: true},
+		{Action: "H", CorrectAction: "D"},
+		{Action: "D", CorrectAction: "D", Correct: true},
+		{Action: "S", CorrectAction: "P"},
+		{Action: "Y", CorrectAction: "Y", Correct: true},
+		{Action: "H", CorrectAction: ""},
+	}
+	byAction := TallyActions(attempts)
+
+	expected := map[string][2]int{"hit": {0, 0}, "stand": {2, 2}, "double": {1, 2}, "split": {1, 2}, "surrender": {0, 0}}
+	for key, want := range expected {
+		if data := byAction[key]; data.Correct != want[0] || data.Total != want[1] {
+			t.Errorf("%s: expected %d/%d, got %d/%d", key, want[0], want[1], data.Correct, data.Total)
+		}
+	}
+	if len(byAction) != len(ActionKeys) {
+		t.Errorf("Expected only the action classes, got %v", byAction)
+	}
+}
+
+// Test attempts are tallied by whether the hand had more than two cards
+func TestTallyHandSizes(t *testing.T) {
+	attempts := []history.Attempt{
+		{Cards: []int{10, 6}, Correct: true},
+		{Cards: []int{5, 6}},
+		{Cards: []int{4, 2, 10}, Correct: true},
+		{Cards: []int{2, 3, 4, 7}},
+		{Cards: []int{3, 4, 2}, Correct: true},
+		{},
+	}
+	bySize := TallyHandSizes(attempts)
+	if data := bySize["two-card"]; data.Correct != 1 || data.Total != 2 {
+		t.Errorf("Two-card: expected 1/2, got %d/%d", data.Correct, data.Total)
+	}
+	if data := bySize["multi-card"]; data.Correct != 2 || data.Total != 3 {
+		t.Errorf("Multi-card: expected 2/3, got %d/%d", data.Correct, data.Total)
+	}
+}
+
+// Test the session chart keeps only the rows asked, with every dealer column
+func TestSessionChart(t *testing.T) {
+	attempts := []history.Attempt{
+		{Cards: []int{10, 6}, DealerCard: 10, Correct: true},
+		{Cards: []int{10, 6}, DealerCard: 10},
+		{Cards: []int{9, 7}, DealerCard: 2, Correct: true},
+		{Cards: []int{8, 8}, DealerCard: 11},
+	}
+	sections := SessionChart(attempts, strategy.New())
+
+	if len(sections) != 2 || sections[0].Title != "Hard Totals" || sections[1].Title != "Pairs" {
+		t.Fatalf("Expected hard and pairs sections, got %+v", sections)
+	}
+	hard := sections[0]
+	if len(hard.Rows) != 1 || hard.Rows[0].Label != "16" {
+		t.Fatalf("Expected only the hard 16 row, got %+v", hard.Rows)
+	}
+	row := hard.Rows[0]
+	if cell := row.Cells[10-2]; cell.Action != 'H' || cell.Correct != 1 || cell.Total != 2 {
+		t.Errorf("Hard 16 vs 10: expected H 1/2, got %c %d/%d", cell.Action, cell.Correct, cell.Total)
+	}
+	if cell := row.Cells[0]; cell.Action != 'S' || cell.Correct != 1 || cell.Total != 1 {
+		t.Errorf("Hard 16 vs 2: expected S 1/1, got %c %d/%d", cell.Action, cell.Correct, cell.Total)
+	}
+	if cell := row.Cells[7-2]; cell.Action != 'H' || cell.Total != 0 {
+		t.Errorf("Hard 16 vs 7 should show the play but not be asked, got %c %d", cell.Action, cell.Total)
+	}
+	if pairs := sections[1]; pairs.Rows[0].Label != "8,8" || pairs.Rows[0].Cells[9].Total != 1 {
+		t.Errorf("Expected 8,8 vs A asked once, got %+v", pairs.Rows)
+	}
+}
@@ -0,0 +1,160 @@
+package stats
+
+import (
+	"blackjack_trainer/internal/history"
+	"fmt"
+	"strings"
+)
+
+// Severity classifies a wrong answer by the EV it gives up.
+type Severity int
+
+const (
+	// SeverityUnknown is a mistake recorded before mistakes were priced.
+	SeverityUnknown Severity = iota
+	// Harmless mistakes give up less than 0.02 bets, within simulation
+	// noise of the chart's play.
+	Harmless
+	// Minor mistakes give up less than a tenth of a bet.
+	Minor
+	// Costly mistakes give up less than 0.4 bets.
+	Costly
+	// Disastrous mistakes give up 0.4 bets or more, such as standing on 11.
+	Disastrous
+)
+
+// severityLimits are the EV losses, in bets, below which a mistake is
+// Harmless, Minor and Costly.
+var severityLimits = []struct {
+	limit    float64
+	severity Severity
+}{
+	{0.02, Harmless},
+	{0.10, Minor},
+	{0.40, Costly},
+}
+
+var severityNames = map[Severity]string{
+	Harmless:   "harmless",
+	Minor:      "minor",
+	Costly:     "costly",
+	Disastrous: "disastrous",
+}
+
+// ClassifyLoss returns the severity of a mistake that gives up loss bets.
+func ClassifyLoss(loss float64) Severity {
+	for _, s := range severityLimits {
+		if loss < s.limit {
+			return s.severity
+		}
+	}
+	return Disastrous
+}
+
+// ParseSeverity returns the severity with the given name, or
+// SeverityUnknown.
+func ParseSeverity(name string) Severity {
+	for s, n := range severityNames {
+		if n == name {
+			return s
+		}
+	}
+	return SeverityUnknown
+}
+
+// String returns the severity's name, e.g. "costly", or "" if unknown.
+func (s Severity) String() string {
+	return severityNames[s]
+}
+
+// Describe describes a mistake of this severity giving up loss bets, e.g.
+// "costly (gives up ~0.24 bets)".
+func (s Severity) Describe(loss float64) string {
+	return fmt.Sprintf("%s (gives up ~%.2f bets)", s, loss)
+}
+
+// Credit returns the share of a correct answer's score a mistake of this
+// severity keeps, so a weighted score counts cheap mistakes lightly and
+// expensive ones in full. Unpriced mistakes earn nothing, as before.
+func (s Severity) Credit() float64 {
+	switch s {
+	case Harmless:
+		return 0.75
+	case Minor:
+		return 0.5
+	case Costly:
+		return 0.25
+	default:
+		return 0
+	}
+}
+
+// masteryLoss returns the share of its mastery a cell loses to a mistake of
+// this severity: a disastrous one costs most of it, a harmless one little,
+// so the adaptive scheduler returns to the expensive mistakes first.
+func (s Severity) masteryLoss() float64 {
+	switch s {
+	case Harmless:
+		return 0.2
+	case Minor:
+		return 0.35
+	case Disastrous:
+		return 0.7
+	default:
+		return masteryLoss
+	}
+}
+
+// AttemptSeverity returns the severity of a wrong answer, or
+// SeverityUnknown for a correct or unpriced one.
+func AttemptSeverity(attempt history.Attempt) Severity {
+	if attempt.Correct {
+		return SeverityUnknown
+	}
+	return ParseSeverity(attempt.Severity)
+}
+
+// WeightedScore returns the percentage score of the attempts with each
+// mistake given its severity's credit.
+func WeightedScore(attempts []history.Attempt) float64 {
+	if len(attempts) == 0 {
+		return 0
+	}
+	credit := 0.0
+	for _, attempt := range attempts {
+		if attempt.Correct {
+			credit++
+		} else {
+			credit += AttemptSeverity(attempt).Credit()
+		}
+	}
+	return credit / float64(len(attempts)) * 100
+}
+
+// CountSeverities counts the priced wrong answers of each severity.
+func CountSeverities(attempts []history.Attempt) map[Severity]int {
+	counts := make(map[Severity]int)
+	for _, attempt := range attempts {
+		if s := AttemptSeverity(attempt); s != SeverityUnknown {
+			counts[s]++
+		}
+	}
+	return counts
+}
+
+// Severities returns the known severities from most to least expensive.
+func Severities() []Severity {
+	return []Severity{Disastrous, Costly, Minor, Harmless}
+}
+
+// FormatSeverities lists the counts from most to least expensive, e.g.
+// "2 disastrous, 5 costly, 1 harmless", or "" if there are none.
+func FormatSeverities(counts map[Severity]int) string {
+	var parts []string
+	for _, s := range Severities() {
+		if counts[s] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[s], s))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,47 @@
+package stats
+
+import (
+	"blackjack_trainer/internal/history"
+	"fmt"
+	"strings"
+)
+
+// ShareCard returns a compact text summary of a session suitable for
+// pasting into a chat or study-group post. The day streak is the number of
+// consecutive days practiced, including this session.
+func ShareCard(session history.Session, dayStreak int) string {
+	lines := []string{
+		fmt.Sprintf("Mode: %s", session.Mode),
+		fmt.Sprintf("Score: %d/%d (%.1f%%)", session.Correct, session.Total, percentage(session.Correct, session.Total)),
+		fmt.Sprintf("Best streak: %d correct in a row", session.BestStreak()),
+		fmt.Sprintf("Practice streak: %s", plural(dayStreak, "day")),
+		fmt.Sprintf("Date: %s", session.Started.Format("2006-01-02")),
+	}
+
+	title := "Blackjack Strategy Trainer"
+	width := len(title)
+	for _, line := range lines {
+		if len(line) > width {
+			width = len(line)
+		}
+	}
+
+	var b strings.Builder
+	border := "+" + strings.Repeat("-", width+2) + "+\n"
+	b.WriteString(border)
+	fmt.Fprintf(&b, "| %-*s |\n", width, title)
+	b.WriteString(border)
+	for _, line := range lines {
+		fmt.Fprintf(&b, "| %-*s |\n", width, line)
+	}
+	b.WriteString(border)
+	return b.String()
+}
+
+// plural formats a count with a unit, adding "s" when the count is not 1.
+func plural(count int, unit string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, unit)
+	}
+	return fmt.Sprintf("%d %ss", count, unit)
+}
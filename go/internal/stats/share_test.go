@@ -0,0 +1,47 @@
+package stats
+
+import (
+	"blackjack_trainer/internal/history"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test share cards include mode, score, and streaks in a bordered block
+func TestShareCard(t *testing.T) {
+	session := history.Session{
+		Mode:    "absolutes",
+		Started: time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC),
+		Correct: 3,
+		Total:   4,
+		Attempts: []history.Attempt{
+			{Correct: true}, {Correct: true}, {Correct: false}, {Correct: true},
+		},
+	}
+
+	card := ShareCard(session, 1)
+
+	for _, want := range []string{
+		"Mode: absolutes",
+		"Score: 3/4 (75.0%)",
+		"Best streak: 2 correct in a row",
+		"Practice streak: 1 day ",
+		"Date: 2024-03-06",
+	} {
+		if !strings.Contains(card, want) {
+			t.Errorf("Share card missing %q:\n%s", want, card)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(card, "\n"), "\n")
+	for _, line := range lines {
+		if len(line) != len(lines[0]) {
+			t.Errorf("Share card lines should have equal width:\n%s", card)
+			break
+		}
+	}
+
+	if !strings.Contains(ShareCard(session, 5), "Practice streak: 5 days") {
+		t.Error("Share card should pluralize day streak")
+	}
+}
@@ -11,21 +11,31 @@
 // - Strong: 9, 10, A (strong dealer cards)
 //
 // The statistics are maintained for the current session and can be displayed
-// to show the user's progress and identify areas for improvement.
+// to show the user's progress and identify areas for improvement. Practice
+// time is also tracked, both for the current run and (via the persistent
+// session history) for today, this week, and lifetime, and the history is
+// used to score how well each chart cell is known (see ComputeMastery).
 package stats
 
 import (
+	"blackjack_trainer/internal/history"
 	"blackjack_trainer/internal/strategy"
 	"bufio"
 	"fmt"
-	"os"
+	"io"
+	"log/slog"
 	"strings"
+	"time"
 )
 
 // CategoryData tracks correct and total attempts for a category.
 type CategoryData struct {
 	Correct int
 	Total   int
+	// Rated counts the attempts the player rated for difficulty, and
+	// RatingSum adds up their ratings (see history.Attempt.Rating).
+	Rated     int
+	RatingSum int
 }
 
 // Statistics tracks performance metrics for training sessions.
@@ -34,6 +44,8 @@ type Statistics struct {
 	correctAnswers   int
 	byCategory       map[string]*CategoryData
 	byDealerStrength map[string]*CategoryData
+	practiceTime     time.Duration
+	history          *history.History
 }
 
 // New creates a new statistics tracker.
@@ -43,6 +55,7 @@ func New() *Statistics {
 		correctAnswers:   0,
 		byCategory:       make(map[string]*CategoryData),
 		byDealerStrength: make(map[string]*CategoryData),
+		history:          history.New(),
 	}
 
 	// Initialize category tracking
@@ -83,6 +96,56 @@ func (s *Statistics) RecordAttempt(handType strategy.HandType, dealerStrength st
 	}
 }
 
+// SetHistory sets the persistent session history used for lifetime statistics.
+func (s *Statistics) SetHistory(h *history.History) {
+	s.history = h
+}
+
+// History returns the persistent session history.
+func (s *Statistics) History() *history.History {
+	return s.history
+}
+
+// RecordSession records a completed session's practice time and saves it to
+// the session history.
+func (s *Statistics) RecordSession(session history.Session) error {
+	s.practiceTime += session.Duration()
+	s.history.Add(session)
+	if err := s.history.Save(); err != nil {
+		slog.Debug("saving history failed", slog.String("path", s.history.Path()),
+			slog.Int("sessions", len(s.history.Sessions)), slog.Any("error", err))
+		return err
+	}
+	slog.Debug("saved history", slog.String("path", s.history.Path()),
+		slog.Int("sessions", len(s.history.Sessions)), slog.Bool("encrypted", s.history.Encrypted()))
+	return nil
+}
+
+// CheckpointSession saves a session in progress so its answers survive a
+// crash. The session is recorded with RecordSession once it is complete.
+func (s *Statistics) CheckpointSession(session history.Session) error {
+	if err := s.history.Checkpoint(session); err != nil {
+		slog.Debug("checkpoint failed", slog.String("path", s.history.Path()), slog.Any("error", err))
+		return err
+	}
+	return nil
+}
+
+// DiscardSession drops the checkpoint of a session the player chose not to
+// record, so it is not recovered into the history later.
+func (s *Statistics) DiscardSession() error {
+	if err := s.history.DiscardCheckpoint(); err != nil {
+		slog.Debug("discarding checkpoint failed", slog.String("path", s.history.Path()), slog.Any("error", err))
+		return err
+	}
+	return nil
+}
+
+// GetPracticeTime returns the practice time accumulated during this run.
+func (s *Statistics) GetPracticeTime() time.Duration {
+	return s.practiceTime
+}
+
 // GetCategoryAccuracy returns accuracy percentage for a specific category.
 func (s *Statistics) GetCategoryAccuracy(category string) float64 {
 	if data, exists := s.byCategory[category]; exists && data.Total > 0 {
@@ -107,48 +170,151 @@ func (s *Statistics) GetSessionAccuracy() float64 {
 	return (float64(s.correctAnswers) / float64(s.totalAttempts)) * 100.0
 }
 
-// DisplayProgress displays progress statistics to the console.
-func (s *Statistics) DisplayProgress() {
-	fmt.Println("\n" + strings.Repeat("=", 50))
-	fmt.Println("SESSION STATISTICS")
-	fmt.Println(strings.Repeat("=", 50))
+// DisplayProgress writes progress statistics to w, then waits for Enter to
+// be read from in.
+func (s *Statistics) DisplayProgress(w io.Writer, in io.Reader) {
+	fmt.Fprintln(w, "\n"+strings.Repeat("=", 50))
+	fmt.Fprintln(w, "SESSION STATISTICS")
+	fmt.Fprintln(w, strings.Repeat("=", 50))
 
 	if s.totalAttempts == 0 {
-		fmt.Println("No practice attempts yet this session.")
-		fmt.Print("\nPress Enter to continue...")
-		bufio.NewReader(os.Stdin).ReadString('\n')
+		fmt.Fprintln(w, "No practice attempts yet this session.")
+		s.displayPracticeTime(w)
+		s.displayRules(w)
+		s.displayActions(w)
+		s.displayEVLoss(w)
+		s.displayMastery(w)
+		fmt.Fprint(w, "\nPress Enter to continue...")
+		bufio.NewReader(in).ReadString('\n')
 		return
 	}
 
-	fmt.Printf("Overall: %d/%d (%.1f%%)\n",
+	fmt.Fprintf(w, "Overall: %d/%d (%.1f%%)\n",
 		s.correctAnswers, s.totalAttempts, s.GetSessionAccuracy())
 
-	fmt.Println("\nBy Hand Type:")
+	fmt.Fprintln(w, "\nBy Hand Type:")
 	for _, handType := range []string{"hard", "soft", "pair"} {
 		if data, exists := s.byCategory[handType]; exists && data.Total > 0 {
 			accuracy := (float64(data.Correct) / float64(data.Total)) * 100.0
 			capitalized := strings.Title(handType)
-			fmt.Printf("  %s: %d/%d (%.1f%%)\n", capitalized, data.Correct, data.Total, accuracy)
+			fmt.Fprintf(w, "  %s: %d/%d (%.1f%%)\n", capitalized, data.Correct, data.Total, accuracy)
 		}
 	}
 
-	fmt.Println("\nBy Dealer Strength:")
+	fmt.Fprintln(w, "\nBy Dealer Strength:")
 	for _, strength := range []string{"weak", "medium", "strong"} {
 		if data, exists := s.byDealerStrength[strength]; exists && data.Total > 0 {
 			accuracy := (float64(data.Correct) / float64(data.Total)) * 100.0
 			capitalized := strings.Title(strength)
-			fmt.Printf("  %s: %d/%d (%.1f%%)\n", capitalized, data.Correct, data.Total, accuracy)
+			fmt.Fprintf(w, "  %s: %d/%d (%.1f%%)\n", capitalized, data.Correct, data.Total, accuracy)
 		}
 	}
 
-	fmt.Print("\nPress Enter to continue...")
-	bufio.NewReader(os.Stdin).ReadString('\n')
+	s.displayPracticeTime(w)
+	s.displayRules(w)
+	s.displayActions(w)
+	s.displayEVLoss(w)
+	s.displayMastery(w)
+
+	fmt.Fprint(w, "\nPress Enter to continue...")
+	bufio.NewReader(in).ReadString('\n')
+}
+
+// displayPracticeTime displays time practiced this run, today, this week, and lifetime.
+func (s *Statistics) displayPracticeTime(w io.Writer) {
+	now := time.Now()
+
+	fmt.Fprintln(w, "\nPractice Time:")
+	fmt.Fprintf(w, "  This session: %s\n", FormatDuration(s.practiceTime))
+	fmt.Fprintf(w, "  Today: %s\n", FormatDuration(s.history.DurationSince(history.StartOfDay(now))))
+	fmt.Fprintf(w, "  This week: %s\n", FormatDuration(s.history.DurationSince(history.StartOfWeek(now))))
+	fmt.Fprintf(w, "  Lifetime: %s\n", FormatDuration(s.history.TotalDuration()))
+}
+
+// displayRules displays lifetime accuracy under each rule set practiced.
+func (s *Statistics) displayRules(w io.Writer) {
+	byRules := ByRules(s.history.Attempts())
+	if len(byRules) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nLifetime by Rules:")
+	for _, data := range byRules {
+		fmt.Fprintf(w, "  %s: %d/%d (%.1f%%)\n", data.Rules, data.Correct, data.Total, data.Accuracy())
+	}
+}
+
+// displayActions displays lifetime accuracy by the correct action and by
+// hand size, so weaknesses such as doubling show up apart from the hand
+// types.
+func (s *Statistics) displayActions(w io.Writer) {
+	attempts := s.history.Attempts()
+	displayLifetime(w, "Lifetime by Correct Action:", ActionKeys, TallyActions(attempts))
+	displayLifetime(w, "Lifetime by Hand Size:", HandSizeKeys, TallyHandSizes(attempts))
+}
+
+// displayLifetime displays a lifetime breakdown, skipping empty categories,
+// and nothing if all are empty.
+func displayLifetime(w io.Writer, title string, keys []string, data map[string]*CategoryData) {
+	header := false
+	for _, key := range keys {
+		d := data[key]
+		if d.Total == 0 {
+			continue
+		}
+		if !header {
+			fmt.Fprintln(w, "\n"+title)
+			header = true
+		}
+		fmt.Fprintf(w, "  %s: %d/%d (%.1f%%)\n", strings.Title(key), d.Correct, d.Total, d.Accuracy())
+	}
+}
+
+// displayEVLoss displays the EV given up by recent mistakes against the
+// sessions before, once sessions have been priced.
+func (s *Statistics) displayEVLoss(w io.Writer) {
+	recent, before := EVLossTrend(s.history)
+	if recent.Hands == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nEV Lost to Mistakes:")
+	fmt.Fprintf(w, "  Last %d session(s): %s\n", recent.Sessions, recent)
+	if before.Hands > 0 {
+		fmt.Fprintf(w, "  %d before: %s\n", before.Sessions, before)
+	}
+	if counts := FormatSeverities(CountSeverities(s.history.Attempts())); counts != "" {
+		fmt.Fprintf(w, "  Lifetime mistakes: %s\n", counts)
+	}
+}
+
+// displayMastery displays how much of the chart has been mastered, from the
+// session history.
+func (s *Statistics) displayMastery(w io.Writer) {
+	fmt.Fprintln(w, "\nChart Mastery:")
+	fmt.Fprintf(w, "  %s\n", ComputeMastery(s.history, time.Now()).Summary())
+}
+
+// FormatDuration formats a duration for display (e.g. "1h 05m", "12m 30s", "45s").
+func FormatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	hours := int(d / time.Hour)
+	minutes := int(d % time.Hour / time.Minute)
+	seconds := int(d % time.Minute / time.Second)
+
+	switch {
+	case hours > 0:
+		return fmt.Sprintf("%dh %02dm", hours, minutes)
+	case minutes > 0:
+		return fmt.Sprintf("%dm %02ds", minutes, seconds)
+	default:
+		return fmt.Sprintf("%ds", seconds)
+	}
 }
 
 // ResetSession resets session statistics.
 func (s *Statistics) ResetSession() {
 	s.totalAttempts = 0
 	s.correctAnswers = 0
+	s.practiceTime = 0
 
 	for _, category := range s.byCategory {
 		category.Correct = 0
@@ -163,6 +329,11 @@ func (s *Statistics) ResetSession() {
 
 // GetDealerStrength determines dealer strength from dealer card.
 func (s *Statistics) GetDealerStrength(dealerCard int) string {
+	return dealerStrength(dealerCard)
+}
+
+// dealerStrength classifies a dealer card as weak, medium, or strong.
+func dealerStrength(dealerCard int) string {
 	switch dealerCard {
 	case 4, 5, 6:
 		return "weak"
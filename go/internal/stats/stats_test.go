@@ -1,9 +1,13 @@
 package stats
 
 import (
+	"blackjack_trainer/internal/history"
 	"blackjack_trainer/internal/strategy"
+	"io"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 // Test initial state of new statistics tracker
@@ -259,11 +263,24 @@ func TestStatistics_DisplayProgress(t *testing.T) {
 				byCategory:       tt.fields.byCategory,
 				byDealerStrength: tt.fields.byDealerStrength,
 			}
-			s.DisplayProgress()
+			s.DisplayProgress(io.Discard, strings.NewReader("\n"))
 		})
 	}
 }
 
+// Test every section of the progress display goes to the writer given
+func TestDisplayProgressWriter(t *testing.T) {
+	s := New()
+	s.RecordAttempt(strategy.HandTypeHard, "weak", true)
+	var out strings.Builder
+	s.DisplayProgress(&out, strings.NewReader("\n"))
+	for _, want := range []string{"SESSION STATISTICS", "Overall: 1/1 (100.0%)", "Hard: 1/1", "Practice Time:", "Chart Mastery:", "Press Enter to continue..."} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Output missing %q:\n%s", want, out.String())
+		}
+	}
+}
+
 func TestStatistics_GetCategoryAccuracy(t *testing.T) {
 	type fields struct {
 		totalAttempts    int
@@ -449,3 +466,44 @@ func TestStatistics_ResetSession(t *testing.T) {
 		})
 	}
 }
+
+// Test duration formatting
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		want     string
+	}{
+		{0, "0s"},
+		{45 * time.Second, "45s"},
+		{12*time.Minute + 30*time.Second, "12m 30s"},
+		{65 * time.Minute, "1h 05m"},
+		{1500 * time.Millisecond, "2s"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatDuration(tt.duration); got != tt.want {
+			t.Errorf("FormatDuration(%v) = %q, want %q", tt.duration, got, tt.want)
+		}
+	}
+}
+
+// Test recording sessions accumulates practice time and updates history
+func TestRecordSession(t *testing.T) {
+	stats := New()
+	started := time.Now()
+
+	stats.RecordSession(history.Session{Mode: "random", Started: started, Ended: started.Add(3 * time.Minute)})
+	stats.RecordSession(history.Session{Mode: "absolutes", Started: started, Ended: started.Add(2 * time.Minute)})
+
+	if practice := stats.GetPracticeTime(); practice != 5*time.Minute {
+		t.Errorf("Expected 5m practice time, got %v", practice)
+	}
+	if sessions := len(stats.History().Sessions); sessions != 2 {
+		t.Errorf("Expected 2 sessions in history, got %d", sessions)
+	}
+
+	stats.ResetSession()
+	if practice := stats.GetPracticeTime(); practice != 0 {
+		t.Errorf("Practice time should be 0 after reset, got %v", practice)
+	}
+}
@@ -0,0 +1,164 @@
+package strategy
+
+import (
+	"blackjack_trainer/internal/hand"
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Charts can be saved to and loaded from a plain text file with one row per
+// player hand and one action per dealer card, 2 through A:
+//
+//	# Blackjack strategy chart
+//	rules standard
+//	#         2  3  4  5  6  7  8  9  10 A
+//	hard 12   H  H  S  S  S  H  H  H  H  H
+//	soft 18   S  D  D  D  D  S  S  H  H  H
+//	pair A    Y  Y  Y  Y  Y  Y  Y  Y  Y  Y
+//
+// Blank lines and lines starting with # are ignored. Pair rows are labelled
+// by card. The rules line names the rule set the chart is for and defaults
+// to standard.
+
+// Export writes the chart in the text format read by Load.
+func (c *StrategyChart) Export(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "# Blackjack strategy chart")
+	fmt.Fprintf(bw, "rules %s\n", c.rules.Key)
+
+	for _, section := range chartSections() {
+		fmt.Fprintf(bw, "\n#         2  3  4  5  6  7  8  9  10 A\n")
+		for _, total := range section.totals {
+			label := strconv.Itoa(total)
+			if section.handType == HandTypePair {
+				label = CardToString(total)
+			}
+			actions := make([]string, 0, 10)
+			for dealer := 2; dealer <= 11; dealer++ {
+				actions = append(actions, string(c.GetCorrectAction(section.handType, total, dealer)))
+			}
+			fmt.Fprintf(bw, "%-4s %-4s %s\n", section.handType, label, strings.Join(actions, "  "))
+		}
+	}
+	return bw.Flush()
+}
+
+// Load reads a chart in the format written by Export. Every cell must be
+// given exactly once, and the chart must pass Validate and CheckInvariants.
+func Load(r io.Reader) (*StrategyChart, error) {
+	c := &StrategyChart{
+		mnemonics:    make(map[MnemonicKey]string),
+		dealerGroups: make(map[string][]int),
+		rules:        Standard,
+		notes:        make(map[ruleCell]string),
+	}
+	c.buildMnemonics()
+	c.buildDealerGroups()
+
+	rulesSeen := false
+	seenRows := make(map[ruleCell]bool)
+	scanner := bufio.NewScanner(r)
+	for number := 1; scanner.Scan(); number++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		if fields[0] == "rules" {
+			if len(fields) != 2 || rulesSeen {
+				return nil, fmt.Errorf("line %d: expected a single \"rules <name>\" line", number)
+			}
+			rules, err := chartRules(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", number, err)
+			}
+			c.rules = rules
+			rulesSeen = true
+			continue
+		}
+
+		handType, total, err := parseRowLabel(fields)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", number, err)
+		}
+		row := ruleCell{handType, HandKey{PlayerTotal: total}}
+		if seenRows[row] {
+			return nil, fmt.Errorf("line %d: %s %d given twice", number, handType, total)
+		}
+		seenRows[row] = true
+
+		if len(fields) != 12 {
+			return nil, fmt.Errorf("line %d: expected 10 actions (dealer 2 through A), got %d", number, len(fields)-2)
+		}
+		for i, field := range fields[2:] {
+			if len(field) != 1 || !containsAction("HSDYP", rune(field[0])) {
+				return nil, fmt.Errorf("line %d: invalid action %q", number, field)
+			}
+			action := rune(field[0])
+			if action == 'P' {
+				action = 'Y'
+			}
+			c.section(handType)[total][i+2] = action
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	problems := append(Validate(c).Problems, CheckInvariants(c)...)
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid chart: %s (%d problem(s) in all)", problems[0], len(problems))
+	}
+	return c, nil
+}
+
+// parseRowLabel parses the hand type and total that start a chart row.
+func parseRowLabel(fields []string) (HandType, int, error) {
+	if len(fields) < 2 {
+		return 0, 0, fmt.Errorf("expected a hand type and total")
+	}
+	for _, section := range chartSections() {
+		if fields[0] != section.handType.String() {
+			continue
+		}
+		var total int
+		var err error
+		if section.handType == HandTypePair {
+			total, err = hand.ParseCard(fields[1])
+		} else {
+			total, err = strconv.Atoi(fields[1])
+		}
+		if err != nil || !containsTotal(section.totals, total) {
+			return 0, 0, fmt.Errorf("invalid %s total %q", section.handType, fields[1])
+		}
+		return section.handType, total, nil
+	}
+	return 0, 0, fmt.Errorf("unknown hand type %q (valid: hard, soft, pair)", fields[0])
+}
+
+// chartRules returns the rule set for a chart file's rules line: a preset,
+// or the rules of a game variant.
+func chartRules(key string) (RuleSet, error) {
+	if key == freeBetRules.Key {
+		return freeBetRules, nil
+	}
+	return LookupRules(key)
+}
+
+// chartSection is a section of the chart and the totals in it.
+type chartSection struct {
+	handType HandType
+	totals   []int
+}
+
+// chartSections lists the chart sections in chart order.
+func chartSections() []chartSection {
+	return []chartSection{
+		{HandTypeHard, totalRange(5, 21)},
+		{HandTypeSoft, totalRange(13, 21)},
+		{HandTypePair, totalRange(2, 11)},
+	}
+}
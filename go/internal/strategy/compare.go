@@ -0,0 +1,45 @@
+package strategy
+
+// Difference is a chart cell whose action differs between two charts.
+type Difference struct {
+	HandType    HandType
+	PlayerTotal int
+	DealerCard  int
+	// From and To are the actions in the first and second chart.
+	From rune
+	To   rune
+	// Reason explains the rule behind the second chart's action, and
+	// FromReason the rule behind the first chart's. Each is empty where
+	// that chart plays the base chart's action.
+	Reason     string
+	FromReason string
+}
+
+// Compare returns the cells whose action differs between two charts, in
+// chart order: hard totals, soft totals, then pairs, each by total and
+// dealer card.
+func Compare(from, to *StrategyChart) []Difference {
+	var diffs []Difference
+	for _, section := range chartSections() {
+		for _, total := range section.totals {
+			for dealer := 2; dealer <= 11; dealer++ {
+				a := from.GetCorrectAction(section.handType, total, dealer)
+				b := to.GetCorrectAction(section.handType, total, dealer)
+				if a == b {
+					continue
+				}
+				cell := ruleCell{section.handType, HandKey{total, dealer}}
+				diffs = append(diffs, Difference{
+					HandType:    section.handType,
+					PlayerTotal: total,
+					DealerCard:  dealer,
+					From:        a,
+					To:          b,
+					Reason:      to.notes[cell],
+					FromReason:  from.notes[cell],
+				})
+			}
+		}
+	}
+	return diffs
+}
@@ -0,0 +1,115 @@
+package strategy
+
+import "blackjack_trainer/internal/hand"
+
+// Exception is a composition-dependent play: a hard total where the cards
+// that make it up change the correct action against some dealer cards.
+// The chart itself is total-dependent, so exceptions are only applied by the
+// composition lookups below.
+type Exception struct {
+	// Name describes the exception, e.g. "16 of three or more cards vs 10".
+	Name string
+	// Total is the hard total the exception applies to.
+	Total int
+	// DealerCards are the dealer up-cards the exception applies against.
+	DealerCards []int
+	// Action is the composition-dependent play.
+	Action rune
+	// Explanation says why the cards matter.
+	Explanation string
+
+	maxDecks int // 0 for any number of decks
+	matches  func(cards []int) bool
+}
+
+// Matches reports whether a hand has the composition the exception is for.
+func (e Exception) Matches(h hand.Hand) bool {
+	return !h.IsSoft() && !h.IsPair() && h.Total() == e.Total && e.matches(h.Cards)
+}
+
+// exceptions are the composition-dependent plays the trainer knows, for
+// dealer stands or hits on soft 17 alike.
+var exceptions = []Exception{
+	{
+		Name:        "16 of three or more cards vs 10",
+		Total:       16,
+		DealerCards: []int{10},
+		Action:      'S',
+		Explanation: "Three or more small cards use up the cards that would help you; stand on a multi-card 16 vs 10",
+		matches:     func(cards []int) bool { return len(cards) >= 3 },
+	},
+	{
+		Name:        "12 of 10-2 vs 4",
+		Total:       12,
+		DealerCards: []int{4},
+		Action:      'H',
+		Explanation: "With one or two decks, the ten in 10-2 is one fewer ten to bust you; hit 10-2 vs 4",
+		maxDecks:    2,
+		matches:     func(cards []int) bool { return twoCards(cards, 10, 2) },
+	},
+	{
+		Name:        "8 of 6-2 vs 5 or 6",
+		Total:       8,
+		DealerCards: []int{5, 6},
+		Action:      'H',
+		Explanation: "With one or two decks, the 6 and 2 take away small cards you need; hit 6-2 rather than double",
+		maxDecks:    2,
+		matches:     func(cards []int) bool { return twoCards(cards, 6, 2) },
+	},
+}
+
+// twoCards reports whether cards are exactly a and b, in either order.
+func twoCards(cards []int, a, b int) bool {
+	return len(cards) == 2 && (cards[0] == a && cards[1] == b || cards[0] == b && cards[1] == a)
+}
+
+// Exceptions returns the composition-dependent exceptions for the chart's
+// rules, each limited to the dealer cards where it changes the chart's play.
+func (c *StrategyChart) Exceptions() []Exception {
+	var result []Exception
+	for _, e := range exceptions {
+		if e.maxDecks > 0 && c.rules.Decks > e.maxDecks {
+			continue
+		}
+		var dealers []int
+		for _, dealer := range e.DealerCards {
+			if c.GetCorrectAction(HandTypeHard, e.Total, dealer) != e.Action {
+				dealers = append(dealers, dealer)
+			}
+		}
+		if len(dealers) > 0 {
+			e.DealerCards = dealers
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// ExceptionForHand returns the composition-dependent exception that applies
+// to a hand against a dealer card, if any.
+func (c *StrategyChart) ExceptionForHand(h hand.Hand, dealerCard int) (Exception, bool) {
+	for _, e := range c.Exceptions() {
+		if e.Matches(h) && containsTotal(e.DealerCards, dealerCard) {
+			return e, true
+		}
+	}
+	return Exception{}, false
+}
+
+// GetCompositionActionForHand returns the correct action for a hand taking
+// its exact cards into account: an applicable exception overrides the chart.
+func (c *StrategyChart) GetCompositionActionForHand(h hand.Hand, dealerCard int) rune {
+	if e, ok := c.ExceptionForHand(h, dealerCard); ok {
+		return e.Action
+	}
+	return c.GetCorrectActionForHand(h, dealerCard)
+}
+
+// GetCompositionExplanationForHand returns the explanation matching
+// GetCompositionActionForHand.
+func (c *StrategyChart) GetCompositionExplanationForHand(h hand.Hand, dealerCard int) string {
+	if e, ok := c.ExceptionForHand(h, dealerCard); ok {
+		return e.Explanation
+	}
+	return c.GetExplanationForHand(h, dealerCard)
+}
@@ -0,0 +1,140 @@
+package strategy
+
+import "fmt"
+
+// DealerOutcome is how a dealer's hand ends up from one upcard, for an
+// infinite deck.
+type DealerOutcome struct {
+	// Upcard is the dealer's face-up card, 2-11 with 11 for an ace.
+	Upcard int
+	// Totals holds the chances of finishing on 17, 18, 19, 20 and 21 (not
+	// counting a blackjack).
+	Totals [5]float64
+	// Blackjack is the chance of a two-card 21. It is zero for a 10 or ace
+	// under rules where the dealer checks for blackjack before the player
+	// acts, since the player only faces the hands that turned out not to be.
+	Blackjack float64
+	// Bust is the chance of going over 21.
+	Bust float64
+}
+
+// cardChance returns the chance of drawing a card from an infinite deck:
+// four ranks count as 10.
+func cardChance(card int) float64 {
+	if card == 10 {
+		return 4.0 / 13
+	}
+	return 1.0 / 13
+}
+
+// DealerOutcomes returns the outcome of the dealer's hand from each upcard,
+// 2 through ace, under the rules' soft 17 and peek rules.
+func DealerOutcomes(r RuleSet) []DealerOutcome {
+	outcomes := make([]DealerOutcome, 0, maxDealerCard-1)
+	for upcard := 2; upcard <= maxDealerCard; upcard++ {
+		outcomes = append(outcomes, DealerOutcomeFor(r, upcard))
+	}
+	return outcomes
+}
+
+// DealerOutcomeFor returns the outcome of the dealer's hand from an upcard,
+// 2-11 with 11 for an ace, under the rules' soft 17 and peek rules.
+func DealerOutcomeFor(r RuleSet, upcard int) DealerOutcome {
+	outcome := DealerOutcome{Upcard: upcard}
+	finishes := make(map[dealerHand][6]float64)
+	start := dealerHand{}.draw(upcard)
+	var excluded float64
+	for hole := 2; hole <= 11; hole++ {
+		chance := cardChance(hole)
+		h := start.draw(hole)
+		if h.total == 21 {
+			if r.HoleCard {
+				excluded += chance
+			} else {
+				outcome.Blackjack += chance
+			}
+			continue
+		}
+		finish := h.finish(r.DealerHitsSoft17, finishes)
+		for i := range outcome.Totals {
+			outcome.Totals[i] += chance * finish[i]
+		}
+		outcome.Bust += chance * finish[5]
+	}
+
+	// A peeked blackjack ends the round before the player acts, so the
+	// outcomes are those of the hands left
+	if excluded > 0 {
+		for i := range outcome.Totals {
+			outcome.Totals[i] /= 1 - excluded
+		}
+		outcome.Bust /= 1 - excluded
+	}
+	return outcome
+}
+
+// dealerHand is a dealer's hand as the drawing rules see it: the total,
+// and whether an ace in it is counted as 11.
+type dealerHand struct {
+	total int
+	soft  bool
+}
+
+// draw returns the hand after drawing a card, counting an ace as 11 when
+// that doesn't bust and falling back on 1 when a later card would.
+func (h dealerHand) draw(card int) dealerHand {
+	h.total += card
+	if card == 11 {
+		if h.total > 21 {
+			h.total -= 10
+		} else {
+			h.soft = true
+		}
+	}
+	if h.total > 21 && h.soft {
+		h.total -= 10
+		h.soft = false
+	}
+	return h
+}
+
+// finish returns the chances of the hand ending on 17 through 21 and of
+// busting, in that order, as the dealer draws to 17. Results are memoized
+// in finishes.
+func (h dealerHand) finish(hitSoft17 bool, finishes map[dealerHand][6]float64) [6]float64 {
+	var result [6]float64
+	switch {
+	case h.total > 21:
+		result[5] = 1
+		return result
+	case h.total > 17 || h.total == 17 && !(h.soft && hitSoft17):
+		result[h.total-17] = 1
+		return result
+	}
+	if cached, ok := finishes[h]; ok {
+		return cached
+	}
+	for card := 2; card <= 11; card++ {
+		next := h.draw(card).finish(hitSoft17, finishes)
+		for i := range result {
+			result[i] += cardChance(card) * next[i]
+		}
+	}
+	finishes[h] = result
+	return result
+}
+
+// DealerBustNote states how often the dealer busts from the upcard, e.g.
+// "Dealer 6 busts ~42% of the time.", to ground an explanation based on the
+// dealer's strength in numbers. It returns "" for other explanations.
+func (c *StrategyChart) DealerBustNote(explanation string, dealerCard int) string {
+	if explanation != c.mnemonics[MnemonicDealerWeak] && explanation != c.mnemonics[MnemonicTeensVsStrong] {
+		return ""
+	}
+	outcome := DealerOutcomeFor(c.rules, dealerCard)
+	note := fmt.Sprintf("Dealer %s busts ~%.0f%% of the time", CardToString(dealerCard), outcome.Bust*100)
+	if c.rules.HoleCard && (dealerCard == 10 || dealerCard == 11) {
+		note += " once blackjack is ruled out"
+	}
+	return note + "."
+}
@@ -0,0 +1,119 @@
+package strategy
+
+import "fmt"
+
+// baseEdge is the house edge, in percent of the initial bet, against a
+// basic strategy player in the game the rule effects are measured from:
+// one deck, dealer stands on soft 17, double any two cards but not after
+// splitting, no resplitting aces, no surrender, hole card, 3:2.
+const baseEdge = 0.0
+
+// deckEffects is the change in house edge from adding decks to the base
+// single-deck game. Deck counts between those listed use the next lower.
+var deckEffects = []struct {
+	decks  int
+	effect float64
+}{
+	{1, 0},
+	{2, 0.32},
+	{4, 0.49},
+	{5, 0.52},
+	{6, 0.54},
+	{8, 0.57},
+}
+
+// Rule effects on the house edge, in percent of the initial bet. These are
+// the usual published approximations for a basic strategy player; the
+// effects of combined rules are close to, but not exactly, their sum.
+const (
+	hitSoft17Effect          = 0.20
+	doubleAfterSplitEffect   = -0.14
+	doubleNineToElevenEffect = 0.09
+	resplitAcesEffect        = -0.08
+	lateSurrenderEffect      = -0.08
+	noHoleCardEffect         = 0.11
+)
+
+// EdgeOptions are rules the house edge depends on that RuleSet doesn't
+// record, because they never change the chart.
+type EdgeOptions struct {
+	// ResplitAces allows splitting again when a split ace draws an ace.
+	ResplitAces bool
+}
+
+// RuleEffect is one rule's change to the house edge.
+type RuleEffect struct {
+	// Rule describes the rule, e.g. "Dealer hits soft 17".
+	Rule string
+	// Effect is the change in house edge in percent of the initial bet;
+	// negative effects favor the player.
+	Effect float64
+}
+
+// Edge is an approximate house edge with the rule effects that make it up.
+type Edge struct {
+	Rules RuleSet
+	// Base is the house edge of the base game the effects are measured from:
+	// one deck, S17, no double after split, 3:2.
+	Base    float64
+	Effects []RuleEffect
+}
+
+// Total returns the house edge in percent of the initial bet: the base game's
+// edge plus every rule effect.
+func (e Edge) Total() float64 {
+	total := e.Base
+	for _, effect := range e.Effects {
+		total += effect.Effect
+	}
+	return total
+}
+
+// HouseEdge approximates the house edge of the rules against a player who
+// follows basic strategy, from a table of each rule's effect on a base
+// single-deck game. Rules that match the base game contribute nothing and
+// aren't listed.
+func HouseEdge(r RuleSet, opts EdgeOptions) Edge {
+	e := Edge{Rules: r, Base: baseEdge}
+	add := func(rule string, effect float64) {
+		e.Effects = append(e.Effects, RuleEffect{Rule: rule, Effect: effect})
+	}
+
+	if decks := deckEffect(r.Decks); decks != 0 {
+		add(fmt.Sprintf("%d decks", r.Decks), decks)
+	}
+	if r.DealerHitsSoft17 {
+		add("Dealer hits soft 17", hitSoft17Effect)
+	}
+	if r.DoubleAfterSplit {
+		add("Double after split", doubleAfterSplitEffect)
+	}
+	if r.Double == DoubleNineToEleven {
+		add("Double on hard 9-11 only", doubleNineToElevenEffect)
+	}
+	if opts.ResplitAces {
+		add("Resplit aces", resplitAcesEffect)
+	}
+	if r.LateSurrender {
+		add("Late surrender", lateSurrenderEffect)
+	}
+	if !r.HoleCard {
+		add("No hole card", noHoleCardEffect)
+	}
+	if cost := r.PayoutCost(); cost != 0 {
+		add("Blackjack pays "+r.BlackjackPays, cost)
+	}
+	return e
+}
+
+// deckEffect returns the change in house edge from playing with the given
+// number of decks instead of one.
+func deckEffect(decks int) float64 {
+	effect := 0.0
+	for _, d := range deckEffects {
+		if decks >= d.decks {
+			effect = d.effect
+		}
+	}
+	return effect
+}
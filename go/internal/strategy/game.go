@@ -0,0 +1,172 @@
+package strategy
+
+import (
+	"blackjack_trainer/internal/hand"
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// Game is a blackjack variant. Each variant has its own strategy chart and
+// deals the decisions worth practicing for it.
+type Game interface {
+	// Key is the short name used to select the game, e.g. "free-bet".
+	Key() string
+	// Name is the display name, e.g. "Free Bet".
+	Name() string
+	// Description summarizes how the game differs from classic blackjack.
+	Description() string
+	// Chart returns the game's strategy chart.
+	Chart() *StrategyChart
+	// Deal returns a practice decision: a player hand and dealer up-card.
+	Deal(rng *rand.Rand) (hand.Hand, int)
+}
+
+// GameKeys lists the keys accepted by NewGame.
+func GameKeys() []string {
+	return []string{"classic", "free-bet"}
+}
+
+// NewGame creates the game with the given key. Classic blackjack uses the
+// rule set; variants have fixed rules of their own, so only the standard
+// rules may be combined with them.
+func NewGame(key string, rules RuleSet) (Game, error) {
+	switch strings.ToLower(key) {
+	case "", "classic":
+		if rules == Standard {
+			return Classic{chart: Default()}, nil
+		}
+		return Classic{chart: NewForRules(rules)}, nil
+	case "free-bet", "freebet":
+		if rules.Key != Standard.Key {
+			return nil, fmt.Errorf("free-bet has its own rules and cannot be combined with %s", rules.Name)
+		}
+		return FreeBet{chart: newFreeBetChart()}, nil
+	default:
+		return nil, fmt.Errorf("unknown game %q (valid: %s)", key, strings.Join(GameKeys(), ", "))
+	}
+}
+
+// Classic is standard blackjack under a rule set.
+type Classic struct {
+	chart *StrategyChart
+}
+
+// NewClassic returns classic blackjack played by a given chart, such as one
+// read with Load.
+func NewClassic(chart *StrategyChart) Classic {
+	return Classic{chart: chart}
+}
+
+// Key returns "classic".
+func (g Classic) Key() string { return "classic" }
+
+// Name returns the display name.
+func (g Classic) Name() string { return "Classic" }
+
+// Description summarizes the rules.
+func (g Classic) Description() string {
+	return "Classic blackjack: " + g.chart.Rules().Summary()
+}
+
+// Chart returns the chart for the rule set.
+func (g Classic) Chart() *StrategyChart { return g.chart }
+
+// Deal picks a hard, soft or pair hand with equal chance against any dealer card.
+func (g Classic) Deal(rng *rand.Rand) (hand.Hand, int) {
+	return dealAny(rng), rng.Intn(10) + 2
+}
+
+// FreeBet is Free Bet Blackjack: the casino pays for doubles on hard 9-11
+// and splits of any pair except tens, and a dealer 22 pushes every hand
+// that is not a blackjack. Free actions cost nothing, so they are always
+// taken.
+type FreeBet struct {
+	chart *StrategyChart
+}
+
+// freeBetRules are the table rules of Free Bet Blackjack.
+var freeBetRules = RuleSet{
+	Key:              "free-bet",
+	Name:             "Free Bet",
+	Decks:            6,
+	DealerHitsSoft17: true,
+	DoubleAfterSplit: true,
+	Double:           DoubleAnyTwo,
+	HoleCard:         true,
+	BlackjackPays:    "3:2",
+}
+
+// newFreeBetChart adjusts the chart for the Free Bet rules. Free doubles and
+// splits are always taken, except 9,9 against 7, 10 and A, where standing
+// on 18 is still better than two hands of 9.
+func newFreeBetChart() *StrategyChart {
+	c := NewForRules(freeBetRules)
+
+	const doubleNote = "Free Bet: doubling 9, 10 and 11 is free, so always double"
+	for _, total := range []int{9, 10, 11} {
+		c.adjust(HandTypeHard, total, 'D', doubleNote, totalRange(2, 11)...)
+	}
+	c.adjust(HandTypePair, 5, 'D', doubleNote, totalRange(2, 11)...)
+
+	const splitNote = "Free Bet: splitting is free (except tens), so split"
+	for _, pair := range []int{2, 3, 4, 6, 7, 8, 11} {
+		c.adjust(HandTypePair, pair, 'Y', splitNote, totalRange(2, 11)...)
+	}
+	c.adjust(HandTypePair, 9, 'Y', splitNote, 2, 3, 4, 5, 6, 8, 9)
+	return c
+}
+
+// Key returns "free-bet".
+func (g FreeBet) Key() string { return "free-bet" }
+
+// Name returns the display name.
+func (g FreeBet) Name() string { return "Free Bet" }
+
+// Description summarizes the variant.
+func (g FreeBet) Description() string {
+	return "Free Bet: free doubles on hard 9-11, free splits except tens, dealer 22 pushes"
+}
+
+// Chart returns the Free Bet chart.
+func (g FreeBet) Chart() *StrategyChart { return g.chart }
+
+// Deal favors the free doubles and splits that set the game apart: half the
+// decisions are a hard 9-11 or a pair, the rest are dealt as in Classic.
+func (g FreeBet) Deal(rng *rand.Rand) (hand.Hand, int) {
+	dealer := rng.Intn(10) + 2
+	if rng.Intn(2) == 0 {
+		return dealAny(rng), dealer
+	}
+	if rng.Intn(2) == 0 {
+		pair := rng.Intn(10) + 2
+		return hand.New(pair, pair), dealer
+	}
+	total := rng.Intn(3) + 9
+	for {
+		first := rng.Intn(total-3) + 2
+		if second := total - first; second != first {
+			return hand.New(first, second), dealer
+		}
+	}
+}
+
+// dealAny returns a random two-card hand: hard, soft or pair with equal chance.
+func dealAny(rng *rand.Rand) hand.Hand {
+	switch rng.Intn(3) {
+	case 0:
+		pair := rng.Intn(10) + 2
+		return hand.New(pair, pair)
+	case 1:
+		return hand.New(hand.Ace, rng.Intn(8)+2)
+	default:
+		total := rng.Intn(15) + 5 // 5-19; two different cards can't make 20
+		for {
+			first := rng.Intn(9) + 2
+			second := total - first
+			if second >= 2 && second <= 10 && second != first {
+				return hand.New(first, second)
+			}
+		}
+	}
+}
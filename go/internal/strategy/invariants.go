@@ -0,0 +1,61 @@
+package strategy
+
+import "fmt"
+
+// CheckInvariants checks properties every sound chart has, whatever its
+// rules, and returns a problem for each cell that breaks one:
+// - Hard 11 or less never stands, since another card can't bust it
+// - Soft 17 or less never stands, since the ace can drop back to 1
+// - Standing on a hard total means standing on every higher hard total
+// against the same dealer card
+// - Standing on a soft total means standing (or doubling) on every higher
+// soft total against the same dealer card
+// - Tens and fives are never split
+//
+// Validate checks that a chart is well formed; CheckInvariants checks that
+// its plays make sense, so it is most useful on charts built or loaded from
+// outside the package.
+func CheckInvariants(c *StrategyChart) []Problem {
+	var problems []Problem
+	problem := func(handType HandType, total, dealer int, format string, args ...interface{}) {
+		problems = append(problems, Problem{
+			HandType:    handType,
+			PlayerTotal: total,
+			DealerCard:  dealer,
+			Message:     fmt.Sprintf(format, args...),
+		})
+	}
+
+	for dealer := 2; dealer <= 11; dealer++ {
+		for total := 5; total <= 21; total++ {
+			action := c.GetCorrectAction(HandTypeHard, total, dealer)
+			if total <= 11 && action == 'S' {
+				problem(HandTypeHard, total, dealer, "stands on a total that can't bust")
+			}
+			if total < 21 && action == 'S' {
+				if next := c.GetCorrectAction(HandTypeHard, total+1, dealer); next != 'S' {
+					problem(HandTypeHard, total+1, dealer, "plays %c but hard %d stands", next, total)
+				}
+			}
+		}
+
+		for total := 13; total <= 21; total++ {
+			action := c.GetCorrectAction(HandTypeSoft, total, dealer)
+			if total <= 17 && action == 'S' {
+				problem(HandTypeSoft, total, dealer, "stands on a soft total of 17 or less")
+			}
+			if total < 21 && action == 'S' {
+				if next := c.GetCorrectAction(HandTypeSoft, total+1, dealer); next != 'S' && next != 'D' {
+					problem(HandTypeSoft, total+1, dealer, "plays %c but soft %d stands", next, total)
+				}
+			}
+		}
+
+		for _, pair := range []int{5, 10} {
+			if c.GetCorrectAction(HandTypePair, pair, dealer) == 'Y' {
+				problem(HandTypePair, pair, dealer, "splits a pair that should never be split")
+			}
+		}
+	}
+	return problems
+}
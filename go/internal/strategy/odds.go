@@ -0,0 +1,39 @@
+package strategy
+
+// HitBustChance returns the chance that taking a card busts a hard total,
+// drawing from an infinite deck.
+func HitBustChance(total int) float64 {
+	var bust float64
+	for card := 2; card <= 11; card++ {
+		if total+hardValue(card) > 21 {
+			bust += cardChance(card)
+		}
+	}
+	return bust
+}
+
+// HitBustChanceFrom returns the chance that taking a card busts a hard
+// total, drawing from the cards left: counts[c] is the number of cards of
+// value c, 2-11 with 11 for aces. With no cards left it falls back on an
+// infinite deck.
+func HitBustChanceFrom(total int, counts [12]int) float64 {
+	var bust, left int
+	for card := 2; card <= 11; card++ {
+		left += counts[card]
+		if total+hardValue(card) > 21 {
+			bust += counts[card]
+		}
+	}
+	if left == 0 {
+		return HitBustChance(total)
+	}
+	return float64(bust) / float64(left)
+}
+
+// hardValue returns what a card adds to a hard total: an ace counts 1.
+func hardValue(card int) int {
+	if card == 11 {
+		return 1
+	}
+	return card
+}
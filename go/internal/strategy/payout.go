@@ -0,0 +1,59 @@
+package strategy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FullPayout is the traditional payout for a natural, 3 to 2.
+const FullPayout = 1.5
+
+// Payout returns what a natural pays per unit bet: 1.5 for "3:2", 1.2 for
+// "6:5". Unreadable payouts are taken as 3:2.
+func (r RuleSet) Payout() float64 {
+	win, bet, ok := strings.Cut(r.BlackjackPays, ":")
+	if !ok {
+		return FullPayout
+	}
+	w, err1 := strconv.Atoi(strings.TrimSpace(win))
+	b, err2 := strconv.Atoi(strings.TrimSpace(bet))
+	if err1 != nil || err2 != nil || w <= 0 || b <= 0 {
+		return FullPayout
+	}
+	return float64(w) / float64(b)
+}
+
+// BlackjackChance returns the chance of being dealt a natural from a full
+// shoe of the given number of decks.
+func BlackjackChance(decks int) float64 {
+	cards := float64(52 * decks)
+	aces, tens := float64(4*decks), float64(16*decks)
+	return 2 * aces / cards * tens / (cards - 1)
+}
+
+// PayoutCost returns how much the rules' blackjack payout adds to the house
+// edge compared with 3:2, as a percentage of the initial bet. A natural is
+// paid unless the dealer has one too, so the cost is the chance of a paid
+// natural times the difference in payout.
+func (r RuleSet) PayoutCost() float64 {
+	decks := r.Decks
+	if decks < 1 {
+		decks = 1
+	}
+	cards := float64(52 * decks)
+	aces, tens := float64(4*decks), float64(16*decks)
+	// The dealer's natural is drawn from the shoe less the player's ace and ten
+	dealerNatural := 2 * (aces - 1) / (cards - 2) * (tens - 1) / (cards - 3)
+	return BlackjackChance(decks) * (1 - dealerNatural) * (FullPayout - r.Payout()) * 100
+}
+
+// PayoutWarning warns about a blackjack payout below 3:2, saying what it
+// costs, or returns "" for a full payout.
+func PayoutWarning(r RuleSet) string {
+	if r.Payout() >= FullPayout {
+		return ""
+	}
+	return fmt.Sprintf("Warning: blackjack pays %s here, which adds %.2f%% to the house edge, more than "+
+		"any other common rule. Look for a 3:2 table.", r.BlackjackPays, r.PayoutCost())
+}
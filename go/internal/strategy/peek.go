@@ -0,0 +1,53 @@
+package strategy
+
+import "strings"
+
+// WithHoleCard returns the rules with the peek rule changed: holeCard true
+// for a dealer who takes a hole card and checks for blackjack before play,
+// false for European no-hole-card rules. The result is the preset with
+// those rules if there is one, or custom rules named for the change.
+func (r RuleSet) WithHoleCard(holeCard bool) RuleSet {
+	if r.HoleCard == holeCard {
+		return r
+	}
+	r.HoleCard = holeCard
+	if preset, ok := MatchPreset(r); ok {
+		return preset
+	}
+	r.Key = "custom"
+	if holeCard {
+		r.Name = strings.TrimSuffix(r.Name, " (no hole card)") + " (hole card)"
+	} else {
+		r.Name = strings.TrimSuffix(r.Name, " (hole card)") + " (no hole card)"
+	}
+	return r
+}
+
+// PeekDifferences returns the cells whose play depends on the peek rule,
+// with the other rules as in r: From is the play when the dealer checks for
+// blackjack, To the play without a hole card.
+func PeekDifferences(r RuleSet) []Difference {
+	return Compare(NewForRules(r.WithHoleCard(true)), NewForRules(r.WithHoleCard(false)))
+}
+
+// PeekContrast describes how the play in a cell would change under the
+// other peek rule, for a chart built by NewForRules. It returns "" for
+// charts of other games, whose plays it can't speak for.
+func PeekContrast(c *StrategyChart, handType HandType, playerTotal, dealerCard int) string {
+	rules := c.Rules()
+	action := c.GetCorrectAction(handType, playerTotal, dealerCard)
+	if NewForRules(rules).GetCorrectAction(handType, playerTotal, dealerCard) != action {
+		return ""
+	}
+	other := NewForRules(rules.WithHoleCard(!rules.HoleCard)).GetCorrectAction(handType, playerTotal, dealerCard)
+	switch {
+	case other == action:
+		return "The peek rule doesn't change this play."
+	case rules.HoleCard:
+		return "The dealer has already checked for blackjack, so the extra money is safe. Without a hole card you would " +
+			ActionToString(other) + ": a blackjack revealed after you play takes doubles and splits too."
+	default:
+		return "Where the dealer checks for blackjack before you play, a blackjack can't catch the extra money, so you would " +
+			ActionToString(other) + "."
+	}
+}
@@ -0,0 +1,378 @@
+package strategy
+
+import (
+	"blackjack_trainer/internal/hand"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// DoubleRule says which two-card hands may be doubled.
+type DoubleRule int
+
+const (
+	// DoubleAnyTwo allows doubling on any first two cards.
+	DoubleAnyTwo DoubleRule = iota
+	// DoubleNineToEleven allows doubling only on hard 9, 10 and 11.
+	DoubleNineToEleven
+)
+
+// String returns a short description of the doubling rule.
+func (d DoubleRule) String() string {
+	switch d {
+	case DoubleAnyTwo:
+		return "any two cards"
+	case DoubleNineToEleven:
+		return "hard 9-11 only"
+	default:
+		return "unknown"
+	}
+}
+
+// RuleSet describes the table rules that basic strategy depends on.
+type RuleSet struct {
+	// Key is the short name used to select the rule set, e.g. "vegas-strip".
+	Key string
+	// Name is the display name, e.g. "Vegas Strip".
+	Name string
+	// Decks is the number of decks in the shoe.
+	Decks int
+	// DealerHitsSoft17 is true for H17 games and false for S17 games.
+	DealerHitsSoft17 bool
+	// DoubleAfterSplit allows doubling the hands made by a split.
+	DoubleAfterSplit bool
+	// Double says which first two cards may be doubled.
+	Double DoubleRule
+	// LateSurrender allows giving up half the bet after the dealer checks
+	// for blackjack. The trainer's charts do not include surrender, so this
+	// is informational.
+	LateSurrender bool
+	// HoleCard is true when the dealer takes a hole card and checks for
+	// blackjack before play. Without one (European no-hole-card rules), a
+	// dealer blackjack also takes any extra money put out on doubles and
+	// splits.
+	HoleCard bool
+	// BlackjackPays is the payout for a natural, e.g. "3:2".
+	BlackjackPays string
+}
+
+// allowsDouble reports whether the rules allow doubling a two-card hand of
+// the type and total. A pair doubles as the hard total of both cards, and
+// a pair of aces as soft 12.
+func (r RuleSet) allowsDouble(handType HandType, total int) bool {
+	if r.Double != DoubleNineToEleven {
+		return true
+	}
+	switch {
+	case handType == HandTypeSoft, handType == HandTypePair && total == 11:
+		return false
+	case handType == HandTypePair:
+		total *= 2
+	}
+	return total >= 9 && total <= 11
+}
+
+// Standard is the rule set the base chart is built for: multiple decks,
+// dealer stands on soft 17, double after split allowed, no surrender.
+var Standard = RuleSet{
+	Key:              "standard",
+	Name:             "Standard",
+	Decks:            6,
+	DoubleAfterSplit: true,
+	Double:           DoubleAnyTwo,
+	HoleCard:         true,
+	BlackjackPays:    "3:2",
+}
+
+// presets are the named rule sets, in display order.
+var presets = []RuleSet{
+	Standard,
+	{
+		Key:              "vegas-strip",
+		Name:             "Vegas Strip",
+		Decks:            6,
+		DealerHitsSoft17: true,
+		DoubleAfterSplit: true,
+		Double:           DoubleAnyTwo,
+		LateSurrender:    true,
+		HoleCard:         true,
+		BlackjackPays:    "3:2",
+	},
+	{
+		Key:              "atlantic-city",
+		Name:             "Atlantic City",
+		Decks:            8,
+		DoubleAfterSplit: true,
+		Double:           DoubleAnyTwo,
+		LateSurrender:    true,
+		HoleCard:         true,
+		BlackjackPays:    "3:2",
+	},
+	{
+		Key:              "european",
+		Name:             "European",
+		Decks:            6,
+		DoubleAfterSplit: true,
+		Double:           DoubleNineToEleven,
+		HoleCard:         false,
+		BlackjackPays:    "3:2",
+	},
+	{
+		Key:              "single-deck-downtown",
+		Name:             "Single Deck Downtown",
+		Decks:            1,
+		DealerHitsSoft17: true,
+		DoubleAfterSplit: false,
+		Double:           DoubleAnyTwo,
+		HoleCard:         true,
+		BlackjackPays:    "3:2",
+	},
+}
+
+// presetAliases are extra names accepted by LookupRules.
+var presetAliases = map[string]string{
+	"vegas":      "vegas-strip",
+	"strip":      "vegas-strip",
+	"ac":         "atlantic-city",
+	"europe":     "european",
+	"downtown":   "single-deck-downtown",
+	"singledeck": "single-deck-downtown",
+}
+
+// Presets returns the named rule sets, starting with Standard.
+func Presets() []RuleSet {
+	return append([]RuleSet(nil), presets...)
+}
+
+// PresetKeys returns the keys of the named rule sets.
+func PresetKeys() []string {
+	keys := make([]string, len(presets))
+	for i, r := range presets {
+		keys[i] = r.Key
+	}
+	return keys
+}
+
+// LookupRules finds a preset by key, display name or alias. Case, spaces
+// and punctuation are ignored, so "Vegas Strip", "vegas-strip" and "vegas"
+// all select the same rule set.
+func LookupRules(name string) (RuleSet, error) {
+	wanted := normalizeRuleName(name)
+	if key, ok := presetAliases[wanted]; ok {
+		wanted = normalizeRuleName(key)
+	}
+	for _, r := range presets {
+		if normalizeRuleName(r.Key) == wanted || normalizeRuleName(r.Name) == wanted {
+			return r, nil
+		}
+	}
+	return RuleSet{}, fmt.Errorf("unknown rules %q (valid: %s)", name, strings.Join(PresetKeys(), ", "))
+}
+
+// MatchPreset returns the preset with the same rules as r, whatever r's
+// key and name, so rules described one at a time can be named.
+func MatchPreset(r RuleSet) (RuleSet, bool) {
+	r.Key, r.Name = "", ""
+	for _, preset := range presets {
+		unnamed := preset
+		unnamed.Key, unnamed.Name = "", ""
+		if unnamed == r {
+			return preset, true
+		}
+	}
+	return RuleSet{}, false
+}
+
+func normalizeRuleName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Summary returns the rules on one line, e.g.
+// "6 decks, H17, DAS, double any two, late surrender, 3:2".
+func (r RuleSet) Summary() string {
+	parts := []string{}
+	if r.Decks == 1 {
+		parts = append(parts, "1 deck")
+	} else {
+		parts = append(parts, fmt.Sprintf("%d decks", r.Decks))
+	}
+	if r.DealerHitsSoft17 {
+		parts = append(parts, "H17")
+	} else {
+		parts = append(parts, "S17")
+	}
+	if r.DoubleAfterSplit {
+		parts = append(parts, "DAS")
+	} else {
+		parts = append(parts, "no DAS")
+	}
+	parts = append(parts, "double "+r.Double.String())
+	if r.LateSurrender {
+		parts = append(parts, "late surrender")
+	}
+	if !r.HoleCard {
+		parts = append(parts, "no hole card")
+	}
+	parts = append(parts, r.BlackjackPays)
+	return strings.Join(parts, ", ")
+}
+
+// HandHeld reports whether games under the rules are usually dealt by hand
+// (pitch games) rather than from a shoe.
+func (r RuleSet) HandHeld() bool {
+	return r.Decks <= 2
+}
+
+// NewForRules creates a strategy chart adjusted for a rule set.
+//
+// The base chart is for Standard rules; the adjustments are the standard
+// basic strategy changes for each rule that differs:
+// - Dealer hits soft 17: double 11 vs A, A,7 vs 2 and A,8 vs 6
+// - One or two decks: more doubles (9 vs 2, 8 vs 5-6, A,2-A,3 vs 4, A,6 vs 2)
+// - No double after split: split small pairs less often
+// - Double 9-11 only: soft doubles become hits (stands on soft 18 and 19)
+// - No hole card: don't double 11 vs 10, or split A,A vs A and 8,8 vs 10 or A
+//
+// Pairs that are not split are then played like the equivalent hard total.
+func NewForRules(r RuleSet) *StrategyChart {
+	c := New()
+	c.rules = r
+	singleDeck := r.Decks <= 2
+
+	if r.DealerHitsSoft17 {
+		c.adjust(HandTypeHard, 11, 'D', "The dealer hits soft 17, so 11 vs A is worth a double", 11)
+		c.adjust(HandTypeSoft, 18, 'D', "The dealer hits soft 17, which makes doubling A,7 vs 2 pay", 2)
+		c.adjust(HandTypeSoft, 19, 'D', "The dealer hits soft 17, which makes doubling A,8 vs 6 pay", 6)
+	}
+
+	if singleDeck {
+		const note = "Fewer decks favor doubling"
+		c.adjust(HandTypeHard, 11, 'D', note, 11)
+		c.adjust(HandTypeHard, 9, 'D', note, 2)
+		c.adjust(HandTypeHard, 8, 'D', note, 5, 6)
+		c.adjust(HandTypeSoft, 13, 'D', note, 4)
+		c.adjust(HandTypeSoft, 14, 'D', note, 4)
+		c.adjust(HandTypeSoft, 17, 'D', note, 2)
+		c.adjust(HandTypeSoft, 19, 'D', note, 6)
+	}
+
+	// Split ranges for the small pairs depend on deck count and DAS
+	var splits map[int][]int
+	switch {
+	case singleDeck && r.DoubleAfterSplit:
+		splits = map[int][]int{2: {2, 3, 4, 5, 6, 7}, 3: {2, 3, 4, 5, 6, 7, 8}, 4: {4, 5, 6}, 6: {2, 3, 4, 5, 6, 7}, 7: {2, 3, 4, 5, 6, 7, 8}}
+	case singleDeck:
+		splits = map[int][]int{2: {3, 4, 5, 6, 7}, 3: {4, 5, 6, 7}, 4: {}, 6: {2, 3, 4, 5, 6}, 7: {2, 3, 4, 5, 6, 7}}
+	case !r.DoubleAfterSplit:
+		splits = map[int][]int{2: {4, 5, 6, 7}, 3: {4, 5, 6, 7}, 4: {}, 6: {3, 4, 5, 6}}
+	}
+	for pair, dealers := range splits {
+		for dealer := 2; dealer <= 11; dealer++ {
+			split := containsTotal(dealers, dealer)
+			if split != (c.pairs[pair][dealer] == 'Y') {
+				action := 'H' // replaced by the hard total below
+				if split {
+					action = 'Y'
+				}
+				c.adjust(HandTypePair, pair, action, splitNote(r), dealer)
+			}
+		}
+	}
+
+	if r.Double == DoubleNineToEleven {
+		const note = "Soft hands can't be doubled under these rules"
+		c.softTotals.forEach(func(total, dealer int, action rune) {
+			if action == 'D' {
+				replacement := 'H'
+				if total >= 18 {
+					replacement = 'S'
+				}
+				c.adjust(HandTypeSoft, total, replacement, note, dealer)
+			}
+		})
+		c.hardTotals.forEach(func(total, dealer int, action rune) {
+			if action == 'D' && (total < 9 || total > 11) {
+				c.adjust(HandTypeHard, total, 'H', note, dealer)
+			}
+		})
+	}
+
+	if !r.HoleCard {
+		for _, dealer := range []int{10, 11} {
+			c.adjust(HandTypeHard, 11, 'H', noHoleCardNote("double", dealer), dealer)
+			c.adjust(HandTypePair, 8, 'H', noHoleCardNote("split", dealer), dealer)
+		}
+		c.adjust(HandTypePair, 11, 'H', noHoleCardNote("split", 11), 11)
+	}
+
+	// Unsplit pairs play like the hard total of both cards
+	c.pairs.forEach(func(pair, dealer int, action rune) {
+		if action != 'Y' && pair != 11 {
+			hard, ok := c.hardTotals.get(pair*2, dealer)
+			if !ok {
+				hard = 'H' // 2,2 makes hard 4, below the chart
+			}
+			if hard != action {
+				c.pairs[pair][dealer] = hard
+				if note, ok := c.notes[ruleCell{HandTypeHard, HandKey{pair * 2, dealer}}]; ok {
+					c.notes[ruleCell{HandTypePair, HandKey{pair, dealer}}] = note
+				}
+			}
+		}
+	})
+
+	return c
+}
+
+// noHoleCardNote explains why a double or split isn't worth it against
+// dealerCard when the dealer doesn't check for blackjack first.
+func noHoleCardNote(bet string, dealerCard int) string {
+	odds := "a 10 hides an ace 1 time in 13"
+	if dealerCard == hand.Ace {
+		odds = "an ace hides a ten 4 times in 13"
+	}
+	return fmt.Sprintf("No hole card: %s, and that blackjack takes the %s money too, so don't put more out", odds, bet)
+}
+
+// Rules returns the rule set the chart was built for.
+func (c *StrategyChart) Rules() RuleSet {
+	return c.rules
+}
+
+// adjust sets a chart cell against each of the given dealer cards, noting
+// why it differs from the base chart.
+func (c *StrategyChart) adjust(handType HandType, playerTotal int, action rune, note string, dealerCards ...int) {
+	cells := c.section(handType)
+	for _, dealer := range dealerCards {
+		if cells[playerTotal][dealer] == action {
+			continue
+		}
+		cells[playerTotal][dealer] = action
+		c.notes[ruleCell{handType, HandKey{playerTotal, dealer}}] = note
+	}
+}
+
+// section returns the cells for a hand type.
+func (c *StrategyChart) section(handType HandType) *cellTable {
+	switch handType {
+	case HandTypePair:
+		return &c.pairs
+	case HandTypeSoft:
+		return &c.softTotals
+	default:
+		return &c.hardTotals
+	}
+}
+
+func splitNote(r RuleSet) string {
+	if r.DoubleAfterSplit {
+		return "Fewer decks make splitting small pairs stronger"
+	}
+	return "Without double after split, small pairs are split less often"
+}
@@ -2,7 +2,8 @@
 //
 // This package encapsulates the optimal basic strategy for blackjack based on
 // standard casino rules: 4-8 decks, dealer stands on soft 17, double after
-// split allowed, surrender not allowed.
+// split allowed, surrender not allowed. NewForRules adjusts the chart for
+// other rule sets, such as the named presets in rules.go.
 //
 // The strategy chart covers three main categories:
 // - Hard totals (5-21): Hands without aces or where ace counts as 1
@@ -22,12 +23,23 @@
 //
 // All strategy decisions are based on mathematically optimal play that
 // minimizes the house edge over the long term.
+//
+// Charts are immutable once built: no exported method changes a chart or
+// returns its internal state, so a single chart can be shared by any number
+// of goroutines. Default returns a shared chart for the Standard rules.
 package strategy
 
 import (
-	"fmt"
+	"blackjack_trainer/internal/hand"
+	"strconv"
+	"sync"
 )
 
+// ChartVersion is the revision of the built-in charts, rule presets and
+// their explanations. Increase it whenever any of them changes, so a recording
+// or bug report shows which chart judged the answers.
+const ChartVersion = 1
+
 // HandType represents the different types of blackjack hands.
 type HandType int
 
@@ -96,13 +108,53 @@ func (mk MnemonicKey) String() string {
 	}
 }
 
-// StrategyChart represents the complete blackjack basic strategy chart.
+// StrategyChart represents the complete blackjack basic strategy chart. A
+// chart is immutable after construction and safe for concurrent use.
 type StrategyChart struct {
-	hardTotals   map[HandKey]rune
-	softTotals   map[HandKey]rune
-	pairs        map[HandKey]rune
+	hardTotals   cellTable
+	softTotals   cellTable
+	pairs        cellTable
 	mnemonics    map[MnemonicKey]string
 	dealerGroups map[string][]int
+	rules        RuleSet
+	notes        map[ruleCell]string
+}
+
+// ruleCell identifies a chart cell that a rule set changed.
+type ruleCell struct {
+	handType HandType
+	key      HandKey
+}
+
+// Chart bounds: the highest player total (or pair card) and dealer card.
+const (
+	maxChartTotal = 21
+	maxDealerCard = 11
+)
+
+// cellTable stores one section of the chart densely, indexed by player total
+// (or pair card) and dealer card, since the trainer, server and simulator
+// look cells up millions of times. A zero rune marks a cell with no action.
+type cellTable [maxChartTotal + 1][maxDealerCard + 1]rune
+
+// get returns the action in a cell, if the cell is in range and set.
+func (t *cellTable) get(playerTotal, dealerCard int) (rune, bool) {
+	if playerTotal < 0 || playerTotal > maxChartTotal || dealerCard < 0 || dealerCard > maxDealerCard {
+		return 0, false
+	}
+	action := t[playerTotal][dealerCard]
+	return action, action != 0
+}
+
+// forEach calls fn for every set cell, by total and then dealer card.
+func (t *cellTable) forEach(fn func(playerTotal, dealerCard int, action rune)) {
+	for total := range t {
+		for dealer, action := range t[total] {
+			if action != 0 {
+				fn(total, dealer, action)
+			}
+		}
+	}
 }
 
 // HandKey represents a (player_total, dealer_card) combination.
@@ -111,14 +163,23 @@ type HandKey struct {
 	DealerCard  int
 }
 
-// New creates a new strategy chart with all data initialized.
+// defaultChart is built on first use and shared from then on.
+var defaultChart = sync.OnceValue(New)
+
+// Default returns the shared chart for the Standard rules. Prefer it to New
+// when the chart is only read, which is always outside this package.
+func Default() *StrategyChart {
+	return defaultChart()
+}
+
+// New creates a new strategy chart for the Standard rules with all data
+// initialized.
 func New() *StrategyChart {
 	chart := &StrategyChart{
-		hardTotals:   make(map[HandKey]rune),
-		softTotals:   make(map[HandKey]rune),
-		pairs:        make(map[HandKey]rune),
 		mnemonics:    make(map[MnemonicKey]string),
 		dealerGroups: make(map[string][]int),
+		rules:        Standard,
+		notes:        make(map[ruleCell]string),
 	}
 
 	chart.buildHardTotals()
@@ -132,27 +193,57 @@ func New() *StrategyChart {
 
 // GetCorrectAction returns the correct action for a given scenario.
 func (c *StrategyChart) GetCorrectAction(handType HandType, playerTotal, dealerCard int) rune {
-	key := HandKey{PlayerTotal: playerTotal, DealerCard: dealerCard}
-
 	switch handType {
 	case HandTypePair:
-		if action, exists := c.pairs[key]; exists {
+		if action, exists := c.pairs.get(playerTotal, dealerCard); exists {
 			return action
 		}
 	case HandTypeSoft:
-		if action, exists := c.softTotals[key]; exists {
+		if action, exists := c.softTotals.get(playerTotal, dealerCard); exists {
 			return action
 		}
 	case HandTypeHard:
-		if action, exists := c.hardTotals[key]; exists {
+		if action, exists := c.hardTotals.get(playerTotal, dealerCard); exists {
 			return action
 		}
 	}
 	return 'H' // Default to hit
 }
 
+// Classify returns the chart section and lookup value for a hand.
+//
+// Pairs are looked up by the value of one card (A,A is 11), while hard and
+// soft hands are looked up by their total.
+func Classify(h hand.Hand) (HandType, int) {
+	switch {
+	case h.IsPair():
+		return HandTypePair, h.PairCard()
+	case h.IsSoft():
+		return HandTypeSoft, h.Total()
+	default:
+		return HandTypeHard, h.Total()
+	}
+}
+
+// GetCorrectActionForHand returns the correct action for a hand against a dealer card.
+func (c *StrategyChart) GetCorrectActionForHand(h hand.Hand, dealerCard int) rune {
+	handType, value := Classify(h)
+	return c.GetCorrectAction(handType, value, dealerCard)
+}
+
+// GetExplanationForHand returns an explanation/mnemonic for a hand against a dealer card.
+func (c *StrategyChart) GetExplanationForHand(h hand.Hand, dealerCard int) string {
+	handType, value := Classify(h)
+	return c.GetExplanation(handType, value, dealerCard)
+}
+
 // GetExplanation returns an explanation/mnemonic for a given scenario.
 func (c *StrategyChart) GetExplanation(handType HandType, playerTotal, dealerCard int) string {
+	// Cells changed by the rule set explain the rule that changed them
+	if note, ok := c.notes[ruleCell{handType, HandKey{playerTotal, dealerCard}}]; ok {
+		return note
+	}
+
 	// Specific explanations for key scenarios
 	switch handType {
 	case HandTypePair:
@@ -202,8 +293,17 @@ func (c *StrategyChart) GetExplanation(handType HandType, playerTotal, dealerCar
 func (c *StrategyChart) IsAbsoluteRule(handType HandType, playerTotal, dealerCard int) bool {
 	switch handType {
 	case HandTypePair:
-		// Pair absolutes: A,A (11), 8,8, 10,10, 5,5
-		return playerTotal == 11 || playerTotal == 8 || playerTotal == 10 || playerTotal == 5
+		// Pair absolutes: A,A (11), 8,8, 10,10, 5,5, unless the rule set
+		// makes an exception against some dealer card
+		if playerTotal != 11 && playerTotal != 8 && playerTotal != 10 && playerTotal != 5 {
+			return false
+		}
+		for key := range c.notes {
+			if key.handType == HandTypePair && key.key.PlayerTotal == playerTotal {
+				return false
+			}
+		}
+		return true
 	case HandTypeHard:
 		// Hard 17+ always stand
 		return playerTotal >= 17
@@ -214,16 +314,20 @@ func (c *StrategyChart) IsAbsoluteRule(handType HandType, playerTotal, dealerCar
 	return false
 }
 
-// GetDealerGroups returns the dealer strength groups.
+// GetDealerGroups returns a copy of the dealer strength groups.
 func (c *StrategyChart) GetDealerGroups() map[string][]int {
-	return c.dealerGroups
+	groups := make(map[string][]int, len(c.dealerGroups))
+	for name, cards := range c.dealerGroups {
+		groups[name] = append([]int(nil), cards...)
+	}
+	return groups
 }
 
 func (c *StrategyChart) buildHardTotals() {
 	// Hard 5-8: Always hit
 	for total := 5; total <= 8; total++ {
 		for dealer := 2; dealer <= 11; dealer++ {
-			c.hardTotals[HandKey{total, dealer}] = 'H'
+			c.hardTotals[total][dealer] = 'H'
 		}
 	}
 
@@ -233,7 +337,7 @@ func (c *StrategyChart) buildHardTotals() {
 		if dealer >= 3 && dealer <= 6 {
 			action = 'D'
 		}
-		c.hardTotals[HandKey{9, dealer}] = action
+		c.hardTotals[9][dealer] = action
 	}
 
 	// Hard 10: Double vs 2-9, otherwise hit
@@ -242,7 +346,7 @@ func (c *StrategyChart) buildHardTotals() {
 		if dealer >= 2 && dealer <= 9 {
 			action = 'D'
 		}
-		c.hardTotals[HandKey{10, dealer}] = action
+		c.hardTotals[10][dealer] = action
 	}
 
 	// Hard 11: Double vs 2-10, hit vs Ace
@@ -251,7 +355,7 @@ func (c *StrategyChart) buildHardTotals() {
 		if dealer <= 10 {
 			action = 'D'
 		}
-		c.hardTotals[HandKey{11, dealer}] = action
+		c.hardTotals[11][dealer] = action
 	}
 
 	// Hard 12: Stand vs 4-6, otherwise hit
@@ -260,7 +364,7 @@ func (c *StrategyChart) buildHardTotals() {
 		if dealer >= 4 && dealer <= 6 {
 			action = 'S'
 		}
-		c.hardTotals[HandKey{12, dealer}] = action
+		c.hardTotals[12][dealer] = action
 	}
 
 	// Hard 13-16: Stand vs 2-6, otherwise hit
@@ -270,14 +374,14 @@ func (c *StrategyChart) buildHardTotals() {
 			if dealer >= 2 && dealer <= 6 {
 				action = 'S'
 			}
-			c.hardTotals[HandKey{total, dealer}] = action
+			c.hardTotals[total][dealer] = action
 		}
 	}
 
 	// Hard 17+: Always stand
 	for total := 17; total <= 21; total++ {
 		for dealer := 2; dealer <= 11; dealer++ {
-			c.hardTotals[HandKey{total, dealer}] = 'S'
+			c.hardTotals[total][dealer] = 'S'
 		}
 	}
 }
@@ -290,7 +394,7 @@ func (c *StrategyChart) buildSoftTotals() {
 			if dealer >= 5 && dealer <= 6 {
 				action = 'D'
 			}
-			c.softTotals[HandKey{total, dealer}] = action
+			c.softTotals[total][dealer] = action
 		}
 	}
 
@@ -301,7 +405,7 @@ func (c *StrategyChart) buildSoftTotals() {
 			if dealer >= 4 && dealer <= 6 {
 				action = 'D'
 			}
-			c.softTotals[HandKey{total, dealer}] = action
+			c.softTotals[total][dealer] = action
 		}
 	}
 
@@ -311,7 +415,7 @@ func (c *StrategyChart) buildSoftTotals() {
 		if dealer >= 3 && dealer <= 6 {
 			action = 'D'
 		}
-		c.softTotals[HandKey{17, dealer}] = action
+		c.softTotals[17][dealer] = action
 	}
 
 	// Soft 18 (A,7): Stand vs 2,7,8; Double vs 3-6; Hit vs 9,10,A
@@ -325,13 +429,13 @@ func (c *StrategyChart) buildSoftTotals() {
 		default: // 9, 10, A
 			action = 'H'
 		}
-		c.softTotals[HandKey{18, dealer}] = action
+		c.softTotals[18][dealer] = action
 	}
 
 	// Soft 19-21: Always stand
 	for _, total := range []int{19, 20, 21} {
 		for dealer := 2; dealer <= 11; dealer++ {
-			c.softTotals[HandKey{total, dealer}] = 'S'
+			c.softTotals[total][dealer] = 'S'
 		}
 	}
 }
@@ -339,7 +443,7 @@ func (c *StrategyChart) buildSoftTotals() {
 func (c *StrategyChart) buildPairs() {
 	// A,A: Always split
 	for dealer := 2; dealer <= 11; dealer++ {
-		c.pairs[HandKey{11, dealer}] = 'Y'
+		c.pairs[11][dealer] = 'Y'
 	}
 
 	// 2,2 and 3,3: Split vs 2-7, otherwise hit
@@ -349,7 +453,7 @@ func (c *StrategyChart) buildPairs() {
 			if dealer >= 2 && dealer <= 7 {
 				action = 'Y'
 			}
-			c.pairs[HandKey{pairVal, dealer}] = action
+			c.pairs[pairVal][dealer] = action
 		}
 	}
 
@@ -359,7 +463,7 @@ func (c *StrategyChart) buildPairs() {
 		if dealer >= 5 && dealer <= 6 {
 			action = 'Y'
 		}
-		c.pairs[HandKey{4, dealer}] = action
+		c.pairs[4][dealer] = action
 	}
 
 	// 5,5: Never split, treat as hard 10
@@ -368,7 +472,7 @@ func (c *StrategyChart) buildPairs() {
 		if dealer >= 2 && dealer <= 9 {
 			action = 'D'
 		}
-		c.pairs[HandKey{5, dealer}] = action
+		c.pairs[5][dealer] = action
 	}
 
 	// 6,6: Split vs 2-6, otherwise hit
@@ -377,7 +481,7 @@ func (c *StrategyChart) buildPairs() {
 		if dealer >= 2 && dealer <= 6 {
 			action = 'Y'
 		}
-		c.pairs[HandKey{6, dealer}] = action
+		c.pairs[6][dealer] = action
 	}
 
 	// 7,7: Split vs 2-7, otherwise hit
@@ -386,12 +490,12 @@ func (c *StrategyChart) buildPairs() {
 		if dealer >= 2 && dealer <= 7 {
 			action = 'Y'
 		}
-		c.pairs[HandKey{7, dealer}] = action
+		c.pairs[7][dealer] = action
 	}
 
 	// 8,8: Always split
 	for dealer := 2; dealer <= 11; dealer++ {
-		c.pairs[HandKey{8, dealer}] = 'Y'
+		c.pairs[8][dealer] = 'Y'
 	}
 
 	// 9,9: Split vs 2-9 except 7, stand vs 7,10,A
@@ -400,13 +504,37 @@ func (c *StrategyChart) buildPairs() {
 		if dealer == 7 || dealer == 10 || dealer == 11 {
 			action = 'S'
 		}
-		c.pairs[HandKey{9, dealer}] = action
+		c.pairs[9][dealer] = action
 	}
 
 	// 10,10: Never split, always stand
 	for dealer := 2; dealer <= 11; dealer++ {
-		c.pairs[HandKey{10, dealer}] = 'S'
+		c.pairs[10][dealer] = 'S'
+	}
+}
+
+// mnemonicOrder is the order mnemonics are listed in, as in the lessons:
+// pairs first, then doubling, the dealer's weak cards and the exceptions.
+var mnemonicOrder = []MnemonicKey{
+	MnemonicAlwaysSplit,
+	MnemonicNeverSplit,
+	MnemonicDoubles,
+	MnemonicDealerWeak,
+	MnemonicTeensVsStrong,
+	MnemonicHard12,
+	MnemonicSoft17,
+}
+
+// Mnemonics returns every mnemonic the chart explains plays with, for a
+// reference list.
+func (c *StrategyChart) Mnemonics() []string {
+	list := make([]string, 0, len(mnemonicOrder))
+	for _, key := range mnemonicOrder {
+		if text, ok := c.mnemonics[key]; ok {
+			list = append(list, text)
+		}
 	}
+	return list
 }
 
 func (c *StrategyChart) buildMnemonics() {
@@ -441,14 +569,52 @@ func ActionToString(action rune) string {
 	}
 }
 
-// CardToString converts card value to display string.
-func CardToString(card int) string {
-	switch card {
-	case 11:
-		return "A"
-	case 10:
-		return "10"
+// HandSignal describes the table gesture for an action. Hand-held games
+// (cards dealt face down and held in one hand) use different signals from
+// shoe games, where the cards are dealt face up and never touched.
+func HandSignal(action rune, handHeld bool) string {
+	switch action {
+	case 'H':
+		if handHeld {
+			return "Scrape the edge of your cards lightly toward you on the felt"
+		}
+		return "Tap the table behind your cards or beckon with a finger"
+	case 'S':
+		if handHeld {
+			return "Tuck your cards face down under your chips"
+		}
+		return "Wave your hand flat, palm down, over your cards"
+	case 'D':
+		if handHeld {
+			return "Turn your cards face up and place an equal bet beside your original bet"
+		}
+		return "Place an equal bet beside (not on top of) your original bet and hold up one finger"
+	case 'Y', 'P':
+		if handHeld {
+			return "Turn your cards face up, separate them, and place an equal bet beside your original bet"
+		}
+		return "Place an equal bet beside your original bet and make a V with two fingers"
 	default:
-		return fmt.Sprintf("%d", card)
+		return ""
 	}
 }
+
+// CellLabel returns a short description of a chart cell (e.g. "Hard 16 vs 10",
+// "Soft 18 vs 9", "Pair 8,8 vs A").
+func CellLabel(handType HandType, playerTotal, dealerCard int) string {
+	dealer := CardToString(dealerCard)
+	switch handType {
+	case HandTypePair:
+		card := CardToString(playerTotal)
+		return "Pair " + card + "," + card + " vs " + dealer
+	case HandTypeSoft:
+		return "Soft " + strconv.Itoa(playerTotal) + " vs " + dealer
+	default:
+		return "Hard " + strconv.Itoa(playerTotal) + " vs " + dealer
+	}
+}
+
+// CardToString converts card value to display string.
+func CardToString(card int) string {
+	return hand.CardString(card)
+}
@@ -1,6 +1,11 @@
 package strategy
 
 import (
+	"blackjack_trainer/internal/hand"
+	"bytes"
+	"math"
+	"math/rand"
+	"strings"
 	"testing"
 )
 
@@ -526,6 +531,40 @@ func TestAbsoluteRules(t *testing.T) {
 	}
 }
 
+// Test the default chart is shared, standard, and safe to read concurrently
+func TestDefault(t *testing.T) {
+	chart := Default()
+	if Default() != chart {
+		t.Error("Default should return the same chart every time")
+	}
+	if chart.Rules() != Standard {
+		t.Errorf("Default should use the standard rules, got %s", chart.Rules().Name)
+	}
+	if game, _ := NewGame("classic", Standard); game.Chart() != chart {
+		t.Error("Classic standard games should share the default chart")
+	}
+
+	// Callers can't change the chart through what it returns
+	chart.GetDealerGroups()["weak"][0] = 10
+	delete(chart.GetDealerGroups(), "strong")
+	if groups := chart.GetDealerGroups(); groups["weak"][0] != 4 || len(groups["strong"]) == 0 {
+		t.Errorf("Dealer groups should be copied, got %v", groups)
+	}
+
+	done := make(chan bool)
+	for i := 0; i < 4; i++ {
+		go func() {
+			report := Validate(Default())
+			done <- report.OK()
+		}()
+	}
+	for i := 0; i < 4; i++ {
+		if !<-done {
+			t.Error("Concurrent validation of the default chart failed")
+		}
+	}
+}
+
 // Test dealer groups
 func TestDealerGroups(t *testing.T) {
 	chart := New()
@@ -593,3 +632,764 @@ func TestExplanations(t *testing.T) {
 		t.Error("Should have explanation for strong dealer vs teens")
 	}
 }
+
+// Test the mnemonic list covers every explanation, pairs first
+func TestMnemonics(t *testing.T) {
+	chart := New()
+	list := chart.Mnemonics()
+	if len(list) != len(mnemonicOrder) {
+		t.Fatalf("Mnemonics() has %d entries, want %d", len(list), len(mnemonicOrder))
+	}
+	if list[0] != "Aces and eights, don't hesitate" {
+		t.Errorf("First mnemonic = %q", list[0])
+	}
+	listed := make(map[string]bool)
+	for _, text := range list {
+		listed[text] = true
+	}
+	for _, explanation := range []string{
+		chart.GetExplanation(HandTypePair, 10, 6),
+		chart.GetExplanation(HandTypeHard, 12, 3),
+		chart.GetExplanation(HandTypeHard, 15, 10),
+		chart.GetExplanation(HandTypeSoft, 18, 9),
+		chart.GetExplanation(HandTypeHard, 11, 5),
+	} {
+		if !listed[explanation] {
+			t.Errorf("Explanation %q is missing from Mnemonics()", explanation)
+		}
+	}
+}
+
+// Test hand classification and hand-based lookups
+func TestHandLookups(t *testing.T) {
+	chart := New()
+
+	tests := []struct {
+		cards    []int
+		handType HandType
+		value    int
+		dealer   int
+		action   rune
+	}{
+		{[]int{8, 8}, HandTypePair, 8, 10, 'Y'},
+		{[]int{11, 11}, HandTypePair, 11, 11, 'Y'},
+		{[]int{10, 10}, HandTypePair, 10, 6, 'S'},
+		{[]int{11, 7}, HandTypeSoft, 18, 9, 'H'},
+		{[]int{10, 6}, HandTypeHard, 16, 10, 'H'},
+		{[]int{4, 5}, HandTypeHard, 9, 4, 'D'},
+		{[]int{11, 5, 10}, HandTypeHard, 16, 6, 'S'},
+	}
+
+	for _, tt := range tests {
+		h := hand.New(tt.cards...)
+		handType, value := Classify(h)
+		if handType != tt.handType || value != tt.value {
+			t.Errorf("%v: expected %s %d, got %s %d", tt.cards, tt.handType, tt.value, handType, value)
+		}
+		if action := chart.GetCorrectActionForHand(h, tt.dealer); action != tt.action {
+			t.Errorf("%v vs %d: expected %c, got %c", tt.cards, tt.dealer, tt.action, action)
+		}
+		if explanation := chart.GetExplanationForHand(h, tt.dealer); explanation == "" {
+			t.Errorf("%v vs %d: expected explanation", tt.cards, tt.dealer)
+		}
+	}
+}
+
+// Test cell labels
+func TestCellLabel(t *testing.T) {
+	tests := []struct {
+		handType HandType
+		total    int
+		dealer   int
+		want     string
+	}{
+		{HandTypeHard, 16, 10, "Hard 16 vs 10"},
+		{HandTypeSoft, 18, 9, "Soft 18 vs 9"},
+		{HandTypePair, 8, 11, "Pair 8,8 vs A"},
+		{HandTypePair, 11, 6, "Pair A,A vs 6"},
+	}
+
+	for _, tt := range tests {
+		if got := CellLabel(tt.handType, tt.total, tt.dealer); got != tt.want {
+			t.Errorf("CellLabel(%s, %d, %d) = %q, want %q", tt.handType, tt.total, tt.dealer, got, tt.want)
+		}
+	}
+}
+
+// Test difficulty tiers derived from the chart
+func TestGetTier(t *testing.T) {
+	chart := New()
+	tests := []struct {
+		handType HandType
+		total    int
+		dealer   int
+		want     Tier
+	}{
+		{HandTypeHard, 17, 10, TierTrivial},
+		{HandTypeHard, 6, 5, TierTrivial},
+		{HandTypePair, 8, 11, TierTrivial},
+		{HandTypeHard, 14, 2, TierStandard},
+		{HandTypeSoft, 13, 9, TierStandard},
+		{HandTypePair, 2, 2, TierStandard},
+		{HandTypeHard, 12, 3, TierTricky},
+		{HandTypeHard, 16, 7, TierTricky},
+		{HandTypeHard, 11, 11, TierTricky},
+		{HandTypeSoft, 18, 9, TierTricky},
+		{HandTypePair, 9, 7, TierTricky},
+	}
+
+	for _, test := range tests {
+		if got := chart.GetTier(test.handType, test.total, test.dealer); got != test.want {
+			t.Errorf("%s: expected %s, got %s", CellLabel(test.handType, test.total, test.dealer), test.want, got)
+		}
+	}
+}
+
+// Test rule presets can be found by key, display name or alias
+func TestLookupRules(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+	}{
+		{"standard", "standard"},
+		{"Vegas Strip", "vegas-strip"},
+		{"vegas", "vegas-strip"},
+		{"ATLANTIC-CITY", "atlantic-city"},
+		{"european", "european"},
+		{"Single Deck Downtown", "single-deck-downtown"},
+		{"downtown", "single-deck-downtown"},
+	}
+
+	for _, tt := range tests {
+		rules, err := LookupRules(tt.name)
+		if err != nil || rules.Key != tt.key {
+			t.Errorf("LookupRules(%q) = %q, %v; want %q", tt.name, rules.Key, err, tt.key)
+		}
+	}
+
+	if _, err := LookupRules("reno"); err == nil {
+		t.Error("Expected an error for an unknown preset")
+	}
+}
+
+// Test rules described one at a time are matched to their preset
+func TestMatchPreset(t *testing.T) {
+	vegas, _ := LookupRules("vegas")
+	described := vegas
+	described.Key, described.Name = "custom", "My Casino"
+	if preset, ok := MatchPreset(described); !ok || preset.Key != "vegas-strip" {
+		t.Errorf("MatchPreset() = %q, %v; want vegas-strip", preset.Key, ok)
+	}
+
+	described.BlackjackPays = "6:5"
+	if preset, ok := MatchPreset(described); ok {
+		t.Errorf("6:5 rules should match no preset, got %q", preset.Key)
+	}
+}
+
+// Test rule-adjusted charts change the expected cells and stay valid
+func TestNewForRules(t *testing.T) {
+	tests := []struct {
+		preset   string
+		handType HandType
+		total    int
+		dealer   int
+		want     rune
+	}{
+		{"standard", HandTypeHard, 11, 11, 'H'},
+		{"vegas-strip", HandTypeHard, 11, 11, 'D'},
+		{"vegas-strip", HandTypeSoft, 18, 2, 'D'},
+		{"vegas-strip", HandTypeSoft, 19, 6, 'D'},
+		{"atlantic-city", HandTypeSoft, 18, 2, 'S'},
+		{"european", HandTypeHard, 11, 10, 'H'},
+		{"european", HandTypeSoft, 17, 4, 'H'},
+		{"european", HandTypeSoft, 18, 4, 'S'},
+		{"european", HandTypePair, 8, 10, 'H'},
+		{"european", HandTypePair, 11, 11, 'H'},
+		{"european", HandTypePair, 8, 9, 'Y'},
+		{"single-deck-downtown", HandTypeHard, 9, 2, 'D'},
+		{"single-deck-downtown", HandTypeHard, 8, 6, 'D'},
+		{"single-deck-downtown", HandTypePair, 4, 5, 'D'},
+		{"single-deck-downtown", HandTypePair, 2, 2, 'H'},
+		{"single-deck-downtown", HandTypePair, 3, 3, 'H'},
+		{"single-deck-downtown", HandTypePair, 6, 6, 'Y'},
+	}
+
+	for _, tt := range tests {
+		rules, err := LookupRules(tt.preset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		chart := NewForRules(rules)
+		if got := chart.GetCorrectAction(tt.handType, tt.total, tt.dealer); got != tt.want {
+			t.Errorf("%s %s: expected %c, got %c", tt.preset, CellLabel(tt.handType, tt.total, tt.dealer), tt.want, got)
+		}
+	}
+
+	for _, rules := range Presets() {
+		chart := NewForRules(rules)
+		if chart.Rules().Key != rules.Key {
+			t.Errorf("%s: chart reports rules %q", rules.Key, chart.Rules().Key)
+		}
+		if report := Validate(chart); !report.OK() {
+			t.Errorf("%s: chart has problems: %v", rules.Key, report.Problems)
+		}
+	}
+
+	european := NewForRules(Presets()[3])
+	if european.IsAbsoluteRule(HandTypePair, 8, 5) {
+		t.Error("8,8 should not be an absolute rule without a hole card")
+	}
+	if explanation := european.GetExplanation(HandTypePair, 8, 10); explanation == New().GetExplanation(HandTypePair, 8, 10) {
+		t.Errorf("Expected a rule explanation for 8,8 vs 10, got %q", explanation)
+	}
+}
+
+// Test comparing charts lists only the cells that differ
+func TestCompare(t *testing.T) {
+	if diffs := Compare(New(), New()); len(diffs) != 0 {
+		t.Errorf("Identical charts should not differ, got %d differences", len(diffs))
+	}
+
+	vegas, _ := LookupRules("vegas")
+	diffs := Compare(New(), NewForRules(vegas))
+	want := map[string][2]rune{
+		"Hard 11 vs A": {'H', 'D'},
+		"Soft 18 vs 2": {'S', 'D'},
+		"Soft 19 vs 6": {'S', 'D'},
+	}
+	if len(diffs) != len(want) {
+		t.Errorf("Expected %d differences, got %d: %v", len(want), len(diffs), diffs)
+	}
+	for _, d := range diffs {
+		label := CellLabel(d.HandType, d.PlayerTotal, d.DealerCard)
+		actions, ok := want[label]
+		if !ok || d.From != actions[0] || d.To != actions[1] {
+			t.Errorf("Unexpected difference %s: %c -> %c", label, d.From, d.To)
+		}
+		if d.Reason == "" || d.FromReason != "" {
+			t.Errorf("%s: expected a reason from the Vegas rules only, got %q and %q", label, d.Reason, d.FromReason)
+		}
+	}
+
+	// Reversing the charts reverses each difference and swaps the reasons
+	reversed := Compare(NewForRules(vegas), New())
+	if len(reversed) != len(diffs) || reversed[0].From != diffs[0].To ||
+		reversed[0].FromReason != diffs[0].Reason || reversed[0].Reason != "" {
+		t.Errorf("Reversed comparison should mirror the original: %v vs %v", reversed, diffs)
+	}
+}
+
+// Test each reason comes from the rules that set that side's action, when
+// the two rule sets depart from the base chart in different cells
+func TestCompareReasons(t *testing.T) {
+	vegas, _ := LookupRules("vegas")
+	european, _ := LookupRules("european")
+	restricted := vegas
+	restricted.Name, restricted.Double = "Vegas 9-11", DoubleNineToEleven
+
+	tests := []struct {
+		from, to         RuleSet
+		cell             string
+		reason, fromNote string
+	}{
+		// European's missing hole card changes 11 vs 10; Vegas plays it as the base chart does
+		{vegas, european, "Hard 11 vs 10", "No hole card", ""},
+		// Vegas's soft 17 rule changes 11 vs A; European plays it as the base chart does
+		{vegas, european, "Hard 11 vs A", "", "hits soft 17"},
+		{european, vegas, "Hard 11 vs A", "hits soft 17", ""},
+		// Both rule sets change soft 18 vs 2, for different reasons
+		{vegas, restricted, "Soft 18 vs 2", "can't be doubled", "hits soft 17"},
+		{restricted, vegas, "Soft 18 vs 2", "hits soft 17", "can't be doubled"},
+	}
+	for _, test := range tests {
+		var found bool
+		for _, d := range Compare(NewForRules(test.from), NewForRules(test.to)) {
+			if CellLabel(d.HandType, d.PlayerTotal, d.DealerCard) != test.cell {
+				continue
+			}
+			found = true
+			if (test.reason == "") != (d.Reason == "") || !strings.Contains(d.Reason, test.reason) {
+				t.Errorf("%s -> %s, %s: reason %q, want %q", test.from.Name, test.to.Name, test.cell, d.Reason, test.reason)
+			}
+			if (test.fromNote == "") != (d.FromReason == "") || !strings.Contains(d.FromReason, test.fromNote) {
+				t.Errorf("%s -> %s, %s: from reason %q, want %q", test.from.Name, test.to.Name, test.cell, d.FromReason, test.fromNote)
+			}
+		}
+		if !found {
+			t.Errorf("%s -> %s: %s should differ", test.from.Name, test.to.Name, test.cell)
+		}
+	}
+}
+
+// Test composition-dependent exceptions apply only to matching hands and rules
+func TestCompositionExceptions(t *testing.T) {
+	chart := New()
+	downtown, _ := LookupRules("downtown")
+	singleDeck := NewForRules(downtown)
+
+	tests := []struct {
+		chart  *StrategyChart
+		cards  []int
+		dealer int
+		want   rune
+	}{
+		{chart, []int{10, 6}, 10, 'H'},
+		{chart, []int{4, 5, 7}, 10, 'S'},
+		{chart, []int{4, 5, 7}, 9, 'H'},
+		{chart, []int{10, 2}, 4, 'S'},
+		{singleDeck, []int{10, 2}, 4, 'H'},
+		{singleDeck, []int{7, 5}, 4, 'S'},
+		{singleDeck, []int{6, 2}, 5, 'H'},
+		{singleDeck, []int{5, 3}, 5, 'D'},
+	}
+
+	for _, tt := range tests {
+		h := hand.New(tt.cards...)
+		if got := tt.chart.GetCompositionActionForHand(h, tt.dealer); got != tt.want {
+			t.Errorf("%s %v vs %d: expected %c, got %c", tt.chart.Rules().Key, tt.cards, tt.dealer, tt.want, got)
+		}
+	}
+
+	if n := len(chart.Exceptions()); n != 1 {
+		t.Errorf("Expected 1 exception for a shoe game, got %d", n)
+	}
+	if n := len(singleDeck.Exceptions()); n != 3 {
+		t.Errorf("Expected 3 exceptions for single deck, got %d", n)
+	}
+	if explanation := chart.GetCompositionExplanationForHand(hand.New(4, 5, 7), 10); explanation == chart.GetExplanationForHand(hand.New(4, 5, 7), 10) {
+		t.Errorf("Expected the exception's explanation, got %q", explanation)
+	}
+}
+
+// Test games provide valid charts and deal valid decisions
+func TestGames(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for _, key := range GameKeys() {
+		game, err := NewGame(key, Standard)
+		if err != nil {
+			t.Fatalf("NewGame(%q): %v", key, err)
+		}
+		if game.Key() != key {
+			t.Errorf("NewGame(%q) returned %q", key, game.Key())
+		}
+		if report := Validate(game.Chart()); !report.OK() {
+			t.Errorf("%s: chart has problems: %v", key, report.Problems)
+		}
+		for i := 0; i < 200; i++ {
+			h, dealer := game.Deal(rng)
+			if len(h.Cards) != 2 || h.Total() == 21 || dealer < 2 || dealer > 11 {
+				t.Errorf("%s: invalid deal %v vs %d", key, h.Cards, dealer)
+			}
+		}
+	}
+
+	freeBet, _ := NewGame("free-bet", Standard)
+	tests := []struct {
+		handType HandType
+		total    int
+		dealer   int
+		want     rune
+	}{
+		{HandTypeHard, 9, 2, 'D'},
+		{HandTypeHard, 11, 11, 'D'},
+		{HandTypePair, 5, 10, 'D'},
+		{HandTypePair, 4, 10, 'Y'},
+		{HandTypePair, 9, 7, 'S'},
+		{HandTypePair, 10, 6, 'S'},
+		{HandTypeHard, 16, 10, 'H'},
+	}
+	for _, tt := range tests {
+		if got := freeBet.Chart().GetCorrectAction(tt.handType, tt.total, tt.dealer); got != tt.want {
+			t.Errorf("Free Bet %s: expected %c, got %c", CellLabel(tt.handType, tt.total, tt.dealer), tt.want, got)
+		}
+	}
+
+	vegas, _ := LookupRules("vegas")
+	if _, err := NewGame("free-bet", vegas); err == nil {
+		t.Error("Free Bet should reject other rule sets")
+	}
+	if _, err := NewGame("pontoon", Standard); err == nil {
+		t.Error("Expected an error for an unknown game")
+	}
+}
+
+// Test every action has a hand signal for shoe and hand-held games
+func TestHandSignal(t *testing.T) {
+	for _, action := range "HSDYP" {
+		shoe, handHeld := HandSignal(action, false), HandSignal(action, true)
+		if shoe == "" || handHeld == "" || shoe == handHeld {
+			t.Errorf("%c: expected distinct shoe and hand-held signals, got %q and %q", action, shoe, handHeld)
+		}
+	}
+	if HandSignal('X', false) != "" {
+		t.Error("Unknown actions should have no signal")
+	}
+
+	downtown, _ := LookupRules("downtown")
+	if !downtown.HandHeld() || Standard.HandHeld() {
+		t.Error("Only one- and two-deck games should be hand-held")
+	}
+}
+
+// Test lookups stay within their performance budget: no allocations, since
+// the server and simulator call them millions of times
+func TestLookupBudget(t *testing.T) {
+	charts := map[string]*StrategyChart{"standard": New()}
+	european, _ := LookupRules("european")
+	charts["european"] = NewForRules(european)
+
+	for name, chart := range charts {
+		if allocs := testing.AllocsPerRun(100, func() { chart.GetCorrectAction(HandTypeSoft, 18, 10) }); allocs != 0 {
+			t.Errorf("%s: GetCorrectAction allocates %.0f times per call", name, allocs)
+		}
+		if allocs := testing.AllocsPerRun(100, func() { chart.GetExplanation(HandTypeHard, 11, 10) }); allocs != 0 {
+			t.Errorf("%s: GetExplanation allocates %.0f times per call", name, allocs)
+		}
+	}
+}
+
+// Benchmark looking up every chart cell in turn
+func BenchmarkGetCorrectAction(b *testing.B) {
+	chart := New()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		handType := HandType(i % 3)
+		total := 5 + i%17
+		if handType == HandTypePair {
+			total = 2 + i%10
+		}
+		chart.GetCorrectAction(handType, total, 2+i%10)
+	}
+}
+
+// Benchmark looking up the action for a hand, including classification
+func BenchmarkGetCorrectActionForHand(b *testing.B) {
+	chart := New()
+	hands := []hand.Hand{hand.New(10, 6), hand.New(hand.Ace, 7), hand.New(8, 8), hand.New(5, 4, 3)}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		chart.GetCorrectActionForHand(hands[i%len(hands)], 2+i%10)
+	}
+}
+
+// Benchmark explanations, including cells with rule notes
+func BenchmarkGetExplanation(b *testing.B) {
+	rules, _ := LookupRules("european")
+	chart := NewForRules(rules)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		chart.GetExplanation(HandType(i%3), 5+i%17, 2+i%10)
+	}
+}
+
+// presetCharts returns the chart for every preset and game variant.
+func presetCharts() []*StrategyChart {
+	var charts []*StrategyChart
+	for _, rules := range Presets() {
+		charts = append(charts, NewForRules(rules))
+	}
+	return append(charts, newFreeBetChart())
+}
+
+// Test every built-in chart keeps the invariants, and broken charts don't
+func TestCheckInvariants(t *testing.T) {
+	for _, chart := range presetCharts() {
+		for _, problem := range CheckInvariants(chart) {
+			t.Errorf("%s: %s", chart.Rules().Name, problem)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(c *StrategyChart)
+	}{
+		{"stand on hard 11", func(c *StrategyChart) { c.hardTotals[11][5] = 'S' }},
+		{"stand on soft 17", func(c *StrategyChart) { c.softTotals[17][7] = 'S' }},
+		{"hit above a stand", func(c *StrategyChart) { c.hardTotals[15][6] = 'H' }},
+		{"hit soft 19 above a stand", func(c *StrategyChart) { c.softTotals[19][7] = 'H' }},
+		{"split tens", func(c *StrategyChart) { c.pairs[10][6] = 'Y' }},
+	}
+	for _, tt := range tests {
+		chart := New()
+		tt.mutate(chart)
+		if len(CheckInvariants(chart)) == 0 {
+			t.Errorf("%s: expected an invariant problem", tt.name)
+		}
+	}
+}
+
+// Test exported charts load back unchanged, and bad files are rejected
+func TestExportLoad(t *testing.T) {
+	for _, chart := range presetCharts() {
+		var buf bytes.Buffer
+		if err := chart.Export(&buf); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := Load(&buf)
+		if err != nil {
+			t.Errorf("%s: %v", chart.Rules().Name, err)
+			continue
+		}
+		if loaded.Rules() != chart.Rules() {
+			t.Errorf("%s: loaded rules %s", chart.Rules().Name, loaded.Rules().Name)
+		}
+		if diffs := Compare(chart, loaded); len(diffs) != 0 {
+			t.Errorf("%s: %d cell(s) changed on reload, first %+v", chart.Rules().Name, len(diffs), diffs[0])
+		}
+	}
+
+	var buf bytes.Buffer
+	Default().Export(&buf)
+	valid := buf.String()
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"empty", ""},
+		{"missing row", strings.Replace(valid, "hard 12 ", "# hard 12", 1)},
+		{"duplicate row", valid + "hard 12   H  H  S  S  S  H  H  H  H  H\n"},
+		{"short row", strings.Replace(valid, "pair A    Y  Y  Y  Y  Y  Y  Y  Y  Y  Y", "pair A Y Y", 1)},
+		{"bad action", strings.Replace(valid, "pair A    Y", "pair A    X", 1)},
+		{"bad total", valid + "soft 12   H  H  H  H  H  H  H  H  H  H\n"},
+		{"unknown rules", strings.Replace(valid, "rules standard", "rules pontoon", 1)},
+		{"illegal split", strings.Replace(valid, "hard 16   S", "hard 16   Y", 1)},
+	}
+	for _, tt := range tests {
+		if _, err := Load(strings.NewReader(tt.input)); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+// Fuzz chart loading: no input panics, and anything that loads is a valid
+// chart that exports and loads back the same
+func FuzzLoad(f *testing.F) {
+	for _, chart := range presetCharts() {
+		var buf bytes.Buffer
+		chart.Export(&buf)
+		f.Add(buf.String())
+	}
+	f.Add("rules vegas\nhard 12 H H S S S H H H H H\n")
+	f.Add("pair 10 S S S S S S S S S S")
+
+	f.Fuzz(func(t *testing.T, input string) {
+		chart, err := Load(strings.NewReader(input))
+		if err != nil {
+			return
+		}
+		if report := Validate(chart); !report.OK() {
+			t.Fatalf("Loaded chart fails validation: %v", report.Problems)
+		}
+		if problems := CheckInvariants(chart); len(problems) > 0 {
+			t.Fatalf("Loaded chart breaks the invariants: %v", problems)
+		}
+		var buf bytes.Buffer
+		if err := chart.Export(&buf); err != nil {
+			t.Fatal(err)
+		}
+		reloaded, err := Load(&buf)
+		if err != nil {
+			t.Fatalf("Exported chart doesn't load: %v", err)
+		}
+		if diffs := Compare(chart, reloaded); len(diffs) != 0 {
+			t.Fatalf("%d cell(s) changed on reload", len(diffs))
+		}
+	})
+}
+
+// Test the peek rule changes only doubles and splits vs 10 and A, and the
+// contrast names the other rule's play
+func TestPeekDifferences(t *testing.T) {
+	diffs := PeekDifferences(Standard)
+	var labels []string
+	for _, d := range diffs {
+		labels = append(labels, CellLabel(d.HandType, d.PlayerTotal, d.DealerCard))
+		if d.DealerCard < 10 || (d.From != 'D' && d.From != 'Y') || d.To != 'H' {
+			t.Errorf("Unexpected peek difference %+v", d)
+		}
+		if !strings.HasPrefix(d.Reason, "No hole card: ") {
+			t.Errorf("%s: reason should explain the missing hole card, got %q", labels[len(labels)-1], d.Reason)
+		}
+	}
+	if got, want := strings.Join(labels, "; "), "Hard 11 vs 10; Pair 8,8 vs 10; Pair 8,8 vs A; Pair A,A vs A"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	if r := Standard.WithHoleCard(false); r.HoleCard || r.Key != "custom" || r.Name != "Standard (no hole card)" {
+		t.Errorf("Unexpected rules without a hole card: %+v", r)
+	}
+	if r := Standard.WithHoleCard(false).WithHoleCard(true); r != Standard {
+		t.Errorf("Restoring the hole card should give the preset back, got %+v", r)
+	}
+
+	noPeek := NewForRules(Standard.WithHoleCard(false))
+	for _, tt := range []struct {
+		chart *StrategyChart
+		total int
+		want  string
+	}{
+		{Default(), 11, "Without a hole card you would HIT"},
+		{noPeek, 11, "you would DOUBLE"},
+		{Default(), 10, "doesn't change this play"},
+	} {
+		if got := PeekContrast(tt.chart, HandTypeHard, tt.total, 10); !strings.Contains(got, tt.want) {
+			t.Errorf("%s hard %d vs 10: expected %q, got %q", tt.chart.Rules().Name, tt.total, tt.want, got)
+		}
+	}
+}
+
+// Test payouts are read from the rules and a 6:5 payout's cost is worked out
+func TestPayout(t *testing.T) {
+	sixFive := Standard
+	sixFive.BlackjackPays = "6:5"
+	tests := []struct {
+		pays    string
+		payout  float64
+		minCost float64
+		maxCost float64
+	}{
+		{"3:2", 1.5, 0, 0},
+		{"6:5", 1.2, 1.35, 1.37},
+		{"1:1", 1, 2.2, 2.3},
+		{"", 1.5, 0, 0},
+		{"two to one", 1.5, 0, 0},
+	}
+	for _, tt := range tests {
+		r := Standard
+		r.BlackjackPays = tt.pays
+		if got := r.Payout(); got != tt.payout {
+			t.Errorf("Payout(%q) = %v, want %v", tt.pays, got, tt.payout)
+		}
+		if got := r.PayoutCost(); got < tt.minCost || got > tt.maxCost {
+			t.Errorf("PayoutCost(%q) = %.4f, want %.2f to %.2f", tt.pays, got, tt.minCost, tt.maxCost)
+		}
+	}
+
+	if warning := PayoutWarning(sixFive); !strings.Contains(warning, "adds 1.36% to the house edge") {
+		t.Errorf("Unexpected 6:5 warning %q", warning)
+	}
+	if warning := PayoutWarning(Standard); warning != "" {
+		t.Errorf("A 3:2 table shouldn't warn: %q", warning)
+	}
+}
+
+// Test the house edge adds up each rule's effect, listing only rules that differ from the base game
+func TestHouseEdge(t *testing.T) {
+	vegas, _ := LookupRules("vegas")
+	european, _ := LookupRules("european")
+	downtown, _ := LookupRules("downtown")
+	sixFive := Standard
+	sixFive.BlackjackPays = "6:5"
+	tests := []struct {
+		rules   RuleSet
+		opts    EdgeOptions
+		total   float64
+		effects int
+	}{
+		{Standard, EdgeOptions{}, 0.40, 2},
+		{Standard, EdgeOptions{ResplitAces: true}, 0.32, 3},
+		{vegas, EdgeOptions{}, 0.52, 4},
+		{european, EdgeOptions{}, 0.60, 4},
+		{downtown, EdgeOptions{}, 0.20, 1},
+		{sixFive, EdgeOptions{}, 1.76, 3},
+	}
+	for _, tt := range tests {
+		edge := HouseEdge(tt.rules, tt.opts)
+		if got := edge.Total(); got < tt.total-0.005 || got > tt.total+0.005 {
+			t.Errorf("%s: house edge %.3f, want %.2f", tt.rules.Name, got, tt.total)
+		}
+		if len(edge.Effects) != tt.effects {
+			t.Errorf("%s: %d effects, want %d: %+v", tt.rules.Name, len(edge.Effects), tt.effects, edge.Effects)
+		}
+	}
+}
+
+// Test dealer outcomes against infinite-deck figures, and that
+// each upcard's outcomes add up
+func TestDealerOutcomes(t *testing.T) {
+	outcomes := DealerOutcomes(Standard)
+	if len(outcomes) != 10 || outcomes[0].Upcard != 2 || outcomes[9].Upcard != 11 {
+		t.Fatalf("DealerOutcomes returned upcards %v", outcomes)
+	}
+	bust := map[int]float64{2: 0.3536, 5: 0.4164, 6: 0.4232, 7: 0.2623, 10: 0.2298, 11: 0.1665}
+	for _, o := range outcomes {
+		sum := o.Blackjack + o.Bust
+		for _, p := range o.Totals {
+			sum += p
+		}
+		if math.Abs(sum-1) > 1e-9 {
+			t.Errorf("Upcard %d outcomes add up to %f", o.Upcard, sum)
+		}
+		if want, ok := bust[o.Upcard]; ok && math.Abs(o.Bust-want) > 0.0005 {
+			t.Errorf("Upcard %d busts %.4f, want %.4f", o.Upcard, o.Bust, want)
+		}
+		if o.Blackjack != 0 {
+			t.Errorf("Upcard %d has blackjack chance %f after the peek", o.Upcard, o.Blackjack)
+		}
+	}
+
+	// Without a hole card a blackjack is one of the outcomes, and hitting
+	// soft 17 busts more often
+	european, _ := LookupRules("european")
+	if o := DealerOutcomeFor(european, 11); math.Abs(o.Blackjack-4.0/13) > 1e-9 {
+		t.Errorf("No-hole-card ace blackjack chance = %f, want 4/13", o.Blackjack)
+	}
+	h17 := Standard
+	h17.DealerHitsSoft17 = true
+	if DealerOutcomeFor(h17, 6).Bust <= DealerOutcomeFor(Standard, 6).Bust {
+		t.Error("Hitting soft 17 should raise the dealer's bust chance with a 6")
+	}
+}
+
+// Test the bust note is given only for explanations based on the dealer's
+// strength
+func TestDealerBustNote(t *testing.T) {
+	chart := New()
+	weak := chart.GetExplanation(HandTypeHard, 13, 6)
+	if got := chart.DealerBustNote(weak, 6); got != "Dealer 6 busts ~42% of the time." {
+		t.Errorf("DealerBustNote vs 6 = %q", got)
+	}
+	strong := chart.GetExplanation(HandTypeHard, 16, 10)
+	if got := chart.DealerBustNote(strong, 10); got != "Dealer 10 busts ~23% of the time once blackjack is ruled out." {
+		t.Errorf("DealerBustNote vs 10 = %q", got)
+	}
+	if got := chart.DealerBustNote(chart.GetExplanation(HandTypePair, 8, 6), 6); got != "" {
+		t.Errorf("DealerBustNote for a pair rule = %q, want none", got)
+	}
+}
+
+// Test the chance of busting a hard total by hitting, from an infinite deck
+// and from the cards left
+func TestHitBustChance(t *testing.T) {
+	tests := []struct {
+		total int
+		want  float64
+	}{
+		{11, 0},
+		{12, 4.0 / 13},
+		{16, 8.0 / 13},
+		{20, 12.0 / 13},
+		{21, 1},
+	}
+	for _, tt := range tests {
+		if got := HitBustChance(tt.total); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("HitBustChance(%d) = %f, want %f", tt.total, got, tt.want)
+		}
+	}
+
+	// Only tens and sixes left: a 16 busts on all of them, a 12 on the tens
+	var counts [12]int
+	counts[10], counts[6] = 3, 1
+	if got := HitBustChanceFrom(16, counts); got != 1 {
+		t.Errorf("HitBustChanceFrom(16) = %f, want 1", got)
+	}
+	if got := HitBustChanceFrom(12, counts); got != 0.75 {
+		t.Errorf("HitBustChanceFrom(12) = %f, want 0.75", got)
+	}
+	if got := HitBustChanceFrom(16, [12]int{}); got != HitBustChance(16) {
+		t.Errorf("HitBustChanceFrom an empty shoe = %f, want the infinite-deck chance", got)
+	}
+}
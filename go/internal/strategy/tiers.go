@@ -0,0 +1,86 @@
+package strategy
+
+// Tier is how difficult a chart cell is to remember.
+type Tier int
+
+const (
+	// TierTrivial cells follow an absolute rule or a row with a single action
+	// (e.g. hard 17 stands, 8,8 splits).
+	TierTrivial Tier = iota
+	// TierStandard cells follow their row's main pattern.
+	TierStandard
+	// TierTricky cells sit where the correct action changes, next to a cell
+	// with a different play (e.g. hard 12 vs 3, soft 18 vs 9, 9,9 vs 7).
+	TierTricky
+)
+
+// Tiers lists every tier from easiest to hardest.
+var Tiers = []Tier{TierTrivial, TierStandard, TierTricky}
+
+// String returns the tier name.
+func (t Tier) String() string {
+	switch t {
+	case TierTrivial:
+		return "trivial"
+	case TierStandard:
+		return "standard"
+	case TierTricky:
+		return "tricky"
+	default:
+		return "unknown"
+	}
+}
+
+// chartRange returns the range of player values covered by a chart section.
+func chartRange(handType HandType) (low, high int) {
+	switch handType {
+	case HandTypeSoft:
+		return 13, 21
+	case HandTypePair:
+		return 2, 11
+	default:
+		return 5, 21
+	}
+}
+
+// GetTier returns the difficulty tier of a chart cell.
+//
+// Tiers are derived from the chart itself: a cell is trivial if it is an
+// absolute rule or its whole row has one action, and tricky if the action
+// changes at the neighboring dealer card or, for hard and soft totals, at
+// the neighboring player total. Pair rows are not compared with each other
+// because adjacent pair values are unrelated hands.
+func (c *StrategyChart) GetTier(handType HandType, playerTotal, dealerCard int) Tier {
+	if c.IsAbsoluteRule(handType, playerTotal, dealerCard) || c.uniformRow(handType, playerTotal) {
+		return TierTrivial
+	}
+
+	action := c.GetCorrectAction(handType, playerTotal, dealerCard)
+	for _, dealer := range []int{dealerCard - 1, dealerCard + 1} {
+		if dealer >= 2 && dealer <= 11 && c.GetCorrectAction(handType, playerTotal, dealer) != action {
+			return TierTricky
+		}
+	}
+
+	if handType != HandTypePair {
+		low, high := chartRange(handType)
+		for _, total := range []int{playerTotal - 1, playerTotal + 1} {
+			if total >= low && total <= high && c.GetCorrectAction(handType, total, dealerCard) != action {
+				return TierTricky
+			}
+		}
+	}
+
+	return TierStandard
+}
+
+// uniformRow reports whether every dealer card has the same action for a player value.
+func (c *StrategyChart) uniformRow(handType HandType, playerTotal int) bool {
+	first := c.GetCorrectAction(handType, playerTotal, 2)
+	for dealer := 3; dealer <= 11; dealer++ {
+		if c.GetCorrectAction(handType, playerTotal, dealer) != first {
+			return false
+		}
+	}
+	return true
+}
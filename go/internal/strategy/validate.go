@@ -0,0 +1,147 @@
+package strategy
+
+import (
+	"fmt"
+)
+
+// Problem describes a single chart integrity violation.
+type Problem struct {
+	HandType    HandType
+	PlayerTotal int
+	DealerCard  int
+	Message     string
+}
+
+// String returns a human-readable description of the problem.
+func (p Problem) String() string {
+	return fmt.Sprintf("%s %d vs %s: %s", p.HandType, p.PlayerTotal, CardToString(p.DealerCard), p.Message)
+}
+
+// Report summarizes the result of validating a strategy chart.
+type Report struct {
+	// CellsChecked counts the cells examined in each chart section.
+	CellsChecked map[HandType]int
+	// Problems lists every integrity violation found.
+	Problems []Problem
+}
+
+// OK reports whether the chart passed every check.
+func (r Report) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// TotalCells returns the number of cells checked across all sections.
+func (r Report) TotalCells() int {
+	total := 0
+	for _, count := range r.CellsChecked {
+		total += count
+	}
+	return total
+}
+
+// Validate exhaustively checks a chart for integrity problems.
+//
+// The checks are:
+// - Coverage: every player hand has an action against every dealer card
+// - Legal actions: only H/S/D appear for totals, only pairs may split, and
+// hands are only doubled where the chart's rules allow it
+// - Consistency: 21 always stands, and a pair that is not split is played
+// the same as the equivalent hard total
+func Validate(c *StrategyChart) Report {
+	report := Report{CellsChecked: make(map[HandType]int)}
+	rules := c.Rules()
+
+	sections := []struct {
+		handType HandType
+		cells    *cellTable
+		totals   []int
+		legal    string
+	}{
+		{HandTypeHard, &c.hardTotals, totalRange(5, 21), "HSD"},
+		{HandTypeSoft, &c.softTotals, totalRange(13, 21), "HSD"},
+		{HandTypePair, &c.pairs, totalRange(2, 11), "HSDY"},
+	}
+
+	for _, section := range sections {
+		for _, total := range section.totals {
+			for dealer := 2; dealer <= 11; dealer++ {
+				report.CellsChecked[section.handType]++
+				problem := func(format string, args ...interface{}) {
+					report.Problems = append(report.Problems, Problem{
+						HandType:    section.handType,
+						PlayerTotal: total,
+						DealerCard:  dealer,
+						Message:     fmt.Sprintf(format, args...),
+					})
+				}
+
+				action, exists := section.cells.get(total, dealer)
+				if !exists {
+					problem("missing action")
+					continue
+				}
+				if !containsAction(section.legal, action) {
+					problem("illegal action %q", action)
+					continue
+				}
+				if action == 'D' && !rules.allowsDouble(section.handType, total) {
+					problem("doubles, but %s rules allow doubling %s", rules.Name, rules.Double)
+				}
+
+				if section.handType != HandTypePair && total == 21 && action != 'S' {
+					problem("21 must stand, got %c", action)
+				}
+
+				if section.handType == HandTypePair && action != 'Y' && total != 11 {
+					hardAction, hasHard := c.hardTotals.get(total*2, dealer)
+					if hasHard && hardAction != action {
+						problem("unsplit pair plays %c but hard %d plays %c", action, total*2, hardAction)
+					}
+				}
+			}
+		}
+	}
+
+	// Cells outside the expected ranges indicate a malformed chart
+	for _, section := range sections {
+		section.cells.forEach(func(total, dealer int, _ rune) {
+			if !containsTotal(section.totals, total) || dealer < 2 || dealer > 11 {
+				report.Problems = append(report.Problems, Problem{
+					HandType:    section.handType,
+					PlayerTotal: total,
+					DealerCard:  dealer,
+					Message:     "cell outside chart range",
+				})
+			}
+		})
+	}
+
+	return report
+}
+
+// totalRange returns the inclusive list of totals from low to high.
+func totalRange(low, high int) []int {
+	totals := make([]int, 0, high-low+1)
+	for total := low; total <= high; total++ {
+		totals = append(totals, total)
+	}
+	return totals
+}
+
+func containsTotal(totals []int, total int) bool {
+	for _, t := range totals {
+		if t == total {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAction(legal string, action rune) bool {
+	for _, a := range legal {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
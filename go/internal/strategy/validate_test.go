@@ -0,0 +1,147 @@
+package strategy
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// Test the built-in chart passes every integrity check
+func TestValidateBuiltInChart(t *testing.T) {
+	report := Validate(New())
+
+	if !report.OK() {
+		for _, problem := range report.Problems {
+			t.Errorf("Unexpected problem: %s", problem)
+		}
+	}
+
+	expected := map[HandType]int{
+		HandTypeHard: 17 * 10,
+		HandTypeSoft: 9 * 10,
+		HandTypePair: 10 * 10,
+	}
+	for handType, count := range expected {
+		if report.CellsChecked[handType] != count {
+			t.Errorf("%s: expected %d cells checked, got %d", handType, count, report.CellsChecked[handType])
+		}
+	}
+	if report.TotalCells() != 360 {
+		t.Errorf("Expected 360 total cells, got %d", report.TotalCells())
+	}
+}
+
+// Test every preset's chart passes validation, including the doubling
+// restriction of rule sets that only double hard 9-11
+func TestValidatePresets(t *testing.T) {
+	restricted := 0
+	for _, rules := range Presets() {
+		chart := NewForRules(rules)
+		for _, problem := range Validate(chart).Problems {
+			t.Errorf("%s: unexpected problem: %s", rules.Name, problem)
+		}
+		if rules.Double == DoubleNineToEleven {
+			restricted++
+			chart.softTotals.forEach(func(total, dealer int, action rune) {
+				if action == 'D' {
+					t.Errorf("%s: soft %d vs %d doubles", rules.Name, total, dealer)
+				}
+			})
+		}
+	}
+	if restricted == 0 {
+		t.Error("Expected a preset that only doubles hard 9-11, such as European")
+	}
+
+	tests := []struct {
+		handType HandType
+		total    int
+		want     bool
+	}{
+		{HandTypeHard, 8, false},
+		{HandTypeHard, 9, true},
+		{HandTypeHard, 11, true},
+		{HandTypeHard, 12, false},
+		{HandTypeSoft, 17, false},
+		{HandTypePair, 5, true},
+		{HandTypePair, 4, false},
+		{HandTypePair, 11, false},
+	}
+	european, _ := LookupRules("european")
+	for _, tt := range tests {
+		if got := european.allowsDouble(tt.handType, tt.total); got != tt.want {
+			t.Errorf("European doubling %s %d = %v, want %v", tt.handType, tt.total, got, tt.want)
+		}
+		if !Standard.allowsDouble(tt.handType, tt.total) {
+			t.Errorf("Standard rules should allow doubling %s %d", tt.handType, tt.total)
+		}
+	}
+}
+
+// Test validation detects missing, illegal, and inconsistent cells
+func TestValidateDetectsProblems(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(c *StrategyChart)
+	}{
+		{"missing cell", func(c *StrategyChart) { c.hardTotals[12][4] = 0 }},
+		{"illegal action", func(c *StrategyChart) { c.softTotals[18][2] = 'X' }},
+		{"split outside pairs", func(c *StrategyChart) { c.hardTotals[16][10] = 'Y' }},
+		{"hit on 21", func(c *StrategyChart) { c.hardTotals[21][5] = 'H' }},
+		{"inconsistent unsplit pair", func(c *StrategyChart) { c.pairs[5][5] = 'H' }},
+		{"out of range cell", func(c *StrategyChart) { c.pairs[12][5] = 'Y' }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chart := New()
+			tt.mutate(chart)
+
+			report := Validate(chart)
+			if report.OK() {
+				t.Errorf("Expected validation to fail for %s", tt.name)
+			}
+		})
+	}
+}
+
+// Test a chart that doubles where its rules don't allow it fails validation,
+// and a loaded chart must pass the invariants as well
+func TestValidateRules(t *testing.T) {
+	european, err := LookupRules("european")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	NewForRules(european).Export(&buf)
+	text := buf.String()
+
+	// Soft 18 doubled against 3-6, as under rules that allow doubling any two cards
+	doubled := strings.Replace(text, "soft 18   S  S  S  S  S  S  S  H  H  H", "soft 18   S  D  D  D  D  S  S  H  H  H", 1)
+	if doubled == text {
+		t.Fatal("Expected to find the European soft 18 row")
+	}
+	if _, err := Load(strings.NewReader(doubled)); err == nil || !strings.Contains(err.Error(), "European rules allow doubling hard 9-11 only") {
+		t.Errorf("Loading a European chart that doubles soft 18 = %v", err)
+	}
+
+	chart := NewForRules(european)
+	chart.softTotals[18][3] = 'D'
+	chart.hardTotals[8][5] = 'D'
+	chart.pairs[4][5] = 'D'
+	report := Validate(chart)
+	if len(report.Problems) != 3 {
+		t.Errorf("Expected 3 doubling problems, got %v", report.Problems)
+	}
+	if report := Validate(NewForRules(Standard)); !report.OK() {
+		t.Errorf("Standard rules allow doubling any two cards: %v", report.Problems)
+	}
+
+	// Standing on hard 11 is well formed but breaks an invariant
+	var standard bytes.Buffer
+	Default().Export(&standard)
+	stands := strings.Replace(standard.String(), "hard 11   D", "hard 11   S", 1)
+	if _, err := Load(strings.NewReader(stands)); err == nil || !strings.Contains(err.Error(), "can't bust") {
+		t.Errorf("Loading a chart that stands on 11 = %v", err)
+	}
+}
@@ -0,0 +1,218 @@
+// Package summary renders a progress summary of the last week's practice and
+// sends it to a webhook or by email, for study groups that share weekly
+// accountability reports.
+//
+// A webhook receives a JSON POST whose "text" and "content" fields hold the
+// rendered summary, which chat services such as Slack, Mattermost and Discord
+// post as a message, and whose "summary" field holds the figures for other
+// receivers. Email is sent through a sendmail-compatible command.
+package summary
+
+import (
+	"blackjack_trainer/internal/history"
+	"blackjack_trainer/internal/stats"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxMissed is the number of most-missed cells listed.
+const maxMissed = 5
+
+// Score is the number of answers and correct answers.
+type Score struct {
+	Correct int `json:"correct"`
+	Total   int `json:"total"`
+}
+
+// Accuracy returns the percentage of answers that were correct.
+func (s Score) Accuracy() float64 {
+	if s.Total == 0 {
+		return 0
+	}
+	return float64(s.Correct) / float64(s.Total) * 100
+}
+
+// Missed is a chart cell answered wrongly in the period.
+type Missed struct {
+	Cell   string `json:"cell"`
+	Misses int    `json:"misses"`
+	Asked  int    `json:"asked"`
+}
+
+// Summary is a period's practice.
+type Summary struct {
+	// Name identifies the player to the group; empty if not configured.
+	Name            string    `json:"name,omitempty"`
+	From            time.Time `json:"from"`
+	To              time.Time `json:"to"`
+	Sessions        int       `json:"sessions"`
+	DaysPracticed   int       `json:"days_practiced"`
+	PracticeSeconds int64     `json:"practice_seconds"`
+	Score
+	// Previous is the score in the period of the same length before.
+	Previous   Score            `json:"previous"`
+	DayStreak  int              `json:"day_streak"`
+	ByHandType map[string]Score `json:"by_hand_type"`
+	Missed     []Missed         `json:"missed"`
+}
+
+// Build summarizes the sessions started in the days before now.
+func Build(h *history.History, now time.Time, days int) Summary {
+	from := now.AddDate(0, 0, -days)
+	previousFrom := from.AddDate(0, 0, -days)
+	s := Summary{
+		From:       from,
+		To:         now,
+		DayStreak:  h.DayStreak(now),
+		ByHandType: make(map[string]Score),
+		Missed:     []Missed{},
+	}
+
+	practiced := make(map[time.Time]bool)
+	var attempts []history.Attempt
+	for _, session := range h.Sessions {
+		switch {
+		case !session.Started.Before(from) && !session.Started.After(now):
+			s.Sessions++
+			s.PracticeSeconds += int64(session.Duration().Seconds())
+			practiced[history.StartOfDay(session.Started.In(now.Location()))] = true
+			s.Correct += session.Correct
+			s.Total += session.Total
+			attempts = append(attempts, session.Attempts...)
+		case !session.Started.Before(previousFrom) && session.Started.Before(from):
+			s.Previous.Correct += session.Correct
+			s.Previous.Total += session.Total
+		}
+	}
+	s.DaysPracticed = len(practiced)
+
+	for _, attempt := range attempts {
+		score := s.ByHandType[attempt.HandType]
+		score.Total++
+		if attempt.Correct {
+			score.Correct++
+		}
+		s.ByHandType[attempt.HandType] = score
+	}
+	for cell, data := range stats.ByCell(attempts) {
+		if misses := data.Total - data.Correct; misses > 0 {
+			s.Missed = append(s.Missed, Missed{Cell: cell.Label(), Misses: misses, Asked: data.Total})
+		}
+	}
+	sort.Slice(s.Missed, func(i, j int) bool {
+		a, b := s.Missed[i], s.Missed[j]
+		if a.Misses != b.Misses {
+			return a.Misses > b.Misses
+		}
+		return a.Cell < b.Cell
+	})
+	if len(s.Missed) > maxMissed {
+		s.Missed = s.Missed[:maxMissed]
+	}
+	return s
+}
+
+// Subject returns a one-line title for the summary.
+func (s Summary) Subject() string {
+	subject := fmt.Sprintf("Blackjack practice %s to %s", s.From.Format("Jan 2"), s.To.Format("Jan 2"))
+	if s.Name != "" {
+		subject += ": " + s.Name
+	}
+	return subject
+}
+
+// Text renders the summary as plain text.
+func (s Summary) Text() string {
+	var b strings.Builder
+	fmt.Fprintln(&b, s.Subject())
+	if s.Sessions == 0 {
+		fmt.Fprintln(&b, "No practice this period.")
+		fmt.Fprintf(&b, "Day streak: %d\n", s.DayStreak)
+		return b.String()
+	}
+	fmt.Fprintf(&b, "Sessions: %d on %d day(s), %s\n", s.Sessions, s.DaysPracticed,
+		stats.FormatDuration(time.Duration(s.PracticeSeconds)*time.Second))
+	fmt.Fprintf(&b, "Questions: %d (%.1f%% correct", s.Total, s.Accuracy())
+	if s.Previous.Total > 0 {
+		change := s.Accuracy() - s.Previous.Accuracy()
+		fmt.Fprintf(&b, ", %+.1f from the period before", change)
+	}
+	fmt.Fprintln(&b, ")")
+	fmt.Fprintf(&b, "Day streak: %d\n", s.DayStreak)
+	for _, key := range []string{"hard", "soft", "pair"} {
+		if score := s.ByHandType[key]; score.Total > 0 {
+			fmt.Fprintf(&b, "  %-6s %d/%d (%.1f%%)\n", strings.Title(key), score.Correct, score.Total, score.Accuracy())
+		}
+	}
+	if len(s.Missed) > 0 {
+		fmt.Fprintln(&b, "Most missed:")
+		for _, m := range s.Missed {
+			fmt.Fprintf(&b, "  %s: %d of %d wrong\n", m.Cell, m.Misses, m.Asked)
+		}
+	}
+	return b.String()
+}
+
+// PostWebhook posts the summary to a webhook URL.
+func PostWebhook(ctx context.Context, client *http.Client, url string, s Summary) error {
+	text := s.Text()
+	data, err := json.Marshal(struct {
+		Text    string  `json:"text"`
+		Content string  `json:"content"`
+		Summary Summary `json:"summary"`
+	}{text, text, s})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting summary: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("posting summary: %s", resp.Status)
+	}
+	return nil
+}
+
+// Message returns the summary as an email message to the comma-separated
+// addresses in to.
+func Message(to string, s Summary) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", s.Subject())
+	fmt.Fprint(&b, "MIME-Version: 1.0\r\n")
+	fmt.Fprint(&b, "Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(s.Text(), "\n", "\r\n"))
+	return b.Bytes()
+}
+
+// Sendmail emails the summary to the addresses in to by running a
+// sendmail-compatible command, which reads the recipients from the message.
+func Sendmail(ctx context.Context, command, to string, s Summary) error {
+	if to == "" {
+		return errors.New("no email address configured")
+	}
+	cmd := exec.CommandContext(ctx, command, "-t", "-i")
+	cmd.Stdin = bytes.NewReader(Message(to, s))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("running %s: %w: %s", command, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package summary
+
+import (
+	"blackjack_trainer/internal/history"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sampleHistory has two sessions this week and one the week before.
+func sampleHistory(now time.Time) *history.History {
+	hard16 := history.Attempt{Cards: []int{10, 6}, DealerCard: 10, HandType: "hard", Action: "S", CorrectAction: "H"}
+	soft18 := history.Attempt{Cards: []int{11, 7}, DealerCard: 9, HandType: "soft", Action: "H", CorrectAction: "H", Correct: true}
+	h := history.New()
+	h.Add(history.Session{Mode: "random", Started: now.AddDate(0, 0, -10), Ended: now.AddDate(0, 0, -10).Add(time.Minute),
+		Correct: 1, Total: 2, Attempts: []history.Attempt{hard16, soft18}})
+	h.Add(history.Session{Mode: "random", Started: now.AddDate(0, 0, -2), Ended: now.AddDate(0, 0, -2).Add(5 * time.Minute),
+		Correct: 1, Total: 3, Attempts: []history.Attempt{hard16, hard16, soft18}})
+	h.Add(history.Session{Mode: "daily", Started: now.Add(-time.Hour), Ended: now.Add(-time.Hour).Add(time.Minute),
+		Correct: 1, Total: 1, Attempts: []history.Attempt{soft18}})
+	return h
+}
+
+// Test the summary counts only the period's sessions and lists the cells missed most
+func TestBuild(t *testing.T) {
+	now := time.Date(2026, 6, 14, 18, 0, 0, 0, time.Local)
+	s := Build(sampleHistory(now), now, 7)
+
+	if s.Sessions != 2 || s.DaysPracticed != 2 || s.Total != 4 || s.Correct != 2 || s.PracticeSeconds != 360 {
+		t.Errorf("Unexpected totals %+v", s)
+	}
+	if s.Previous.Total != 2 || s.Previous.Accuracy() != 50 {
+		t.Errorf("Unexpected previous period %+v", s.Previous)
+	}
+	if got := s.ByHandType["hard"]; got.Total != 2 || got.Correct != 0 {
+		t.Errorf("Unexpected hard score %+v", got)
+	}
+	if len(s.Missed) != 1 || s.Missed[0].Cell != "Hard 16 vs 10" || s.Missed[0].Misses != 2 {
+		t.Errorf("Unexpected missed cells %+v", s.Missed)
+	}
+
+	text := s.Text()
+	for _, want := range []string{"Sessions: 2 on 2 day(s)", "Questions: 4 (50.0% correct, +0.0 from the period before)", "Hard 16 vs 10: 2 of 2 wrong"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Text missing %q:\n%s", want, text)
+		}
+	}
+
+	empty := Build(history.New(), now, 7)
+	if !strings.Contains(empty.Text(), "No practice this period.") {
+		t.Errorf("Empty summary text:\n%s", empty.Text())
+	}
+}
+
+// Test the webhook receives the text for chat services and the figures
+func TestPostWebhook(t *testing.T) {
+	var received struct {
+		Text    string  `json:"text"`
+		Content string  `json:"content"`
+		Summary Summary `json:"summary"`
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &received)
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	s := Build(sampleHistory(now), now, 7)
+	s.Name = "Pat"
+	if err := PostWebhook(context.Background(), nil, server.URL, s); err != nil {
+		t.Fatal(err)
+	}
+	if received.Text != s.Text() || received.Content != received.Text || received.Summary.Total != 4 {
+		t.Errorf("Webhook received %+v", received)
+	}
+	if !strings.HasSuffix(s.Subject(), ": Pat") {
+		t.Errorf("Subject should name the player: %q", s.Subject())
+	}
+}
+
+// Test the email message has headers and a CRLF body
+func TestMessage(t *testing.T) {
+	now := time.Now()
+	message := string(Message("group@example.com", Build(sampleHistory(now), now, 7)))
+	if !strings.HasPrefix(message, "To: group@example.com\r\nSubject: Blackjack practice ") {
+		t.Errorf("Unexpected headers:\n%s", message)
+	}
+	if strings.Contains(strings.ReplaceAll(message, "\r\n", ""), "\n") {
+		t.Error("Every line should end in CRLF")
+	}
+}
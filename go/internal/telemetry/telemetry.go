@@ -0,0 +1,186 @@
+// Package telemetry reports anonymous, aggregate error rates to the
+// maintainers, so the default difficulty tiers can be tuned from how often
+// players really miss each hand.
+//
+// Telemetry is off unless the player turns it on in the config file and names
+// the endpoint to report to. A report holds only counts: for each chart cell
+// and rule set, how many times it was asked and how many answers were wrong,
+// plus the trainer and chart versions. It carries no names, times, sessions,
+// individual answers or anything else that identifies the player, and each
+// report covers only sessions finished since the last one was sent.
+//
+// When reports were last sent is stored as JSON in telemetry.json beside the
+// practice history.
+package telemetry
+
+import (
+	"blackjack_trainer/internal/atomicfile"
+	"blackjack_trainer/internal/history"
+	"blackjack_trainer/internal/stats"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+)
+
+// StateFileName is the name of the telemetry state file in the trainer's
+// directory.
+const StateFileName = "telemetry.json"
+
+// Interval is the least time between automatic reports.
+const Interval = 24 * time.Hour
+
+// Cell is the error count for one chart cell under one rule set.
+type Cell struct {
+	Cell     string `json:"cell"`
+	Rules    string `json:"rules"`
+	Attempts int    `json:"attempts"`
+	Errors   int    `json:"errors"`
+}
+
+// ErrorRate returns the fraction of answers that were wrong.
+func (c Cell) ErrorRate() float64 {
+	if c.Attempts == 0 {
+		return 0
+	}
+	return float64(c.Errors) / float64(c.Attempts)
+}
+
+// Report is what is sent to the endpoint.
+type Report struct {
+	// Version is the trainer's version and Chart its chart version, since
+	// the correct answers depend on them.
+	Version string `json:"version"`
+	Chart   int    `json:"chart"`
+	Cells   []Cell `json:"cells"`
+}
+
+// Attempts returns the number of answers the report counts.
+func (r Report) Attempts() int {
+	total := 0
+	for _, c := range r.Cells {
+		total += c.Attempts
+	}
+	return total
+}
+
+// Build counts the answers in sessions that ended after since, by cell and
+// rules, ordered by rules and then cell.
+func Build(sessions []history.Session, since time.Time) Report {
+	type key struct {
+		cell  stats.CellKey
+		rules string
+	}
+	counts := make(map[key]*Cell)
+	for _, s := range sessions {
+		if !s.Ended.After(since) {
+			continue
+		}
+		for _, attempt := range s.Attempts {
+			rules := attempt.Rules
+			if rules == "" {
+				rules = s.Rules
+			}
+			if rules == "" {
+				rules = stats.UnrecordedRules
+			}
+			k := key{stats.AttemptCell(attempt), rules}
+			c, ok := counts[k]
+			if !ok {
+				c = &Cell{Cell: k.cell.Label(), Rules: rules}
+				counts[k] = c
+			}
+			c.Attempts++
+			if !attempt.Correct {
+				c.Errors++
+			}
+		}
+	}
+
+	report := Report{Cells: []Cell{}}
+	for _, c := range counts {
+		report.Cells = append(report.Cells, *c)
+	}
+	sort.Slice(report.Cells, func(i, j int) bool {
+		a, b := report.Cells[i], report.Cells[j]
+		if a.Rules != b.Rules {
+			return a.Rules < b.Rules
+		}
+		return a.Cell < b.Cell
+	})
+	return report
+}
+
+// State records when a report was last sent.
+type State struct {
+	path     string
+	LastSent time.Time `json:"last_sent,omitempty"`
+}
+
+// OpenState loads the state file at path. A missing file means no report
+// has been sent.
+func OpenState(path string) (*State, error) {
+	s := &State{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Due reports whether Interval has passed since the last report.
+func (s *State) Due(now time.Time) bool {
+	return now.Sub(s.LastSent) >= Interval
+}
+
+// Save writes the state file.
+func (s *State) Save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return atomicfile.WriteFile(s.path, data, 0o600)
+}
+
+// Send posts the report as JSON to url. An empty report is not sent.
+func Send(ctx context.Context, client *http.Client, url string, report Report) error {
+	if url == "" {
+		return errors.New("no telemetry URL configured")
+	}
+	if len(report.Cells) == 0 {
+		return nil
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sending telemetry: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("sending telemetry: %s", resp.Status)
+	}
+	return nil
+}
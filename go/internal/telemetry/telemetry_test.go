@@ -0,0 +1,110 @@
+package telemetry
+
+import (
+	"blackjack_trainer/internal/history"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test reports count only sessions since the last report, by cell and rules
+func TestBuild(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	hard16 := history.Attempt{Cards: []int{10, 6}, DealerCard: 10, HandType: "hard", Action: "S", CorrectAction: "H"}
+	sessions := []history.Session{
+		{Rules: "Standard", Ended: start, Attempts: []history.Attempt{hard16}},
+		{Rules: "Standard", Ended: start.Add(time.Hour), Attempts: []history.Attempt{
+			hard16,
+			{Cards: []int{9, 7}, DealerCard: 10, HandType: "hard", Action: "H", CorrectAction: "H", Correct: true},
+			{Cards: []int{10, 6}, DealerCard: 10, HandType: "hard", Action: "S", CorrectAction: "S", Correct: true, Rules: "Vegas Strip"},
+		}},
+		{Ended: start.Add(2 * time.Hour), Attempts: []history.Attempt{hard16}},
+	}
+
+	report := Build(sessions, start)
+	if len(report.Cells) != 3 {
+		t.Fatalf("Expected 3 cells, got %+v", report.Cells)
+	}
+	if report.Attempts() != 4 {
+		t.Errorf("Expected 4 attempts since the last report, got %d", report.Attempts())
+	}
+	first := report.Cells[0]
+	if first.Rules != "Not recorded" || first.Attempts != 1 || first.Errors != 1 {
+		t.Errorf("Unexpected first cell %+v", first)
+	}
+	standard := report.Cells[1]
+	if standard.Rules != "Standard" || standard.Attempts != 2 || standard.Errors != 1 || standard.ErrorRate() != 0.5 {
+		t.Errorf("Unexpected Standard cell %+v", standard)
+	}
+	if strip := report.Cells[2]; strip.Rules != "Vegas Strip" || strip.Errors != 0 {
+		t.Errorf("Attempt rules should override the session's, got %+v", strip)
+	}
+
+	data, _ := json.Marshal(report)
+	for _, private := range []string{"2026", "latency", "cards", "action"} {
+		if strings.Contains(string(data), private) {
+			t.Errorf("Report should not contain %q: %s", private, data)
+		}
+	}
+}
+
+// Test the state file records when a report was last sent
+func TestState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), StateFileName)
+	state, err := OpenState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if !state.Due(now) {
+		t.Error("A report should be due when none has been sent")
+	}
+	state.LastSent = now
+	if err := state.Save(); err != nil {
+		t.Fatal(err)
+	}
+	state, err = OpenState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Due(now.Add(time.Hour)) || !state.Due(now.Add(Interval)) {
+		t.Errorf("Reports should be due once a day, last sent %v", state.LastSent)
+	}
+}
+
+// Test reports are posted as JSON and failures are errors
+func TestSend(t *testing.T) {
+	var received Report
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Unexpected request %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		json.Unmarshal(body, &received)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	report := Report{Version: "dev", Chart: 1, Cells: []Cell{{Cell: "Hard 16 vs 10", Rules: "Standard", Attempts: 3, Errors: 1}}}
+	if err := Send(context.Background(), nil, server.URL, report); err != nil {
+		t.Fatal(err)
+	}
+	if len(received.Cells) != 1 || received.Cells[0] != report.Cells[0] {
+		t.Errorf("Server received %+v", received)
+	}
+
+	status = http.StatusInternalServerError
+	if err := Send(context.Background(), nil, server.URL, report); err == nil {
+		t.Error("A failed post should be an error")
+	}
+	if err := Send(context.Background(), nil, "", report); err == nil {
+		t.Error("A missing URL should be an error")
+	}
+}
@@ -0,0 +1,74 @@
+package trainer
+
+// autoWindow is how many of the latest answers an auto-adjusting session
+// judges the player's form by.
+const autoWindow = 10
+
+// Accuracy over the window above which an auto-adjusting session asks
+// trickier cells, and below which it eases off.
+const (
+	autoRaiseAccuracy = 0.95
+	autoEaseAccuracy  = 0.60
+)
+
+// difficultyLadder orders the tiered difficulties an auto-adjusting session
+// moves between, easiest first.
+var difficultyLadder = []Difficulty{DifficultyEasy, DifficultyNormal, DifficultyHard}
+
+// difficultyCurve adjusts a session's difficulty to the player's rolling
+// accuracy, to keep the questions hard enough to stay interesting and easy
+// enough not to discourage.
+type difficultyCurve struct {
+	level   int
+	answers []bool // the latest answers, at most autoWindow
+}
+
+// newDifficultyCurve returns a curve starting at the given difficulty, or
+// false if it isn't one of the tiered difficulties.
+func newDifficultyCurve(start Difficulty) (*difficultyCurve, bool) {
+	for i, d := range difficultyLadder {
+		if d == start {
+			return &difficultyCurve{level: i}, true
+		}
+	}
+	return nil, false
+}
+
+// record notes an answer and returns the difficulty to use from now on,
+// and the step taken to it: 1 up, -1 down or 0. Once a full window of
+// answers is above autoRaiseAccuracy the difficulty steps up, and below
+// autoEaseAccuracy it steps down; the window then starts over, so each
+// step is judged on answers at the new difficulty.
+func (c *difficultyCurve) record(correct bool) (Difficulty, int) {
+	c.answers = append(c.answers, correct)
+	if len(c.answers) > autoWindow {
+		c.answers = c.answers[1:]
+	}
+	if len(c.answers) < autoWindow {
+		return difficultyLadder[c.level], 0
+	}
+
+	step := 0
+	switch accuracy := c.accuracy(); {
+	case accuracy > autoRaiseAccuracy && c.level < len(difficultyLadder)-1:
+		step = 1
+	case accuracy < autoEaseAccuracy && c.level > 0:
+		step = -1
+	}
+	if step != 0 {
+		c.level += step
+		c.answers = nil
+	}
+	return difficultyLadder[c.level], step
+}
+
+// accuracy returns the share of the window's answers that were correct.
+func (c *difficultyCurve) accuracy() float64 {
+	correct := 0
+	for _, answer := range c.answers {
+		if answer {
+			correct++
+		}
+	}
+	return float64(correct) / float64(len(c.answers))
+}
@@ -0,0 +1,492 @@
+package trainer
+
+import (
+	"blackjack_trainer/internal/hand"
+	"blackjack_trainer/internal/stats"
+	"blackjack_trainer/internal/strategy"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Constraints limit the scenarios a session deals. An empty field allows
+// everything: every dealer card, every hand type and every total.
+//
+// Constraints can be written as a spec of space-separated terms (see
+// ParseConstraints), e.g. "action=double dealer=2-6", so focused drills can
+// be composed with -focus, named in the config or requested over the API.
+type Constraints struct {
+	// DealerCards are the dealer upcards to choose from, 2-11 (11 for an ace).
+	DealerCards []int
+	// HandTypes are the kinds of player hand to choose from.
+	HandTypes []strategy.HandType
+	// Totals limits the player totals dealt for each hand type, as the
+	// chart labels them: a pair's total is the value of one of its cards.
+	// Totals outside what can be dealt (hard 5-20, soft 13-20, pairs 2-11)
+	// are left out, and a range with nothing left is ignored.
+	Totals map[strategy.HandType]TotalRange
+	// Tiers limits the cells dealt to those of the given difficulty tiers.
+	Tiers []strategy.Tier
+	// ActionClasses limits the cells dealt to those whose correct action
+	// is one of these: 'H', 'S', 'D' or 'Y'.
+	ActionClasses []rune
+	// Chart judges Tiers and ActionClasses. Nil means strategy.Default().
+	Chart *strategy.StrategyChart
+}
+
+// TotalRange is an inclusive range of player totals.
+type TotalRange struct {
+	Min, Max int
+}
+
+// allDealerCards lists every dealer upcard, 2 through ace.
+var allDealerCards = []int{2, 3, 4, 5, 6, 7, 8, 9, 10, 11}
+
+// allHandTypes lists every kind of player hand.
+var allHandTypes = []strategy.HandType{strategy.HandTypeHard, strategy.HandTypeSoft, strategy.HandTypePair}
+
+// dealableTotals are the totals random hands are dealt with for each hand
+// type. Hard hands start at 5 so they can be two different cards, and soft
+// 21 would be a blackjack.
+var dealableTotals = map[strategy.HandType]TotalRange{
+	strategy.HandTypeHard: {5, 20},
+	strategy.HandTypeSoft: {13, 20},
+	strategy.HandTypePair: {2, 11},
+}
+
+// totals returns the range of totals to deal for a hand type.
+func (c Constraints) totals(handType strategy.HandType) TotalRange {
+	r := dealableTotals[handType]
+	limit, ok := c.Totals[handType]
+	if !ok {
+		return r
+	}
+	if limit.Min > r.Min {
+		r.Min = limit.Min
+	}
+	if limit.Max < r.Max {
+		r.Max = limit.Max
+	}
+	if r.Min > r.Max {
+		return dealableTotals[handType]
+	}
+	return r
+}
+
+// generate deals a scenario within the constraints. A choice of a single
+// hand type draws no random number for it, so sessions drilling one kind
+// of hand deal the same questions from a seed as they always have.
+func (bt *BaseTrainer) generate(c Constraints) Scenario {
+	dealerCards := c.DealerCards
+	if len(dealerCards) == 0 {
+		dealerCards = allDealerCards
+	}
+	dealerCard := dealerCards[bt.rng.Intn(len(dealerCards))]
+
+	handTypes := c.HandTypes
+	if len(handTypes) == 0 {
+		handTypes = allHandTypes
+	}
+	handType := handTypes[0]
+	if len(handTypes) > 1 {
+		handType = handTypes[bt.rng.Intn(len(handTypes))]
+	}
+
+	return Scenario{Hand: bt.generateHand(handType, c.totals(handType)), DealerCard: dealerCard}
+}
+
+// generateHand deals a hand of the given type with a total in the range.
+func (bt *BaseTrainer) generateHand(handType strategy.HandType, totals TotalRange) hand.Hand {
+	total := bt.rng.Intn(totals.Max-totals.Min+1) + totals.Min
+	switch handType {
+	case strategy.HandTypePair:
+		return hand.New(total, total)
+	case strategy.HandTypeSoft:
+		return hand.New(hand.Ace, total-hand.Ace)
+	default:
+		return bt.GenerateHardHand(total)
+	}
+}
+
+// allows reports whether the constraints allow a chart cell.
+func (c Constraints) allows(chart *strategy.StrategyChart, cell stats.CellKey) bool {
+	if len(c.DealerCards) > 0 && !containsCard(c.DealerCards, cell.DealerCard) {
+		return false
+	}
+	if len(c.HandTypes) > 0 && !containsHandType(c.HandTypes, cell.HandType) {
+		return false
+	}
+	if r := c.totals(cell.HandType); cell.PlayerTotal < r.Min || cell.PlayerTotal > r.Max {
+		return false
+	}
+	if len(c.Tiers) > 0 && !containsTier(c.Tiers, chart.GetTier(cell.HandType, cell.PlayerTotal, cell.DealerCard)) {
+		return false
+	}
+	if len(c.ActionClasses) > 0 {
+		action := chart.GetCorrectAction(cell.HandType, cell.PlayerTotal, cell.DealerCard)
+		if !strings.ContainsRune(string(c.ActionClasses), action) {
+			return false
+		}
+	}
+	return true
+}
+
+// containsCard reports whether cards holds card.
+func containsCard(cards []int, card int) bool {
+	for _, c := range cards {
+		if c == card {
+			return true
+		}
+	}
+	return false
+}
+
+// containsHandType reports whether handTypes holds handType.
+func containsHandType(handTypes []strategy.HandType, handType strategy.HandType) bool {
+	for _, t := range handTypes {
+		if t == handType {
+			return true
+		}
+	}
+	return false
+}
+
+// containsTier reports whether tiers holds tier.
+func containsTier(tiers []strategy.Tier, tier strategy.Tier) bool {
+	for _, t := range tiers {
+		if t == tier {
+			return true
+		}
+	}
+	return false
+}
+
+// constraintActions maps the action names of a spec to action codes.
+var constraintActions = []struct {
+	name   string
+	action rune
+}{
+	{"hit", 'H'}, {"stand", 'S'}, {"double", 'D'}, {"split", 'Y'},
+}
+
+// ParseConstraints parses a constraint spec: space-separated key=value
+// terms, each value a comma-separated list.
+//
+//	hand=hard,soft,pair    hand types
+//	dealer=2-6,A           dealer upcards, with ranges
+//	hard=12-16             hard totals (likewise soft=13-18, pair=2-9)
+//	tier=tricky            difficulty tiers: trivial, standard, tricky
+//	action=double,split    correct actions: hit, stand, double, split
+//
+// An empty spec allows every cell.
+func ParseConstraints(spec string) (Constraints, error) {
+	var c Constraints
+	for _, term := range strings.Fields(spec) {
+		key, value, ok := strings.Cut(term, "=")
+		if !ok || value == "" {
+			return Constraints{}, fmt.Errorf("constraint %q should be key=value", term)
+		}
+		var err error
+		switch strings.ToLower(key) {
+		case "hand":
+			err = parseHandTypes(&c, value)
+		case "dealer":
+			err = parseDealerCards(&c, value)
+		case "hard", "soft", "pair":
+			err = parseTotals(&c, strings.ToLower(key), value)
+		case "tier":
+			err = parseTiers(&c, value)
+		case "action":
+			err = parseActions(&c, value)
+		default:
+			err = fmt.Errorf("unknown constraint %q (valid: hand, dealer, hard, soft, pair, tier, action)", key)
+		}
+		if err != nil {
+			return Constraints{}, err
+		}
+	}
+	return c, nil
+}
+
+// parseHandTypes adds the hand types of a spec value.
+func parseHandTypes(c *Constraints, value string) error {
+	for _, name := range strings.Split(value, ",") {
+		handType, ok := parseHandType(name)
+		if !ok {
+			return fmt.Errorf("unknown hand type %q (valid: hard, soft, pair)", name)
+		}
+		c.HandTypes = append(c.HandTypes, handType)
+	}
+	return nil
+}
+
+// parseHandType parses a hand type name, accepting "pairs" for pair.
+func parseHandType(name string) (strategy.HandType, bool) {
+	for _, t := range allHandTypes {
+		if strings.EqualFold(name, t.String()) || strings.EqualFold(name, t.String()+"s") {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
+// parseDealerCards adds the dealer cards and card ranges of a spec value.
+func parseDealerCards(c *Constraints, value string) error {
+	for _, item := range strings.Split(value, ",") {
+		lowName, highName, isRange := strings.Cut(item, "-")
+		low, err := hand.ParseCard(lowName)
+		if err != nil {
+			return fmt.Errorf("dealer: %w", err)
+		}
+		high := low
+		if isRange {
+			if high, err = hand.ParseCard(highName); err != nil {
+				return fmt.Errorf("dealer: %w", err)
+			}
+		}
+		if high < low {
+			return fmt.Errorf("dealer range %q runs backwards", item)
+		}
+		for card := low; card <= high; card++ {
+			if !containsCard(c.DealerCards, card) {
+				c.DealerCards = append(c.DealerCards, card)
+			}
+		}
+	}
+	sort.Ints(c.DealerCards)
+	return nil
+}
+
+// parseTotals sets the range of totals for a hand type, e.g. "12-16" or
+// "12". Pair totals are card names, so "pair=8-A" is allowed.
+func parseTotals(c *Constraints, key, value string) error {
+	handType, _ := parseHandType(key)
+	lowName, highName, isRange := strings.Cut(value, "-")
+	if !isRange {
+		highName = lowName
+	}
+	parse := func(s string) (int, error) {
+		if handType == strategy.HandTypePair {
+			return hand.ParseCard(s)
+		}
+		var n int
+		if _, err := fmt.Sscanf(s, "%d", &n); err != nil || fmt.Sprint(n) != s {
+			return 0, fmt.Errorf("invalid total %q", s)
+		}
+		return n, nil
+	}
+	low, err := parse(lowName)
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	high, err := parse(highName)
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	if high < low {
+		return fmt.Errorf("%s range %q runs backwards", key, value)
+	}
+	if c.Totals == nil {
+		c.Totals = make(map[strategy.HandType]TotalRange)
+	}
+	c.Totals[handType] = TotalRange{low, high}
+	return nil
+}
+
+// parseTiers adds the difficulty tiers of a spec value.
+func parseTiers(c *Constraints, value string) error {
+	for _, name := range strings.Split(value, ",") {
+		found := false
+		for _, tier := range strategy.Tiers {
+			if strings.EqualFold(name, tier.String()) {
+				c.Tiers, found = append(c.Tiers, tier), true
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown tier %q (valid: trivial, standard, tricky)", name)
+		}
+	}
+	return nil
+}
+
+// parseActions adds the correct actions of a spec value.
+func parseActions(c *Constraints, value string) error {
+	for _, name := range strings.Split(value, ",") {
+		found := false
+		for _, a := range constraintActions {
+			if strings.EqualFold(name, a.name) {
+				c.ActionClasses, found = append(c.ActionClasses, a.action), true
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown action %q (valid: hit, stand, double, split)", name)
+		}
+	}
+	return nil
+}
+
+// String formats the constraints as a spec that ParseConstraints reads back.
+func (c Constraints) String() string {
+	var terms []string
+	if len(c.HandTypes) > 0 {
+		names := make([]string, len(c.HandTypes))
+		for i, t := range c.HandTypes {
+			names[i] = t.String()
+		}
+		terms = append(terms, "hand="+strings.Join(names, ","))
+	}
+	if len(c.DealerCards) > 0 {
+		names := make([]string, len(c.DealerCards))
+		for i, card := range c.DealerCards {
+			names[i] = hand.CardString(card)
+		}
+		terms = append(terms, "dealer="+strings.Join(names, ","))
+	}
+	for _, t := range allHandTypes {
+		if r, ok := c.Totals[t]; ok {
+			low, high := fmt.Sprint(r.Min), fmt.Sprint(r.Max)
+			if t == strategy.HandTypePair {
+				low, high = hand.CardString(r.Min), hand.CardString(r.Max)
+			}
+			terms = append(terms, fmt.Sprintf("%s=%s-%s", t, low, high))
+		}
+	}
+	if len(c.Tiers) > 0 {
+		names := make([]string, len(c.Tiers))
+		for i, tier := range c.Tiers {
+			names[i] = tier.String()
+		}
+		terms = append(terms, "tier="+strings.Join(names, ","))
+	}
+	if len(c.ActionClasses) > 0 {
+		var names []string
+		for _, action := range c.ActionClasses {
+			for _, a := range constraintActions {
+				if a.action == action {
+					names = append(names, a.name)
+				}
+			}
+		}
+		terms = append(terms, "action="+strings.Join(names, ","))
+	}
+	return strings.Join(terms, " ")
+}
+
+// ConstrainedSession drills the chart cells allowed by a set of
+// constraints, each equally often, so focused drills (e.g. only doubles
+// against weak dealers) need no session type of their own.
+type ConstrainedSession struct {
+	*BaseTrainer
+	constraints Constraints
+	cells       []stats.CellKey
+	name        string // built-in drill name, or "" for a custom spec
+	description string
+}
+
+// builtinDrills are the focused drills offered on the menu, which -focus
+// also accepts by name.
+var builtinDrills = map[string]struct {
+	constraints Constraints
+	description string
+}{
+	"doubles": {Constraints{ActionClasses: []rune{'D'}},
+		"only hands to double: doubling puts twice the bet at risk, and doubles are the plays most often missed"},
+	"splits": {Constraints{ActionClasses: []rune{'Y'}},
+		"only hands to split: splitting puts a second bet at risk, and splits are among the plays most often missed"},
+	"ten-ace": {Constraints{
+		DealerCards: []int{10, 11},
+		HandTypes:   []strategy.HandType{strategy.HandTypeHard, strategy.HandTypeSoft},
+		Totals: map[strategy.HandType]TotalRange{
+			strategy.HandTypeHard: {10, 17},
+			strategy.HandTypeSoft: {17, 19},
+		}},
+		"hard 10-17 and soft 17-19 against a 10 or ace, the upcards behind most costly mistakes: " +
+			"where doubling stops, stiff hands hit, and standing starts. Hard 15 and 16 are " +
+			"the hands to surrender where it's allowed, but the charts don't include surrender"},
+}
+
+// BuiltinDrillNames lists the built-in focused drills.
+func BuiltinDrillNames() []string {
+	names := make([]string, 0, len(builtinDrills))
+	for name := range builtinDrills {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewBuiltinDrill creates a built-in focused drill under a chart's rules,
+// or returns false for an unknown name.
+func NewBuiltinDrill(name string, chart *strategy.StrategyChart) (*ConstrainedSession, bool) {
+	drill, ok := builtinDrills[name]
+	if !ok {
+		return nil, false
+	}
+	c := drill.constraints
+	c.Chart = chart
+	session, err := NewConstrainedSession(c)
+	if err != nil {
+		return nil, false
+	}
+	session.name, session.description = name, drill.description
+	return session, true
+}
+
+// NewConstrainedSession creates a session drilling the cells the
+// constraints allow, or returns an error if they allow none.
+func NewConstrainedSession(c Constraints) (*ConstrainedSession, error) {
+	chart := c.Chart
+	if chart == nil {
+		chart = strategy.Default()
+	}
+	var cells []stats.CellKey
+	for _, cell := range stats.ChartCells() {
+		if c.allows(chart, cell) {
+			cells = append(cells, cell)
+		}
+	}
+	if len(cells) == 0 {
+		return nil, fmt.Errorf("no chart cells match %q", c.String())
+	}
+	return &ConstrainedSession{
+		BaseTrainer: NewBaseTrainer(),
+		constraints: c,
+		cells:       cells,
+	}, nil
+}
+
+// GetModeName returns the mode name.
+func (s *ConstrainedSession) GetModeName() string {
+	if s.name != "" {
+		return s.name
+	}
+	return "constrained"
+}
+
+// Description describes the constraints for the help screen.
+func (s *ConstrainedSession) Description() string {
+	if s.description != "" {
+		return s.description
+	}
+	spec := s.constraints.String()
+	if spec == "" {
+		spec = "no constraints"
+	}
+	return fmt.Sprintf("the %d chart cells matching %s", len(s.cells), spec)
+}
+
+// GetMaxQuestions returns the maximum number of questions.
+func (s *ConstrainedSession) GetMaxQuestions() int {
+	return 50
+}
+
+// SetupSession sets up the session (no additional setup needed).
+func (s *ConstrainedSession) SetupSession() bool {
+	return true
+}
+
+// GenerateScenario deals a hand for one of the allowed cells chosen at random.
+func (s *ConstrainedSession) GenerateScenario() Scenario {
+	key := s.cells[s.rng.Intn(len(s.cells))]
+	return s.generateCell(key.HandType, key.PlayerTotal, key.DealerCard)
+}
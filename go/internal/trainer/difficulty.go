@@ -0,0 +1,64 @@
+package trainer
+
+import (
+	"blackjack_trainer/internal/strategy"
+	"fmt"
+)
+
+// Difficulty controls how often each chart tier is asked.
+type Difficulty string
+
+const (
+	// DifficultyEasy draws mostly trivial cells.
+	DifficultyEasy Difficulty = "easy"
+	// DifficultyNormal asks scenarios as the session generates them.
+	DifficultyNormal Difficulty = "normal"
+	// DifficultyHard draws mostly tricky cells.
+	DifficultyHard Difficulty = "hard"
+	// DifficultyAdaptive draws mostly the cells the player has mastered
+	// least, by the mastery model in package stats.
+	DifficultyAdaptive Difficulty = "adaptive"
+)
+
+// tierWeights gives the relative chance of keeping a generated scenario of
+// each tier. Difficulties without weights keep every scenario.
+var tierWeights = map[Difficulty]map[strategy.Tier]int{
+	DifficultyEasy: {strategy.TierTrivial: 6, strategy.TierStandard: 3, strategy.TierTricky: 1},
+	DifficultyHard: {strategy.TierTrivial: 1, strategy.TierStandard: 3, strategy.TierTricky: 6},
+}
+
+// ParseDifficulty parses a difficulty name. An empty name is normal.
+func ParseDifficulty(name string) (Difficulty, error) {
+	switch Difficulty(name) {
+	case "", DifficultyNormal:
+		return DifficultyNormal, nil
+	case DifficultyEasy, DifficultyHard, DifficultyAdaptive:
+		return Difficulty(name), nil
+	default:
+		return "", fmt.Errorf("unknown difficulty %q (valid: easy, normal, hard, adaptive)", name)
+	}
+}
+
+// tierWeight returns the relative chance of keeping a scenario of the given
+// tier, out of the difficulty's maximum weight. Unweighted difficulties keep
+// every scenario.
+func (d Difficulty) tierWeight(tier strategy.Tier) (weight, max int) {
+	weights, weighted := tierWeights[d]
+	if !weighted {
+		return 1, 1
+	}
+	for _, w := range weights {
+		if w > max {
+			max = w
+		}
+	}
+	return weights[tier], max
+}
+
+// masteryWeight returns the relative chance of keeping a scenario whose cell
+// has the given mastery score, out of the maximum weight: unpracticed cells
+// are always kept and fully mastered ones one time in five, so they are
+// still refreshed.
+func masteryWeight(score float64) (weight, max int) {
+	return 10 - int(8*score), 10
+}
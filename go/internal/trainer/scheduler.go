@@ -0,0 +1,166 @@
+package trainer
+
+import (
+	"blackjack_trainer/internal/stats"
+	"blackjack_trainer/internal/strategy"
+	"context"
+	"log/slog"
+	"math/rand"
+)
+
+// DefaultMaxRepeat is the default limit on consecutive questions sharing the
+// same correct action.
+const DefaultMaxRepeat = 3
+
+// maxDraws bounds how many scenarios are generated looking for an acceptable
+// one, so sessions that cannot satisfy every preference (e.g. absolutes on
+// hard difficulty, or a pairs drill against dealer cards where every pair
+// splits) still produce questions.
+const maxDraws = 50
+
+// scheduler chooses each question of a session. It weights scenarios by
+// difficulty tier, or by mastery in adaptive sessions, and keeps runs of the
+// same correct action short, so the answer cannot be guessed from the
+// previous questions.
+type scheduler struct {
+	session     TrainingSession
+	difficulty  Difficulty
+	maxRepeat   int // 0 disables the limit
+	chart       *strategy.StrategyChart
+	rng         *rand.Rand
+	composition bool // apply composition-dependent exceptions
+	// mastery scores the player's cells for adaptive sessions and reviews.
+	mastery stats.Mastery
+	// reviews holds the cells due for review, asked in sessions that can
+	// generate a given cell, as a reviewRate share of the questions.
+	reviews    []stats.CellKey
+	reviewRate float64
+	// reviewing is set when the last question was a review.
+	reviewing bool
+
+	lastAction rune
+	run        int
+}
+
+// newScheduler creates a scheduler for a session.
+func newScheduler(session TrainingSession, difficulty Difficulty, maxRepeat int,
+	chart *strategy.StrategyChart, rng *rand.Rand) *scheduler {
+	return &scheduler{
+		session:     session,
+		difficulty:  difficulty,
+		maxRepeat:   maxRepeat,
+		chart:       chart,
+		rng:         rng,
+		composition: usesComposition(session),
+	}
+}
+
+// next returns the next question. If no acceptable scenario is found within
+// maxDraws, the best candidate is used: one that keeps the run short if any
+// was generated, otherwise the last one.
+func (s *scheduler) next() Scenario {
+	if scenario, ok := s.review(); ok {
+		s.reviewing = true
+		s.record(scenario, 0, 0, false)
+		return scenario
+	}
+	s.reviewing = false
+
+	var scenario, fallback Scenario
+	haveFallback := false
+	rejectedRun, rejectedTier := 0, 0
+
+	for i := 0; i < maxDraws; i++ {
+		scenario = s.session.GenerateScenario()
+		if s.extendsRunTooFar(scenario) {
+			rejectedRun++
+			continue
+		}
+		if !haveFallback {
+			fallback, haveFallback = scenario, true
+		}
+
+		handType, value := strategy.Classify(scenario.Hand)
+		weight, max := s.weight(handType, value, scenario.DealerCard)
+		if s.rng.Intn(max) < weight {
+			s.record(scenario, rejectedRun, rejectedTier, false)
+			return scenario
+		}
+		rejectedTier++
+	}
+
+	if haveFallback {
+		scenario = fallback
+	}
+	s.record(scenario, rejectedRun, rejectedTier, true)
+	return scenario
+}
+
+// review returns a question on the next cell due for review, at the review
+// rate while any remain. A review that would extend the run of the same
+// correct action too far waits for a later question.
+func (s *scheduler) review() (Scenario, bool) {
+	generator, ok := s.session.(cellGenerator)
+	if !ok || len(s.reviews) == 0 || s.rng.Float64() >= s.reviewRate {
+		return Scenario{}, false
+	}
+	key := s.reviews[0]
+	scenario := generator.GenerateCell(key.HandType, key.PlayerTotal, key.DealerCard)
+	if s.extendsRunTooFar(scenario) {
+		return Scenario{}, false
+	}
+	s.reviews = s.reviews[1:]
+	return scenario, true
+}
+
+// weight returns the relative chance, out of max, of asking a cell: by its
+// tier, or in adaptive sessions by how little it has been mastered.
+func (s *scheduler) weight(handType strategy.HandType, value, dealerCard int) (weight, max int) {
+	if s.difficulty == DifficultyAdaptive {
+		return masteryWeight(s.mastery.Score(stats.CellKey{HandType: handType, PlayerTotal: value, DealerCard: dealerCard}))
+	}
+	return s.difficulty.tierWeight(s.chart.GetTier(handType, value, dealerCard))
+}
+
+// extendsRunTooFar reports whether asking the scenario would exceed the
+// limit on consecutive questions with the same correct action.
+func (s *scheduler) extendsRunTooFar(scenario Scenario) bool {
+	if s.maxRepeat <= 0 || s.run < s.maxRepeat {
+		return false
+	}
+	return s.correctAction(scenario) == s.lastAction
+}
+
+// record tracks the correct action of an asked scenario and logs why it was
+// chosen: how many candidates were rejected for extending the run of the
+// same action or by the difficulty weighting, and whether it is a fallback.
+func (s *scheduler) record(scenario Scenario, rejectedRun, rejectedTier int, fallback bool) {
+	action := s.correctAction(scenario)
+	if action == s.lastAction {
+		s.run++
+	} else {
+		s.lastAction, s.run = action, 1
+	}
+
+	if !slog.Default().Enabled(context.Background(), slog.LevelDebug) {
+		return
+	}
+	handType, value := strategy.Classify(scenario.Hand)
+	slog.Debug("scheduled question",
+		slog.String("mode", s.session.GetModeName()),
+		slog.String("cell", strategy.CellLabel(handType, value, scenario.DealerCard)),
+		slog.String("cards", scenario.Hand.String()),
+		slog.String("tier", s.chart.GetTier(handType, value, scenario.DealerCard).String()),
+		slog.String("difficulty", string(s.difficulty)),
+		slog.String("action", string(action)),
+		slog.Int("run", s.run),
+		slog.Int("rejected_run", rejectedRun),
+		slog.Int("rejected_tier", rejectedTier),
+		slog.Bool("fallback", fallback),
+	)
+}
+
+func (s *scheduler) correctAction(scenario Scenario) rune {
+	action, _ := correctPlay(s.chart, scenario, s.composition)
+	return action
+}
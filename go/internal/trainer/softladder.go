@@ -0,0 +1,179 @@
+package trainer
+
+import (
+	"blackjack_trainer/internal/hand"
+	"blackjack_trainer/internal/strategy"
+	"blackjack_trainer/internal/ui"
+	"fmt"
+	"strings"
+)
+
+// The soft totals and dealer cards of the soft doubling ladder: every soft
+// double is against a 2-7 and for a soft 13-18.
+const (
+	ladderLowTotal, ladderHighTotal   = 13, 18
+	ladderLowDealer, ladderHighDealer = 2, 7
+)
+
+// ladderRung is a run of soft totals that double against the same dealer
+// cards, e.g. soft 13-14 against 5-6.
+type ladderRung struct {
+	low, high int   // soft totals
+	doubles   []int // dealer cards doubled against, in order
+}
+
+// SoftLadderTrainingSession teaches the soft doubling ladder: each step up
+// in soft total doubles against more dealer cards. The first pass walks
+// the ladder in order, row by row, with each rung pointed out; the second
+// asks the same hands in random order.
+type SoftLadderTrainingSession struct {
+	*BaseTrainer
+	rungs []ladderRung
+	cells []Scenario // first pass, in ladder order
+	next  int
+}
+
+// NewSoftLadderTrainingSession creates a soft doubling ladder drill for the
+// chart's rules.
+func NewSoftLadderTrainingSession(chart *strategy.StrategyChart) *SoftLadderTrainingSession {
+	s := &SoftLadderTrainingSession{BaseTrainer: NewBaseTrainer()}
+	for total := ladderLowTotal; total <= ladderHighTotal; total++ {
+		var doubles []int
+		for dealer := ladderLowDealer; dealer <= ladderHighDealer; dealer++ {
+			s.cells = append(s.cells, Scenario{Hand: hand.New(hand.Ace, total-hand.Ace), DealerCard: dealer})
+			if chart.GetCorrectAction(strategy.HandTypeSoft, total, dealer) == 'D' {
+				doubles = append(doubles, dealer)
+			}
+		}
+		if n := len(s.rungs); n > 0 && sameCards(s.rungs[n-1].doubles, doubles) {
+			s.rungs[n-1].high = total
+		} else {
+			s.rungs = append(s.rungs, ladderRung{low: total, high: total, doubles: doubles})
+		}
+	}
+	return s
+}
+
+// GetModeName returns the mode name.
+func (s *SoftLadderTrainingSession) GetModeName() string {
+	return "soft_ladder"
+}
+
+// Description describes the mode for the help screen.
+func (s *SoftLadderTrainingSession) Description() string {
+	return "soft 13-18 against dealer 2-7, in ladder order and then shuffled, to learn where soft hands double"
+}
+
+// GetMaxQuestions returns the maximum number of questions: the ladder in
+// order, then shuffled.
+func (s *SoftLadderTrainingSession) GetMaxQuestions() int {
+	return 2 * len(s.cells)
+}
+
+// SetupSession shows the ladder before climbing it.
+func (s *SoftLadderTrainingSession) SetupSession() bool {
+	fmt.Fprintln(ui.Output(), "The soft doubling ladder: the higher the soft total, the more dealer cards it doubles against.")
+	for _, rung := range s.rungs {
+		fmt.Fprintf(ui.Output(), "  %s\n", rung)
+	}
+	fmt.Fprintln(ui.Output(), "First we climb it row by row, then ask the same hands in random order.")
+	return true
+}
+
+// FixedOrder reports that the ladder must be climbed in order.
+func (s *SoftLadderTrainingSession) FixedOrder() bool {
+	return true
+}
+
+// GenerateScenario returns the next hand up the ladder, or on the second
+// pass one of its hands at random.
+func (s *SoftLadderTrainingSession) GenerateScenario() Scenario {
+	s.next++
+	if s.next <= len(s.cells) {
+		return s.cells[s.next-1]
+	}
+	return s.cells[s.rng.Intn(len(s.cells))]
+}
+
+// Annotate points out each rung as the first pass reaches it, and the
+// start of the shuffled pass.
+func (s *SoftLadderTrainingSession) Annotate(scenario Scenario) string {
+	perRow := ladderHighDealer - ladderLowDealer + 1
+	switch {
+	case s.next == len(s.cells)+1:
+		return "Ladder climbed. Now the same hands in random order: recall each rung."
+	case s.next > len(s.cells) || (s.next-1)%perRow != 0:
+		return ""
+	}
+	_, total := strategy.Classify(scenario.Hand)
+	for i, rung := range s.rungs {
+		if total != rung.low {
+			continue
+		}
+		note := "Next rung: " + rung.String()
+		if i > 0 && len(rung.doubles) > len(s.rungs[i-1].doubles) {
+			note += fmt.Sprintf(", %d more dealer card(s) than the rung below", len(rung.doubles)-len(s.rungs[i-1].doubles))
+		}
+		return note + "."
+	}
+	return ""
+}
+
+// Explain adds the hand's rung of the ladder to the explanation.
+func (s *SoftLadderTrainingSession) Explain(chart *strategy.StrategyChart, scenario Scenario, explanation string) string {
+	_, total := strategy.Classify(scenario.Hand)
+	for _, rung := range s.rungs {
+		if total >= rung.low && total <= rung.high {
+			return explanation + "\nLadder: " + rung.String()
+		}
+	}
+	return explanation
+}
+
+// String describes the rung, e.g. "A,2-A,3 double against 5-6".
+func (r ladderRung) String() string {
+	hands := softName(r.low)
+	if r.high > r.low {
+		hands += "-" + softName(r.high)
+	}
+	if len(r.doubles) == 0 {
+		return hands + " never double"
+	}
+	return hands + " double against " + cardRange(r.doubles)
+}
+
+// softName names a two-card soft total by its cards, e.g. "A,4" for soft 15.
+func softName(total int) string {
+	return "A," + hand.CardString(total-hand.Ace)
+}
+
+// cardRange formats dealer cards as ranges, e.g. "3-6" or "2, 4-6".
+func cardRange(cards []int) string {
+	var parts []string
+	for i := 0; i < len(cards); {
+		j := i
+		for j+1 < len(cards) && cards[j+1] == cards[j]+1 {
+			j++
+		}
+		part := hand.CardString(cards[i])
+		if j > i {
+			part += "-" + hand.CardString(cards[j])
+		}
+		parts = append(parts, part)
+		i = j + 1
+	}
+	return strings.Join(parts, ", ")
+}
+
+// sameCards reports whether two lists of cards are the same.
+func sameCards(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
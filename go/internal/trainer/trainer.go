@@ -6,14 +6,34 @@
 // - DealerGroupTrainingSession: Focus on specific dealer strength groups
 // - HandTypeTrainingSession: Focus on specific hand types (hard/soft/pairs)
 // - AbsoluteTrainingSession: Practice absolute rules (always/never scenarios)
+// - RealisticTrainingSession: Hands dealt from a shoe at real-game frequencies
+// - CompositionTrainingSession: Composition-dependent exceptions to the chart
+// - GameTrainingSession: Decisions dealt by a blackjack variant such as Free Bet
+// - TaggedTrainingSession: Hands the player tagged during earlier sessions
+// - ExamTrainingSession: A full-chart exam, scored apart from practice
+// - QuizTrainingSession: An instructor's fixed list of questions
+// - ConstrainedSession: The chart cells allowed by constraints, for focused drills
+// - SoftLadderTrainingSession: The soft doubling ladder, in order and then shuffled
 package trainer
 
 import (
+	"blackjack_trainer/internal/deck"
+	"blackjack_trainer/internal/eventlog"
+	"blackjack_trainer/internal/exam"
+	"blackjack_trainer/internal/hand"
+	"blackjack_trainer/internal/history"
+	"blackjack_trainer/internal/lessons"
+	"blackjack_trainer/internal/simulate"
+	"blackjack_trainer/internal/spectate"
 	"blackjack_trainer/internal/stats"
 	"blackjack_trainer/internal/strategy"
 	"blackjack_trainer/internal/ui"
+	"context"
+	"errors"
 	"fmt"
 	"math/rand"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -24,18 +44,39 @@ type TrainingSession interface {
 	// GetMaxQuestions returns the maximum number of questions for this session type.
 	GetMaxQuestions() int
 	// GenerateScenario generates a scenario for this training mode.
-	// Returns (handType, playerCards, playerTotal, dealerCard).
-	GenerateScenario() (strategy.HandType, []int, int, int)
+	GenerateScenario() Scenario
 	// SetupSession sets up the session. Returns true if setup successful, false if user cancelled.
 	SetupSession() bool
 }
 
+// compositionDependent is implemented by sessions whose answers depend on
+// the exact cards in a hand, not just its total.
+type compositionDependent interface {
+	CompositionDependent() bool
+}
+
+// usesComposition reports whether a session's answers take the cards in each
+// hand into account.
+func usesComposition(session TrainingSession) bool {
+	cd, ok := session.(compositionDependent)
+	return ok && cd.CompositionDependent()
+}
+
+// correctPlay returns the correct action and its explanation for a
+// scenario, applying composition-dependent exceptions when composition is set.
+func correctPlay(chart *strategy.StrategyChart, scenario Scenario, composition bool) (rune, string) {
+	if composition {
+		return chart.GetCompositionActionForHand(scenario.Hand, scenario.DealerCard),
+			chart.GetCompositionExplanationForHand(scenario.Hand, scenario.DealerCard)
+	}
+	return chart.GetCorrectActionForHand(scenario.Hand, scenario.DealerCard),
+		chart.GetExplanationForHand(scenario.Hand, scenario.DealerCard)
+}
+
 // Scenario represents a training scenario.
 type Scenario struct {
-	HandType    strategy.HandType
-	PlayerCards []int
-	PlayerTotal int
-	DealerCard  int
+	Hand       hand.Hand
+	DealerCard int
 }
 
 // BaseTrainer provides common functionality for all training sessions.
@@ -50,6 +91,16 @@ func NewBaseTrainer() *BaseTrainer {
 	}
 }
 
+// Seed restarts the random number generator from a seed.
+func (bt *BaseTrainer) Seed(seed int64) {
+	bt.SetSource(rand.NewSource(seed))
+}
+
+// SetSource draws questions from src from now on.
+func (bt *BaseTrainer) SetSource(src rand.Source) {
+	bt.rng = rand.New(src)
+}
+
 // GenerateHandCards generates card representation for a hand.
 func (bt *BaseTrainer) GenerateHandCards(handType strategy.HandType, playerTotal int) []int {
 	switch handType {
@@ -98,6 +149,47 @@ func (bt *BaseTrainer) GenerateHandCards(handType strategy.HandType, playerTotal
 	}
 }
 
+// GenerateHardHand generates a hard hand (no aces, not a pair) with the given total.
+// Totals of 5-11 are always two different cards so the hand can be doubled.
+func (bt *BaseTrainer) GenerateHardHand(playerTotal int) hand.Hand {
+	if playerTotal >= 5 && playerTotal <= 11 {
+		for {
+			firstCard := bt.rng.Intn(playerTotal-3) + 2 // 2 to playerTotal-2
+			secondCard := playerTotal - firstCard
+			if secondCard != firstCard && secondCard <= 10 {
+				return hand.New(firstCard, secondCard)
+			}
+		}
+	}
+
+	for {
+		h := hand.New(bt.GenerateHandCards(strategy.HandTypeHard, playerTotal)...)
+		if !h.IsPair() {
+			return h
+		}
+	}
+}
+
+// generateCell deals a hand for a chart cell. Soft 21 is dealt as three
+// cards, since two would be a blackjack.
+func (bt *BaseTrainer) generateCell(handType strategy.HandType, playerTotal, dealerCard int) Scenario {
+	var h hand.Hand
+	switch {
+	case handType == strategy.HandTypeHard:
+		h = bt.GenerateHardHand(playerTotal)
+	case handType == strategy.HandTypeSoft && playerTotal == 21:
+		h = hand.New(hand.Ace, 4, 6)
+	default:
+		h = hand.New(bt.GenerateHandCards(handType, playerTotal)...)
+	}
+	return Scenario{Hand: h, DealerCard: dealerCard}
+}
+
+// GenerateRandomHand generates a random hand of the given type.
+func (bt *BaseTrainer) GenerateRandomHand(handType strategy.HandType) hand.Hand {
+	return bt.generateHand(handType, dealableTotals[handType])
+}
+
 // CheckAnswer checks if user's action matches the correct action.
 func CheckAnswer(userAction, correctAction rune) bool {
 	normalizedUser := userAction
@@ -107,54 +199,821 @@ func CheckAnswer(userAction, correctAction rune) bool {
 	return normalizedUser == correctAction
 }
 
-// RunSession runs the main training session loop.
-func RunSession(session TrainingSession, statistics *stats.Statistics) {
+// NewAttempt records an answer to a scenario whose correct play is
+// correctAction, under the chart's rules. A wrong answer is priced with the
+// loss table and classed by severity, so every mode that records answers
+// weighs mistakes the same way.
+func NewAttempt(chart *strategy.StrategyChart, losses *simulate.LossTable, scenario Scenario, action, correctAction rune, latency time.Duration) history.Attempt {
+	handType, _ := strategy.Classify(scenario.Hand)
+	attempt := history.Attempt{
+		Cards:         scenario.Hand.Cards,
+		DealerCard:    scenario.DealerCard,
+		HandType:      handType.String(),
+		Action:        string(action),
+		CorrectAction: string(correctAction),
+		Correct:       CheckAnswer(action, correctAction),
+		LatencyMs:     latency.Milliseconds(),
+		Rules:         chart.Rules().Name,
+	}
+	if !attempt.Correct {
+		attempt.EVLoss = losses.Loss(scenario.Hand, scenario.DealerCard, action)
+		attempt.Severity = stats.ClassifyLoss(attempt.EVLoss).String()
+	}
+	return attempt
+}
+
+// Options controls how a training session is run.
+type Options struct {
+	// TimeLimit ends the session once this much time has elapsed, instead of
+	// after the session's maximum number of questions. Zero means no limit.
+	TimeLimit time.Duration
+	// FixedLength keeps a session with a TimeLimit to the session's maximum
+	// number of questions too, ending at whichever comes first, as for
+	// quizzes with a set list of questions.
+	FixedLength bool
+	// Questions overrides the session's maximum number of questions when
+	// positive, except for sessions that ask a fixed list.
+	Questions int
+	// Goal ends the session once the player answers at least this percent
+	// of the questions correctly, over at least GoalQuestions answers, and
+	// announces it. The session then runs until the goal is met or the
+	// player quits, instead of for its maximum number of questions. Zero
+	// sets no goal; exams and fixed-length quizzes ignore it.
+	Goal int
+	// GoalQuestions is the fewest answers a Goal is judged over; zero
+	// means DefaultGoalQuestions.
+	GoalQuestions int
+	// Share prints a shareable summary card after the session.
+	Share bool
+	// Difficulty weights questions toward trivial or tricky chart cells.
+	Difficulty Difficulty
+	// AutoDifficulty moves an easy, normal or hard session up a difficulty
+	// when the player answers more than 95% of the last 10 questions
+	// correctly, and down when they answer fewer than 60%, announcing
+	// each change.
+	AutoDifficulty bool
+	// MaxRepeat limits how many consecutive questions may share the same
+	// correct action. Zero means no limit.
+	MaxRepeat int
+	// EventLog receives a detailed event for every answered question when set.
+	EventLog *eventlog.Logger
+	// Spectators, when set, are shown each question and answer live.
+	Spectators *spectate.Server
+	// Chart is the strategy chart answers are checked against. Nil means
+	// the shared chart for the standard rules, strategy.Default().
+	Chart *strategy.StrategyChart
+	// Seed makes the questions repeatable: the same seed and answers give
+	// the same session. Zero seeds from the clock.
+	Seed int64
+	// Source, when set, supplies the random numbers instead of Seed: a
+	// secure source for questions that can't be predicted (rng.Secure), or
+	// a fixed sequence for tests (rng.Ints).
+	Source rand.Source
+	// ReviewRate is the share of questions in random sessions, from 0 to 1,
+	// that refresh cells due for review: practiced before but not for weeks
+	// (see stats.Mastery.Due). Zero asks no review questions.
+	ReviewRate float64
+	// Skips is how many questions the player may skip per session with n.
+	// Skipped questions are recorded apart, neither right nor wrong, and
+	// don't count toward the session length. Zero allows none, and exams
+	// never allow skipping.
+	Skips int
+	// RepeatMisses asks a missed hand again 3-5 questions later, and once
+	// more near the end of the session, since re-testing a mistake soon
+	// fixes it far better than meeting it again next week. Quizzes and
+	// exams ask their own questions and are never repeated.
+	RepeatMisses bool
+	// DetailedFeedback adds the numbers behind the play to the feedback
+	// for every answer, such as the chance of busting if you hit a hard
+	// total.
+	DetailedFeedback bool
+	// Coach shows the odds before each answer in sessions dealt from a
+	// shoe: the chance of busting if you hit, the dealer's outcomes from
+	// the upcard and the EV of each legal action. Coached answers are
+	// marked as hinted, so the coach is a learning aid to turn off when
+	// testing yourself.
+	Coach bool
+	// ConfirmAbsolutes asks "Are you sure?" once when an answer in the
+	// absolutes drill breaks an always/never rule, before recording it, as a
+	// scaffold for beginners. It only applies at easy difficulty.
+	ConfirmAbsolutes bool
+	// Now returns the current time. Nil means time.Now; scripted runs use a
+	// fake clock so the timings they print are repeatable.
+	Now func() time.Time
+}
+
+// DefaultSkips is how many questions may be skipped per session unless
+// configured otherwise.
+const DefaultSkips = 3
+
+// DefaultGoalQuestions is the fewest answers a session goal is judged over
+// unless configured otherwise, so a lucky first few can't meet it.
+const DefaultGoalQuestions = 20
+
+// reaskDelay is how many questions later a hand taken back as a slip is
+// asked again, so its answer isn't fresh in mind.
+const reaskDelay = 3
+
+// A missed hand is asked again between repeatMin and repeatMax questions
+// later, while the mistake is fresh, and once more repeatEndGap questions
+// before the end of the session.
+const (
+	repeatMin    = 3
+	repeatMax    = 5
+	repeatEndGap = 2
+)
+
+// reask is a hand taken back as a slip, or missed, waiting to be asked
+// again.
+type reask struct {
+	scenario Scenario
+	due      int  // question count at which it is asked
+	missed   bool // asked again because it was missed
+}
+
+// scheduleReask adds a hand to the reasks, which are kept in the order due.
+func scheduleReask(reasks []reask, r reask) []reask {
+	i := len(reasks)
+	for i > 0 && reasks[i-1].due > r.due {
+		i--
+	}
+	reasks = append(reasks, reask{})
+	copy(reasks[i+1:], reasks[i:])
+	reasks[i] = r
+	return reasks
+}
+
+// describer is implemented by sessions that can describe what they drill,
+// for the help screen.
+type describer interface {
+	Description() string
+}
+
+// cellGenerator is implemented by sessions that can deal a hand for a given
+// chart cell, so review questions can be mixed in.
+type cellGenerator interface {
+	GenerateCell(handType strategy.HandType, playerTotal, dealerCard int) Scenario
+}
+
+// seeder is implemented by sessions whose questions can be made repeatable.
+type seeder interface {
+	Seed(seed int64)
+}
+
+// sourcer is implemented by sessions that can draw their questions from a
+// given source of random numbers.
+type sourcer interface {
+	SetSource(src rand.Source)
+}
+
+// SetSource makes session draw its questions from src, reporting whether
+// the session supports it. Sessions with a fixed order of questions have
+// no use for random numbers.
+func SetSource(session TrainingSession, src rand.Source) bool {
+	s, ok := session.(sourcer)
+	if ok {
+		s.SetSource(src)
+	}
+	return ok
+}
+
+// examSession is implemented by exams, whose answers can't be taken back
+// and whose questions aren't weighted by difficulty.
+type examSession interface {
+	IsExam() bool
+}
+
+// explainer is implemented by sessions that add to the chart's explanation
+// of the correct play, to teach what they drill.
+type explainer interface {
+	Explain(chart *strategy.StrategyChart, scenario Scenario, explanation string) string
+}
+
+// absoluteRuler is implemented by sessions that drill always/never rules,
+// so an answer breaking one can be questioned before it is recorded.
+type absoluteRuler interface {
+	BrokenRule(scenario Scenario, action, correctAction rune) (rule string, broken bool)
+}
+
+// fixedOrder is implemented by sessions whose questions must be asked in
+// the order generated, without the scheduler skipping any.
+type fixedOrder interface {
+	FixedOrder() bool
+}
+
+// shoeDealer is implemented by sessions that deal from a shoe, so odds can
+// be worked out from the cards left in it rather than an infinite deck.
+type shoeDealer interface {
+	RemainingCards() [12]int
+}
+
+// annotator is implemented by sessions that coach a pattern, pointing out
+// where a question fits in it before it is asked.
+type annotator interface {
+	Annotate(scenario Scenario) string
+}
+
+// RunSession runs the main training session loop. The session ends early,
+// keeping the questions answered so far, when ctx is cancelled; this is
+// checked before each question. Returns the session as recorded in the
+// history, or a zero Session if none was recorded.
+func RunSession(ctx context.Context, session TrainingSession, statistics *stats.Statistics, opts Options) history.Session {
 	ui.DisplaySessionHeader(session.GetModeName())
+	out := ui.Output()
+	now := opts.Now
+	if now == nil {
+		now = time.Now
+	}
+
+	strategyChart := opts.Chart
+	if strategyChart == nil {
+		strategyChart = strategy.Default()
+	}
+	if rules := strategyChart.Rules(); rules.Key != strategy.Standard.Key {
+		fmt.Fprintf(out, "Rules: %s (%s)\n", rules.Name, rules.Summary())
+		if warning := strategy.PayoutWarning(rules); warning != "" {
+			fmt.Fprintln(out, warning)
+		}
+	}
 
 	if !session.SetupSession() {
-		return // User cancelled setup
+		return history.Session{} // User cancelled setup
 	}
 
-	strategyChart := strategy.New()
-	var correctCount, totalCount, questionCount int
+	description := session.GetModeName()
+	if d, ok := session.(describer); ok {
+		description += " - " + d.Description()
+	}
+	rules := strategyChart.Rules()
+	ui.SetSessionHelp(description, fmt.Sprintf("%s (%s)", rules.Name, rules.Summary()))
+	defer ui.SetSessionHelp("", "")
+	ui.SetMnemonics(strategyChart.Mnemonics())
+	defer ui.SetMnemonics(nil)
+	defer ui.ClearStatus()
+
+	if opts.TimeLimit > 0 {
+		fmt.Fprintf(out, "Time limit: %s\n", stats.FormatDuration(opts.TimeLimit))
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.TimeLimit)
+		defer cancel()
+	}
+
+	difficulty := opts.Difficulty
+	e, isExam := session.(examSession)
+	isExam = isExam && e.IsExam()
+	f, fixed := session.(fixedOrder)
+	fixed = fixed && f.FixedOrder()
+	// Weighting by difficulty would skip questions of a fixed order
+	if difficulty == "" || isExam || fixed {
+		difficulty = DifficultyNormal
+	}
+
+	seed := opts.Seed
+	if opts.Source != nil {
+		SetSource(session, opts.Source)
+	} else if seed == 0 {
+		seed = time.Now().UnixNano()
+	} else if s, ok := session.(seeder); ok {
+		s.Seed(seed)
+	}
+	schedulerSource := opts.Source
+	if schedulerSource == nil {
+		schedulerSource = rand.NewSource(seed + 1)
+	}
+	maxRepeat, maxQuestions := opts.MaxRepeat, session.GetMaxQuestions()
+	if fixed {
+		maxRepeat = 0
+	} else if opts.Questions > 0 {
+		maxQuestions = opts.Questions
+	}
+	questions := newScheduler(session, difficulty, maxRepeat, strategyChart,
+		rand.New(schedulerSource))
+	var curve *difficultyCurve
+	if opts.AutoDifficulty && !isExam && !fixed {
+		curve, _ = newDifficultyCurve(difficulty)
+	}
+	if difficulty == DifficultyAdaptive || opts.ReviewRate > 0 {
+		questions.mastery = stats.ComputeMastery(statistics.History(), now())
+	}
+	if opts.ReviewRate > 0 {
+		questions.reviews, questions.reviewRate = questions.mastery.Due(now()), opts.ReviewRate
+	}
+	var correctCount, totalCount, questionCount, streak int
+	var evLoss float64
+	losses := simulate.NewLossTable(strategyChart, 0)
+	var attempts, corrected, skipped []history.Attempt
+	var reasks []reask
+	started := now()
 
-	for questionCount < session.GetMaxQuestions() {
-		handType, playerCards, playerTotal, dealerCard := session.GenerateScenario()
+	// confirmQuit asks a player quitting with answers to lose whether to
+	// stop and whether to record them. Returns false to keep practicing.
+	discard := false
+	confirmQuit := func() bool {
+		if totalCount == 0 {
+			return true
+		}
+		switch ui.ConfirmQuit(correctCount, totalCount) {
+		case ui.KeepPracticing:
+			return false
+		case ui.QuitWithoutRecording:
+			discard = true
+		}
+		return true
+	}
+
+	repeatMisses := opts.RepeatMisses && !isExam && !fixed
+
+	// skipsLeft is negative when the session can't be skipped through
+	skipsLeft := opts.Skips
+	if skipsLeft <= 0 || isExam {
+		skipsLeft = -1
+	}
+
+	goal, goalQuestions := opts.Goal, opts.GoalQuestions
+	if isExam || opts.FixedLength {
+		goal = 0
+	}
+	if goalQuestions <= 0 {
+		goalQuestions = DefaultGoalQuestions
+	}
+	if goal > 0 {
+		fmt.Fprintf(out, "Goal: %d%% correct over at least %d questions\n", goal, goalQuestions)
+	}
+
+	openEnded := (opts.TimeLimit > 0 || goal > 0) && !opts.FixedLength
+	begin := spectate.Event{Type: spectate.Started, Mode: description, Rules: rules.Name}
+	if !openEnded {
+		begin.Questions = maxQuestions
+	}
+	opts.Spectators.Publish(begin)
+	for openEnded || questionCount < maxQuestions {
+		if err := ctx.Err(); err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				fmt.Fprintln(out, "\nTime's up!")
+			} else {
+				fmt.Fprintln(out, "\nSession cancelled.")
+			}
+			break
+		}
+
+		status := ui.Status{
+			Question: questionCount + 1,
+			Correct:  correctCount,
+			Streak:   streak,
+			Mode:     session.GetModeName(),
+			Rules:    rules.Name,
+		}
+		if skipsLeft >= 0 {
+			status.Skips = ui.SkipsLeft(skipsLeft)
+		}
+		if opts.TimeLimit > 0 {
+			status.TimeLeft = stats.FormatDuration(opts.TimeLimit-now().Sub(started)) + " left"
+			ui.SetRemaining(status.TimeLeft)
+		}
+		if !openEnded {
+			status.Total = maxQuestions
+			remaining := fmt.Sprintf("%d of %d questions", status.Total-questionCount, status.Total)
+			if status.TimeLeft != "" {
+				remaining += ", " + status.TimeLeft
+			}
+			ui.SetRemaining(remaining)
+		}
+		if goal > 0 {
+			remaining := describeGoal(goal, goalQuestions, correctCount, totalCount)
+			if status.TimeLeft != "" {
+				remaining += ", " + status.TimeLeft
+			}
+			ui.SetRemaining(remaining)
+		}
+
+		// Hands taken back are asked once due, or sooner if the remaining
+		// questions are only enough for them
+		var scenario Scenario
+		var current reask
+		isReask := len(reasks) > 0 && (questionCount >= reasks[0].due ||
+			!openEnded && maxQuestions-questionCount <= len(reasks))
+		if isReask {
+			current, reasks = reasks[0], reasks[1:]
+			scenario = current.scenario
+		} else {
+			scenario = questions.next()
+		}
+
+		ui.DisplayStatus(status)
+		if questions.reviewing && !isReask {
+			handType, value := strategy.Classify(scenario.Hand)
+			cell := questions.mastery[stats.CellKey{HandType: handType, PlayerTotal: value, DealerCard: scenario.DealerCard}]
+			fmt.Fprintf(out, "\nReview: you last practiced this hand %s ago.\n", describeAge(now().Sub(cell.LastSeen)))
+		}
+		if current.missed {
+			fmt.Fprintln(out, "\nAgain: you missed this hand earlier in the session.")
+		}
+		if a, ok := session.(annotator); ok && !isReask {
+			if note := a.Annotate(scenario); note != "" {
+				fmt.Fprintf(out, "\n%s\n", note)
+			}
+		}
 
-		ui.DisplayHand(playerCards, dealerCard, handType, playerTotal)
+		ui.DisplayHand(scenario.Hand, scenario.DealerCard)
+		handType, value := strategy.Classify(scenario.Hand)
+		opts.Spectators.Publish(spectate.Event{Type: spectate.Question, Number: questionCount + 1,
+			Cards: scenario.Hand.Cards, DealerCard: scenario.DealerCard,
+			Label: stats.CellKey{HandType: handType, PlayerTotal: value, DealerCard: scenario.DealerCard}.Label(),
+			Score: correctCount, Answered: totalCount})
+		_, coached := session.(shoeDealer)
+		coached = coached && opts.Coach
+		if coached {
+			fmt.Fprint(out, coach(session, strategyChart, losses, scenario))
+		}
 
-		userAction, quit := ui.GetUserAction()
+		asked := now()
+		ui.TakeHint() // the list shown at the last feedback prompt doesn't count
+		userAction, skip, quit := ui.GetUserActionOrSkip(skipsLeft)
+		for quit && !confirmQuit() {
+			ui.DisplayHand(scenario.Hand, scenario.DealerCard)
+			userAction, skip, quit = ui.GetUserActionOrSkip(skipsLeft)
+		}
 		if quit {
 			break
 		}
+		latency := now().Sub(asked)
+
+		correctAction, explanation := correctPlay(strategyChart, scenario, questions.composition)
+		if skip {
+			// A skipped question is kept apart from the score and doesn't
+			// count toward the session length
+			skipsLeft--
+			opts.Spectators.Publish(spectate.Event{Type: spectate.Skipped, Number: questionCount + 1,
+				Score: correctCount, Answered: totalCount})
+			skipped = append(skipped, history.Attempt{
+				Cards:         scenario.Hand.Cards,
+				DealerCard:    scenario.DealerCard,
+				HandType:      handType.String(),
+				CorrectAction: string(correctAction),
+				LatencyMs:     latency.Milliseconds(),
+				Rules:         strategyChart.Rules().Name,
+			})
+			fmt.Fprintf(out, "Skipped (%s).\n", ui.SkipsLeft(skipsLeft))
+			continue
+		}
+		if r, ok := session.(absoluteRuler); ok && opts.ConfirmAbsolutes && difficulty == DifficultyEasy {
+			if rule, broken := r.BrokenRule(scenario, userAction, correctAction); broken && !ui.ConfirmAbsolute(rule) {
+				ui.DisplayHand(scenario.Hand, scenario.DealerCard)
+				userAction, quit = ui.GetUserAction()
+				for quit && !confirmQuit() {
+					ui.DisplayHand(scenario.Hand, scenario.DealerCard)
+					userAction, quit = ui.GetUserAction()
+				}
+				if quit {
+					break
+				}
+			}
+		}
+		hinted := ui.TakeHint() || coached
+		if note := strategyChart.DealerBustNote(explanation, scenario.DealerCard); note != "" {
+			explanation += "\n" + note
+		}
+		if e, ok := session.(explainer); ok {
+			explanation = e.Explain(strategyChart, scenario, explanation)
+		}
+		attempt := NewAttempt(strategyChart, losses, scenario, userAction, correctAction, latency)
+		correct, loss := attempt.Correct, attempt.EVLoss
+		mistake := ""
+		if !correct {
+			mistake = stats.ClassifyLoss(loss).Describe(loss)
+		}
 
-		correctAction := strategyChart.GetCorrectAction(handType, playerTotal, dealerCard)
-		correct := CheckAnswer(userAction, correctAction)
-		explanation := strategyChart.GetExplanation(handType, playerTotal, dealerCard)
+		var lesson *lessons.Lesson
+		if found, ok := lessons.ForHand(scenario.Hand, scenario.DealerCard); ok {
+			lesson = &found
+		}
+		if correct {
+			ui.PlayAnswerCue(true, streak+1)
+		} else {
+			ui.PlayAnswerCue(false, 0)
+		}
+		var details []string
+		if opts.DetailedFeedback {
+			if odds := hitOdds(session, scenario); odds != "" {
+				details = append(details, odds)
+			}
+		}
+		answer := spectate.Event{Type: spectate.Answer, Number: questionCount + 1, Action: string(userAction),
+			CorrectAction: string(correctAction), Correct: correct, Explanation: explanation,
+			Score: correctCount, Answered: totalCount + 1}
+		if correct {
+			answer.Score++
+		}
+		opts.Spectators.Publish(answer)
+		simulation := func() string {
+			return simulateActions(strategyChart, scenario, correctAction, userAction, now().UnixNano())
+		}
+		feedback := ui.DisplayFeedback(correct, userAction, correctAction, mistake, explanation, details, lesson, simulation, !isReask && !isExam)
+		slip := feedback.Corrected
+		if slip {
+			opts.Spectators.Publish(spectate.Event{Type: spectate.Corrected, Number: questionCount + 1,
+				Score: correctCount, Answered: totalCount})
+		}
 
-		quitRequested := ui.DisplayFeedback(correct, userAction, correctAction, explanation)
+		attempt.Tags = feedback.Tags
+		attempt.Rating = feedback.Rating
+		attempt.Hinted = hinted
+		attempt.Notes = feedback.Notes
 
-		// Record statistics
-		dealerStrength := statistics.GetDealerStrength(dealerCard)
-		statistics.RecordAttempt(handType, dealerStrength, correct)
+		// A slip is kept apart from the scored answers, and its question
+		// doesn't count toward the session length
+		number := questionCount + 1
+		if slip {
+			corrected = append(corrected, attempt)
+			reasks = scheduleReask(reasks, reask{scenario: scenario, due: questionCount + reaskDelay})
+		} else {
+			statistics.RecordAttempt(handType, statistics.GetDealerStrength(scenario.DealerCard), correct)
+			attempts = append(attempts, attempt)
+			questionCount++
+		}
 
-		questionCount++
+		err := opts.EventLog.Log(eventlog.Event{
+			Time:          now(),
+			SessionID:     eventlog.SessionID(session.GetModeName(), started),
+			SessionMode:   session.GetModeName(),
+			Question:      number,
+			Cards:         scenario.Hand.Cards,
+			DealerCard:    scenario.DealerCard,
+			HandType:      handType.String(),
+			PlayerValue:   value,
+			Tier:          strategyChart.GetTier(handType, value, scenario.DealerCard).String(),
+			Difficulty:    string(difficulty),
+			Action:        string(userAction),
+			CorrectAction: string(correctAction),
+			Correct:       correct,
+			LatencyMs:     latency.Milliseconds(),
+			LessonViewed:  feedback.LessonViewed,
+			Corrected:     slip,
+			Tags:          feedback.Tags,
+			Rating:        feedback.Rating,
+			Hinted:        hinted,
+		})
+		if err != nil {
+			fmt.Fprintf(out, "Warning: could not write event log: %v\n", err)
+		}
+		if slip {
+			continue
+		}
 
 		if correct {
 			correctCount++
+			streak++
+		} else {
+			streak = 0
+			evLoss += loss
 		}
 		totalCount++
+		if !correct && repeatMisses {
+			// A repeat missed again comes back soon, but only a first miss
+			// is also saved for the end of the session. The question just
+			// answered is already counted, so it is asked as question
+			// due+1.
+			due := questionCount - 1 + repeatMin + questions.rng.Intn(repeatMax-repeatMin+1)
+			reasks = scheduleReask(reasks, reask{scenario: scenario, due: due, missed: true})
+			if end := maxQuestions - repeatEndGap; !openEnded && !isReask && end > due {
+				reasks = scheduleReask(reasks, reask{scenario: scenario, due: end, missed: true})
+			}
+		}
+		if curve != nil {
+			if next, step := curve.record(correct); step != 0 {
+				announceDifficulty(next, step)
+				difficulty, questions.difficulty = next, next
+			}
+		}
+
+		// Checkpoint so the answers so far survive a crash; failures are
+		// logged by stats and the session is still saved when it ends.
+		statistics.CheckpointSession(sessionRecord(session, rules.Name, started, now(), correctCount, totalCount, attempts, corrected, skipped))
+
+		if goal > 0 && totalCount >= goalQuestions && correctCount*100 >= goal*totalCount {
+			fmt.Fprintf(out, "\nGoal reached! %d of %d correct (%.0f%%), meeting your %d%% goal.\n",
+				correctCount, totalCount, float64(correctCount)*100/float64(totalCount), goal)
+			break
+		}
+
+		questionsLeft := openEnded || questionCount < maxQuestions
+		if feedback.Quit && questionsLeft && confirmQuit() {
+			break
+		}
+	}
+
+	opts.Spectators.Publish(spectate.Event{Type: spectate.Ended, Score: correctCount, Answered: totalCount})
+
+	// Show session report card
+	if totalCount == 0 {
+		return history.Session{}
+	}
+	record := sessionRecord(session, rules.Name, started, now(), correctCount, totalCount, attempts, corrected, skipped)
+	record.EVLoss = &evLoss
+
+	fmt.Fprintln(out, "\nSession complete!")
+	stats.NewReportCard(record, statistics.History(), strategyChart).Display(out)
+	ui.OfferSessionChart(stats.SessionChart(record.Attempts, strategyChart))
+
+	if discard {
+		if err := statistics.DiscardSession(); err != nil {
+			fmt.Fprintf(out, "Warning: could not discard session checkpoint: %v\n", err)
+		}
+		fmt.Fprintln(out, "\nThis partial session was not recorded in your history.")
+		return history.Session{}
+	}
+	if err := statistics.RecordSession(record); err != nil {
+		fmt.Fprintf(out, "Warning: could not save session history: %v\n", err)
+	}
+
+	if opts.Share {
+		fmt.Fprintln(out, "\nShare your progress:")
+		fmt.Fprint(out, stats.ShareCard(record, statistics.History().DayStreak(now())))
+	}
+	return record
+}
+
+// announceDifficulty tells the player an auto-adjusting session stepped up
+// or down to a difficulty, and why.
+func announceDifficulty(to Difficulty, step int) {
+	if step > 0 {
+		fmt.Fprintf(ui.Output(), "\nDifficulty raised to %s: more than %.0f%% of your last %d answers were right.\n",
+			to, autoRaiseAccuracy*100, autoWindow)
+	} else {
+		fmt.Fprintf(ui.Output(), "\nDifficulty eased to %s: fewer than %.0f%% of your last %d answers were right.\n",
+			to, autoEaseAccuracy*100, autoWindow)
+	}
+}
 
-		if quitRequested {
+// hitOdds describes the chance of busting a hard total of 12 or more by
+// hitting, drawing from the session's shoe if it deals from one and from an
+// infinite deck otherwise. It returns "" for other hands.
+func hitOdds(session TrainingSession, scenario Scenario) string {
+	handType, total := strategy.Classify(scenario.Hand)
+	if handType != strategy.HandTypeHard || total < 12 {
+		return ""
+	}
+	if s, ok := session.(shoeDealer); ok {
+		counts := s.RemainingCards()
+		left := 0
+		for _, n := range counts {
+			left += n
+		}
+		return fmt.Sprintf("hitting hard %d busts ~%.0f%% of the time with the %d cards left in the shoe",
+			total, strategy.HitBustChanceFrom(total, counts)*100, left)
+	}
+	return fmt.Sprintf("hitting hard %d busts ~%.0f%% of the time (infinite deck)", total, strategy.HitBustChance(total)*100)
+}
+
+// coach describes the odds of a scenario before it is answered: the chance
+// of busting if you hit, the dealer's outcomes from the upcard and the EV of
+// each legal action, best first.
+func coach(session TrainingSession, chart *strategy.StrategyChart, losses *simulate.LossTable, scenario Scenario) string {
+	var b strings.Builder
+	fmt.Fprintln(&b, "\nCoach:")
+	if odds := hitOdds(session, scenario); odds != "" {
+		fmt.Fprintf(&b, "  If you hit: %s\n", odds)
+	}
+
+	dealer := strategy.DealerOutcomeFor(chart.Rules(), scenario.DealerCard)
+	fmt.Fprintf(&b, "  Dealer %s:", strategy.CardToString(scenario.DealerCard))
+	for i, p := range dealer.Totals {
+		fmt.Fprintf(&b, " %d %.0f%%,", 17+i, p*100)
+	}
+	if dealer.Blackjack > 0 {
+		fmt.Fprintf(&b, " blackjack %.0f%%,", dealer.Blackjack*100)
+	}
+	fmt.Fprintf(&b, " bust %.0f%%\n", dealer.Bust*100)
+
+	type actionEV struct {
+		action rune
+		ev     float64
+	}
+	var evs []actionEV
+	for _, action := range []rune{'H', 'S', 'D', 'Y'} {
+		if ev, ok := losses.EV(scenario.Hand, scenario.DealerCard, action); ok {
+			evs = append(evs, actionEV{action, ev})
+		}
+	}
+	sort.SliceStable(evs, func(i, j int) bool { return evs[i].ev > evs[j].ev })
+	parts := make([]string, len(evs))
+	for i, e := range evs {
+		parts[i] = fmt.Sprintf("%s %+.2f", strategy.ActionToString(e.action), e.ev)
+	}
+	fmt.Fprintf(&b, "  EV per bet: %s\n", strings.Join(parts, ", "))
+	return b.String()
+}
+
+// describeGoal describes the progress toward a session goal, for help.
+func describeGoal(goal, goalQuestions, correct, total int) string {
+	progress := fmt.Sprintf("goal %d%% over %d questions", goal, goalQuestions)
+	if total == 0 {
+		return progress
+	}
+	return fmt.Sprintf("%s: %.0f%% over %d so far", progress, float64(correct)*100/float64(total), total)
+}
+
+// describeAge describes how long ago a cell was practiced, in days or weeks.
+func describeAge(d time.Duration) string {
+	days := int(d / (24 * time.Hour))
+	if days < 14 {
+		return fmt.Sprintf("%d days", days)
+	}
+	return fmt.Sprintf("%d weeks", days/7)
+}
+
+// simulateActions simulates the scenario under the correct action and the
+// user's action, formatted for display.
+func simulateActions(chart *strategy.StrategyChart, scenario Scenario, correctAction, userAction rune, seed int64) string {
+	opts := simulate.Options{Seed: seed}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d rounds each of %s vs %s:\n", simulate.DefaultRounds, scenario.Hand, strategy.CardToString(scenario.DealerCard))
+	for _, action := range []rune{correctAction, userAction} {
+		result, err := simulate.RunParallel(context.Background(), chart, scenario.Hand, scenario.DealerCard, action, opts)
+		if err != nil {
+			fmt.Fprintf(&b, "  %s: %v\n", strategy.ActionToString(action), err)
+		} else {
+			fmt.Fprintf(&b, "  %s\n", result)
+		}
+		if userAction == correctAction || userAction == 'P' && correctAction == 'Y' {
 			break
 		}
 	}
+	return b.String()
+}
 
-	// Show session summary
-	if totalCount > 0 {
-		accuracy := (float64(correctCount) / float64(totalCount)) * 100.0
-		fmt.Printf("\nSession complete! Final score: %d/%d (%.1f%%)\n",
-			correctCount, totalCount, accuracy)
+// sessionRecord returns the history record of a session.
+func sessionRecord(session TrainingSession, rules string, started, ended time.Time, correct, total int, attempts, corrected, skipped []history.Attempt) history.Session {
+	return history.Session{
+		Mode:      session.GetModeName(),
+		Rules:     rules,
+		Started:   started,
+		Ended:     ended,
+		Correct:   correct,
+		Total:     total,
+		Attempts:  attempts,
+		Corrected: corrected,
+		Skipped:   skipped,
+	}
+}
+
+// SessionTypes lists the session types accepted by NewSession.
+func SessionTypes() []string {
+	return []string{"random", "dealer", "hand", "absolute", "realistic", "composition", "peek", "ladder", "exam"}
+}
+
+// NewSession creates a training session of the given type, or returns nil
+// for an unknown type. Random practice of a variant deals the decisions its
+// game favors.
+func NewSession(sessionType string, game strategy.Game) TrainingSession {
+	switch sessionType {
+	case "random":
+		if game.Key() != "classic" {
+			return NewGameTrainingSession(game)
+		}
+		return NewRandomTrainingSession()
+	case "dealer":
+		return NewDealerGroupTrainingSession()
+	case "hand":
+		return NewHandTypeTrainingSession()
+	case "absolute":
+		return NewAbsoluteTrainingSession()
+	case "realistic":
+		return NewRealisticTrainingSession()
+	case "composition":
+		return NewCompositionTrainingSession(game.Chart())
+	case "peek":
+		return NewPeekTrainingSession(game.Chart().Rules())
+	case "ladder":
+		return NewSoftLadderTrainingSession(game.Chart())
+	case "exam":
+		return NewExamTrainingSession()
+	default:
+		return nil
+	}
+}
+
+// DrillNames lists the drills a practice plan can name: the session types,
+// plus hand types and dealer groups chosen in advance.
+func DrillNames() []string {
+	return append(SessionTypes(), "hard", "soft", "pairs", "weak", "medium", "strong")
+}
+
+// NewDrill creates the session for a drill named in a practice plan, or
+// returns nil for an unknown drill. Hand type and dealer group drills skip
+// the setup menu.
+func NewDrill(drill string, game strategy.Game) TrainingSession {
+	switch drill {
+	case "hard", "soft", "pairs":
+		session := NewHandTypeTrainingSession()
+		session.handTypeChoice = map[string]int{"hard": 1, "soft": 2, "pairs": 3}[drill]
+		return session
+	case "weak", "medium", "strong":
+		session := NewDealerGroupTrainingSession()
+		session.dealerGroup = map[string]int{"weak": 1, "medium": 2, "strong": 3}[drill]
+		return session
+	default:
+		return NewSession(drill, game)
 	}
 }
 
@@ -175,6 +1034,11 @@ func (r *RandomTrainingSession) GetModeName() string {
 	return "random"
 }
 
+// Description describes the mode for the help screen.
+func (r *RandomTrainingSession) Description() string {
+	return "mixed practice with all hand types and dealer cards"
+}
+
 // GetMaxQuestions returns the maximum number of questions.
 func (r *RandomTrainingSession) GetMaxQuestions() int {
 	return 50
@@ -186,30 +1050,13 @@ func (r *RandomTrainingSession) SetupSession() bool {
 }
 
 // GenerateScenario generates a random scenario.
-func (r *RandomTrainingSession) GenerateScenario() (strategy.HandType, []int, int, int) {
-	dealerCard := r.rng.Intn(10) + 2 // 2-11
-	handTypes := []strategy.HandType{strategy.HandTypeHard, strategy.HandTypeSoft, strategy.HandTypePair}
-	handType := handTypes[r.rng.Intn(len(handTypes))]
-
-	var playerCards []int
-	var playerTotal int
-
-	switch handType {
-	case strategy.HandTypePair:
-		pairValues := []int{2, 3, 4, 5, 6, 7, 8, 9, 10, 11}
-		pairValue := pairValues[r.rng.Intn(len(pairValues))]
-		playerCards = []int{pairValue, pairValue}
-		playerTotal = pairValue
-	case strategy.HandTypeSoft:
-		otherCard := r.rng.Intn(8) + 2 // 2-9
-		playerCards = []int{11, otherCard}
-		playerTotal = 11 + otherCard
-	case strategy.HandTypeHard:
-		playerTotal = r.rng.Intn(16) + 5 // 5-20
-		playerCards = r.GenerateHandCards(strategy.HandTypeHard, playerTotal)
-	}
+func (r *RandomTrainingSession) GenerateScenario() Scenario {
+	return r.generate(Constraints{})
+}
 
-	return handType, playerCards, playerTotal, dealerCard
+// GenerateCell deals a hand for a chart cell, for review questions.
+func (r *RandomTrainingSession) GenerateCell(handType strategy.HandType, playerTotal, dealerCard int) Scenario {
+	return r.generateCell(handType, playerTotal, dealerCard)
 }
 
 // DealerGroupTrainingSession focuses on specific dealer strength groups.
@@ -231,13 +1078,29 @@ func (d *DealerGroupTrainingSession) GetModeName() string {
 	return "dealer_groups"
 }
 
+// Description describes the chosen dealer group for the help screen.
+func (d *DealerGroupTrainingSession) Description() string {
+	switch d.dealerGroup {
+	case 1:
+		return "every hand against weak dealer cards (4, 5, 6)"
+	case 2:
+		return "every hand against medium dealer cards (2, 3, 7, 8)"
+	default:
+		return "every hand against strong dealer cards (9, 10, A)"
+	}
+}
+
 // GetMaxQuestions returns the maximum number of questions.
 func (d *DealerGroupTrainingSession) GetMaxQuestions() int {
 	return 50
 }
 
-// SetupSession sets up the session by asking user to choose dealer group.
+// SetupSession sets up the session by asking user to choose dealer group,
+// unless a drill chose it already.
 func (d *DealerGroupTrainingSession) SetupSession() bool {
+	if d.dealerGroup != 0 {
+		return true
+	}
 	choice, ok := ui.DisplayDealerGroups()
 	if !ok {
 		return false
@@ -247,43 +1110,17 @@ func (d *DealerGroupTrainingSession) SetupSession() bool {
 }
 
 // GenerateScenario generates a scenario with specific dealer group.
-func (d *DealerGroupTrainingSession) GenerateScenario() (strategy.HandType, []int, int, int) {
-	// Select dealer card based on chosen group
-	var dealerCard int
+func (d *DealerGroupTrainingSession) GenerateScenario() Scenario {
+	var dealerCards []int
 	switch d.dealerGroup {
 	case 1: // Weak
-		weakCards := []int{4, 5, 6}
-		dealerCard = weakCards[d.rng.Intn(len(weakCards))]
+		dealerCards = []int{4, 5, 6}
 	case 2: // Medium
-		mediumCards := []int{2, 3, 7, 8}
-		dealerCard = mediumCards[d.rng.Intn(len(mediumCards))]
+		dealerCards = []int{2, 3, 7, 8}
 	default: // Strong
-		strongCards := []int{9, 10, 11}
-		dealerCard = strongCards[d.rng.Intn(len(strongCards))]
-	}
-
-	handTypes := []strategy.HandType{strategy.HandTypeHard, strategy.HandTypeSoft, strategy.HandTypePair}
-	handType := handTypes[d.rng.Intn(len(handTypes))]
-
-	var playerCards []int
-	var playerTotal int
-
-	switch handType {
-	case strategy.HandTypePair:
-		pairValues := []int{2, 3, 4, 5, 6, 7, 8, 9, 10, 11}
-		pairValue := pairValues[d.rng.Intn(len(pairValues))]
-		playerCards = []int{pairValue, pairValue}
-		playerTotal = pairValue
-	case strategy.HandTypeSoft:
-		otherCard := d.rng.Intn(8) + 2 // 2-9
-		playerCards = []int{11, otherCard}
-		playerTotal = 11 + otherCard
-	case strategy.HandTypeHard:
-		playerTotal = d.rng.Intn(16) + 5 // 5-20
-		playerCards = d.GenerateHandCards(strategy.HandTypeHard, playerTotal)
+		dealerCards = []int{9, 10, 11}
 	}
-
-	return handType, playerCards, playerTotal, dealerCard
+	return d.generate(Constraints{DealerCards: dealerCards})
 }
 
 // HandTypeTrainingSession focuses on specific hand types.
@@ -305,13 +1142,29 @@ func (h *HandTypeTrainingSession) GetModeName() string {
 	return "hand_types"
 }
 
+// Description describes the chosen hand type for the help screen.
+func (h *HandTypeTrainingSession) Description() string {
+	switch h.handTypeChoice {
+	case 1:
+		return "hard totals (no ace, or an ace counting 1) against every dealer card"
+	case 2:
+		return "soft totals (an ace counting 11) against every dealer card"
+	default:
+		return "pairs against every dealer card"
+	}
+}
+
 // GetMaxQuestions returns the maximum number of questions.
 func (h *HandTypeTrainingSession) GetMaxQuestions() int {
 	return 50
 }
 
-// SetupSession sets up the session by asking user to choose hand type.
+// SetupSession sets up the session by asking user to choose hand type,
+// unless a drill chose it already.
 func (h *HandTypeTrainingSession) SetupSession() bool {
+	if h.handTypeChoice != 0 {
+		return true
+	}
 	choice, ok := ui.DisplayHandTypes()
 	if !ok {
 		return false
@@ -321,32 +1174,17 @@ func (h *HandTypeTrainingSession) SetupSession() bool {
 }
 
 // GenerateScenario generates a scenario with specific hand type.
-func (h *HandTypeTrainingSession) GenerateScenario() (strategy.HandType, []int, int, int) {
-	dealerCard := h.rng.Intn(10) + 2 // 2-11
-
+func (h *HandTypeTrainingSession) GenerateScenario() Scenario {
 	var handType strategy.HandType
-	var playerCards []int
-	var playerTotal int
-
 	switch h.handTypeChoice {
 	case 1: // Hard totals
 		handType = strategy.HandTypeHard
-		playerTotal = h.rng.Intn(16) + 5 // 5-20
-		playerCards = h.GenerateHandCards(strategy.HandTypeHard, playerTotal)
 	case 2: // Soft totals
 		handType = strategy.HandTypeSoft
-		otherCard := h.rng.Intn(8) + 2 // 2-9
-		playerCards = []int{11, otherCard}
-		playerTotal = 11 + otherCard
 	default: // Pairs
 		handType = strategy.HandTypePair
-		pairValues := []int{2, 3, 4, 5, 6, 7, 8, 9, 10, 11}
-		pairValue := pairValues[h.rng.Intn(len(pairValues))]
-		playerCards = []int{pairValue, pairValue}
-		playerTotal = pairValue
 	}
-
-	return handType, playerCards, playerTotal, dealerCard
+	return h.generate(Constraints{HandTypes: []strategy.HandType{handType}})
 }
 
 // AbsoluteTrainingSession focuses on absolute rules (always/never scenarios).
@@ -366,6 +1204,11 @@ func (a *AbsoluteTrainingSession) GetModeName() string {
 	return "absolutes"
 }
 
+// Description describes the mode for the help screen.
+func (a *AbsoluteTrainingSession) Description() string {
+	return "the always/never plays: always split A,A and 8,8, never split 10,10 or 5,5, always stand on hard 17+ and soft 19+"
+}
+
 // GetMaxQuestions returns the maximum number of questions.
 func (a *AbsoluteTrainingSession) GetMaxQuestions() int {
 	return 20
@@ -376,34 +1219,523 @@ func (a *AbsoluteTrainingSession) SetupSession() bool {
 	return true
 }
 
-// GenerateScenario generates a scenario with absolute rules.
-func (a *AbsoluteTrainingSession) GenerateScenario() (strategy.HandType, []int, int, int) {
-	absolutes := []struct {
-		handType    strategy.HandType
-		playerCards []int
-		playerTotal int
-	}{
-		{strategy.HandTypePair, []int{11, 11}, 11}, // A,A
-		{strategy.HandTypePair, []int{8, 8}, 8},    // 8,8
-		{strategy.HandTypePair, []int{10, 10}, 10}, // 10,10
-		{strategy.HandTypePair, []int{5, 5}, 5},    // 5,5
-		{strategy.HandTypeHard, []int{}, 17},       // Hard 17
-		{strategy.HandTypeHard, []int{}, 18},       // Hard 18
-		{strategy.HandTypeHard, []int{}, 19},       // Hard 19
-		{strategy.HandTypeHard, []int{}, 20},       // Hard 20
-		{strategy.HandTypeSoft, []int{11, 8}, 19},  // Soft 19
-		{strategy.HandTypeSoft, []int{11, 9}, 20},  // Soft 20
-	}
+// absolutes are the hands the absolutes drill deals.
+var absolutes = []struct {
+	playerCards []int // Fixed cards for pairs and soft hands
+	hardTotal   int   // Total of a generated hard hand when no cards are fixed
+}{
+	{[]int{11, 11}, 0}, // A,A
+	{[]int{8, 8}, 0},   // 8,8
+	{[]int{10, 10}, 0}, // 10,10
+	{[]int{5, 5}, 0},   // 5,5
+	{nil, 17},          // Hard 17
+	{nil, 18},          // Hard 18
+	{nil, 19},          // Hard 19
+	{nil, 20},          // Hard 20
+	{[]int{11, 8}, 0},  // Soft 19
+	{[]int{11, 9}, 0},  // Soft 20
+}
 
+// GenerateScenario generates a scenario with absolute rules.
+func (a *AbsoluteTrainingSession) GenerateScenario() Scenario {
 	absolute := absolutes[a.rng.Intn(len(absolutes))]
 	dealerCard := a.rng.Intn(10) + 2 // 2-11
 
-	playerCards := absolute.playerCards
-	if len(playerCards) == 0 { // Hard totals
-		playerCards = a.GenerateHandCards(absolute.handType, absolute.playerTotal)
+	if absolute.playerCards == nil {
+		return Scenario{Hand: a.GenerateHardHand(absolute.hardTotal), DealerCard: dealerCard}
+	}
+	return Scenario{Hand: hand.New(absolute.playerCards...), DealerCard: dealerCard}
+}
+
+// BrokenRule returns the always/never rule an answer breaks, e.g. "always
+// split A,A". An always rule only counts where the chart agrees with it,
+// since some table rules make exceptions (soft 19 doubles against a 6 when
+// the dealer hits soft 17).
+func (a *AbsoluteTrainingSession) BrokenRule(scenario Scenario, action, correctAction rune) (string, bool) {
+	if action == 'P' {
+		action = 'Y'
+	}
+	handType, value := strategy.Classify(scenario.Hand)
+	never := func(rule string, forbidden rune) (string, bool) {
+		return rule, action == forbidden
+	}
+	always := func(rule string, required rune) (string, bool) {
+		return rule, action != required && correctAction == required
+	}
+	switch {
+	case handType == strategy.HandTypePair && value == hand.Ace:
+		return always("always split A,A", 'Y')
+	case handType == strategy.HandTypePair && value == 8:
+		return always("always split 8,8", 'Y')
+	case handType == strategy.HandTypePair && value == 10:
+		return never("never split 10,10", 'Y')
+	case handType == strategy.HandTypePair && value == 5:
+		return never("never split 5,5", 'Y')
+	case handType == strategy.HandTypeHard && value >= 17:
+		return always("always stand on hard 17 or more", 'S')
+	case handType == strategy.HandTypeSoft && value >= 19:
+		return always("always stand on soft 19 or more", 'S')
+	}
+	return "", false
+}
+
+// RealisticTrainingSession deals scenarios from a shoe so hands appear at the
+// frequencies seen at a real table.
+type RealisticTrainingSession struct {
+	shoe *deck.Shoe
+}
+
+// NewRealisticTrainingSession creates a new realistic training session using a six-deck shoe.
+func NewRealisticTrainingSession() *RealisticTrainingSession {
+	return &RealisticTrainingSession{
+		shoe: deck.NewShoe(6, deck.DefaultPenetration, time.Now().UnixNano()),
+	}
+}
+
+// Seed replaces the shoe with a fresh one shuffled from a seed.
+func (r *RealisticTrainingSession) Seed(seed int64) {
+	r.shoe = deck.NewShoe(6, deck.DefaultPenetration, seed)
+}
+
+// SetSource replaces the shoe with a fresh one shuffled with numbers from src.
+func (r *RealisticTrainingSession) SetSource(src rand.Source) {
+	r.shoe = deck.NewShoeFromSource(6, deck.DefaultPenetration, src)
+}
+
+// RemainingCards returns the cards left in the shoe by value.
+func (r *RealisticTrainingSession) RemainingCards() [12]int {
+	return r.shoe.Counts()
+}
+
+// GetModeName returns the mode name.
+func (r *RealisticTrainingSession) GetModeName() string {
+	return "realistic"
+}
+
+// Description describes the mode for the help screen.
+func (r *RealisticTrainingSession) Description() string {
+	return "hands dealt from a six-deck shoe at real-game frequencies"
+}
+
+// GetMaxQuestions returns the maximum number of questions.
+func (r *RealisticTrainingSession) GetMaxQuestions() int {
+	return 50
+}
+
+// SetupSession sets up the session (no additional setup needed).
+func (r *RealisticTrainingSession) SetupSession() bool {
+	return true
+}
+
+// GenerateScenario deals two player cards and a dealer upcard from the shoe.
+// Player blackjacks are skipped since they require no decision.
+func (r *RealisticTrainingSession) GenerateScenario() Scenario {
+	for {
+		if r.shoe.NeedsShuffle() {
+			r.shoe.Shuffle()
+		}
+
+		playerHand := hand.New(r.shoe.Deal(), r.shoe.Deal())
+		dealerCard := r.shoe.Deal()
+
+		if playerHand.Total() != 21 {
+			return Scenario{Hand: playerHand, DealerCard: dealerCard}
+		}
+	}
+}
+
+// CompositionTrainingSession drills composition-dependent exceptions: hard
+// totals where the exact cards change the chart's play, such as a multi-card
+// 16 vs 10. Each exception is asked with both matching hands and ordinary
+// hands of the same total, so the cards must be read, not just the total.
+type CompositionTrainingSession struct {
+	*BaseTrainer
+	exceptions []strategy.Exception
+}
+
+// NewCompositionTrainingSession creates a composition-dependent drill for
+// the exceptions that apply to the chart's rules.
+func NewCompositionTrainingSession(chart *strategy.StrategyChart) *CompositionTrainingSession {
+	return &CompositionTrainingSession{
+		BaseTrainer: NewBaseTrainer(),
+		exceptions:  chart.Exceptions(),
+	}
+}
+
+// GetModeName returns the mode name.
+func (c *CompositionTrainingSession) GetModeName() string {
+	return "composition"
+}
+
+// Description describes the mode for the help screen.
+func (c *CompositionTrainingSession) Description() string {
+	return "hard totals where the exact cards, not just the total, change the play"
+}
+
+// GetMaxQuestions returns the maximum number of questions.
+func (c *CompositionTrainingSession) GetMaxQuestions() int {
+	return 20
+}
+
+// SetupSession lists the exceptions being drilled.
+func (c *CompositionTrainingSession) SetupSession() bool {
+	if len(c.exceptions) == 0 {
+		fmt.Fprintln(ui.Output(), "No composition-dependent exceptions apply to these rules.")
+		return false
+	}
+	fmt.Fprintln(ui.Output(), "Composition-dependent exceptions (the cards, not just the total, decide):")
+	for _, e := range c.exceptions {
+		fmt.Fprintf(ui.Output(), "  - %s: %s\n", e.Name, strategy.ActionToString(e.Action))
+	}
+	return true
+}
+
+// CompositionDependent reports that answers depend on the cards in each hand.
+func (c *CompositionTrainingSession) CompositionDependent() bool {
+	return true
+}
+
+// GenerateScenario picks an exception and deals, with equal chance, a hand
+// it applies to or an ordinary two-card hand of the same total.
+func (c *CompositionTrainingSession) GenerateScenario() Scenario {
+	e := c.exceptions[c.rng.Intn(len(c.exceptions))]
+	dealerCard := e.DealerCards[c.rng.Intn(len(e.DealerCards))]
+
+	if c.rng.Intn(2) == 0 {
+		for {
+			if h := c.generateMultiCardHand(e.Total); e.Matches(h) {
+				return Scenario{Hand: h, DealerCard: dealerCard}
+			}
+		}
+	}
+	for {
+		if h := c.GenerateHardHand(e.Total); !e.Matches(h) {
+			return Scenario{Hand: h, DealerCard: dealerCard}
+		}
+	}
+}
+
+// generateMultiCardHand returns a hard hand of two to four cards (2-10)
+// with the given total, which must be at least 6.
+func (c *CompositionTrainingSession) generateMultiCardHand(total int) hand.Hand {
+	for {
+		count := c.rng.Intn(3) + 2
+		cards := make([]int, 0, count)
+		remaining := total
+		for i := 0; i < count-1; i++ {
+			card := c.rng.Intn(9) + 2
+			cards = append(cards, card)
+			remaining -= card
+		}
+		if remaining < 2 || remaining > 10 {
+			continue
+		}
+		h := hand.New(append(cards, remaining)...)
+		if !h.IsPair() {
+			return h
+		}
+	}
+}
+
+// peekContrasts are doubles and splits against a 9, 10 or ace that are
+// played the same whether or not the dealer peeks, asked between the plays
+// that change so the rule isn't overapplied.
+var peekContrasts = []strategy.Difference{
+	{HandType: strategy.HandTypeHard, PlayerTotal: 11, DealerCard: 9},
+	{HandType: strategy.HandTypeHard, PlayerTotal: 10, DealerCard: 10},
+	{HandType: strategy.HandTypeHard, PlayerTotal: 10, DealerCard: 11},
+	{HandType: strategy.HandTypePair, PlayerTotal: 8, DealerCard: 9},
+	{HandType: strategy.HandTypePair, PlayerTotal: 11, DealerCard: 10},
+}
+
+// PeekTrainingSession drills the plays that depend on whether the dealer
+// peeks for blackjack: doubles and splits against a 10 or ace, which a
+// dealer without a hole card can still beat with a blackjack after the
+// money is out.
+type PeekTrainingSession struct {
+	*BaseTrainer
+	rules       strategy.RuleSet
+	differences []strategy.Difference
+}
+
+// NewPeekTrainingSession creates a peek rule drill for the rules.
+func NewPeekTrainingSession(rules strategy.RuleSet) *PeekTrainingSession {
+	return &PeekTrainingSession{
+		BaseTrainer: NewBaseTrainer(),
+		rules:       rules,
+		differences: strategy.PeekDifferences(rules),
+	}
+}
+
+// GetModeName returns the mode name.
+func (p *PeekTrainingSession) GetModeName() string {
+	return "peek"
+}
+
+// Description describes the mode for the help screen.
+func (p *PeekTrainingSession) Description() string {
+	return "doubles and splits against a 10 or ace, which depend on whether the dealer peeks for blackjack"
+}
+
+// GetMaxQuestions returns the maximum number of questions.
+func (p *PeekTrainingSession) GetMaxQuestions() int {
+	return 12
+}
+
+// SetupSession lists the plays that depend on the peek rule.
+func (p *PeekTrainingSession) SetupSession() bool {
+	out := ui.Output()
+	if len(p.differences) == 0 {
+		fmt.Fprintln(out, "No plays depend on the peek rule under these rules.")
+		return false
+	}
+	if p.rules.HoleCard {
+		fmt.Fprintln(out, "At this table the dealer checks for blackjack before you play.")
+	} else {
+		fmt.Fprintln(out, "At this table the dealer takes no hole card, so a blackjack can still")
+		fmt.Fprintln(out, "come after you have doubled or split, and takes that money too.")
+	}
+	fmt.Fprintln(out, "Plays that depend on it:")
+	for _, d := range p.differences {
+		fmt.Fprintf(out, "  - %s: %s with a hole card, %s without\n", strategy.CellLabel(d.HandType, d.PlayerTotal, d.DealerCard),
+			strategy.ActionToString(d.From), strategy.ActionToString(d.To))
+	}
+	return true
+}
+
+// GenerateScenario asks a play that depends on the peek rule two times in
+// three, and otherwise a nearby play that doesn't.
+func (p *PeekTrainingSession) GenerateScenario() Scenario {
+	cells := p.differences
+	if p.rng.Intn(3) == 0 {
+		cells = peekContrasts
+	}
+	d := cells[p.rng.Intn(len(cells))]
+	return p.generateCell(d.HandType, d.PlayerTotal, d.DealerCard)
+}
+
+// Explain adds how the play would change under the other peek rule.
+func (p *PeekTrainingSession) Explain(chart *strategy.StrategyChart, scenario Scenario, explanation string) string {
+	handType, value := strategy.Classify(scenario.Hand)
+	if contrast := strategy.PeekContrast(chart, handType, value, scenario.DealerCard); contrast != "" {
+		return explanation + "\n" + contrast
+	}
+	return explanation
+}
+
+// GameTrainingSession practices a blackjack variant, asking the decisions
+// its game deals.
+type GameTrainingSession struct {
+	*BaseTrainer
+	game strategy.Game
+}
+
+// NewGameTrainingSession creates a training session for a game.
+func NewGameTrainingSession(game strategy.Game) *GameTrainingSession {
+	return &GameTrainingSession{
+		BaseTrainer: NewBaseTrainer(),
+		game:        game,
+	}
+}
+
+// GetModeName returns the game's key.
+func (g *GameTrainingSession) GetModeName() string {
+	return g.game.Key()
+}
+
+// Description describes the game for the help screen.
+func (g *GameTrainingSession) Description() string {
+	return g.game.Description()
+}
+
+// GetMaxQuestions returns the maximum number of questions.
+func (g *GameTrainingSession) GetMaxQuestions() int {
+	return 50
+}
+
+// SetupSession describes the game being practiced.
+func (g *GameTrainingSession) SetupSession() bool {
+	fmt.Fprintln(ui.Output(), g.game.Description())
+	return true
+}
+
+// GenerateScenario deals a decision from the game.
+func (g *GameTrainingSession) GenerateScenario() Scenario {
+	playerHand, dealerCard := g.game.Deal(g.rng)
+	return Scenario{Hand: playerHand, DealerCard: dealerCard}
+}
+
+// TaggedTrainingSession drills the hands the player tagged with a name,
+// such as "confusing", at the feedback prompt of earlier sessions.
+type TaggedTrainingSession struct {
+	*BaseTrainer
+	tag       string
+	scenarios []Scenario
+}
+
+// NewTaggedTrainingSession creates a drill of the hands in attempts, the
+// hands tagged with tag as returned by history.Tagged.
+func NewTaggedTrainingSession(tag string, attempts []history.Attempt) *TaggedTrainingSession {
+	scenarios := make([]Scenario, len(attempts))
+	for i, a := range attempts {
+		scenarios[i] = Scenario{Hand: hand.New(a.Cards...), DealerCard: a.DealerCard}
+	}
+	return &TaggedTrainingSession{
+		BaseTrainer: NewBaseTrainer(),
+		tag:         tag,
+		scenarios:   scenarios,
+	}
+}
+
+// GetModeName returns the mode name, which includes the tag.
+func (t *TaggedTrainingSession) GetModeName() string {
+	return "tagged:" + t.tag
+}
+
+// Description describes the mode for the help screen.
+func (t *TaggedTrainingSession) Description() string {
+	return fmt.Sprintf("the %d hand(s) you tagged %q", len(t.scenarios), t.tag)
+}
+
+// GetMaxQuestions returns the maximum number of questions: each tagged hand
+// about twice, up to 20.
+func (t *TaggedTrainingSession) GetMaxQuestions() int {
+	return min(2*len(t.scenarios), 20)
+}
+
+// SetupSession checks that there are hands to drill.
+func (t *TaggedTrainingSession) SetupSession() bool {
+	if len(t.scenarios) == 0 {
+		fmt.Fprintf(ui.Output(), "No hands are tagged %q yet. Tag a hand after answering it with: t %s\n", t.tag, t.tag)
+		return false
+	}
+	fmt.Fprintf(ui.Output(), "Drilling %d hand(s) tagged %q.\n", len(t.scenarios), t.tag)
+	return true
+}
+
+// CompositionDependent reports whether any tagged hand has more than two
+// cards, such as those from the composition drill, whose play can depend on
+// the cards rather than just the total.
+func (t *TaggedTrainingSession) CompositionDependent() bool {
+	for _, s := range t.scenarios {
+		if len(s.Hand.Cards) > 2 {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateScenario picks a tagged hand.
+func (t *TaggedTrainingSession) GenerateScenario() Scenario {
+	return t.scenarios[t.rng.Intn(len(t.scenarios))]
+}
+
+// ExamQuestions is the number of questions in an exam.
+const ExamQuestions = 50
+
+// ExamTrainingSession is a full-chart exam: questions drawn evenly from
+// every cell of the chart, whatever the difficulty, with answers that can't
+// be taken back as slips. Package exam stores the results.
+type ExamTrainingSession struct {
+	*BaseTrainer
+	cells []stats.CellKey
+}
+
+// NewExamTrainingSession creates an exam.
+func NewExamTrainingSession() *ExamTrainingSession {
+	return &ExamTrainingSession{
+		BaseTrainer: NewBaseTrainer(),
+		cells:       stats.ChartCells(),
 	}
+}
+
+// GetModeName returns the mode name.
+func (e *ExamTrainingSession) GetModeName() string {
+	return "exam"
+}
+
+// Description describes the mode for the help screen.
+func (e *ExamTrainingSession) Description() string {
+	return fmt.Sprintf("%d questions drawn evenly from the whole chart; pass with %.0f%%", ExamQuestions, exam.PassMark)
+}
+
+// GetMaxQuestions returns the maximum number of questions.
+func (e *ExamTrainingSession) GetMaxQuestions() int {
+	return ExamQuestions
+}
+
+// SetupSession explains the exam rules.
+func (e *ExamTrainingSession) SetupSession() bool {
+	fmt.Fprintf(ui.Output(), "Exam: %d questions from the whole chart. Answer them all and score %.0f%% or\n", ExamQuestions, exam.PassMark)
+	fmt.Fprintln(ui.Output(), "better to pass. Answers can't be taken back.")
+	return true
+}
+
+// IsExam reports that the session is an exam.
+func (e *ExamTrainingSession) IsExam() bool {
+	return true
+}
+
+// GenerateScenario deals a hand for a chart cell chosen at random.
+func (e *ExamTrainingSession) GenerateScenario() Scenario {
+	key := e.cells[e.rng.Intn(len(e.cells))]
+	return e.generateCell(key.HandType, key.PlayerTotal, key.DealerCard)
+}
+
+// QuizTrainingSession asks an instructor's quiz: a fixed list of hands, in
+// order, scored like an exam.
+type QuizTrainingSession struct {
+	name      string
+	scenarios []Scenario
+	next      int
+}
+
+// NewQuizTrainingSession creates a session asking the scenarios of the
+// named quiz in order.
+func NewQuizTrainingSession(name string, scenarios []Scenario) *QuizTrainingSession {
+	return &QuizTrainingSession{name: name, scenarios: scenarios}
+}
+
+// GetModeName returns the mode name, which includes the quiz name.
+func (q *QuizTrainingSession) GetModeName() string {
+	return "quiz:" + q.name
+}
+
+// Description describes the mode for the help screen.
+func (q *QuizTrainingSession) Description() string {
+	return fmt.Sprintf("the %d questions of quiz %q", len(q.scenarios), q.name)
+}
+
+// GetMaxQuestions returns the number of questions in the quiz.
+func (q *QuizTrainingSession) GetMaxQuestions() int {
+	return len(q.scenarios)
+}
+
+// SetupSession introduces the quiz.
+func (q *QuizTrainingSession) SetupSession() bool {
+	if len(q.scenarios) == 0 {
+		fmt.Fprintf(ui.Output(), "Quiz %q has no questions.\n", q.name)
+		return false
+	}
+	fmt.Fprintf(ui.Output(), "Quiz %q: %d questions. Answers can't be taken back.\n", q.name, len(q.scenarios))
+	return true
+}
+
+// IsExam reports that quizzes are scored like exams.
+func (q *QuizTrainingSession) IsExam() bool {
+	return true
+}
+
+// FixedOrder reports that quiz questions are asked in order.
+func (q *QuizTrainingSession) FixedOrder() bool {
+	return true
+}
 
-	return absolute.handType, playerCards, absolute.playerTotal, dealerCard
+// GenerateScenario returns the next question of the quiz.
+func (q *QuizTrainingSession) GenerateScenario() Scenario {
+	scenario := q.scenarios[q.next%len(q.scenarios)]
+	q.next++
+	return scenario
 }
 
 // Helper function to get minimum of two integers.
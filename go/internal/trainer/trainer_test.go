@@ -1,8 +1,23 @@
 package trainer
 
 import (
+	"blackjack_trainer/internal/hand"
+	"blackjack_trainer/internal/history"
+	"blackjack_trainer/internal/rng"
+	"blackjack_trainer/internal/spectate"
+	"blackjack_trainer/internal/stats"
 	"blackjack_trainer/internal/strategy"
+	"blackjack_trainer/internal/ui"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 // Test hand generation produces valid card combinations
@@ -221,3 +236,1026 @@ func TestHandGeneration(t *testing.T) {
 		}
 	})
 }
+
+// Test generated hands classify as the hand type that was requested
+func TestGeneratedHandsMatchType(t *testing.T) {
+	baseTrainer := NewBaseTrainer()
+
+	for iteration := 0; iteration < 200; iteration++ {
+		for _, handType := range []strategy.HandType{strategy.HandTypeHard, strategy.HandTypeSoft, strategy.HandTypePair} {
+			h := baseTrainer.GenerateRandomHand(handType)
+			if got, _ := strategy.Classify(h); got != handType {
+				t.Errorf("Requested %s hand, got %s: %v", handType, got, h.Cards)
+			}
+		}
+	}
+
+	for total := 5; total <= 20; total++ {
+		h := baseTrainer.GenerateHardHand(total)
+		if h.Total() != total || h.IsSoft() || h.IsPair() {
+			t.Errorf("Hard %d generated invalid hand: %v", total, h.Cards)
+		}
+		if total <= 11 && !h.CanDouble() {
+			t.Errorf("Hard %d should be a doubleable two-card hand: %v", total, h.Cards)
+		}
+	}
+}
+
+// Test realistic sessions deal valid two-card hands and skip blackjacks
+func TestRealisticSessionScenarios(t *testing.T) {
+	session := NewRealisticTrainingSession()
+
+	for iteration := 0; iteration < 500; iteration++ {
+		scenario := session.GenerateScenario()
+		if len(scenario.Hand.Cards) != 2 {
+			t.Fatalf("Realistic hand should have 2 cards: %v", scenario.Hand.Cards)
+		}
+		if scenario.Hand.Total() == 21 {
+			t.Errorf("Realistic session should skip blackjack: %v", scenario.Hand.Cards)
+		}
+		if scenario.DealerCard < 2 || scenario.DealerCard > 11 {
+			t.Errorf("Invalid dealer card %d", scenario.DealerCard)
+		}
+	}
+}
+
+// Test constrained scenarios stay within their dealer cards, hand types and totals
+func TestGenerateConstrained(t *testing.T) {
+	tests := []struct {
+		name        string
+		constraints Constraints
+		dealer      []int
+		handType    strategy.HandType
+		min, max    int
+	}{
+		{"weak dealers", Constraints{DealerCards: []int{4, 5, 6}, HandTypes: []strategy.HandType{strategy.HandTypeHard}}, []int{4, 5, 6}, strategy.HandTypeHard, 5, 20},
+		{"hard 12-16", Constraints{HandTypes: []strategy.HandType{strategy.HandTypeHard},
+			Totals: map[strategy.HandType]TotalRange{strategy.HandTypeHard: {12, 16}}}, allDealerCards, strategy.HandTypeHard, 12, 16},
+		{"soft clamped", Constraints{HandTypes: []strategy.HandType{strategy.HandTypeSoft},
+			Totals: map[strategy.HandType]TotalRange{strategy.HandTypeSoft: {17, 30}}}, allDealerCards, strategy.HandTypeSoft, 17, 20},
+		{"empty range ignored", Constraints{HandTypes: []strategy.HandType{strategy.HandTypePair},
+			Totals: map[strategy.HandType]TotalRange{strategy.HandTypePair: {12, 15}}}, allDealerCards, strategy.HandTypePair, 2, 11},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bt := NewBaseTrainer()
+			bt.Seed(1)
+			for i := 0; i < 200; i++ {
+				scenario := bt.generate(tt.constraints)
+				if !containsInt(tt.dealer, scenario.DealerCard) {
+					t.Fatalf("Dealer card %d not in %v", scenario.DealerCard, tt.dealer)
+				}
+				handType, total := strategy.Classify(scenario.Hand)
+				if handType != tt.handType || total < tt.min || total > tt.max {
+					t.Fatalf("Hand %v (%v %d) outside %v %d-%d", scenario.Hand.Cards, handType, total, tt.handType, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+// Test constraint specs parse, format back, and reject bad terms
+func TestParseConstraints(t *testing.T) {
+	c, err := ParseConstraints("hand=soft,pairs dealer=4-6,A soft=13-18 pair=8-A tier=tricky action=double,split")
+	if err != nil {
+		t.Fatalf("ParseConstraints: %v", err)
+	}
+	if want := []int{4, 5, 6, 11}; !equalCards(c.DealerCards, want) {
+		t.Errorf("Dealer cards = %v, want %v", c.DealerCards, want)
+	}
+	if r := c.Totals[strategy.HandTypePair]; r != (TotalRange{8, 11}) {
+		t.Errorf("Pair totals = %v, want 8-11", r)
+	}
+	want := "hand=soft,pair dealer=4,5,6,A soft=13-18 pair=8-A tier=tricky action=double,split"
+	if got := c.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if again, err := ParseConstraints(c.String()); err != nil || again.String() != want {
+		t.Errorf("String should parse back: %q, %v", again.String(), err)
+	}
+
+	for _, spec := range []string{"hand", "hand=wild", "dealer=12", "dealer=9-4", "hard=x", "hard=16-12", "tier=hard", "action=surrender", "color=red"} {
+		if _, err := ParseConstraints(spec); err == nil {
+			t.Errorf("ParseConstraints(%q) should fail", spec)
+		}
+	}
+}
+
+// Test a constrained session deals only the allowed cells
+func TestConstrainedSession(t *testing.T) {
+	chart := strategy.Default()
+	c, _ := ParseConstraints("action=double dealer=4-6")
+	session, err := NewConstrainedSession(c)
+	if err != nil {
+		t.Fatalf("NewConstrainedSession: %v", err)
+	}
+	for i := 0; i < 200; i++ {
+		scenario := session.GenerateScenario()
+		if action := chart.GetCorrectActionForHand(scenario.Hand, scenario.DealerCard); action != 'D' {
+			t.Fatalf("%v vs %d should be a double, got %c", scenario.Hand.Cards, scenario.DealerCard, action)
+		}
+		if scenario.DealerCard < 4 || scenario.DealerCard > 6 {
+			t.Fatalf("Dealer card %d outside 4-6", scenario.DealerCard)
+		}
+	}
+
+	for _, name := range BuiltinDrillNames() {
+		drill, ok := NewBuiltinDrill(name, chart)
+		if !ok || drill.GetModeName() != name {
+			t.Errorf("Built-in drill %q should be available under its name", name)
+		}
+	}
+	splits, _ := NewBuiltinDrill("splits", chart)
+	for i := 0; i < 100; i++ {
+		scenario := splits.GenerateScenario()
+		if action := chart.GetCorrectActionForHand(scenario.Hand, scenario.DealerCard); action != 'Y' {
+			t.Fatalf("Splits drill asked %v vs %d, a %c", scenario.Hand.Cards, scenario.DealerCard, action)
+		}
+	}
+
+	tenAce, _ := NewBuiltinDrill("ten-ace", chart)
+	for i := 0; i < 100; i++ {
+		if scenario := tenAce.GenerateScenario(); scenario.DealerCard != 10 && scenario.DealerCard != 11 {
+			t.Fatalf("Ten-ace drill dealt a dealer %d", scenario.DealerCard)
+		}
+	}
+
+	c, _ = ParseConstraints("hand=pair pair=10 action=split")
+	if _, err := NewConstrainedSession(c); err == nil {
+		t.Error("Never splitting tens, no cells should match")
+	}
+}
+
+// Test the soft ladder climbs in order, points out each rung, then shuffles
+func TestSoftLadderSession(t *testing.T) {
+	session := NewSoftLadderTrainingSession(strategy.Default())
+	var rungs []string
+	for _, r := range session.rungs {
+		rungs = append(rungs, r.String())
+	}
+	if got, want := strings.Join(rungs, "; "), "A,2-A,3 double against 5-6; A,4-A,5 double against 4-6; A,6-A,7 double against 3-6"; got != want {
+		t.Errorf("Rungs = %q, want %q", got, want)
+	}
+
+	var notes int
+	for total := 13; total <= 18; total++ {
+		for dealer := 2; dealer <= 7; dealer++ {
+			scenario := session.GenerateScenario()
+			if _, got := strategy.Classify(scenario.Hand); got != total || scenario.DealerCard != dealer {
+				t.Fatalf("Expected soft %d vs %d in order, got %v vs %d", total, dealer, scenario.Hand.Cards, scenario.DealerCard)
+			}
+			if session.Annotate(scenario) != "" {
+				notes++
+			}
+		}
+	}
+	if notes != len(session.rungs) {
+		t.Errorf("Expected a note per rung, got %d", notes)
+	}
+	if note := session.Annotate(session.GenerateScenario()); !strings.Contains(note, "random order") {
+		t.Errorf("Second pass should be announced, got %q", note)
+	}
+}
+
+// containsInt reports whether values holds v.
+func containsInt(values []int, v int) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Test an injected source chooses the questions
+func TestSetSource(t *testing.T) {
+	session := NewAbsoluteTrainingSession()
+	if !SetSource(session, rng.Ints(0, 7, 1, 0)) {
+		t.Fatal("Absolutes session should accept a source")
+	}
+	// Each pair of numbers picks an absolute and a dealer card
+	want := []Scenario{
+		{Hand: hand.New(11, 11), DealerCard: 9},
+		{Hand: hand.New(8, 8), DealerCard: 2},
+	}
+	for i, w := range want {
+		got := session.GenerateScenario()
+		if got.DealerCard != w.DealerCard || !equalCards(got.Hand.Cards, w.Hand.Cards) {
+			t.Errorf("Question %d = %v vs %d, want %v vs %d", i+1, got.Hand.Cards, got.DealerCard, w.Hand.Cards, w.DealerCard)
+		}
+	}
+
+	if SetSource(NewQuizTrainingSession("fixed", nil), rng.Secure()) {
+		t.Error("Quiz sessions ask a fixed list and shouldn't take a source")
+	}
+}
+
+// Test difficulty weights scenarios toward trivial or tricky cells
+func TestDrawScenarioDifficulty(t *testing.T) {
+	chart := strategy.New()
+	rng := rand.New(rand.NewSource(1))
+	session := NewRandomTrainingSession()
+
+	trickyShare := func(difficulty Difficulty) float64 {
+		questions := newScheduler(session, difficulty, 0, chart, rng)
+		tricky := 0
+		const draws = 2000
+		for i := 0; i < draws; i++ {
+			scenario := questions.next()
+			handType, value := strategy.Classify(scenario.Hand)
+			if chart.GetTier(handType, value, scenario.DealerCard) == strategy.TierTricky {
+				tricky++
+			}
+		}
+		return float64(tricky) / draws
+	}
+
+	easy, normal, hard := trickyShare(DifficultyEasy), trickyShare(DifficultyNormal), trickyShare(DifficultyHard)
+	if !(easy < normal && normal < hard) {
+		t.Errorf("Tricky share should rise with difficulty: easy %.2f, normal %.2f, hard %.2f", easy, normal, hard)
+	}
+	if hard < 0.5 {
+		t.Errorf("Hard sessions should draw mostly tricky cells, got %.2f", hard)
+	}
+}
+
+// Test adaptive sessions favor the cells mastered least
+func TestDrawScenarioAdaptive(t *testing.T) {
+	chart := strategy.New()
+	questions := newScheduler(NewRandomTrainingSession(), DifficultyAdaptive, 0, chart, rand.New(rand.NewSource(1)))
+	questions.mastery = stats.Mastery{}
+	for _, key := range stats.ChartCells() {
+		if key.HandType == strategy.HandTypeHard {
+			questions.mastery[key] = stats.CellMastery{Score: 1, Attempts: 10}
+		}
+	}
+
+	hard := 0
+	const draws = 2000
+	for i := 0; i < draws; i++ {
+		if handType, _ := strategy.Classify(questions.next().Hand); handType == strategy.HandTypeHard {
+			hard++
+		}
+	}
+	// A third of random questions are hard totals, and mastered cells are
+	// kept one time in five, so about one in ten should be
+	if share := float64(hard) / draws; share > 0.2 {
+		t.Errorf("Adaptive sessions should seldom ask mastered hard totals, got %.2f", share)
+	}
+}
+
+// Test cells unpracticed for weeks are mixed into random sessions for review
+func TestReviewQuestions(t *testing.T) {
+	now := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	h := history.New()
+	h.Add(history.Session{Mode: "random", Ended: now.Add(-30 * 24 * time.Hour), Correct: 2, Total: 3, Attempts: []history.Attempt{
+		{Cards: []int{10, 2}, DealerCard: 3, Correct: true},
+		{Cards: []int{11, 7}, DealerCard: 9},
+		{Cards: []int{10, 6}, DealerCard: 10, Correct: true},
+	}})
+	h.Add(history.Session{Mode: "random", Ended: now.Add(-time.Hour), Correct: 1, Total: 1, Attempts: []history.Attempt{
+		{Cards: []int{10, 6}, DealerCard: 10, Correct: true},
+	}})
+	statistics := stats.New()
+	statistics.SetHistory(h)
+
+	var out strings.Builder
+	ui.SetIO(strings.NewReader("h\n\nh\n\nq\nn\n"), &out)
+	RunSession(context.Background(), NewRandomTrainingSession(), statistics, Options{
+		Seed:       1,
+		ReviewRate: 1,
+		Now:        func() time.Time { return now },
+	})
+	ui.SetIO(os.Stdin, os.Stdout)
+
+	// Hard 16 vs 10 was practiced an hour ago, so only two cells are due
+	transcript := out.String()
+	if count := strings.Count(transcript, "Review: you last practiced this hand 4 weeks ago."); count != 2 {
+		t.Errorf("Expected two review questions, got %d in:\n%s", count, transcript)
+	}
+	for _, hand := range []string{"(Hard 12)", "Your hand: A, 7 (Soft 18)"} {
+		if !strings.Contains(transcript, hand) {
+			t.Errorf("Expected review of %q in:\n%s", hand, transcript)
+		}
+	}
+	if strings.Contains(transcript, "Hard 16)\n") {
+		t.Errorf("Hard 16 vs 10 isn't due yet but was asked:\n%s", transcript)
+	}
+
+	// Without a review rate the due cells aren't asked ahead of others
+	out.Reset()
+	ui.SetIO(strings.NewReader("q\n"), &out)
+	RunSession(context.Background(), NewRandomTrainingSession(), statistics, Options{Seed: 1, Now: func() time.Time { return now }})
+	ui.SetIO(os.Stdin, os.Stdout)
+	if strings.Contains(out.String(), "Review:") {
+		t.Errorf("Expected no review questions without a review rate:\n%s", out.String())
+	}
+}
+
+// Test a session with a goal runs until the goal is met over the minimum
+// number of answers. Seed 1 asks hard 12 vs 3 (hit), then hard 14 vs 3
+// (stand).
+func TestSessionGoal(t *testing.T) {
+	tests := []struct {
+		name          string
+		goal, minimum int
+		input         string
+	}{
+		{"accuracy below goal", 50, 1, "s\n\ns\n\n"},
+		{"too few answers", 100, 2, "h\n\ns\n\n"},
+	}
+	for _, tt := range tests {
+		var out strings.Builder
+		ui.SetIO(strings.NewReader(tt.input), &out)
+		record := RunSession(context.Background(), NewRandomTrainingSession(), stats.New(), Options{
+			Seed:          1,
+			Goal:          tt.goal,
+			GoalQuestions: tt.minimum,
+		})
+		ui.SetIO(os.Stdin, os.Stdout)
+
+		if record.Total != 2 {
+			t.Errorf("%s: session ended after %d answers, want 2", tt.name, record.Total)
+		}
+		if !strings.Contains(out.String(), fmt.Sprintf("Goal reached! %d of 2 correct", record.Correct)) {
+			t.Errorf("%s: expected the goal announced in:\n%s", tt.name, out.String())
+		}
+		if strings.Contains(out.String(), "Question 3") {
+			t.Errorf("%s: a question was asked after the goal was met:\n%s", tt.name, out.String())
+		}
+	}
+}
+
+// Test difficulty names are parsed
+func TestParseDifficulty(t *testing.T) {
+	for name, want := range map[string]Difficulty{"": DifficultyNormal, "easy": DifficultyEasy, "hard": DifficultyHard, "adaptive": DifficultyAdaptive} {
+		if got, err := ParseDifficulty(name); err != nil || got != want {
+			t.Errorf("ParseDifficulty(%q) = %q, %v", name, got, err)
+		}
+	}
+	if _, err := ParseDifficulty("expert"); err == nil {
+		t.Error("Expected error for unknown difficulty")
+	}
+}
+
+// Test the scheduler limits consecutive questions with the same correct action
+func TestSchedulerLimitsRepeats(t *testing.T) {
+	chart := strategy.New()
+	rng := rand.New(rand.NewSource(1))
+
+	for _, maxRepeat := range []int{1, 2, 3} {
+		questions := newScheduler(NewRandomTrainingSession(), DifficultyNormal, maxRepeat, chart, rng)
+		var last rune
+		run := 0
+		for i := 0; i < 1000; i++ {
+			scenario := questions.next()
+			action := chart.GetCorrectActionForHand(scenario.Hand, scenario.DealerCard)
+			if action == last {
+				run++
+			} else {
+				last, run = action, 1
+			}
+			if run > maxRepeat {
+				t.Fatalf("Max repeat %d: %d consecutive %c answers", maxRepeat, run, action)
+			}
+		}
+	}
+}
+
+// Test sessions that cannot vary their answer still produce questions
+func TestSchedulerFallsBack(t *testing.T) {
+	chart := strategy.New()
+	session := fixedSession{Scenario{Hand: hand.New(8, 8), DealerCard: 10}}
+
+	questions := newScheduler(session, DifficultyHard, 1, chart, rand.New(rand.NewSource(1)))
+	for i := 0; i < 5; i++ {
+		if scenario := questions.next(); !scenario.Hand.IsPair() || scenario.Hand.PairCard() != 8 {
+			t.Errorf("Expected 8,8, got %s", scenario.Hand)
+		}
+	}
+}
+
+// fixedSession always asks the same scenario.
+type fixedSession struct {
+	scenario Scenario
+}
+
+func (s fixedSession) GetModeName() string        { return "fixed" }
+func (s fixedSession) GetMaxQuestions() int       { return 1 }
+func (s fixedSession) GenerateScenario() Scenario { return s.scenario }
+func (s fixedSession) SetupSession() bool         { return true }
+
+// Test the composition drill mixes exception hands with ordinary ones
+func TestCompositionSessionScenarios(t *testing.T) {
+	downtown, _ := strategy.LookupRules("downtown")
+	chart := strategy.NewForRules(downtown)
+	session := NewCompositionTrainingSession(chart)
+	if !usesComposition(session) || usesComposition(NewRandomTrainingSession()) {
+		t.Error("Only the composition drill should use composition-dependent answers")
+	}
+
+	matched, ordinary := 0, 0
+	for iteration := 0; iteration < 500; iteration++ {
+		scenario := session.GenerateScenario()
+		h := scenario.Hand
+		if h.IsSoft() || h.IsPair() {
+			t.Fatalf("Composition hands should be hard non-pairs: %v", h.Cards)
+		}
+
+		exception, ok := chart.ExceptionForHand(h, scenario.DealerCard)
+		action, _ := correctPlay(chart, scenario, true)
+		if ok {
+			matched++
+			if action != exception.Action {
+				t.Errorf("%v vs %d: expected exception action %c, got %c", h.Cards, scenario.DealerCard, exception.Action, action)
+			}
+		} else {
+			ordinary++
+			if action != chart.GetCorrectActionForHand(h, scenario.DealerCard) {
+				t.Errorf("%v vs %d: ordinary hand should follow the chart", h.Cards, scenario.DealerCard)
+			}
+		}
+	}
+	if matched < 100 || ordinary < 100 {
+		t.Errorf("Expected a mix of exception and ordinary hands, got %d and %d", matched, ordinary)
+	}
+}
+
+// Test game sessions deal from the game and are named after it
+func TestGameSessionScenarios(t *testing.T) {
+	game, err := strategy.NewGame("free-bet", strategy.Standard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session := NewGameTrainingSession(game)
+	if session.GetModeName() != "free-bet" {
+		t.Errorf("Expected mode free-bet, got %s", session.GetModeName())
+	}
+
+	for iteration := 0; iteration < 200; iteration++ {
+		scenario := session.GenerateScenario()
+		if len(scenario.Hand.Cards) != 2 || scenario.DealerCard < 2 || scenario.DealerCard > 11 {
+			t.Errorf("Invalid scenario %v vs %d", scenario.Hand.Cards, scenario.DealerCard)
+		}
+	}
+}
+
+// Test that quitting mid-session records the partial session only when asked
+func TestQuitConfirmation(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		record bool
+	}{
+		{"record", "s\n\nq\ny\n", true},
+		{"default records", "s\nq\n\n", true},
+		{"discard", "s\nq\nn\n", false},
+		{"keep practicing then discard", "s\nq\nc\ns\nq\nn\n", false},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), history.FileName)
+		h, err := history.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		statistics := stats.New()
+		statistics.SetHistory(h)
+
+		ui.SetIO(strings.NewReader(tt.input), io.Discard)
+		RunSession(context.Background(), NewAbsoluteTrainingSession(), statistics, Options{Seed: 1})
+		ui.SetIO(os.Stdin, os.Stdout)
+
+		reopened, err := history.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := len(reopened.Sessions) == 1; got != tt.record {
+			t.Errorf("%s: recorded = %v, want %v (sessions %+v)", tt.name, got, tt.record, reopened.Sessions)
+		}
+	}
+}
+
+// Test that a wrong answer taken back as a slip is kept apart from the score
+// and its hand asked again after reaskDelay more questions
+func TestCorrectedSlip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), history.FileName)
+	h, err := history.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	statistics := stats.New()
+	statistics.SetHistory(h)
+
+	input := "h\nu\n" + strings.Repeat("s\n\n", reaskDelay+1) + "q\ny\n"
+	ui.SetIO(strings.NewReader(input), io.Discard)
+	RunSession(context.Background(), NewAbsoluteTrainingSession(), statistics, Options{Seed: 1})
+	ui.SetIO(os.Stdin, os.Stdout)
+
+	if len(h.Sessions) != 1 {
+		t.Fatalf("Expected one recorded session, got %d", len(h.Sessions))
+	}
+	session := h.Sessions[0]
+	if len(session.Corrected) != 1 || session.Corrected[0].Action != "H" {
+		t.Fatalf("Expected the first answer corrected, got %+v", session.Corrected)
+	}
+	if session.Total != reaskDelay+1 || len(session.Attempts) != reaskDelay+1 {
+		t.Errorf("Slip should not be scored: total %d, attempts %d", session.Total, len(session.Attempts))
+	}
+	slip, again := session.Corrected[0], session.Attempts[reaskDelay]
+	if !equalCards(slip.Cards, again.Cards) || slip.DealerCard != again.DealerCard {
+		t.Errorf("Expected %v vs %d asked again, got %v vs %d", slip.Cards, slip.DealerCard, again.Cards, again.DealerCard)
+	}
+}
+
+// Test spectators are shown each question and answer, and slips and the
+// session's end
+func TestSpectatedSession(t *testing.T) {
+	spectators, err := spectate.Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ui.SetIO(strings.NewReader("h\nu\ns\n\ns\n\nq\ny\n"), io.Discard)
+	record := RunSession(context.Background(), NewAbsoluteTrainingSession(), stats.New(), Options{Seed: 1, Spectators: spectators})
+	ui.SetIO(os.Stdin, os.Stdout)
+
+	events, _, stop := spectators.Subscribe()
+	stop()
+	var types []string
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	want := "started question answer corrected question answer question answer question ended"
+	if strings.Join(types, " ") != want {
+		t.Fatalf("Events = %v, want %s", types, want)
+	}
+	first, last := events[1], events[len(events)-1]
+	if !equalCards(first.Cards, record.Corrected[0].Cards) || first.DealerCard != record.Corrected[0].DealerCard || first.Label == "" {
+		t.Errorf("First question = %+v, want the slip %+v", first, record.Corrected[0])
+	}
+	if events[2].Action != "H" || last.Score != record.Correct || last.Answered != record.Total {
+		t.Errorf("Answer %+v and end %+v don't match the record %d/%d", events[2], last, record.Correct, record.Total)
+	}
+}
+
+// Test a coach's notes are shown with the feedback of the question they
+// were sent during and saved with its answer
+func TestCoachNotes(t *testing.T) {
+	notes := make(chan string, 2)
+	notes <- "Count the dealer's bust cards"
+	notes <- "Then decide"
+	ui.SetCoachNotes(notes)
+	defer ui.SetCoachNotes(nil)
+
+	var out bytes.Buffer
+	ui.SetIO(strings.NewReader("s\n\ns\n\nq\ny\n"), &out)
+	record := RunSession(context.Background(), NewAbsoluteTrainingSession(), stats.New(), Options{Seed: 1})
+	ui.SetIO(os.Stdin, os.Stdout)
+
+	if len(record.Attempts) != 2 {
+		t.Fatalf("Expected two answers, got %+v", record.Attempts)
+	}
+	if got := strings.Join(record.Attempts[0].Notes, "|"); got != "Count the dealer's bust cards|Then decide" {
+		t.Errorf("First answer's notes = %q", got)
+	}
+	if record.Attempts[1].Notes != nil {
+		t.Errorf("Second answer should have no notes, got %q", record.Attempts[1].Notes)
+	}
+	if !strings.Contains(out.String(), "\nCoach: Count the dealer's bust cards\n") {
+		t.Errorf("Notes should be shown with the feedback:\n%s", out.String())
+	}
+}
+
+// Test tags given at the feedback prompt are saved and drilled later
+func TestTaggedSession(t *testing.T) {
+	h := history.New()
+	statistics := stats.New()
+	statistics.SetHistory(h)
+
+	input := "s\nt Table Saw This\nt confusing\n\ns\n\nq\ny\n"
+	ui.SetIO(strings.NewReader(input), io.Discard)
+	RunSession(context.Background(), NewAbsoluteTrainingSession(), statistics, Options{Seed: 1})
+	ui.SetIO(os.Stdin, os.Stdout)
+
+	if len(h.Sessions) != 1 || len(h.Sessions[0].Attempts) != 2 {
+		t.Fatalf("Expected one session of two attempts, got %+v", h.Sessions)
+	}
+	first := h.Sessions[0].Attempts[0]
+	if len(first.Tags) != 2 || first.Tags[0] != "table-saw-this" || first.Tags[1] != "confusing" {
+		t.Errorf("Expected the first hand tagged table-saw-this and confusing, got %v", first.Tags)
+	}
+	if tags := h.Sessions[0].Attempts[1].Tags; len(tags) != 0 {
+		t.Errorf("Expected the second hand untagged, got %v", tags)
+	}
+
+	session := NewTaggedTrainingSession("confusing", h.Tagged("confusing"))
+	if session.GetMaxQuestions() != 2 {
+		t.Errorf("Expected 2 questions for one tagged hand, got %d", session.GetMaxQuestions())
+	}
+	for i := 0; i < 10; i++ {
+		scenario := session.GenerateScenario()
+		if !equalCards(scenario.Hand.Cards, first.Cards) || scenario.DealerCard != first.DealerCard {
+			t.Fatalf("Expected only the tagged hand, got %v vs %d", scenario.Hand.Cards, scenario.DealerCard)
+		}
+	}
+
+	ui.SetIO(strings.NewReader(""), io.Discard)
+	defer ui.SetIO(os.Stdin, os.Stdout)
+	if NewTaggedTrainingSession("missing", nil).SetupSession() {
+		t.Error("A drill with no tagged hands should not start")
+	}
+}
+
+// Test exams can't be taken back and return the recorded session
+func TestExamSession(t *testing.T) {
+	statistics := stats.New()
+	ui.SetIO(strings.NewReader(strings.Repeat("h\nu\n", ExamQuestions)), io.Discard)
+	record := RunSession(context.Background(), NewExamTrainingSession(), statistics, Options{Seed: 1, Difficulty: DifficultyHard})
+	ui.SetIO(os.Stdin, os.Stdout)
+
+	if record.Mode != "exam" || record.Total != ExamQuestions || len(record.Attempts) != ExamQuestions {
+		t.Errorf("Expected a recorded exam of %d questions, got %s with %d", ExamQuestions, record.Mode, record.Total)
+	}
+	if len(record.Corrected) != 0 {
+		t.Errorf("Exam answers should not be taken back, got %d corrected", len(record.Corrected))
+	}
+	if len(statistics.History().Sessions) != 1 {
+		t.Errorf("Expected the exam recorded in the history")
+	}
+
+	// A session quit without recording returns a zero session
+	ui.SetIO(strings.NewReader("h\n\nq\nn\n"), io.Discard)
+	record = RunSession(context.Background(), NewExamTrainingSession(), statistics, Options{Seed: 1})
+	ui.SetIO(os.Stdin, os.Stdout)
+	if record.Total != 0 {
+		t.Errorf("Expected no record for a discarded session, got %+v", record)
+	}
+}
+
+// Test a quiz asks its hands in order, even with a time limit
+func TestQuizSession(t *testing.T) {
+	scenarios := []Scenario{
+		{Hand: hand.New(10, 6), DealerCard: 10},
+		{Hand: hand.New(hand.Ace, 7), DealerCard: 9},
+		{Hand: hand.New(8, 8), DealerCard: 6},
+	}
+	statistics := stats.New()
+	ui.SetIO(strings.NewReader(strings.Repeat("s\nu\n", len(scenarios))), io.Discard)
+	record := RunSession(context.Background(), NewQuizTrainingSession("Week 1", scenarios), statistics, Options{
+		TimeLimit:   time.Hour,
+		FixedLength: true,
+	})
+	ui.SetIO(os.Stdin, os.Stdout)
+
+	if record.Mode != "quiz:Week 1" || record.Total != len(scenarios) {
+		t.Fatalf("Expected a recorded quiz of %d questions, got %s with %d", len(scenarios), record.Mode, record.Total)
+	}
+	for i, attempt := range record.Attempts {
+		if !equalCards(attempt.Cards, scenarios[i].Hand.Cards) || attempt.DealerCard != scenarios[i].DealerCard {
+			t.Errorf("Question %d: expected %v vs %d, got %v vs %d", i+1, scenarios[i].Hand.Cards, scenarios[i].DealerCard, attempt.Cards, attempt.DealerCard)
+		}
+	}
+}
+
+// Test the peek drill asks the plays the peek rule changes, and explains
+// what the other rule would do
+func TestPeekSession(t *testing.T) {
+	european, _ := strategy.LookupRules("european")
+	for _, rules := range []strategy.RuleSet{strategy.Standard, european} {
+		session := NewPeekTrainingSession(rules)
+		chart := strategy.NewForRules(rules)
+		changed, same := 0, 0
+		for iteration := 0; iteration < 300; iteration++ {
+			scenario := session.GenerateScenario()
+			if scenario.DealerCard < 9 {
+				t.Fatalf("%s: %v vs %d isn't a peek scenario", rules.Name, scenario.Hand.Cards, scenario.DealerCard)
+			}
+			_, explanation := correctPlay(chart, scenario, false)
+			explanation = session.Explain(chart, scenario, explanation)
+			if strings.Contains(explanation, "doesn't change this play") {
+				same++
+			} else if strings.Contains(explanation, "you would") {
+				changed++
+			} else {
+				t.Errorf("%s: %v vs %d has no peek explanation: %s", rules.Name, scenario.Hand.Cards, scenario.DealerCard, explanation)
+			}
+		}
+		if changed < 150 || same < 50 {
+			t.Errorf("%s: expected mostly plays the rule changes, got %d changed and %d the same", rules.Name, changed, same)
+		}
+	}
+}
+
+// Test plan drills choose their hand type or dealer group without asking
+func TestNewDrill(t *testing.T) {
+	game := strategy.NewClassic(strategy.Default())
+	tests := []struct {
+		drill string
+		check func(Scenario) bool
+	}{
+		{"soft", func(s Scenario) bool {
+			handType, _ := strategy.Classify(s.Hand)
+			return handType == strategy.HandTypeSoft
+		}},
+		{"pairs", func(s Scenario) bool { return s.Hand.IsPair() }},
+		{"weak", func(s Scenario) bool { return s.DealerCard >= 4 && s.DealerCard <= 6 }},
+	}
+	for _, tt := range tests {
+		session := NewDrill(tt.drill, game)
+		ui.SetIO(strings.NewReader(""), io.Discard)
+		ok := session.SetupSession()
+		ui.SetIO(os.Stdin, os.Stdout)
+		if !ok {
+			t.Errorf("%s: setup should not ask for a choice", tt.drill)
+			continue
+		}
+		for i := 0; i < 20; i++ {
+			if scenario := session.GenerateScenario(); !tt.check(scenario) {
+				t.Errorf("%s: unexpected scenario %s vs %d", tt.drill, scenario.Hand, scenario.DealerCard)
+			}
+		}
+	}
+	if NewDrill("absolute", game) == nil || NewDrill("bogus", game) != nil {
+		t.Error("Expected session types to be drills and unknown names rejected")
+	}
+}
+
+// equalCards reports whether two card lists are the same.
+func equalCards(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Test which answers break an always/never rule in the absolutes drill
+func TestBrokenRule(t *testing.T) {
+	session := NewAbsoluteTrainingSession()
+	tests := []struct {
+		cards         []int
+		action        rune
+		correctAction rune
+		broken        bool
+	}{
+		{[]int{8, 8}, 'S', 'Y', true},
+		{[]int{8, 8}, 'Y', 'Y', false},
+		{[]int{10, 10}, 'P', 'S', true},
+		{[]int{5, 5}, 'Y', 'H', true},
+		{[]int{5, 5}, 'S', 'H', false},
+		{[]int{10, 7}, 'H', 'S', true},
+		{[]int{11, 8}, 'H', 'S', true},
+		// Soft 19 doubles against a 6 when the dealer hits soft 17
+		{[]int{11, 8}, 'S', 'D', false},
+		{[]int{10, 6}, 'S', 'H', false},
+	}
+	for _, tt := range tests {
+		_, broken := session.BrokenRule(Scenario{Hand: hand.New(tt.cards...), DealerCard: 6}, tt.action, tt.correctAction)
+		if broken != tt.broken {
+			t.Errorf("%v answered %c (correct %c): broken = %v, want %v", tt.cards, tt.action, tt.correctAction, broken, tt.broken)
+		}
+	}
+}
+
+// Test an answer breaking an absolute rule is questioned once at easy
+// difficulty, and the second answer recorded
+func TestConfirmAbsolutes(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   Options
+		input  string
+		action string
+	}{
+		// The first hand with seed 1 is 8,8 against a 9
+		{"keep", Options{Seed: 1, Difficulty: DifficultyEasy, ConfirmAbsolutes: true}, "s\ny\n\nq\ny\n", "S"},
+		{"answer again", Options{Seed: 1, Difficulty: DifficultyEasy, ConfirmAbsolutes: true}, "s\nn\np\n\nq\ny\n", "Y"},
+		{"off", Options{Seed: 1, Difficulty: DifficultyEasy}, "s\n\nq\ny\n", "S"},
+		{"normal difficulty", Options{Seed: 1, ConfirmAbsolutes: true}, "s\n\nq\ny\n", "S"},
+	}
+	for _, tt := range tests {
+		h := history.New()
+		statistics := stats.New()
+		statistics.SetHistory(h)
+
+		var output strings.Builder
+		ui.SetIO(strings.NewReader(tt.input), &output)
+		RunSession(context.Background(), NewAbsoluteTrainingSession(), statistics, tt.opts)
+		ui.SetIO(os.Stdin, os.Stdout)
+
+		if len(h.Sessions) != 1 || len(h.Sessions[0].Attempts) != 1 {
+			t.Fatalf("%s: expected one session of one attempt, got %+v", tt.name, h.Sessions)
+		}
+		if got := h.Sessions[0].Attempts[0].Action; got != tt.action {
+			t.Errorf("%s: recorded %s, want %s", tt.name, got, tt.action)
+		}
+		asked := strings.Contains(output.String(), "Are you sure? This is an absolute rule: always split 8,8.")
+		if want := tt.opts.ConfirmAbsolutes && tt.opts.Difficulty == DifficultyEasy; asked != want {
+			t.Errorf("%s: asked = %v, want %v", tt.name, asked, want)
+		}
+	}
+}
+
+// Test the hit odds use an infinite deck, or the shoe of a realistic
+// session, and are given only for hard totals of 12 or more
+func TestHitOdds(t *testing.T) {
+	random := NewRandomTrainingSession()
+	sixteen := Scenario{Hand: hand.New(10, 6), DealerCard: 10}
+	if got, want := hitOdds(random, sixteen), "hitting hard 16 busts ~62% of the time (infinite deck)"; got != want {
+		t.Errorf("hitOdds = %q, want %q", got, want)
+	}
+	for _, cards := range [][]int{{6, 5}, {11, 6}, {8, 8}} {
+		if got := hitOdds(random, Scenario{Hand: hand.New(cards...), DealerCard: 10}); got != "" {
+			t.Errorf("hitOdds for %v = %q, want none", cards, got)
+		}
+	}
+
+	realistic := NewRealisticTrainingSession()
+	realistic.Seed(1)
+	realistic.GenerateScenario()
+	got := hitOdds(realistic, sixteen)
+	if !strings.Contains(got, "hitting hard 16 busts ~") || !strings.Contains(got, "cards left in the shoe") {
+		t.Errorf("hitOdds from a shoe = %q", got)
+	}
+}
+
+// Test the coach shows the odds before each answer in a realistic session,
+// marking coached answers as hinted, and stays out of other sessions
+func TestCoach(t *testing.T) {
+	h := history.New()
+	statistics := stats.New()
+	statistics.SetHistory(h)
+
+	var output strings.Builder
+	ui.SetIO(strings.NewReader("s\n\nq\ny\n"), &output)
+	RunSession(context.Background(), NewRealisticTrainingSession(), statistics, Options{Seed: 1, Coach: true})
+	ui.SetIO(os.Stdin, os.Stdout)
+
+	if len(h.Sessions) != 1 || len(h.Sessions[0].Attempts) != 1 || !h.Sessions[0].Attempts[0].Hinted {
+		t.Fatalf("Expected one coached attempt, got %+v", h.Sessions)
+	}
+	for _, want := range []string{"Coach:", "bust", "EV per bet: ", "STAND"} {
+		if !strings.Contains(output.String(), want) {
+			t.Errorf("Coached session missing %q:\n%s", want, output.String())
+		}
+	}
+
+	output.Reset()
+	ui.SetIO(strings.NewReader("p\n\nq\ny\n"), &output)
+	RunSession(context.Background(), NewAbsoluteTrainingSession(), statistics, Options{Seed: 1, Coach: true})
+	ui.SetIO(os.Stdin, os.Stdout)
+	if strings.Contains(output.String(), "Coach:") {
+		t.Error("The coach should only appear in sessions dealt from a shoe")
+	}
+}
+
+// Test a rating entered at the feedback prompt is recorded and continues
+func TestRatingRecorded(t *testing.T) {
+	h := history.New()
+	statistics := stats.New()
+	statistics.SetHistory(h)
+
+	// The first hand with seed 1 is 8,8 against a 9; rating it moves on to
+	// the second, where 'q' quits
+	var output strings.Builder
+	ui.SetIO(strings.NewReader("p\n3\nq\ny\n"), &output)
+	ui.SetRatings(true)
+	RunSession(context.Background(), NewAbsoluteTrainingSession(), statistics, Options{Seed: 1})
+	ui.SetRatings(false)
+	ui.SetIO(os.Stdin, os.Stdout)
+
+	if len(h.Sessions) != 1 || len(h.Sessions[0].Attempts) != 1 {
+		t.Fatalf("Expected one session of one attempt, got %+v", h.Sessions)
+	}
+	if got := h.Sessions[0].Attempts[0].Rating; got != 3 {
+		t.Errorf("Recorded rating %d, want 3", got)
+	}
+	if !strings.Contains(output.String(), "Rated: hard") {
+		t.Error("Rating should be confirmed")
+	}
+}
+
+// Test looking up the mnemonics before answering marks only that answer as
+// hinted
+func TestMnemonicsHint(t *testing.T) {
+	h := history.New()
+	statistics := stats.New()
+	statistics.SetHistory(h)
+
+	// Seed 1 asks 8,8 against a 9 first: 'm' shows the list before it is
+	// split, then at the feedback prompt, which doesn't count toward the
+	// second hand
+	var output strings.Builder
+	ui.SetIO(strings.NewReader("m\np\nm\n\ns\n\nq\ny\n"), &output)
+	RunSession(context.Background(), NewAbsoluteTrainingSession(), statistics, Options{Seed: 1})
+	ui.SetIO(os.Stdin, os.Stdout)
+
+	if len(h.Sessions) != 1 || len(h.Sessions[0].Attempts) != 2 {
+		t.Fatalf("Expected one session of two attempts, got %+v", h.Sessions)
+	}
+	if attempts := h.Sessions[0].Attempts; !attempts[0].Hinted || attempts[1].Hinted {
+		t.Errorf("Hinted = %v, %v; want true, false", attempts[0].Hinted, attempts[1].Hinted)
+	}
+	if strings.Count(output.String(), "Aces and eights, don't hesitate") != 2 {
+		t.Error("Mnemonics list should be shown at both prompts")
+	}
+	if !strings.Contains(output.String(), "Hints: mnemonics looked up for 1 question(s)") {
+		t.Error("Report card should count the hint")
+	}
+}
+
+// Test the difficulty curve steps up after a strong window, down after a
+// weak one, and judges each step on a fresh window
+func TestDifficultyCurve(t *testing.T) {
+	if _, ok := newDifficultyCurve(DifficultyAdaptive); ok {
+		t.Error("Adaptive sessions should not auto-adjust")
+	}
+
+	curve, ok := newDifficultyCurve(DifficultyNormal)
+	if !ok {
+		t.Fatal("Normal sessions should auto-adjust")
+	}
+	for i := 0; i < autoWindow-1; i++ {
+		if _, step := curve.record(true); step != 0 {
+			t.Fatalf("Stepped after only %d answers", i+1)
+		}
+	}
+	if d, step := curve.record(true); step != 1 || d != DifficultyHard {
+		t.Fatalf("Expected a step up to hard after %d right, got %s (step %d)", autoWindow, d, step)
+	}
+
+	// Hard is the top; a perfect window stays there
+	for i := 0; i < autoWindow; i++ {
+		if d, step := curve.record(true); step != 0 || d != DifficultyHard {
+			t.Fatalf("Expected to stay hard, got %s (step %d)", d, step)
+		}
+	}
+
+	// 9 of 10 is neither above 95% nor below 60%
+	curve, _ = newDifficultyCurve(DifficultyNormal)
+	for i := 0; i < autoWindow; i++ {
+		if _, step := curve.record(i != 0); step != 0 {
+			t.Fatalf("9 of 10 should not change the difficulty")
+		}
+	}
+
+	// The window rolls: 5 of the last 10 eases off
+	var d Difficulty
+	step := 0
+	for i := 0; i < 5 && step == 0; i++ {
+		d, step = curve.record(false)
+	}
+	if step != -1 || d != DifficultyEasy {
+		t.Errorf("Expected a step down to easy, got %s (step %d)", d, step)
+	}
+}
+
+// Test a missed hand is asked again a few questions later and near the end
+func TestRepeatMisses(t *testing.T) {
+	h := history.New()
+	statistics := stats.New()
+	statistics.SetHistory(h)
+
+	// Standing on the first hand, 8,8 against a 9, misses it
+	var output strings.Builder
+	ui.SetIO(strings.NewReader(strings.Repeat("s\n\n", 20)), &output)
+	RunSession(context.Background(), NewAbsoluteTrainingSession(), statistics, Options{Seed: 1, RepeatMisses: true})
+	ui.SetIO(os.Stdin, os.Stdout)
+
+	if len(h.Sessions) != 1 || len(h.Sessions[0].Attempts) != 20 {
+		t.Fatalf("Expected one session of 20 attempts, got %+v", h.Sessions)
+	}
+	attempts := h.Sessions[0].Attempts
+	missed := attempts[0]
+	if missed.Correct {
+		t.Fatalf("Expected the first hand missed, got %+v", missed)
+	}
+	var asked []int
+	for i, attempt := range attempts[1:] {
+		if equalCards(attempt.Cards, missed.Cards) && attempt.DealerCard == missed.DealerCard {
+			asked = append(asked, i+1)
+		}
+	}
+	if len(asked) < 2 || asked[0] < repeatMin || asked[0] > repeatMax || asked[len(asked)-1] < 20-repeatEndGap-repeatMax {
+		t.Errorf("Expected the miss asked again after %d-%d questions and near the end, got questions %v", repeatMin, repeatMax, asked)
+	}
+	if !strings.Contains(output.String(), "Again: you missed this hand earlier in the session.") {
+		t.Error("Expected repeats to be announced")
+	}
+}
+
+// Test reasks are kept in the order due
+func TestScheduleReask(t *testing.T) {
+	var reasks []reask
+	for _, due := range []int{5, 2, 9, 5, 1} {
+		reasks = scheduleReask(reasks, reask{due: due})
+	}
+	for i := 1; i < len(reasks); i++ {
+		if reasks[i-1].due > reasks[i].due {
+			t.Fatalf("Reasks out of order: %+v", reasks)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+// Package tutorial is a guided introduction for new players, shown on first
+// launch before the main menu.
+//
+// It explains the four actions, how hands and dealer cards are written, and
+// the dealer strength groups the trainer organizes practice around, then
+// walks through three practice questions with commentary on each. Answers
+// are checked against the selected chart but not recorded in the practice
+// history.
+package tutorial
+
+import (
+	"blackjack_trainer/internal/hand"
+	"blackjack_trainer/internal/strategy"
+	"blackjack_trainer/internal/ui"
+	"fmt"
+	"strings"
+)
+
+// Page is one screen of explanation.
+type Page struct {
+	Title string
+	Text  string
+}
+
+// Question is a practice question and the commentary shown after it.
+type Question struct {
+	Cards      []int
+	DealerCard int
+	// Action is the correct play, the same under every rule preset.
+	Action     rune
+	Commentary string
+}
+
+// Pages returns the explanation pages in order.
+func Pages() []Page {
+	return []Page{
+		{
+			Title: "Welcome",
+			Text: `Basic strategy is the best play for every hand you can hold against every
+card the dealer can show. This trainer shows you a hand and the dealer's up
+card and asks for your move, then tells you whether it was right and why.
+A few minutes a day is enough to learn the whole chart.`,
+		},
+		{
+			Title: "The Four Actions",
+			Text: `HIT     Take another card. You can keep hitting until you stand or bust.
+STAND   Take no more cards and keep your total.
+DOUBLE  Double your bet, take exactly one more card, and stand.
+SPLIT   Split a pair into two hands, each with its own bet.
+
+The prompt shows the key for each action: H, S, D and P unless you have
+chosen another key scheme. Enter q at any prompt to stop, or h? for help.`,
+		},
+		{
+			Title: "Reading a Hand",
+			Text: `Cards are written 2 through 10 and A for an ace. Jacks, queens and kings
+count 10 and are shown as 10.
+
+Hard 16   No ace, or an ace that must count 1: 10, 6
+Soft 18   An ace counting 11 that can drop to 1 if you draw: A, 7
+Pair 8    Two cards of the same rank, which you may split: 8, 8
+
+A soft hand can't bust on one more card, which is why it is played more
+aggressively than the hard hand with the same total.`,
+		},
+		{
+			Title: "Dealer Strength Groups",
+			Text: `You only see one of the dealer's cards, and it tells you a lot:
+
+Weak     4, 5, 6       Bust cards: the dealer often breaks, so stand and
+                       double more
+Medium   2, 3, 7, 8    The dealer usually makes a hand; play your own total
+Strong   9, 10, A      The dealer is likely to finish strong; take more risks
+                       to improve your hand
+
+Learning the chart one dealer group at a time is one of the practice modes.`,
+		},
+	}
+}
+
+// Questions returns the practice questions in order.
+func Questions() []Question {
+	return []Question{
+		{
+			Cards: []int{10, 6}, DealerCard: 5, Action: 'S',
+			Commentary: `Hard 16 is a bad hand, but the dealer shows a 5, a weak card. The dealer
+must keep hitting to 17 and busts more than four times in ten, so let the
+dealer take the risk: stand on 12 through 16 against 4, 5 and 6.`,
+		},
+		{
+			Cards: []int{10, 6}, DealerCard: 10, Action: 'H',
+			Commentary: `The same hard 16, but now the dealer shows a 10, a strong card that usually
+ends on 17 or more. Standing wins only when the dealer busts, so hitting is
+the lesser evil even though you will often bust.`,
+		},
+		{
+			Cards: []int{8, 8}, DealerCard: 6, Action: 'Y',
+			Commentary: `Pair 8 is hard 16 again, the worst total in the game. Split it: two hands
+starting from 8 each do far better than one 16, especially against a bust
+card like 6. "Aces and eights, don't hesitate."`,
+		},
+	}
+}
+
+// Run shows the tutorial using the ui streams and key bindings, checking
+// answers against chart. The player can skip the rest of the tutorial from
+// any page. Returns the number of questions answered correctly and asked.
+func Run(chart *strategy.StrategyChart) (correct, asked int) {
+	out := ui.Output()
+
+	fmt.Fprintln(out, "\n"+strings.Repeat("=", 40))
+	fmt.Fprintln(out, "Tutorial: Getting Started")
+	fmt.Fprintln(out, strings.Repeat("=", 40))
+
+	// next waits for Enter, returning false if the player skips the rest
+	next := func(prompt string) bool {
+		input, err := ui.Prompt(fmt.Sprintf("\n%s (or 's' + Enter to skip the tutorial): ", prompt))
+		if err != nil {
+			return false
+		}
+		input = strings.ToLower(input)
+		return input != "s" && input != "q"
+	}
+
+	for i, page := range Pages() {
+		fmt.Fprintf(out, "\n%d. %s\n\n%s\n", i+1, page.Title, page.Text)
+		if !next("Press Enter to continue") {
+			return correct, asked
+		}
+	}
+
+	questions := Questions()
+	fmt.Fprintf(out, "\nNow try %d practice questions.\n", len(questions))
+	for i, q := range questions {
+		h := hand.New(q.Cards...)
+		fmt.Fprintf(out, "\nPractice question %d/%d", i+1, len(questions))
+		ui.DisplayHand(h, q.DealerCard)
+		action, quit := ui.GetUserAction()
+		if quit {
+			return correct, asked
+		}
+
+		asked++
+		want := chart.GetCorrectActionForHand(h, q.DealerCard)
+		if action == want {
+			correct++
+			fmt.Fprintln(out, "\n✓ Correct!")
+		} else {
+			fmt.Fprintf(out, "\n❌ Not quite. The play is %s; you chose %s.\n",
+				strategy.ActionToString(want), strategy.ActionToString(action))
+		}
+		fmt.Fprintf(out, "\n%s\n", q.Commentary)
+		if i < len(questions)-1 && !next("Press Enter for the next question") {
+			return correct, asked
+		}
+	}
+
+	fmt.Fprintf(out, "\nTutorial complete: %d/%d correct.\n", correct, asked)
+	fmt.Fprintln(out, "Quick Practice mixes every kind of hand; the other modes focus on one part")
+	fmt.Fprintln(out, "of the chart. Run the tutorial again any time with: blackjack_trainer tutorial")
+	return correct, asked
+}
@@ -0,0 +1,76 @@
+package tutorial
+
+import (
+	"blackjack_trainer/internal/hand"
+	"blackjack_trainer/internal/strategy"
+	"blackjack_trainer/internal/ui"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestQuestionsHoldUnderEveryRuleSet tests that each practice question's
+// play and commentary are right for every preset and game variant
+func TestQuestionsHoldUnderEveryRuleSet(t *testing.T) {
+	for _, gameKey := range strategy.GameKeys() {
+		for _, rulesKey := range strategy.PresetKeys() {
+			rules, err := strategy.LookupRules(rulesKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			game, err := strategy.NewGame(gameKey, rules)
+			if err != nil {
+				continue // Variants with their own rules only run on the standard preset
+			}
+			for _, q := range Questions() {
+				got := game.Chart().GetCorrectActionForHand(hand.New(q.Cards...), q.DealerCard)
+				if got != q.Action {
+					t.Errorf("%s/%s: %v vs %d plays %c, tutorial says %c", gameKey, rulesKey, q.Cards, q.DealerCard, got, q.Action)
+				}
+			}
+		}
+	}
+}
+
+// runWithInput runs the tutorial on the standard chart with scripted input
+func runWithInput(input string) (string, int, int) {
+	var out strings.Builder
+	ui.SetIO(strings.NewReader(input), &out)
+	defer ui.SetIO(os.Stdin, os.Stdout)
+	correct, asked := Run(strategy.Default())
+	return out.String(), correct, asked
+}
+
+// TestRun tests a complete walk through the tutorial
+func TestRun(t *testing.T) {
+	input := strings.Repeat("\n", len(Pages())) + "s\n\nh\n\nh\n"
+	out, correct, asked := runWithInput(input)
+	if correct != 2 || asked != 3 {
+		t.Errorf("Run scored %d/%d, want 2/3", correct, asked)
+	}
+	for _, page := range Pages() {
+		if !strings.Contains(out, page.Title) {
+			t.Errorf("output missing page %q", page.Title)
+		}
+	}
+	if !strings.Contains(out, "Not quite. The play is SPLIT") {
+		t.Error("output missing correction for the wrong answer")
+	}
+	if !strings.Contains(out, "Tutorial complete: 2/3 correct.") {
+		t.Errorf("output missing summary:\n%s", out)
+	}
+}
+
+// TestRunSkip tests skipping the tutorial from a page and from a question
+func TestRunSkip(t *testing.T) {
+	out, _, asked := runWithInput("\ns\n")
+	if asked != 0 || strings.Contains(out, Pages()[2].Title) || strings.Contains(out, "Tutorial complete") {
+		t.Errorf("skipping from page 2 went on:\n%s", out)
+	}
+
+	input := strings.Repeat("\n", len(Pages())) + "s\ns\n"
+	out, correct, asked := runWithInput(input)
+	if correct != 1 || asked != 1 || strings.Contains(out, "Practice question 2/") {
+		t.Errorf("skipping after question 1 scored %d/%d:\n%s", correct, asked, out)
+	}
+}
@@ -0,0 +1,335 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// actionOrder lists the player actions in the order they are prompted.
+var actionOrder = []rune{'H', 'S', 'D', 'Y'}
+
+// actionNames maps configuration action names to action codes.
+var actionNames = map[string]rune{
+	"hit":    'H',
+	"stand":  'S',
+	"double": 'D',
+	"split":  'Y',
+}
+
+// keySchemes holds the preset key layouts. The first key listed for an
+// action is the one shown in the prompt.
+var keySchemes = map[string][]struct {
+	key    rune
+	action rune
+}{
+	"letters": {{'H', 'H'}, {'S', 'S'}, {'D', 'D'}, {'P', 'Y'}, {'Y', 'Y'}},
+	"numbers": {{'1', 'H'}, {'2', 'S'}, {'3', 'D'}, {'4', 'Y'}},
+	"vim":     {{'H', 'H'}, {'J', 'S'}, {'K', 'D'}, {'L', 'Y'}},
+}
+
+// quitKey is reserved for quitting and cannot be bound to an action.
+const quitKey = 'Q'
+
+// skipKey is reserved for skipping a question and cannot be bound to an
+// action.
+const skipKey = 'N'
+
+// mnemonicsKey is reserved for showing the mnemonics list mid-session and
+// cannot be bound to an action.
+const mnemonicsKey = 'M'
+
+// KeyBindings maps input keys to player actions.
+type KeyBindings struct {
+	keys       map[rune]rune // key -> action
+	promptKeys map[rune]rune // action -> key shown in the prompt
+	classic    bool          // unmodified letters scheme
+}
+
+// DefaultKeyBindings returns the classic H/S/D/P bindings.
+func DefaultKeyBindings() KeyBindings {
+	bindings, _ := NewKeyBindings("", nil)
+	return bindings
+}
+
+// KeySchemeNames returns the names of the preset key schemes.
+func KeySchemeNames() []string {
+	names := make([]string, 0, len(keySchemes))
+	for name := range keySchemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewKeyBindings builds key bindings from a preset scheme ("letters" when
+// empty) and per-action overrides keyed by action name (hit, stand, double,
+// split). An override replaces every scheme key for that action.
+func NewKeyBindings(scheme string, overrides map[string]string) (KeyBindings, error) {
+	if scheme == "" {
+		scheme = "letters"
+	}
+	preset, exists := keySchemes[strings.ToLower(scheme)]
+	if !exists {
+		return KeyBindings{}, fmt.Errorf("unknown key scheme %q (valid: %s)",
+			scheme, strings.Join(KeySchemeNames(), ", "))
+	}
+
+	bindings := KeyBindings{
+		keys:       make(map[rune]rune),
+		promptKeys: make(map[rune]rune),
+		classic:    strings.EqualFold(scheme, "letters") && len(overrides) == 0,
+	}
+	for _, binding := range preset {
+		bindings.keys[binding.key] = binding.action
+		if _, exists := bindings.promptKeys[binding.action]; !exists {
+			bindings.promptKeys[binding.action] = binding.key
+		}
+	}
+
+	// Apply overrides in a fixed order so conflicts are reported consistently
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		action, exists := actionNames[strings.ToLower(name)]
+		if !exists {
+			return KeyBindings{}, fmt.Errorf("unknown action %q in key bindings", name)
+		}
+
+		value := overrides[name]
+		if utf8.RuneCountInString(value) != 1 {
+			return KeyBindings{}, fmt.Errorf("key for %s must be a single character, got %q", name, value)
+		}
+		key, _ := utf8.DecodeRuneInString(value)
+		key = unicode.ToUpper(key)
+		if key == quitKey {
+			return KeyBindings{}, fmt.Errorf("key %q is reserved for quitting", value)
+		}
+		if key == skipKey {
+			return KeyBindings{}, fmt.Errorf("key %q is reserved for skipping", value)
+		}
+		if key == mnemonicsKey {
+			return KeyBindings{}, fmt.Errorf("key %q is reserved for the mnemonics list", value)
+		}
+
+		for k, a := range bindings.keys {
+			if a == action {
+				delete(bindings.keys, k)
+			}
+		}
+		bindings.keys[key] = action
+		bindings.promptKeys[action] = key
+	}
+
+	// Every action must still be reachable by exactly the key shown for it
+	for _, action := range actionOrder {
+		key := bindings.promptKeys[action]
+		if bindings.keys[key] != action {
+			return KeyBindings{}, fmt.Errorf("key %q is bound to more than one action", string(key))
+		}
+	}
+
+	return bindings, nil
+}
+
+// Lookup returns the action bound to a key.
+func (kb KeyBindings) Lookup(key rune) (rune, bool) {
+	action, exists := kb.keys[unicode.ToUpper(key)]
+	return action, exists
+}
+
+// Prompt returns the action prompt describing the bound keys.
+func (kb KeyBindings) Prompt() string {
+	if kb.classic {
+		return "(H)it, (S)tand, (D)ouble, s(P)lit: "
+	}
+
+	parts := make([]string, len(actionOrder))
+	for i, action := range actionOrder {
+		name := strings.Title(strings.ToLower(actionWord(action)))
+		parts[i] = fmt.Sprintf("%s [%c]", name, unicode.ToLower(kb.promptKeys[action]))
+	}
+	return strings.Join(parts, ", ") + ": "
+}
+
+// Help returns one line per action listing every key bound to it, the
+// prompted key first.
+func (kb KeyBindings) Help() []string {
+	lines := make([]string, len(actionOrder))
+	for i, action := range actionOrder {
+		keys := []string{string(unicode.ToLower(kb.promptKeys[action]))}
+		var others []string
+		for key, a := range kb.keys {
+			if a == action && key != kb.promptKeys[action] {
+				others = append(others, string(unicode.ToLower(key)))
+			}
+		}
+		sort.Strings(others)
+		name := strings.Title(strings.ToLower(actionWord(action)))
+		lines[i] = fmt.Sprintf("%-8s %s", name, strings.Join(append(keys, others...), ", "))
+	}
+	return lines
+}
+
+// actionWord returns the configuration name of an action code.
+func actionWord(action rune) string {
+	for name, a := range actionNames {
+		if a == action {
+			return name
+		}
+	}
+	return "unknown"
+}
+
+// actionAliases are common shorthands accepted as answers.
+var actionAliases = map[string]rune{
+	"dd":  'D',
+	"dbl": 'D',
+	"sp":  'Y',
+	"spl": 'Y',
+}
+
+// surrender is recognized as an answer word only to explain that the charts
+// don't offer it.
+const surrender = "surrender"
+
+// answerWords lists the words an answer can be matched against, in prompt
+// order.
+var answerWords = []string{"hit", "stand", "double", "split", surrender}
+
+// Parse returns the action for an answer. A single character is looked up
+// as a key; longer input may be a whole action word, an alias such as "dd"
+// or "sp", an unambiguous prefix such as "sta", or a word with one typo such
+// as "stnad". Ambiguous and unknown input is rejected with a message saying
+// what to type instead.
+func (kb KeyBindings) Parse(input string) (rune, error) {
+	input = strings.ToLower(strings.TrimSpace(input))
+	if input == "" {
+		return 0, fmt.Errorf("no answer given")
+	}
+
+	if utf8.RuneCountInString(input) == 1 {
+		key, _ := utf8.DecodeRuneInString(input)
+		if action, ok := kb.Lookup(key); ok {
+			return action, nil
+		}
+		// An unbound letter may still start an action word
+		if len(prefixMatches(input)) == 0 {
+			return 0, fmt.Errorf("Unrecognized key '%c'.", key)
+		}
+	}
+
+	if action, ok := actionAliases[input]; ok {
+		return action, nil
+	}
+
+	word, err := matchWord(input)
+	if err != nil {
+		return 0, err
+	}
+	return wordAction(word)
+}
+
+// prefixMatches returns the answer words that start with input.
+func prefixMatches(input string) []string {
+	var matches []string
+	for _, word := range answerWords {
+		if strings.HasPrefix(word, input) {
+			matches = append(matches, word)
+		}
+	}
+	return matches
+}
+
+// matchWord returns the answer word input names: the word itself, the only
+// word it is a prefix of, or the only word it is one typo away from.
+// Typos are only forgiven in input of three or more letters, where they
+// can't turn one short prefix into another.
+func matchWord(input string) (string, error) {
+	prefixed := prefixMatches(input)
+	for _, word := range prefixed {
+		if word == input {
+			return word, nil
+		}
+	}
+	switch {
+	case len(prefixed) == 1:
+		return prefixed[0], nil
+	case len(prefixed) > 1:
+		return "", fmt.Errorf("%q could mean %s. Type more letters.", input, joinOr(prefixed))
+	}
+
+	if utf8.RuneCountInString(input) >= 3 {
+		var near []string
+		for _, word := range answerWords {
+			if editDistance(input, word) <= 1 {
+				near = append(near, word)
+			}
+		}
+		switch {
+		case len(near) == 1:
+			return near[0], nil
+		case len(near) > 1:
+			return "", fmt.Errorf("%q could mean %s. Type more letters.", input, joinOr(near))
+		}
+	}
+	return "", fmt.Errorf("Unrecognized answer %q. Type a key or one of: %s.", input, joinOr(answerWords[:len(actionOrder)]))
+}
+
+// wordAction returns the action for a matched answer word.
+func wordAction(word string) (rune, error) {
+	if word == surrender {
+		return 0, fmt.Errorf("Surrender isn't offered here: the charts assume no surrender. Choose %s.", joinOr(answerWords[:len(actionOrder)]))
+	}
+	return actionNames[word], nil
+}
+
+// joinOr lists words as "a, b or c".
+func joinOr(words []string) string {
+	if len(words) < 2 {
+		return strings.Join(words, "")
+	}
+	return strings.Join(words[:len(words)-1], ", ") + " or " + words[len(words)-1]
+}
+
+// editDistance returns the number of single-character insertions,
+// deletions, substitutions and adjacent swaps that turn a into b.
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	d := make([][]int, len(s)+1)
+	for i := range d {
+		d[i] = make([]int, len(t)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			best := d[i-1][j] + 1
+			if v := d[i][j-1] + 1; v < best {
+				best = v
+			}
+			if v := d[i-1][j-1] + cost; v < best {
+				best = v
+			}
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				if v := d[i-2][j-2] + 1; v < best {
+					best = v
+				}
+			}
+			d[i][j] = best
+		}
+	}
+	return d[len(s)][len(t)]
+}
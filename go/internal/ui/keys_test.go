@@ -0,0 +1,184 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+// Test the default bindings accept the classic letters
+func TestDefaultKeyBindings(t *testing.T) {
+	bindings := DefaultKeyBindings()
+
+	tests := map[rune]rune{'h': 'H', 'S': 'S', 'd': 'D', 'p': 'Y', 'y': 'Y'}
+	for key, expected := range tests {
+		if action, ok := bindings.Lookup(key); !ok || action != expected {
+			t.Errorf("Key %c: expected %c, got %c (ok=%v)", key, expected, action, ok)
+		}
+	}
+
+	if _, ok := bindings.Lookup('1'); ok {
+		t.Error("Default bindings should not accept number keys")
+	}
+	if prompt := bindings.Prompt(); prompt != "(H)it, (S)tand, (D)ouble, s(P)lit: " {
+		t.Errorf("Unexpected default prompt: %q", prompt)
+	}
+}
+
+// Test preset schemes and prompts
+func TestKeySchemes(t *testing.T) {
+	numbers, err := NewKeyBindings("numbers", nil)
+	if err != nil {
+		t.Fatalf("numbers scheme: %v", err)
+	}
+	if action, ok := numbers.Lookup('4'); !ok || action != 'Y' {
+		t.Errorf("Key 4 should split in numbers scheme, got %c", action)
+	}
+	if prompt := numbers.Prompt(); prompt != "Hit [1], Stand [2], Double [3], Split [4]: " {
+		t.Errorf("Unexpected numbers prompt: %q", prompt)
+	}
+
+	vim, err := NewKeyBindings("VIM", nil)
+	if err != nil {
+		t.Fatalf("vim scheme: %v", err)
+	}
+	if action, ok := vim.Lookup('j'); !ok || action != 'S' {
+		t.Errorf("Key j should stand in vim scheme, got %c", action)
+	}
+
+	if _, err := NewKeyBindings("dvorak", nil); err == nil {
+		t.Error("Expected error for unknown scheme")
+	}
+}
+
+// Test overrides replace scheme keys and reject conflicts
+func TestKeyBindingOverrides(t *testing.T) {
+	bindings, err := NewKeyBindings("numbers", map[string]string{"split": "0"})
+	if err != nil {
+		t.Fatalf("Override failed: %v", err)
+	}
+	if action, ok := bindings.Lookup('0'); !ok || action != 'Y' {
+		t.Errorf("Key 0 should split, got %c", action)
+	}
+	if _, ok := bindings.Lookup('4'); ok {
+		t.Error("Overridden key 4 should no longer be bound")
+	}
+
+	invalid := []map[string]string{
+		{"stand": "h"},  // conflicts with hit
+		{"hit": "q"},    // reserved
+		{"stand": "n"},  // reserved
+		{"double": "m"}, // reserved
+		{"hit": "hh"},   // not a single character
+		{"hit": ""},     // empty key
+		{"insure": "i"}, // unknown action
+	}
+	for _, overrides := range invalid {
+		if _, err := NewKeyBindings("", overrides); err == nil {
+			t.Errorf("Expected error for overrides %v", overrides)
+		}
+	}
+}
+
+// Test the help lines list every key bound to each action
+func TestKeyBindingsHelp(t *testing.T) {
+	want := []string{"Hit      h", "Stand    s", "Double   d", "Split    p, y"}
+	if got := DefaultKeyBindings().Help(); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Default help = %q, want %q", got, want)
+	}
+
+	custom, err := NewKeyBindings("vim", map[string]string{"split": "x"})
+	if err != nil {
+		t.Fatalf("custom bindings: %v", err)
+	}
+	if got := custom.Help()[3]; got != "Split    x" {
+		t.Errorf("Custom split help = %q, want %q", got, "Split    x")
+	}
+}
+
+// Test help inputs are recognized in any case
+func TestIsHelp(t *testing.T) {
+	for _, input := range []string{"h?", "H?", "?", "help", "HELP"} {
+		if !isHelp(input) {
+			t.Errorf("isHelp(%q) = false", input)
+		}
+	}
+	for _, input := range []string{"h", "hit", "", "q"} {
+		if isHelp(input) {
+			t.Errorf("isHelp(%q) = true", input)
+		}
+	}
+}
+
+// Test answers given as words, aliases, prefixes and typos
+func TestParseAnswer(t *testing.T) {
+	letters := DefaultKeyBindings()
+	vim, _ := NewKeyBindings("vim", nil)
+
+	tests := []struct {
+		bindings KeyBindings
+		input    string
+		expected rune
+	}{
+		{letters, "h", 'H'},
+		{letters, "P", 'Y'},
+		{letters, "hit", 'H'},
+		{letters, "Stand", 'S'},
+		{letters, " double ", 'D'},
+		{letters, "split", 'Y'},
+		{letters, "dd", 'D'},
+		{letters, "sp", 'Y'},
+		{letters, "sta", 'S'},
+		{letters, "doub", 'D'},
+		{letters, "stnad", 'S'},
+		{letters, "doubel", 'D'},
+		{letters, "spilt", 'Y'},
+		{vim, "j", 'S'},
+		{vim, "d", 'D'},
+		{vim, "stand", 'S'},
+	}
+	for _, tt := range tests {
+		if action, err := tt.bindings.Parse(tt.input); err != nil || action != tt.expected {
+			t.Errorf("Parse(%q): expected %c, got %c (err=%v)", tt.input, tt.expected, action, err)
+		}
+	}
+
+	rejected := []struct {
+		bindings KeyBindings
+		input    string
+		message  string
+	}{
+		{letters, "x", "Unrecognized key 'x'"},
+		{letters, "hx", "Unrecognized answer"},
+		{letters, "banana", "Unrecognized answer"},
+		{vim, "s", "stand, split or surrender"},
+		{letters, "surrender", "Surrender isn't offered"},
+		{letters, "su", "Surrender isn't offered"},
+		{letters, "", "no answer"},
+	}
+	for _, tt := range rejected {
+		_, err := tt.bindings.Parse(tt.input)
+		if err == nil || !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("Parse(%q): expected error containing %q, got %v", tt.input, tt.message, err)
+		}
+	}
+}
+
+// Test the edit distance counts adjacent swaps as one edit
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"stand", "stand", 0},
+		{"stnad", "stand", 1},
+		{"hot", "hit", 1},
+		{"dobule", "double", 1},
+		{"stan", "stand", 1},
+		{"split", "stand", 4},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.expected {
+			t.Errorf("editDistance(%q, %q) = %d, expected %d", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
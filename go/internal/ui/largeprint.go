@@ -0,0 +1,125 @@
+package ui
+
+import (
+	"blackjack_trainer/internal/hand"
+	"blackjack_trainer/internal/strategy"
+	"fmt"
+	"strings"
+)
+
+// largePrint shows hands in large ASCII-art characters with high-contrast
+// labels, for players with low vision.
+var largePrint bool
+
+// SetLargePrint enables or disables the large-print display.
+func SetLargePrint(enabled bool) {
+	largePrint = enabled
+}
+
+// boldReverse starts the high-contrast style of large-print labels.
+const boldReverse = "\x1b[1;7m"
+
+// largeWidth is the widest a line of large characters is drawn before the
+// cards wrap onto another line.
+const largeWidth = 78
+
+// largeGlyphs is a 5x5 font for card ranks. Each pixel is drawn two
+// characters wide so the strokes stay thick on a terminal.
+var largeGlyphs = map[rune][5]string{
+	'0': {".###.", "#...#", "#...#", "#...#", ".###."},
+	'1': {"..#..", ".##..", "..#..", "..#..", ".###."},
+	'2': {"####.", "....#", ".###.", "#....", "#####"},
+	'3': {"####.", "....#", ".###.", "....#", "####."},
+	'4': {"#...#", "#...#", "#####", "....#", "....#"},
+	'5': {"#####", "#....", "####.", "....#", "####."},
+	'6': {".###.", "#....", "####.", "#...#", ".###."},
+	'7': {"#####", "....#", "...#.", "..#..", "..#.."},
+	'8': {".###.", "#...#", ".###.", "#...#", ".###."},
+	'9': {".###.", "#...#", ".####", "....#", ".###."},
+	'A': {".###.", "#...#", "#####", "#...#", "#...#"},
+}
+
+// largeText draws words (card ranks) in large characters, with wide gaps
+// between words and narrow ones between a word's characters. Words that
+// would run past largeWidth wrap to another block of lines, separated by a
+// blank line.
+func largeText(words []string) []string {
+	const letterGap, wordGap = "  ", "      "
+	var lines []string
+	var block [5]string
+	empty := true
+	flush := func() {
+		if empty {
+			return
+		}
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		for _, row := range block {
+			lines = append(lines, strings.TrimRight(row, " "))
+		}
+		block, empty = [5]string{}, true
+	}
+
+	for _, word := range words {
+		var drawn [5]string
+		for i, r := range word {
+			glyph := largeGlyphs[r]
+			for row := range drawn {
+				if i > 0 {
+					drawn[row] += letterGap
+				}
+				pixels := glyph[row]
+				if pixels == "" {
+					pixels = "....."
+				}
+				drawn[row] += strings.NewReplacer(".", "  ", "#", "##").Replace(pixels)
+			}
+		}
+		if !empty && len(block[0])+len(wordGap)+len(drawn[0]) > largeWidth {
+			flush()
+		}
+		for row := range block {
+			if !empty {
+				block[row] += wordGap
+			}
+			block[row] += drawn[row]
+		}
+		empty = false
+	}
+	flush()
+	return lines
+}
+
+// highContrast formats a label in bold reverse video on a terminal, and as
+// plain capitals elsewhere, such as when output is piped or scripted.
+func highContrast(label string) string {
+	label = strings.ToUpper(label)
+	if !styledOutput() {
+		return label
+	}
+	return boldReverse + " " + label + " " + resetStyle
+}
+
+// displayLargeHand shows the dealer's card and the player's hand in large
+// print.
+func displayLargeHand(playerHand hand.Hand, dealerCard int) {
+	fmt.Fprintf(out, "\n%s\n\n", highContrast("Dealer shows"))
+	for _, line := range largeText([]string{strategy.CardToString(dealerCard)}) {
+		fmt.Fprintln(out, line)
+	}
+
+	handType, value := strategy.Classify(playerHand)
+	desc := fmt.Sprintf("%s %d", handType, value)
+	if handType == strategy.HandTypePair {
+		desc = fmt.Sprintf("%s %s", handType, strategy.CardToString(value))
+	}
+	cards := make([]string, len(playerHand.Cards))
+	for i, card := range playerHand.Cards {
+		cards[i] = strategy.CardToString(card)
+	}
+	fmt.Fprintf(out, "\n%s\n\n", highContrast("Your hand: "+desc))
+	for _, line := range largeText(cards) {
+		fmt.Fprintln(out, line)
+	}
+}
@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"blackjack_trainer/internal/hand"
+	"os"
+	"strings"
+	"testing"
+)
+
+// Test large characters are drawn two columns per pixel and wrap before the line is too wide
+func TestLargeText(t *testing.T) {
+	lines := largeText([]string{"A"})
+	want := []string{"  ######", "##      ##", "##########", "##      ##", "##      ##"}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Large A:\n%s", strings.Join(lines, "\n"))
+	}
+
+	lines = largeText([]string{"10", "10", "10", "10"})
+	if len(lines) != 11 || lines[5] != "" {
+		t.Errorf("Four tens should wrap into two blocks, got %d lines:\n%s", len(lines), strings.Join(lines, "\n"))
+	}
+	for _, line := range lines {
+		if len(line) > largeWidth {
+			t.Errorf("Line wider than %d: %q", largeWidth, line)
+		}
+	}
+}
+
+// Test the large-print hand has plain labels when output isn't a terminal
+func TestDisplayLargeHand(t *testing.T) {
+	var b strings.Builder
+	SetIO(strings.NewReader(""), &b)
+	defer SetIO(os.Stdin, os.Stdout)
+	SetLargePrint(true)
+	defer SetLargePrint(false)
+
+	DisplayHand(hand.New(8, 8), hand.Ace)
+	got := b.String()
+	for _, want := range []string{"DEALER SHOWS", "YOUR HAND: PAIR 8", "  ######          ######"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Large-print hand missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "\x1b") {
+		t.Errorf("Escape sequences written to a non-terminal:\n%q", got)
+	}
+}
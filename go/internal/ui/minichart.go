@@ -0,0 +1,75 @@
+package ui
+
+import (
+	"blackjack_trainer/internal/stats"
+	"blackjack_trainer/internal/strategy"
+	"fmt"
+	"strings"
+)
+
+// Background colors of session chart cells on a terminal.
+const (
+	cellRight = "\x1b[30;42m" // black on green
+	cellMixed = "\x1b[30;43m" // black on yellow
+	cellWrong = "\x1b[97;41m" // white on red
+)
+
+// OfferSessionChart offers to show a session's hands on a mini chart after
+// the report card, and shows it if asked.
+func OfferSessionChart(sections []stats.ChartSection) {
+	if len(sections) == 0 {
+		return
+	}
+	input, err := Prompt("\nSee this session's hands on the chart? ('c' + Enter, or Enter to continue): ")
+	if err != nil || !strings.EqualFold(input, "c") {
+		return
+	}
+	DisplaySessionChart(sections)
+}
+
+// DisplaySessionChart shows the rows of the chart a session asked about,
+// each asked cell marked by how it was answered: in color on a terminal,
+// otherwise with a mark before the chart's play.
+func DisplaySessionChart(sections []stats.ChartSection) {
+	styled := styledOutput()
+	fmt.Fprintln(out, "\nThis Session on the Chart")
+	if styled {
+		fmt.Fprintf(out, "%s all right %s  %s some wrong %s  %s all wrong %s  . not asked\n",
+			cellRight, resetStyle, cellMixed, resetStyle, cellWrong, resetStyle)
+	} else {
+		fmt.Fprintln(out, "+ all right   ~ some wrong   ! all wrong   . not asked")
+	}
+
+	for _, section := range sections {
+		fmt.Fprintf(out, "\n%s\n%-5s", section.Title, "")
+		for dealer := 2; dealer <= 11; dealer++ {
+			fmt.Fprintf(out, " %2s", strategy.CardToString(dealer))
+		}
+		fmt.Fprintln(out)
+		for _, row := range section.Rows {
+			fmt.Fprintf(out, "%-5s", row.Label)
+			for _, cell := range row.Cells {
+				fmt.Fprint(out, " "+chartCellText(cell, styled))
+			}
+			fmt.Fprintln(out)
+		}
+	}
+}
+
+// chartCellText formats a session chart cell two characters wide.
+func chartCellText(cell stats.ChartCell, styled bool) string {
+	if cell.Total == 0 {
+		return " ."
+	}
+	style, mark := cellMixed, "~"
+	switch cell.Correct {
+	case cell.Total:
+		style, mark = cellRight, "+"
+	case 0:
+		style, mark = cellWrong, "!"
+	}
+	if styled {
+		return style + " " + string(cell.Action) + resetStyle
+	}
+	return mark + string(cell.Action)
+}
@@ -0,0 +1,135 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Status is the live progress shown at the top of each question.
+type Status struct {
+	// Question is the number of the question about to be asked, from 1.
+	Question int
+	// Total is the number of questions in the session, or zero when the
+	// session has a time limit instead.
+	Total int
+	// TimeLeft describes the time left in a timed session, which may also
+	// have a number of questions.
+	TimeLeft string
+	// Skips describes how many questions may still be skipped, if the
+	// session allows skipping.
+	Skips   string
+	Correct int
+	Streak  int
+	Mode    string
+	Rules   string
+}
+
+// String formats the status as a single line.
+func (s Status) String() string {
+	question := fmt.Sprintf("Question %d/%d", s.Question, s.Total)
+	if s.Total == 0 {
+		question = fmt.Sprintf("Question %d", s.Question)
+	}
+	if s.TimeLeft != "" {
+		question += ", " + s.TimeLeft
+	}
+	parts := []string{
+		question,
+		fmt.Sprintf("%d correct", s.Correct),
+		fmt.Sprintf("streak %d", s.Streak),
+	}
+	if s.Skips != "" {
+		parts = append(parts, s.Skips)
+	}
+	return strings.Join(append(parts, s.Mode, s.Rules), " | ")
+}
+
+// ANSI escape sequences used to pin the status line to the top row.
+const (
+	saveCursor    = "\x1b7"
+	restoreCursor = "\x1b8"
+	clearLine     = "\x1b[2K"
+	reverseVideo  = "\x1b[7m"
+	resetStyle    = "\x1b[0m"
+	resetRegion   = "\x1b[r"
+)
+
+// pinned reports whether the status line is drawn on the terminal's top
+// row, with the rest of the screen scrolling beneath it.
+var pinned bool
+
+// DisplayStatus shows the session status. On a terminal the status is
+// drawn on the top row, which the session's output scrolls beneath;
+// otherwise, as when output is piped or scripted, it is printed as a line
+// above the question.
+func DisplayStatus(s Status) {
+	rows, ok := terminalRows()
+	if !ok {
+		fmt.Fprintf(out, "\n[%s]\n", s)
+		return
+	}
+
+	if !pinned {
+		// Move off the top row before it is taken over
+		fmt.Fprintln(out)
+		pinned = true
+	}
+	// Setting the scroll region homes the cursor, so it is saved first
+	fmt.Fprint(out, saveCursor)
+	fmt.Fprintf(out, "\x1b[2;%dr", rows)
+	fmt.Fprintf(out, "\x1b[1;1H%s%s%s%s", clearLine, reverseVideo, s, resetStyle)
+	fmt.Fprint(out, restoreCursor)
+}
+
+// ClearStatus removes a pinned status line and gives the whole screen back
+// to scrolling output.
+func ClearStatus() {
+	if !pinned {
+		return
+	}
+	pinned = false
+	fmt.Fprint(out, saveCursor+resetRegion+"\x1b[1;1H"+clearLine+restoreCursor)
+}
+
+// Styled reports whether standard output is a terminal that understands
+// ANSI escape sequences, for commands that draw their own screens.
+func Styled() bool {
+	return styledOutput()
+}
+
+// styledOutput reports whether output goes to a terminal that understands
+// ANSI escape sequences.
+func styledOutput() bool {
+	if out != os.Stdout || os.Getenv("TERM") == "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// terminalRows returns the height of the terminal when output goes to one
+// that understands cursor control.
+func terminalRows() (int, bool) {
+	if !styledOutput() {
+		return 0, false
+	}
+
+	cmd := exec.Command("stty", "size")
+	cmd.Stdin = os.Stdin
+	size, err := cmd.Output()
+	if err != nil {
+		return 0, false
+	}
+	fields := strings.Fields(string(size))
+	if len(fields) != 2 {
+		return 0, false
+	}
+	rows, err := strconv.Atoi(fields[0])
+	if err != nil || rows < 3 {
+		return 0, false
+	}
+	return rows, true
+}
@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// Test status lines for counted, timed, and timed and counted sessions
+func TestStatusString(t *testing.T) {
+	tests := []struct {
+		status Status
+		want   string
+	}{
+		{
+			Status{Question: 12, Total: 50, Correct: 10, Streak: 4, Mode: "random", Rules: "Standard"},
+			"Question 12/50 | 10 correct | streak 4 | random | Standard",
+		},
+		{
+			Status{Question: 3, TimeLeft: "4m left", Correct: 2, Mode: "absolutes", Rules: "European"},
+			"Question 3, 4m left | 2 correct | streak 0 | absolutes | European",
+		},
+		{
+			Status{Question: 5, Total: 20, TimeLeft: "9m 30s left", Correct: 4, Streak: 4, Mode: "quiz", Rules: "Standard"},
+			"Question 5/20, 9m 30s left | 4 correct | streak 4 | quiz | Standard",
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.status.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+// Test that the status is printed as a plain line when output is not a terminal
+func TestDisplayStatusPlain(t *testing.T) {
+	var b strings.Builder
+	SetIO(strings.NewReader(""), &b)
+	defer SetIO(os.Stdin, os.Stdout)
+
+	DisplayStatus(Status{Question: 1, Total: 20, Mode: "absolutes", Rules: "Standard"})
+	ClearStatus()
+	want := "\n[Question 1/20 | 0 correct | streak 0 | absolutes | Standard]\n"
+	if b.String() != want {
+		t.Errorf("DisplayStatus wrote %q, want %q", b.String(), want)
+	}
+}
@@ -6,36 +6,385 @@
 // - User action input with validation
 // - Feedback display with explanations
 // - Session headers and progress indicators
+// - Optional spoken announcements of scenarios and results
+// - Optional scenario images for streaming overlays
+// - Notes from a coach watching the session, on the feedback screen
+// - Help on request ("h?" or "help") at every prompt
 package ui
 
 import (
+	"blackjack_trainer/internal/hand"
+	"blackjack_trainer/internal/history"
+	"blackjack_trainer/internal/lessons"
+	"blackjack_trainer/internal/overlay"
+	"blackjack_trainer/internal/sound"
+	"blackjack_trainer/internal/speech"
 	"blackjack_trainer/internal/strategy"
 	"bufio"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
+	"os/exec"
 	"strconv"
 	"strings"
+	"sync"
 )
 
-// DisplayMenu displays the main menu and gets user choice.
+// in and out are the streams the trainer reads answers from and writes
+// to: the terminal unless SetIO replaces them, e.g. to drive a session from
+// a script. All input goes through one buffered reader so that lines read
+// ahead are never lost between prompts.
+var (
+	in            = bufio.NewReader(os.Stdin)
+	out io.Writer = os.Stdout
+)
+
+// SetIO sets the streams used for all input and output.
+func SetIO(r io.Reader, w io.Writer) {
+	in = bufio.NewReader(r)
+	out = w
+}
+
+// Input returns the reader used for input, for prompts outside this package.
+func Input() *bufio.Reader {
+	return in
+}
+
+// Output returns the writer used for output, for text outside this package.
+func Output() io.Writer {
+	return out
+}
+
+// Prompt writes a prompt and reads a line of input, trimmed of surrounding
+// space. Entering "h?", "?" or "help" shows the help screen and asks again,
+// so every prompt offers help.
+func Prompt(prompt string) (string, error) {
+	for {
+		fmt.Fprint(out, prompt)
+		stop := followCoachNotes(prompt)
+		input, err := in.ReadString('\n')
+		stop()
+		input = strings.TrimSpace(input)
+		if err != nil {
+			return input, err
+		}
+		if isMnemonics(input) && len(mnemonics.list) > 0 {
+			ShowMnemonics()
+			continue
+		}
+		if !isHelp(input) {
+			return input, nil
+		}
+		ShowHelp()
+	}
+}
+
+// isMnemonics reports whether input asks for the mnemonics list.
+func isMnemonics(input string) bool {
+	return strings.EqualFold(input, string(mnemonicsKey)) || strings.EqualFold(input, "mnemonics")
+}
+
+// mnemonics holds the mnemonics list offered during a session, and whether
+// it was shown since the last call to TakeHint.
+var mnemonics struct {
+	list  []string
+	shown bool
+}
+
+// SetMnemonics sets the mnemonics shown when "m" is entered at a prompt;
+// nil, as when a session ends, stops offering them.
+func SetMnemonics(list []string) {
+	mnemonics.list = list
+	mnemonics.shown = false
+}
+
+// ShowMnemonics displays the mnemonics list, counting it as a hint.
+func ShowMnemonics() {
+	fmt.Fprintln(out, "\nMnemonics")
+	for _, text := range mnemonics.list {
+		fmt.Fprintf(out, "  - %s\n", text)
+	}
+	mnemonics.shown = true
+}
+
+// TakeHint reports whether the mnemonics list was shown since the last
+// call, and starts counting afresh.
+func TakeHint() bool {
+	shown := mnemonics.shown
+	mnemonics.shown = false
+	return shown
+}
+
+// isHelp reports whether input asks for help.
+func isHelp(input string) bool {
+	switch strings.ToLower(input) {
+	case "h?", "?", "help":
+		return true
+	}
+	return false
+}
+
+// help holds what the help screen shows about the session in progress.
+var help struct {
+	mode      string
+	rules     string
+	remaining string
+}
+
+// SetSessionHelp sets the description of the practice mode and the table
+// rules shown by help; empty strings, as when a session ends, omit them.
+func SetSessionHelp(mode, rules string) {
+	help.mode = mode
+	help.rules = rules
+	help.remaining = ""
+}
+
+// SetRemaining sets how much of the session is left, as shown by help.
+func SetRemaining(remaining string) {
+	help.remaining = remaining
+}
+
+// ShowHelp displays the keys for every action and, during a session, the
+// practice mode, the table rules and how much of the session is left.
+func ShowHelp() {
+	fmt.Fprintln(out, "\nHelp")
+	fmt.Fprintln(out, "Keys:")
+	for _, line := range bindings.Help() {
+		fmt.Fprintf(out, "  %s\n", line)
+	}
+	fmt.Fprintln(out, "  Words    hit, stand, double, split, or a short form like sta, dd, sp")
+	fmt.Fprintln(out, "  Skip     n (when the session allows skips)")
+	fmt.Fprintln(out, "  Quit     q (at any prompt)")
+	fmt.Fprintln(out, "  Help     h?, ? or help (at any prompt)")
+	if len(mnemonics.list) > 0 {
+		fmt.Fprintln(out, "  Mnemonics m (at any prompt; counts as a hint)")
+	}
+	if help.mode != "" {
+		fmt.Fprintln(out, "  Tag      t and a name after an answer, e.g. t confusing")
+	}
+	if help.mode != "" {
+		fmt.Fprintf(out, "Mode: %s\n", help.mode)
+	}
+	if help.rules != "" {
+		fmt.Fprintf(out, "Rules: %s\n", help.rules)
+	}
+	if help.remaining != "" {
+		fmt.Fprintf(out, "Remaining: %s\n", help.remaining)
+	}
+}
+
+// bindings maps input keys to actions.
+var bindings = DefaultKeyBindings()
+
+// SetKeyBindings sets the key bindings used for action input.
+func SetKeyBindings(kb KeyBindings) {
+	bindings = kb
+}
+
+// speaker reads scenarios and results aloud when set.
+var speaker speech.Speaker
+
+// SetSpeaker enables spoken announcements using the given speaker.
+// Pass nil to disable speech.
+func SetSpeaker(s speech.Speaker) {
+	speaker = s
+}
+
+// speak announces text if speech is enabled, ignoring playback errors.
+func speak(text string) {
+	if speaker != nil {
+		speaker.Speak(text)
+	}
+}
+
+// cues plays an audio cue for each answer when beeper is set, with a
+// milestone every streakEvery correct answers in a row.
+var cues struct {
+	beeper      sound.Beeper
+	streakEvery int
+}
+
+// SetBeeper enables audio cues for answers using the given beeper, with a
+// streak milestone every streakEvery correct answers (none if zero). Pass
+// nil to disable cues.
+func SetBeeper(b sound.Beeper, streakEvery int) {
+	cues.beeper, cues.streakEvery = b, streakEvery
+}
+
+// PlayAnswerCue plays the cue for an answer if cues are enabled; streak is
+// the run of correct answers including this one. Playback errors are logged.
+func PlayAnswerCue(correct bool, streak int) {
+	if cues.beeper == nil {
+		return
+	}
+	if err := cues.beeper.Beep(sound.AnswerCue(correct, streak, cues.streakEvery)); err != nil {
+		slog.Debug("playing audio cue", "err", err)
+	}
+}
+
+// scenarioOverlay publishes an image of each scenario when set.
+var scenarioOverlay overlay.Overlay
+
+// SetOverlay publishes an image of each scenario, for streaming the
+// current question. Pass nil to stop.
+func SetOverlay(o overlay.Overlay) {
+	scenarioOverlay = o
+}
+
+// coach shows notes sent by a coach watching the session on the feedback
+// screen: those waiting when it's shown, and while its prompt waits for
+// input, those that arrive.
+var coach struct {
+	notes <-chan string
+	// live is set while the feedback screen is shown, and arrived holds the
+	// notes shown as they arrived
+	live    bool
+	arrived []string
+}
+
+// SetCoachNotes shows each note received on the feedback screen of the
+// question it was sent during, or the next one shown. Pass nil to stop.
+func SetCoachNotes(notes <-chan string) {
+	coach.notes = notes
+}
+
+// showCoachNote prints a note from the coach.
+func showCoachNote(note string) {
+	fmt.Fprintf(out, "\nCoach: %s\n", note)
+}
+
+// takeCoachNotes shows the notes waiting and returns them.
+func takeCoachNotes() []string {
+	var notes []string
+	for {
+		select {
+		case note := <-coach.notes:
+			showCoachNote(note)
+			notes = append(notes, note)
+		default:
+			return notes
+		}
+	}
+}
+
+// followCoachNotes shows notes as they arrive while the feedback prompt
+// waits for input, adding them to coach.arrived, until the returned
+// function is called.
+func followCoachNotes(prompt string) (stop func()) {
+	if !coach.live || coach.notes == nil {
+		return func() {}
+	}
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case note := <-coach.notes:
+				showCoachNote(note)
+				fmt.Fprint(out, prompt)
+				coach.arrived = append(coach.arrived, note)
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+// signals controls showing the hand signal for the correct action in
+// feedback, for players preparing to play at a live table.
+var signals struct {
+	enabled  bool
+	handHeld bool
+}
+
+// SetHandSignals enables or disables hand signals in feedback. handHeld
+// selects the signals for hand-held (pitch) games instead of shoe games.
+func SetHandSignals(enabled, handHeld bool) {
+	signals.enabled = enabled
+	signals.handHeld = handHeld
+}
+
+// ratings offers to rate each question's difficulty at the feedback prompt.
+var ratings bool
+
+// ratingNames names the difficulty ratings, from 1.
+var ratingNames = []string{"easy", "medium", "hard"}
+
+// SetRatings enables or disables rating questions' difficulty in feedback.
+func SetRatings(enabled bool) {
+	ratings = enabled
+}
+
+// planStatus describes progress through the practice plan being followed,
+// shown as a menu choice; empty when there is no plan.
+var planStatus string
+
+// PlanChoice is the menu choice returned for the practice plan entry.
+const PlanChoice = 0
+
+// Menu choices returned for the lettered quick drills.
+const (
+	DoublesChoice = 10
+	SplitsChoice  = 11
+	TenAceChoice  = 12
+)
+
+// SetPlanStatus sets the practice plan entry of the main menu, e.g.
+// "Two-Week Basics: Day 3 of 14 - Soft totals". Pass "" to remove it.
+func SetPlanStatus(status string) {
+	planStatus = status
+}
+
+// DisplayMenu displays the main menu and gets user choice. Choosing the
+// practice plan entry, or just pressing Enter when there is one, returns
+// PlanChoice.
 func DisplayMenu() (int, bool) {
-	fmt.Println("\nBlackjack Basic Strategy Trainer")
-	fmt.Println("1. Quick Practice (random)")
-	fmt.Println("2. Learn by Dealer Strength")
-	fmt.Println("3. Focus on Hand Types")
-	fmt.Println("4. Absolutes Drill")
-	fmt.Println("5. View Statistics")
-	fmt.Println("6. Quit")
-	fmt.Print("\nChoice (1-6): ")
-
-	reader := bufio.NewReader(os.Stdin)
-	input, err := reader.ReadString('\n')
+	fmt.Fprintln(out, "\nBlackjack Basic Strategy Trainer")
+	if planStatus != "" {
+		fmt.Fprintf(out, "P. Today's Plan Session (%s)\n", planStatus)
+	}
+	fmt.Fprintln(out, "1. Quick Practice (random)")
+	fmt.Fprintln(out, "2. Learn by Dealer Strength")
+	fmt.Fprintln(out, "3. Focus on Hand Types")
+	fmt.Fprintln(out, "4. Absolutes Drill")
+	fmt.Fprintln(out, "D. Only Doubles")
+	fmt.Fprintln(out, "S. Only Splits")
+	fmt.Fprintln(out, "T. Dealer 10 and Ace")
+	fmt.Fprintln(out, "5. View Statistics")
+	fmt.Fprintln(out, "6. Strategy Lessons")
+	fmt.Fprintln(out, "7. Rules Quiz")
+	fmt.Fprintln(out, "8. Table Rules")
+	fmt.Fprintln(out, "9. Quit")
+
+	choices := "1-9, D, S, T"
+	if planStatus != "" {
+		choices = "P, 1-9, D, S, T, Enter for P"
+	}
+	input, err := Prompt("\nChoice (" + choices + "): ")
 	if err != nil {
 		return 0, false
 	}
+	if planStatus != "" && (input == "" || strings.EqualFold(input, "p")) {
+		return PlanChoice, true
+	}
+	switch strings.ToLower(input) {
+	case "d":
+		return DoublesChoice, true
+	case "s":
+		return SplitsChoice, true
+	case "t":
+		return TenAceChoice, true
+	}
 
-	choice, err := strconv.Atoi(strings.TrimSpace(input))
-	if err != nil || choice < 1 || choice > 6 {
+	choice, err := strconv.Atoi(input)
+	if err != nil || choice < 1 || choice > 9 {
 		return 0, false
 	}
 
@@ -44,94 +393,384 @@ func DisplayMenu() (int, bool) {
 
 // DisplaySessionHeader displays session header with mode name.
 func DisplaySessionHeader(modeName string) {
-	fmt.Println("\n" + strings.Repeat("=", 40))
-	fmt.Printf("Training Mode: %s\n", modeName)
-	fmt.Println(strings.Repeat("=", 40))
-	fmt.Println("(Press 'q' + Enter to quit at any time)")
+	fmt.Fprintln(out, "\n"+strings.Repeat("=", 40))
+	fmt.Fprintf(out, "Training Mode: %s\n", modeName)
+	fmt.Fprintln(out, strings.Repeat("=", 40))
+	fmt.Fprintln(out, "(Press 'q' + Enter to quit at any time, 'h?' + Enter for help)")
 }
 
 // DisplayHand displays the current hand and dealer card.
-func DisplayHand(playerCards []int, dealerCard int, handType strategy.HandType, playerTotal int) {
-	fmt.Printf("\nDealer shows: %s\n", strategy.CardToString(dealerCard))
+func DisplayHand(playerHand hand.Hand, dealerCard int) {
+	if largePrint {
+		displayLargeHand(playerHand, dealerCard)
+	} else {
+		fmt.Fprintf(out, "\nDealer shows: %s\n", strategy.CardToString(dealerCard))
+
+		fmt.Fprintf(out, "Your hand: %s", playerHand)
 
-	fmt.Print("Your hand: ")
-	for i, card := range playerCards {
-		if i > 0 {
-			fmt.Print(", ")
+		handType, value := strategy.Classify(playerHand)
+		handDesc := strings.Title(handType.String())
+		if handType == strategy.HandTypePair {
+			fmt.Fprintf(out, " (%s %s)\n", handDesc, strategy.CardToString(value))
+		} else {
+			fmt.Fprintf(out, " (%s %d)\n", handDesc, value)
 		}
-		fmt.Print(strategy.CardToString(card))
 	}
 
-	handDesc := strings.Title(handType.String())
-	fmt.Printf(" (%s %d)\n", handDesc, playerTotal)
+	speak(speech.DescribeScenario(playerHand, dealerCard))
+	if scenarioOverlay != nil {
+		if err := scenarioOverlay.Show(playerHand, dealerCard); err != nil {
+			slog.Debug("showing scenario overlay failed", slog.Any("error", err))
+		}
+	}
 }
 
-// GetUserAction gets user's action choice.
+// GetUserAction gets user's action choice using the active key bindings or
+// an action word (see KeyBindings.Parse). Unrecognized and ambiguous answers
+// are rejected and the user is asked again.
 func GetUserAction() (rune, bool) {
-	fmt.Println("\nWhat's your move?")
-	fmt.Print("(H)it, (S)tand, (D)ouble, s(P)lit: ")
+	action, _, quit := GetUserActionOrSkip(-1)
+	return action, quit
+}
 
-	reader := bufio.NewReader(os.Stdin)
-	input, err := reader.ReadString('\n')
-	if err != nil {
-		return 0, true
+// GetUserActionOrSkip gets user's action choice like GetUserAction, and also
+// lets the question be skipped with n while skipsLeft is positive. A
+// negative skipsLeft means the question can't be skipped.
+func GetUserActionOrSkip(skipsLeft int) (action rune, skip, quit bool) {
+	fmt.Fprintln(out, "\nWhat's your move?")
+	if skipsLeft > 0 {
+		fmt.Fprintf(out, "(n to skip, %s)\n", SkipsLeft(skipsLeft))
 	}
 
-	input = strings.TrimSpace(input)
-	if len(input) == 0 {
-		return 0, true
-	}
+	for {
+		input, err := Prompt(bindings.Prompt())
+		if err != nil {
+			return 0, false, true
+		}
+
+		if len(input) == 0 {
+			return 0, false, true
+		}
+
+		// Check for quit
+		if strings.EqualFold(input, string(quitKey)) || strings.EqualFold(input, "quit") {
+			return 0, false, true
+		}
+
+		if skipsLeft >= 0 && (strings.EqualFold(input, string(skipKey)) || strings.EqualFold(input, "skip")) {
+			if skipsLeft > 0 {
+				return 0, true, false
+			}
+			fmt.Fprintln(out, "No skips left this session.")
+			continue
+		}
 
-	action := rune(strings.ToUpper(input)[0])
+		action, err := bindings.Parse(input)
+		if err == nil {
+			return action, false, false
+		}
+		fmt.Fprintln(out, err)
+	}
+}
 
-	// Check for quit
-	if action == 'Q' {
-		return 0, true
+// SkipsLeft describes how many questions may still be skipped, e.g.
+// "2 skips left".
+func SkipsLeft(n int) string {
+	if n == 1 {
+		return "1 skip left"
 	}
+	return fmt.Sprintf("%d skips left", n)
+}
 
-	return action, false
+// Feedback is what the player chose at the feedback prompt.
+type Feedback struct {
+	// Quit is set if the player wants to quit.
+	Quit bool
+	// LessonViewed is set if the related lesson was read.
+	LessonViewed bool
+	// Corrected is set if the answer was taken back as a slip.
+	Corrected bool
+	// Tags holds the tags the player gave the hand, normalized by
+	// history.NormalizeTag.
+	Tags []string
+	// Rating is how hard the player rated the question, from 1 (easy) to
+	// 3 (hard), or 0 if they didn't.
+	Rating int
+	// Notes are the coach's notes shown with the feedback.
+	Notes []string
 }
 
-// DisplayFeedback displays feedback after user's answer.
-// Returns true if user wants to quit.
-func DisplayFeedback(correct bool, userAction, correctAction rune, explanation string) bool {
+// DisplayFeedback displays feedback after user's answer. Details, such as
+// the odds behind the play, are shown whether the answer was right or
+// wrong. For incorrect answers mistake, if not empty, says what the answer
+// cost, the related
+// lesson, if any, is offered for reading, and when
+// canCorrect is set the player can take the answer back as a slip by
+// entering 'u'. When simulate is not nil, entering 'e' displays the result
+// of calling it, a simulation of the scenario under each action. Entering
+// 't' and a name tags the hand, for a drill of tagged hands later. When
+// ratings are enabled, entering 1, 2 or 3 rates how hard the question felt
+// and continues. Notes from a coach watching the session are shown, and
+// returned with the player's choices.
+func DisplayFeedback(correct bool, userAction, correctAction rune, mistake, explanation string, details []string,
+	lesson *lessons.Lesson, simulate func() string, canCorrect bool) (feedback Feedback) {
+	speak(speech.DescribeResult(correct, strategy.ActionToString(correctAction)))
+
 	if correct {
-		fmt.Println("\n✓ Correct!")
+		if largePrint {
+			fmt.Fprintf(out, "\n%s\n", highContrast("Correct!"))
+		} else {
+			fmt.Fprintln(out, "\n✓ Correct!")
+		}
+		lesson = nil
+		canCorrect = false
 	} else {
-		fmt.Println("\n❌ Incorrect!")
-		fmt.Printf("\nCorrect answer: %s\n", strategy.ActionToString(correctAction))
-		fmt.Printf("Your answer: %s\n", strategy.ActionToString(userAction))
-		fmt.Printf("\nPattern: %s\n", explanation)
+		if largePrint {
+			fmt.Fprintf(out, "\n%s\n", highContrast("Incorrect!"))
+			fmt.Fprintf(out, "\n%s\n", highContrast("Correct answer: "+strategy.ActionToString(correctAction)))
+		} else {
+			fmt.Fprintln(out, "\n❌ Incorrect!")
+			fmt.Fprintf(out, "\nCorrect answer: %s\n", strategy.ActionToString(correctAction))
+		}
+		fmt.Fprintf(out, "Your answer: %s\n", strategy.ActionToString(userAction))
+		if mistake != "" {
+			fmt.Fprintf(out, "Mistake: %s\n", mistake)
+		}
+		fmt.Fprintf(out, "\nPattern: %s\n", explanation)
+		if lesson != nil {
+			fmt.Fprintf(out, "Read lesson: %s ('l' + Enter)\n", lesson.Title)
+		}
+		fmt.Fprintln(out, "Tag this hand to drill it later ('t' + a name + Enter, e.g. t confusing)")
 	}
+	for _, detail := range details {
+		fmt.Fprintf(out, "Odds: %s\n", detail)
+	}
+	if signals.enabled {
+		fmt.Fprintf(out, "Signal: %s\n", strategy.HandSignal(correctAction, signals.handHeld))
+	}
+	if simulate != nil {
+		fmt.Fprintln(out, "Simulate the outcomes ('e' + Enter)")
+	}
+	if canCorrect {
+		fmt.Fprintln(out, "Slip of the finger? Take it back and be asked again later ('u' + Enter)")
+	}
+	if ratings {
+		fmt.Fprintln(out, "How hard was it? Rate and continue ('1' easy, '2' medium, '3' hard + Enter)")
+	}
+
+	feedback.Notes = takeCoachNotes()
+	coach.live, coach.arrived = true, nil
+	defer func() {
+		feedback.Notes = append(feedback.Notes, coach.arrived...)
+		coach.live, coach.arrived = false, nil
+	}()
+	for {
+		input, err := Prompt("\nPress Enter to continue (or 'q' + Enter to quit): ")
+		if err != nil {
+			return feedback
+		}
 
-	fmt.Print("\nPress Enter to continue (or 'q' + Enter to quit): ")
+		if name, ok := tagInput(input); ok {
+			if tag := history.NormalizeTag(name); tag == "" {
+				fmt.Fprintln(out, "Give the tag a name, e.g. t confusing")
+			} else {
+				feedback.Tags = appendTag(feedback.Tags, tag)
+				fmt.Fprintf(out, "Tagged: %s\n", tag)
+			}
+			continue
+		}
 
-	reader := bufio.NewReader(os.Stdin)
-	input, err := reader.ReadString('\n')
-	if err != nil {
-		return false
+		if rating := ratingInput(input); ratings && rating > 0 {
+			feedback.Rating = rating
+			fmt.Fprintf(out, "Rated: %s\n", ratingNames[rating-1])
+			return feedback
+		}
+
+		input = strings.ToUpper(input)
+		if lesson != nil && input == "L" {
+			fmt.Fprintln(out)
+			fmt.Fprint(out, lesson.Format())
+			feedback.LessonViewed = true
+			continue
+		}
+		if simulate != nil && input == "E" {
+			fmt.Fprintln(out, "\nSimulating...")
+			fmt.Fprint(out, simulate())
+			continue
+		}
+		if canCorrect && input == "U" {
+			fmt.Fprintln(out, "Taken back: this hand will be asked again in a few questions.")
+			feedback.Corrected = true
+			return feedback
+		}
+		feedback.Quit = len(input) > 0 && input[0] == 'Q'
+		return feedback
+	}
+}
+
+// ratingInput returns the difficulty rating entered at the feedback
+// prompt, or 0 if the input isn't one.
+func ratingInput(input string) int {
+	if len(input) == 1 && input[0] >= '1' && input[0] <= '3' {
+		return int(input[0] - '0')
 	}
+	return 0
+}
 
-	input = strings.TrimSpace(input)
-	return len(input) > 0 && strings.ToUpper(input)[0] == 'Q'
+// tagInput returns the tag name from feedback input of the form "t name".
+func tagInput(input string) (string, bool) {
+	if input == "" || input[0] != 't' && input[0] != 'T' {
+		return "", false
+	}
+	if len(input) == 1 {
+		return "", true
+	}
+	if input[1] != ' ' {
+		return "", false
+	}
+	return input[2:], true
+}
+
+// appendTag adds tag to tags unless it is already there.
+func appendTag(tags []string, tag string) []string {
+	for _, t := range tags {
+		if t == tag {
+			return tags
+		}
+	}
+	return append(tags, tag)
+}
+
+// QuitChoice is the player's answer to the quit confirmation.
+type QuitChoice int
+
+const (
+	// QuitAndRecord ends the session and records it in the history.
+	QuitAndRecord QuitChoice = iota
+	// QuitWithoutRecording ends the session without recording it.
+	QuitWithoutRecording
+	// KeepPracticing goes back to the session.
+	KeepPracticing
+)
+
+// ConfirmQuit asks a player quitting mid-session whether to record the
+// partial session, showing the score so far. Enter, or the end of input,
+// records it.
+func ConfirmQuit(correct, total int) QuitChoice {
+	fmt.Fprintf(out, "\nQuit with %d/%d correct (%.1f%%) so far?\n", correct, total, float64(correct)/float64(total)*100)
+	fmt.Fprintln(out, "  y - quit and record the partial session in your history (default)")
+	fmt.Fprintln(out, "  n - quit without recording it")
+	fmt.Fprintln(out, "  c - keep practicing")
+
+	for {
+		input, err := Prompt("Choice (y/n/c): ")
+		if err != nil {
+			return QuitAndRecord
+		}
+		switch strings.ToLower(input) {
+		case "", "y", "yes":
+			return QuitAndRecord
+		case "n", "no":
+			return QuitWithoutRecording
+		case "c", "continue":
+			return KeepPracticing
+		}
+		fmt.Fprintln(out, "Please enter y, n or c.")
+	}
+}
+
+// ConfirmAbsolute asks a player whose answer breaks an always/never rule
+// whether to keep it. Returns false to answer again; Enter, or the end of
+// input, keeps the answer.
+func ConfirmAbsolute(rule string) bool {
+	fmt.Fprintf(out, "\nAre you sure? This is an absolute rule: %s.\n", rule)
+	for {
+		input, err := Prompt("Keep your answer? (y/n, default y): ")
+		if err != nil {
+			return true
+		}
+		switch strings.ToLower(input) {
+		case "", "y", "yes":
+			return true
+		case "n", "no":
+			return false
+		}
+		fmt.Fprintln(out, "Please enter y or n.")
+	}
+}
+
+// BrowseLessons lists the strategy lessons and displays the chosen ones
+// until the user goes back to the main menu.
+func BrowseLessons() {
+	all := lessons.All()
+
+	for {
+		fmt.Fprintln(out, "\nStrategy Lessons")
+		for i, lesson := range all {
+			fmt.Fprintf(out, "%d. %s\n", i+1, lesson.Title)
+		}
+		fmt.Fprintln(out, "0. Back")
+
+		input, err := Prompt(fmt.Sprintf("\nChoice (0-%d): ", len(all)))
+		if err != nil {
+			return
+		}
+
+		choice, err := strconv.Atoi(input)
+		if err != nil || choice < 0 || choice > len(all) {
+			fmt.Fprintf(out, "Invalid choice. Please enter a number 0-%d.\n", len(all))
+			continue
+		}
+		if choice == 0 {
+			return
+		}
+
+		fmt.Fprintln(out)
+		fmt.Fprint(out, all[choice-1].Format())
+		if _, err := Prompt("\nPress Enter to return to the lesson list..."); err != nil {
+			return
+		}
+	}
+}
+
+// DisplayRuleSets shows the active rules and offers the presets, then the
+// rules wizard, to switch to. Returns the 1-based preset number, or one
+// past the last preset for the wizard.
+func DisplayRuleSets(current strategy.RuleSet) (int, bool) {
+	fmt.Fprintf(out, "\nCurrent rules: %s (%s)\n", current.Name, current.Summary())
+	fmt.Fprintln(out, "\nSwitch to:")
+	presets := strategy.Presets()
+	for i, r := range presets {
+		fmt.Fprintf(out, "%d. %s (%s)\n", i+1, r.Name, r.Summary())
+	}
+	fmt.Fprintf(out, "%d. Describe your table (rules wizard)\n", len(presets)+1)
+	fmt.Fprintln(out, "0. Keep the current rules")
+
+	input, err := Prompt(fmt.Sprintf("\nChoice (0-%d): ", len(presets)+1))
+	if err != nil {
+		return 0, false
+	}
+	choice, err := strconv.Atoi(input)
+	if err != nil || choice < 1 || choice > len(presets)+1 {
+		return 0, false
+	}
+	return choice, true
 }
 
 // DisplayDealerGroups displays dealer groups menu and gets user choice.
 func DisplayDealerGroups() (int, bool) {
-	fmt.Println("\nChoose dealer strength group to practice:")
-	fmt.Println("1. Weak cards (4, 5, 6) - 'Bust cards'")
-	fmt.Println("2. Medium cards (2, 3, 7, 8)")
-	fmt.Println("3. Strong cards (9, 10, A)")
-	fmt.Println("0. Cancel")
-	fmt.Print("\nChoice (0-3): ")
-
-	reader := bufio.NewReader(os.Stdin)
-	input, err := reader.ReadString('\n')
+	fmt.Fprintln(out, "\nChoose dealer strength group to practice:")
+	fmt.Fprintln(out, "1. Weak cards (4, 5, 6) - 'Bust cards'")
+	fmt.Fprintln(out, "2. Medium cards (2, 3, 7, 8)")
+	fmt.Fprintln(out, "3. Strong cards (9, 10, A)")
+	fmt.Fprintln(out, "0. Cancel")
+
+	input, err := Prompt("\nChoice (0-3): ")
 	if err != nil {
 		return 0, false
 	}
 
-	choice, err := strconv.Atoi(strings.TrimSpace(input))
+	choice, err := strconv.Atoi(input)
 	if err != nil {
 		return 0, false
 	}
@@ -149,20 +788,18 @@ func DisplayDealerGroups() (int, bool) {
 
 // DisplayHandTypes displays hand types menu and gets user choice.
 func DisplayHandTypes() (int, bool) {
-	fmt.Println("\nChoose hand type to practice:")
-	fmt.Println("1. Hard totals (no ace or ace = 1)")
-	fmt.Println("2. Soft totals (ace = 11)")
-	fmt.Println("3. Pairs")
-	fmt.Println("0. Cancel")
-	fmt.Print("\nChoice (0-3): ")
-
-	reader := bufio.NewReader(os.Stdin)
-	input, err := reader.ReadString('\n')
+	fmt.Fprintln(out, "\nChoose hand type to practice:")
+	fmt.Fprintln(out, "1. Hard totals (no ace or ace = 1)")
+	fmt.Fprintln(out, "2. Soft totals (ace = 11)")
+	fmt.Fprintln(out, "3. Pairs")
+	fmt.Fprintln(out, "0. Cancel")
+
+	input, err := Prompt("\nChoice (0-3): ")
 	if err != nil {
 		return 0, false
 	}
 
-	choice, err := strconv.Atoi(strings.TrimSpace(input))
+	choice, err := strconv.Atoi(input)
 	if err != nil {
 		return 0, false
 	}
@@ -177,3 +814,29 @@ func DisplayHandTypes() (int, bool) {
 
 	return choice, true
 }
+
+// ReadPassphrase prompts for a passphrase. Terminal echo is turned off while
+// typing where the stty command is available.
+func ReadPassphrase(prompt string) (string, error) {
+	fmt.Fprint(out, prompt)
+
+	if stty("-echo") == nil {
+		defer func() {
+			stty("echo")
+			fmt.Fprintln(out)
+		}()
+	}
+
+	input, err := in.ReadString('\n')
+	if err != nil && input == "" {
+		return "", err
+	}
+	return strings.TrimRight(input, "\r\n"), nil
+}
+
+// stty changes terminal settings for standard input.
+func stty(setting string) error {
+	cmd := exec.Command("stty", setting)
+	cmd.Stdin = os.Stdin
+	return cmd.Run()
+}
@@ -0,0 +1,117 @@
+// Package version reports which build of the trainer is running: its
+// semantic version, the commit and date it was built from, and the versions
+// of the data built into it, such as the strategy charts, that decide how
+// answers are judged.
+//
+// Release builds set the version, commit and date with -ldflags:
+//
+//	go build -ldflags "-X blackjack_trainer/internal/version.Version=1.4.0 \
+//	  -X blackjack_trainer/internal/version.Commit=$(git rev-parse --short HEAD) \
+//	  -X blackjack_trainer/internal/version.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Without them the commit and date come from the version control details Go
+// records in the binary, when it was built inside a git checkout.
+package version
+
+import (
+	"blackjack_trainer/internal/history"
+	"blackjack_trainer/internal/strategy"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// Set with -ldflags -X at build time.
+var (
+	// Version is the semantic version of the release, or "dev".
+	Version = "dev"
+	// Commit is the revision the binary was built from.
+	Commit = ""
+	// Date is when the binary was built, in RFC 3339 format. Without
+	// -ldflags it is the time of the commit.
+	Date = ""
+)
+
+// Info describes the running build.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Date      string `json:"date,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+	// Chart is strategy.ChartVersion and History the history schema
+	// version the build writes.
+	Chart   int `json:"chart"`
+	History int `json:"history"`
+}
+
+// Get returns the running build's information.
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		Chart:     strategy.ChartVersion,
+		History:   history.CurrentVersion,
+	}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				if info.Date == "" {
+					info.Date = setting.Value
+				}
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+	if len(info.Commit) > 12 {
+		info.Commit = info.Commit[:12]
+	}
+	return info
+}
+
+// Short returns the version and commit as one word, e.g. "1.4.0+3f9c2a1b7d4e",
+// for recordings and bug reports.
+func (i Info) Short() string {
+	s := i.Version
+	if i.Commit != "" {
+		s += "+" + i.Commit
+	}
+	if i.Modified {
+		s += ".dirty"
+	}
+	return s
+}
+
+// String describes the build over several lines, as printed by -version.
+func (i Info) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "blackjack_trainer %s\n", i.Version)
+	commit := i.Commit
+	if commit == "" {
+		commit = "unknown"
+	}
+	if i.Modified {
+		commit += " (modified)"
+	}
+	fmt.Fprintf(&b, "  commit:  %s\n", commit)
+	date := i.Date
+	if date == "" {
+		date = "unknown"
+	}
+	fmt.Fprintf(&b, "  date:    %s\n", date)
+	fmt.Fprintf(&b, "  go:      %s for %s\n", i.GoVersion, i.Platform)
+	fmt.Fprintf(&b, "  chart:   version %d (%s)\n", i.Chart, strings.Join(strategy.PresetKeys(), ", "))
+	fmt.Fprintf(&b, "  history: schema version %d\n", i.History)
+	return b.String()
+}
@@ -0,0 +1,47 @@
+package version
+
+import (
+	"blackjack_trainer/internal/history"
+	"blackjack_trainer/internal/strategy"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Test the short form joins the version, commit and modified flag into one word
+func TestShort(t *testing.T) {
+	tests := []struct {
+		info Info
+		want string
+	}{
+		{Info{Version: "dev"}, "dev"},
+		{Info{Version: "1.4.0", Commit: "3f9c2a1b7d4e"}, "1.4.0+3f9c2a1b7d4e"},
+		{Info{Version: "dev", Commit: "3f9c2a1b7d4e", Modified: true}, "dev+3f9c2a1b7d4e.dirty"},
+	}
+	for _, tt := range tests {
+		if got := tt.info.Short(); got != tt.want {
+			t.Errorf("Short() of %+v = %q, want %q", tt.info, got, tt.want)
+		}
+	}
+}
+
+// Test the build information includes values set with -ldflags and the
+// versions of the built-in data
+func TestGet(t *testing.T) {
+	saved := []string{Version, Commit, Date}
+	defer func() { Version, Commit, Date = saved[0], saved[1], saved[2] }()
+	Version, Commit, Date = "1.4.0", "3f9c2a1b7d4e8a0c", "2026-10-16T00:00:00Z"
+
+	info := Get()
+	if info.Version != "1.4.0" || info.Commit != "3f9c2a1b7d4e" || info.Date != "2026-10-16T00:00:00Z" {
+		t.Errorf("Get() = %+v, want the -ldflags values with the commit shortened", info)
+	}
+	if info.Chart != strategy.ChartVersion || info.History != history.CurrentVersion {
+		t.Errorf("Get() data versions = chart %d, history %d", info.Chart, info.History)
+	}
+	for _, want := range []string{"blackjack_trainer 1.4.0\n", "commit:  3f9c2a1b7d4e", fmt.Sprintf("chart:   version %d", strategy.ChartVersion)} {
+		if !strings.Contains(info.String(), want) {
+			t.Errorf("String() missing %q:\n%s", want, info)
+		}
+	}
+}
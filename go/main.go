@@ -6,137 +6,2959 @@
 // Usage:
 //
 //	blackjack_trainer [flags]
+//	blackjack_trainer selftest
+//	blackjack_trainer lookup HAND vs DEALER
+//	blackjack_trainer stats
+//	blackjack_trainer summary [-days n] [-send]
+//	blackjack_trainer report [-o file] [-sort rating]
+//	blackjack_trainer cheatsheet [-n count] [-markdown] [-o file]
+//	blackjack_trainer dashboard [-watch interval] [-user name] [-data dir]
+//	blackjack_trainer watch LINK
+//	blackjack_trainer sync [-url url]
+//	blackjack_trainer telemetry [preview|send]
+//	blackjack_trainer import [-dry-run] file.csv
+//	blackjack_trainer replay [-list] [-all] [n]
+//	blackjack_trainer chart compare [--rules a] --rules b
+//	blackjack_trainer chart export [-o file]
+//	blackjack_trainer etiquette [-n count]
+//	blackjack_trainer edge [--rules name] [-rsa]
+//	blackjack_trainer simulate [-rounds n] [-workers n] [-seed n] [-all]
+//	blackjack_trainer run-script [-update] file...
+//	blackjack_trainer tutorial
+//	blackjack_trainer daily [-status]
+//	blackjack_trainer duo [-n count] [-stats] [NAME NAME]
+//	blackjack_trainer challenge
+//	blackjack_trainer tags
+//	blackjack_trainer certificates [-print n] [-name name] [-o file]
+//	blackjack_trainer quiz create -name name [-n count | -hands list] [-time limit] [-rules rules] [-o file]
+//	blackjack_trainer quiz code file
+//	blackjack_trainer quiz [-name student] [-o file] CODE|file
+//	blackjack_trainer aggregate file|directory...
+//	blackjack_trainer rules [-reset]
+//	blackjack_trainer calendar [-o file] [-start date] [-at time] [-minutes n] [plan]
+//	blackjack_trainer serve [-addr host:port] [-data dir] [-open-registration] [-rate-limit n] [-add-user name] [-link name=provider:id]
+//	blackjack_trainer help [topic]
+//	blackjack_trainer man [-o file]
 //
 // Flags:
 //
-//	-session string    Session type: random, dealer, hand, absolute
-//	-difficulty string Difficulty level: easy, normal, hard (default "normal")
+//	-session string    Session type: random, dealer, hand, absolute, realistic, composition, peek, ladder, exam
+//	-difficulty string Difficulty level: easy, normal, hard, adaptive (default "normal")
+//	-auto-difficulty  Raise or ease the difficulty by your accuracy over the last 10 questions
+//	-confirm-absolutes Ask "Are you sure?" before recording a broken always/never rule at easy difficulty (overrides config)
+//	-rate             Offer to rate each question 1-3 for how hard it felt, after feedback (overrides config)
+//	-detailed-feedback Add the odds behind each play to feedback, such as the chance of busting if you hit (overrides config)
+//	-coach            Show the odds before each answer in the realistic mode; coached answers count as hinted (overrides config)
+//	-speak            Read scenarios and results aloud (uses say or espeak)
+//	-sound string     Audio cues for answers and streaks: bell, files, off (overrides config)
+//	-large-print      Show hands in large ASCII-art characters with high-contrast labels (overrides config)
+//	-overlay dest     Render each scenario as a PNG to a file (name.png) or serve it at host:port, for streaming
+//	-spectate addr    Let a coach watch your sessions live and send you notes, from a link served at host:port
+//	-keys string      Key scheme: letters, numbers, vim (overrides config)
+//	-config string    Path to config file (default in user config directory)
+//	-duration value   End sessions after a time budget (e.g. 10m) instead of a question count
+//	-goal int         End sessions once this percent of answers are right, e.g. 90 (overrides config)
+//	-goal-questions int Fewest answers a -goal is judged over (default 20, overrides config)
+//	-share            Print a shareable summary card after each session
+//	-event-log file   Append a JSON record of every question to file (off by default)
+//	-max-repeat int   Most consecutive questions with the same correct action (default 3, 0 for no limit)
+//	-skips int        Questions that may be skipped per session with n (default 3, 0 for none)
+//	-repeat-misses    Ask missed hands again 3-5 questions later and near the end
+//	-rules string     Table rules: standard, vegas-strip, atlantic-city, european, single-deck-downtown
+//	-game string      Blackjack variant: classic, free-bet (default "classic")
+//	-chart file       Practice with a chart file written by "chart export"
+//	-live-prep        Show the table hand signal for the correct action (overrides config)
+//	-record file      Record the session's seed, rules and input to file (with -session)
+//	-replay file      Play back a session recorded with -record
+//	-secure-rng       Draw questions from a cryptographically secure generator, so they can't be predicted
+//	-tag string       Drill the hands you tagged with this name at the feedback prompt
+//	-focus spec       Drill only the cells a constraint spec allows (e.g. "action=double dealer=2-6"), a built-in drill (doubles, splits, ten-ace), or a drill named in the config
+//	-review int       Percent of random-session questions that review cells unpracticed for weeks (default 0)
+//	-plan file        Follow a multi-day practice plan file or built-in plan (bootcamp), shown on the menu ("off" to stop)
+//	-verbose          Log diagnostic details to standard error (same as -log-level debug)
+//	-log-level string Log level: debug, info, warn, error (default warn, info for serve)
+//	-version          Print the version, commit, build date and chart version
+//	-json             Print JSON from selftest, lookup, stats, summary, replay -list, chart, edge, simulate, tags, certificates, cheatsheet and aggregate
 //	-help             Show help message
 package main
 
 import (
+	"blackjack_trainer/internal/challenge"
+	"blackjack_trainer/internal/cheatsheet"
+	"blackjack_trainer/internal/classroom"
+	"blackjack_trainer/internal/config"
+	"blackjack_trainer/internal/csvimport"
+	"blackjack_trainer/internal/daily"
+	"blackjack_trainer/internal/dashboard"
+	"blackjack_trainer/internal/duo"
+	"blackjack_trainer/internal/etiquette"
+	"blackjack_trainer/internal/eventlog"
+	"blackjack_trainer/internal/exam"
+	"blackjack_trainer/internal/hand"
+	"blackjack_trainer/internal/help"
+	"blackjack_trainer/internal/history"
+	"blackjack_trainer/internal/htmlreport"
+	"blackjack_trainer/internal/overlay"
+	"blackjack_trainer/internal/plan"
+	"blackjack_trainer/internal/remotesync"
+	"blackjack_trainer/internal/replay"
+	"blackjack_trainer/internal/rng"
+	"blackjack_trainer/internal/rulequiz"
+	"blackjack_trainer/internal/rulewizard"
+	"blackjack_trainer/internal/script"
+	"blackjack_trainer/internal/server"
+	"blackjack_trainer/internal/simulate"
+	"blackjack_trainer/internal/sound"
+	"blackjack_trainer/internal/spectate"
+	"blackjack_trainer/internal/speech"
 	"blackjack_trainer/internal/stats"
+	"blackjack_trainer/internal/strategy"
+	"blackjack_trainer/internal/summary"
+	"blackjack_trainer/internal/telemetry"
 	"blackjack_trainer/internal/trainer"
+	"blackjack_trainer/internal/tutorial"
 	"blackjack_trainer/internal/ui"
+	"blackjack_trainer/internal/version"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"math/rand"
+	"net"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
 func main() {
 	// Define command line flags
-	sessionType := flag.String("session", "", "Session type: random, dealer, hand, absolute")
-	difficulty := flag.String("difficulty", "normal", "Difficulty level: easy, normal, hard")
+	sessionType := flag.String("session", "", "Session type: random, dealer, hand, absolute, realistic, composition, peek, ladder, exam")
+	difficulty := flag.String("difficulty", "normal", "Difficulty level: easy, normal, hard, adaptive")
+	autoDifficulty := flag.Bool("auto-difficulty", false, "Raise or ease the difficulty by your accuracy over the last 10 questions")
+	confirmAbsolutes := flag.Bool("confirm-absolutes", false, "Ask \"Are you sure?\" before recording a broken always/never rule at easy difficulty (overrides config)")
+	rate := flag.Bool("rate", false, "Offer to rate each question 1-3 for how hard it felt, after feedback (overrides config)")
+	detailedFeedback := flag.Bool("detailed-feedback", false, "Add the odds behind each play to feedback, such as the chance of busting if you hit (overrides config)")
+	coach := flag.Bool("coach", false, "Show the odds before each answer in the realistic mode; coached answers count as hinted (overrides config)")
+	speak := flag.Bool("speak", false, "Read scenarios and results aloud (uses say or espeak)")
+	soundCues := flag.String("sound", "", "Audio cues for answers and streaks: bell, files, off (overrides config)")
+	largePrint := flag.Bool("large-print", false, "Show hands in large ASCII-art characters with high-contrast labels (overrides config)")
+	overlayDest := flag.String("overlay", "", "Render each scenario as a PNG to a file (name.png) or serve it at host:port, for streaming")
+	spectateAddr := flag.String("spectate", "", "Let a coach watch your sessions live and send you notes, from a link served at host:port")
+	keyScheme := flag.String("keys", "", "Key scheme: letters, numbers, vim (overrides config)")
+	configPath := flag.String("config", "", "Path to config file (default in user config directory)")
+	duration := flag.Duration("duration", 0, "End sessions after a time budget (e.g. 10m) instead of a question count")
+	goal := flag.Int("goal", 0, "End sessions once this percent of answers are right, e.g. 90 (overrides config)")
+	goalQuestions := flag.Int("goal-questions", 0, "Fewest answers a -goal is judged over (default 20, overrides config)")
+	share := flag.Bool("share", false, "Print a shareable summary card after each session")
+	eventLogPath := flag.String("event-log", "", "Append a JSON record of every question to this file (off by default)")
+	maxRepeat := flag.Int("max-repeat", trainer.DefaultMaxRepeat, "Most consecutive questions with the same correct action (0 for no limit)")
+	repeatMisses := flag.Bool("repeat-misses", false, "Ask missed hands again 3-5 questions later and near the end")
+	skips := flag.Int("skips", trainer.DefaultSkips, "Questions that may be skipped per session with n (0 for none)")
+	rulesName := flag.String("rules", strategy.Standard.Key, "Table rules: "+strings.Join(strategy.PresetKeys(), ", "))
+	gameName := flag.String("game", "classic", "Blackjack variant: "+strings.Join(strategy.GameKeys(), ", "))
+	chartPath := flag.String("chart", "", "Practice with a chart file written by \"chart export\"")
+	livePrep := flag.Bool("live-prep", false, "Show the table hand signal for the correct action (overrides config)")
+	recordPath := flag.String("record", "", "Record the session's seed, rules and input to this file (with -session)")
+	replayPath := flag.String("replay", "", "Play back a session recorded with -record")
+	secureRNG := flag.Bool("secure-rng", false, "Draw questions from a cryptographically secure generator, so they can't be predicted")
+	tag := flag.String("tag", "", "Drill the hands you tagged with this name at the feedback prompt")
+	focus := flag.String("focus", "", "Drill only the cells a constraint spec allows (e.g. \"action=double dealer=2-6\"), a built-in drill (doubles, splits, ten-ace), or a drill named in the config")
+	planPath := flag.String("plan", "", "Follow a multi-day practice plan file or built-in plan (bootcamp), shown on the menu (\"off\" to stop)")
+	review := flag.Int("review", 0, "Percent of random-session questions that review cells unpracticed for weeks, e.g. 10 (default none)")
+	verbose := flag.Bool("verbose", false, "Log diagnostic details to standard error (same as -log-level debug)")
+	logLevel := flag.String("log-level", "", "Log level: debug, info, warn, error (default warn, info for serve)")
+	asJSON := flag.Bool("json", false, "Print JSON from non-interactive commands (selftest, lookup, stats, summary, replay -list, chart, edge, simulate, tags, certificates, cheatsheet, aggregate)")
+	showVersion := flag.Bool("version", false, "Print the version, commit, build date and chart version")
 	showHelp := flag.Bool("help", false, "Show help message")
 
 	flag.Parse()
 
+	if err := setupLogging(*logLevel, *verbose, flag.Arg(0) == "serve"); err != nil {
+		fmt.Printf("Invalid log level: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Show help if requested
 	if *showHelp {
 		showUsage()
 		return
 	}
+	if *showVersion {
+		if *asJSON {
+			os.Exit(printJSON(version.Get()))
+		}
+		fmt.Print(version.Get())
+		return
+	}
+
+	if *replayPath != "" {
+		os.Exit(runReplayRecording(*replayPath))
+	}
+
+	rules, err := strategy.LookupRules(*rulesName)
+	if err != nil {
+		fmt.Printf("Invalid rules: %v\n", err)
+		os.Exit(1)
+	}
+	// Rules saved with the rules wizard replace the standard rules unless
+	// the table is chosen with flags, or a recording names its rules
+	explicit := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	if !explicit["rules"] && !explicit["game"] && !explicit["chart"] && !explicit["record"] {
+		if saved, ok := loadSavedRules(); ok {
+			rules = saved
+		} else if flag.NArg() == 0 && *sessionType == "" && *tag == "" && firstRun() {
+			// Ask new players where they play before the tutorial
+			if described, ok := runRulesWizard(true); ok {
+				rules = described
+			}
+		}
+	}
+	game, err := strategy.NewGame(*gameName, rules)
+	if err != nil {
+		fmt.Printf("Invalid game: %v\n", err)
+		os.Exit(1)
+	}
+	if *chartPath != "" {
+		if *rulesName != strategy.Standard.Key || game.Key() != "classic" {
+			fmt.Println("Error: -chart sets its own rules and can't be combined with -rules or -game")
+			os.Exit(1)
+		}
+		loaded, err := loadChart(*chartPath)
+		if err != nil {
+			fmt.Printf("Error loading chart: %v\n", err)
+			os.Exit(1)
+		}
+		game = strategy.NewClassic(loaded)
+	}
+	chart := game.Chart()
+
+	// Run a command if one was given
+	if flag.NArg() > 0 {
+		switch flag.Arg(0) {
+		case "selftest":
+			os.Exit(runSelfTest(chart, *asJSON))
+		case "lookup":
+			os.Exit(runLookup(chart, flag.Args()[1:], *asJSON))
+		case "stats":
+			os.Exit(runStats(*configPath, *asJSON))
+		case "summary":
+			os.Exit(runSummary(*configPath, flag.Args()[1:], *asJSON))
+		case "report":
+			os.Exit(runReport(*configPath, chart, flag.Args()[1:]))
+		case "cheatsheet":
+			os.Exit(runCheatsheet(*configPath, chart, flag.Args()[1:], *asJSON))
+		case "dashboard":
+			os.Exit(runDashboard(*configPath, chart, flag.Args()[1:]))
+		case "watch":
+			os.Exit(runWatch(flag.Args()[1:]))
+		case "sync":
+			os.Exit(runSync(*configPath, flag.Args()[1:]))
+		case "telemetry":
+			os.Exit(runTelemetry(*configPath, flag.Args()[1:]))
+		case "import":
+			os.Exit(runImport(*configPath, chart, flag.Args()[1:]))
+		case "replay":
+			os.Exit(runReplay(*configPath, chart, flag.Args()[1:], *asJSON))
+		case "chart":
+			os.Exit(runChart(chart, flag.Args()[1:], *asJSON))
+		case "etiquette":
+			os.Exit(runEtiquette(flag.Args()[1:]))
+		case "edge":
+			os.Exit(runEdge(chart, flag.Args()[1:], *asJSON))
+		case "simulate":
+			os.Exit(runSimulate(chart, flag.Args()[1:], *asJSON))
+		case "run-script":
+			os.Exit(runScripts(flag.Args()[1:]))
+		case "tutorial":
+			os.Exit(runTutorial(*configPath, *keyScheme, chart))
+		case "daily":
+			os.Exit(runDaily(*configPath, *keyScheme, game, flag.Args()[1:]))
+		case "challenge":
+			os.Exit(runChallenge(*configPath, *keyScheme))
+		case "duo":
+			os.Exit(runDuo(*configPath, *keyScheme, game, flag.Args()[1:]))
+		case "tags":
+			os.Exit(runTags(*configPath, *asJSON))
+		case "certificates":
+			os.Exit(runCertificates(flag.Args()[1:], *asJSON))
+		case "quiz":
+			os.Exit(runQuiz(*configPath, *keyScheme, flag.Args()[1:]))
+		case "aggregate":
+			os.Exit(runAggregate(flag.Args()[1:], *asJSON))
+		case "rules":
+			os.Exit(runRules(flag.Args()[1:]))
+		case "calendar":
+			os.Exit(runCalendar(flag.Args()[1:]))
+		case "serve":
+			os.Exit(runServe(*configPath, chart, flag.Args()[1:]))
+		case "help":
+			os.Exit(runHelp(flag.Args()[1:]))
+		case "man":
+			os.Exit(runMan(flag.Args()[1:]))
+		default:
+			fmt.Printf("Unknown command: %s\n", flag.Arg(0))
+			fmt.Println("Valid commands: " + strings.Join(help.CommandNames(), ", "))
+			os.Exit(1)
+		}
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *keyScheme != "" {
+		cfg.KeyScheme = *keyScheme
+	}
+	keyBindings, err := ui.NewKeyBindings(cfg.KeyScheme, cfg.KeyBindings)
+	if err != nil {
+		fmt.Printf("Invalid key bindings: %v\n", err)
+		os.Exit(1)
+	}
+	ui.SetKeyBindings(keyBindings)
+
+	if *livePrep {
+		cfg.LiveTablePrep = true
+	}
+	ui.SetHandSignals(cfg.LiveTablePrep, chart.Rules().HandHeld())
+	ui.SetLargePrint(*largePrint || cfg.LargePrint)
+	ui.SetRatings(*rate || cfg.RateQuestions)
+
+	if *speak {
+		speaker, err := speech.NewSystemSpeaker()
+		if err != nil {
+			fmt.Printf("Warning: speech disabled: %v\n", err)
+		} else {
+			ui.SetSpeaker(speaker)
+		}
+	}
+
+	if *soundCues != "" {
+		cfg.Sound.Cues = *soundCues
+	}
+	if beeper, streakEvery, err := openBeeper(cfg.Sound); err != nil {
+		fmt.Printf("Warning: audio cues disabled: %v\n", err)
+	} else {
+		ui.SetBeeper(beeper, streakEvery)
+	}
+
+	if *overlayDest != "" {
+		scenarios, err := overlay.Open(*overlayDest)
+		if err != nil {
+			fmt.Printf("Error opening overlay: %v\n", err)
+			os.Exit(1)
+		}
+		ui.SetOverlay(scenarios)
+		fmt.Printf("Scenario overlay: %s\n", scenarios)
+	}
 
+	// Interactive sessions wait on terminal input, so Ctrl-C exits the
+	// program as usual rather than cancelling the context.
+	ctx := context.Background()
 	statistics := stats.New()
+	statistics.SetHistory(openHistory(cfg))
+	level, err := trainer.ParseDifficulty(*difficulty)
+	if err != nil {
+		fmt.Printf("Invalid difficulty: %v\n", err)
+		os.Exit(1)
+	}
+	if *goal != 0 {
+		cfg.Goal = *goal
+	}
+	if *goalQuestions != 0 {
+		cfg.GoalQuestions = *goalQuestions
+	}
+	if cfg.Goal < 0 || cfg.Goal > 100 || cfg.GoalQuestions < 0 {
+		fmt.Printf("Invalid goal: %d%% over %d questions (the goal must be 0-100)\n", cfg.Goal, cfg.GoalQuestions)
+		os.Exit(1)
+	}
+	if *review < 0 || *review > 100 {
+		fmt.Printf("Invalid review percentage: %d (must be 0-100)\n", *review)
+		os.Exit(1)
+	}
+	runOptions := trainer.Options{TimeLimit: *duration, Share: *share, Difficulty: level, AutoDifficulty: *autoDifficulty,
+		MaxRepeat: *maxRepeat, Chart: chart, Skips: *skips, RepeatMisses: *repeatMisses, ReviewRate: float64(*review) / 100,
+		ConfirmAbsolutes: *confirmAbsolutes || cfg.ConfirmAbsolutes, Goal: cfg.Goal, GoalQuestions: cfg.GoalQuestions,
+		DetailedFeedback: *detailedFeedback || cfg.DetailedFeedback, Coach: *coach || cfg.Coach}
+	quizSource := rng.FromClock()
+	if *secureRNG {
+		if *recordPath != "" {
+			fmt.Println("Error: -record replays sessions from a seed, which -secure-rng doesn't use")
+			os.Exit(1)
+		}
+		runOptions.Source, quizSource = rng.Secure(), rng.Secure()
+	}
+	if *eventLogPath != "" {
+		eventLog, err := eventlog.Open(*eventLogPath)
+		if err != nil {
+			fmt.Printf("Error opening event log: %v\n", err)
+			os.Exit(1)
+		}
+		defer eventLog.Close()
+		runOptions.EventLog = eventLog
+	}
+	if *spectateAddr != "" {
+		spectators, err := spectate.Listen(*spectateAddr)
+		if err != nil {
+			fmt.Printf("Error starting spectator server: %v\n", err)
+			os.Exit(1)
+		}
+		runOptions.Spectators = spectators
+		ui.SetCoachNotes(spectators.Notes())
+		fmt.Printf("Spectator link (share only with your coach): %s\n", spectators)
+	}
+
+	practice, err := loadPracticePlan(*planPath)
+	if err != nil {
+		if *planPath != "" {
+			fmt.Printf("Error loading practice plan: %v\n", err)
+			fmt.Println("Built-in plans: " + strings.Join(plan.BuiltinNames(), ", "))
+			os.Exit(1)
+		}
+		fmt.Printf("Warning: practice plan not loaded: %v\n", err)
+	}
+
+	// Drill the cells a constraint spec allows if one was given
+	if *focus != "" {
+		if *sessionType != "" || *recordPath != "" || *tag != "" {
+			fmt.Println("Error: -focus runs its own drill and can't be combined with -session, -record or -tag")
+			os.Exit(1)
+		}
+		session, err := focusSession(*focus, cfg.Drills, chart)
+		if err != nil {
+			fmt.Printf("Invalid focus: %v\n", err)
+			os.Exit(1)
+		}
+		trainer.RunSession(ctx, session, statistics, runOptions)
+		return
+	}
+
+	// Drill the hands carrying a tag if one was given
+	if *tag != "" {
+		if *sessionType != "" || *recordPath != "" {
+			fmt.Println("Error: -tag runs its own drill and can't be combined with -session or -record")
+			os.Exit(1)
+		}
+		name := history.NormalizeTag(*tag)
+		session := trainer.NewTaggedTrainingSession(name, statistics.History().Tagged(name))
+		trainer.RunSession(ctx, session, statistics, runOptions)
+		return
+	}
 
 	// If session type specified via command line, run it directly
 	if *sessionType != "" {
-		session := createSession(*sessionType, *difficulty)
-		if session != nil {
-			trainer.RunSession(session, statistics)
-		} else {
+		session := trainer.NewSession(*sessionType, game)
+		if session == nil {
 			fmt.Printf("Invalid session type: %s\n", *sessionType)
-			fmt.Println("Valid types: random, dealer, hand, absolute")
+			fmt.Println("Valid types: " + strings.Join(trainer.SessionTypes(), ", "))
 			os.Exit(1)
 		}
+		if *recordPath != "" {
+			if *chartPath != "" || *duration != 0 || level == trainer.DifficultyAdaptive {
+				fmt.Println("Error: -record can't reproduce sessions that use -chart, -duration or -difficulty adaptive")
+				os.Exit(1)
+			}
+			// Review questions depend on the history, which replays don't have
+			runOptions.ReviewRate = 0
+			recording, err := startRecording(*recordPath, script.Script{
+				Session:        *sessionType,
+				Seed:           time.Now().UnixNano(),
+				Difficulty:     level,
+				AutoDifficulty: *autoDifficulty,
+				Rules:          *rulesName,
+				Game:           game.Key(),
+				MaxRepeat:      *maxRepeat,
+				Skips:          *skips,
+				RepeatMisses:   *repeatMisses,
+				Goal:           cfg.Goal,
+				GoalQuestions:  cfg.GoalQuestions,
+				Keys:           cfg.KeyScheme,
+				Bindings:       cfg.KeyBindings,
+				Version:        version.Get().Short(),
+				Chart:          strategy.ChartVersion,
+			}, &runOptions)
+			if err != nil {
+				fmt.Printf("Error starting recording: %v\n", err)
+				os.Exit(1)
+			}
+			defer recording.Close()
+		}
+		record := trainer.RunSession(ctx, session, statistics, runOptions)
+		if *sessionType == "exam" && record.Total > 0 {
+			recordExam(record, chart.Rules().Name)
+		}
 		return
+	} else if *recordPath != "" {
+		fmt.Println("Error: -record needs a session type (-session)")
+		os.Exit(1)
+	}
+
+	// Introduce the trainer to new players before the menu
+	if firstRun() {
+		tutorial.Run(chart)
 	}
 
 	// Otherwise, show interactive menu
 	for {
+		if practice != nil {
+			ui.SetPlanStatus(practice.progress.Status(practice.plan, time.Now()))
+		}
 		choice, ok := ui.DisplayMenu()
 		if !ok {
-			fmt.Println("Invalid choice. Please enter a number 1-6.")
+			fmt.Println("Invalid choice. Please enter a number 1-9, D, S or T.")
 			continue
 		}
 
 		switch choice {
+		case ui.PlanChoice:
+			runPlanDay(ctx, practice, game, statistics, runOptions)
+
 		case 1: // Quick Practice (random)
-			session := trainer.NewRandomTrainingSession()
-			trainer.RunSession(session, statistics)
+			session := trainer.NewSession("random", game)
+			trainer.RunSession(ctx, session, statistics, runOptions)
 
 		case 2: // Learn by Dealer Strength
 			session := trainer.NewDealerGroupTrainingSession()
-			trainer.RunSession(session, statistics)
+			trainer.RunSession(ctx, session, statistics, runOptions)
 
 		case 3: // Focus on Hand Types
 			session := trainer.NewHandTypeTrainingSession()
-			trainer.RunSession(session, statistics)
+			trainer.RunSession(ctx, session, statistics, runOptions)
 
 		case 4: // Absolutes Drill
 			session := trainer.NewAbsoluteTrainingSession()
-			trainer.RunSession(session, statistics)
+			trainer.RunSession(ctx, session, statistics, runOptions)
+
+		case ui.DoublesChoice, ui.SplitsChoice, ui.TenAceChoice: // Quick drills
+			name := map[int]string{ui.DoublesChoice: "doubles", ui.SplitsChoice: "splits", ui.TenAceChoice: "ten-ace"}[choice]
+			if session, ok := trainer.NewBuiltinDrill(name, chart); ok {
+				trainer.RunSession(ctx, session, statistics, runOptions)
+			}
 
 		case 5: // View Statistics
-			statistics.DisplayProgress()
+			statistics.DisplayProgress(ui.Output(), ui.Input())
+
+		case 6: // Strategy Lessons
+			ui.BrowseLessons()
+
+		case 7: // Rules Quiz
+			rulequiz.Run(ui.Output(), ui.Input(), chart.Rules(), rand.New(quizSource))
 
-		case 6: // Quit
+		case 8: // Table Rules
+			rules, ok := chooseRules(chart.Rules())
+			if !ok {
+				break
+			}
+			switched, err := strategy.NewGame(game.Key(), rules)
+			if err != nil {
+				fmt.Printf("Can't switch rules: %v\n", err)
+				break
+			}
+			game, chart = switched, switched.Chart()
+			runOptions.Chart = chart
+			ui.SetHandSignals(cfg.LiveTablePrep, chart.Rules().HandHeld())
+			fmt.Printf("Now practicing %s rules. Sessions are recorded with the rules they were played under.\n", rules.Name)
+
+		case 9: // Quit
+			reportTelemetry(cfg, statistics.History())
 			fmt.Println("Thanks for practicing! Good luck at the tables!")
 			return
 
 		default:
-			fmt.Println("Invalid choice. Please enter a number 1-6.")
+			fmt.Println("Invalid choice. Please enter a number 1-9, D, S or T.")
 		}
 	}
 }
 
-// createSession creates a training session based on the session type and difficulty.
-func createSession(sessionType, difficulty string) trainer.TrainingSession {
-	// Note: Difficulty levels could be implemented in the future to modify
-	// question complexity, but for now we create sessions without difficulty
-	_ = difficulty
+// focusSession returns a session drilling the cells a constraint spec
+// allows. The spec may instead name a drill defined in the config, or a
+// built-in drill such as doubles.
+func focusSession(spec string, drills map[string]string, chart *strategy.StrategyChart) (*trainer.ConstrainedSession, error) {
+	if named, ok := drills[spec]; ok {
+		spec = named
+	} else if session, ok := trainer.NewBuiltinDrill(spec, chart); ok {
+		return session, nil
+	}
+	c, err := trainer.ParseConstraints(spec)
+	if err != nil {
+		return nil, err
+	}
+	c.Chart = chart
+	return trainer.NewConstrainedSession(c)
+}
 
-	switch sessionType {
-	case "random":
-		return trainer.NewRandomTrainingSession()
-	case "dealer":
-		return trainer.NewDealerGroupTrainingSession()
-	case "hand":
-		return trainer.NewHandTypeTrainingSession()
-	case "absolute":
-		return trainer.NewAbsoluteTrainingSession()
-	default:
-		return nil
+// setupLogging sends log records at or above the level to standard error.
+// -verbose selects debug. Without either flag the server logs requests at
+// info, and the interactive trainer logs only warnings and errors.
+func setupLogging(name string, verbose, serving bool) error {
+	level := slog.LevelWarn
+	if serving {
+		level = slog.LevelInfo
+	}
+	switch {
+	case verbose:
+		level = slog.LevelDebug
+	case name != "":
+		if err := level.UnmarshalText([]byte(name)); err != nil {
+			return err
+		}
 	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+	return nil
 }
 
-// showUsage displays the usage information.
-func showUsage() {
-	fmt.Println(`Blackjack Basic Strategy Trainer
+// loadConfig loads the config file at path, or from the default location if path is empty.
+func loadConfig(path string) (*config.Config, error) {
+	if path == "" {
+		defaultPath, err := config.DefaultPath()
+		if err != nil {
+			return &config.Config{}, nil
+		}
+		path = defaultPath
+	}
+	slog.Debug("loading config", slog.String("path", path))
+	return config.Load(path)
+}
+
+// firstRun reports whether no practice history has been saved yet, i.e.
+// whether this is the player's first launch.
+func firstRun() bool {
+	dir, err := config.Dir()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(filepath.Join(dir, history.FileName))
+	return errors.Is(err, fs.ErrNotExist)
+}
+
+// loadSavedRules returns the rules saved with the rules wizard, if any.
+func loadSavedRules() (strategy.RuleSet, bool) {
+	dir, err := config.Dir()
+	if err != nil {
+		return strategy.RuleSet{}, false
+	}
+	rules, ok, err := rulewizard.Load(filepath.Join(dir, rulewizard.FileName))
+	if err != nil {
+		fmt.Printf("Warning: saved rules not used: %v\n", err)
+	}
+	return rules, ok
+}
+
+// runRulesWizard asks the player about their table and saves the rules
+// described as their default, after asking unless save is set. Returns the
+// rules, or false if the wizard was stopped.
+func runRulesWizard(save bool) (strategy.RuleSet, bool) {
+	rules, ok := rulewizard.Run(ui.Output(), ui.Input())
+	if !ok {
+		fmt.Println("\nNo rules saved.")
+		return strategy.RuleSet{}, false
+	}
+	saveRules(rules, !save)
+	return rules, true
+}
+
+// chooseRules shows the active rules and lets the player pick a preset or
+// describe their table, offering to keep the choice for later runs.
+func chooseRules(current strategy.RuleSet) (strategy.RuleSet, bool) {
+	choice, ok := ui.DisplayRuleSets(current)
+	if !ok {
+		return strategy.RuleSet{}, false
+	}
+	presets := strategy.Presets()
+	if choice > len(presets) {
+		return runRulesWizard(false)
+	}
+	saveRules(presets[choice-1], true)
+	return presets[choice-1], true
+}
+
+// saveRules saves rules as the player's default, first asking whether to
+// if ask is set.
+func saveRules(rules strategy.RuleSet, ask bool) {
+	if ask {
+		answer, err := ui.Prompt("Use these rules from now on? (y/n): ")
+		if err != nil || !strings.HasPrefix(strings.ToLower(answer), "y") {
+			fmt.Println("Rules not saved.")
+			return
+		}
+	}
+
+	dir, err := config.Dir()
+	if err == nil {
+		err = rulewizard.Save(filepath.Join(dir, rulewizard.FileName), rules)
+	}
+	if err != nil {
+		fmt.Printf("Warning: could not save rules: %v\n", err)
+		return
+	}
+	fmt.Println("Saved as your rules. Change them with \"blackjack_trainer rules\", or use -rules for one run.")
+}
+
+// runRules runs the rules wizard on demand, or with -reset forgets the
+// saved rules. Returns the process exit code.
+func runRules(args []string) int {
+	flags := flag.NewFlagSet("rules", flag.ExitOnError)
+	reset := flags.Bool("reset", false, "Forget the saved rules and go back to the standard rules")
+	flags.Parse(args)
+
+	if *reset {
+		dir, err := config.Dir()
+		if err == nil {
+			err = os.Remove(filepath.Join(dir, rulewizard.FileName))
+		}
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			fmt.Printf("Error removing saved rules: %v\n", err)
+			return 1
+		}
+		fmt.Println("Saved rules removed; practicing with the standard rules.")
+		return 0
+	}
+
+	if saved, ok := loadSavedRules(); ok {
+		fmt.Printf("Your rules: %s (%s)\n", saved.Name, saved.Summary())
+	}
+	if _, ok := runRulesWizard(false); !ok {
+		return 1
+	}
+	return 0
+}
+
+// recordExam saves the result of an exam taken under the named rules and
+// tells the player whether it was passed.
+func recordExam(session history.Session, rules string) {
+	result := exam.Result{
+		Taken:     session.Ended,
+		Rules:     rules,
+		Correct:   session.Correct,
+		Total:     session.Total,
+		Questions: trainer.ExamQuestions,
+	}
+	if result.Passed() {
+		fmt.Printf("\nExam passed with %.1f%%! Print a certificate with: blackjack_trainer certificates\n", result.Score())
+	} else {
+		fmt.Printf("\nExam not passed: answer all %d questions and score %.0f%% or better to pass.\n", result.Questions, exam.PassMark)
+	}
 
-A terminal-based application to help memorize optimal blackjack strategy
-through interactive practice sessions.
+	dir, err := config.Dir()
+	if err != nil {
+		fmt.Printf("Warning: could not save exam result: %v\n", err)
+		return
+	}
+	store, err := exam.Open(filepath.Join(dir, exam.FileName))
+	if err == nil {
+		err = store.Add(result)
+	}
+	if err != nil {
+		fmt.Printf("Warning: could not save exam result: %v\n", err)
+	}
+}
+
+// practicePlan is the practice plan being followed and the progress
+// through it.
+type practicePlan struct {
+	plan     *plan.Plan
+	progress *plan.Progress
+}
+
+// loadPracticePlan starts following the plan file at path, or the built-in
+// plan of that name, if one is given, stops following a plan if path is
+// "off", and otherwise loads the plan already being followed. Returns nil when no plan is followed.
+func loadPracticePlan(path string) (*practicePlan, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return nil, err
+	}
+	progress, err := plan.OpenProgress(filepath.Join(dir, plan.ProgressFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	switch path {
+	case "off":
+		if progress.Plan != "" {
+			fmt.Println("Stopped following the practice plan.")
+		}
+		return nil, progress.Stop()
+	case "":
+		if progress.Plan == "" {
+			return nil, nil
+		}
+		p, err := loadPlanFile(progress.Plan)
+		if err != nil {
+			return nil, err
+		}
+		return &practicePlan{plan: p, progress: progress}, nil
+	}
+
+	key := path
+	if !plan.IsBuiltin(path) {
+		if key, err = filepath.Abs(path); err != nil {
+			return nil, err
+		}
+	}
+	p, err := loadPlanFile(key)
+	if err != nil {
+		return nil, err
+	}
+	if progress.Plan != key {
+		if err := progress.Start(key, time.Now()); err != nil {
+			return nil, err
+		}
+		fmt.Printf("Started practice plan %q: %d days. Choose P on the menu to practice each day.\n", p.Name, len(p.Days))
+	}
+	return &practicePlan{plan: p, progress: progress}, nil
+}
+
+// loadPlanFile reads a plan file, or a built-in plan by name, and checks
+// that it names only known drills.
+func loadPlanFile(path string) (*plan.Plan, error) {
+	load := plan.Load
+	if plan.IsBuiltin(path) {
+		load = plan.Builtin
+	}
+	p, err := load(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.Check(trainer.DrillNames()); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+// runCalendar writes an iCalendar file with a daily event for each day of a
+// practice plan: the days left of the plan being followed, or every day of
+// the plan named.
+func runCalendar(args []string) int {
+	flags := flag.NewFlagSet("calendar", flag.ExitOnError)
+	output := flags.String("o", "practice_plan.ics", "Output file")
+	startDate := flags.String("start", "", "Date of the first session, YYYY-MM-DD (default today, or tomorrow if today's is done)")
+	at := flags.String("at", "19:00", "Time of each day's session, HH:MM")
+	minutes := flags.Int("minutes", 20, "Length of each session in minutes")
+	flags.Parse(args)
+
+	clock, err := time.Parse("15:04", *at)
+	if err != nil {
+		fmt.Printf("Invalid time %q (use HH:MM)\n", *at)
+		return 1
+	}
+	if *minutes < 1 {
+		fmt.Printf("Invalid session length: %d minutes\n", *minutes)
+		return 1
+	}
+
+	now := time.Now()
+	day := history.StartOfDay(now)
+	var p *plan.Plan
+	first := 0
+	if flags.NArg() > 0 {
+		if p, err = loadPlanFile(flags.Arg(0)); err != nil {
+			fmt.Printf("Error loading plan: %v\n", err)
+			return 1
+		}
+	} else {
+		practice, err := loadPracticePlan("")
+		if err != nil {
+			fmt.Printf("Error loading plan: %v\n", err)
+			return 1
+		}
+		if practice == nil {
+			fmt.Println("No practice plan is being followed. Start one with -plan, or name one, e.g.: calendar bootcamp")
+			return 1
+		}
+		if practice.progress.Finished(practice.plan) {
+			fmt.Printf("You have finished %q; there are no sessions left to schedule.\n", practice.plan.Name)
+			return 0
+		}
+		p, first = practice.plan, len(practice.progress.Completed)
+		if !practice.progress.Open(now) {
+			day = day.AddDate(0, 0, 1)
+		}
+	}
+	if *startDate != "" {
+		if day, err = time.ParseInLocation("2006-01-02", *startDate, time.Local); err != nil {
+			fmt.Printf("Invalid date %q (use YYYY-MM-DD)\n", *startDate)
+			return 1
+		}
+	}
+
+	schedule := plan.Schedule{
+		First:  first,
+		Start:  day.Add(time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute),
+		Length: time.Duration(*minutes) * time.Minute,
+	}
+	var buf bytes.Buffer
+	if err := p.WriteCalendar(&buf, schedule, now); err != nil {
+		fmt.Printf("Error writing calendar: %v\n", err)
+		return 1
+	}
+	if err := os.WriteFile(*output, buf.Bytes(), 0o644); err != nil {
+		fmt.Printf("Error writing %s: %v\n", *output, err)
+		return 1
+	}
+	fmt.Printf("Wrote %d day(s) of %q to %s, from %s at %s\n", len(p.Days)-first, p.Name, *output,
+		schedule.Start.Format("Mon Jan 2"), schedule.Start.Format("15:04"))
+	return 0
+}
+
+// runPlanDay runs the drills of the plan's current day not yet passed, in
+// order, stopping at a drill that is quit, unfinished or failed.
+func runPlanDay(ctx context.Context, practice *practicePlan, game strategy.Game, statistics *stats.Statistics, opts trainer.Options) {
+	p, progress := practice.plan, practice.progress
+	if progress.Finished(p) {
+		fmt.Printf("\nYou have finished %q. Start another plan with -plan, or stop with -plan off.\n", p.Name)
+		return
+	}
+	if !progress.Open(time.Now()) {
+		fmt.Printf("\nDay %d of %q opens tomorrow. Good work today!\n", progress.Day(), p.Name)
+		return
+	}
+
+	day := p.Days[progress.Day()-1]
+	opts.Questions = day.Questions
+	for _, drill := range progress.Remaining(p) {
+		fmt.Printf("\n%s\n", progress.Status(p, time.Now()))
+		session := trainer.NewDrill(drill, game)
+		length := session.GetMaxQuestions()
+		if day.Questions > 0 {
+			length = day.Questions
+		}
+		record := trainer.RunSession(ctx, session, statistics, opts)
+		if drill == "exam" && record.Total > 0 {
+			recordExam(record, game.Chart().Rules().Name)
+		}
+		if record.Total == 0 || (opts.TimeLimit == 0 && record.Total < length) {
+			fmt.Println("Finish the drill to count it toward the plan.")
+			return
+		}
+
+		passed, dayDone, err := progress.Record(p, record.Correct, record.Total, time.Now())
+		if err != nil {
+			fmt.Printf("Warning: could not save plan progress: %v\n", err)
+		}
+		switch {
+		case !passed:
+			fmt.Printf("Score %.0f%% or better on this drill to move on. Try it again from the menu.\n", day.Pass)
+			return
+		case dayDone && progress.Finished(p):
+			fmt.Printf("\nCongratulations! You have finished every day of %q.\n", p.Name)
+		case dayDone:
+			fmt.Printf("\nDay %d complete! Day %d opens tomorrow.\n", progress.Day()-1, progress.Day())
+		}
+	}
+}
+
+// runCertificates lists the passed exams, or writes a certificate for one of
+// them. Returns the process exit code.
+func runCertificates(args []string, asJSON bool) int {
+	flags := flag.NewFlagSet("certificates", flag.ExitOnError)
+	number := flags.Int("print", 0, "Write a printable certificate for the numbered exam")
+	name := flags.String("name", "", "Name to print on the certificate (default a blank line)")
+	output := flags.String("o", "", "Output file for the certificate (default standard output)")
+	flags.Parse(args)
+
+	dir, err := config.Dir()
+	if err != nil {
+		fmt.Printf("Error locating exam results: %v\n", err)
+		return 1
+	}
+	store, err := exam.Open(filepath.Join(dir, exam.FileName))
+	if err != nil {
+		fmt.Printf("Error reading exam results: %v\n", err)
+		return 1
+	}
+	passed := store.Passed()
+
+	if *number == 0 && asJSON {
+		return printJSON(struct {
+			Taken  int           `json:"taken"`
+			Passed []exam.Result `json:"passed"`
+		}{len(store.Results), append([]exam.Result{}, passed...)})
+	}
+	if *number == 0 {
+		if len(passed) == 0 {
+			fmt.Printf("No exams passed yet (%d taken). Take one with: blackjack_trainer -session exam\n", len(store.Results))
+			return 0
+		}
+		fmt.Printf("Passed exams (%d of %d taken):\n", len(passed), len(store.Results))
+		for i, r := range passed {
+			fmt.Printf("  %d. %s\n", i+1, r)
+		}
+		fmt.Println("\nPrint a certificate with: blackjack_trainer certificates -print n -name \"Your Name\"")
+		return 0
+	}
+
+	if *number < 1 || *number > len(passed) {
+		fmt.Printf("No passed exam %d (%d passed)\n", *number, len(passed))
+		return 1
+	}
+	w := io.Writer(os.Stdout)
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fmt.Printf("Error creating certificate: %v\n", err)
+			return 1
+		}
+		defer file.Close()
+		w = file
+	}
+	if err := exam.Certificate(w, passed[*number-1], *name); err != nil {
+		fmt.Printf("Error writing certificate: %v\n", err)
+		return 1
+	}
+	if *output != "" {
+		fmt.Printf("Certificate written to %s\n", *output)
+	}
+	return 0
+}
+
+// runTags lists the tags in the session history with the number of hands
+// carrying each. Returns the process exit code.
+func runTags(configPath string, asJSON bool) int {
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return 1
+	}
+	h, _, err := loadHistory(cfg)
+	if err != nil {
+		fmt.Printf("Error reading history: %v\n", err)
+		return 1
+	}
+
+	counts := h.Tags()
+	if asJSON {
+		return printJSON(counts)
+	}
+	if len(counts) == 0 {
+		fmt.Println("No hands tagged yet. Tag a hand after answering it with 't name', e.g. t confusing.")
+		return 0
+	}
+	tags := make([]string, 0, len(counts))
+	for tag := range counts {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		fmt.Printf("%-20s %d hand(s)\n", tag, counts[tag])
+	}
+	fmt.Println("\nDrill a tag with: blackjack_trainer -tag name")
+	return 0
+}
+
+// runTutorial runs the first-launch tutorial on demand, with the configured
+// key bindings. Returns the process exit code.
+func runTutorial(configPath, keyScheme string, chart *strategy.StrategyChart) int {
+	if _, err := loadKeyBindings(configPath, keyScheme); err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	tutorial.Run(chart)
+	return 0
+}
+
+// runDaily asks one question, records it, and prints the day streak as a
+// line for a shell prompt or status bar. With -status it only prints the
+// line. Returns 0 for a correct answer (or, with -status, if today's
+// practice is done) and 1 otherwise.
+func runDaily(configPath, keyScheme string, game strategy.Game, args []string) int {
+	flags := flag.NewFlagSet("daily", flag.ExitOnError)
+	statusOnly := flags.Bool("status", false, "Print the streak line without asking a question")
+	flags.Parse(args)
+
+	cfg, err := loadKeyBindings(configPath, keyScheme)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	h, _, err := loadHistory(cfg)
+	if err != nil {
+		fmt.Printf("Error reading history: %v\n", err)
+		return 1
+	}
+
+	if *statusOnly {
+		fmt.Println(daily.Status(h, time.Now()))
+		if daily.PracticedToday(h, time.Now()) {
+			return 0
+		}
+		return 1
+	}
 
-Usage:
-  blackjack_trainer [flags]
+	record, ok := daily.Ask(trainer.NewSession("random", game), game.Chart())
+	if !ok {
+		return 1
+	}
+	statistics := stats.New()
+	statistics.SetHistory(h)
+	if err := statistics.RecordSession(record); err != nil {
+		fmt.Printf("Warning: could not save history: %v\n", err)
+	}
+	fmt.Println(daily.Status(h, time.Now()))
+	if record.Correct == 0 {
+		return 1
+	}
+	return 0
+}
+
+// runChallenge plays today's daily challenge, under the standard rules so
+// every player's score compares, and prints the card to share. Once today's
+// challenge is played it prints that try's card instead.
+func runChallenge(configPath, keyScheme string) int {
+	cfg, err := loadKeyBindings(configPath, keyScheme)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	h, _, err := loadHistory(cfg)
+	if err != nil {
+		fmt.Printf("Error reading history: %v\n", err)
+		return 1
+	}
 
-Flags:
-  -session string    Session type: random, dealer, hand, absolute
-  -difficulty string Difficulty level: easy, normal, hard (default "normal")
-  -help             Show this help message
+	day := challenge.Day(time.Now())
+	if played, ok := challenge.Find(h, day); ok {
+		fmt.Printf("You've played today's challenge. A new one starts tomorrow.\n\n")
+		fmt.Print(challenge.ShareCard(day, played))
+		return 0
+	}
+
+	statistics := stats.New()
+	statistics.SetHistory(h)
+	record := trainer.RunSession(context.Background(), challenge.NewSession(day), statistics, trainer.Options{
+		FixedLength: true,
+		Chart:       strategy.Default(),
+	})
+	if record.Total == 0 {
+		return 1
+	}
+	fmt.Println("\nShare your score:")
+	fmt.Print(challenge.ShareCard(day, record))
+	return 0
+}
+
+// runDuo plays a cooperative session for two players at one terminal and
+// saves the result, or with -stats prints every player's duo statistics.
+func runDuo(configPath, keyScheme string, game strategy.Game, args []string) int {
+	flags := flag.NewFlagSet("duo", flag.ExitOnError)
+	questions := flags.Int("n", duo.DefaultQuestions, "Number of questions")
+	statsOnly := flags.Bool("stats", false, "Print each player's duo statistics")
+	flags.Parse(args)
+
+	names := [2]string{"Player 1", "Player 2"}
+	switch flags.NArg() {
+	case 0:
+	case 2:
+		names = [2]string{flags.Arg(0), flags.Arg(1)}
+		if names[0] == names[1] {
+			fmt.Println("Error: the two players need different names")
+			return 1
+		}
+	default:
+		fmt.Println("Usage: blackjack_trainer duo [-n count] [-stats] [NAME NAME]")
+		return 1
+	}
+	if *questions < 1 {
+		fmt.Println("Error: -n must be at least 1")
+		return 1
+	}
 
-Session Types:
-  random     Mixed practice with all hand types and dealer cards
-  dealer     Practice by dealer strength groups (weak/medium/strong)
-  hand       Focus on specific hand types (hard/soft/pairs)
-  absolute   Practice absolute rules (always/never scenarios)
+	dir, err := config.Dir()
+	if err != nil {
+		fmt.Printf("Error locating duo results: %v\n", err)
+		return 1
+	}
+	store, err := duo.Open(filepath.Join(dir, duo.FileName))
+	if err != nil {
+		fmt.Printf("Error reading duo results: %v\n", err)
+		return 1
+	}
+	if *statsOnly {
+		duo.WriteStats(os.Stdout, store.Players())
+		return 0
+	}
 
-Examples:
-  blackjack_trainer                           # Interactive mode
-  blackjack_trainer -session random           # Quick practice
-  blackjack_trainer -session dealer           # Dealer groups
-  blackjack_trainer -session hand -difficulty hard
+	if _, err := loadKeyBindings(configPath, keyScheme); err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	result := duo.Play(trainer.NewSession("random", game), game.Chart(), names, *questions)
+	duo.WriteSummary(os.Stdout, result)
+	if result.Total == 0 {
+		return 0
+	}
+	if err := store.Add(result); err != nil {
+		fmt.Printf("Warning: could not save duo results: %v\n", err)
+	}
+	return 0
+}
 
-If no session type is specified, the program will start in interactive mode
-with a menu to choose the practice mode.`)
+// openBeeper returns the beeper for the configured audio cues, or nil if
+// cues are off, and how many correct answers in a row make a milestone.
+func openBeeper(cfg config.SoundConfig) (sound.Beeper, int, error) {
+	streakEvery := cfg.StreakEvery
+	if streakEvery == 0 {
+		streakEvery = sound.DefaultStreakEvery
+	}
+	switch cfg.Cues {
+	case "", "off":
+		return nil, 0, nil
+	case "bell":
+		return sound.NewBell(os.Stdout), streakEvery, nil
+	case "files":
+		files := map[sound.Cue]string{
+			sound.Correct:   cfg.Correct,
+			sound.Incorrect: cfg.Incorrect,
+			sound.Milestone: cfg.Milestone,
+		}
+		if cfg.Player != "" {
+			return sound.NewCommandBeeper(cfg.Player, files), streakEvery, nil
+		}
+		beeper, err := sound.NewSystemBeeper(files)
+		if err != nil {
+			return nil, 0, err
+		}
+		return beeper, streakEvery, nil
+	default:
+		return nil, 0, fmt.Errorf("unknown sound cues %q (want bell, files or off)", cfg.Cues)
+	}
+}
+
+// loadKeyBindings loads the config and sets the ui key bindings from it, or
+// from keyScheme if given, for commands that ask questions.
+func loadKeyBindings(configPath, keyScheme string) (*config.Config, error) {
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("Error loading config: %v", err)
+	}
+	if keyScheme != "" {
+		cfg.KeyScheme = keyScheme
+	}
+	keyBindings, err := ui.NewKeyBindings(cfg.KeyScheme, cfg.KeyBindings)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid key bindings: %v", err)
+	}
+	ui.SetKeyBindings(keyBindings)
+	return cfg, nil
+}
+
+// runQuiz creates a classroom quiz, prints the code for a quiz file, or
+// takes a quiz given as a code or file. Returns the process exit code.
+func runQuiz(configPath, keyScheme string, args []string) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "create":
+			return runQuizCreate(args[1:])
+		case "code":
+			if len(args) != 2 {
+				fmt.Println("Usage: blackjack_trainer quiz code file")
+				return 1
+			}
+			quiz, err := classroom.Load(args[1])
+			if err != nil {
+				fmt.Printf("Error reading quiz: %v\n", err)
+				return 1
+			}
+			return printQuizCode(quiz)
+		}
+	}
+
+	flags := flag.NewFlagSet("quiz", flag.ExitOnError)
+	student := flags.String("name", "", "Your name, as the instructor should see it (asked for if not given)")
+	output := flags.String("o", "", "Result file (default named after the quiz and student)")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		fmt.Println("Usage: blackjack_trainer quiz [-name student] [-o file] CODE|file")
+		return 1
+	}
+
+	quiz, err := classroom.Load(flags.Arg(0))
+	if err != nil {
+		fmt.Printf("Error reading quiz: %v\n", err)
+		return 1
+	}
+	rules, err := strategy.LookupRules(quiz.Rules)
+	if err != nil {
+		fmt.Printf("Invalid quiz rules: %v\n", err)
+		return 1
+	}
+	game, err := strategy.NewGame("classic", rules)
+	if err != nil {
+		fmt.Printf("Invalid quiz rules: %v\n", err)
+		return 1
+	}
+	cfg, err := loadKeyBindings(configPath, keyScheme)
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+
+	if *student == "" {
+		if *student, err = ui.Prompt("Your name: "); err != nil || *student == "" {
+			fmt.Println("A name is needed so the instructor can tell the results apart.")
+			return 1
+		}
+	}
+
+	scenarios := make([]trainer.Scenario, len(quiz.Questions))
+	for i, q := range quiz.Questions {
+		scenarios[i] = trainer.Scenario{Hand: hand.New(q.Cards...), DealerCard: q.DealerCard}
+	}
+	statistics := stats.New()
+	statistics.SetHistory(openHistory(cfg))
+	record := trainer.RunSession(context.Background(), trainer.NewQuizTrainingSession(quiz.Name, scenarios), statistics, trainer.Options{
+		TimeLimit:   time.Duration(quiz.TimeLimit),
+		FixedLength: true,
+		Chart:       game.Chart(),
+	})
+	if record.Total == 0 {
+		fmt.Println("No answers recorded, so no result file was written.")
+		return 1
+	}
+
+	result := classroom.Result{
+		Quiz:      quiz.Name,
+		Student:   *student,
+		Rules:     rules.Key,
+		Taken:     record.Ended,
+		Correct:   record.Correct,
+		Total:     record.Total,
+		Questions: len(quiz.Questions),
+		Attempts:  record.Attempts,
+	}
+	path := *output
+	if path == "" {
+		path = result.FileName()
+	}
+	if err := classroom.WriteResult(path, result); err != nil {
+		fmt.Printf("Error writing result: %v\n", err)
+		return 1
+	}
+	fmt.Printf("\nResult written to %s. Send it to your instructor.\n", path)
+	return 0
+}
+
+// runAggregate reads students' result files, given directly or as
+// directories holding them, and prints the class report. Returns the
+// process exit code.
+func runAggregate(args []string, asJSON bool) int {
+	if len(args) == 0 {
+		fmt.Println("Usage: blackjack_trainer aggregate file|directory...")
+		return 1
+	}
+
+	var results []classroom.Result
+	for _, arg := range args {
+		paths := []string{arg}
+		if info, err := os.Stat(arg); err == nil && info.IsDir() {
+			paths, _ = filepath.Glob(filepath.Join(arg, "*"+classroom.ResultSuffix))
+			if len(paths) == 0 {
+				fmt.Printf("Warning: no result files in %s\n", arg)
+			}
+		}
+		for _, path := range paths {
+			r, err := classroom.ReadResult(path)
+			if err != nil {
+				fmt.Printf("Error reading result: %v\n", err)
+				return 1
+			}
+			results = append(results, r)
+		}
+	}
+	if len(results) == 0 {
+		fmt.Println("No results to aggregate.")
+		return 1
+	}
+
+	report := classroom.Aggregate(results)
+	if asJSON {
+		return printJSON(newClassReportJSON(report))
+	}
+	report.Display(os.Stdout)
+	return 0
+}
+
+// runQuizCreate writes a quiz file, from a list of hands or drawn evenly
+// from the chart, and prints its code. Returns the process exit code.
+func runQuizCreate(args []string) int {
+	flags := flag.NewFlagSet("quiz create", flag.ExitOnError)
+	name := flags.String("name", "", "Quiz name (required)")
+	count := flags.Int("n", 20, "Number of questions drawn from the whole chart")
+	hands := flags.String("hands", "", "Questions to ask instead, separated by semicolons (e.g. \"10,6 vs 10; A,7 vs 9\")")
+	limit := flags.Duration("time", 0, "Time limit for the whole quiz (e.g. 10m; default untimed)")
+	rulesName := flags.String("rules", strategy.Standard.Key, "Table rules: "+strings.Join(strategy.PresetKeys(), ", "))
+	seed := flags.Int64("seed", 0, "Seed for the drawn questions (default from the clock)")
+	output := flags.String("o", "", "Quiz file (default named after the quiz)")
+	flags.Parse(args)
+
+	rules, err := strategy.LookupRules(*rulesName)
+	if err != nil {
+		fmt.Printf("Invalid rules: %v\n", err)
+		return 1
+	}
+	quiz := classroom.Quiz{Name: strings.TrimSpace(*name), Rules: rules.Key, TimeLimit: classroom.Duration(*limit)}
+
+	if *hands != "" {
+		for _, text := range strings.Split(*hands, ";") {
+			if strings.TrimSpace(text) == "" {
+				continue
+			}
+			q, err := classroom.ParseQuestion(text)
+			if err != nil {
+				fmt.Printf("Invalid hand: %v\n", err)
+				return 1
+			}
+			quiz.Questions = append(quiz.Questions, q)
+		}
+	} else {
+		if *seed == 0 {
+			*seed = time.Now().UnixNano()
+		}
+		draw := trainer.NewExamTrainingSession()
+		draw.Seed(*seed)
+		for i := 0; i < *count; i++ {
+			scenario := draw.GenerateScenario()
+			quiz.Questions = append(quiz.Questions, classroom.Question{Cards: scenario.Hand.Cards, DealerCard: scenario.DealerCard})
+		}
+	}
+	if err := quiz.Validate(); err != nil {
+		fmt.Printf("Invalid quiz: %v\n", err)
+		return 1
+	}
+
+	path := *output
+	if path == "" {
+		path = strings.TrimSuffix(classroom.Result{Quiz: quiz.Name}.FileName(), classroom.ResultSuffix) + ".quiz"
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		fmt.Printf("Error creating quiz: %v\n", err)
+		return 1
+	}
+	defer file.Close()
+	if err := quiz.Write(file); err != nil {
+		fmt.Printf("Error writing quiz: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Quiz %q (%d questions) written to %s\n", quiz.Name, len(quiz.Questions), path)
+	return printQuizCode(quiz)
+}
+
+// printQuizCode prints the code students use to take a quiz. Returns the
+// process exit code.
+func printQuizCode(quiz classroom.Quiz) int {
+	code, err := quiz.Code()
+	if err != nil {
+		fmt.Printf("Error encoding quiz: %v\n", err)
+		return 1
+	}
+	fmt.Printf("\nStudents take the quiz with:\n  blackjack_trainer quiz %s\n", code)
+	fmt.Println("or with the quiz file in place of the code.")
+	return 0
+}
+
+// openHistory opens the persistent session history. If it cannot be read,
+// a warning is printed and an in-memory history is used so the existing
+// file is not overwritten.
+func openHistory(cfg *config.Config) *history.History {
+	if _, err := config.Dir(); err != nil {
+		return history.New()
+	}
+
+	h, _, err := loadHistory(cfg)
+	if err != nil {
+		fmt.Printf("Warning: session history unavailable: %v\n", err)
+		return history.New()
+	}
+	return h
+}
+
+// loadHistory opens the persistent session history, returning an error if
+// it cannot be located or read. Commands that rewrite the history use this
+// rather than openHistory so a damaged file is reported, not replaced.
+// The passphrase comes from the environment, or is prompted for when the
+// config enables encryption or the file is already encrypted; it is
+// returned so callers can encrypt other copies of the history.
+func loadHistory(cfg *config.Config) (*history.History, string, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return nil, "", err
+	}
+	path := filepath.Join(dir, history.FileName)
+
+	passphrase := os.Getenv(config.PassphraseEnv)
+	if passphrase == "" && cfg.EncryptHistory {
+		if passphrase, err = ui.ReadPassphrase("History passphrase: "); err != nil {
+			return nil, "", err
+		}
+	}
+
+	h, err := history.OpenEncrypted(path, passphrase)
+	if errors.Is(err, history.ErrEncrypted) {
+		if passphrase, err = ui.ReadPassphrase("History is encrypted. Passphrase: "); err != nil {
+			return nil, "", err
+		}
+		h, err = history.OpenEncrypted(path, passphrase)
+	}
+	if err != nil {
+		slog.Debug("opening history failed", slog.String("path", path), slog.Any("error", err))
+		return nil, "", err
+	}
+	slog.Debug("opened history", slog.String("path", path),
+		slog.Int("sessions", len(h.Sessions)), slog.Bool("encrypted", h.Encrypted()))
+	return h, passphrase, nil
+}
+
+// loadChart reads a chart file written by "chart export".
+func loadChart(path string) (*strategy.StrategyChart, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return strategy.Load(file)
+}
+
+// runSelfTest validates the strategy chart, checks its invariants, and
+// prints a report. Returns the process exit code.
+func runSelfTest(chart *strategy.StrategyChart, asJSON bool) int {
+	report := strategy.Validate(chart)
+	problems := append(report.Problems, strategy.CheckInvariants(chart)...)
+
+	if asJSON {
+		result := struct {
+			Rules        rulesJSON      `json:"rules"`
+			CellsChecked map[string]int `json:"cells_checked"`
+			Passed       bool           `json:"passed"`
+			Problems     []string       `json:"problems"`
+		}{Rules: newRulesJSON(chart.Rules()), CellsChecked: make(map[string]int), Passed: len(problems) == 0, Problems: []string{}}
+		for handType, n := range report.CellsChecked {
+			result.CellsChecked[handType.String()] = n
+		}
+		for _, problem := range problems {
+			result.Problems = append(result.Problems, problem.String())
+		}
+		if code := printJSON(result); code != 0 || result.Passed {
+			return code
+		}
+		return 1
+	}
+
+	fmt.Printf("Strategy chart self-test (%s rules)\n", chart.Rules().Name)
+	for _, handType := range []strategy.HandType{strategy.HandTypeHard, strategy.HandTypeSoft, strategy.HandTypePair} {
+		fmt.Printf("  %-5s %d cells checked\n", handType.String()+":", report.CellsChecked[handType])
+	}
+
+	if len(problems) == 0 {
+		fmt.Printf("\nPASS: all %d cells valid, invariants hold\n", report.TotalCells())
+		return 0
+	}
+
+	fmt.Printf("\nFAIL: %d problem(s) found\n", len(problems))
+	for _, problem := range problems {
+		fmt.Printf("  %s\n", problem)
+	}
+	return 1
+}
+
+// runLookup prints the chart's play for a hand written like "10,6 vs 10".
+// Returns the process exit code.
+func runLookup(chart *strategy.StrategyChart, args []string, asJSON bool) int {
+	if len(args) == 0 {
+		fmt.Println("Usage: blackjack_trainer lookup HAND vs DEALER (e.g. lookup 10,6 vs 10)")
+		return 1
+	}
+	question, err := classroom.ParseQuestion(strings.Join(args, " "))
+	if err != nil {
+		fmt.Printf("Invalid hand: %v\n", err)
+		return 1
+	}
+
+	playerHand := hand.New(question.Cards...)
+	handType, total := strategy.Classify(playerHand)
+	result := lookupResult{
+		cellJSON:    newCellJSON(handType, total, question.DealerCard),
+		Hand:        question.Cards,
+		Rules:       chart.Rules().Name,
+		Action:      strategy.ActionToString(chart.GetCorrectActionForHand(playerHand, question.DealerCard)),
+		Explanation: chart.GetExplanationForHand(playerHand, question.DealerCard),
+	}
+	if asJSON {
+		return printJSON(result)
+	}
+	fmt.Printf("%s (%s rules): %s\n", result.Cell, result.Rules, result.Action)
+	if result.Explanation != "" {
+		fmt.Printf("  %s\n", result.Explanation)
+	}
+	return 0
+}
+
+// runStats prints lifetime statistics from the session history. Returns the
+// process exit code.
+func runStats(configPath string, asJSON bool) int {
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return 1
+	}
+	h, _, err := loadHistory(cfg)
+	if err != nil {
+		fmt.Printf("Error reading history: %v\n", err)
+		return 1
+	}
+
+	now := time.Now()
+	accuracy, questions := h.Accuracy()
+	byCategory, byDealerStrength := stats.Tally(h.Attempts())
+	byAction := stats.TallyActions(h.Attempts())
+	byHandSize := stats.TallyHandSizes(h.Attempts())
+	mastery := stats.ComputeMastery(h, now)
+	result := struct {
+		Sessions           int                  `json:"sessions"`
+		Questions          int                  `json:"questions"`
+		Accuracy           float64              `json:"accuracy"`
+		PracticeSeconds    int64                `json:"practice_seconds"`
+		DayStreak          int                  `json:"day_streak"`
+		CellsMastered      int                  `json:"cells_mastered"`
+		ByHandType         map[string]scoreJSON `json:"by_hand_type"`
+		ByDealerStrength   map[string]scoreJSON `json:"by_dealer_strength"`
+		ByAction           map[string]scoreJSON `json:"by_action"`
+		ByHandSize         map[string]scoreJSON `json:"by_hand_size"`
+		ByRules            map[string]scoreJSON `json:"by_rules"`
+		EVLoss             *evLossJSON          `json:"ev_loss,omitempty"`
+		MistakesBySeverity map[string]int       `json:"mistakes_by_severity"`
+	}{
+		Sessions:           len(h.Sessions),
+		Questions:          questions,
+		Accuracy:           accuracy,
+		PracticeSeconds:    int64(h.TotalDuration().Seconds()),
+		DayStreak:          h.DayStreak(now),
+		CellsMastered:      mastery.Mastered(),
+		ByHandType:         newScoresJSON(byCategory),
+		ByDealerStrength:   newScoresJSON(byDealerStrength),
+		ByAction:           newScoresJSON(byAction),
+		ByHandSize:         newScoresJSON(byHandSize),
+		ByRules:            make(map[string]scoreJSON),
+		MistakesBySeverity: make(map[string]int),
+	}
+	byRules := stats.ByRules(h.Attempts())
+	for _, data := range byRules {
+		result.ByRules[data.Rules] = newScoreJSON(data.CategoryData)
+	}
+	severities := stats.CountSeverities(h.Attempts())
+	for severity, n := range severities {
+		result.MistakesBySeverity[severity.String()] = n
+	}
+	lifetimeLoss := stats.TallyEVLoss(h.Sessions)
+	recentLoss, _ := stats.EVLossTrend(h)
+	if lifetimeLoss.Hands > 0 {
+		result.EVLoss = &evLossJSON{
+			Sessions:       lifetimeLoss.Sessions,
+			LifetimePer100: lifetimeLoss.Rate(),
+			Recent:         recentLoss.Sessions,
+			RecentPer100:   recentLoss.Rate(),
+		}
+	}
+	if asJSON {
+		return printJSON(result)
+	}
+
+	if len(h.Sessions) == 0 {
+		fmt.Println("No sessions recorded yet.")
+		return 0
+	}
+	fmt.Printf("Sessions: %d\n", result.Sessions)
+	fmt.Printf("Questions: %d (%.1f%% correct)\n", questions, accuracy)
+	fmt.Printf("Practice time: %s\n", stats.FormatDuration(h.TotalDuration()))
+	fmt.Printf("Day streak: %d\n", result.DayStreak)
+	if lifetimeLoss.Hands > 0 {
+		fmt.Printf("EV lost to mistakes: %s (last %d session(s): %.2f)\n",
+			lifetimeLoss, recentLoss.Sessions, recentLoss.Rate())
+	}
+	if counts := stats.FormatSeverities(severities); counts != "" {
+		fmt.Printf("Mistakes by cost: %s\n", counts)
+	}
+	for _, section := range []struct {
+		title string
+		keys  []string
+		data  map[string]*stats.CategoryData
+	}{
+		{"By Hand Type:", []string{"hard", "soft", "pair"}, byCategory},
+		{"By Dealer Strength:", []string{"weak", "medium", "strong"}, byDealerStrength},
+		{"By Correct Action:", stats.ActionKeys, byAction},
+		{"By Hand Size:", stats.HandSizeKeys, byHandSize},
+	} {
+		fmt.Println("\n" + section.title)
+		for _, key := range section.keys {
+			if d := section.data[key]; d.Total > 0 {
+				fmt.Printf("  %-18s %d/%d (%.1f%%)\n", strings.Title(key), d.Correct, d.Total, d.Accuracy())
+			}
+		}
+	}
+	fmt.Println("\nBy Rules:")
+	for _, data := range byRules {
+		fmt.Printf("  %-18s %d/%d (%.1f%%)\n", data.Rules, data.Correct, data.Total, data.Accuracy())
+	}
+	fmt.Printf("\n%s\n", mastery.Summary())
+	return 0
+}
+
+// runSummary prints the summary of the last week's practice, or sends it to
+// the webhook and email addresses in the config.
+func runSummary(configPath string, args []string, asJSON bool) int {
+	flags := flag.NewFlagSet("summary", flag.ExitOnError)
+	days := flags.Int("days", 7, "Number of days to summarize")
+	send := flags.Bool("send", false, "Send the summary to the configured webhook and email")
+	flags.Parse(args)
+	if *days < 1 {
+		fmt.Printf("Invalid number of days: %d\n", *days)
+		return 1
+	}
+
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return 1
+	}
+	h, _, err := loadHistory(cfg)
+	if err != nil {
+		fmt.Printf("Error reading history: %v\n", err)
+		return 1
+	}
+	report := summary.Build(h, time.Now(), *days)
+	report.Name = cfg.Summary.Name
+
+	if !*send {
+		if asJSON {
+			return printJSON(report)
+		}
+		fmt.Print(report.Text())
+		return 0
+	}
+
+	if cfg.Summary.WebhookURL == "" && cfg.Summary.Email == "" {
+		fmt.Println("No summary destination configured. Set \"summary\": {\"webhook_url\": ...} or")
+		fmt.Println("{\"email\": ...} in the config file.")
+		return 1
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	status := 0
+	if cfg.Summary.WebhookURL != "" {
+		if err := summary.PostWebhook(ctx, nil, cfg.Summary.WebhookURL, report); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			status = 1
+		} else {
+			fmt.Printf("Posted summary to %s\n", cfg.Summary.WebhookURL)
+		}
+	}
+	if cfg.Summary.Email != "" {
+		command := cfg.Summary.Sendmail
+		if command == "" {
+			command = "sendmail"
+		}
+		if err := summary.Sendmail(ctx, command, cfg.Summary.Email, report); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			status = 1
+		} else {
+			fmt.Printf("Emailed summary to %s\n", cfg.Summary.Email)
+		}
+	}
+	return status
+}
+
+// runReport renders the persisted statistics as a standalone HTML file.
+// Returns the process exit code.
+func runReport(configPath string, chart *strategy.StrategyChart, args []string) int {
+	flags := flag.NewFlagSet("report", flag.ExitOnError)
+	output := flags.String("o", "blackjack_report.html", "Output HTML file")
+	sortBy := flags.String("sort", "chart", "Heatmap row order: chart, or rating (hardest-rated first)")
+	flags.Parse(args)
+	if *sortBy != "chart" && *sortBy != "rating" {
+		fmt.Printf("Invalid sort %q (valid: chart, rating)\n", *sortBy)
+		return 1
+	}
+
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return 1
+	}
+	h := openHistory(cfg)
+
+	file, err := os.Create(*output)
+	if err != nil {
+		fmt.Printf("Error creating report: %v\n", err)
+		return 1
+	}
+	defer file.Close()
+
+	if err := htmlreport.Render(file, h, chart, time.Now(), htmlreport.Options{SortByRating: *sortBy == "rating"}); err != nil {
+		fmt.Printf("Error rendering report: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Report written to %s\n", *output)
+	return 0
+}
+
+// runCheatsheet writes a printable card of the weakest cells under the
+// chart's rules, as text or Markdown. Returns the process exit code.
+func runCheatsheet(configPath string, chart *strategy.StrategyChart, args []string, asJSON bool) int {
+	flags := flag.NewFlagSet("cheatsheet", flag.ExitOnError)
+	count := flags.Int("n", cheatsheet.DefaultCells, "Number of cells to list")
+	markdown := flags.Bool("markdown", false, "Write Markdown instead of plain text")
+	output := flags.String("o", "", "Output file (default standard output)")
+	flags.Parse(args)
+	if *count < 1 {
+		fmt.Println("Error: -n must be at least 1")
+		return 1
+	}
+	if asJSON && *output != "" {
+		fmt.Println("Error: -json writes the cheat sheet to standard output and can't be combined with -o")
+		return 1
+	}
+
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return 1
+	}
+	h, _, err := loadHistory(cfg)
+	if err != nil {
+		fmt.Printf("Error reading history: %v\n", err)
+		return 1
+	}
+
+	sheet := cheatsheet.Build(h, chart, time.Now(), *count)
+	if asJSON {
+		return printJSON(sheet)
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			fmt.Printf("Error creating cheat sheet: %v\n", err)
+			return 1
+		}
+		defer file.Close()
+		w = file
+	}
+	if *markdown {
+		sheet.WriteMarkdown(w)
+	} else {
+		sheet.WriteText(w)
+	}
+	if *output != "" {
+		fmt.Printf("Cheat sheet written to %s\n", *output)
+	}
+	return 0
+}
+
+func runDashboard(configPath string, chart *strategy.StrategyChart, args []string) int {
+	flags := flag.NewFlagSet("dashboard", flag.ExitOnError)
+	watch := flags.Duration("watch", 0, "Redraw at this interval, e.g. 5s, until q is entered (default draw once)")
+	user := flags.String("user", "", "Show a server user's statistics instead of yours")
+	dataDir := flags.String("data", "", "Server data directory for -user (default \"server\" in the config directory)")
+	flags.Parse(args)
+	if *watch < 0 || (*watch > 0 && *watch < time.Second) {
+		fmt.Println("Error: -watch must be at least 1s")
+		return 1
+	}
+	if *dataDir != "" && *user == "" {
+		fmt.Println("Error: -data needs -user")
+		return 1
+	}
+
+	// load reads the history afresh for each redraw, so a watched dashboard
+	// follows sessions as they're saved
+	var load func() (*history.History, error)
+	if *user != "" {
+		if *dataDir == "" {
+			dir, err := config.Dir()
+			if err != nil {
+				fmt.Printf("Error finding config directory: %v\n", err)
+				return 1
+			}
+			*dataDir = filepath.Join(dir, "server")
+		}
+		path := filepath.Join(*dataDir, "history", *user+".json")
+		if _, err := os.Stat(path); err != nil {
+			fmt.Printf("Error: no history for user %q in %s\n", *user, *dataDir)
+			return 1
+		}
+		load = func() (*history.History, error) { return history.Open(path) }
+	} else {
+		cfg, err := loadConfig(configPath)
+		if err != nil {
+			fmt.Printf("Error loading config: %v\n", err)
+			return 1
+		}
+		// Ask for an encrypted history's passphrase once, not on each redraw
+		h, passphrase, err := loadHistory(cfg)
+		if err != nil {
+			fmt.Printf("Error reading history: %v\n", err)
+			return 1
+		}
+		path := h.Path()
+		load = func() (*history.History, error) { return history.OpenEncrypted(path, passphrase) }
+	}
+
+	draw := func() error {
+		h, err := load()
+		if err != nil {
+			return err
+		}
+		d := dashboard.Build(h, chart, time.Now())
+		d.Title = *user
+		opts := dashboard.Options{Styled: ui.Styled()}
+		if *watch > 0 {
+			opts.Footer = fmt.Sprintf("Updated %s, every %s. Enter redraws now, q quits.", d.Generated.Format("15:04:05"), *watch)
+		}
+		d.Render(os.Stdout, opts)
+		return nil
+	}
+	if err := draw(); err != nil {
+		fmt.Printf("Error reading history: %v\n", err)
+		return 1
+	}
+	if *watch == 0 {
+		return 0
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			lines <- strings.TrimSpace(scanner.Text())
+		}
+		close(lines)
+	}()
+	ticker := time.NewTicker(*watch)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return 0
+		case line, ok := <-lines:
+			if !ok || strings.EqualFold(line, "q") {
+				return 0
+			}
+		case <-ticker.C:
+		}
+		// A history caught mid-save is skipped until the next redraw
+		if err := draw(); err != nil {
+			slog.Debug("dashboard redraw failed", slog.Any("error", err))
+		}
+	}
+}
+
+// runSync merges the local session history with the remote copy configured
+// in the config file (or given with -url). Returns the process exit code.
+func runSync(configPath string, args []string) int {
+	flags := flag.NewFlagSet("sync", flag.ExitOnError)
+	url := flags.String("url", "", "Remote history URL (overrides config)")
+	flags.Parse(args)
+
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return 1
+	}
+	if *url != "" {
+		cfg.Sync.URL = *url
+	}
+	if cfg.Sync.URL == "" {
+		fmt.Println("No sync URL configured. Set \"sync\": {\"url\": ...} in the config file or use -url.")
+		return 1
+	}
+
+	local, passphrase, err := loadHistory(cfg)
+	if err != nil {
+		fmt.Printf("Error reading history: %v\n", err)
+		return 1
+	}
+
+	client := remotesync.NewClient(cfg.Sync.URL)
+	client.Passphrase = passphrase
+	client.Username = cfg.Sync.Username
+	client.Password = cfg.Sync.Password
+	client.Token = cfg.Sync.Token
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	result, err := client.Sync(ctx, local)
+	if err != nil {
+		fmt.Printf("Sync failed: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Synced with %s: %d session(s) pulled, %d pushed (%d total)\n",
+		cfg.Sync.URL, result.Pulled, result.Pushed, len(local.Sessions))
+	return 0
+}
+
+// runTelemetry shows whether anonymous telemetry is on and what the next
+// report holds, previews the report as JSON, or sends it now.
+func runTelemetry(configPath string, args []string) int {
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return 1
+	}
+	h, _, err := loadHistory(cfg)
+	if err != nil {
+		fmt.Printf("Error reading history: %v\n", err)
+		return 1
+	}
+	state, err := openTelemetryState()
+	if err != nil {
+		fmt.Printf("Error reading telemetry state: %v\n", err)
+		return 1
+	}
+	report := newTelemetryReport(h, state)
+
+	action := ""
+	if len(args) > 0 {
+		action = args[0]
+	}
+	switch action {
+	case "":
+		if cfg.Telemetry.Enabled && cfg.Telemetry.URL != "" {
+			fmt.Printf("Telemetry is on, reporting to %s at most once a day.\n", cfg.Telemetry.URL)
+		} else {
+			fmt.Println("Telemetry is off. To help tune the difficulty tiers, set")
+			fmt.Println("\"telemetry\": {\"enabled\": true, \"url\": ...} in the config file.")
+		}
+		if !state.LastSent.IsZero() {
+			fmt.Printf("Last report sent %s.\n", state.LastSent.Local().Format("2006-01-02 15:04"))
+		}
+		fmt.Printf("The next report counts %d answer(s) in %d cell(s); see it with \"telemetry preview\".\n",
+			report.Attempts(), len(report.Cells))
+		return 0
+	case "preview":
+		return printJSON(report)
+	case "send":
+		if !cfg.Telemetry.Enabled || cfg.Telemetry.URL == "" {
+			fmt.Println("Telemetry is off. Set \"telemetry\": {\"enabled\": true, \"url\": ...} in the config file first.")
+			return 1
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		if err := sendTelemetry(ctx, cfg, state, report); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Sent %d answer(s) in %d cell(s) to %s\n", report.Attempts(), len(report.Cells), cfg.Telemetry.URL)
+		return 0
+	default:
+		fmt.Printf("Unknown telemetry command: %s (use preview or send)\n", action)
+		return 1
+	}
+}
+
+// reportTelemetry sends the day's report when the player has opted in.
+// Failures are only logged; telemetry never gets in the way of practice.
+func reportTelemetry(cfg *config.Config, h *history.History) {
+	if !cfg.Telemetry.Enabled || cfg.Telemetry.URL == "" {
+		return
+	}
+	state, err := openTelemetryState()
+	if err != nil {
+		slog.Debug("reading telemetry state failed", slog.Any("error", err))
+		return
+	}
+	if !state.Due(time.Now()) {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := sendTelemetry(ctx, cfg, state, newTelemetryReport(h, state)); err != nil {
+		slog.Debug("telemetry failed", slog.Any("error", err))
+	}
+}
+
+// newTelemetryReport counts the sessions finished since the last report.
+func newTelemetryReport(h *history.History, state *telemetry.State) telemetry.Report {
+	report := telemetry.Build(h.Sessions, state.LastSent)
+	report.Version = version.Get().Version
+	report.Chart = strategy.ChartVersion
+	return report
+}
+
+// sendTelemetry sends the report and records when it was sent.
+func sendTelemetry(ctx context.Context, cfg *config.Config, state *telemetry.State, report telemetry.Report) error {
+	if err := telemetry.Send(ctx, nil, cfg.Telemetry.URL, report); err != nil {
+		return err
+	}
+	state.LastSent = time.Now()
+	return state.Save()
+}
+
+// openTelemetryState loads when telemetry was last sent.
+func openTelemetryState() (*telemetry.State, error) {
+	dir, err := config.Dir()
+	if err != nil {
+		return nil, err
+	}
+	return telemetry.OpenState(filepath.Join(dir, telemetry.StateFileName))
+}
+
+// runImport merges practice history exported from another trainer as CSV
+// into the local history. Returns the process exit code.
+func runImport(configPath string, chart *strategy.StrategyChart, args []string) int {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "Show what would be imported without saving")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		fmt.Println("Usage: blackjack_trainer import [-dry-run] file.csv")
+		return 1
+	}
+
+	file, err := os.Open(flags.Arg(0))
+	if err != nil {
+		fmt.Printf("Error opening import file: %v\n", err)
+		return 1
+	}
+	defer file.Close()
+
+	// Rows without timestamps are dated by the file, not the import, so
+	// importing the same file again adds nothing
+	info, err := file.Stat()
+	if err != nil {
+		fmt.Printf("Error opening import file: %v\n", err)
+		return 1
+	}
+	sessions, err := csvimport.Read(file, chart, info.ModTime())
+	if err != nil {
+		fmt.Printf("Error importing %s: %v\n", flags.Arg(0), err)
+		return 1
+	}
+
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return 1
+	}
+	local, _, err := loadHistory(cfg)
+	if err != nil {
+		fmt.Printf("Error reading history: %v\n", err)
+		return 1
+	}
+
+	imported := history.New()
+	questions := 0
+	for _, session := range sessions {
+		imported.Add(session)
+		if !local.Contains(session) {
+			questions += session.Total
+		}
+	}
+	added := local.Merge(imported)
+
+	if *dryRun {
+		fmt.Printf("Would import %d question(s) in %d session(s); %d session(s) already present\n",
+			questions, added, len(sessions)-added)
+		return 0
+	}
+	if added > 0 {
+		if err := local.Save(); err != nil {
+			fmt.Printf("Error saving history: %v\n", err)
+			return 1
+		}
+	}
+
+	fmt.Printf("Imported %d question(s) in %d session(s); %d session(s) already present\n",
+		questions, added, len(sessions)-added)
+	return 0
+}
+
+// runReplay plays back a recorded session question by question.
+// Returns the process exit code.
+func runReplay(configPath string, chart *strategy.StrategyChart, args []string, asJSON bool) int {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	list := flags.Bool("list", false, "List recorded sessions with their numbers")
+	all := flags.Bool("all", false, "Show every question without pausing")
+	flags.Parse(args)
+
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return 1
+	}
+	h, _, err := loadHistory(cfg)
+	if err != nil {
+		fmt.Printf("Error reading history: %v\n", err)
+		return 1
+	}
+
+	if *list && asJSON {
+		sessions := []sessionJSON{}
+		for i, session := range h.Sessions {
+			sessions = append(sessions, sessionJSON{
+				Number:  i + 1,
+				Mode:    session.Mode,
+				Rules:   session.Rules,
+				Started: session.Started,
+				Seconds: int64(session.Duration().Seconds()),
+				Correct: session.Correct,
+				Total:   session.Total,
+			})
+		}
+		return printJSON(sessions)
+	}
+	if *list {
+		replay.List(os.Stdout, h)
+		return 0
+	}
+	if len(h.Sessions) == 0 {
+		fmt.Println("No sessions recorded yet.")
+		return 1
+	}
+
+	// Default to the most recent session
+	number := len(h.Sessions)
+	if flags.NArg() > 0 {
+		number, err = strconv.Atoi(flags.Arg(0))
+		if err != nil || number < 1 || number > len(h.Sessions) {
+			fmt.Printf("Invalid session number: %s (use 1-%d; see replay -list)\n", flags.Arg(0), len(h.Sessions))
+			return 1
+		}
+	}
+
+	replay.Play(os.Stdout, os.Stdin, h.Sessions[number-1], chart, !*all)
+	return 0
+}
+
+// ruleNames collects the values of a repeated -rules flag.
+type ruleNames []string
+
+func (r *ruleNames) String() string {
+	return strings.Join(*r, ",")
+}
+
+func (r *ruleNames) Set(name string) error {
+	*r = append(*r, name)
+	return nil
+}
+
+// runChart runs a chart subcommand. "compare" prints the cells whose action
+// differs between two rule sets; with one --rules, the other is the rule set
+// selected with the global -rules flag. "export" writes the chart as a text
+// file that -chart can load. Returns the process exit code.
+func runChart(chart *strategy.StrategyChart, args []string, asJSON bool) int {
+	const usage = "Usage: blackjack_trainer chart compare [--rules a] --rules b\n       blackjack_trainer chart export [-o file]"
+	if len(args) > 0 && args[0] == "export" {
+		return runChartExport(chart, args[1:], asJSON)
+	}
+	if len(args) == 0 || args[0] != "compare" {
+		fmt.Println(usage)
+		return 1
+	}
+
+	flags := flag.NewFlagSet("chart compare", flag.ExitOnError)
+	var names ruleNames
+	flags.Var(&names, "rules", "Rule set to compare (give twice, or once to compare with -rules)")
+	flags.Parse(args[1:])
+
+	charts := []*strategy.StrategyChart{chart}
+	switch len(names) {
+	case 1:
+	case 2:
+		charts = nil
+	default:
+		fmt.Println(usage)
+		return 1
+	}
+	for _, name := range names {
+		rules, err := strategy.LookupRules(name)
+		if err != nil {
+			fmt.Printf("Invalid rules: %v\n", err)
+			return 1
+		}
+		charts = append(charts, strategy.NewForRules(rules))
+	}
+	from, to := charts[0], charts[1]
+
+	diffs := strategy.Compare(from, to)
+	if asJSON {
+		result := struct {
+			From        rulesJSON        `json:"from"`
+			To          rulesJSON        `json:"to"`
+			Differences []differenceJSON `json:"differences"`
+		}{newRulesJSON(from.Rules()), newRulesJSON(to.Rules()), []differenceJSON{}}
+		for _, d := range diffs {
+			result.Differences = append(result.Differences, differenceJSON{
+				cellJSON:   newCellJSON(d.HandType, d.PlayerTotal, d.DealerCard),
+				From:       strategy.ActionToString(d.From),
+				To:         strategy.ActionToString(d.To),
+				Reason:     d.Reason,
+				FromReason: d.FromReason,
+			})
+		}
+		return printJSON(result)
+	}
+	fmt.Printf("%s -> %s: %d cell(s) differ\n", from.Rules().Name, to.Rules().Name, len(diffs))
+	fmt.Printf("  %s: %s\n", from.Rules().Name, from.Rules().Summary())
+	fmt.Printf("  %s: %s\n", to.Rules().Name, to.Rules().Summary())
+	if len(diffs) == 0 {
+		fmt.Println("\nThe charts are the same; nothing to relearn.")
+		return 0
+	}
+
+	fmt.Println()
+	for _, d := range diffs {
+		fmt.Printf("  %-18s %-6s -> %-6s  %s\n", strategy.CellLabel(d.HandType, d.PlayerTotal, d.DealerCard),
+			strategy.ActionToString(d.From), strategy.ActionToString(d.To), differenceReason(d, from.Rules(), to.Rules()))
+	}
+	return 0
+}
+
+// differenceReason explains a changed cell: the target rules' reason for the
+// new action, then the reason the old rules had for the old one, if any.
+func differenceReason(d strategy.Difference, from, to strategy.RuleSet) string {
+	var parts []string
+	if d.Reason != "" {
+		parts = append(parts, fmt.Sprintf("%s: %s", to.Name, d.Reason))
+	}
+	if d.FromReason != "" && d.FromReason != d.Reason {
+		parts = append(parts, fmt.Sprintf("%s: %s", from.Name, d.FromReason))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// runChartExport writes the chart to a file or standard output. Returns the
+// process exit code.
+func runChartExport(chart *strategy.StrategyChart, args []string, asJSON bool) int {
+	flags := flag.NewFlagSet("chart export", flag.ExitOnError)
+	output := flags.String("o", "", "Output file (default standard output)")
+	flags.Parse(args)
+
+	if asJSON {
+		if *output != "" {
+			fmt.Println("Error: -json writes the chart to standard output and can't be combined with -o")
+			return 1
+		}
+		result := struct {
+			Rules rulesJSON      `json:"rules"`
+			Cells []lookupResult `json:"cells"`
+		}{Rules: newRulesJSON(chart.Rules())}
+		for _, key := range stats.ChartCells() {
+			result.Cells = append(result.Cells, lookupResult{
+				cellJSON: newCellJSON(key.HandType, key.PlayerTotal, key.DealerCard),
+				Action:   strategy.ActionToString(chart.GetCorrectAction(key.HandType, key.PlayerTotal, key.DealerCard)),
+			})
+		}
+		return printJSON(result)
+	}
+
+	if *output == "" {
+		if err := chart.Export(os.Stdout); err != nil {
+			fmt.Printf("Error writing chart: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		fmt.Printf("Error creating chart file: %v\n", err)
+		return 1
+	}
+	if err := chart.Export(file); err != nil {
+		file.Close()
+		fmt.Printf("Error writing chart: %v\n", err)
+		return 1
+	}
+	if err := file.Close(); err != nil {
+		fmt.Printf("Error writing chart: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Chart written to %s\n", *output)
+	return 0
+}
+
+// runEtiquette runs the table etiquette quiz. Returns the process exit code.
+func runWatch(args []string) int {
+	if len(args) != 1 {
+		fmt.Println("Usage: blackjack_trainer watch LINK")
+		fmt.Println("LINK is the spectator link a trainer run with -spectate prints")
+		return 1
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	fmt.Println("Watching; type a note and press Enter to show it to the student. Press Ctrl-C to stop.")
+
+	// Notes are sent as they're typed, while the session streams in
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			note := strings.TrimSpace(scanner.Text())
+			if note == "" {
+				continue
+			}
+			if err := spectate.SendNote(ctx, args[0], note); err != nil {
+				fmt.Printf("Note not sent: %v\n", err)
+			}
+		}
+	}()
+	if err := spectate.Watch(ctx, args[0], os.Stdout); err != nil {
+		fmt.Printf("Error watching session: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func runEtiquette(args []string) int {
+	flags := flag.NewFlagSet("etiquette", flag.ExitOnError)
+	count := flags.Int("n", 0, "Number of questions to ask (default all)")
+	flags.Parse(args)
+
+	etiquette.Run(os.Stdout, os.Stdin, *count, rand.New(rand.NewSource(time.Now().UnixNano())))
+	return 0
+}
+
+// runEdge prints the approximate house edge of the rules selected with -rules,
+// or of the rule set named by --rules, with each rule's share. Returns the
+// process exit code.
+func runEdge(chart *strategy.StrategyChart, args []string, asJSON bool) int {
+	flags := flag.NewFlagSet("edge", flag.ExitOnError)
+	rulesName := flags.String("rules", "", "Rule set to price (default the rules selected with -rules)")
+	resplitAces := flags.Bool("rsa", false, "The table allows resplitting aces")
+	flags.Parse(args)
+
+	rules := chart.Rules()
+	if *rulesName != "" {
+		var err error
+		if rules, err = strategy.LookupRules(*rulesName); err != nil {
+			fmt.Printf("Invalid rules: %v\n", err)
+			return 1
+		}
+	}
+	edge := strategy.HouseEdge(rules, strategy.EdgeOptions{ResplitAces: *resplitAces})
+
+	if asJSON {
+		type effectJSON struct {
+			Rule   string  `json:"rule"`
+			Effect float64 `json:"effect"`
+		}
+		result := struct {
+			Rules     rulesJSON    `json:"rules"`
+			Base      float64      `json:"base"`
+			HouseEdge float64      `json:"house_edge"`
+			Effects   []effectJSON `json:"effects"`
+		}{Rules: newRulesJSON(rules), Base: edge.Base, HouseEdge: edge.Total(), Effects: []effectJSON{}}
+		for _, e := range edge.Effects {
+			result.Effects = append(result.Effects, effectJSON{e.Rule, e.Effect})
+		}
+		return printJSON(result)
+	}
+
+	fmt.Printf("House edge for %s (%s)\n\n", rules.Name, rules.Summary())
+	fmt.Printf("  %-40s %+6.2f%%\n", "1 deck, S17, no DAS, 3:2 (base game)", edge.Base)
+	for _, e := range edge.Effects {
+		fmt.Printf("  %-40s %+6.2f%%\n", e.Rule, e.Effect)
+	}
+	fmt.Printf("  %-40s %6.2f%%\n", "House edge", edge.Total())
+	fmt.Println("\nFor a player who follows basic strategy. Rule effects are approximate and")
+	fmt.Println("don't add up exactly when combined; negative effects favor the player.")
+	return 0
+}
+
+// runSimulate simulates every legal action on every chart cell and lists the
+// cells where another play beats the chart's by more than simulation noise.
+// Returns the process exit code.
+func runSimulate(chart *strategy.StrategyChart, args []string, asJSON bool) int {
+	flags := flag.NewFlagSet("simulate", flag.ExitOnError)
+	rounds := flags.Int("rounds", simulate.DefaultRounds, "Rounds to simulate for each action on each cell")
+	workers := flags.Int("workers", runtime.GOMAXPROCS(0), "Simulations to run in parallel")
+	seed := flags.Int64("seed", 1, "Seed for the random streams (the same seed gives the same results)")
+	all := flags.Bool("all", false, "List every cell, not just those that disagree with the chart")
+	flags.Parse(args)
+
+	if *rounds <= 0 || *workers <= 0 {
+		fmt.Println("Error: -rounds and -workers must be positive")
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if !asJSON {
+		fmt.Printf("Simulating %d rounds per action on every cell of the %s chart with %d worker(s)...\n",
+			*rounds, chart.Rules().Name, *workers)
+	}
+	started := time.Now()
+	cells, err := simulate.CheckChart(ctx, chart, simulate.Options{Rounds: *rounds, Workers: *workers, Seed: *seed})
+	if err != nil {
+		fmt.Printf("Simulation stopped: %v\n", err)
+		return 1
+	}
+
+	if asJSON {
+		result := struct {
+			Rules    rulesJSON           `json:"rules"`
+			Rounds   int                 `json:"rounds"`
+			Seed     int64               `json:"seed"`
+			Disagree int                 `json:"disagree"`
+			Cells    []simulatedCellJSON `json:"cells"`
+		}{Rules: newRulesJSON(chart.Rules()), Rounds: *rounds, Seed: *seed, Cells: []simulatedCellJSON{}}
+		for _, cell := range cells {
+			if cell.Disagrees() {
+				result.Disagree++
+			} else if !*all {
+				continue
+			}
+			chartResult, _ := cell.Result(cell.Chart)
+			best := cell.Best()
+			result.Cells = append(result.Cells, simulatedCellJSON{
+				cellJSON:  newCellJSON(cell.HandType, cell.PlayerTotal, cell.DealerCard),
+				Chart:     strategy.ActionToString(cell.Chart),
+				ChartEV:   chartResult.EV(),
+				Best:      strategy.ActionToString(best.Action),
+				BestEV:    best.EV(),
+				Disagrees: cell.Disagrees(),
+				Loss:      cell.Loss(),
+			})
+		}
+		return printJSON(result)
+	}
+
+	fmt.Println()
+	disagree := 0
+	for _, cell := range cells {
+		if cell.Disagrees() {
+			disagree++
+		} else if !*all {
+			continue
+		}
+		chartResult, _ := cell.Result(cell.Chart)
+		best := cell.Best()
+		fmt.Printf("  %-18s chart %-6s EV %+.3f  best %-6s EV %+.3f",
+			strategy.CellLabel(cell.HandType, cell.PlayerTotal, cell.DealerCard),
+			strategy.ActionToString(cell.Chart), chartResult.EV(),
+			strategy.ActionToString(best.Action), best.EV())
+		if cell.Disagrees() {
+			fmt.Printf("  (chart loses %.3f)", cell.Loss())
+		}
+		fmt.Println()
+	}
+
+	fmt.Printf("\n%d of %d cells have a better play beyond simulation noise (%s).\n",
+		disagree, len(cells), time.Since(started).Round(time.Millisecond))
+	if disagree > 0 {
+		fmt.Println("The simulator uses an infinite deck, so close calls that depend on the")
+		fmt.Println("number of decks can disagree with the chart by a small amount.")
+	}
+	return 0
+}
+
+// startRecording creates a recording of the session described by s at path
+// and routes ui input through it, setting the seed in opts. Every line is
+// written as soon as it is typed; closing the file records a final
+// unterminated line.
+func startRecording(path string, s script.Script, opts *trainer.Options) (io.Closer, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	comment := fmt.Sprintf("Session recorded %s; play back with: blackjack_trainer -replay %s",
+		time.Now().Format("2006-01-02 15:04"), filepath.Base(path))
+	recorder, err := script.NewRecorder(os.Stdin, file, s, comment)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	ui.SetIO(recorder, os.Stdout)
+	opts.Seed = s.Seed
+	return closerFunc(func() error {
+		err := recorder.Close()
+		if cerr := file.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil {
+			fmt.Printf("Session recorded to %s\n", path)
+		}
+		return err
+	}), nil
+}
+
+// closerFunc adapts a function to io.Closer.
+type closerFunc func() error
+
+func (f closerFunc) Close() error { return f() }
+
+// runReplayRecording plays back a session recorded with -record, showing
+// each recorded input where it was typed. Returns the process exit code.
+func runReplayRecording(path string) int {
+	s, err := script.ParseFile(path)
+	if err != nil {
+		fmt.Printf("Error reading recording: %v\n", err)
+		return 1
+	}
+	if s.Chart != 0 && s.Chart != strategy.ChartVersion {
+		fmt.Printf("Warning: %s was recorded with chart version %d (blackjack_trainer %s), but this is chart version %d (%s);\n",
+			path, s.Chart, s.Version, strategy.ChartVersion, version.Get().Short())
+		fmt.Println("answers may be judged differently than when it was recorded.")
+	}
+	if err := script.Run(s, os.Stdout); err != nil {
+		fmt.Printf("Error replaying %s: %v\n", path, err)
+		return 1
+	}
+	return 0
+}
+
+// runScripts plays each session script and compares its transcript with the
+// golden transcript beside it, or with -update rewrites the golden
+// transcripts. Returns the process exit code.
+func runScripts(args []string) int {
+	flags := flag.NewFlagSet("run-script", flag.ExitOnError)
+	update := flags.Bool("update", false, "Write each script's transcript as its golden transcript")
+	flags.Parse(args)
+
+	if flags.NArg() == 0 {
+		fmt.Println("Usage: blackjack_trainer run-script [-update] file...")
+		return 1
+	}
+
+	failed := 0
+	for _, path := range flags.Args() {
+		if *update {
+			if err := script.Update(path); err != nil {
+				fmt.Printf("ERROR %s: %v\n", path, err)
+				failed++
+				continue
+			}
+			fmt.Printf("UPDATED %s\n", path+script.GoldenSuffix)
+			continue
+		}
+		if err := script.Check(path); err != nil {
+			fmt.Printf("FAIL %s: %v\n", path, err)
+			failed++
+			continue
+		}
+		fmt.Printf("PASS %s\n", path)
+	}
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// runServe runs the multi-user HTTP training server, or with -add-user
+// creates an account in its user store. Returns the process exit code.
+func runServe(configPath string, chart *strategy.StrategyChart, args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := flags.String("addr", "localhost:8080", "Address to listen on")
+	dataDir := flags.String("data", "", "Directory for user accounts and histories (default \"server\" in the config directory)")
+	openRegistration := flags.Bool("open-registration", false, "Let anyone create an account through the API")
+	addUser := flags.String("add-user", "", "Create an account with this name, prompting for its password, and exit")
+	rateLimit := flags.Int("rate-limit", -1, "Requests per minute allowed from each client, 0 for unlimited (overrides config)")
+	link := flags.String("link", "", "Link a sign-in identity to an account, as name=provider:id (e.g. alice=github:12345), and exit")
+	flags.Parse(args)
+
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		return 1
+	}
+
+	if *rateLimit >= 0 {
+		cfg.Server.RateLimit = *rateLimit
+	}
+
+	if *dataDir == "" {
+		dir, err := config.Dir()
+		if err != nil {
+			fmt.Printf("Error finding config directory: %v\n", err)
+			return 1
+		}
+		*dataDir = filepath.Join(dir, "server")
+	}
+
+	var webhooks []server.Webhook
+	for _, hook := range cfg.Server.Webhooks {
+		webhooks = append(webhooks, server.Webhook{URL: hook.URL, Events: hook.Events, Secret: hook.Secret})
+	}
+	var providers []server.OAuthProvider
+	for _, p := range cfg.Server.OAuth {
+		providers = append(providers, server.OAuthProvider{
+			Name: p.Name, ClientID: p.ClientID, ClientSecret: p.ClientSecret,
+			Issuer: p.Issuer, AuthURL: p.AuthURL, TokenURL: p.TokenURL, UserInfoURL: p.UserInfoURL,
+			Scopes: p.Scopes, AllowSignup: p.AllowSignup, Domains: p.Domains,
+		})
+	}
+
+	srv, err := server.New(server.Options{
+		DataDir:          *dataDir,
+		OpenRegistration: *openRegistration,
+		APIKeys:          cfg.Server.APIKeys,
+		RateLimit:        server.RateLimit{PerMinute: cfg.Server.RateLimit, Burst: cfg.Server.RateBurst},
+		TrustProxy:       cfg.Server.TrustProxy,
+		Logger:           slog.Default(),
+		Chart:            chart,
+		Webhooks:         webhooks,
+		OAuth:            providers,
+		PublicURL:        cfg.Server.PublicURL,
+	})
+	if err != nil {
+		fmt.Printf("Error starting server: %v\n", err)
+		return 1
+	}
+
+	if *addUser != "" {
+		password, err := ui.ReadPassphrase(fmt.Sprintf("Password for %s: ", *addUser))
+		if err != nil {
+			fmt.Printf("Error reading password: %v\n", err)
+			return 1
+		}
+		if err := srv.Users().Add(*addUser, password); err != nil {
+			fmt.Printf("Error adding user: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Added user %s\n", *addUser)
+		return 0
+	}
+	if *link != "" {
+		name, identity, ok := strings.Cut(*link, "=")
+		if !ok || !strings.Contains(identity, ":") {
+			fmt.Println("Error: -link takes name=provider:id, e.g. alice=github:12345")
+			return 1
+		}
+		if err := srv.Users().Link(name, identity); err != nil {
+			fmt.Printf("Error linking identity: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Linked %s to %s\n", identity, name)
+		return 0
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fmt.Printf("Error starting server: %v\n", err)
+		return 1
+	}
+
+	// Ctrl-C stops the server after saving sessions in progress
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	fmt.Printf("Serving %d user(s) from %s on http://%s\n", len(srv.Users().Names()), *dataDir, listener.Addr())
+	if err := srv.Serve(ctx, listener); err != nil {
+		fmt.Printf("Server error: %v\n", err)
+		return 1
+	}
+	fmt.Println("Server stopped.")
+	return 0
+}
+
+// printJSON writes v to standard output as indented JSON, for -json.
+// Returns the process exit code.
+func printJSON(v interface{}) int {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing JSON: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// rulesJSON describes a rule set in -json output.
+type rulesJSON struct {
+	Key     string `json:"key"`
+	Name    string `json:"name"`
+	Summary string `json:"summary"`
+}
+
+func newRulesJSON(r strategy.RuleSet) rulesJSON {
+	return rulesJSON{Key: r.Key, Name: r.Name, Summary: r.Summary()}
+}
+
+// evLossJSON is the EV given up by wrong answers in stats -json output, over
+// the sessions whose mistakes were priced and over the most recent of them.
+type evLossJSON struct {
+	Sessions       int     `json:"sessions"`
+	LifetimePer100 float64 `json:"lifetime_per_100"`
+	Recent         int     `json:"recent_sessions"`
+	RecentPer100   float64 `json:"recent_per_100"`
+}
+
+// cellJSON identifies a chart cell in -json output.
+type cellJSON struct {
+	Cell        string `json:"cell"`
+	HandType    string `json:"hand_type"`
+	PlayerTotal int    `json:"player_total"`
+	DealerCard  int    `json:"dealer_card"`
+}
+
+func newCellJSON(handType strategy.HandType, playerTotal, dealerCard int) cellJSON {
+	return cellJSON{
+		Cell:        strategy.CellLabel(handType, playerTotal, dealerCard),
+		HandType:    handType.String(),
+		PlayerTotal: playerTotal,
+		DealerCard:  dealerCard,
+	}
+}
+
+// lookupResult is a cell's correct play, from lookup and chart export.
+type lookupResult struct {
+	cellJSON
+	Hand        []int  `json:"hand,omitempty"`
+	Rules       string `json:"rules,omitempty"`
+	Action      string `json:"action"`
+	Explanation string `json:"explanation,omitempty"`
+}
+
+// differenceJSON is a cell that differs between two charts.
+type differenceJSON struct {
+	cellJSON
+	From       string `json:"from"`
+	To         string `json:"to"`
+	Reason     string `json:"reason"`
+	FromReason string `json:"from_reason,omitempty"`
+}
+
+// simulatedCellJSON is a cell's simulated result from simulate.
+type simulatedCellJSON struct {
+	cellJSON
+	Chart     string  `json:"chart"`
+	ChartEV   float64 `json:"chart_ev"`
+	Best      string  `json:"best"`
+	BestEV    float64 `json:"best_ev"`
+	Disagrees bool    `json:"disagrees"`
+	Loss      float64 `json:"loss"`
+}
+
+// sessionJSON is a recorded session listed by replay -list.
+type sessionJSON struct {
+	Number  int       `json:"number"`
+	Mode    string    `json:"mode"`
+	Rules   string    `json:"rules,omitempty"`
+	Started time.Time `json:"started"`
+	Seconds int64     `json:"seconds"`
+	Correct int       `json:"correct"`
+	Total   int       `json:"total"`
+}
+
+// scoreJSON is accuracy on a category of questions.
+type scoreJSON struct {
+	Correct  int     `json:"correct"`
+	Total    int     `json:"total"`
+	Accuracy float64 `json:"accuracy"`
+}
+
+func newScoreJSON(d stats.CategoryData) scoreJSON {
+	return scoreJSON{Correct: d.Correct, Total: d.Total, Accuracy: d.Accuracy()}
+}
+
+// newScoresJSON converts a breakdown, leaving out empty categories.
+func newScoresJSON(data map[string]*stats.CategoryData) map[string]scoreJSON {
+	scores := make(map[string]scoreJSON)
+	for key, d := range data {
+		if d.Total > 0 {
+			scores[key] = newScoreJSON(*d)
+		}
+	}
+	return scores
+}
+
+// classReportJSON is the class report from aggregate.
+type classReportJSON struct {
+	Results          []classroom.Result   `json:"results"`
+	Replaced         int                  `json:"replaced"`
+	ByHandType       map[string]scoreJSON `json:"by_hand_type"`
+	ByDealerStrength map[string]scoreJSON `json:"by_dealer_strength"`
+	WeakCells        []classCellJSON      `json:"weak_cells"`
+}
+
+// classCellJSON is a cell the class misses.
+type classCellJSON struct {
+	cellJSON
+	scoreJSON
+	Students      int    `json:"students"`
+	MissedBy      int    `json:"missed_by"`
+	CorrectAction string `json:"correct_action"`
+}
+
+func newClassReportJSON(report classroom.ClassReport) classReportJSON {
+	result := classReportJSON{
+		Results:          report.Results,
+		Replaced:         report.Replaced,
+		ByHandType:       newScoresJSON(report.ByCategory),
+		ByDealerStrength: newScoresJSON(report.ByDealerStrength),
+		WeakCells:        []classCellJSON{},
+	}
+	for _, cell := range report.Weak() {
+		correct := ""
+		for _, action := range cell.CorrectAction {
+			correct = strategy.ActionToString(action)
+			break
+		}
+		result.WeakCells = append(result.WeakCells, classCellJSON{
+			cellJSON:      newCellJSON(cell.Key.HandType, cell.Key.PlayerTotal, cell.Key.DealerCard),
+			scoreJSON:     newScoreJSON(cell.CategoryData),
+			Students:      cell.Students,
+			MissedBy:      cell.MissedBy,
+			CorrectAction: correct,
+		})
+	}
+	return result
+}
+
+// showUsage displays the usage information.
+func showUsage() {
+	help.WriteUsage(os.Stdout)
+}
+
+// runHelp prints the usage, or the named help topic. Returns the process
+// exit code.
+func runHelp(args []string) int {
+	if len(args) == 0 {
+		showUsage()
+		return 0
+	}
+	if err := help.WriteTopic(os.Stdout, strings.Join(args, " ")); err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	return 0
+}
+
+// runMan writes the man page to a file or standard output. Returns the
+// process exit code.
+func runMan(args []string) int {
+	flags := flag.NewFlagSet("man", flag.ExitOnError)
+	output := flags.String("o", "", "Output file (default standard output)")
+	flags.Parse(args)
+
+	if *output == "" {
+		help.WriteMan(os.Stdout)
+		return 0
+	}
+	var page strings.Builder
+	help.WriteMan(&page)
+	if err := os.WriteFile(*output, []byte(page.String()), 0o644); err != nil {
+		fmt.Printf("Error writing man page: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Man page written to %s (view it with: man -l %s)\n", *output, *output)
+	return 0
 }
@@ -0,0 +1,326 @@
+// Package client is a Go client for the trainer's HTTP server (the serve
+// command), so other programs can start practice sessions, answer their
+// questions and read statistics without writing the HTTP calls themselves.
+//
+// A Client authenticates with whichever credentials are set:
+// - Token: a login token, as returned by Login
+// - APIKey: an API key defined in the server's config
+// - Username and Password: HTTP basic authentication
+//
+// Errors reported by the server are returned as *APIError, which carries the
+// HTTP status and the server's message.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client calls a trainer server.
+type Client struct {
+	// BaseURL is the server's address, e.g. "http://localhost:8080".
+	BaseURL string
+	// Token is sent as a bearer token when set. Login sets it.
+	Token string
+	// APIKey is sent as "X-API-Key" when set.
+	APIKey string
+	// Username and Password enable HTTP basic authentication when set.
+	Username string
+	Password string
+	// HTTPClient performs requests; http.DefaultClient is used when nil.
+	HTTPClient *http.Client
+}
+
+// New creates a client for the server at baseURL with a request timeout.
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// APIError is an error response from the server.
+type APIError struct {
+	// StatusCode is the HTTP status, e.g. 404.
+	StatusCode int
+	// Message is the server's description of the error.
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// Login is the result of logging in.
+type Login struct {
+	Token   string    `json:"token"`
+	Expires time.Time `json:"expires"`
+}
+
+// Play is the correct play for a hand.
+type Play struct {
+	Cards      []int  `json:"cards"`
+	DealerCard int    `json:"dealer_card"`
+	HandType   string `json:"hand_type"`
+	Total      int    `json:"total"`
+	// Action is H, S, D or Y (split), and ActionName the word for it.
+	Action      string `json:"action"`
+	ActionName  string `json:"action_name"`
+	Explanation string `json:"explanation"`
+}
+
+// Question is a session's current question. Cards are 2-11, with 11 for an
+// ace.
+type Question struct {
+	Number     int    `json:"number"`
+	Cards      []int  `json:"cards"`
+	DealerCard int    `json:"dealer_card"`
+	HandType   string `json:"hand_type"`
+	Total      int    `json:"total"`
+}
+
+// Session is a training session's progress. Question is nil once every
+// question has been answered.
+type Session struct {
+	ID           string    `json:"id"`
+	Mode         string    `json:"mode"`
+	Started      time.Time `json:"started"`
+	Correct      int       `json:"correct"`
+	Total        int       `json:"total"`
+	MaxQuestions int       `json:"max_questions"`
+	Question     *Question `json:"question,omitempty"`
+}
+
+// Done reports whether the session has no questions left.
+func (s Session) Done() bool {
+	return s.Question == nil
+}
+
+// Answer is the feedback for an answered question, with the session as it
+// stands after it.
+type Answer struct {
+	Correct       bool     `json:"correct"`
+	Action        string   `json:"action"`
+	CorrectAction string   `json:"correct_action"`
+	Explanation   string   `json:"explanation"`
+	Session       *Session `json:"session"`
+}
+
+// CategoryStats is the accuracy for one hand type.
+type CategoryStats struct {
+	Correct  int     `json:"correct"`
+	Total    int     `json:"total"`
+	Accuracy float64 `json:"accuracy"`
+}
+
+// Stats summarizes a user's practice history. Accuracy is in percent.
+type Stats struct {
+	Username        string                   `json:"username"`
+	Sessions        int                      `json:"sessions"`
+	Questions       int                      `json:"questions"`
+	Accuracy        float64                  `json:"accuracy"`
+	PracticeSeconds int64                    `json:"practice_seconds"`
+	DayStreak       int                      `json:"day_streak"`
+	ByHandType      map[string]CategoryStats `json:"by_hand_type"`
+}
+
+// credentials is the body of register and login requests.
+type credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// Register creates an account, on servers with open registration.
+func (c *Client) Register(ctx context.Context, username, password string) error {
+	return c.do(ctx, http.MethodPost, "/api/register", credentials{username, password}, nil)
+}
+
+// Login exchanges a username and password for a token, which the client
+// sends with later requests.
+func (c *Client) Login(ctx context.Context, username, password string) (Login, error) {
+	var login Login
+	if err := c.do(ctx, http.MethodPost, "/api/login", credentials{username, password}, &login); err != nil {
+		return Login{}, err
+	}
+	c.Token = login.Token
+	return login, nil
+}
+
+// Logout revokes the client's token.
+func (c *Client) Logout(ctx context.Context) error {
+	if err := c.do(ctx, http.MethodPost, "/api/logout", nil, nil); err != nil {
+		return err
+	}
+	c.Token = ""
+	return nil
+}
+
+// Lookup returns the correct play for a hand written like "A,7" against a
+// dealer card like "9".
+func (c *Client) Lookup(ctx context.Context, cards, dealer string) (Play, error) {
+	query := url.Values{"cards": {cards}, "dealer": {dealer}}
+	var play Play
+	err := c.do(ctx, http.MethodGet, "/api/lookup?"+query.Encode(), nil, &play)
+	return play, err
+}
+
+// StartSession starts a session in a mode: "random" (the default when
+// empty), "absolute", "realistic" or "exam".
+func (c *Client) StartSession(ctx context.Context, mode string) (Session, error) {
+	var session Session
+	err := c.do(ctx, http.MethodPost, "/api/sessions", map[string]string{"mode": mode}, &session)
+	return session, err
+}
+
+// StartDrill starts a focused drill of the hands a constraint spec allows,
+// e.g. "action=double dealer=2-6".
+func (c *Client) StartDrill(ctx context.Context, constraints string) (Session, error) {
+	var session Session
+	err := c.do(ctx, http.MethodPost, "/api/sessions", map[string]string{"constraints": constraints}, &session)
+	return session, err
+}
+
+// Sessions returns the sessions in progress, oldest first.
+func (c *Client) Sessions(ctx context.Context) ([]Session, error) {
+	var list struct {
+		Sessions []Session `json:"sessions"`
+	}
+	err := c.do(ctx, http.MethodGet, "/api/sessions", nil, &list)
+	return list.Sessions, err
+}
+
+// Session returns a session's progress and current question.
+func (c *Client) Session(ctx context.Context, id string) (Session, error) {
+	var session Session
+	err := c.do(ctx, http.MethodGet, sessionPath(id), nil, &session)
+	return session, err
+}
+
+// Answer answers a session's current question with an action: H, S, D or
+// P, or a word such as "stand". The session ends and is saved after its
+// last question.
+func (c *Client) Answer(ctx context.Context, id, action string) (Answer, error) {
+	var answer Answer
+	err := c.do(ctx, http.MethodPost, sessionPath(id)+"/answer", map[string]string{"action": action}, &answer)
+	return answer, err
+}
+
+// EndSession ends a session early, saving the questions answered so far.
+func (c *Client) EndSession(ctx context.Context, id string) (Session, error) {
+	var session Session
+	err := c.do(ctx, http.MethodDelete, sessionPath(id), nil, &session)
+	return session, err
+}
+
+// Stats returns the user's lifetime statistics.
+func (c *Client) Stats(ctx context.Context) (Stats, error) {
+	var stats Stats
+	err := c.do(ctx, http.MethodGet, "/api/stats", nil, &stats)
+	return stats, err
+}
+
+// GraphQLError is an error reported for a GraphQL query, with the path of
+// the field that failed, if any.
+type GraphQLError struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+func (e GraphQLError) Error() string {
+	return e.Message
+}
+
+// GraphQL runs a query over the user's statistics and decodes its data
+// into out. If the query reports errors, the first is returned, after
+// decoding whatever data there is.
+func (c *Client) GraphQL(ctx context.Context, query string, variables map[string]interface{}, out interface{}) error {
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []GraphQLError  `json:"errors"`
+	}
+	body := map[string]interface{}{"query": query, "variables": variables}
+	if err := c.do(ctx, http.MethodPost, "/api/graphql", body, &resp); err != nil {
+		return err
+	}
+	if len(resp.Data) > 0 && out != nil {
+		if err := json.Unmarshal(resp.Data, out); err != nil {
+			return fmt.Errorf("reading GraphQL data: %w", err)
+		}
+	}
+	if len(resp.Errors) > 0 {
+		return resp.Errors[0]
+	}
+	return nil
+}
+
+// sessionPath returns the path of a session.
+func sessionPath(id string) string {
+	return "/api/sessions/" + url.PathEscape(id)
+}
+
+// do sends a request with body, if not nil, as JSON and decodes the JSON
+// response into out, if not nil. Error responses are returned as *APIError.
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.Username != "" || c.Password != "" {
+		req.SetBasicAuth(c.Username, c.Password)
+	}
+	if c.APIKey != "" {
+		req.Header.Set("X-API-Key", c.APIKey)
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var e struct {
+			Error string `json:"error"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<16))
+		if json.Unmarshal(data, &e) != nil || e.Error == "" {
+			e.Error = strings.TrimSpace(string(data))
+		}
+		return &APIError{StatusCode: resp.StatusCode, Message: e.Error}
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%s %s: reading response: %w", method, path, err)
+	}
+	return nil
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
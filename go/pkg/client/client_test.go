@@ -0,0 +1,136 @@
+package client
+
+import (
+	"blackjack_trainer/internal/server"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// newTestClient starts a server with opts in a temporary directory and
+// returns a client for it.
+func newTestClient(t *testing.T, opts server.Options) *Client {
+	t.Helper()
+	opts.DataDir = t.TempDir()
+	s, err := server.New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(s.Handler())
+	t.Cleanup(ts.Close)
+	return New(ts.URL + "/")
+}
+
+// Test a session can be played from start to finish and shows in the stats
+func TestSession(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, server.Options{OpenRegistration: true})
+	if err := c.Register(ctx, "alice", "alice-password"); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if _, err := c.Login(ctx, "alice", "alice-password"); err != nil || c.Token == "" {
+		t.Fatalf("Login: %v (token %q)", err, c.Token)
+	}
+
+	play, err := c.Lookup(ctx, "A,7", "9")
+	if err != nil || play.Action != "H" || play.HandType != "soft" {
+		t.Errorf("Lookup = %+v, %v; want a soft hand to hit", play, err)
+	}
+
+	session, err := c.StartSession(ctx, "absolute")
+	if err != nil || session.Question == nil {
+		t.Fatalf("StartSession = %+v, %v", session, err)
+	}
+	if list, err := c.Sessions(ctx); err != nil || len(list) != 1 || list[0].ID != session.ID {
+		t.Errorf("Sessions = %+v, %v", list, err)
+	}
+	for !session.Done() {
+		q := session.Question
+		play, err := c.Lookup(ctx, cardList(q.Cards), cardName(q.DealerCard))
+		if err != nil {
+			t.Fatalf("Lookup question %d: %v", q.Number, err)
+		}
+		answer, err := c.Answer(ctx, session.ID, play.Action)
+		if err != nil || !answer.Correct {
+			t.Fatalf("Answer question %d = %+v, %v", q.Number, answer, err)
+		}
+		session = *answer.Session
+	}
+
+	stats, err := c.Stats(ctx)
+	if err != nil || stats.Sessions != 1 || stats.Accuracy != 100 || stats.Questions != session.MaxQuestions {
+		t.Errorf("Stats = %+v, %v", stats, err)
+	}
+	var data struct {
+		Stats struct {
+			Questions  int
+			ByHandType []struct {
+				Name  string
+				Total int
+			}
+		}
+	}
+	query := `query ($from: String) { stats(from: $from) { questions byHandType { name total } } }`
+	if err := c.GraphQL(ctx, query, map[string]interface{}{"from": "2000-01-01"}, &data); err != nil ||
+		data.Stats.Questions != session.MaxQuestions || len(data.Stats.ByHandType) != 3 {
+		t.Errorf("GraphQL = %+v, %v", data, err)
+	}
+	var gqlErr GraphQLError
+	if err := c.GraphQL(ctx, `{ stats { pages } }`, nil, &data); !errors.As(err, &gqlErr) || len(gqlErr.Path) != 2 {
+		t.Errorf("GraphQL of an unknown field = %v, want a GraphQLError", err)
+	}
+
+	if err := c.Logout(ctx); err != nil || c.Token != "" {
+		t.Errorf("Logout: %v", err)
+	}
+}
+
+// Test drills, early ends and server errors
+func TestErrors(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, server.Options{APIKeys: map[string]string{"secret": "scoreboard"}})
+
+	var apiErr *APIError
+	if err := c.Register(ctx, "bob", "bob-password"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("Register on a closed server = %v, want 403", err)
+	}
+	if _, err := c.Stats(ctx); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized || apiErr.Message == "" {
+		t.Errorf("Stats without credentials = %v, want 401", err)
+	}
+
+	c.APIKey = "secret"
+	drill, err := c.StartDrill(ctx, "action=split")
+	if err != nil || drill.Mode != "constrained" {
+		t.Fatalf("StartDrill = %+v, %v", drill, err)
+	}
+	if _, err := c.StartDrill(ctx, "nonsense"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("StartDrill of a bad spec = %v, want 400", err)
+	}
+	if _, err := c.EndSession(ctx, drill.ID); err != nil {
+		t.Errorf("EndSession: %v", err)
+	}
+	if _, err := c.Session(ctx, drill.ID); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("Session after it ended = %v, want 404", err)
+	}
+}
+
+// cardList writes cards as Lookup takes them, e.g. "A,7".
+func cardList(cards []int) string {
+	names := make([]string, len(cards))
+	for i, card := range cards {
+		names[i] = cardName(card)
+	}
+	return strings.Join(names, ",")
+}
+
+// cardName writes a card value as Lookup takes it.
+func cardName(card int) string {
+	if card == 11 {
+		return "A"
+	}
+	return strconv.Itoa(card)
+}